@@ -0,0 +1,156 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+// Arbitration is the policy that decides which FeedIn or Collect task the
+// driver services first when several tasks use the same ports.
+type Arbitration int
+
+const (
+	// ArbitrationInOrder services the tasks in the order that they are
+	// created. It is the default.
+	ArbitrationInOrder Arbitration = iota
+
+	// ArbitrationRoundRobin services first the task that was created after
+	// the one that made progress first in the last cycle that any task did,
+	// and wraps around to the oldest task.
+	ArbitrationRoundRobin
+
+	// ArbitrationOldestFirst services first the task that has waited the
+	// longest since it last made progress.
+	ArbitrationOldestFirst
+
+	// ArbitrationPriority services the tasks with higher priorities first,
+	// and the tasks with the same priority in order. See SetTaskPriority.
+	ArbitrationPriority
+)
+
+// TaskStats is the arbitration record of a FeedIn or Collect task.
+type TaskStats struct {
+	// Kind is "FeedIn" or "Collect".
+	Kind     string
+	Device   int
+	Priority int
+
+	// Rounds is the number of rounds that the task has fed in or
+	// collected.
+	Rounds int
+
+	// StarvedCycles is the number of cycles in which the task could not
+	// make progress because another task used one of its ports.
+	StarvedCycles uint64
+}
+
+// taskArb is the state that the arbitration keeps for a task. The stamp
+// orders the tasks by when they were created or last made progress.
+type taskArb struct {
+	seq      int
+	priority int
+	stamp    uint64
+	stats    *TaskStats
+}
+
+// newTaskArb registers a task for the arbitration.
+func (d *driverImpl) newTaskArb(kind string, deviceID int) taskArb {
+	stats := &TaskStats{Kind: kind, Device: deviceID, Priority: d.priority}
+	d.taskStats = append(d.taskStats, stats)
+
+	d.arbStamp++
+
+	return taskArb{
+		seq:      len(d.taskStats) - 1,
+		priority: d.priority,
+		stamp:    d.arbStamp,
+		stats:    stats,
+	}
+}
+
+// serviced records that the task has made progress.
+func (d *driverImpl) serviced(a *taskArb) {
+	d.arbStamp++
+	a.stamp = d.arbStamp
+
+	if a.stats != nil {
+		a.stats.Rounds++
+	}
+}
+
+// starved records that another task has used a port of the task.
+func (a *taskArb) starved() {
+	if a.stats != nil {
+		a.stats.StarvedCycles++
+	}
+}
+
+// taskOrder returns the order to service the tasks in, where the round-robin
+// arbitration starts from the task with the sequence number next. A seed of
+// the driver takes precedence over the arbitration.
+func (d *driverImpl) taskOrder(tasks []*taskArb, next int) []int {
+	order := d.serviceOrder(len(tasks))
+	if d.rand != nil {
+		return order
+	}
+
+	var less func(a, b *taskArb) bool
+
+	switch d.arbitration {
+	case ArbitrationRoundRobin:
+		less = func(a, b *taskArb) bool {
+			if (a.seq >= next) != (b.seq >= next) {
+				return a.seq >= next
+			}
+
+			return a.seq < b.seq
+		}
+	case ArbitrationOldestFirst:
+		less = func(a, b *taskArb) bool {
+			return a.stamp < b.stamp
+		}
+	case ArbitrationPriority:
+		less = func(a, b *taskArb) bool { return a.priority > b.priority }
+	default:
+		return order
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(tasks[order[i]], tasks[order[j]])
+	})
+
+	return order
+}
+
+// usesAny returns true if any of the ports is in used.
+func usesAny(ports []sim.Port, used map[sim.Port]bool) bool {
+	for _, p := range ports {
+		if used[p] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func markUsed(ports []sim.Port, used map[sim.Port]bool) {
+	for _, p := range ports {
+		used[p] = true
+	}
+}
+
+func (d *driverImpl) SetTaskPriority(priority int) {
+	defer d.recorder.end(d.recorder.begin("SetTaskPriority", priority))
+
+	d.priority = priority
+}
+
+func (d *driverImpl) GetTaskStats() []TaskStats {
+	stats := make([]TaskStats, 0, len(d.taskStats))
+	for _, s := range d.taskStats {
+		stats = append(stats, *s)
+	}
+
+	return stats
+}
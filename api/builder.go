@@ -1,6 +1,10 @@
 package api
 
-import "github.com/sarchlab/akita/v3/sim"
+import (
+	"math/rand"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
 
 type defaultPortFactory struct {
 }
@@ -11,8 +15,14 @@ func (f defaultPortFactory) make(c sim.Component, name string) sim.Port {
 
 // DriverBuilder creates a new instance of Driver.
 type DriverBuilder struct {
-	engine sim.Engine
-	freq   sim.Freq
+	engine      sim.Engine
+	freq        sim.Freq
+	syncStages  int
+	seed        int64
+	arbitration Arbitration
+	configBW    int
+	dram        *DRAMInterface
+	record      bool
 }
 
 // WithEngine sets the engine.
@@ -27,13 +37,144 @@ func (b DriverBuilder) WithFreq(freq sim.Freq) DriverBuilder {
 	return b
 }
 
+// WithSyncStages sets the number of synchronizer stages on the connections
+// between the driver and the device. A message crossing the connection
+// becomes visible after this number of cycles of the receiver's clock. With
+// 0 stages, which is the default, messages cross without delay.
+func (b DriverBuilder) WithSyncStages(stages int) DriverBuilder {
+	b.syncStages = stages
+	return b
+}
+
+// WithSeed sets the seed that breaks ties between the streams, the FeedIn
+// tasks, and the Collect tasks that are ready in the same cycle. With a
+// non-zero seed, the driver services them in a random order that only
+// depends on the seed. With 0, which is the default, the driver services
+// the streams in the order that they are created, and the tasks in the
+// order of the arbitration.
+func (b DriverBuilder) WithSeed(seed int64) DriverBuilder {
+	b.seed = seed
+	return b
+}
+
+// WithArbitration sets the policy that orders the FeedIn and the Collect
+// tasks that use the same ports. The default is ArbitrationInOrder.
+func (b DriverBuilder) WithArbitration(policy Arbitration) DriverBuilder {
+	b.arbitration = policy
+	return b
+}
+
+// WithConfigBandwidth sets the number of instructions per cycle that the
+// configuration bus loads into the tiles. The bus loads the programs one
+// after another, and a core starts running its program once it is loaded,
+// so that switching kernels takes time. With 0, which is the default,
+// programs load right away.
+func (b DriverBuilder) WithConfigBandwidth(wordsPerCycle int) DriverBuilder {
+	b.configBW = wordsPerCycle
+	return b
+}
+
+// WithDRAMInterface makes the data of the FeedIn and the Collect tasks
+// cross a streaming interface to the DRAM of the host, whose bandwidth,
+// bursts, and latency limit how fast the data moves. By default, the data
+// moves as fast as the ports take it.
+func (b DriverBuilder) WithDRAMInterface(i DRAMInterface) DriverBuilder {
+	b.dram = &i
+	return b
+}
+
+// WithRecording makes the driver record the calls to its API and the data
+// that crosses the boundary of the devices, for SaveRecording.
+func (b DriverBuilder) WithRecording() DriverBuilder {
+	b.record = true
+	return b
+}
+
 // Build create a driver.
 func (b DriverBuilder) Build(name string) Driver {
 	d := &driverImpl{
 		portFactory: defaultPortFactory{},
+		syncStages:  b.syncStages,
+		arbitration: b.arbitration,
+
+		configBandwidth: b.configBW,
+		seed:            b.seed,
+	}
+
+	if b.dram != nil {
+		d.dram = newDRAM(*b.dram)
+	}
+
+	if b.record {
+		d.recorder = &recorder{rec: Recording{Seed: b.seed}}
+	}
+
+	if b.seed != 0 {
+		d.rand = rand.New(rand.NewSource(b.seed))
 	}
 
 	d.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, d)
 
 	return d
 }
+
+// HostBuilder creates a new instance of Host.
+type HostBuilder struct {
+	engine          sim.Engine
+	freq            sim.Freq
+	driver          Driver
+	launchLatency   int
+	transferLatency int
+}
+
+// WithEngine sets the engine.
+func (b HostBuilder) WithEngine(engine sim.Engine) HostBuilder {
+	b.engine = engine
+	return b
+}
+
+// WithFreq sets the frequency of the host.
+func (b HostBuilder) WithFreq(freq sim.Freq) HostBuilder {
+	b.freq = freq
+	return b
+}
+
+// WithDriver sets the driver that the host issues commands to. The driver
+// must be built by the DriverBuilder.
+func (b HostBuilder) WithDriver(driver Driver) HostBuilder {
+	b.driver = driver
+	return b
+}
+
+// WithLaunchLatency sets the number of host cycles that it takes to launch
+// a program on a core.
+func (b HostBuilder) WithLaunchLatency(cycles int) HostBuilder {
+	b.launchLatency = cycles
+	return b
+}
+
+// WithTransferLatency sets the number of host cycles that it takes to start
+// a FeedIn or a Collect.
+func (b HostBuilder) WithTransferLatency(cycles int) HostBuilder {
+	b.transferLatency = cycles
+	return b
+}
+
+// Build creates a host.
+func (b HostBuilder) Build(name string) *Host {
+	driver, ok := b.driver.(*driverImpl)
+	if !ok {
+		panic("the driver of the host must be built by the DriverBuilder")
+	}
+
+	h := &Host{
+		driver:          driver,
+		launchLatency:   b.launchLatency,
+		transferLatency: b.transferLatency,
+	}
+
+	h.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, h)
+	driver.taskFinished = h.TickLater
+
+	return h
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// FeedInTask describes the data to feed into a device. See Driver.FeedIn for
+// the fields.
+type FeedInTask struct {
+	Device    int
+	Data      []uint32
+	Side      cgra.Side
+	PortRange [2]int
+	Stride    int
+}
+
+// CollectTask describes the data to collect from a device. See
+// Driver.Collect for the fields.
+type CollectTask struct {
+	Device    int
+	Data      []uint32
+	Side      cgra.Side
+	PortRange [2]int
+	Stride    int
+}
+
+// ChainCheck checks the data that a chained collect task has collected on
+// the host. It returns the task to feed in next, or nil to feed nothing, and
+// true to collect again.
+type ChainCheck func(data []uint32) (next *FeedInTask, again bool)
+
+// Chain adds the collect task, and calls check with the collected data when
+// the task finishes. The driver feeds in the task that check returns, and
+// collects into the same buffer again if check asks to, so that a kernel can
+// loop on the device while the host checks the results of each iteration.
+// Check must copy the data if it needs the data of earlier iterations.
+// A driver that records cannot chain, as a replay cannot run check.
+func (d *driverImpl) Chain(collect CollectTask, check ChainCheck) {
+	if d.recorder != nil {
+		panic("cannot record Chain, whose check runs on the host")
+	}
+
+	d.CollectFromDevice(collect.Device, collect.Data, collect.Side,
+		collect.PortRange, collect.Stride)
+
+	task := d.collectTasks[len(d.collectTasks)-1]
+	task.finished = func() {
+		next, again := check(collect.Data)
+		if next != nil {
+			d.FeedInToDevice(next.Device, next.Data, next.Side,
+				next.PortRange, next.Stride)
+		}
+
+		if again {
+			d.Chain(collect, check)
+		}
+	}
+}
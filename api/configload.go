@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+)
+
+// ConfigTime is the time that it takes to load the programs of a kernel
+// into the configuration memories of the tiles. The programs that are
+// mapped in the same cycle belong to the same kernel.
+type ConfigTime struct {
+	// Start is the time when the programs are mapped, and End is the time
+	// when the last of them is loaded and starts running.
+	Start, End sim.VTimeInSec
+
+	// Programs is the number of programs of the kernel, and Words is the
+	// number of instructions in them.
+	Programs int
+	Words    int
+}
+
+// configLoad is a program that the configuration bus is loading into a
+// tile.
+type configLoad struct {
+	tile      cgra.Tile
+	program   []string
+	readyTime sim.VTimeInSec
+}
+
+// mapProgramTimed maps the program to the tile once the configuration bus
+// has carried its instructions. Without a configuration bandwidth, the
+// program is mapped right away.
+func (d *driverImpl) mapProgramTimed(tile cgra.Tile, program []string) {
+	now := d.Engine.CurrentTime()
+	words := configWords(program)
+
+	if d.configBandwidth <= 0 {
+		tile.MapProgram(program)
+		d.recordConfig(now, now, words)
+
+		return
+	}
+
+	err := tile.CheckProgram(program)
+	if err != nil {
+		panic(fmt.Sprintf("invalid program:\n%s", err))
+	}
+
+	start := d.Freq.ThisTick(now)
+	if d.configFree > start {
+		start = d.configFree
+	}
+
+	cycles := (words + d.configBandwidth - 1) / d.configBandwidth
+	readyTime := d.Freq.NCyclesLater(cycles, start)
+	d.configFree = readyTime
+
+	d.configLoads = append(d.configLoads, configLoad{
+		tile:      tile,
+		program:   program,
+		readyTime: readyTime,
+	})
+	d.recordConfig(now, readyTime, words)
+
+	d.TickLater(now)
+}
+
+// configWords returns the number of words of configuration memory that a
+// program takes, one per instruction.
+func configWords(program []string) int {
+	words := 0
+
+	for _, line := range program {
+		if core.Opcode(line) != "" {
+			words++
+		}
+	}
+
+	return words
+}
+
+func (d *driverImpl) recordConfig(start, end sim.VTimeInSec, words int) {
+	n := len(d.configTimes)
+	if n == 0 || d.configTimes[n-1].Start != start {
+		d.configTimes = append(d.configTimes, ConfigTime{Start: start})
+		n++
+	}
+
+	t := &d.configTimes[n-1]
+	t.Programs++
+	t.Words += words
+
+	if end > t.End {
+		t.End = end
+	}
+}
+
+// doConfigLoads maps the programs that have been loaded. It makes progress
+// as long as any program is still loading, so that the driver keeps
+// ticking.
+func (d *driverImpl) doConfigLoads(now sim.VTimeInSec) bool {
+	if len(d.configLoads) == 0 {
+		return false
+	}
+
+	for len(d.configLoads) > 0 && d.configLoads[0].readyTime <= now {
+		load := d.configLoads[0]
+		load.tile.MapProgram(load.program)
+		d.configLoads = d.configLoads[1:]
+	}
+
+	return true
+}
+
+// GetConfigTime returns the configuration time of each kernel.
+func (d *driverImpl) GetConfigTime() []ConfigTime {
+	return append([]ConfigTime(nil), d.configTimes...)
+}
@@ -0,0 +1,298 @@
+package api
+
+import "fmt"
+
+// DRAMInterface is the streaming interface, similar to AXI, between the DRAM
+// of the host and the boundary of the devices. The data of the FeedIn tasks
+// is read from the DRAM and the data of the Collect tasks is written to it,
+// each direction over a channel of its own.
+type DRAMInterface struct {
+	// BeatsPerCycle is the number of words that each channel carries per
+	// cycle of the driver. The default is 1.
+	BeatsPerCycle int
+
+	// BurstWords is the number of words of a burst. A task moves its data
+	// in bursts, the last of which can be shorter. The default is 1.
+	BurstWords int
+
+	// Latency is the number of cycles from the end of the transfer of a
+	// burst until the data of a read arrives or a write is acknowledged.
+	Latency int
+
+	// Outstanding is the number of bursts that each channel can have in
+	// flight. If 0, it is not limited.
+	Outstanding int
+}
+
+// Validate returns an error if any parameter is negative.
+func (i DRAMInterface) Validate() error {
+	if i.BeatsPerCycle < 0 || i.BurstWords < 0 || i.Latency < 0 ||
+		i.Outstanding < 0 {
+		return fmt.Errorf("invalid DRAM interface: %d beats per cycle, "+
+			"%d words per burst, latency %d, %d outstanding bursts",
+			i.BeatsPerCycle, i.BurstWords, i.Latency, i.Outstanding)
+	}
+
+	return nil
+}
+
+func (i DRAMInterface) beats() uint64 {
+	if i.BeatsPerCycle <= 0 {
+		return 1
+	}
+
+	return uint64(i.BeatsPerCycle)
+}
+
+func (i DRAMInterface) burst() int {
+	if i.BurstWords <= 0 {
+		return 1
+	}
+
+	return i.BurstWords
+}
+
+// DRAMStats counts the traffic over the DRAM interface.
+type DRAMStats struct {
+	// ReadWords and WriteWords are the words that the FeedIn tasks have
+	// read and the Collect tasks have written.
+	ReadWords, WriteWords uint64
+
+	// ReadBursts and WriteBursts are the bursts that carried them.
+	ReadBursts, WriteBursts uint64
+
+	// ReadBusyCycles and WriteBusyCycles are the cycles in which each
+	// channel transferred data.
+	ReadBusyCycles, WriteBusyCycles uint64
+
+	// ReadStallCycles are the cycles in which a FeedIn task waited for the
+	// data of its next round, and WriteStallCycles are the cycles in which
+	// a Collect task could not drain its next round, as the write channel
+	// was busy.
+	ReadStallCycles, WriteStallCycles uint64
+}
+
+// dramBurst is a burst in flight.
+type dramBurst struct {
+	words int
+	ready uint64
+
+	feedIn  *feedInTask
+	collect *collectTask
+}
+
+// dramChannel is one direction of the DRAM interface. The channel is
+// counted in beats, so that bursts shorter than a cycle share the cycle.
+type dramChannel struct {
+	cfg *DRAMInterface
+
+	// freeBeat is the first beat in which the channel is free, and
+	// busyUntil is the cycle after the last one that has a beat.
+	freeBeat  uint64
+	busyUntil uint64
+	inflight  []dramBurst
+
+	words, bursts, busyCycles *uint64
+}
+
+// canIssue returns true if the channel can start a burst in the cycle.
+func (c *dramChannel) canIssue(cycle uint64) bool {
+	if c.cfg.Outstanding > 0 && len(c.inflight) >= c.cfg.Outstanding {
+		return false
+	}
+
+	return c.freeBeat < (cycle+1)*c.cfg.beats()
+}
+
+// issue starts a burst in the cycle, after the bursts that the channel is
+// still transferring.
+func (c *dramChannel) issue(cycle uint64, b dramBurst) {
+	beats := c.cfg.beats()
+
+	start := cycle * beats
+	if c.freeBeat > start {
+		start = c.freeBeat
+	}
+
+	end := start + uint64(b.words)
+	first, last := start/beats, (end+beats-1)/beats
+	if first < c.busyUntil {
+		first = c.busyUntil
+	}
+
+	if last > first {
+		*c.busyCycles += last - first
+		c.busyUntil = last
+	}
+
+	c.freeBeat = end
+	b.ready = last + uint64(c.cfg.Latency)
+	c.inflight = append(c.inflight, b)
+
+	*c.words += uint64(b.words)
+	*c.bursts++
+}
+
+// complete removes the bursts that have completed by the cycle and returns
+// them.
+func (c *dramChannel) complete(cycle uint64) []dramBurst {
+	done := []dramBurst{}
+	pending := c.inflight[:0]
+
+	for _, b := range c.inflight {
+		if b.ready <= cycle {
+			done = append(done, b)
+		} else {
+			pending = append(pending, b)
+		}
+	}
+
+	c.inflight = pending
+
+	return done
+}
+
+// dram is the DRAM interface of a driver.
+type dram struct {
+	cfg   DRAMInterface
+	stats DRAMStats
+
+	read, write dramChannel
+}
+
+func newDRAM(cfg DRAMInterface) *dram {
+	if err := cfg.Validate(); err != nil {
+		panic(err.Error())
+	}
+
+	m := &dram{cfg: cfg}
+	m.read = dramChannel{
+		cfg:        &m.cfg,
+		words:      &m.stats.ReadWords,
+		bursts:     &m.stats.ReadBursts,
+		busyCycles: &m.stats.ReadBusyCycles,
+	}
+	m.write = dramChannel{
+		cfg:        &m.cfg,
+		words:      &m.stats.WriteWords,
+		bursts:     &m.stats.WriteBursts,
+		busyCycles: &m.stats.WriteBusyCycles,
+	}
+
+	return m
+}
+
+// doDRAM completes the bursts that have arrived and reads the data of the
+// FeedIn tasks ahead of their rounds. It makes progress as long as any
+// burst is in flight or any data is still to be read, so that the driver
+// keeps ticking.
+func (d *driverImpl) doDRAM() bool {
+	if d.dram == nil {
+		return false
+	}
+
+	cycle := d.Freq.Cycle(d.Engine.CurrentTime())
+
+	for _, b := range d.dram.read.complete(cycle) {
+		b.feedIn.dramArrived += b.words
+	}
+
+	for _, b := range d.dram.write.complete(cycle) {
+		b.collect.dramPending -= b.words
+	}
+
+	busy := false
+	burst := d.dram.cfg.burst()
+
+	for _, task := range d.feedInTasks {
+		total := task.totalWords()
+		for task.dramRead < total && d.dram.read.canIssue(cycle) {
+			words := total - task.dramRead
+			if words > burst {
+				words = burst
+			}
+
+			d.dram.read.issue(cycle, dramBurst{words: words, feedIn: task})
+			task.dramRead += words
+		}
+
+		busy = busy || task.dramRead < total
+	}
+
+	return busy || len(d.dram.read.inflight) > 0 ||
+		len(d.dram.write.inflight) > 0
+}
+
+// totalWords returns the number of words that the task feeds in.
+func (t *feedInTask) totalWords() int {
+	return len(t.data) / t.stride * len(t.localPorts)
+}
+
+// hasDRAMData returns true if the data of the next round of the task has
+// arrived from the DRAM.
+func (d *driverImpl) hasDRAMData(task *feedInTask) bool {
+	if d.dram == nil {
+		return true
+	}
+
+	return task.dramArrived >= (task.round+1)*len(task.localPorts)
+}
+
+// canWriteDRAM returns true if the write channel can take the next round
+// of a Collect task in this cycle.
+func (d *driverImpl) canWriteDRAM() bool {
+	if d.dram == nil {
+		return true
+	}
+
+	return d.dram.write.canIssue(d.Freq.Cycle(d.Engine.CurrentTime()))
+}
+
+// writeDRAM buffers the round that a Collect task has drained and writes
+// the full bursts, and the rest once the task has collected all its data.
+func (d *driverImpl) writeDRAM(task *collectTask) {
+	if d.dram == nil {
+		return
+	}
+
+	cycle := d.Freq.Cycle(d.Engine.CurrentTime())
+	burst := d.dram.cfg.burst()
+
+	task.dramBuffered += len(task.ports)
+	for task.dramBuffered >= burst ||
+		(task.isFinished() && task.dramBuffered > 0) {
+		words := task.dramBuffered
+		if words > burst {
+			words = burst
+		}
+
+		d.dram.write.issue(cycle, dramBurst{words: words, collect: task})
+		task.dramBuffered -= words
+		task.dramPending += words
+	}
+}
+
+// countDRAMStalls counts the cycle as a stall of each channel that a task
+// has waited for.
+func (d *driverImpl) countDRAMStalls(read, write bool) {
+	if d.dram == nil {
+		return
+	}
+
+	if read {
+		d.dram.stats.ReadStallCycles++
+	}
+
+	if write {
+		d.dram.stats.WriteStallCycles++
+	}
+}
+
+// GetDRAMStats returns the traffic over the DRAM interface.
+func (d *driverImpl) GetDRAMStats() DRAMStats {
+	if d.dram == nil {
+		return DRAMStats{}
+	}
+
+	return d.dram.stats
+}
@@ -2,35 +2,271 @@
 package api
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"strings"
 
 	"github.com/sarchlab/akita/v3/sim"
 	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
 )
 
 // Driver provides the interface to control an accelerator.
 type Driver interface {
 	// RegisterDevice registers a device to the driver. The driver will
-	// establish connections to the device.
+	// establish connections to the device. Devices are numbered in the order
+	// that they are registered, starting from 0.
 	RegisterDevice(device cgra.Device)
 
 	// FeedIn provides the data to the accelerator. The data is fed into the
 	// provides ports. The stride is the difference between the indices of
-	// the data that is sent to adjacent ports in the same cycle.
+	// the data that is sent to adjacent ports in the same cycle. It panics if
+	// a port belongs to a disabled tile.
 	FeedIn(data []uint32, side cgra.Side, portRange [2]int, stride int)
 
+	// FeedInPacked is the same as FeedIn, but packs the 16-bit values of two
+	// consecutive rounds into each 32-bit transfer.
+	FeedInPacked(data []uint16, side cgra.Side, portRange [2]int, stride int)
+
+	// FeedInAt is the same as FeedIn, but sends round r of the data no
+	// earlier than cycle startCycle + r*period of the driver, to model
+	// sources that produce data at their own rate or in bursts. A period
+	// of 1 sends as fast as FeedIn from the start cycle on.
+	FeedInAt(
+		data []uint32,
+		side cgra.Side,
+		portRange [2]int,
+		stride int,
+		startCycle, period int,
+	)
+
+	// FeedInStencil feeds a 2D input, surrounded by the halo that the input
+	// asks for, with one row per port. See StencilInput for the options.
+	FeedInStencil(in StencilInput, side cgra.Side, portRange [2]int)
+
+	// FeedInSparse feeds sparse vectors as index-value pairs, with one
+	// vector per port. See SparseRounds for the layout.
+	FeedInSparse(vectors [][]SparsePair, side cgra.Side, portRange [2]int)
+
 	// Collect collects the data from the accelerator. The data is collected
 	// from the provided ports. The stride is the difference between the
 	// indices of the data that is collected from adjacent ports in the same
-	// cycle.
+	// cycle. It panics if a port belongs to a disabled tile.
 	Collect(data []uint32, side cgra.Side, portRange [2]int, stride int)
 
+	// CollectWithValidity is the same as Collect, but also records whether
+	// each token is valid in valid, which has the same layout as data. The
+	// tokens that SEND_PRED sends with a false predicate are invalid, and
+	// their places in data are not written.
+	CollectWithValidity(
+		data []uint32, valid []bool, side cgra.Side, portRange [2]int, stride int)
+
+	// CollectAtRate is the same as Collect, but takes at most one round
+	// every period cycles of the driver, to model a slow consumer. The data
+	// that the consumer has not taken backs up into the device.
+	CollectAtRate(
+		data []uint32, side cgra.Side, portRange [2]int, stride int, period int)
+
+	// Chain collects the data of the collect task and calls check with the
+	// data when the collect finishes, which can feed in more data and
+	// collect again. See ChainCheck.
+	Chain(collect CollectTask, check ChainCheck)
+
 	// MapProgram maps to the provided program to a core at the given cordinate.
+	// It panics if the program does not pass CheckProgram. With a
+	// configuration bandwidth, the core starts running the program once the
+	// configuration bus has loaded it.
 	MapProgram(program string, core [2]int)
 
-	// Run will run all the tasks that have been added to the driver.
+	// CheckProgram checks if the program can run on the core at the given
+	// coordinate. It returns an error that lists all the violations, such as
+	// unknown opcodes, out-of-range registers, and unconnected sides.
+	CheckProgram(program string, core [2]int) error
+
+	// SetProgramConstant sets the value of a named constant. The programs
+	// that are mapped or checked afterwards have the operands with the name
+	// replaced by the value, which overrides the value declared in the
+	// program file.
+	SetProgramConstant(name string, value uint32)
+
+	// SetKernelArg sets the kernel argument with the index on all the tiles
+	// of all the devices. Programs read the argument with the ARGn operand,
+	// where n is the index, so that data sizes and other parameters can
+	// change without changing the programs.
+	SetKernelArg(index int, value uint32)
+
+	// SetRandomSeed seeds the random number generators of all the tiles of
+	// all the devices. Each tile gets a different seed that is derived from
+	// the seed and the position of the tile, so that the RAND instructions
+	// of different tiles return different sequences.
+	SetRandomSeed(seed uint64)
+
+	// SetSchedule makes the core at the given coordinate follow the static
+	// schedule of a compiler. The line i of the program executes for the
+	// k-th time, counting from 0, exactly steps[i] + k * ii cycles after the
+	// core starts, and the simulation panics with the reason if the line
+	// cannot execute then, e.g., because its data has not arrived.
+	SetSchedule(core [2]int, steps []int, ii int)
+
+	// WriteMemory writes the data to the local memory of the core at the
+	// given coordinate, starting from the address in words, e.g., the
+	// tables that GATHER reads.
+	WriteMemory(core [2]int, addr int, data []uint32)
+
+	// ReadMemory returns length words of the local memory of the core at
+	// the given coordinate, starting from the address, e.g., the results
+	// that SCATTER wrote.
+	ReadMemory(core [2]int, addr, length int) []uint32
+
+	// FeedInToDevice is the same as FeedIn, but feeds the data into the
+	// device with the given number.
+	FeedInToDevice(
+		device int, data []uint32, side cgra.Side, portRange [2]int, stride int)
+
+	// CollectFromDevice is the same as Collect, but collects the data from
+	// the device with the given number.
+	CollectFromDevice(
+		device int, data []uint32, side cgra.Side, portRange [2]int, stride int)
+
+	// MapProgramToDevice is the same as MapProgram, but maps the program to
+	// a core of the device with the given number.
+	MapProgramToDevice(device int, program string, core [2]int)
+
+	// Run will run all the tasks that have been added to the driver, until
+	// the simulation finishes or reaches a pause. The next Run continues
+	// from the pause.
 	Run()
+
+	// PauseAt makes Run return once the tiles have run the given cycle of
+	// the driver clock, so that the host can read and write the registers
+	// of the tiles, e.g., to inject new coefficients between phases.
+	PauseAt(cycle uint64)
+
+	// IsPaused returns true if the last Run returned at a pause rather than
+	// at the end of the simulation.
+	IsPaused() bool
+
+	// WatchRegister pauses the simulation like PauseAt at the end of the
+	// cycle in which an instruction of the core at the given coordinate
+	// first sets the register to the value, and records the hit with the
+	// instruction in GetWatchHits.
+	WatchRegister(core [2]int, reg int, value uint32)
+
+	// WatchMemory is the same as WatchRegister, but watches a word of the
+	// local memory at the address.
+	WatchMemory(core [2]int, addr int, value uint32)
+
+	// GetWatchHits returns the watchpoints that have been hit, in the order
+	// of the hits.
+	GetWatchHits() []cgra.WatchHit
+
+	// ReadRegister returns the value of a register of the core at the given
+	// coordinate.
+	ReadRegister(core [2]int, reg int) uint32
+
+	// WriteRegister sets the value of a register of the core at the given
+	// coordinate.
+	WriteRegister(core [2]int, reg int, value uint32)
+
+	// WaitAllDone runs the tasks until the simulation ends and checks that
+	// the programs on all the tiles have executed a DONE instruction. It
+	// panics if any tile with a program is not done, except for the tiles
+	// that an error has halted, which GetErrors reports. If the simulation
+	// reaches a pause, it returns without checking.
+	WaitAllDone()
+
+	// CreateStream creates a stream of operations that the driver issues in
+	// order during Run.
+	CreateStream() Stream
+
+	// GetReturnValues returns the values recorded by the RETURN_VALUE
+	// instructions of the first device, keyed by the [x, y] coordinate of the
+	// tile. Tiles that have not recorded a value are not included.
+	GetReturnValues() map[[2]int]uint32
+
+	// GetActivityStats returns the breakdown of the cycles of each tile of
+	// the first device, keyed by the [x, y] coordinate of the tile.
+	GetActivityStats() map[[2]int]cgra.ActivityStats
+
+	// GetCounters returns the counters that RDCOUNTER instructions read,
+	// of each tile of the first device, keyed by the [x, y] coordinate of
+	// the tile.
+	GetCounters() map[[2]int]cgra.Counters
+
+	// GetLinkStats returns the traffic on the links that leave the tiles of
+	// the first device. Links that have never been used are not included.
+	GetLinkStats() map[cgra.Link]cgra.LinkStats
+
+	// GetRoofline returns the roofline analysis of the run on the first
+	// device, which compares the achieved compute and boundary bandwidth
+	// with the peaks of the device.
+	GetRoofline() Roofline
+
+	// StateHash returns a hash of the current time and the state of all the
+	// tiles of all the devices. Runs of the same setup that end with
+	// different hashes are nondeterministic.
+	StateHash() uint64
+
+	// GetTileStates returns the state of each tile of the first device,
+	// keyed by the [x, y] coordinate of the tile.
+	GetTileStates() map[[2]int]cgra.TileState
+
+	// GetErrors returns the errors of the instructions that the tiles of all
+	// the devices have failed to run, e.g., because of an address out of
+	// the local memory, by the device and then in row-major order. A tile
+	// with an error halts, while the other tiles keep running.
+	GetErrors() []cgra.ErrorRecord
+
+	// SetTaskPriority sets the priority of the FeedIn and Collect tasks that
+	// are created afterwards, which ArbitrationPriority services first if it
+	// is higher. The default is 0.
+	SetTaskPriority(priority int)
+
+	// GetTaskStats returns the arbitration record of each FeedIn and
+	// Collect task, in the order that they are created.
+	GetTaskStats() []TaskStats
+
+	// Reconfigure maps the programs, keyed by the coordinate of the core, to
+	// the tiles in the region of the first device, while the other tiles
+	// keep running. It panics with the hazards if a program is outside of
+	// the region, or if a tile of the region is still running its program,
+	// loading a program, or sending.
+	Reconfigure(region Region, programs map[[2]int]string)
+
+	// Preempt halts the tiles in the region of the first device, waits
+	// until the tokens that they have sent have arrived, saves their
+	// contexts, and maps the programs of another kernel to the region. The
+	// tokens that arrive at the region afterwards go to the other kernel if
+	// it receives them, and are otherwise kept for the original kernel. It
+	// panics if a program is outside of the region or if the region
+	// overlaps one that is already preempted.
+	Preempt(region Region, programs map[[2]int]string)
+
+	// Restore brings back the contexts that Preempt saved, once the
+	// programs of the other kernel are done, and the original kernel
+	// continues from where it was preempted.
+	Restore(region Region)
+
+	// SaveRecording writes the calls and the transfers of a driver that is
+	// built with WithRecording to a replay file.
+	SaveRecording(path string) error
+
+	// Replay makes the calls of a replay file on a new driver, and returns
+	// an error at the first result or transfer that differs from the
+	// recording, e.g., to bisect a change of the behavior between two
+	// versions of the simulator.
+	Replay(path string) error
+
+	// GetConfigTime returns the time that it took to load the programs of
+	// each kernel, in the order that the kernels are mapped. See
+	// DriverBuilder.WithConfigBandwidth.
+	GetConfigTime() []ConfigTime
+
+	// GetDRAMStats returns the traffic over the DRAM interface, which is
+	// empty without one. See DriverBuilder.WithDRAMInterface.
+	GetDRAMStats() DRAMStats
 }
 
 type portFactory interface {
@@ -40,31 +276,149 @@ type portFactory interface {
 type driverImpl struct {
 	*sim.TickingComponent
 
-	device      cgra.Device
+	devices     []cgra.Device
 	portFactory portFactory
+	syncStages  int
+	rand        *rand.Rand
+	constants   map[string]uint32
 
 	feedInTasks  []*feedInTask
 	collectTasks []*collectTask
+	streams      []*streamImpl
+
+	// streamEvents are the events that the streams have recorded, in order,
+	// so that a recording can refer to them by the index.
+	streamEvents []*StreamEvent
+
+	// arbitration decides the order of the FeedIn and the Collect tasks.
+	// The tasks that are created get the priority, and nextFeedIn and
+	// nextCollect are where the round-robin arbitration starts. arbStamp
+	// counts the tasks that are created or make progress.
+	arbitration Arbitration
+	priority    int
+	taskStats   []*TaskStats
+	nextFeedIn  int
+	nextCollect int
+	arbStamp    uint64
+
+	// boundaryWords counts the words that the driver has fed into and
+	// collected from each device.
+	boundaryWords []uint64
+
+	// taskFinished, if set, is called when a FeedIn or Collect task
+	// finishes.
+	taskFinished func(now sim.VTimeInSec)
+
+	// configBandwidth is the number of instructions that the configuration
+	// bus loads per cycle, or 0 if programs load right away. The bus is free
+	// from configFree on.
+	configBandwidth int
+	configLoads     []configLoad
+	configFree      sim.VTimeInSec
+	configTimes     []ConfigTime
+
+	// dram, if set, is the interface that the data of the FeedIn and the
+	// Collect tasks crosses.
+	dram *dram
+
+	preemptions []*preemption
+
+	pauser    pauser
+	watchHits []cgra.WatchHit
+
+	// seed is the seed of the driver, and recorder, if set, records the
+	// calls and the transfers for a replay.
+	seed     int64
+	recorder *recorder
 }
 
 // Tick runs the driver for one cycle.
 func (d *driverImpl) Tick(now sim.VTimeInSec) (madeProgress bool) {
+	madeProgress = d.doConfigLoads(now) || madeProgress
+	madeProgress = d.doPreemptions() || madeProgress
+	madeProgress = d.doStreams() || madeProgress
+	madeProgress = d.doDRAM() || madeProgress
 	madeProgress = d.doFeedIn() || madeProgress
 	madeProgress = d.doCollect() || madeProgress
 
 	return madeProgress
 }
 
+func (d *driverImpl) doStreams() bool {
+	madeProgress := false
+
+	for _, i := range d.serviceOrder(len(d.streams)) {
+		madeProgress = d.streams[i].issueOps() || madeProgress
+	}
+
+	return madeProgress
+}
+
+// serviceOrder returns the order to service n streams or tasks in, which is
+// a permutation drawn from the seed if the driver has one.
+func (d *driverImpl) serviceOrder(n int) []int {
+	if d.rand != nil {
+		return d.rand.Perm(n)
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	return order
+}
+
+// doFeedIn services the FeedIn tasks in the order of the arbitration. Each
+// port carries the data of one task per cycle, and the tasks whose ports
+// are taken starve. The tasks that wait for the cycle of their next round
+// keep the driver ticking.
 func (d *driverImpl) doFeedIn() bool {
 	madeProgress := false
+	waiting := false
+	stalled := false
+	used := make(map[sim.Port]bool)
 
-	for _, task := range d.feedInTasks {
-		madeProgress = d.doOneFeedInTask(task) || madeProgress
+	arbs := make([]*taskArb, len(d.feedInTasks))
+	for i, task := range d.feedInTasks {
+		arbs[i] = &task.taskArb
+	}
+
+	for _, i := range d.taskOrder(arbs, d.nextFeedIn) {
+		task := d.feedInTasks[i]
+		if !d.isDue(task) {
+			waiting = true
+			continue
+		}
+
+		if !d.hasDRAMData(task) {
+			waiting = true
+			stalled = true
+			continue
+		}
+
+		if usesAny(task.localPorts, used) {
+			task.starved()
+			continue
+		}
+
+		if !d.doOneFeedInTask(task) {
+			continue
+		}
+
+		if !madeProgress {
+			d.nextFeedIn = task.seq + 1
+		}
+
+		madeProgress = true
+		d.serviced(&task.taskArb)
+		markUsed(task.localPorts, used)
 	}
 
 	d.removeFinishedFeedInTasks()
+	d.countDRAMStalls(stalled, false)
 
-	return madeProgress
+	return madeProgress || waiting
 }
 
 func (d *driverImpl) removeFinishedFeedInTasks() {
@@ -72,10 +426,17 @@ func (d *driverImpl) removeFinishedFeedInTasks() {
 		if d.feedInTasks[i].isFinished() {
 			d.feedInTasks = append(
 				d.feedInTasks[:i], d.feedInTasks[i+1:]...)
+			d.notifyTaskFinished()
 		}
 	}
 }
 
+func (d *driverImpl) notifyTaskFinished() {
+	if d.taskFinished != nil {
+		d.taskFinished(d.Engine.CurrentTime())
+	}
+}
+
 func (d *driverImpl) doOneFeedInTask(task *feedInTask) bool {
 	madeProgress := false
 
@@ -96,30 +457,79 @@ func (d *driverImpl) doOneFeedInTask(task *feedInTask) bool {
 			WithSrc(port).
 			WithDst(task.remotePorts[i]).
 			WithData(task.data[task.round*task.stride+i]).
+			WithSendTime(d.Engine.CurrentTime()).
 			Build()
 		err := port.Send(msg)
 		if err != nil {
 			panic("CGRA cannot handle the data rate")
 		}
 
+		d.recorder.transfer(Transfer{
+			Cycle: d.Freq.Cycle(d.Engine.CurrentTime()),
+			Port:  port.Name(),
+			Data:  msg.Data,
+		})
+
 		madeProgress = true
 	}
 
+	d.boundaryWords[task.deviceID] += uint64(len(task.localPorts))
 	task.round++
 
 	return madeProgress
 }
 
+// doCollect services the Collect tasks like doFeedIn services the FeedIn
+// tasks. The tasks whose data is ready but whose sink is busy keep the
+// driver ticking.
 func (d *driverImpl) doCollect() bool {
 	madeProgress := false
+	waiting := false
+	stalled := false
+	used := make(map[sim.Port]bool)
 
-	for _, task := range d.collectTasks {
-		madeProgress = d.doOneCollectTask(task) || madeProgress
+	arbs := make([]*taskArb, len(d.collectTasks))
+	for i, task := range d.collectTasks {
+		arbs[i] = &task.taskArb
+	}
+
+	for _, i := range d.taskOrder(arbs, d.nextCollect) {
+		task := d.collectTasks[i]
+		if !d.canDrain(task) {
+			waiting = waiting || d.allDataReady(task)
+			continue
+		}
+
+		if !d.canWriteDRAM() {
+			ready := d.allDataReady(task)
+			waiting = waiting || ready
+			stalled = stalled || ready
+
+			continue
+		}
+
+		if usesAny(task.ports, used) {
+			task.starved()
+			continue
+		}
+
+		if !d.doOneCollectTask(task) {
+			continue
+		}
+
+		if !madeProgress {
+			d.nextCollect = task.seq + 1
+		}
+
+		madeProgress = true
+		d.serviced(&task.taskArb)
+		markUsed(task.ports, used)
 	}
 
 	d.removeFinishedCollectTasks()
+	d.countDRAMStalls(false, stalled)
 
-	return madeProgress
+	return madeProgress || waiting
 }
 
 func (d *driverImpl) doOneCollectTask(task *collectTask) bool {
@@ -129,10 +539,31 @@ func (d *driverImpl) doOneCollectTask(task *collectTask) bool {
 
 	for i, port := range task.ports {
 		msg := port.Retrieve(d.Engine.CurrentTime()).(*cgra.MoveMsg)
-		task.data[task.round*task.stride+i] = msg.Data
+		index := task.round*task.stride + i
+
+		d.recorder.transfer(Transfer{
+			Cycle:   d.Freq.Cycle(d.Engine.CurrentTime()),
+			Port:    port.Name(),
+			Collect: true,
+			Data:    msg.Data,
+			Invalid: msg.Invalid,
+		})
+
+		if task.valid == nil {
+			task.data[index] = msg.Data
+			continue
+		}
+
+		task.valid[index] = !msg.Invalid
+		if !msg.Invalid {
+			task.data[index] = msg.Data
+		}
 	}
 
+	d.boundaryWords[task.deviceID] += uint64(len(task.ports))
 	task.round++
+	task.nextDrain = d.Freq.Cycle(d.Engine.CurrentTime()) + task.period
+	d.writeDRAM(task)
 
 	return true
 }
@@ -150,9 +581,16 @@ func (*driverImpl) allDataReady(task *collectTask) bool {
 
 func (d *driverImpl) removeFinishedCollectTasks() {
 	for i := len(d.collectTasks) - 1; i >= 0; i-- {
-		if d.collectTasks[i].isFinished() {
+		if d.collectTasks[i].isFinished() &&
+			d.collectTasks[i].dramPending == 0 {
+			task := d.collectTasks[i]
 			d.collectTasks = append(
 				d.collectTasks[:i], d.collectTasks[i+1:]...)
+			d.notifyTaskFinished()
+
+			if task.finished != nil {
+				task.finished()
+			}
 		}
 	}
 }
@@ -160,81 +598,147 @@ func (d *driverImpl) removeFinishedCollectTasks() {
 // RegisterDevice registers a device to the driver. The driver will
 // establish connections to the device.
 func (d *driverImpl) RegisterDevice(device cgra.Device) {
-	d.device = device
+	d.devices = append(d.devices, device)
+	d.boundaryWords = append(d.boundaryWords, 0)
+	deviceID := len(d.devices) - 1
 
-	d.establishConnectionOneSide(d.device, cgra.North)
-	d.establishConnectionOneSide(d.device, cgra.South)
-	d.establishConnectionOneSide(d.device, cgra.East)
-	d.establishConnectionOneSide(d.device, cgra.West)
+	d.establishConnectionOneSide(deviceID, cgra.North)
+	d.establishConnectionOneSide(deviceID, cgra.South)
+	d.establishConnectionOneSide(deviceID, cgra.East)
+	d.establishConnectionOneSide(deviceID, cgra.West)
+}
+
+func (d *driverImpl) getDevice(deviceID int) cgra.Device {
+	if deviceID < 0 || deviceID >= len(d.devices) {
+		panic(fmt.Sprintf("device %d is not registered", deviceID))
+	}
+
+	return d.devices[deviceID]
 }
 
 func (d *driverImpl) establishConnectionOneSide(
-	device cgra.Device,
+	deviceID int,
 	side cgra.Side,
 ) {
+	device := d.devices[deviceID]
 	width, height := device.GetSize()
-	maxIndex := 0
+	numTiles := 0
 	switch side {
 	case cgra.North, cgra.South:
-		maxIndex = width - 1
+		numTiles = width
 	case cgra.East, cgra.West:
-		maxIndex = height - 1
+		numTiles = height
 	}
 
-	ports := device.GetSidePorts(side, [2]int{0, maxIndex + 1})
+	ports := device.GetSidePorts(side,
+		[2]int{0, numTiles * device.GetChannels()})
 	for i, port := range ports {
-		d.connectOnePort(side, i, port)
+		if port == nil {
+			continue
+		}
+
+		d.connectOnePort(deviceID, side, i, port)
 	}
 }
 
-func (d *driverImpl) localPortName(side cgra.Side, index int) string {
-	return fmt.Sprintf("Device%s[%d]", side.Name(), index)
+func (d *driverImpl) localPortName(
+	deviceID int,
+	side cgra.Side,
+	index int,
+) string {
+	if deviceID == 0 {
+		return fmt.Sprintf("Device%s[%d]", side.Name(), index)
+	}
+
+	return fmt.Sprintf("Device%d%s[%d]", deviceID, side.Name(), index)
 }
 
-func (d *driverImpl) connectOnePort(side cgra.Side, index int, port sim.Port) {
-	portName := d.localPortName(side, index)
+func (d *driverImpl) connectOnePort(
+	deviceID int,
+	side cgra.Side,
+	index int,
+	port sim.Port,
+) {
+	portName := d.localPortName(deviceID, side, index)
 	localPort := d.portFactory.make(d, d.Name()+"."+portName)
 	d.AddPort(portName, localPort)
 
-	conn := sim.NewDirectConnection(
-		localPort.Name()+"."+port.Name(),
-		d.Engine,
-		d.Freq,
-	)
-	conn.PlugIn(localPort, 1)
-	conn.PlugIn(port, 1)
+	device := d.devices[deviceID]
+	channels := device.GetChannels()
+	tile := boundaryTile(device, side, index/channels)
+	connName := localPort.Name() + "." + port.Name()
 
-	d.setTileRemovePort(side, index, localPort)
+	// A synchronizer makes a message visible only after a number of cycles
+	// of the clock of the receiver.
+	if d.syncStages > 0 {
+		conn := cgra.NewTimedConnection(connName, d.Engine, d.Freq)
+		conn.PlugInWithTiming(localPort, 1,
+			cgra.Timing{Freq: tile.GetFreq(), Latency: d.syncStages})
+		conn.PlugInWithTiming(port, 1,
+			cgra.Timing{Freq: d.Freq, Latency: d.syncStages})
+	} else {
+		conn := sim.NewDirectConnection(connName, d.Engine, d.Freq)
+		conn.PlugIn(localPort, 1)
+		conn.PlugIn(port, 1)
+	}
+
+	tile.SetChannelRemotePort(side, index%channels, localPort)
 }
 
-func (d *driverImpl) setTileRemovePort(
-	side cgra.Side,
-	index int,
-	localPort sim.Port,
-) {
-	width, height := d.device.GetSize()
-	var tile cgra.Tile
+// ChannelPort returns the index of the port of a channel of a boundary tile,
+// for the port ranges of FeedIn and Collect on a device whose tiles have the
+// given number of channels on each side. The tile is the index of the tile
+// along the side, e.g., the row of a tile on the West side.
+func ChannelPort(tile, channel, channels int) int {
+	return tile*channels + channel
+}
+
+func boundaryTile(device cgra.Device, side cgra.Side, index int) cgra.Tile {
+	coord := boundaryCoord(device, side, index)
+
+	return device.GetTile(coord[0], coord[1])
+}
+
+// boundaryCoord returns the [x, y] coordinate of the tile at the index along
+// the side of the device.
+func boundaryCoord(device cgra.Device, side cgra.Side, index int) [2]int {
+	width, height := device.GetSize()
+
 	switch side {
 	case cgra.North:
-		tile = d.device.GetTile(index, 0)
+		return [2]int{index, 0}
 	case cgra.South:
-		tile = d.device.GetTile(index, height-1)
+		return [2]int{index, height - 1}
 	case cgra.East:
-		tile = d.device.GetTile(width-1, index)
+		return [2]int{width - 1, index}
 	case cgra.West:
-		tile = d.device.GetTile(0, index)
+		return [2]int{0, index}
+	default:
+		panic("invalid side")
 	}
-	tile.SetRemotePort(side, localPort)
 }
 
 type feedInTask struct {
-	data []uint32
+	taskArb
+
+	deviceID int
+	data     []uint32
 
 	localPorts  []sim.Port
 	remotePorts []sim.Port
 
 	stride int
 	round  int
+
+	// startCycle and period are the cycle of the driver in which the first
+	// round is due and the cycles between the rounds. FeedIn sends every
+	// round as soon as it can.
+	startCycle uint64
+	period     uint64
+
+	// dramRead and dramArrived are the words that the task has requested
+	// from the DRAM interface and that have arrived.
+	dramRead, dramArrived int
 }
 
 func (t *feedInTask) isFinished() bool {
@@ -247,10 +751,28 @@ func (d *driverImpl) FeedIn(
 	portRange [2]int,
 	stride int,
 ) {
+	defer d.recorder.end(
+		d.recorder.begin("FeedIn", data, side, portRange, stride))
+
+	d.FeedInToDevice(0, data, side, portRange, stride)
+}
+
+func (d *driverImpl) FeedInToDevice(
+	deviceID int,
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("FeedInToDevice", deviceID, data, side, portRange, stride))
+
 	task := &feedInTask{
+		taskArb:     d.newTaskArb("FeedIn", deviceID),
+		deviceID:    deviceID,
 		data:        data,
-		localPorts:  d.getLocalPorts(side, portRange),
-		remotePorts: d.device.GetSidePorts(side, portRange),
+		localPorts:  d.getLocalPorts(deviceID, side, portRange),
+		remotePorts: d.getDevice(deviceID).GetSidePorts(side, portRange),
 		stride:      stride,
 	}
 
@@ -258,23 +780,54 @@ func (d *driverImpl) FeedIn(
 }
 
 func (d *driverImpl) getLocalPorts(
+	deviceID int,
 	side cgra.Side,
 	portRange [2]int,
 ) []sim.Port {
 	ports := make([]sim.Port, 0, portRange[1]-portRange[0]+1)
+	device := d.getDevice(deviceID)
 
 	for i := portRange[0]; i < portRange[1]; i++ {
-		ports = append(ports, d.GetPortByName(d.localPortName(side, i)))
+		tile := i / device.GetChannels()
+		if boundaryTile(device, side, tile) == nil {
+			coord := boundaryCoord(device, side, tile)
+			panic(fmt.Sprintf("port %d on the %s side of device %d "+
+				"belongs to the disabled tile (%d, %d)",
+				i, side.Name(), deviceID, coord[0], coord[1]))
+		}
+
+		ports = append(ports,
+			d.GetPortByName(d.localPortName(deviceID, side, i)))
 	}
 
 	return ports
 }
 
 type collectTask struct {
-	data   []uint32
-	ports  []sim.Port
-	stride int
-	round  int
+	taskArb
+
+	deviceID int
+	data     []uint32
+	ports    []sim.Port
+	stride   int
+	round    int
+
+	// valid, if set, records whether each collected token is valid.
+	valid []bool
+
+	// finished, if set, is called after the task is removed.
+	finished func()
+
+	// period is the number of cycles that the sink takes for a round, and
+	// nextDrain is the cycle from which it can take the next round.
+	// Collect takes every round as soon as it arrives.
+	period    uint64
+	nextDrain uint64
+
+	// dramBuffered are the words that wait for a full burst to be written
+	// to the DRAM interface, and dramPending are the words that are written
+	// but not acknowledged yet.
+	dramBuffered, dramPending int
 }
 
 func (t *collectTask) isFinished() bool {
@@ -287,26 +840,404 @@ func (d *driverImpl) Collect(
 	portRange [2]int,
 	stride int,
 ) {
+	defer d.recorder.end(
+		d.recorder.begin("Collect", data, side, portRange, stride))
+
+	d.CollectFromDevice(0, data, side, portRange, stride)
+}
+
+func (d *driverImpl) CollectFromDevice(
+	deviceID int,
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("CollectFromDevice",
+			deviceID, data, side, portRange, stride))
+
+	d.getDevice(deviceID)
+
 	task := &collectTask{
-		data:   data,
-		ports:  d.getLocalPorts(side, portRange),
-		stride: stride,
+		taskArb:  d.newTaskArb("Collect", deviceID),
+		deviceID: deviceID,
+		data:     data,
+		ports:    d.getLocalPorts(deviceID, side, portRange),
+		stride:   stride,
 	}
 
 	d.collectTasks = append(d.collectTasks, task)
 }
 
+func (d *driverImpl) CollectWithValidity(
+	data []uint32,
+	valid []bool,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("CollectWithValidity", data, valid, side, portRange, stride))
+
+	if len(valid) != len(data) {
+		panic(fmt.Sprintf("the validity map has %d entries, "+
+			"but the data has %d", len(valid), len(data)))
+	}
+
+	d.Collect(data, side, portRange, stride)
+	d.collectTasks[len(d.collectTasks)-1].valid = valid
+}
+
 // MapProgram dispatches a program to a core.
 func (d *driverImpl) MapProgram(program string, core [2]int) {
-	tile := d.device.GetTile(core[0], core[1])
-	tile.MapProgram(strings.Split(program, "\n"))
+	defer d.recorder.end(d.recorder.begin("MapProgram", program, core))
+
+	d.MapProgramToDevice(0, program, core)
 }
 
-// Run runs all the tasks in the driver.
-func (d *driverImpl) Run() {
-	d.TickNow(d.Engine.CurrentTime())
-	err := d.Engine.Run()
-	if err != nil {
-		panic(err)
+// MapProgramToDevice dispatches a program to a core of a device.
+func (d *driverImpl) MapProgramToDevice(
+	deviceID int,
+	program string,
+	core [2]int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("MapProgramToDevice", deviceID, program, core))
+
+	tile := d.getDevice(deviceID).GetTile(core[0], core[1])
+	if tile == nil {
+		panic(fmt.Sprintf("cannot map program to disabled tile (%d, %d)",
+			core[0], core[1]))
+	}
+
+	program = d.resolveConstants(program)
+	d.mapProgramTimed(tile, strings.Split(program, "\n"))
+}
+
+// CheckProgram checks if the program can run on a core.
+func (d *driverImpl) CheckProgram(program string, core [2]int) error {
+	tile := d.getDevice(0).GetTile(core[0], core[1])
+	if tile == nil {
+		return fmt.Errorf("tile (%d, %d) is disabled", core[0], core[1])
+	}
+
+	program = d.resolveConstants(program)
+
+	return tile.CheckProgram(strings.Split(program, "\n"))
+}
+
+func (d *driverImpl) resolveConstants(program string) string {
+	return core.ResolveConstants(program, d.constants)
+}
+
+// SetProgramConstant sets the value of a named constant.
+func (d *driverImpl) SetProgramConstant(name string, value uint32) {
+	defer d.recorder.end(d.recorder.begin("SetProgramConstant", name, value))
+
+	if d.constants == nil {
+		d.constants = make(map[string]uint32)
 	}
+
+	d.constants[name] = value
+}
+
+// SetKernelArg sets a kernel argument on all the tiles.
+func (d *driverImpl) SetKernelArg(index int, value uint32) {
+	defer d.recorder.end(d.recorder.begin("SetKernelArg", index, value))
+
+	for _, device := range d.devices {
+		width, height := device.GetSize()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if tile := device.GetTile(x, y); tile != nil {
+					tile.SetKernelArg(index, value)
+				}
+			}
+		}
+	}
+}
+
+// SetRandomSeed seeds the random number generator of each tile.
+func (d *driverImpl) SetRandomSeed(seed uint64) {
+	defer d.recorder.end(d.recorder.begin("SetRandomSeed", seed))
+
+	for id, device := range d.devices {
+		width, height := device.GetSize()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if tile := device.GetTile(x, y); tile != nil {
+					tile.SetRandomSeed(seed +
+						uint64(id)<<40 + uint64(y)<<20 + uint64(x))
+				}
+			}
+		}
+	}
+}
+
+// SetSchedule makes a core follow a static schedule.
+func (d *driverImpl) SetSchedule(core [2]int, steps []int, ii int) {
+	defer d.recorder.end(d.recorder.begin("SetSchedule", core, steps, ii))
+
+	tile := d.getDevice(0).GetTile(core[0], core[1])
+	if tile == nil {
+		panic(fmt.Sprintf("cannot schedule disabled tile (%d, %d)",
+			core[0], core[1]))
+	}
+
+	tile.SetSchedule(steps, ii)
+}
+
+// WriteMemory writes to the local memory of a core.
+func (d *driverImpl) WriteMemory(core [2]int, addr int, data []uint32) {
+	defer d.recorder.end(d.recorder.begin("WriteMemory", core, addr, data))
+
+	d.memoryTile(core).WriteMemory(addr, data)
+}
+
+// ReadMemory reads the local memory of a core.
+func (d *driverImpl) ReadMemory(core [2]int, addr, length int) []uint32 {
+	call := d.recorder.begin("ReadMemory", core, addr, length)
+	defer d.recorder.end(call)
+
+	data := d.memoryTile(core).ReadMemory(addr, length)
+	d.recorder.setResult(call, data)
+
+	return data
+}
+
+func (d *driverImpl) registerTile(core [2]int) cgra.Tile {
+	tile := d.getDevice(0).GetTile(core[0], core[1])
+	if tile == nil {
+		panic(fmt.Sprintf("tile (%d, %d) is disabled, it has no registers",
+			core[0], core[1]))
+	}
+
+	return tile
+}
+
+func (d *driverImpl) memoryTile(core [2]int) cgra.Tile {
+	tile := d.getDevice(0).GetTile(core[0], core[1])
+	if tile == nil {
+		panic(fmt.Sprintf("tile (%d, %d) is disabled, it has no memory",
+			core[0], core[1]))
+	}
+
+	return tile
+}
+
+// CreateStream creates a stream of operations.
+func (d *driverImpl) CreateStream() Stream {
+	defer d.recorder.end(d.recorder.begin("CreateStream"))
+
+	s := &streamImpl{driver: d, id: len(d.streams)}
+	d.streams = append(d.streams, s)
+
+	return s
+}
+
+// WaitAllDone runs the tasks and checks that all the tiles are done.
+func (d *driverImpl) WaitAllDone() {
+	defer d.recorder.end(d.recorder.begin("WaitAllDone"))
+
+	d.Run()
+	if d.IsPaused() {
+		return
+	}
+
+	notDone := []string{}
+	for id, device := range d.devices {
+		width, height := device.GetSize()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				tile := device.GetTile(x, y)
+				if tile != nil && !tile.IsDone() && tile.GetError() == nil {
+					notDone = append(notDone,
+						fmt.Sprintf("device %d tile (%d, %d)", id, x, y))
+				}
+			}
+		}
+	}
+
+	if len(notDone) > 0 {
+		panic("simulation stalled before all the tiles are done: " +
+			strings.Join(notDone, ", "))
+	}
+}
+
+// GetReturnValues returns the return values recorded by the tiles.
+func (d *driverImpl) GetReturnValues() map[[2]int]uint32 {
+	values := make(map[[2]int]uint32)
+
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := device.GetTile(x, y)
+			if tile == nil {
+				continue
+			}
+
+			if v, ok := tile.GetRetVal(); ok {
+				values[[2]int{x, y}] = v
+			}
+		}
+	}
+
+	return values
+}
+
+// GetActivityStats returns the breakdown of the cycles of each tile.
+func (d *driverImpl) GetActivityStats() map[[2]int]cgra.ActivityStats {
+	stats := make(map[[2]int]cgra.ActivityStats)
+
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := device.GetTile(x, y)
+			if tile == nil {
+				continue
+			}
+
+			stats[[2]int{x, y}] = tile.GetActivityStats()
+		}
+	}
+
+	return stats
+}
+
+// GetCounters returns the counters of each tile.
+func (d *driverImpl) GetCounters() map[[2]int]cgra.Counters {
+	counters := make(map[[2]int]cgra.Counters)
+
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := device.GetTile(x, y)
+			if tile == nil {
+				continue
+			}
+
+			counters[[2]int{x, y}] = tile.GetCounters()
+		}
+	}
+
+	return counters
+}
+
+// GetTileStates returns the state of each tile.
+func (d *driverImpl) GetTileStates() map[[2]int]cgra.TileState {
+	states := make(map[[2]int]cgra.TileState)
+
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := device.GetTile(x, y)
+			if tile == nil {
+				continue
+			}
+
+			states[[2]int{x, y}] = tile.GetState()
+		}
+	}
+
+	return states
+}
+
+// GetErrors returns the errors that have halted the tiles.
+func (d *driverImpl) GetErrors() []cgra.ErrorRecord {
+	records := []cgra.ErrorRecord{}
+
+	for id, device := range d.devices {
+		for _, coord := range device.GetTileCoords() {
+			record := device.GetTile(coord[0], coord[1]).GetError()
+			if record == nil {
+				continue
+			}
+
+			record.Device = id
+			record.Tile = coord
+			records = append(records, *record)
+		}
+	}
+
+	return records
+}
+
+// GetLinkStats returns the traffic on the links that leave each tile.
+func (d *driverImpl) GetLinkStats() map[cgra.Link]cgra.LinkStats {
+	stats := make(map[cgra.Link]cgra.LinkStats)
+
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := device.GetTile(x, y)
+			if tile == nil {
+				continue
+			}
+
+			for side := cgra.North; side <= cgra.West; side++ {
+				port := tile.GetPortStats(side)
+				if port.Sent == 0 && port.Stalls == 0 {
+					continue
+				}
+
+				link := cgra.LinkStats{Messages: port.Sent, Stalls: port.Stalls}
+				if n := neighborTile(device, x, y, side); n != nil {
+					link.PeakOccupancy =
+						n.GetPortStats(side.Opposite()).PeakOccupancy
+				}
+
+				stats[cgra.Link{X: x, Y: y, Side: side}] = link
+			}
+		}
+	}
+
+	return stats
+}
+
+// neighborTile returns the tile next to the tile at [x, y] on the side, or
+// nil if there is no such tile.
+func neighborTile(device cgra.Device, x, y int, side cgra.Side) cgra.Tile {
+	switch side {
+	case cgra.North:
+		y--
+	case cgra.East:
+		x++
+	case cgra.South:
+		y++
+	case cgra.West:
+		x--
+	}
+
+	width, height := device.GetSize()
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return nil
+	}
+
+	return device.GetTile(x, y)
+}
+
+// StateHash hashes the current time and the state of each tile.
+func (d *driverImpl) StateHash() uint64 {
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, float64(d.Engine.CurrentTime()))
+
+	for _, device := range d.devices {
+		width, height := device.GetSize()
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				tile := device.GetTile(x, y)
+				if tile != nil {
+					tile.WriteState(h)
+				}
+			}
+		}
+	}
+
+	return h.Sum64()
 }
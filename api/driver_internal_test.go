@@ -1,6 +1,8 @@
 package api
 
 import (
+	"strings"
+
 	gomock "github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -44,10 +46,13 @@ var _ = Describe("Driver", func() {
 		mockDeviceSidePort.EXPECT().SetConnection(gomock.Any()).AnyTimes()
 
 		mockTile = NewMockTile(mockCtrl)
-		mockTile.EXPECT().SetRemotePort(gomock.Any(), gomock.Any()).AnyTimes()
+		mockTile.EXPECT().
+			SetChannelRemotePort(gomock.Any(), 0, gomock.Any()).
+			AnyTimes()
 
 		mockDevice = NewMockDevice(mockCtrl)
 		mockDevice.EXPECT().GetSize().Return(4, 4).AnyTimes()
+		mockDevice.EXPECT().GetChannels().Return(1).AnyTimes()
 		mockDevice.EXPECT().
 			GetTile(gomock.Any(), gomock.Any()).
 			Return(mockTile).
@@ -67,7 +72,6 @@ var _ = Describe("Driver", func() {
 			ports:    make(map[string]*MockPort),
 		}
 		driver = &driverImpl{
-			device:      mockDevice,
 			portFactory: portFactory,
 		}
 		driver.TickingComponent =
@@ -101,6 +105,145 @@ var _ = Describe("Driver", func() {
 		Expect(driver.feedInTasks[0].stride).To(Equal(3))
 	})
 
+	It("should pack two rounds of 16-bit data per transfer", func() {
+		data := []uint16{1, 2, 3, 4, 5, 6}
+
+		driver.FeedInPacked(data, cgra.North, [2]int{0, 2}, 2)
+
+		Expect(driver.feedInTasks).To(HaveLen(1))
+		Expect(driver.feedInTasks[0].data).To(Equal([]uint32{
+			0x00030001, 0x00040002, 0x00000005, 0x00000006,
+		}))
+		Expect(UnpackRounds(driver.feedInTasks[0].data, 2)).
+			To(Equal([]uint16{1, 2, 3, 4, 5, 6, 0, 0}))
+	})
+
+	It("should add halos to stencil inputs", func() {
+		data := []uint32{1, 2, 3, 4, 5, 6}
+
+		Expect(AddHalo(data, 2, 3, 1, HaloZero)).To(Equal([]uint32{
+			0, 0, 0, 0, 0,
+			0, 1, 2, 3, 0,
+			0, 4, 5, 6, 0,
+			0, 0, 0, 0, 0,
+		}))
+		Expect(AddHalo(data, 2, 3, 1, HaloReplicate)).To(Equal([]uint32{
+			1, 1, 2, 3, 3,
+			1, 1, 2, 3, 3,
+			4, 4, 5, 6, 6,
+			4, 4, 5, 6, 6,
+		}))
+		Expect(AddHalo(data, 2, 3, 1, HaloWrap)).To(Equal([]uint32{
+			6, 4, 5, 6, 4,
+			3, 1, 2, 3, 1,
+			6, 4, 5, 6, 4,
+			3, 1, 2, 3, 1,
+		}))
+	})
+
+	It("should feed stencil inputs with skew", func() {
+		driver.FeedInStencil(StencilInput{
+			Data: []uint32{1, 2, 3},
+			Rows: 1,
+			Cols: 3,
+			Halo: 1,
+			Mode: HaloWrap,
+			Skew: 1,
+		}, cgra.North, [2]int{0, 3})
+
+		Expect(driver.feedInTasks).To(HaveLen(1))
+		Expect(driver.feedInTasks[0].stride).To(Equal(3))
+		Expect(driver.feedInTasks[0].data).To(Equal([]uint32{
+			3, 0, 0,
+			1, 3, 0,
+			2, 1, 3,
+			3, 2, 1,
+			1, 3, 2,
+			0, 1, 3,
+			0, 0, 1,
+		}))
+		Expect(func() {
+			driver.FeedInStencil(StencilInput{Data: []uint32{1}, Rows: 1,
+				Cols: 1, Halo: 1}, cgra.North, [2]int{0, 2})
+		}).To(Panic())
+	})
+
+	It("should feed sparse vectors as index-value pairs", func() {
+		driver.FeedInSparse([][]SparsePair{
+			{{Index: 1, Value: 10}, {Index: 4, Value: 40}},
+			{{Index: 2, Value: 20}},
+		}, cgra.West, [2]int{0, 2})
+
+		Expect(driver.feedInTasks).To(HaveLen(1))
+		Expect(driver.feedInTasks[0].stride).To(Equal(2))
+		Expect(driver.feedInTasks[0].data).To(Equal([]uint32{
+			1, 2,
+			10, 20,
+			4, 0,
+			40, 0,
+		}))
+		Expect(func() {
+			driver.FeedInSparse([][]SparsePair{{}}, cgra.West, [2]int{0, 2})
+		}).To(Panic())
+	})
+
+	It("should convert floats to fixed-point words", func() {
+		words := ToFixed([]float32{0.5, -1, 0.00002, 1e6, -1e6}, 15)
+
+		Expect(words).To(Equal([]uint32{
+			0x4000, 0xffff8000, 1, 0x7fffffff, 0x80000000,
+		}))
+		Expect(FromFixed(words[:2], 15)).To(Equal([]float32{0.5, -1}))
+	})
+
+	It("should convert floats to half-precision bits", func() {
+		Expect(ToF16([]float32{1, -2, 65520, 0.1})).
+			To(Equal([]uint16{0x3c00, 0xc000, 0x7c00, 0x2e66}))
+		Expect(ToBF16([]float32{1, -2, 0.1})).
+			To(Equal([]uint16{0x3f80, 0xc000, 0x3dcd}))
+		Expect(FromF16([]uint16{0x3c00, 0x0001})).
+			To(Equal([]float32{1, 5.9604645e-08}))
+		Expect(FromBF16(WordHalves([]uint32{0x12343f80}))).
+			To(Equal([]float32{1}))
+		Expect(HalfWords([]uint16{0xc000})).To(Equal([]uint32{0xc000}))
+	})
+
+	It("should build a reduction tree over a region", func() {
+		programs, root := ReductionTree(1, 0, 3, 2, "REDUCE_ADD", "$1")
+
+		Expect(root).To(Equal([2]int{2, 0}))
+		Expect(programs).To(Equal(map[[2]int]string{
+			{1, 0}: "SEND, NET_SEND_1, $1",
+			{2, 0}: "REDUCE_ADD, $1, $1, 14",
+			{3, 0}: "SEND, NET_SEND_3, $1",
+			{1, 1}: "SEND, NET_SEND_1, $1",
+			{2, 1}: "REDUCE_ADD, $1, $1, 10\nSEND, NET_SEND_0, $1",
+			{3, 1}: "SEND, NET_SEND_3, $1",
+		}))
+		Expect(func() { ReductionTree(0, 0, 1, 1, "I_ADD", "$0") }).To(Panic())
+	})
+
+	It("should generate prefix-sum programs", func() {
+		p := GeneratePrefixSumProgram(4, 2, 2)
+
+		Expect(p.Rows).To(Equal(2))
+		Expect(p.Programs).To(HaveLen(4))
+		Expect(p.Programs[[2]int{1, 1}]).To(Equal(strings.Join([]string{
+			"WAIT, $0, NET_RECV_3",
+			"REDUCE_ADD, $0, $0, 8",
+			"WAIT, $1, NET_RECV_0",
+			"I_ADD, $0, $0, $1",
+			"SEND, NET_SEND_3, $1",
+			"SEND, NET_SEND_1, $0",
+			"FORWARD, NET_SEND_1, NET_RECV_3",
+			"DONE",
+		}, "\n")))
+		Expect(p.Input([]uint32{1, 2, 3, 4})).To(Equal([]uint32{1, 3, 2, 4}))
+		Expect(p.Output([]uint32{2, 4, 1, 3})).To(Equal([]uint32{1, 2, 3, 4}))
+		Expect(func() { GeneratePrefixSumProgram(5, 2, 4) }).To(Panic())
+		Expect(func() { GeneratePrefixSumProgram(6, 2, 2) }).To(Panic())
+	})
+
 	It("should handle Collect API", func() {
 		data := make([]uint32, 6)
 
@@ -191,6 +334,66 @@ var _ = Describe("Driver", func() {
 		Expect(driver.collectTasks).To(BeEmpty())
 		Expect(data).To(Equal([]uint32{1, 2, 3, 4, 5, 6}))
 	})
+
+	It("should record the validity of the collected tokens", func() {
+		localPort1 := portFactory.ports["Driver.DeviceNorth[0]"]
+		localPort2 := portFactory.ports["Driver.DeviceNorth[1]"]
+		data := []uint32{9, 9}
+		valid := make([]bool, 2)
+
+		driver.CollectWithValidity(data, valid, cgra.North, [2]int{0, 2}, 2)
+
+		invalid := cgra.MoveMsgBuilder{}.WithData(5).WithInvalid(true).Build()
+		localPort1.EXPECT().Peek().Return(invalid)
+		localPort1.EXPECT().Retrieve(gomock.Any()).Return(invalid)
+		expectPortsToRecv([]*MockPort{localPort2}, []uint32{7})
+
+		driver.Tick(0)
+
+		Expect(data).To(Equal([]uint32{9, 7}))
+		Expect(valid).To(Equal([]bool{false, true}))
+		Expect(func() {
+			driver.CollectWithValidity(data, nil, cgra.North, [2]int{0, 2}, 2)
+		}).To(Panic())
+	})
+
+	It("should feed in and collect again after a chained collect", func() {
+		localPort := portFactory.ports["Driver.DeviceNorth[0]"]
+		data := make([]uint32, 1)
+		checked := []uint32{}
+
+		driver.Chain(CollectTask{
+			Data:      data,
+			Side:      cgra.North,
+			PortRange: [2]int{0, 1},
+			Stride:    1,
+		}, func(data []uint32) (*FeedInTask, bool) {
+			checked = append(checked, data[0])
+			return &FeedInTask{
+				Data:      []uint32{data[0] + 1},
+				Side:      cgra.North,
+				PortRange: [2]int{0, 1},
+				Stride:    1,
+			}, data[0] < 2
+		})
+
+		expectPortsToRecv([]*MockPort{localPort}, []uint32{1})
+		driver.Tick(0)
+
+		Expect(checked).To(Equal([]uint32{1}))
+		Expect(driver.collectTasks).To(HaveLen(1))
+		Expect(driver.feedInTasks).To(HaveLen(1))
+		Expect(driver.feedInTasks[0].data).To(Equal([]uint32{2}))
+
+		localPort.EXPECT().CanSend().Return(true)
+		localPort.EXPECT().Send(gomock.Any())
+		expectPortsToRecv([]*MockPort{localPort}, []uint32{2})
+		driver.Tick(1)
+
+		Expect(checked).To(Equal([]uint32{1, 2}))
+		Expect(driver.collectTasks).To(BeEmpty())
+		Expect(driver.feedInTasks).To(HaveLen(1))
+	})
 })
 
 func expectPortsToSend(
@@ -0,0 +1,32 @@
+package api
+
+import "math"
+
+// ToFixed converts floats to signed 32-bit fixed-point words with frac
+// fraction bits, e.g., 15 for the Q15 format of FXMUL_Q15. The values are
+// rounded to the nearest and saturate at the limits of the format.
+func ToFixed(values []float32, frac int) []uint32 {
+	scale := math.Ldexp(1, frac)
+	words := make([]uint32, len(values))
+
+	for i, v := range values {
+		f := math.Round(float64(v) * scale)
+		f = math.Max(math.Min(f, math.MaxInt32), math.MinInt32)
+		words[i] = uint32(int32(f))
+	}
+
+	return words
+}
+
+// FromFixed converts signed fixed-point words with frac fraction bits back
+// to floats.
+func FromFixed(words []uint32, frac int) []float32 {
+	scale := math.Ldexp(1, -frac)
+	values := make([]float32, len(words))
+
+	for i, w := range words {
+		values[i] = float32(float64(int32(w)) * scale)
+	}
+
+	return values
+}
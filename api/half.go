@@ -0,0 +1,68 @@
+package api
+
+import "github.com/sarchlab/zeonica/core"
+
+// ToF16 converts floats to the binary16 bits that the F16 opcodes read,
+// rounded to nearest, ties to even. FeedInPacked feeds two values per
+// transfer, and PEs split the words with UNPACK.
+func ToF16(values []float32) []uint16 {
+	halves := make([]uint16, len(values))
+	for i, v := range values {
+		halves[i] = core.EncodeF16(v)
+	}
+
+	return halves
+}
+
+// FromF16 converts binary16 bits back to floats, e.g., the low halves of
+// the collected words or the values that UnpackRounds returns.
+func FromF16(halves []uint16) []float32 {
+	values := make([]float32, len(halves))
+	for i, h := range halves {
+		values[i] = core.DecodeF16(h)
+	}
+
+	return values
+}
+
+// ToBF16 is the same as ToF16, but converts to bfloat16.
+func ToBF16(values []float32) []uint16 {
+	halves := make([]uint16, len(values))
+	for i, v := range values {
+		halves[i] = core.EncodeBF16(v)
+	}
+
+	return halves
+}
+
+// FromBF16 is the same as FromF16, but converts from bfloat16.
+func FromBF16(halves []uint16) []float32 {
+	values := make([]float32, len(halves))
+	for i, h := range halves {
+		values[i] = core.DecodeBF16(h)
+	}
+
+	return values
+}
+
+// HalfWords returns the halves in the low bits of words, e.g., to feed one
+// value per transfer with FeedIn.
+func HalfWords(halves []uint16) []uint32 {
+	words := make([]uint32, len(halves))
+	for i, h := range halves {
+		words[i] = uint32(h)
+	}
+
+	return words
+}
+
+// WordHalves returns the low halves of words, e.g., of the data that
+// Collect received from F16 or BF16 instructions.
+func WordHalves(words []uint32) []uint16 {
+	halves := make([]uint16, len(words))
+	for i, w := range words {
+		halves[i] = uint16(w)
+	}
+
+	return halves
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// HostCommandRecord records when a host command is issued and completed.
+type HostCommandRecord struct {
+	Name         string
+	StartTime    sim.VTimeInSec
+	IssueTime    sim.VTimeInSec
+	CompleteTime sim.VTimeInSec
+}
+
+type hostCommand struct {
+	name    string
+	latency int
+	issue   func()
+	done    func() bool
+}
+
+// Host models a host CPU that offloads work to the accelerator through the
+// driver. The host executes a list of commands in order. Each command takes
+// a number of host cycles before it reaches the driver, so that the
+// end-to-end latency includes the host overhead.
+type Host struct {
+	*sim.TickingComponent
+
+	driver *driverImpl
+
+	launchLatency   int
+	transferLatency int
+
+	commands  []*hostCommand
+	records   []HostCommandRecord
+	started   bool
+	countdown int
+	issued    bool
+}
+
+// MapProgram adds a command that launches a program on a core.
+func (h *Host) MapProgram(program string, core [2]int) {
+	h.commands = append(h.commands, &hostCommand{
+		name:    "MapProgram",
+		latency: h.launchLatency,
+		issue:   func() { h.driver.MapProgram(program, core) },
+		done:    func() bool { return true },
+	})
+}
+
+// FeedIn adds a command that feeds data into the device. The command
+// completes when all the data is sent.
+func (h *Host) FeedIn(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	var task *feedInTask
+
+	h.commands = append(h.commands, &hostCommand{
+		name:    "FeedIn",
+		latency: h.transferLatency,
+		issue: func() {
+			h.driver.FeedIn(data, side, portRange, stride)
+			task = h.driver.feedInTasks[len(h.driver.feedInTasks)-1]
+		},
+		done: func() bool { return task.isFinished() },
+	})
+}
+
+// Collect adds a command that collects data from the device. The command
+// completes once it is issued; use Wait to wait for the data.
+func (h *Host) Collect(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	h.commands = append(h.commands, &hostCommand{
+		name:    "Collect",
+		latency: h.transferLatency,
+		issue: func() {
+			h.driver.Collect(data, side, portRange, stride)
+		},
+		done: func() bool { return true },
+	})
+}
+
+// Wait adds a command that completes when all the data transfers that have
+// been issued are finished.
+func (h *Host) Wait() {
+	h.commands = append(h.commands, &hostCommand{
+		name:  "Wait",
+		issue: func() {},
+		done: func() bool {
+			return len(h.driver.feedInTasks) == 0 &&
+				len(h.driver.collectTasks) == 0
+		},
+	})
+}
+
+// Run executes all the commands.
+func (h *Host) Run() {
+	h.TickNow(h.Engine.CurrentTime())
+
+	err := h.Engine.Run()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// GetCommandRecords returns the records of the commands that have completed.
+func (h *Host) GetCommandRecords() []HostCommandRecord {
+	return h.records
+}
+
+// GetEndToEndLatency returns the time from the start of the first command to
+// the completion of the last command.
+func (h *Host) GetEndToEndLatency() sim.VTimeInSec {
+	if len(h.records) == 0 {
+		return 0
+	}
+
+	return h.records[len(h.records)-1].CompleteTime - h.records[0].StartTime
+}
+
+// Tick advances the current command by one cycle.
+func (h *Host) Tick(now sim.VTimeInSec) (madeProgress bool) {
+	if len(h.commands) == 0 {
+		return false
+	}
+
+	cmd := h.commands[0]
+
+	switch {
+	case !h.started:
+		h.start(cmd, now)
+	case !h.issued:
+		h.countdown--
+	case cmd.done():
+		h.complete(now)
+		return true
+	default:
+		// Waiting for the driver, which wakes the host up when a task
+		// finishes.
+		return false
+	}
+
+	if !h.issued && h.countdown == 0 {
+		h.issue(cmd, now)
+	}
+
+	return true
+}
+
+func (h *Host) start(cmd *hostCommand, now sim.VTimeInSec) {
+	h.records = append(h.records, HostCommandRecord{
+		Name:      cmd.name,
+		StartTime: now,
+	})
+	h.started = true
+	h.countdown = cmd.latency
+}
+
+func (h *Host) issue(cmd *hostCommand, now sim.VTimeInSec) {
+	cmd.issue()
+	h.issued = true
+	h.records[len(h.records)-1].IssueTime = now
+	h.driver.TickLater(now)
+}
+
+func (h *Host) complete(now sim.VTimeInSec) {
+	h.records[len(h.records)-1].CompleteTime = now
+	h.commands = h.commands[1:]
+	h.started = false
+	h.issued = false
+}
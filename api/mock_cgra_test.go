@@ -4,6 +4,7 @@
 package api
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,34 @@ func (m *MockDevice) EXPECT() *MockDeviceMockRecorder {
 	return m.recorder
 }
 
+// DescribeTile mocks base method.
+func (m *MockDevice) DescribeTile(arg0, arg1 int) cgra.TileInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeTile", arg0, arg1)
+	ret0, _ := ret[0].(cgra.TileInfo)
+	return ret0
+}
+
+// DescribeTile indicates an expected call of DescribeTile.
+func (mr *MockDeviceMockRecorder) DescribeTile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTile", reflect.TypeOf((*MockDevice)(nil).DescribeTile), arg0, arg1)
+}
+
+// GetChannels mocks base method.
+func (m *MockDevice) GetChannels() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannels")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetChannels indicates an expected call of GetChannels.
+func (mr *MockDeviceMockRecorder) GetChannels() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannels", reflect.TypeOf((*MockDevice)(nil).GetChannels))
+}
+
 // GetSidePorts mocks base method.
 func (m *MockDevice) GetSidePorts(arg0 cgra.Side, arg1 [2]int) []sim.Port {
 	m.ctrl.T.Helper()
@@ -77,6 +106,20 @@ func (mr *MockDeviceMockRecorder) GetTile(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTile", reflect.TypeOf((*MockDevice)(nil).GetTile), arg0, arg1)
 }
 
+// GetTileCoords mocks base method.
+func (m *MockDevice) GetTileCoords() [][2]int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTileCoords")
+	ret0, _ := ret[0].([][2]int)
+	return ret0
+}
+
+// GetTileCoords indicates an expected call of GetTileCoords.
+func (mr *MockDeviceMockRecorder) GetTileCoords() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTileCoords", reflect.TypeOf((*MockDevice)(nil).GetTileCoords))
+}
+
 // MockTile is a mock of Tile interface.
 type MockTile struct {
 	ctrl     *gomock.Controller
@@ -100,6 +143,90 @@ func (m *MockTile) EXPECT() *MockTileMockRecorder {
 	return m.recorder
 }
 
+// CheckProgram mocks base method.
+func (m *MockTile) CheckProgram(arg0 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckProgram", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckProgram indicates an expected call of CheckProgram.
+func (mr *MockTileMockRecorder) CheckProgram(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckProgram", reflect.TypeOf((*MockTile)(nil).CheckProgram), arg0)
+}
+
+// GetActivityStats mocks base method.
+func (m *MockTile) GetActivityStats() cgra.ActivityStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivityStats")
+	ret0, _ := ret[0].(cgra.ActivityStats)
+	return ret0
+}
+
+// GetActivityStats indicates an expected call of GetActivityStats.
+func (mr *MockTileMockRecorder) GetActivityStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivityStats", reflect.TypeOf((*MockTile)(nil).GetActivityStats))
+}
+
+// GetChannelPort mocks base method.
+func (m *MockTile) GetChannelPort(arg0 cgra.Side, arg1 int) sim.Port {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChannelPort", arg0, arg1)
+	ret0, _ := ret[0].(sim.Port)
+	return ret0
+}
+
+// GetChannelPort indicates an expected call of GetChannelPort.
+func (mr *MockTileMockRecorder) GetChannelPort(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChannelPort", reflect.TypeOf((*MockTile)(nil).GetChannelPort), arg0, arg1)
+}
+
+// GetCounters mocks base method.
+func (m *MockTile) GetCounters() cgra.Counters {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCounters")
+	ret0, _ := ret[0].(cgra.Counters)
+	return ret0
+}
+
+// GetCounters indicates an expected call of GetCounters.
+func (mr *MockTileMockRecorder) GetCounters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCounters", reflect.TypeOf((*MockTile)(nil).GetCounters))
+}
+
+// GetError mocks base method.
+func (m *MockTile) GetError() *cgra.ErrorRecord {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetError")
+	ret0, _ := ret[0].(*cgra.ErrorRecord)
+	return ret0
+}
+
+// GetError indicates an expected call of GetError.
+func (mr *MockTileMockRecorder) GetError() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetError", reflect.TypeOf((*MockTile)(nil).GetError))
+}
+
+// GetFreq mocks base method.
+func (m *MockTile) GetFreq() sim.Freq {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFreq")
+	ret0, _ := ret[0].(sim.Freq)
+	return ret0
+}
+
+// GetFreq indicates an expected call of GetFreq.
+func (mr *MockTileMockRecorder) GetFreq() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFreq", reflect.TypeOf((*MockTile)(nil).GetFreq))
+}
+
 // GetPort mocks base method.
 func (m *MockTile) GetPort(arg0 cgra.Side) sim.Port {
 	m.ctrl.T.Helper()
@@ -114,6 +241,63 @@ func (mr *MockTileMockRecorder) GetPort(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPort", reflect.TypeOf((*MockTile)(nil).GetPort), arg0)
 }
 
+// GetPortStats mocks base method.
+func (m *MockTile) GetPortStats(arg0 cgra.Side) cgra.PortStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortStats", arg0)
+	ret0, _ := ret[0].(cgra.PortStats)
+	return ret0
+}
+
+// GetPortStats indicates an expected call of GetPortStats.
+func (mr *MockTileMockRecorder) GetPortStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortStats", reflect.TypeOf((*MockTile)(nil).GetPortStats), arg0)
+}
+
+// GetRetVal mocks base method.
+func (m *MockTile) GetRetVal() (uint32, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRetVal")
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetRetVal indicates an expected call of GetRetVal.
+func (mr *MockTileMockRecorder) GetRetVal() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRetVal", reflect.TypeOf((*MockTile)(nil).GetRetVal))
+}
+
+// GetState mocks base method.
+func (m *MockTile) GetState() cgra.TileState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetState")
+	ret0, _ := ret[0].(cgra.TileState)
+	return ret0
+}
+
+// GetState indicates an expected call of GetState.
+func (mr *MockTileMockRecorder) GetState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetState", reflect.TypeOf((*MockTile)(nil).GetState))
+}
+
+// IsDone mocks base method.
+func (m *MockTile) IsDone() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDone")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDone indicates an expected call of IsDone.
+func (mr *MockTileMockRecorder) IsDone() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDone", reflect.TypeOf((*MockTile)(nil).IsDone))
+}
+
 // MapProgram mocks base method.
 func (m *MockTile) MapProgram(arg0 []string) {
 	m.ctrl.T.Helper()
@@ -126,6 +310,108 @@ func (mr *MockTileMockRecorder) MapProgram(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapProgram", reflect.TypeOf((*MockTile)(nil).MapProgram), arg0)
 }
 
+// ReadMemory mocks base method.
+func (m *MockTile) ReadMemory(arg0, arg1 int) []uint32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadMemory", arg0, arg1)
+	ret0, _ := ret[0].([]uint32)
+	return ret0
+}
+
+// ReadMemory indicates an expected call of ReadMemory.
+func (mr *MockTileMockRecorder) ReadMemory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadMemory", reflect.TypeOf((*MockTile)(nil).ReadMemory), arg0, arg1)
+}
+
+// ReadRegister mocks base method.
+func (m *MockTile) ReadRegister(arg0 int) uint32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRegister", arg0)
+	ret0, _ := ret[0].(uint32)
+	return ret0
+}
+
+// ReadRegister indicates an expected call of ReadRegister.
+func (mr *MockTileMockRecorder) ReadRegister(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRegister", reflect.TypeOf((*MockTile)(nil).ReadRegister), arg0)
+}
+
+// RestoreContext mocks base method.
+func (m *MockTile) RestoreContext(arg0 cgra.TileContext) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RestoreContext", arg0)
+}
+
+// RestoreContext indicates an expected call of RestoreContext.
+func (mr *MockTileMockRecorder) RestoreContext(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreContext", reflect.TypeOf((*MockTile)(nil).RestoreContext), arg0)
+}
+
+// SaveContext mocks base method.
+func (m *MockTile) SaveContext() cgra.TileContext {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveContext")
+	ret0, _ := ret[0].(cgra.TileContext)
+	return ret0
+}
+
+// SaveContext indicates an expected call of SaveContext.
+func (mr *MockTileMockRecorder) SaveContext() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveContext", reflect.TypeOf((*MockTile)(nil).SaveContext))
+}
+
+// SetChannelRemotePort mocks base method.
+func (m *MockTile) SetChannelRemotePort(arg0 cgra.Side, arg1 int, arg2 sim.Port) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetChannelRemotePort", arg0, arg1, arg2)
+}
+
+// SetChannelRemotePort indicates an expected call of SetChannelRemotePort.
+func (mr *MockTileMockRecorder) SetChannelRemotePort(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannelRemotePort", reflect.TypeOf((*MockTile)(nil).SetChannelRemotePort), arg0, arg1, arg2)
+}
+
+// SetHalted mocks base method.
+func (m *MockTile) SetHalted(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHalted", arg0)
+}
+
+// SetHalted indicates an expected call of SetHalted.
+func (mr *MockTileMockRecorder) SetHalted(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHalted", reflect.TypeOf((*MockTile)(nil).SetHalted), arg0)
+}
+
+// SetKernelArg mocks base method.
+func (m *MockTile) SetKernelArg(arg0 int, arg1 uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetKernelArg", arg0, arg1)
+}
+
+// SetKernelArg indicates an expected call of SetKernelArg.
+func (mr *MockTileMockRecorder) SetKernelArg(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKernelArg", reflect.TypeOf((*MockTile)(nil).SetKernelArg), arg0, arg1)
+}
+
+// SetRandomSeed mocks base method.
+func (m *MockTile) SetRandomSeed(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRandomSeed", arg0)
+}
+
+// SetRandomSeed indicates an expected call of SetRandomSeed.
+func (mr *MockTileMockRecorder) SetRandomSeed(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRandomSeed", reflect.TypeOf((*MockTile)(nil).SetRandomSeed), arg0)
+}
+
 // SetRemotePort mocks base method.
 func (m *MockTile) SetRemotePort(arg0 cgra.Side, arg1 sim.Port) {
 	m.ctrl.T.Helper()
@@ -137,3 +423,63 @@ func (mr *MockTileMockRecorder) SetRemotePort(arg0, arg1 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRemotePort", reflect.TypeOf((*MockTile)(nil).SetRemotePort), arg0, arg1)
 }
+
+// SetSchedule mocks base method.
+func (m *MockTile) SetSchedule(arg0 []int, arg1 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSchedule", arg0, arg1)
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockTileMockRecorder) SetSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockTile)(nil).SetSchedule), arg0, arg1)
+}
+
+// Watch mocks base method.
+func (m *MockTile) Watch(arg0 cgra.Watchpoint, arg1 func(cgra.WatchHit)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Watch", arg0, arg1)
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockTileMockRecorder) Watch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockTile)(nil).Watch), arg0, arg1)
+}
+
+// WriteMemory mocks base method.
+func (m *MockTile) WriteMemory(arg0 int, arg1 []uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WriteMemory", arg0, arg1)
+}
+
+// WriteMemory indicates an expected call of WriteMemory.
+func (mr *MockTileMockRecorder) WriteMemory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteMemory", reflect.TypeOf((*MockTile)(nil).WriteMemory), arg0, arg1)
+}
+
+// WriteRegister mocks base method.
+func (m *MockTile) WriteRegister(arg0 int, arg1 uint32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WriteRegister", arg0, arg1)
+}
+
+// WriteRegister indicates an expected call of WriteRegister.
+func (mr *MockTileMockRecorder) WriteRegister(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteRegister", reflect.TypeOf((*MockTile)(nil).WriteRegister), arg0, arg1)
+}
+
+// WriteState mocks base method.
+func (m *MockTile) WriteState(arg0 io.Writer) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WriteState", arg0)
+}
+
+// WriteState indicates an expected call of WriteState.
+func (mr *MockTileMockRecorder) WriteState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteState", reflect.TypeOf((*MockTile)(nil).WriteState), arg0)
+}
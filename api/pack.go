@@ -0,0 +1,52 @@
+package api
+
+import "github.com/sarchlab/zeonica/cgra"
+
+// PackRounds packs the 16-bit data of two consecutive rounds into one round
+// of 32-bit words, so that each transfer carries two values. The data is
+// laid out as for FeedIn, with stride values per round. The value of the
+// even round goes to the low half of the word and the value of the odd
+// round to the high half. The high halves of the last round are 0 if the
+// number of rounds is odd. PEs split the words with UNPACK.
+func PackRounds(data []uint16, stride int) []uint32 {
+	rounds := len(data) / stride
+	words := make([]uint32, (rounds+1)/2*stride)
+
+	for r := 0; r < rounds; r++ {
+		shift := uint(16 * (r % 2))
+		for i := 0; i < stride; i++ {
+			words[r/2*stride+i] |= uint32(data[r*stride+i]) << shift
+		}
+	}
+
+	return words
+}
+
+// UnpackRounds reverses PackRounds. Each round of words becomes two rounds
+// of 16-bit values, e.g., for the data that PEs packed with PACK before
+// sending it to Collect.
+func UnpackRounds(words []uint32, stride int) []uint16 {
+	data := make([]uint16, 2*len(words))
+
+	for j, w := range words {
+		r, i := j/stride, j%stride
+		data[2*r*stride+i] = uint16(w)
+		data[(2*r+1)*stride+i] = uint16(w >> 16)
+	}
+
+	return data
+}
+
+// FeedInPacked feeds 16-bit data with two values per transfer, which halves
+// the number of rounds. See PackRounds for the layout.
+func (d *driverImpl) FeedInPacked(
+	data []uint16,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("FeedInPacked", data, side, portRange, stride))
+
+	d.FeedIn(PackRounds(data, stride), side, portRange, stride)
+}
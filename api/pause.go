@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+// pauseEvent stops the simulation at the end of a cycle. It is a secondary
+// event, so that it runs after all the ticks of the cycle.
+type pauseEvent struct {
+	*sim.EventBase
+}
+
+func (pauseEvent) IsSecondary() bool {
+	return true
+}
+
+// pauser runs the engine in its own goroutine while the driver has pauses,
+// so that Run can return to the host at a pause and continue from there.
+type pauser struct {
+	pending int
+	running bool
+
+	paused chan struct{}
+	resume chan struct{}
+
+	// done carries the value that the engine panicked with, or nil if the
+	// simulation finished.
+	done chan interface{}
+}
+
+// Handle blocks the engine at a pause until the host runs again.
+func (p *pauser) Handle(_ sim.Event) error {
+	p.pending--
+	p.paused <- struct{}{}
+	<-p.resume
+
+	return nil
+}
+
+func (p *pauser) runEngine(engine sim.Engine) {
+	var failure interface{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			failure = r
+		}
+
+		p.done <- failure
+	}()
+
+	err := engine.Run()
+	if err != nil {
+		failure = err
+	}
+}
+
+// PauseAt makes Run return after the tiles have run the cycle, so that the
+// host can inspect and patch the state of the tiles before it runs again.
+func (d *driverImpl) PauseAt(cycle uint64) {
+	defer d.recorder.end(d.recorder.begin("PauseAt", cycle))
+
+	now := d.Engine.CurrentTime()
+	if cycle < d.Freq.Cycle(now) {
+		panic(fmt.Sprintf("cannot pause at cycle %d, which has passed", cycle))
+	}
+
+	d.pauser.expect()
+	t := sim.VTimeInSec(cycle) * d.Freq.Period()
+	d.Engine.Schedule(pauseEvent{sim.NewEventBase(t, &d.pauser)})
+}
+
+// expect counts a pause that is to come, so that Run runs the engine in its
+// own goroutine.
+func (p *pauser) expect() {
+	if p.paused == nil {
+		p.paused = make(chan struct{})
+		p.resume = make(chan struct{})
+		p.done = make(chan interface{})
+	}
+
+	p.pending++
+}
+
+// IsPaused returns true if the last Run returned at a pause.
+func (d *driverImpl) IsPaused() bool {
+	return d.pauser.running
+}
+
+// Run runs all the tasks in the driver until the simulation finishes or
+// reaches a pause.
+func (d *driverImpl) Run() {
+	defer d.recorder.end(d.recorder.begin("Run"))
+
+	p := &d.pauser
+	d.TickNow(d.Engine.CurrentTime())
+
+	if p.running {
+		p.resume <- struct{}{}
+	} else if p.pending > 0 {
+		p.running = true
+		go p.runEngine(d.Engine)
+	} else {
+		err := d.Engine.Run()
+		if err != nil {
+			panic(err)
+		}
+
+		return
+	}
+
+	select {
+	case <-p.paused:
+	case failure := <-p.done:
+		p.running = false
+		if failure != nil {
+			panic(failure)
+		}
+	}
+}
+
+// ReadRegister returns the value of a register of a core.
+func (d *driverImpl) ReadRegister(core [2]int, reg int) uint32 {
+	call := d.recorder.begin("ReadRegister", core, reg)
+	defer d.recorder.end(call)
+
+	value := d.registerTile(core).ReadRegister(reg)
+	d.recorder.setResult(call, value)
+
+	return value
+}
+
+// WriteRegister sets the value of a register of a core.
+func (d *driverImpl) WriteRegister(core [2]int, reg int, value uint32) {
+	defer d.recorder.end(d.recorder.begin("WriteRegister", core, reg, value))
+
+	d.registerTile(core).WriteRegister(reg, value)
+}
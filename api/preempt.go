@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// preemption is a region whose kernel is swapped out for another kernel.
+// The contexts of the tiles are saved once the region has drained, and are
+// restored once Restore is called and the other kernel has finished.
+type preemption struct {
+	region    Region
+	programs  map[[2]int]string
+	contexts  map[[2]int]cgra.TileContext
+	restoring bool
+}
+
+// Preempt swaps the kernel that runs in a region for another one.
+func (d *driverImpl) Preempt(region Region, programs map[[2]int]string) {
+	defer d.recorder.end(
+		d.recorder.begin("Preempt", region, programList(programs)))
+
+	for coord := range programs {
+		if !region.Contains(coord[0], coord[1]) {
+			panic(fmt.Sprintf("cannot preempt the region, "+
+				"tile (%d, %d) is outside of it", coord[0], coord[1]))
+		}
+	}
+
+	for _, p := range d.preemptions {
+		if p.region.overlaps(region) {
+			panic("the region overlaps a region that is already preempted")
+		}
+	}
+
+	d.forRegionTiles(region, func(_ [2]int, tile cgra.Tile) {
+		tile.SetHalted(true)
+	})
+
+	d.preemptions = append(d.preemptions,
+		&preemption{region: region, programs: programs})
+
+	d.TickLater(d.Engine.CurrentTime())
+}
+
+// Restore brings back the kernel that Preempt has swapped out of the region.
+func (d *driverImpl) Restore(region Region) {
+	defer d.recorder.end(d.recorder.begin("Restore", region))
+
+	for _, p := range d.preemptions {
+		if p.region == region {
+			p.restoring = true
+			d.TickLater(d.Engine.CurrentTime())
+
+			return
+		}
+	}
+
+	panic(fmt.Sprintf("region %+v is not preempted", region))
+}
+
+// doPreemptions swaps the kernels of the preempted regions that are ready.
+// It makes progress as long as any region waits to be swapped, so that the
+// driver keeps ticking.
+func (d *driverImpl) doPreemptions() bool {
+	waiting := false
+
+	for i := 0; i < len(d.preemptions); i++ {
+		p := d.preemptions[i]
+
+		switch {
+		case p.contexts == nil && d.regionDrained(p.region):
+			d.swapOut(p)
+		case p.contexts == nil:
+			waiting = true
+		case p.restoring && d.regionFinished(p.region):
+			d.swapIn(p)
+			d.preemptions = append(d.preemptions[:i], d.preemptions[i+1:]...)
+			i--
+		case p.restoring:
+			waiting = true
+		}
+	}
+
+	return waiting
+}
+
+// regionDrained returns true if the tokens that the tiles of the region
+// have sent to their neighbors have arrived, and no tile of the region is
+// loading a program.
+func (d *driverImpl) regionDrained(region Region) bool {
+	drained := true
+	device := d.getDevice(0)
+
+	d.forRegionTiles(region, func(coord [2]int, tile cgra.Tile) {
+		if d.isLoading(tile) {
+			drained = false
+		}
+
+		for side := cgra.North; side <= cgra.West; side++ {
+			n := neighborTile(device, coord[0], coord[1], side)
+			if n != nil && inFlight(tile, n, side) {
+				drained = false
+			}
+		}
+	})
+
+	return drained
+}
+
+// regionFinished returns true if the tiles of the region have finished
+// their programs and sent their tokens.
+func (d *driverImpl) regionFinished(region Region) bool {
+	finished := true
+
+	d.forRegionTiles(region, func(_ [2]int, tile cgra.Tile) {
+		if d.reconfigHazard(tile) != "" {
+			finished = false
+		}
+	})
+
+	return finished
+}
+
+// inFlight returns true if a token that the tile has sent through the side
+// has not arrived at the neighbor on that side.
+func inFlight(tile, neighbor cgra.Tile, side cgra.Side) bool {
+	return tile.GetPortStats(side).Sent !=
+		neighbor.GetPortStats(side.Opposite()).Received
+}
+
+func (d *driverImpl) swapOut(p *preemption) {
+	p.contexts = make(map[[2]int]cgra.TileContext)
+
+	d.forRegionTiles(p.region, func(coord [2]int, tile cgra.Tile) {
+		p.contexts[coord] = tile.SaveContext()
+		tile.SetHalted(false)
+	})
+
+	for _, coord := range sortedCoords(p.programs) {
+		d.MapProgram(p.programs[coord], coord)
+	}
+}
+
+func (d *driverImpl) swapIn(p *preemption) {
+	d.forRegionTiles(p.region, func(coord [2]int, tile cgra.Tile) {
+		tile.RestoreContext(p.contexts[coord])
+	})
+}
+
+// forRegionTiles calls f with the tiles of the region of the first device
+// that are not disabled.
+func (d *driverImpl) forRegionTiles(
+	region Region,
+	f func(coord [2]int, tile cgra.Tile),
+) {
+	device := d.getDevice(0)
+	width, height := device.GetSize()
+
+	for y := region.Y; y < region.Y+region.Height && y < height; y++ {
+		for x := region.X; x < region.X+region.Width && x < width; x++ {
+			if tile := device.GetTile(x, y); tile != nil {
+				f([2]int{x, y}, tile)
+			}
+		}
+	}
+}
+
+func (r Region) overlaps(o Region) bool {
+	return r.X < o.X+o.Width && o.X < r.X+r.Width &&
+		r.Y < o.Y+o.Height && o.Y < r.Y+r.Height
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// PrefixSum is a generated prefix-sum kernel. The PEs in the first Rows rows
+// of the grid hold one value each, in the row-major order.
+type PrefixSum struct {
+	Programs map[[2]int]string
+	Width    int
+	Rows     int
+}
+
+// GeneratePrefixSumProgram generates the programs that compute the inclusive
+// prefix sum of n values on a grid of gridW x gridH PEs. The n values fill
+// n / gridW rows, so n must be a multiple of gridW. The values enter each row
+// from the West side and the sums leave it from the East side.
+//
+// Each row first scans its values in log2(gridW) steps. In the step with
+// distance d, a PE shifts its partial sum d PEs to the East, and the PEs in
+// between FORWARD it. The last column then accumulates the totals of the
+// rows from the North to the South, and each row adds the total of the rows
+// above it.
+func GeneratePrefixSumProgram(n, gridW, gridH int) PrefixSum {
+	if gridW <= 0 || n <= 0 || n%gridW != 0 {
+		panic(fmt.Sprintf("cannot lay %d values out in rows of %d PEs",
+			n, gridW))
+	}
+
+	p := PrefixSum{
+		Programs: make(map[[2]int]string),
+		Width:    gridW,
+		Rows:     n / gridW,
+	}
+
+	if p.Rows > gridH {
+		panic(fmt.Sprintf("%d values need %d rows, but the grid has %d",
+			n, p.Rows, gridH))
+	}
+
+	for y := 0; y < p.Rows; y++ {
+		for x := 0; x < gridW; x++ {
+			lines := p.loadCode(x)
+			lines = append(lines, p.scanCode(x)...)
+			lines = append(lines, p.offsetCode(x, y)...)
+			lines = append(lines, p.storeCode(x)...)
+
+			p.Programs[[2]int{x, y}] = strings.Join(lines, "\n")
+		}
+	}
+
+	return p
+}
+
+// loadCode keeps the first value from the West and forwards the values of
+// the PEs to the East.
+func (p PrefixSum) loadCode(x int) []string {
+	lines := []string{"WAIT, $0, NET_RECV_3"}
+
+	for i := x + 1; i < p.Width; i++ {
+		lines = append(lines, "FORWARD, NET_SEND_1, NET_RECV_3")
+	}
+
+	return lines
+}
+
+// scanCode computes the prefix sum of the row. In the step with distance d,
+// the partial sum of the PE at s travels East to the PE at s + d. The partial
+// sums from the West arrive in the order of the distance, so the last one is
+// the one to add.
+func (p PrefixSum) scanCode(x int) []string {
+	lines := []string{}
+
+	for d := 1; d < p.Width; d *= 2 {
+		if x+d < p.Width {
+			lines = append(lines, "SEND, NET_SEND_1, $0")
+		}
+
+		for s := x - 1; s >= 0 && s >= x-d; s-- {
+			switch {
+			case s+d >= p.Width:
+				continue
+			case s == x-d:
+				lines = append(lines, "REDUCE_ADD, $0, $0, 8")
+			default:
+				lines = append(lines, "FORWARD, NET_SEND_1, NET_RECV_3")
+			}
+		}
+	}
+
+	return lines
+}
+
+// offsetCode adds the total of the rows above. The last column passes the
+// running total to the South and the offset of the row to the West.
+func (p PrefixSum) offsetCode(x, y int) []string {
+	last := x == p.Width-1
+
+	switch {
+	case y == 0 && last && p.Rows > 1:
+		return []string{"SEND, NET_SEND_2, $0"}
+	case y == 0:
+		return nil
+	case last:
+		lines := []string{"WAIT, $1, NET_RECV_0", "I_ADD, $0, $0, $1"}
+		if y < p.Rows-1 {
+			lines = append(lines, "SEND, NET_SEND_2, $0")
+		}
+
+		if x > 0 {
+			lines = append(lines, "SEND, NET_SEND_3, $1")
+		}
+
+		return lines
+	}
+
+	lines := []string{"WAIT, $1, NET_RECV_1"}
+	if x > 0 {
+		lines = append(lines, "SEND, NET_SEND_3, $1")
+	}
+
+	return append(lines, "I_ADD, $0, $0, $1")
+}
+
+// storeCode sends the sum to the East, followed by the sums of the PEs to
+// the West.
+func (p PrefixSum) storeCode(x int) []string {
+	lines := []string{"SEND, NET_SEND_1, $0"}
+
+	for i := 0; i < x; i++ {
+		lines = append(lines, "FORWARD, NET_SEND_1, NET_RECV_3")
+	}
+
+	return append(lines, "DONE")
+}
+
+// Input lays the values out for FeedIn on the West side, with the ports
+// [0, Rows) and a stride of Rows.
+func (p PrefixSum) Input(values []uint32) []uint32 {
+	data := make([]uint32, len(values))
+
+	for y := 0; y < p.Rows; y++ {
+		for r := 0; r < p.Width; r++ {
+			data[r*p.Rows+y] = values[y*p.Width+r]
+		}
+	}
+
+	return data
+}
+
+// Output reorders the data that Collect receives on the East side, with the
+// ports [0, Rows) and a stride of Rows, into the prefix sums. The sums of
+// each row arrive from the East to the West.
+func (p PrefixSum) Output(data []uint32) []uint32 {
+	sums := make([]uint32, len(data))
+
+	for y := 0; y < p.Rows; y++ {
+		for r := 0; r < p.Width; r++ {
+			sums[y*p.Width+p.Width-1-r] = data[r*p.Rows+y]
+		}
+	}
+
+	return sums
+}
+
+// Run maps the programs, feeds the values in, runs the driver, and returns
+// the prefix sums.
+func (p PrefixSum) Run(driver Driver, values []uint32) []uint32 {
+	ports := [2]int{0, p.Rows}
+	data := make([]uint32, len(values))
+
+	driver.FeedIn(p.Input(values), cgra.West, ports, p.Rows)
+	driver.Collect(data, cgra.East, ports, p.Rows)
+
+	for _, coord := range sortedCoords(p.Programs) {
+		driver.MapProgram(p.Programs[coord], coord)
+	}
+
+	driver.Run()
+
+	return p.Output(data)
+}
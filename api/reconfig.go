@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Region is a rectangular region of tiles, where (X, Y) is the coordinate of
+// the top-left tile.
+type Region struct {
+	X, Y, Width, Height int
+}
+
+// Contains returns true if the tile at (x, y) is in the region.
+func (r Region) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Reconfigure maps programs to the tiles of a region while the other tiles
+// keep running.
+func (d *driverImpl) Reconfigure(region Region, programs map[[2]int]string) {
+	defer d.recorder.end(
+		d.recorder.begin("Reconfigure", region, programList(programs)))
+
+	hazards := []string{}
+	for _, coord := range sortedCoords(programs) {
+		if !region.Contains(coord[0], coord[1]) {
+			hazards = append(hazards, fmt.Sprintf(
+				"tile (%d, %d) is outside of the region", coord[0], coord[1]))
+		}
+	}
+
+	d.forRegionTiles(region, func(coord [2]int, tile cgra.Tile) {
+		if h := d.reconfigHazard(tile); h != "" {
+			hazards = append(hazards,
+				fmt.Sprintf("tile (%d, %d) %s", coord[0], coord[1], h))
+		}
+	})
+
+	if len(hazards) > 0 {
+		panic("cannot reconfigure the region:\n" +
+			strings.Join(hazards, "\n"))
+	}
+
+	for _, coord := range sortedCoords(programs) {
+		d.MapProgram(programs[coord], coord)
+	}
+}
+
+// reconfigHazard returns why the tile cannot be reconfigured now, or an
+// empty string if it can.
+func (d *driverImpl) reconfigHazard(tile cgra.Tile) string {
+	if !tile.IsDone() {
+		return "is still running its program"
+	}
+
+	if d.isLoading(tile) {
+		return "is still loading a program"
+	}
+
+	if isSending(tile) {
+		return "is still sending"
+	}
+
+	return ""
+}
+
+// isLoading returns true if the configuration bus is loading a program into
+// the tile.
+func (d *driverImpl) isLoading(tile cgra.Tile) bool {
+	for _, load := range d.configLoads {
+		if load.tile == tile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSending returns true if a NET_SEND register of the tile holds a token
+// that has not been sent.
+func isSending(tile cgra.Tile) bool {
+	for _, busy := range tile.GetState().SendBusy {
+		if busy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedCoords returns the coordinates of the programs, row by row.
+func sortedCoords(programs map[[2]int]string) [][2]int {
+	coords := make([][2]int, 0, len(programs))
+	for coord := range programs {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	return coords
+}
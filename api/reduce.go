@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// ReductionTree builds the programs that reduce the register reg of all the
+// PEs in the region of width x height PEs whose top-left PE is at (x, y).
+// The op is REDUCE_ADD, REDUCE_MIN, or REDUCE_MAX. The values flow along
+// each row to the middle column and then along the middle column to the
+// middle PE, which is returned as the root and ends with the result in reg.
+// Each PE combines the values of all its children with one REDUCE
+// instruction. The programs are fragments to append to the programs that
+// compute the values, e.g., the root can append RETURN_VALUE.
+func ReductionTree(
+	x, y, width, height int,
+	op, reg string,
+) (map[[2]int]string, [2]int) {
+	switch op {
+	case "REDUCE_ADD", "REDUCE_MIN", "REDUCE_MAX":
+	default:
+		panic(fmt.Sprintf("invalid reduction %s", op))
+	}
+
+	if width <= 0 || height <= 0 {
+		panic("the region of the reduction is empty")
+	}
+
+	root := [2]int{x + (width-1)/2, y + (height-1)/2}
+	programs := make(map[[2]int]string)
+
+	for py := y; py < y+height; py++ {
+		for px := x; px < x+width; px++ {
+			coord := [2]int{px, py}
+			lines := []string{}
+
+			mask := childMask(coord, root, x, y, width, height)
+			if mask != 0 {
+				lines = append(lines,
+					fmt.Sprintf("%s, %s, %s, %d", op, reg, reg, mask))
+			}
+
+			if coord != root {
+				lines = append(lines, fmt.Sprintf("SEND, NET_SEND_%d, %s",
+					parentSide(coord, root), reg))
+			}
+
+			programs[coord] = strings.Join(lines, "\n")
+		}
+	}
+
+	return programs, root
+}
+
+// parentSide returns the side of the PE that the PE sends its partial
+// result to.
+func parentSide(coord, root [2]int) cgra.Side {
+	switch {
+	case coord[0] < root[0]:
+		return cgra.East
+	case coord[0] > root[0]:
+		return cgra.West
+	case coord[1] < root[1]:
+		return cgra.South
+	default:
+		return cgra.North
+	}
+}
+
+// childMask returns the mask of the sides that the children of the PE
+// send their partial results from.
+func childMask(coord, root [2]int, x, y, width, height int) int {
+	mask := 0
+
+	if coord[0] <= root[0] && coord[0] > x {
+		mask |= 1 << cgra.West
+	}
+
+	if coord[0] >= root[0] && coord[0] < x+width-1 {
+		mask |= 1 << cgra.East
+	}
+
+	if coord[0] != root[0] {
+		return mask
+	}
+
+	if coord[1] <= root[1] && coord[1] > y {
+		mask |= 1 << cgra.North
+	}
+
+	if coord[1] >= root[1] && coord[1] < y+height-1 {
+		mask |= 1 << cgra.South
+	}
+
+	return mask
+}
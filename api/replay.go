@@ -0,0 +1,763 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Recording is the content of a replay file. It has the calls to the driver
+// API in order, and the data that the driver has fed into and collected
+// from the devices.
+type Recording struct {
+	Seed      int64      `json:"seed"`
+	Calls     []Call     `json:"calls"`
+	Transfers []Transfer `json:"transfers"`
+}
+
+// Call is a call to the driver API. The calls that other calls make, e.g.,
+// the FeedIn of a FeedInAt, are not recorded. A call to a stream is
+// recorded as Stream.<method>, with the index of the stream as the first
+// argument, and the event of a WaitEvent is the index of the event among
+// those that the streams have recorded. Result is the return value of a
+// read, e.g., ReadMemory.
+type Call struct {
+	Method string            `json:"method"`
+	Args   []json.RawMessage `json:"args"`
+	Result json.RawMessage   `json:"result,omitempty"`
+}
+
+// Transfer is a word that crosses the boundary of a device, in the cycle of
+// the driver, through a port of the driver.
+type Transfer struct {
+	Cycle   uint64 `json:"cycle"`
+	Port    string `json:"port"`
+	Collect bool   `json:"collect,omitempty"`
+	Data    uint32 `json:"data"`
+	Invalid bool   `json:"invalid,omitempty"`
+}
+
+func (t Transfer) String() string {
+	dir := "fed"
+	if t.Collect {
+		dir = "collected"
+	}
+
+	s := fmt.Sprintf("%d %s at %s in cycle %d", t.Data, dir, t.Port, t.Cycle)
+	if t.Invalid {
+		s += " (invalid)"
+	}
+
+	return s
+}
+
+// recorder records the calls and the transfers of a driver. depth counts
+// the calls in progress, so that only the outermost call is recorded.
+type recorder struct {
+	rec   Recording
+	depth int
+}
+
+// begin records a call, unless it comes from another call, and returns the
+// index of the recorded call or -1. It does nothing on a nil recorder.
+func (r *recorder) begin(method string, args ...interface{}) int {
+	if r == nil {
+		return -1
+	}
+
+	r.depth++
+	if r.depth > 1 {
+		return -1
+	}
+
+	c := Call{Method: method}
+
+	for _, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			panic(fmt.Sprintf("cannot record %s: %v", method, err))
+		}
+
+		c.Args = append(c.Args, data)
+	}
+
+	r.rec.Calls = append(r.rec.Calls, c)
+
+	return len(r.rec.Calls) - 1
+}
+
+// end ends the call that begin has started.
+func (r *recorder) end(int) {
+	if r != nil {
+		r.depth--
+	}
+}
+
+// setResult records the return value of the call that begin has recorded.
+func (r *recorder) setResult(call int, v interface{}) {
+	if r == nil || call < 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("cannot record the result of %s: %v",
+			r.rec.Calls[call].Method, err))
+	}
+
+	r.rec.Calls[call].Result = data
+}
+
+func (r *recorder) transfer(t Transfer) {
+	if r != nil {
+		r.rec.Transfers = append(r.rec.Transfers, t)
+	}
+}
+
+// decode decodes the arguments of the call into the pointers.
+func (c *Call) decode(ptrs ...interface{}) error {
+	if len(c.Args) != len(ptrs) {
+		return fmt.Errorf("%s has %d arguments, not %d",
+			c.Method, len(c.Args), len(ptrs))
+	}
+
+	for i, p := range ptrs {
+		err := json.Unmarshal(c.Args[i], p)
+		if err != nil {
+			return fmt.Errorf("argument %d of %s: %w", i, c.Method, err)
+		}
+	}
+
+	return nil
+}
+
+// programEntry is a program of a map from coordinates to programs, which
+// JSON cannot encode as a map.
+type programEntry struct {
+	Core    [2]int `json:"core"`
+	Program string `json:"program"`
+}
+
+func programList(programs map[[2]int]string) []programEntry {
+	list := make([]programEntry, 0, len(programs))
+	for _, c := range sortedCoords(programs) {
+		list = append(list, programEntry{c, programs[c]})
+	}
+
+	return list
+}
+
+func programMap(list []programEntry) map[[2]int]string {
+	programs := make(map[[2]int]string)
+	for _, e := range list {
+		programs[e.Core] = e.Program
+	}
+
+	return programs
+}
+
+// replayer decodes the arguments of a call and makes it again. It returns
+// the result of the call, if the call has one.
+type replayer func(d *driverImpl, c *Call) (interface{}, error)
+
+func noResult(err error) (interface{}, error) {
+	return nil, err
+}
+
+var replayers = map[string]replayer{
+	"FeedIn": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&data, &side, &portRange, &stride)
+		if err == nil {
+			d.FeedIn(data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"FeedInToDevice": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			device    int
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&device, &data, &side, &portRange, &stride)
+		if err == nil {
+			d.FeedInToDevice(device, data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"FeedInPacked": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data      []uint16
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&data, &side, &portRange, &stride)
+		if err == nil {
+			d.FeedInPacked(data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"FeedInAt": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data               []uint32
+			side               cgra.Side
+			portRange          [2]int
+			stride             int
+			startCycle, period int
+		)
+
+		err := c.decode(&data, &side, &portRange, &stride, &startCycle, &period)
+		if err == nil {
+			d.FeedInAt(data, side, portRange, stride, startCycle, period)
+		}
+
+		return noResult(err)
+	},
+	"FeedInStencil": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			in        StencilInput
+			side      cgra.Side
+			portRange [2]int
+		)
+
+		err := c.decode(&in, &side, &portRange)
+		if err == nil {
+			d.FeedInStencil(in, side, portRange)
+		}
+
+		return noResult(err)
+	},
+	"FeedInSparse": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			vectors   [][]SparsePair
+			side      cgra.Side
+			portRange [2]int
+		)
+
+		err := c.decode(&vectors, &side, &portRange)
+		if err == nil {
+			d.FeedInSparse(vectors, side, portRange)
+		}
+
+		return noResult(err)
+	},
+	"Collect": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&data, &side, &portRange, &stride)
+		if err == nil {
+			d.Collect(data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"CollectFromDevice": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			device    int
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&device, &data, &side, &portRange, &stride)
+		if err == nil {
+			d.CollectFromDevice(device, data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"CollectWithValidity": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data      []uint32
+			valid     []bool
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		err := c.decode(&data, &valid, &side, &portRange, &stride)
+		if err == nil {
+			d.CollectWithValidity(data, valid, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"CollectAtRate": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+			period    int
+		)
+
+		err := c.decode(&data, &side, &portRange, &stride, &period)
+		if err == nil {
+			d.CollectAtRate(data, side, portRange, stride, period)
+		}
+
+		return noResult(err)
+	},
+	"MapProgram": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			program string
+			core    [2]int
+		)
+
+		err := c.decode(&program, &core)
+		if err == nil {
+			d.MapProgram(program, core)
+		}
+
+		return noResult(err)
+	},
+	"MapProgramToDevice": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			device  int
+			program string
+			core    [2]int
+		)
+
+		err := c.decode(&device, &program, &core)
+		if err == nil {
+			d.MapProgramToDevice(device, program, core)
+		}
+
+		return noResult(err)
+	},
+	"SetProgramConstant": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			name  string
+			value uint32
+		)
+
+		err := c.decode(&name, &value)
+		if err == nil {
+			d.SetProgramConstant(name, value)
+		}
+
+		return noResult(err)
+	},
+	"SetKernelArg": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			index int
+			value uint32
+		)
+
+		err := c.decode(&index, &value)
+		if err == nil {
+			d.SetKernelArg(index, value)
+		}
+
+		return noResult(err)
+	},
+	"SetRandomSeed": func(d *driverImpl, c *Call) (interface{}, error) {
+		var seed uint64
+
+		err := c.decode(&seed)
+		if err == nil {
+			d.SetRandomSeed(seed)
+		}
+
+		return noResult(err)
+	},
+	"SetSchedule": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core  [2]int
+			steps []int
+			ii    int
+		)
+
+		err := c.decode(&core, &steps, &ii)
+		if err == nil {
+			d.SetSchedule(core, steps, ii)
+		}
+
+		return noResult(err)
+	},
+	"WriteMemory": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core [2]int
+			addr int
+			data []uint32
+		)
+
+		err := c.decode(&core, &addr, &data)
+		if err == nil {
+			d.WriteMemory(core, addr, data)
+		}
+
+		return noResult(err)
+	},
+	"ReadMemory": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core         [2]int
+			addr, length int
+		)
+
+		err := c.decode(&core, &addr, &length)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ReadMemory(core, addr, length), nil
+	},
+	"WriteRegister": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core  [2]int
+			reg   int
+			value uint32
+		)
+
+		err := c.decode(&core, &reg, &value)
+		if err == nil {
+			d.WriteRegister(core, reg, value)
+		}
+
+		return noResult(err)
+	},
+	"ReadRegister": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core [2]int
+			reg  int
+		)
+
+		err := c.decode(&core, &reg)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.ReadRegister(core, reg), nil
+	},
+	"WatchRegister": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core  [2]int
+			reg   int
+			value uint32
+		)
+
+		err := c.decode(&core, &reg, &value)
+		if err == nil {
+			d.WatchRegister(core, reg, value)
+		}
+
+		return noResult(err)
+	},
+	"WatchMemory": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			core  [2]int
+			addr  int
+			value uint32
+		)
+
+		err := c.decode(&core, &addr, &value)
+		if err == nil {
+			d.WatchMemory(core, addr, value)
+		}
+
+		return noResult(err)
+	},
+	"PauseAt": func(d *driverImpl, c *Call) (interface{}, error) {
+		var cycle uint64
+
+		err := c.decode(&cycle)
+		if err == nil {
+			d.PauseAt(cycle)
+		}
+
+		return noResult(err)
+	},
+	"SetTaskPriority": func(d *driverImpl, c *Call) (interface{}, error) {
+		var priority int
+
+		err := c.decode(&priority)
+		if err == nil {
+			d.SetTaskPriority(priority)
+		}
+
+		return noResult(err)
+	},
+	"Reconfigure": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			region   Region
+			programs []programEntry
+		)
+
+		err := c.decode(&region, &programs)
+		if err == nil {
+			d.Reconfigure(region, programMap(programs))
+		}
+
+		return noResult(err)
+	},
+	"Preempt": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			region   Region
+			programs []programEntry
+		)
+
+		err := c.decode(&region, &programs)
+		if err == nil {
+			d.Preempt(region, programMap(programs))
+		}
+
+		return noResult(err)
+	},
+	"Restore": func(d *driverImpl, c *Call) (interface{}, error) {
+		var region Region
+
+		err := c.decode(&region)
+		if err == nil {
+			d.Restore(region)
+		}
+
+		return noResult(err)
+	},
+	"Run": func(d *driverImpl, c *Call) (interface{}, error) {
+		err := c.decode()
+		if err == nil {
+			d.Run()
+		}
+
+		return noResult(err)
+	},
+	"WaitAllDone": func(d *driverImpl, c *Call) (interface{}, error) {
+		err := c.decode()
+		if err == nil {
+			d.WaitAllDone()
+		}
+
+		return noResult(err)
+	},
+	"CreateStream": func(d *driverImpl, c *Call) (interface{}, error) {
+		err := c.decode()
+		if err == nil {
+			d.CreateStream()
+		}
+
+		return noResult(err)
+	},
+	"Stream.MapProgram": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			stream  int
+			program string
+			core    [2]int
+		)
+
+		s, err := d.decodeStream(c, &stream, &program, &core)
+		if err == nil {
+			s.MapProgram(program, core)
+		}
+
+		return noResult(err)
+	},
+	"Stream.FeedIn": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			stream    int
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		s, err := d.decodeStream(c, &stream, &data, &side, &portRange, &stride)
+		if err == nil {
+			s.FeedIn(data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"Stream.Collect": func(d *driverImpl, c *Call) (interface{}, error) {
+		var (
+			stream    int
+			data      []uint32
+			side      cgra.Side
+			portRange [2]int
+			stride    int
+		)
+
+		s, err := d.decodeStream(c, &stream, &data, &side, &portRange, &stride)
+		if err == nil {
+			s.Collect(data, side, portRange, stride)
+		}
+
+		return noResult(err)
+	},
+	"Stream.Barrier": func(d *driverImpl, c *Call) (interface{}, error) {
+		var stream int
+
+		s, err := d.decodeStream(c, &stream)
+		if err == nil {
+			s.Barrier()
+		}
+
+		return noResult(err)
+	},
+	"Stream.RecordEvent": func(d *driverImpl, c *Call) (interface{}, error) {
+		var stream int
+
+		s, err := d.decodeStream(c, &stream)
+		if err == nil {
+			s.RecordEvent()
+		}
+
+		return noResult(err)
+	},
+	"Stream.WaitEvent": func(d *driverImpl, c *Call) (interface{}, error) {
+		var stream, event int
+
+		s, err := d.decodeStream(c, &stream, &event)
+		if err != nil {
+			return nil, err
+		}
+
+		if event < 0 || event >= len(d.streamEvents) {
+			return nil, fmt.Errorf("%s waits for event %d, but the streams "+
+				"have recorded %d events", c.Method, event, len(d.streamEvents))
+		}
+
+		s.WaitEvent(d.streamEvents[event])
+
+		return nil, nil
+	},
+}
+
+// decodeStream decodes the arguments of a call to a stream, whose first
+// argument is the index of the stream, and returns the stream.
+func (d *driverImpl) decodeStream(
+	c *Call,
+	stream *int,
+	ptrs ...interface{},
+) (*streamImpl, error) {
+	err := c.decode(append([]interface{}{stream}, ptrs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if *stream < 0 || *stream >= len(d.streams) {
+		return nil, fmt.Errorf("%s is on stream %d, but the driver has %d "+
+			"streams", c.Method, *stream, len(d.streams))
+	}
+
+	return d.streams[*stream], nil
+}
+
+// SaveRecording writes the recording of a driver that is built with
+// WithRecording to a replay file.
+func (d *driverImpl) SaveRecording(path string) error {
+	if d.recorder == nil {
+		return fmt.Errorf("the driver does not record")
+	}
+
+	data, err := json.MarshalIndent(d.recorder.rec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Replay makes the calls of a replay file again, and returns an error at
+// the first call whose result or the first transfer that differs from the
+// recording. The driver must have the same devices as the recorded one and
+// no calls before the replay. The replay is recorded in place of the
+// recording of the driver, if any.
+func (d *driverImpl) Replay(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	rec := Recording{}
+
+	err = json.Unmarshal(data, &rec)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	if rec.Seed != d.seed {
+		return fmt.Errorf("the recording has seed %d, the driver %d",
+			rec.Seed, d.seed)
+	}
+
+	d.recorder = &recorder{rec: Recording{Seed: d.seed}}
+
+	for i := range rec.Calls {
+		err = d.replayCall(i, &rec.Calls[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return compareTransfers(rec.Transfers, d.recorder.rec.Transfers)
+}
+
+func (d *driverImpl) replayCall(i int, c *Call) error {
+	replay, ok := replayers[c.Method]
+	if !ok {
+		return fmt.Errorf("call %d: cannot replay %s", i, c.Method)
+	}
+
+	result, err := replay(d, c)
+	if err != nil {
+		return fmt.Errorf("call %d: %w", i, err)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	recorded := &bytes.Buffer{}
+
+	err = json.Compact(recorded, c.Result)
+	if err != nil || !bytes.Equal(data, recorded.Bytes()) {
+		return fmt.Errorf("call %d: %s returns %s, the recording has %s",
+			i, c.Method, data, recorded)
+	}
+
+	return nil
+}
+
+func compareTransfers(recorded, replayed []Transfer) error {
+	for i := 0; i < len(recorded) && i < len(replayed); i++ {
+		if recorded[i] != replayed[i] {
+			return fmt.Errorf("transfer %d differs: the recording has %s, "+
+				"the replay %s", i, recorded[i], replayed[i])
+		}
+	}
+
+	if len(recorded) != len(replayed) {
+		return fmt.Errorf("the recording has %d transfers, the replay %d",
+			len(recorded), len(replayed))
+	}
+
+	return nil
+}
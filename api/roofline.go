@@ -0,0 +1,106 @@
+package api
+
+import (
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Roofline compares the compute and the boundary bandwidth that a run has
+// achieved with the peaks of the device. Each enabled tile executes at most
+// one instruction per cycle, and each boundary port carries at most one word
+// per cycle.
+type Roofline struct {
+	// Cycles is the number of cycles of the run, the most among the tiles.
+	Cycles uint64
+
+	// ComputeOps is the number of arithmetic and comparison instructions
+	// that the tiles have executed.
+	ComputeOps uint64
+
+	// BoundaryWords is the number of words that the driver has fed in and
+	// collected.
+	BoundaryWords uint64
+
+	// PeakOpsPerCycle and PeakWordsPerCycle are the number of enabled tiles
+	// and the number of boundary ports.
+	PeakOpsPerCycle   float64
+	PeakWordsPerCycle float64
+
+	// OpsPerCycle and WordsPerCycle are the achieved rates.
+	OpsPerCycle   float64
+	WordsPerCycle float64
+
+	// Intensity is the number of compute ops per boundary word. It is 0 if
+	// no word has crossed the boundary.
+	Intensity float64
+
+	// Bound is the limit at the intensity, "compute" if the peak compute is
+	// lower than the intensity times the peak bandwidth, or "bandwidth"
+	// otherwise.
+	Bound string
+
+	// Efficiency is the achieved ops per cycle over the limit.
+	Efficiency float64
+}
+
+// GetRoofline returns the roofline analysis of the first device.
+func (d *driverImpl) GetRoofline() Roofline {
+	device := d.getDevice(0)
+	r := Roofline{
+		BoundaryWords:     d.boundaryWords[0],
+		PeakWordsPerCycle: float64(boundaryPorts(device)),
+	}
+
+	for _, s := range d.GetActivityStats() {
+		r.PeakOpsPerCycle++
+		r.ComputeOps += s.ComputeCycles
+
+		if s.Cycles > r.Cycles {
+			r.Cycles = s.Cycles
+		}
+	}
+
+	if r.Cycles == 0 {
+		return r
+	}
+
+	r.OpsPerCycle = float64(r.ComputeOps) / float64(r.Cycles)
+	r.WordsPerCycle = float64(r.BoundaryWords) / float64(r.Cycles)
+
+	roof := r.PeakOpsPerCycle
+	r.Bound = "compute"
+
+	if r.BoundaryWords > 0 {
+		r.Intensity = float64(r.ComputeOps) / float64(r.BoundaryWords)
+		if bw := r.Intensity * r.PeakWordsPerCycle; bw < roof {
+			roof = bw
+			r.Bound = "bandwidth"
+		}
+	}
+
+	if roof > 0 {
+		r.Efficiency = r.OpsPerCycle / roof
+	}
+
+	return r
+}
+
+// boundaryPorts returns the number of ports on the edges of the device.
+func boundaryPorts(device cgra.Device) int {
+	width, height := device.GetSize()
+	n := 0
+
+	for side := cgra.North; side <= cgra.West; side++ {
+		count := width * device.GetChannels()
+		if side == cgra.East || side == cgra.West {
+			count = height * device.GetChannels()
+		}
+
+		for _, port := range device.GetSidePorts(side, [2]int{0, count}) {
+			if port != nil {
+				n++
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// CollectAtRate collects the data like Collect, but takes at most one round
+// every period cycles of the driver, as a slow consumer does. The data that
+// waits stays in the ports, so the PEs that send it stall.
+func (d *driverImpl) CollectAtRate(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+	period int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("CollectAtRate", data, side, portRange, stride, period))
+
+	if period < 1 {
+		panic(fmt.Sprintf("invalid drain period %d, it must be positive",
+			period))
+	}
+
+	d.Collect(data, side, portRange, stride)
+	d.collectTasks[len(d.collectTasks)-1].period = uint64(period)
+}
+
+// canDrain returns true if the sink of the task can take a round in the
+// current cycle.
+func (d *driverImpl) canDrain(task *collectTask) bool {
+	return d.Freq.Cycle(d.Engine.CurrentTime()) >= task.nextDrain
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// SparsePair is an element of a sparse vector, with its index and value.
+type SparsePair struct {
+	Index uint32
+	Value uint32
+}
+
+// SparseRounds lays sparse vectors out for FeedIn, with one vector per port.
+// Each pair takes two rounds, the index first and then the value, so that a
+// PE can WAIT for the index and the value on the same port. The vectors that
+// are shorter than the longest one are padded with zero pairs. The stride is
+// the number of vectors.
+func SparseRounds(vectors [][]SparsePair) []uint32 {
+	pairs := 0
+	for _, v := range vectors {
+		if len(v) > pairs {
+			pairs = len(v)
+		}
+	}
+
+	ports := len(vectors)
+	out := make([]uint32, 2*pairs*ports)
+
+	for p, v := range vectors {
+		for i, pair := range v {
+			out[2*i*ports+p] = pair.Index
+			out[(2*i+1)*ports+p] = pair.Value
+		}
+	}
+
+	return out
+}
+
+// FeedInSparse feeds sparse vectors, one vector per port, as index-value
+// pairs. See SparseRounds for the layout.
+func (d *driverImpl) FeedInSparse(
+	vectors [][]SparsePair,
+	side cgra.Side,
+	portRange [2]int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("FeedInSparse", vectors, side, portRange))
+
+	if portRange[1]-portRange[0] != len(vectors) {
+		panic(fmt.Sprintf("%d sparse vectors need %d ports, "+
+			"but the port range has %d", len(vectors), len(vectors),
+			portRange[1]-portRange[0]))
+	}
+
+	d.FeedIn(SparseRounds(vectors), side, portRange, len(vectors))
+}
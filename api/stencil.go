@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// HaloMode is the way to fill in the halo around the input of a stencil.
+type HaloMode int
+
+const (
+	// HaloZero fills the halo with zeros.
+	HaloZero HaloMode = iota
+	// HaloReplicate repeats the values on the edges of the input.
+	HaloReplicate
+	// HaloWrap takes the values from the opposite edges of the input.
+	HaloWrap
+)
+
+// StencilInput is a 2D input of a stencil kernel.
+type StencilInput struct {
+	// Data holds Rows x Cols values in the row-major order.
+	Data []uint32
+	Rows int
+	Cols int
+
+	// Halo is the number of halo cells on each side, e.g., 1 for a 3x3
+	// stencil.
+	Halo int
+	Mode HaloMode
+
+	// Skew is the number of rounds that each port lags behind the previous
+	// port, as for a systolic array.
+	Skew int
+}
+
+// AddHalo returns the input surrounded by halo cells on all the four sides,
+// which is (rows + 2 * halo) x (cols + 2 * halo) values in the row-major
+// order.
+func AddHalo(data []uint32, rows, cols, halo int, mode HaloMode) []uint32 {
+	if len(data) != rows*cols {
+		panic(fmt.Sprintf("%d values do not make a %dx%d input",
+			len(data), rows, cols))
+	}
+
+	w := cols + 2*halo
+	padded := make([]uint32, (rows+2*halo)*w)
+
+	for y := -halo; y < rows+halo; y++ {
+		for x := -halo; x < cols+halo; x++ {
+			sy, okY := haloIndex(y, rows, mode)
+			sx, okX := haloIndex(x, cols, mode)
+
+			if okY && okX {
+				padded[(y+halo)*w+x+halo] = data[sy*cols+sx]
+			}
+		}
+	}
+
+	return padded
+}
+
+// haloIndex maps an index that may be in the halo to the index of the input
+// value to use. It returns false if the halo cell is a zero.
+func haloIndex(i, n int, mode HaloMode) (int, bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+
+	switch mode {
+	case HaloReplicate:
+		if i < 0 {
+			return 0, true
+		}
+
+		return n - 1, true
+	case HaloWrap:
+		return (i%n + n) % n, true
+	case HaloZero:
+		return 0, false
+	default:
+		panic(fmt.Sprintf("invalid halo mode %d", mode))
+	}
+}
+
+// SkewRounds lays the rows of a 2D input out for FeedIn, with one row per
+// port and one column per round. Each port starts skew rounds after the
+// previous port, and the rounds before the start and after the end of a row
+// are zeros. The stride is the number of rows.
+func SkewRounds(data []uint32, rows, cols, skew int) []uint32 {
+	rounds := cols + skew*(rows-1)
+	out := make([]uint32, rounds*rows)
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			out[(x+y*skew)*rows+y] = data[y*cols+x]
+		}
+	}
+
+	return out
+}
+
+// FeedInStencil feeds a 2D input with its halo. Each row of the input,
+// including the halo rows, goes to one port, so the port range must cover
+// Rows + 2 * Halo ports. The columns, including the halo columns, go in
+// rounds.
+func (d *driverImpl) FeedInStencil(
+	in StencilInput,
+	side cgra.Side,
+	portRange [2]int,
+) {
+	defer d.recorder.end(d.recorder.begin("FeedInStencil", in, side, portRange))
+
+	rows := in.Rows + 2*in.Halo
+	if portRange[1]-portRange[0] != rows {
+		panic(fmt.Sprintf("a stencil input with %d rows needs %d ports, "+
+			"but the port range has %d", in.Rows, rows,
+			portRange[1]-portRange[0]))
+	}
+
+	padded := AddHalo(in.Data, in.Rows, in.Cols, in.Halo, in.Mode)
+	data := SkewRounds(padded, rows, in.Cols+2*in.Halo, in.Skew)
+
+	d.FeedIn(data, side, portRange, rows)
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// FeedInAt feeds the data like FeedIn, but sends round r no earlier than
+// cycle startCycle + r*period of the driver, counted from the start of the
+// simulation. The rounds that backpressure delays are sent as soon as the
+// ports are free, so a source that falls behind catches up in a burst.
+func (d *driverImpl) FeedInAt(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+	startCycle, period int,
+) {
+	defer d.recorder.end(
+		d.recorder.begin("FeedInAt",
+			data, side, portRange, stride, startCycle, period))
+
+	if startCycle < 0 || period < 1 {
+		panic(fmt.Sprintf("invalid stimulus schedule, the start cycle %d "+
+			"must not be negative and the period %d must be positive",
+			startCycle, period))
+	}
+
+	d.FeedIn(data, side, portRange, stride)
+
+	task := d.feedInTasks[len(d.feedInTasks)-1]
+	task.startCycle = uint64(startCycle)
+	task.period = uint64(period)
+}
+
+// isDue returns true if the next round of the task can be sent in the
+// current cycle.
+func (d *driverImpl) isDue(task *feedInTask) bool {
+	due := task.startCycle + uint64(task.round)*task.period
+
+	return d.Freq.Cycle(d.Engine.CurrentTime()) >= due
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// A Stream is a queue of operations that the driver issues in order. An
+// operation is issued as soon as all the operations before it have been
+// issued, so a FeedIn and the Collect that drains the same kernel can overlap.
+// Barrier and RecordEvent order the operations that follow them after the
+// completion of the operations that precede them.
+type Stream interface {
+	// MapProgram maps a program to a core at the given coordinate.
+	MapProgram(program string, core [2]int)
+
+	// FeedIn feeds data into the device. See Driver.FeedIn.
+	FeedIn(data []uint32, side cgra.Side, portRange [2]int, stride int)
+
+	// Collect collects data from the device. See Driver.Collect.
+	Collect(data []uint32, side cgra.Side, portRange [2]int, stride int)
+
+	// Barrier blocks the stream until all the operations that are added
+	// before it are completed.
+	Barrier()
+
+	// RecordEvent adds a barrier to the stream and returns an event that
+	// is triggered when the barrier is passed.
+	RecordEvent() *StreamEvent
+
+	// WaitEvent blocks the stream until the event is triggered. The event
+	// is usually recorded in another stream.
+	WaitEvent(event *StreamEvent)
+}
+
+// StreamEvent marks a point in a stream that other streams can wait for.
+type StreamEvent struct {
+	triggered bool
+}
+
+// Triggered returns true if the operations before the event are completed.
+func (e *StreamEvent) Triggered() bool {
+	return e.triggered
+}
+
+type streamOp struct {
+	// canIssue returns true if the operation can be issued. If nil, the
+	// operation can always be issued.
+	canIssue func(s *streamImpl) bool
+
+	// issue starts the operation and returns a function that tells if the
+	// operation is completed.
+	issue func() (done func() bool)
+}
+
+type streamImpl struct {
+	driver *driverImpl
+
+	// id is the index of the stream in the driver, by which a recording
+	// refers to the stream.
+	id int
+
+	ops     []*streamOp
+	pending []func() bool
+}
+
+func (s *streamImpl) MapProgram(program string, core [2]int) {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.MapProgram", s.id, program, core))
+
+	s.ops = append(s.ops, &streamOp{
+		issue: func() func() bool {
+			s.driver.MapProgram(program, core)
+			return nil
+		},
+	})
+}
+
+func (s *streamImpl) FeedIn(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.FeedIn", s.id, data, side, portRange, stride))
+
+	s.ops = append(s.ops, &streamOp{
+		issue: func() func() bool {
+			s.driver.FeedIn(data, side, portRange, stride)
+			task := s.driver.feedInTasks[len(s.driver.feedInTasks)-1]
+
+			return task.isFinished
+		},
+	})
+}
+
+func (s *streamImpl) Collect(
+	data []uint32,
+	side cgra.Side,
+	portRange [2]int,
+	stride int,
+) {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.Collect", s.id, data, side, portRange, stride))
+
+	s.ops = append(s.ops, &streamOp{
+		issue: func() func() bool {
+			s.driver.Collect(data, side, portRange, stride)
+			task := s.driver.collectTasks[len(s.driver.collectTasks)-1]
+
+			return task.isFinished
+		},
+	})
+}
+
+func (s *streamImpl) Barrier() {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.Barrier", s.id))
+
+	s.ops = append(s.ops, &streamOp{
+		canIssue: (*streamImpl).allPendingDone,
+		issue:    func() func() bool { return nil },
+	})
+}
+
+func (s *streamImpl) RecordEvent() *StreamEvent {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.RecordEvent", s.id))
+
+	event := &StreamEvent{}
+	s.driver.streamEvents = append(s.driver.streamEvents, event)
+
+	s.ops = append(s.ops, &streamOp{
+		canIssue: (*streamImpl).allPendingDone,
+		issue: func() func() bool {
+			event.triggered = true
+			return nil
+		},
+	})
+
+	return event
+}
+
+func (s *streamImpl) WaitEvent(event *StreamEvent) {
+	r := s.driver.recorder
+	defer r.end(r.begin("Stream.WaitEvent", s.id, s.driver.eventIndex(event)))
+
+	s.ops = append(s.ops, &streamOp{
+		canIssue: func(*streamImpl) bool { return event.triggered },
+		issue:    func() func() bool { return nil },
+	})
+}
+
+// eventIndex returns the index of an event that a stream of the driver has
+// recorded. A recording cannot refer to other events.
+func (d *driverImpl) eventIndex(event *StreamEvent) int {
+	for i, e := range d.streamEvents {
+		if e == event {
+			return i
+		}
+	}
+
+	if d.recorder != nil {
+		panic("cannot record a wait for an event that no stream of the " +
+			"driver has recorded")
+	}
+
+	return -1
+}
+
+func (s *streamImpl) allPendingDone() bool {
+	for _, done := range s.pending {
+		if !done() {
+			return false
+		}
+	}
+
+	s.pending = nil
+
+	return true
+}
+
+// issueOps issues the operations at the head of the stream until an
+// operation cannot be issued. It returns true if any operation is issued.
+func (s *streamImpl) issueOps() bool {
+	madeProgress := false
+
+	for len(s.ops) > 0 {
+		op := s.ops[0]
+		if op.canIssue != nil && !op.canIssue(s) {
+			break
+		}
+
+		done := op.issue()
+		if done != nil {
+			s.pending = append(s.pending, done)
+		}
+
+		s.ops = s.ops[1:]
+		madeProgress = true
+	}
+
+	return madeProgress
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// WatchRegister pauses the simulation when an instruction first sets a
+// register to the value.
+func (d *driverImpl) WatchRegister(core [2]int, reg int, value uint32) {
+	defer d.recorder.end(d.recorder.begin("WatchRegister", core, reg, value))
+
+	d.watch(d.registerTile(core), core,
+		cgra.Watchpoint{Index: reg, Value: value})
+}
+
+// WatchMemory pauses the simulation when an instruction first sets a word of
+// the local memory to the value.
+func (d *driverImpl) WatchMemory(core [2]int, addr int, value uint32) {
+	defer d.recorder.end(d.recorder.begin("WatchMemory", core, addr, value))
+
+	d.watch(d.memoryTile(core), core,
+		cgra.Watchpoint{Memory: true, Index: addr, Value: value})
+}
+
+// watch counts the pause of the watchpoint up front, since the engine has
+// to run in its own goroutine to stop at the pause. A watchpoint that is
+// never hit leaves the count behind, which only keeps Run in that mode.
+func (d *driverImpl) watch(tile cgra.Tile, core [2]int, w cgra.Watchpoint) {
+	tile.Watch(w, func(hit cgra.WatchHit) {
+		hit.Tile = core
+		d.watchHits = append(d.watchHits, hit)
+
+		now := d.Engine.CurrentTime()
+		d.Engine.Schedule(pauseEvent{sim.NewEventBase(now, &d.pauser)})
+	})
+	d.pauser.expect()
+}
+
+// GetWatchHits returns the watchpoints that have been hit.
+func (d *driverImpl) GetWatchHits() []cgra.WatchHit {
+	return append([]cgra.WatchHit(nil), d.watchHits...)
+}
@@ -0,0 +1,103 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Baseline is a set of stored results, keyed by the name of the kernel.
+type Baseline map[string]Result
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := Baseline{}
+
+	err = json.Unmarshal(data, &b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return b, nil
+}
+
+// Save writes the baseline to a file.
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Comparison is the result of a kernel next to its baseline.
+type Comparison struct {
+	Name     string
+	Baseline Result
+	Result   Result
+
+	// InBaseline is false if the baseline has no result of the kernel.
+	InBaseline bool
+}
+
+// SpeedChange is the relative change of the simulation speed, e.g., -0.1
+// if the simulation became 10% slower.
+func (c Comparison) SpeedChange() float64 {
+	if !c.InBaseline || c.Baseline.CyclesPerSec == 0 {
+		return 0
+	}
+
+	return c.Result.CyclesPerSec/c.Baseline.CyclesPerSec - 1
+}
+
+// Regressed returns true if the cycle count changed, or if the simulation
+// speed dropped by more than the tolerance, e.g., 0.2 for 20%.
+func (c Comparison) Regressed(tolerance float64) bool {
+	if !c.InBaseline {
+		return false
+	}
+
+	return c.Result.Cycles != c.Baseline.Cycles || c.SpeedChange() < -tolerance
+}
+
+func (c Comparison) String() string {
+	if !c.InBaseline {
+		return fmt.Sprintf("%-10s %10d cycles %14.0f cycles/s (no baseline)",
+			c.Name, c.Result.Cycles, c.Result.CyclesPerSec)
+	}
+
+	return fmt.Sprintf("%-10s %10d cycles (was %d) %14.0f cycles/s (%+.1f%%)",
+		c.Name, c.Result.Cycles, c.Baseline.Cycles,
+		c.Result.CyclesPerSec, 100*c.SpeedChange())
+}
+
+// Compare pairs the results with the baseline, ordered by the name of the
+// kernel.
+func Compare(b Baseline, results map[string]Result) []Comparison {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	comparisons := make([]Comparison, 0, len(names))
+	for _, name := range names {
+		base, ok := b[name]
+		comparisons = append(comparisons, Comparison{
+			Name:       name,
+			Baseline:   base,
+			Result:     results[name],
+			InBaseline: ok,
+		})
+	}
+
+	return comparisons
+}
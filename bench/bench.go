@@ -0,0 +1,114 @@
+// Package bench measures the speed of the simulator on representative
+// kernels and compares the measurements with stored baselines.
+//
+// The simulated cycle count of a kernel is deterministic, so any change of
+// it is a change of the timing model. The simulation speed, in simulated
+// cycles per second of wall-clock time, depends on the host, so baselines
+// of the speed are only comparable on the same machine.
+package bench
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// Kernel is a benchmark kernel that runs on a Width x Height device.
+type Kernel struct {
+	Name          string
+	Width, Height int
+
+	// setUp maps the programs and queues the data, and returns a function
+	// that checks the results after the run.
+	setUp func(d api.Driver, k Kernel) func() error
+}
+
+// Result is the measurement of one run of a kernel.
+type Result struct {
+	// Cycles is the number of simulated cycles.
+	Cycles uint64 `json:"cycles"`
+
+	// CyclesPerSec is the number of simulated cycles per second of
+	// wall-clock time.
+	CyclesPerSec float64 `json:"cycles_per_sec"`
+}
+
+// Run simulates the kernel without tracing, checks its results, and
+// measures the run.
+func Run(k Kernel) (Result, error) {
+	return RunOn(k, config.DeviceBuilder{})
+}
+
+// RunOn is the same as Run, but builds the device with the given builder,
+// e.g., with other links. The engine, the frequency, the size, and the
+// tracer of the builder are set by RunOn.
+func RunOn(k Kernel, device config.DeviceBuilder) (Result, error) {
+	freq := 1 * sim.GHz
+	engine := sim.NewSerialEngine()
+	driver := api.DriverBuilder{}.
+		WithEngine(engine).
+		WithFreq(freq).
+		Build("Driver")
+	driver.RegisterDevice(device.
+		WithEngine(engine).
+		WithFreq(freq).
+		WithWidth(k.Width).
+		WithHeight(k.Height).
+		WithTracer(trace.Discard).
+		Build("Device"))
+
+	check := k.setUp(driver, k)
+
+	start := time.Now()
+	driver.Run()
+	elapsed := time.Since(start)
+
+	err := check()
+	if err != nil {
+		return Result{}, err
+	}
+
+	cycles := freq.Cycle(engine.CurrentTime())
+
+	return Result{
+		Cycles:       cycles,
+		CyclesPerSec: float64(cycles) / elapsed.Seconds(),
+	}, nil
+}
+
+// RunAll runs each kernel the given number of times and keeps the fastest
+// run, which is the least disturbed by the other load of the host.
+func RunAll(kernels []Kernel, runs int) (map[string]Result, error) {
+	results := make(map[string]Result)
+
+	for _, k := range kernels {
+		for i := 0; i < runs; i++ {
+			r, err := Run(k)
+			if err != nil {
+				return nil, err
+			}
+
+			best, ok := results[k.Name]
+			if !ok || r.CyclesPerSec > best.CyclesPerSec {
+				results[k.Name] = r
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// FindKernel returns the kernel with the name.
+func FindKernel(name string) (Kernel, error) {
+	for _, k := range Kernels {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+
+	return Kernel{}, fmt.Errorf("unknown kernel %q", name)
+}
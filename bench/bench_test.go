@@ -0,0 +1,149 @@
+package bench_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/bench"
+)
+
+var _ = Describe("Kernels", func() {
+	for _, k := range bench.Kernels {
+		k := k
+
+		It("should run "+k.Name+" correctly", func() {
+			r, err := bench.Run(k)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Cycles).To(BeNumerically(">", 0))
+
+			again, err := bench.Run(k)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again.Cycles).To(Equal(r.Cycles))
+		})
+	}
+})
+
+var _ = Describe("Scaling", func() {
+	// reference computes the outputs of the kernel on the host.
+	reference := func(name string, n int) []uint32 {
+		first, second := bench.ScalingInputs(name, n)
+
+		if name == "axpy" {
+			want := make([]uint32, n)
+			for i := range want {
+				want[i] = bench.ScalingAlpha*first[i] + second[i]
+			}
+
+			return want
+		}
+
+		// The outputs are the columns of A*B, one after another.
+		cols := len(second) / n
+		want := make([]uint32, n*cols)
+		for i := 0; i < n; i++ {
+			for j := 0; j < cols; j++ {
+				for k := 0; k < n; k++ {
+					want[j*n+i] += first[i*n+k] * second[k*cols+j]
+				}
+			}
+		}
+
+		return want
+	}
+
+	// The loops take one cycle per instruction, so the cycles per iteration
+	// approach the length of the loop body, which is 6 instructions for axpy
+	// and 7 for the multiply-add of gemv and gemm, within the cost of the
+	// setup and the drain.
+	DescribeTable("should compute the kernel at each size",
+		func(name string, ii float64, sizes ...int) {
+			for _, n := range sizes {
+				r, err := bench.RunScaling(name, n)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(r.Outputs).To(Equal(reference(name, n)),
+					"%s at size %d", name, n)
+				Expect(r.II()).To(BeNumerically("~", ii, 0.1*ii),
+					"%s at size %d", name, n)
+			}
+		},
+		Entry("axpy", "axpy", 6.0, 16, 32, 64, 128),
+		Entry("gemv", "gemv", 7.0, 16, 32, 64),
+		Entry("gemm", "gemm", 7.0, 8, 16, 32),
+	)
+
+	It("should reject sizes that it cannot run", func() {
+		_, err := bench.RunScaling("axpy", 18)
+		Expect(err).To(MatchError("size 18 is not a positive multiple of 4"))
+
+		_, err = bench.RunScaling("gemm", 2*bench.ScalingLimit("gemm"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = bench.RunScaling("conv", 16)
+		Expect(err).To(MatchError("unknown scaling kernel \"conv\""))
+	})
+})
+
+var _ = Describe("Baseline", func() {
+	It("should save and load a baseline", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "baseline.json")
+		b := bench.Baseline{"relu": {Cycles: 100, CyclesPerSec: 1e6}}
+
+		Expect(b.Save(path)).To(Succeed())
+
+		loaded, err := bench.LoadBaseline(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(b))
+	})
+
+	It("should flag changed cycle counts and slowdowns", func() {
+		b := bench.Baseline{
+			"fir":  {Cycles: 100, CyclesPerSec: 1000},
+			"gemm": {Cycles: 100, CyclesPerSec: 1000},
+			"relu": {Cycles: 100, CyclesPerSec: 1000},
+		}
+		results := map[string]bench.Result{
+			"axpy": {Cycles: 50, CyclesPerSec: 1000},
+			"fir":  {Cycles: 101, CyclesPerSec: 1000},
+			"gemm": {Cycles: 100, CyclesPerSec: 700},
+			"relu": {Cycles: 100, CyclesPerSec: 900},
+		}
+
+		c := bench.Compare(b, results)
+
+		Expect(c).To(HaveLen(4))
+		Expect(c[0].Name).To(Equal("axpy"))
+		Expect(c[0].InBaseline).To(BeFalse())
+		Expect(c[0].Regressed(0.2)).To(BeFalse())
+		Expect(c[1].Regressed(0.2)).To(BeTrue())
+		Expect(c[2].SpeedChange()).To(BeNumerically("~", -0.3, 1e-9))
+		Expect(c[2].Regressed(0.2)).To(BeTrue())
+		Expect(c[3].Regressed(0.2)).To(BeFalse())
+	})
+})
+
+func benchmarkKernel(b *testing.B, name string) {
+	k, err := bench.FindKernel(name)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var r bench.Result
+	for i := 0; i < b.N; i++ {
+		r, err = bench.Run(k)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(r.Cycles), "cycles")
+	b.ReportMetric(r.CyclesPerSec, "cycles/s")
+}
+
+func BenchmarkReLU(b *testing.B)      { benchmarkKernel(b, "relu") }
+func BenchmarkFIR(b *testing.B)       { benchmarkKernel(b, "fir") }
+func BenchmarkAXPY(b *testing.B)      { benchmarkKernel(b, "axpy") }
+func BenchmarkHistogram(b *testing.B) { benchmarkKernel(b, "histogram") }
+func BenchmarkGEMM(b *testing.B)      { benchmarkKernel(b, "gemm") }
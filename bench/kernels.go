@@ -0,0 +1,282 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Kernels are the representative kernels, in the order that reports list
+// them.
+var Kernels = []Kernel{
+	{Name: "relu", Width: 16, Height: 16, setUp: setUpReLU},
+	{Name: "fir", Width: 8, Height: 8, setUp: setUpFIR},
+	{Name: "axpy", Width: 8, Height: 8, setUp: setUpAXPY},
+	{Name: "histogram", Width: 8, Height: 8, setUp: setUpHistogram},
+	{Name: "gemm", Width: 8, Height: 8, setUp: setUpGEMM},
+}
+
+// numValues is the number of values that each row of a streaming kernel
+// processes.
+const numValues = 64
+
+const passthrough = `START:
+WAIT, $0, NET_RECV_3
+SEND, NET_SEND_1, $0
+JMP, START`
+
+// mapRows maps first to the first column and passthrough to the others.
+func mapRows(d api.Driver, k Kernel, first string) {
+	for y := 0; y < k.Height; y++ {
+		d.MapProgram(first, [2]int{0, y})
+
+		for x := 1; x < k.Width; x++ {
+			d.MapProgram(passthrough, [2]int{x, y})
+		}
+	}
+}
+
+// randomValues returns the same values in every run, so that the simulated
+// cycle counts are reproducible.
+func randomValues(n int, seed int64, limit int32) []uint32 {
+	r := rand.New(rand.NewSource(seed))
+
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = uint32(r.Int31n(2*limit) - limit)
+	}
+
+	return values
+}
+
+func checkOutputs(name string, got, want []uint32) error {
+	for i := range want {
+		if got[i] != want[i] {
+			return fmt.Errorf("%s: output %d is %d, want %d",
+				name, i, got[i], want[i])
+		}
+	}
+
+	return nil
+}
+
+func setUpReLU(d api.Driver, k Kernel) func() error {
+	mapRows(d, k, `START:
+WAIT, $0, NET_RECV_3
+I_CMP_LT, $1, $0, 0
+JEQ, POS, $1, 0
+SEND, NET_SEND_1, 0
+JMP, START
+POS:
+SEND, NET_SEND_1, $0
+JMP, START`)
+
+	src := randomValues(numValues*k.Height, 1, 10)
+	dst := make([]uint32, len(src))
+	d.FeedIn(src, cgra.West, [2]int{0, k.Height}, k.Height)
+	d.Collect(dst, cgra.East, [2]int{0, k.Height}, k.Height)
+
+	return func() error {
+		want := make([]uint32, len(src))
+		for i, v := range src {
+			if int32(v) >= 0 {
+				want[i] = v
+			}
+		}
+
+		return checkOutputs(k.Name, dst, want)
+	}
+}
+
+// setUpFIR runs a FIR filter on each row, with one tap per PE. The taps are
+// 1, 2, 3, .... A PE receives a delayed sample and a partial sum from the
+// west, adds its product, and sends the sample of the previous iteration
+// and the sum to the east.
+func setUpFIR(d api.Driver, k Kernel) func() error {
+	for y := 0; y < k.Height; y++ {
+		for x := 0; x < k.Width; x++ {
+			d.MapProgram(fmt.Sprintf(`START:
+WAIT, $0, NET_RECV_3
+WAIT, $1, NET_RECV_3
+I_MUL, $3, $0, %d
+I_ADD, $1, $1, $3
+SEND, NET_SEND_1, $2
+SEND, NET_SEND_1, $1
+I_ADD, $2, $0, 0
+JMP, START`, x+1), [2]int{x, y})
+		}
+	}
+
+	samples := randomValues(numValues*k.Height, 2, 100)
+	src := interleave(samples, make([]uint32, len(samples)), k.Height)
+	dst := make([]uint32, len(src))
+	d.FeedIn(src, cgra.West, [2]int{0, k.Height}, k.Height)
+	d.Collect(dst, cgra.East, [2]int{0, k.Height}, k.Height)
+
+	return func() error {
+		got := make([]uint32, 0, len(samples))
+		want := make([]uint32, 0, len(samples))
+
+		for n := 0; n < numValues; n++ {
+			for y := 0; y < k.Height; y++ {
+				got = append(got, dst[(2*n+1)*k.Height+y])
+				want = append(want, firOutput(samples, n, y, k))
+			}
+		}
+
+		return checkOutputs(k.Name, got, want)
+	}
+}
+
+func firOutput(samples []uint32, n, row int, k Kernel) uint32 {
+	sum := uint32(0)
+	for tap := 0; tap < k.Width && tap <= n; tap++ {
+		sum += uint32(tap+1) * samples[(n-tap)*k.Height+row]
+	}
+
+	return sum
+}
+
+// interleave alternates the rounds of a and b. Each round has one value for
+// each of the ports.
+func interleave(a, b []uint32, ports int) []uint32 {
+	out := make([]uint32, 0, len(a)+len(b))
+	for i := 0; i < len(a); i += ports {
+		out = append(out, a[i:i+ports]...)
+		out = append(out, b[i:i+ports]...)
+	}
+
+	return out
+}
+
+// setUpAXPY computes a*x+y on each row, where a is ARG0.
+func setUpAXPY(d api.Driver, k Kernel) func() error {
+	const a = 3
+
+	mapRows(d, k, axpyProgram)
+	d.SetKernelArg(0, a)
+
+	x := randomValues(numValues*k.Height, 3, 1000)
+	y := randomValues(numValues*k.Height, 4, 1000)
+	dst := make([]uint32, len(x))
+	d.FeedIn(interleave(x, y, k.Height), cgra.West, [2]int{0, k.Height}, k.Height)
+	d.Collect(dst, cgra.East, [2]int{0, k.Height}, k.Height)
+
+	return func() error {
+		want := make([]uint32, len(x))
+		for i := range x {
+			want[i] = a*x[i] + y[i]
+		}
+
+		return checkOutputs(k.Name, dst, want)
+	}
+}
+
+// setUpHistogram counts the values of each row in one bin per PE. The PE at
+// column x counts the values that equal x, and returns the count after N
+// values.
+func setUpHistogram(d api.Driver, k Kernel) func() error {
+	d.SetProgramConstant("N", numValues)
+
+	for y := 0; y < k.Height; y++ {
+		for x := 0; x < k.Width; x++ {
+			d.MapProgram(fmt.Sprintf(`START:
+WAIT, $0, NET_RECV_3
+SEND, NET_SEND_1, $0
+I_CMP_EQ, $1, $0, %d
+I_ADD, $2, $2, $1
+I_ADD, $3, $3, 1
+I_CMP_LT, $1, $3, N
+JEQ, START, $1, 1
+RETURN_VALUE, $2
+DONE`, x), [2]int{x, y})
+		}
+	}
+
+	src := randomValues(numValues*k.Height, 5, int32(k.Width))
+	for i, v := range src {
+		src[i] = uint32(int32(v)+int32(k.Width)) / 2
+	}
+
+	d.FeedIn(src, cgra.West, [2]int{0, k.Height}, k.Height)
+	d.Collect(make([]uint32, len(src)), cgra.East, [2]int{0, k.Height}, k.Height)
+
+	return func() error {
+		want := make(map[[2]int]uint32)
+		for i, v := range src {
+			want[[2]int{int(v), i % k.Height}]++
+		}
+
+		return checkReturnValues(d, k, want)
+	}
+}
+
+// setUpGEMM multiplies two square matrices in an output-stationary array.
+// The rows of A enter from the west and the columns of B from the north.
+// The PE at [x, y] computes C[y][x] and returns it after N products.
+func setUpGEMM(d api.Driver, k Kernel) func() error {
+	n := k.Width
+	program := strings.Join([]string{
+		"START:",
+		"WAIT, $0, NET_RECV_3",
+		"WAIT, $1, NET_RECV_0",
+		"SEND, NET_SEND_1, $0",
+		"SEND, NET_SEND_2, $1",
+		"I_MUL, $2, $0, $1",
+		"I_ADD, $3, $3, $2",
+		"I_ADD, $4, $4, 1",
+		"I_CMP_LT, $5, $4, N",
+		"JEQ, START, $5, 1",
+		"RETURN_VALUE, $3",
+		"DONE",
+	}, "\n")
+
+	d.SetProgramConstant("N", uint32(n))
+
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d.MapProgram(program, [2]int{x, y})
+		}
+	}
+
+	// a[k*n+i] is A[i][k] and b[k*n+j] is B[k][j], the order in which the
+	// driver feeds the rounds.
+	a := randomValues(n*n, 6, 100)
+	b := randomValues(n*n, 7, 100)
+	d.FeedIn(a, cgra.West, [2]int{0, n}, n)
+	d.FeedIn(b, cgra.North, [2]int{0, n}, n)
+	d.Collect(make([]uint32, n*n), cgra.East, [2]int{0, n}, n)
+	d.Collect(make([]uint32, n*n), cgra.South, [2]int{0, n}, n)
+
+	return func() error {
+		want := make(map[[2]int]uint32)
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				for i := 0; i < n; i++ {
+					want[[2]int{x, y}] += a[i*n+y] * b[i*n+x]
+				}
+			}
+		}
+
+		return checkReturnValues(d, k, want)
+	}
+}
+
+func checkReturnValues(d api.Driver, k Kernel, want map[[2]int]uint32) error {
+	got := d.GetReturnValues()
+
+	for y := 0; y < k.Height; y++ {
+		for x := 0; x < k.Width; x++ {
+			coord := [2]int{x, y}
+			if got[coord] != want[coord] {
+				return fmt.Errorf("%s: PE(%d, %d) returns %d, want %d",
+					k.Name, x, y, got[coord], want[coord])
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package bench
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+)
+
+// ScalingKernels are the kernels of the scaling suite, whose size is N:
+//
+//   - axpy computes a*x+y on vectors of N elements.
+//   - gemv multiplies an N x N matrix by a vector of N elements.
+//   - gemm multiplies two N x N matrices, as N products of the first matrix
+//     and a column of the second.
+var ScalingKernels = []string{"axpy", "gemv", "gemm"}
+
+// ScalingSizes are the sizes that the scaling suite sweeps by default.
+var ScalingSizes = []int{16, 32, 64, 128, 256, 512, 1024, 2048, 4096}
+
+// scalingLimits are the largest sizes of the kernels whose inputs grow
+// faster than N^2. The inputs of gemm have 2*N^3 words, which take 128 MB at
+// N = 256, and it runs for minutes.
+var scalingLimits = map[string]int{"gemm": 256}
+
+// ScalingLimit returns the largest size at which RunScaling runs the
+// kernel, or 0 if the size is not limited.
+func ScalingLimit(name string) int {
+	return scalingLimits[name]
+}
+
+// ScalingAlpha is the factor a of axpy.
+const ScalingAlpha = 3
+
+// ScalingRows is the number of PEs, in a column, among which the scaling
+// kernels split the work. Each PE takes every ScalingRows-th element or row,
+// so N must be a multiple of it.
+const ScalingRows = 4
+
+// ScalingResult is the measurement of a scaling kernel at a size.
+type ScalingResult struct {
+	Kernel string
+	N      int
+
+	// Cycles is the number of simulated cycles.
+	Cycles uint64
+
+	// Iterations is the number of iterations of the loop of each PE, which
+	// is an element for axpy and a multiply-add for gemv and gemm.
+	Iterations uint64
+
+	// Outputs are the elements of a*x+y for axpy, and the columns of the
+	// product, one after another, for gemv and gemm.
+	Outputs []uint32
+}
+
+// II returns the cycles per iteration, which approaches the initiation
+// interval of the loop as the size grows.
+func (r ScalingResult) II() float64 {
+	if r.Iterations == 0 {
+		return 0
+	}
+
+	return float64(r.Cycles) / float64(r.Iterations)
+}
+
+func (r ScalingResult) String() string {
+	return fmt.Sprintf("%-6s %6d %12d cycles %12d iterations  II %.2f",
+		r.Kernel, r.N, r.Cycles, r.Iterations, r.II())
+}
+
+// ScalingInputs returns the inputs that RunScaling generates, from fixed
+// seeds, for the kernel at the size. For axpy, they are the vectors x and y.
+// For gemv and gemm, they are the N x N matrix A and the matrix B, which has
+// one column for gemv and N columns for gemm, in row-major order.
+func ScalingInputs(name string, n int) (first, second []uint32) {
+	switch name {
+	case "axpy":
+		return randomValues(n, 3, 1000), randomValues(n, 4, 1000)
+	case "gemv":
+		return randomValues(n*n, 6, 100), randomValues(n, 7, 100)
+	case "gemm":
+		return randomValues(n*n, 6, 100), randomValues(n*n, 7, 100)
+	}
+
+	return nil, nil
+}
+
+// RunScaling generates the inputs of the kernel at the size, runs it,
+// checks its outputs against a reference on the host, and measures the run.
+func RunScaling(name string, n int) (ScalingResult, error) {
+	if n <= 0 || n%ScalingRows != 0 {
+		return ScalingResult{}, fmt.Errorf("size %d is not a positive "+
+			"multiple of %d", n, ScalingRows)
+	}
+
+	if limit := ScalingLimit(name); limit > 0 && n > limit {
+		return ScalingResult{}, fmt.Errorf("%s runs up to size %d, not %d",
+			name, limit, n)
+	}
+
+	var (
+		setUp      func(d api.Driver, k Kernel) func() error
+		iterations uint64
+		dst        []uint32
+	)
+
+	size := uint64(n)
+
+	switch name {
+	case "axpy":
+		dst = make([]uint32, n)
+		setUp = func(d api.Driver, k Kernel) func() error {
+			return setUpScalingAXPY(d, n, dst)
+		}
+		iterations = size / ScalingRows
+	case "gemv":
+		dst = make([]uint32, n)
+		setUp = func(d api.Driver, k Kernel) func() error {
+			return setUpScalingGEMM(d, name, n, dst)
+		}
+		iterations = size * size / ScalingRows
+	case "gemm":
+		dst = make([]uint32, n*n)
+		setUp = func(d api.Driver, k Kernel) func() error {
+			return setUpScalingGEMM(d, name, n, dst)
+		}
+		iterations = size * size * size / ScalingRows
+	default:
+		return ScalingResult{}, fmt.Errorf("unknown scaling kernel %q", name)
+	}
+
+	k := Kernel{
+		Name:   fmt.Sprintf("%s-%d", name, n),
+		Width:  1,
+		Height: ScalingRows,
+		setUp:  setUp,
+	}
+
+	r, err := RunOn(k, config.DeviceBuilder{})
+	if err != nil {
+		return ScalingResult{}, err
+	}
+
+	return ScalingResult{
+		Kernel:     name,
+		N:          n,
+		Cycles:     r.Cycles,
+		Iterations: iterations,
+		Outputs:    dst,
+	}, nil
+}
+
+const axpyProgram = `START:
+WAIT, $0, NET_RECV_3
+WAIT, $1, NET_RECV_3
+I_MUL, $0, $0, ARG0
+I_ADD, $0, $0, $1
+SEND, NET_SEND_1, $0
+JMP, START`
+
+// setUpScalingAXPY computes a*x+y into dst, where a is ARG0. The PE at row
+// r takes the elements r, r+ScalingRows, ....
+func setUpScalingAXPY(d api.Driver, n int, dst []uint32) func() error {
+	const a = ScalingAlpha
+
+	for y := 0; y < ScalingRows; y++ {
+		d.MapProgram(axpyProgram, [2]int{0, y})
+	}
+
+	d.SetKernelArg(0, a)
+
+	x, y := ScalingInputs("axpy", n)
+	ports := [2]int{0, ScalingRows}
+	d.FeedIn(interleave(x, y, ScalingRows), cgra.West, ports, ScalingRows)
+	d.Collect(dst, cgra.East, ports, ScalingRows)
+
+	return func() error {
+		want := make([]uint32, n)
+		for i := range x {
+			want[i] = a*x[i] + y[i]
+		}
+
+		return checkOutputs(fmt.Sprintf("axpy-%d", n), dst, want)
+	}
+}
+
+// setUpScalingGEMM multiplies the N x N matrix A by the matrix B of the
+// kernel into dst, a column at a time, which is gemv if B has one column. The
+// PE at row r
+// computes the rows r, r+ScalingRows, ... of each column of C. For each
+// element of C, it receives the pairs of A[i][k] and B[k][j] from the west,
+// and sends the sum of their products to the east after N pairs.
+func setUpScalingGEMM(
+	d api.Driver, name string, n int, dst []uint32,
+) func() error {
+	program := strings.Join([]string{
+		"START:",
+		"WAIT, $0, NET_RECV_3",
+		"WAIT, $1, NET_RECV_3",
+		"I_MUL, $2, $0, $1",
+		"I_ADD, $3, $3, $2",
+		"I_ADD, $4, $4, 1",
+		"I_CMP_LT, $5, $4, N",
+		"JEQ, START, $5, 1",
+		"SEND, NET_SEND_1, $3",
+		"I_ADD, $3, 0, 0",
+		"I_ADD, $4, 0, 0",
+		"JMP, START",
+	}, "\n")
+
+	d.SetProgramConstant("N", uint32(n))
+
+	for y := 0; y < ScalingRows; y++ {
+		d.MapProgram(program, [2]int{0, y})
+	}
+
+	// a[i*n+k] is A[i][k] and b[k*cols+j] is B[k][j].
+	a, b := ScalingInputs(name, n)
+	cols := len(b) / n
+
+	// Each round has a value for each of the PEs, which take the pairs in
+	// turns.
+	src := make([]uint32, 0, 2*n*n*cols)
+	for j := 0; j < cols; j++ {
+		for t := 0; t < n; t += ScalingRows {
+			for k := 0; k < n; k++ {
+				for r := 0; r < ScalingRows; r++ {
+					src = append(src, a[(t+r)*n+k])
+				}
+
+				for r := 0; r < ScalingRows; r++ {
+					src = append(src, b[k*cols+j])
+				}
+			}
+		}
+	}
+
+	// dst[j*n+i] is C[i][j].
+	ports := [2]int{0, ScalingRows}
+	d.FeedIn(src, cgra.West, ports, ScalingRows)
+	d.Collect(dst, cgra.East, ports, ScalingRows)
+
+	return func() error {
+		want := make([]uint32, n*cols)
+		for j := 0; j < cols; j++ {
+			for i := 0; i < n; i++ {
+				for k := 0; k < n; k++ {
+					want[j*n+i] += a[i*n+k] * b[k*cols+j]
+				}
+			}
+		}
+
+		return checkOutputs(fmt.Sprintf("%s-%d", name, n), dst, want)
+	}
+}
@@ -0,0 +1,51 @@
+package cgra
+
+import "sort"
+
+// PECaps is the capability class of a PE, defined by the opcodes that the PE
+// can execute. The zero value supports all opcodes.
+type PECaps struct {
+	opcodes map[string]bool
+}
+
+// NewPECaps creates a capability class that supports the given opcodes only.
+func NewPECaps(opcodes ...string) PECaps {
+	c := PECaps{opcodes: make(map[string]bool)}
+
+	for _, op := range opcodes {
+		c.opcodes[op] = true
+	}
+
+	return c
+}
+
+// SupportsAll returns true if the PE can execute all opcodes.
+func (c PECaps) SupportsAll() bool {
+	return c.opcodes == nil
+}
+
+// Supports returns true if the PE can execute the opcode.
+func (c PECaps) Supports(opcode string) bool {
+	if c.opcodes == nil {
+		return true
+	}
+
+	return c.opcodes[opcode]
+}
+
+// Opcodes returns the sorted opcodes that the PE supports. It returns nil if
+// the PE supports all opcodes.
+func (c PECaps) Opcodes() []string {
+	if c.opcodes == nil {
+		return nil
+	}
+
+	opcodes := make([]string, 0, len(c.opcodes))
+	for op := range c.opcodes {
+		opcodes = append(opcodes, op)
+	}
+
+	sort.Strings(opcodes)
+
+	return opcodes
+}
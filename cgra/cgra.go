@@ -2,6 +2,9 @@
 package cgra
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/sarchlab/akita/v3/sim"
 )
 
@@ -31,17 +34,157 @@ func (s Side) Name() string {
 	}
 }
 
+// PortName returns the name of the port of a tile on the side and the
+// channel. The port of channel 0 is named after the side, e.g., West, and the
+// ports of the other channels have a suffix, e.g., West[1].
+func PortName(side Side, channel int) string {
+	if channel == 0 {
+		return side.Name()
+	}
+
+	return fmt.Sprintf("%s[%d]", side.Name(), channel)
+}
+
+// Opposite returns the side that faces the side.
+func (s Side) Opposite() Side {
+	switch s {
+	case North:
+		return South
+	case West:
+		return East
+	case South:
+		return North
+	case East:
+		return West
+	default:
+		panic("invalid side")
+	}
+}
+
 // Tile defines a tile in the CGRA.
 type Tile interface {
 	GetPort(side Side) sim.Port
 	SetRemotePort(side Side, port sim.Port)
+
+	// GetChannelPort returns the port of a channel on the side. Channel 0 is
+	// the port that GetPort returns.
+	GetChannelPort(side Side, channel int) sim.Port
+
+	// SetChannelRemotePort sets the port that a channel on the side sends
+	// to.
+	SetChannelRemotePort(side Side, channel int, port sim.Port)
+
 	MapProgram(program []string)
+
+	// SetKernelArg sets the value that the ARGn operands of the program
+	// read, where n is the index.
+	SetKernelArg(index int, value uint32)
+
+	// SetRandomSeed restarts the random number generator that the RAND
+	// instructions of the tile read.
+	SetRandomSeed(seed uint64)
+
+	// SetSchedule makes the tile execute each line of its program at the
+	// time step of the line plus a multiple of the II, and panic if the
+	// line cannot execute then.
+	SetSchedule(steps []int, ii int)
+
+	// ReadRegister returns the value of a register of the tile.
+	ReadRegister(index int) uint32
+
+	// WriteRegister sets the value of a register of the tile.
+	WriteRegister(index int, value uint32)
+
+	// WriteMemory writes the data to the local memory of the tile, starting
+	// from the address in words.
+	WriteMemory(addr int, data []uint32)
+
+	// ReadMemory returns length words of the local memory of the tile,
+	// starting from the address.
+	ReadMemory(addr, length int) []uint32
+
+	// CheckProgram returns an error that lists all the reasons why the
+	// program cannot run on the tile, or nil if it can.
+	CheckProgram(program []string) error
+	GetActivityStats() ActivityStats
+
+	// GetCounters returns the counters that RDCOUNTER instructions read.
+	GetCounters() Counters
+
+	// GetPortStats returns the traffic through the port of channel 0 on the
+	// given side.
+	GetPortStats(side Side) PortStats
+	GetFreq() sim.Freq
+
+	// IsDone returns true if the tile has no program or if its program has
+	// executed a DONE instruction.
+	IsDone() bool
+
+	// GetRetVal returns the value recorded by the last RETURN_VALUE
+	// instruction of the tile. The second return value is false if no value
+	// has been recorded.
+	GetRetVal() (uint32, bool)
+
+	// WriteState writes the state of the tile, e.g., the registers and the
+	// buffers, to w in a stable binary form, so that states can be hashed
+	// and compared.
+	WriteState(w io.Writer)
+
+	// GetState returns a copy of the architectural state of the tile.
+	GetState() TileState
+
+	// SetHalted stops the tile from running instructions, or lets it run
+	// again. A halted tile still sends and receives tokens.
+	SetHalted(halted bool)
+
+	// SaveContext returns the context of the tile, e.g., its program, its
+	// registers, its memory, and its network buffers, and clears the tile
+	// so that it can run another program.
+	SaveContext() TileContext
+
+	// RestoreContext brings back a context that SaveContext of the tile has
+	// returned.
+	RestoreContext(ctx TileContext)
+
+	// GetError returns the error that has halted the tile, or nil if the
+	// tile has not failed to run an instruction since its program was
+	// mapped.
+	GetError() *ErrorRecord
+
+	// Watch calls hit in the cycle in which an instruction first sets the
+	// location of the watchpoint to its value, from a different value.
+	Watch(w Watchpoint, hit func(WatchHit))
 }
 
+// TileContext is the saved context of a tile, which only the tile that saved
+// it can restore.
+type TileContext interface{}
+
 // A Device is a CGRA device.
 type Device interface {
 	GetSize() (width, height int)
+
+	// GetTile returns the tile at the given coordinate, or nil if the tile is
+	// disabled.
 	GetTile(x, y int) Tile
+
+	// GetTileCoords returns the [x, y] coordinates of the tiles that are not
+	// disabled, in row-major order.
+	GetTileCoords() [][2]int
+
+	// DescribeTile returns the name, the ports, the neighbors, and the
+	// program of the tile at the given coordinate. It panics if the tile is
+	// disabled.
+	DescribeTile(x, y int) TileInfo
+
+	// GetChannels returns the number of channels that each tile has on each
+	// side. The tiles on the edges connect all their channels to the driver,
+	// while neighbor tiles connect through channel 0 only.
+	GetChannels() int
+
+	// GetSidePorts returns the ports on the given side of the device. Port
+	// i is the channel i % GetChannels() of the i / GetChannels()-th tile on
+	// the side. The ports of disabled tiles are nil.
 	GetSidePorts(side Side, portRange [2]int) []sim.Port
 }
 
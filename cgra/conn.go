@@ -0,0 +1,234 @@
+package cgra
+
+import "github.com/sarchlab/akita/v3/sim"
+
+// Timing is how a TimedConnection times the messages that one of its ports
+// sends. A message of n words takes ceil(n/Bandwidth) cycles of Freq to
+// enter the connection, after the messages before it, and arrives Latency
+// cycles of Freq after its last word enters. A Bandwidth of 0 does not
+// limit the words per cycle, so a message enters in one cycle and does not
+// delay the next.
+type Timing struct {
+	Freq      sim.Freq
+	Latency   int
+	Bandwidth int
+}
+
+// cycles returns the number of cycles that a message of the words takes to
+// enter the connection.
+func (t Timing) cycles(words int) int {
+	if t.Bandwidth <= 0 {
+		return 1
+	}
+
+	return (words + t.Bandwidth - 1) / t.Bandwidth
+}
+
+// TimedConnection connects ports with a latency and a bandwidth that each
+// port sets for the messages it sends. It can connect two neighbor tiles,
+// a tile and a driver in another clock domain, or the PEs of a cluster.
+// Each port receives at most one message per cycle, and the oldest message
+// that has arrived goes first.
+type TimedConnection struct {
+	sim.HookableBase
+
+	name   string
+	engine sim.Engine
+	freq   sim.Freq
+	ends   map[sim.Port]*timedEnd
+	queue  []timedMsg
+
+	// lastRecv is the time when each port has last received a message.
+	lastRecv map[sim.Port]sim.VTimeInSec
+}
+
+type timedEnd struct {
+	port     sim.Port
+	timing   Timing
+	bufSize  int
+	inFlight int
+	busy     bool
+
+	// free is the time when the connection can take the next message of
+	// the port.
+	free sim.VTimeInSec
+}
+
+type timedMsg struct {
+	msg       sim.Msg
+	freq      sim.Freq
+	readyTime sim.VTimeInSec
+}
+
+// NewTimedConnection creates a TimedConnection. The ports that PlugIn
+// connects send with a latency of 1 cycle of the frequency, without a limit
+// on the words per cycle.
+func NewTimedConnection(
+	name string,
+	engine sim.Engine,
+	freq sim.Freq,
+) *TimedConnection {
+	return &TimedConnection{
+		name:     name,
+		engine:   engine,
+		freq:     freq,
+		ends:     make(map[sim.Port]*timedEnd),
+		lastRecv: make(map[sim.Port]sim.VTimeInSec),
+	}
+}
+
+// Name returns the name of the connection.
+func (c *TimedConnection) Name() string {
+	return c.name
+}
+
+// PlugIn connects a port that sends with the default timing.
+func (c *TimedConnection) PlugIn(port sim.Port, sourceSideBufSize int) {
+	c.PlugInWithTiming(port, sourceSideBufSize, Timing{Freq: c.freq, Latency: 1})
+}
+
+// PlugInWithTiming connects a port that sends with the given timing. The
+// port can have sourceSideBufSize messages on the way at a time.
+func (c *TimedConnection) PlugInWithTiming(
+	port sim.Port,
+	sourceSideBufSize int,
+	timing Timing,
+) {
+	c.ends[port] = &timedEnd{
+		port:    port,
+		timing:  timing,
+		bufSize: sourceSideBufSize,
+	}
+
+	port.SetConnection(c)
+}
+
+// Unplug is not supported.
+func (c *TimedConnection) Unplug(_ sim.Port) {
+	panic("not implemented")
+}
+
+// CanSend checks if the connection can accept a message from the port.
+func (c *TimedConnection) CanSend(src sim.Port) bool {
+	end := c.ends[src]
+
+	canSend := end.inFlight < end.bufSize
+	if !canSend {
+		end.busy = true
+	}
+
+	return canSend
+}
+
+// Send puts a message into the connection.
+func (c *TimedConnection) Send(msg sim.Msg) *sim.SendError {
+	srcEnd := c.ends[msg.Meta().Src]
+	if _, ok := c.ends[msg.Meta().Dst]; !ok {
+		panic("dst is not connected")
+	}
+
+	if srcEnd.inFlight >= srcEnd.bufSize {
+		srcEnd.busy = true
+		return sim.NewSendError()
+	}
+
+	timing := srcEnd.timing
+	start := timing.Freq.ThisTick(c.engine.CurrentTime())
+	if srcEnd.free > start {
+		start = srcEnd.free
+	}
+
+	cycles := timing.cycles(msgWords(msg))
+	if timing.Bandwidth > 0 {
+		srcEnd.free = timing.Freq.NCyclesLater(cycles, start)
+	}
+
+	readyTime := timing.Freq.NCyclesLater(cycles+timing.Latency-1, start)
+	srcEnd.inFlight++
+	c.queue = append(c.queue,
+		timedMsg{msg: msg, freq: timing.Freq, readyTime: readyTime})
+
+	c.engine.Schedule(sim.MakeTickEvent(readyTime, c))
+
+	return nil
+}
+
+// msgWords returns the number of words that a message carries.
+func msgWords(msg sim.Msg) int {
+	words := msg.Meta().TrafficBytes / WordBytes
+	if words < 1 {
+		return 1
+	}
+
+	return words
+}
+
+// NotifyAvailable is called by a port when it can receive messages again.
+func (c *TimedConnection) NotifyAvailable(now sim.VTimeInSec, _ sim.Port) {
+	c.engine.Schedule(sim.MakeTickEvent(now, c))
+}
+
+// Handle delivers the messages that have arrived, at most one to each port.
+// A message whose port has received another one in this cycle waits for the
+// next cycle, and one whose port is full waits until the port is available.
+func (c *TimedConnection) Handle(e sim.Event) error {
+	now := e.Time()
+	blocked := make(map[sim.Port]bool)
+	next := sim.VTimeInSec(-1)
+	remaining := c.queue[:0]
+
+	for _, m := range c.queue {
+		dst := m.msg.Meta().Dst
+
+		switch {
+		case m.readyTime > now || blocked[dst]:
+			remaining = append(remaining, m)
+			continue
+		case c.received(dst, now):
+			if t := m.freq.NextTick(now); next < 0 || t < next {
+				next = t
+			}
+
+			remaining = append(remaining, m)
+
+			continue
+		}
+
+		m.msg.Meta().RecvTime = now
+		if dst.Recv(m.msg) != nil {
+			blocked[dst] = true
+			remaining = append(remaining, m)
+
+			continue
+		}
+
+		c.lastRecv[dst] = now
+		c.release(c.ends[m.msg.Meta().Src], now)
+	}
+
+	c.queue = remaining
+
+	if next >= 0 {
+		c.engine.Schedule(sim.MakeTickEvent(next, c))
+	}
+
+	return nil
+}
+
+// received returns true if the port has received a message at the time.
+func (c *TimedConnection) received(port sim.Port, now sim.VTimeInSec) bool {
+	t, ok := c.lastRecv[port]
+
+	return ok && t == now
+}
+
+// release frees the place of a delivered message of the port, and tells the
+// port if it has failed to send for the lack of that place.
+func (c *TimedConnection) release(end *timedEnd, now sim.VTimeInSec) {
+	end.inFlight--
+
+	if end.busy {
+		end.busy = false
+		end.port.NotifyAvailable(now)
+	}
+}
@@ -0,0 +1,45 @@
+package cgra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+// ErrorRecord describes an instruction that a tile fails to run, e.g.,
+// because it accesses an address out of the local memory. The tile halts at
+// the instruction, while the other tiles keep running.
+type ErrorRecord struct {
+	// Device and Tile are the number of the device and the [x, y]
+	// coordinate of the tile. The tile itself leaves them zero, and the
+	// driver fills them in.
+	Device int
+	Tile   [2]int
+
+	Time     sim.VTimeInSec
+	PC       uint32
+	Opcode   string
+	Operands []string
+
+	// Source is the source metadata of the instruction, if any.
+	Source string
+
+	Msg string
+}
+
+// Error returns the description of the error.
+func (r ErrorRecord) Error() string {
+	inst := r.Opcode
+	if len(r.Operands) > 0 {
+		inst += ", " + strings.Join(r.Operands, ", ")
+	}
+
+	s := fmt.Sprintf("device %d tile (%d, %d) line %d: %q: %s",
+		r.Device, r.Tile[0], r.Tile[1], r.PC, inst, r.Msg)
+	if r.Source != "" {
+		s += " (" + r.Source + ")"
+	}
+
+	return s
+}
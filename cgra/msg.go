@@ -7,6 +7,20 @@ type MoveMsg struct {
 	sim.MsgMeta
 
 	Data uint32
+
+	// Extra are the words that follow Data in a wide message. The words of
+	// a message move together.
+	Extra []uint32
+
+	// Invalid marks a token whose predicate is false. The token keeps its
+	// place in the stream, but its data is not a result.
+	Invalid bool
+
+	// Copy marks a message of a block copy, whose words the receiving PE
+	// writes to its local memory from CopyAddr rather than to a NET_RECV
+	// register.
+	Copy     bool
+	CopyAddr uint32
 }
 
 // Meta returns the meta data of the msg.
@@ -14,11 +28,23 @@ func (m *MoveMsg) Meta() *sim.MsgMeta {
 	return &m.MsgMeta
 }
 
+// Words returns the words of the msg, Data first.
+func (m *MoveMsg) Words() []uint32 {
+	return append([]uint32{m.Data}, m.Extra...)
+}
+
+// WordBytes is the number of bytes of a word of a MoveMsg.
+const WordBytes = 4
+
 // MoveMsgBuilder is a factory for MoveMsg.
 type MoveMsgBuilder struct {
 	src, dst sim.Port
 	sendTime sim.VTimeInSec
 	data     uint32
+	extra    []uint32
+	invalid  bool
+	copy     bool
+	copyAddr uint32
 }
 
 // WithSrc sets the source port of the msg.
@@ -45,15 +71,48 @@ func (m MoveMsgBuilder) WithData(data uint32) MoveMsgBuilder {
 	return m
 }
 
-// Build creates a MoveMsg.
+// WithExtra sets the words that follow the data in a wide msg.
+func (m MoveMsgBuilder) WithExtra(extra []uint32) MoveMsgBuilder {
+	m.extra = extra
+	return m
+}
+
+// WithInvalid marks the msg as a token whose predicate is false.
+func (m MoveMsgBuilder) WithInvalid(invalid bool) MoveMsgBuilder {
+	m.invalid = invalid
+	return m
+}
+
+// WithCopyAddr marks the msg as a block copy to the address in the local
+// memory of the receiver.
+func (m MoveMsgBuilder) WithCopyAddr(addr uint32) MoveMsgBuilder {
+	m.copy = true
+	m.copyAddr = addr
+
+	return m
+}
+
+// Build creates a MoveMsg. The traffic of the msg is WordBytes for each
+// word, so that the network charges wide msgs for the extra words, and for
+// the address of a block copy.
 func (m MoveMsgBuilder) Build() *MoveMsg {
+	words := 1 + len(m.extra)
+	if m.copy {
+		words++
+	}
+
 	return &MoveMsg{
 		MsgMeta: sim.MsgMeta{
-			ID:       sim.GetIDGenerator().Generate(),
-			Src:      m.src,
-			Dst:      m.dst,
-			SendTime: m.sendTime,
+			ID:           sim.GetIDGenerator().Generate(),
+			Src:          m.src,
+			Dst:          m.dst,
+			SendTime:     m.sendTime,
+			TrafficBytes: WordBytes * words,
 		},
-		Data: m.data,
+		Data:     m.data,
+		Extra:    m.extra,
+		Invalid:  m.invalid,
+		Copy:     m.copy,
+		CopyAddr: m.copyAddr,
 	}
 }
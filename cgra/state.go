@@ -0,0 +1,56 @@
+package cgra
+
+// TileState is the architectural state of a tile at a point in time.
+type TileState struct {
+	PC        uint32   `json:"pc"`
+	Registers []uint32 `json:"registers"`
+
+	// RecvBuf holds the value in the NET_RECV register of each side, and
+	// RecvReady is true if the value has arrived and has not been consumed.
+	RecvBuf   []uint32 `json:"recv_buf"`
+	RecvReady []bool   `json:"recv_ready"`
+
+	// SendBuf holds the value in the NET_SEND register of each side, and
+	// SendBusy is true if the value has not been sent yet.
+	SendBuf  []uint32 `json:"send_buf"`
+	SendBusy []bool   `json:"send_busy"`
+
+	Done      bool   `json:"done"`
+	RetVal    uint32 `json:"ret_val"`
+	HasRetVal bool   `json:"has_ret_val"`
+}
+
+// ProgramSummary describes the program that is mapped to a tile and how far
+// the tile has run it.
+type ProgramSummary struct {
+	// Instructions is the number of instructions, not counting the labels.
+	Instructions int
+
+	// Opcodes counts the instructions by the opcode.
+	Opcodes map[string]int
+
+	// PC is the line that the tile runs next, and Current is the next
+	// instruction, skipping the labels, or empty if the program has
+	// finished.
+	PC      uint32
+	Current string
+
+	Done bool
+}
+
+// TileInfo describes a tile of a device for external tools, e.g.,
+// visualizers and mappers.
+type TileInfo struct {
+	X, Y int
+	Name string
+
+	// Ports are the full names of the ports of the tile, by the side and
+	// then by the channel, as they appear in the trace.
+	Ports []string
+
+	// Neighbors has the coordinate of the tile on each side that connects
+	// to another tile of the same device.
+	Neighbors map[Side][2]int
+
+	Program ProgramSummary
+}
@@ -0,0 +1,107 @@
+package cgra
+
+// ActivityStats is the breakdown of the cycles of a PE. Energy models can
+// treat the idle cycles as clock-gated cycles.
+type ActivityStats struct {
+	// Cycles is the number of cycles that have passed in the clock domain of
+	// the PE.
+	Cycles uint64
+
+	// InstCycles is the number of cycles in which the PE executed an
+	// instruction.
+	InstCycles uint64
+
+	// ComputeCycles is the number of cycles in which the PE executed an
+	// arithmetic or comparison instruction, which is a part of InstCycles.
+	ComputeCycles uint64
+
+	// PortCycles is the number of cycles in which the PE sent or received
+	// data.
+	PortCycles uint64
+
+	// ActiveCycles is the number of cycles in which the PE executed an
+	// instruction, sent data, or received data.
+	ActiveCycles uint64
+
+	// IdleCycles is the number of cycles in which the PE did nothing.
+	IdleCycles uint64
+
+	// Stalls classifies the cycles in which the PE did not execute an
+	// instruction.
+	Stalls StallStats
+}
+
+// Counters are the counters of a PE, which RDCOUNTER instructions read.
+type Counters struct {
+	// Cycles is the number of cycles that have passed in the clock domain
+	// of the PE.
+	Cycles uint64
+
+	// Ops is the number of instructions that the PE has executed.
+	Ops uint64
+
+	// Tokens is the number of messages that the PE has received through
+	// all of its ports.
+	Tokens uint64
+}
+
+// StallStats is the breakdown of the cycles in which a PE could not execute
+// an instruction, by the reason.
+type StallStats struct {
+	// InputCycles is the number of cycles in which the instruction waited
+	// for data on a NET_RECV register.
+	InputCycles uint64
+
+	// OutputCycles is the number of cycles in which the instruction waited
+	// for a busy NET_SEND register.
+	OutputCycles uint64
+
+	// BarrierCycles is the number of cycles in which the PE waited at a
+	// barrier.
+	BarrierCycles uint64
+
+	// NoProgramCycles is the number of cycles in which the PE had no
+	// program, or had finished it.
+	NoProgramCycles uint64
+
+	// ScheduleCycles is the number of cycles in which the instruction
+	// waited for its cycle in the static schedule of the PE.
+	ScheduleCycles uint64
+}
+
+// PortStats is the traffic through a port of a PE.
+type PortStats struct {
+	// Sent is the number of messages sent through the port.
+	Sent uint64
+
+	// Received is the number of messages that have arrived at the port.
+	Received uint64
+
+	// Stalls is the number of times that sending a message through the port
+	// was rejected because the receiver was full.
+	Stalls uint64
+
+	// PeakOccupancy is the largest number of received messages that waited
+	// at the port, including the one in the NET_RECV register.
+	PeakOccupancy int
+}
+
+// Link is the link that leaves the tile at [X, Y] on the Side, which leads
+// to a neighbor or, on the edge of the device, to the driver.
+type Link struct {
+	X, Y int
+	Side Side
+}
+
+// LinkStats is the traffic on a link.
+type LinkStats struct {
+	// Messages is the number of messages sent over the link.
+	Messages uint64
+
+	// Stalls is the number of times that the receiver rejected a message.
+	Stalls uint64
+
+	// PeakOccupancy is the largest number of messages that waited at the
+	// receiver. It is 0 for links to the driver.
+	PeakOccupancy int
+}
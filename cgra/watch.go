@@ -0,0 +1,59 @@
+package cgra
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+// Watchpoint is a register or a word of the local memory of a tile, and the
+// value to watch for.
+type Watchpoint struct {
+	// Memory selects a word of the local memory instead of a register.
+	Memory bool
+
+	// Index is the register or the address of the word.
+	Index int
+
+	Value uint32
+}
+
+// String describes the watchpoint, e.g., "$3 == 7" or "mem[12] == 7".
+func (w Watchpoint) String() string {
+	if w.Memory {
+		return fmt.Sprintf("mem[%d] == %d", w.Index, w.Value)
+	}
+
+	return fmt.Sprintf("$%d == %d", w.Index, w.Value)
+}
+
+// WatchHit records the instruction that has first set a watched location to
+// its value.
+type WatchHit struct {
+	// Device and Tile are the number of the device and the [x, y]
+	// coordinate of the tile. The tile itself leaves them zero, and the
+	// driver fills them in.
+	Device int
+	Tile   [2]int
+
+	Watchpoint Watchpoint
+
+	Time sim.VTimeInSec
+	PC   uint32
+	Inst string
+
+	// Source is the source metadata of the instruction, if any.
+	Source string
+}
+
+// String describes the hit with its context.
+func (h WatchHit) String() string {
+	s := fmt.Sprintf("device %d tile (%d, %d) at %.0f ns: %s after line %d: %q",
+		h.Device, h.Tile[0], h.Tile[1], float64(h.Time)*1e9,
+		h.Watchpoint, h.PC, h.Inst)
+	if h.Source != "" {
+		s += " (" + h.Source + ")"
+	}
+
+	return s
+}
@@ -0,0 +1,34 @@
+// Command zeonica-server runs simulations for remote clients over HTTP.
+// See the server package for the endpoints.
+//
+// Usage:
+//
+//	zeonica-server [-addr :8080] [-dir jobs] [-workers 1] [-queue 64]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/sarchlab/zeonica/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "the address to listen on")
+	dir := flag.String("dir", "jobs", "the directory of the files of the jobs")
+	workers := flag.Int("workers", 1, "the number of jobs that run at a time")
+	queue := flag.Int("queue", 64, "the number of jobs that can wait")
+	flag.Parse()
+
+	s, err := server.New(*dir, *workers, *queue)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "zeonica-server:", err)
+		os.Exit(1)
+	}
+
+	log.Printf("serving on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
@@ -0,0 +1,62 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command zeonica-wasm runs small kernels in a browser, for teaching. It
+// defines the JavaScript function zeonicaRun, which takes a job of the
+// server package in JSON and returns
+//
+//	{"results": {...}, "error": "...", "trace": "..."}
+//
+// Build it with GOOS=js GOARCH=wasm and serve it with web/index.html.
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"syscall/js"
+
+	"github.com/sarchlab/zeonica/server"
+)
+
+type response struct {
+	Results *server.Results `json:"results"`
+	Error   string          `json:"error,omitempty"`
+	Trace   string          `json:"trace,omitempty"`
+}
+
+func run(_ js.Value, args []js.Value) interface{} {
+	rsp := response{}
+	job := server.Job{}
+
+	err := json.Unmarshal([]byte(args[0].String()), &job)
+	if err == nil {
+		var (
+			trace *strings.Builder
+			w     io.Writer
+		)
+
+		if job.Trace {
+			trace = &strings.Builder{}
+			w = trace
+		}
+
+		rsp.Results, err = server.Run(job, w)
+		if trace != nil {
+			rsp.Trace = trace.String()
+		}
+	}
+
+	if err != nil {
+		rsp.Error = err.Error()
+	}
+
+	data, _ := json.Marshal(rsp)
+
+	return string(data)
+}
+
+func main() {
+	js.Global().Set("zeonicaRun", js.FuncOf(run))
+	select {}
+}
@@ -0,0 +1,16 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Fprintln(os.Stderr,
+		"zeonica-wasm only runs in a browser, build it with "+
+			"GOOS=js GOARCH=wasm")
+	os.Exit(1)
+}
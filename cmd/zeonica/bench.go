@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sarchlab/zeonica/bench"
+)
+
+func runBenchmarks(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	baselinePath := flags.String("baseline", "",
+		"compare the results with a baseline file")
+	update := flags.Bool("update", false,
+		"write the results to the baseline file instead of comparing")
+	tolerance := flags.Float64("tolerance", 0.2,
+		"the slowdown, as a fraction, that is not a regression")
+	runs := flags.Int("runs", 3, "run each kernel this many times and keep the fastest")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica bench [flags] [kernels]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	kernels, err := benchKernels(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	results, err := bench.RunAll(kernels, *runs)
+	if err != nil {
+		return err
+	}
+
+	if *update {
+		if *baselinePath == "" {
+			return fmt.Errorf("-update requires -baseline")
+		}
+
+		return bench.Baseline(results).Save(*baselinePath)
+	}
+
+	baseline := bench.Baseline{}
+	if *baselinePath != "" {
+		baseline, err = bench.LoadBaseline(*baselinePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	regressions := 0
+	for _, c := range bench.Compare(baseline, results) {
+		fmt.Println(c)
+
+		if c.Regressed(*tolerance) {
+			regressions++
+		}
+	}
+
+	if regressions > 0 {
+		return fmt.Errorf("%d kernels regressed", regressions)
+	}
+
+	return nil
+}
+
+// benchKernels returns the kernels with the names, or all the kernels if no
+// name is given.
+func benchKernels(names []string) ([]bench.Kernel, error) {
+	if len(names) == 0 {
+		return bench.Kernels, nil
+	}
+
+	kernels := make([]bench.Kernel, 0, len(names))
+	for _, name := range names {
+		k, err := bench.FindKernel(name)
+		if err != nil {
+			return nil, err
+		}
+
+		kernels = append(kernels, k)
+	}
+
+	return kernels, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// loadPrograms loads a program file in the YAML format if it has a .yaml or
+// .yml extension, or in the ASM format otherwise.
+func loadPrograms(path string) (map[[2]int]string, error) {
+	if isYAML(path) {
+		return core.LoadProgramFileFromYAML(path)
+	}
+
+	return core.LoadProgramFileFromASM(path)
+}
+
+// loadArch loads an arch spec. If the path is empty, the architecture is a
+// mesh that is just large enough for the programs.
+func loadArch(
+	path string,
+	programs map[[2]int]string,
+) (config.DeviceBuilder, verify.ArchInfo, error) {
+	if path != "" {
+		return config.LoadArchSpec(path)
+	}
+
+	spec := config.ArchSpec{Topology: config.MeshInterconnect}
+	for coord := range programs {
+		if coord[0]+1 > spec.Columns {
+			spec.Columns = coord[0] + 1
+		}
+
+		if coord[1]+1 > spec.Rows {
+			spec.Rows = coord[1] + 1
+		}
+	}
+
+	return spec.DeviceBuilder(), spec.ArchInfo(), nil
+}
+
+// sortCoords sorts [x, y] coordinates in row-major order.
+func sortCoords(coords [][2]int) [][2]int {
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	return coords
+}
+
+func parseSide(name string) (cgra.Side, error) {
+	for side := cgra.North; side <= cgra.West; side++ {
+		if strings.EqualFold(name, side.Name()) {
+			return side, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid side %q", name)
+}
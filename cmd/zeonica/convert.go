@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sarchlab/zeonica/core"
+)
+
+func convert(args []string) error {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica convert <input> <output>")
+		fmt.Fprintln(flags.Output(),
+			"Files with the .yaml or .yml extension are in the YAML format, "+
+				"and other files are in the ASM format.")
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("convert requires an input and an output file")
+	}
+
+	programs, err := loadPrograms(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if isYAML(flags.Arg(1)) {
+		return core.WriteProgramsToYAML(f, programs)
+	}
+
+	return core.WriteProgramsToASM(f, programs)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sarchlab/zeonica/snapshot"
+)
+
+func diffSnapshots(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(),
+			"Usage: zeonica diff <before.json> <after.json>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("diff requires two snapshot files")
+	}
+
+	a, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	b, err := snapshot.Load(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diffs := snapshot.Diff(a, b)
+
+	err = snapshot.WriteDiff(os.Stdout, diffs)
+	if err != nil {
+		return err
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("found %d differences", len(diffs))
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+// Command zeonica runs, verifies, and converts CGRA programs.
+//
+// Usage:
+//
+//	zeonica <command> [flags] [args]
+//
+// Run "zeonica <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"run":       {"run a scenario", runScenario},
+	"verify":    {"check programs against an architecture", verifyPrograms},
+	"convert":   {"convert programs between the ASM and YAML formats", convert},
+	"trace":     {"summarize a trace log", summarizeTrace},
+	"report":    {"write a verification report", writeReport},
+	"diff":      {"compare the final states of two runs", diffSnapshots},
+	"bench":     {"measure the simulation speed on the benchmark kernels", runBenchmarks},
+	"timing":    {"compare the elastic and the strict timing of a scenario", compareTiming},
+	"testbench": {"run a directory of kernels and report which pass", runTestbench},
+	"retime":    {"delay the schedule of a scenario to fix its timing", retime},
+	"sweep":     {"run the bench kernels over a grid of parameters", runSweep},
+	"replay":    {"replay a recorded run and find where it differs", replay},
+	"scaling":   {"validate axpy, gemv, and gemm over a sweep of sizes", runScaling},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: zeonica <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "zeonica: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "zeonica:", err)
+		os.Exit(1)
+	}
+}
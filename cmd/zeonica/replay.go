@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+func replay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	seed := flags.Int64("seed", 0, "the seed of the recorded run")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(),
+			"Usage: zeonica replay [flags] <scenario.yaml> <run.json>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errors.New("replay requires a scenario and a recording")
+	}
+
+	s, err := loadScenario(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, driver, _, err := buildDriver(
+		s, trace.Discard, api.DriverBuilder{}.WithSeed(*seed))
+	if err != nil {
+		return err
+	}
+
+	err = driver.Replay(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("the replay matches the recording")
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarchlab/zeonica/verify"
+)
+
+func writeReport(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	archPath := flags.String("arch", "", "the arch spec file")
+	format := flags.String("format", "text", "text, markdown, or html")
+	output := flags.String("o", "", "the output file, stdout if empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(),
+			"Usage: zeonica report [flags] <programs>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("report requires a program file")
+	}
+
+	programs, err := loadPrograms(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, arch, err := loadArch(*archPath, programs)
+	if err != nil {
+		return err
+	}
+
+	report := verify.GenerateReport(programs, arch)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	switch *format {
+	case "text":
+		return report.WriteText(w)
+	case "markdown", "md":
+		return report.WriteMarkdown(w)
+	case "html":
+		return report.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown report format %q", *format)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/mapper"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+// retime fixes the timing issues of the schedule of a scenario and prints
+// the delays and the corrected schedule, which can replace the schedule of
+// the scenario.
+func retime(args []string) error {
+	flags := flag.NewFlagSet("retime", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica retime <scenario>")
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("retime requires a scenario file")
+	}
+
+	s, err := loadScenario(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	file, err := core.LoadProgramFile(s.Programs)
+	if err != nil {
+		return err
+	}
+
+	_, arch, err := loadArch(s.Arch, file.Programs)
+	if err != nil {
+		return err
+	}
+
+	schedules := make(map[[2]int]verify.Schedule)
+	for _, sch := range s.Schedule {
+		schedules[sch.PE] = verify.Schedule{Steps: sch.Steps, II: sch.II}
+	}
+
+	r, err := mapper.Retime(file.Programs, schedules, arch)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range r.Delays {
+		fmt.Printf("# PE(%d, %d) line %d: +%d cycles\n",
+			d.Inst.PE[0], d.Inst.PE[1], d.Inst.Line, d.Cycles)
+	}
+
+	fmt.Printf("# added latency: %d cycles\n", r.AddedLatency)
+
+	for _, issue := range verify.CheckGroups(file.Programs, r.Schedules, arch) {
+		fmt.Printf("# %s\n", issue)
+	}
+
+	fmt.Println("schedule:")
+
+	for _, sch := range s.Schedule {
+		steps := make([]string, 0, len(sch.Steps))
+		for _, step := range r.Schedules[sch.PE].Steps {
+			steps = append(steps, fmt.Sprint(step))
+		}
+
+		fmt.Printf("  - {pe: [%d, %d], ii: %d, steps: [%s]}\n",
+			sch.PE[0], sch.PE[1], sch.II, strings.Join(steps, ", "))
+	}
+
+	return nil
+}
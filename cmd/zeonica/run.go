@@ -0,0 +1,635 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sarchlab/akita/v3/monitoring"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/snapshot"
+	"github.com/sarchlab/zeonica/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// scenario is a simulation setup. The paths are relative to the scenario
+// file. A scenario looks like
+//
+//	arch: arch_spec.yaml
+//	programs: kernel.yaml
+//	feed_in:
+//	  - {side: west, ports: [0, 1], stride: 1, data: [1, 2, 3]}
+//	collect:
+//	  - {side: east, ports: [0, 1], stride: 1, length: 3}
+//	constants: {N: 3}
+//	args: [5, 7]
+//	random_seed: 42
+//	schedule:
+//	  - {pe: [0, 0], ii: 4, steps: [0, 1, 2]}
+//	dram: {beats_per_cycle: 2, burst_words: 8, latency: 20}
+//
+// The constants override the named constants of the program file. The args
+// are the kernel arguments that ARG0, ARG1, ... read. The random seed seeds
+// the generators that RAND reads. The schedule has the time step of each
+// line of the program of a PE, which the strict timing mode enforces. The
+// dram, if set, makes the data cross an api.DRAMInterface.
+type scenario struct {
+	Arch       string             `yaml:"arch"`
+	Programs   string             `yaml:"programs"`
+	FeedIn     []scenarioIO       `yaml:"feed_in"`
+	Collect    []scenarioIO       `yaml:"collect"`
+	Constants  map[string]string  `yaml:"constants"`
+	Args       []uint32           `yaml:"args"`
+	RandomSeed uint64             `yaml:"random_seed"`
+	Schedule   []scenarioSchedule `yaml:"schedule"`
+	DRAM       *scenarioDRAM      `yaml:"dram"`
+}
+
+type scenarioDRAM struct {
+	BeatsPerCycle int `yaml:"beats_per_cycle"`
+	BurstWords    int `yaml:"burst_words"`
+	Latency       int `yaml:"latency"`
+	Outstanding   int `yaml:"outstanding"`
+}
+
+type scenarioSchedule struct {
+	PE    [2]int `yaml:"pe"`
+	II    int    `yaml:"ii"`
+	Steps []int  `yaml:"steps"`
+}
+
+type scenarioIO struct {
+	Side   string   `yaml:"side"`
+	Ports  [2]int   `yaml:"ports"`
+	Stride int      `yaml:"stride"`
+	Data   []uint32 `yaml:"data"`
+	Length int      `yaml:"length"`
+
+	// StartCycle and Period, if either is set, schedule the rounds of a
+	// FeedIn as FeedInAt does. Period defaults to 1. On a Collect, Period
+	// limits the rate of the sink as CollectAtRate does.
+	StartCycle int `yaml:"start_cycle"`
+	Period     int `yaml:"period"`
+}
+
+func loadScenario(path string) (scenario, error) {
+	s := scenario{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+
+	err = yaml.Unmarshal(data, &s)
+	if err != nil {
+		return s, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	if s.Programs == "" {
+		return s, fmt.Errorf("%s: programs is not set", path)
+	}
+
+	dir := filepath.Dir(path)
+	s.Programs = filepath.Join(dir, s.Programs)
+	if s.Arch != "" {
+		s.Arch = filepath.Join(dir, s.Arch)
+	}
+
+	return s, nil
+}
+
+// runOptions are the flags of the run command.
+type runOptions struct {
+	traceFile        string
+	binaryTrace      bool
+	traceLevel       string
+	traceValue       string
+	linkStats        bool
+	stallStats       bool
+	roofline         bool
+	strict           bool
+	seed             int64
+	checkDeterminism bool
+	snapshotFile     string
+	monitor          bool
+	monitorPort      int
+	recordFile       string
+}
+
+func parseRunFlags(args []string) (runOptions, []string) {
+	o := runOptions{}
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	flags.StringVar(&o.traceFile, "trace", "",
+		"write the trace to a file instead of the standard output")
+	flags.BoolVar(&o.binaryTrace, "binary", false,
+		"write the trace in the binary format")
+	flags.StringVar(&o.traceLevel, "trace-level", "all",
+		"the events to trace: all, message, inst, or off")
+	flags.StringVar(&o.traceValue, "trace-value", "",
+		"only trace the data events whose value matches, e.g., eq=0xdead,nan")
+	flags.BoolVar(&o.linkStats, "link-stats", false,
+		"print the traffic on each link, busiest first")
+	flags.BoolVar(&o.stallStats, "stall-stats", false,
+		"print why each PE stalled, by the number of cycles")
+	flags.BoolVar(&o.roofline, "roofline", false,
+		"print the achieved compute and bandwidth against the peaks")
+	flags.BoolVar(&o.strict, "strict", false,
+		"enforce the schedule of the scenario instead of elastic timing")
+	flags.Int64Var(&o.seed, "seed", 0,
+		"the seed that breaks ties in the driver, 0 for the creation order")
+	flags.BoolVar(&o.checkDeterminism, "check-determinism", false,
+		"run the scenario again and fail if the final state differs")
+	flags.StringVar(&o.snapshotFile, "snapshot", "",
+		"save the final state of the PEs to a file for zeonica diff")
+	flags.StringVar(&o.recordFile, "record", "",
+		"record the driver calls and the boundary traffic for zeonica replay")
+	flags.BoolVar(&o.monitor, "monitor", false,
+		"serve the akita monitor, which streams the trace at /api/trace, "+
+			"and run once a client subscribes")
+	flags.IntVar(&o.monitorPort, "monitor-port", 0,
+		"the port of the monitor, a random one by default")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica run [flags] <scenario.yaml>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+	}
+
+	return o, flags.Args()
+}
+
+func runScenario(args []string) error {
+	o, args := parseRunFlags(args)
+	if len(args) != 1 {
+		return errors.New("run requires a scenario file")
+	}
+
+	s, err := loadScenario(args[0])
+	if err != nil {
+		return err
+	}
+
+	tracer, closeTrace, err := openTracer(o.traceFile, o.binaryTrace)
+	if err != nil {
+		return err
+	}
+
+	filtered, err := filterTrace(tracer, o)
+	if err != nil {
+		_ = closeTrace()
+		return err
+	}
+
+	var beforeRun func(sim.Engine, api.Driver)
+
+	if o.monitor {
+		stream := trace.NewStream()
+		defer stream.Close()
+
+		filtered = trace.Tee(filtered, stream)
+		beforeRun = func(engine sim.Engine, driver api.Driver) {
+			startMonitor(engine, driver, stream, o.monitorPort)
+		}
+	}
+
+	b := api.DriverBuilder{}.WithSeed(o.seed)
+	if o.recordFile != "" {
+		b = b.WithRecording()
+	}
+
+	driver, outputs, err := simulate(s, filtered, b, o.strict, beforeRun)
+	if closeErr := closeTrace(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	printResults(driver, outputs, o)
+
+	if o.recordFile != "" {
+		err = driver.SaveRecording(o.recordFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.snapshotFile != "" {
+		err = snapshot.New(driver.GetTileStates()).Save(o.snapshotFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.checkDeterminism {
+		return rerun(s, o, driver.StateHash())
+	}
+
+	return nil
+}
+
+// startMonitor serves the akita monitor of the engine and the driver, with
+// the stream at /api/trace, and waits for the first subscriber of the
+// stream, so that it does not miss the first events.
+func startMonitor(
+	engine sim.Engine,
+	driver api.Driver,
+	stream *trace.Stream,
+	port int,
+) {
+	http.Handle("/api/trace", stream.Handler())
+
+	monitor := monitoring.NewMonitor().WithPortNumber(port)
+	monitor.RegisterEngine(engine)
+	monitor.RegisterComponent(driver.(sim.Component))
+	monitor.StartServer()
+
+	fmt.Fprintln(os.Stderr, "Waiting for a subscriber of /api/trace")
+
+	for stream.Subscribers() == 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// filterTrace applies the trace level and the value predicate of the
+// options to the tracer.
+func filterTrace(tracer trace.Tracer, o runOptions) (trace.Tracer, error) {
+	level, err := trace.ParseLevel(o.traceLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer = trace.Filter(tracer, level)
+	if o.traceValue == "" {
+		return tracer, nil
+	}
+
+	match, err := trace.ParseValuePredicate(o.traceValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return trace.FilterValues(tracer, match), nil
+}
+
+// simulate runs the scenario and returns the driver and the collected data.
+// In the strict timing mode, the PEs follow the schedule of the scenario.
+func simulate(
+	s scenario,
+	tracer trace.Tracer,
+	b api.DriverBuilder,
+	strict bool,
+	beforeRun func(sim.Engine, api.Driver),
+) (api.Driver, [][]uint32, error) {
+	engine, driver, file, err := buildDriver(s, tracer, b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	programs := file.Programs
+
+	err = setKernelParams(driver, file.Constants, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputs, err := setUpIO(driver, s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapPrograms(driver, programs, s, strict)
+
+	if beforeRun != nil {
+		beforeRun(engine, driver)
+	}
+
+	driver.Run()
+
+	return driver, outputs, nil
+}
+
+// buildDriver loads the programs of the scenario and builds the driver and
+// the device that they run on, without mapping them.
+func buildDriver(
+	s scenario,
+	tracer trace.Tracer,
+	b api.DriverBuilder,
+) (sim.Engine, api.Driver, *core.ProgramFile, error) {
+	file, err := core.LoadProgramFile(s.Programs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	builder, _, err := loadArch(s.Arch, file.Programs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if s.DRAM != nil {
+		dram := api.DRAMInterface(*s.DRAM)
+		if err := dram.Validate(); err != nil {
+			return nil, nil, nil, err
+		}
+
+		b = b.WithDRAMInterface(dram)
+	}
+
+	engine := sim.NewSerialEngine()
+	driver := b.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Driver")
+	driver.RegisterDevice(builder.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithTracer(tracer).
+		Build("Device"))
+
+	return engine, driver, file, nil
+}
+
+// mapPrograms maps the programs in row-major order and, in the strict timing
+// mode, sets the schedule of the scenario.
+func mapPrograms(
+	driver api.Driver,
+	programs map[[2]int]string,
+	s scenario,
+	strict bool,
+) {
+	coords := make([][2]int, 0, len(programs))
+	for coord := range programs {
+		coords = append(coords, coord)
+	}
+
+	for _, coord := range sortCoords(coords) {
+		driver.MapProgram(programs[coord], coord)
+	}
+
+	if !strict {
+		return
+	}
+
+	for _, sch := range s.Schedule {
+		driver.SetSchedule(sch.PE, sch.Steps, sch.II)
+	}
+}
+
+// setKernelParams sets the constants of the program file, the overrides of
+// the scenario, and the kernel arguments and the random seed of the
+// scenario.
+func setKernelParams(
+	driver api.Driver,
+	constants map[string]uint32,
+	s scenario,
+) error {
+	for name, v := range constants {
+		driver.SetProgramConstant(name, v)
+	}
+
+	for name, value := range s.Constants {
+		v, err := core.ParseConstant(value)
+		if err != nil {
+			return fmt.Errorf("constant %s: %w", name, err)
+		}
+
+		driver.SetProgramConstant(name, v)
+	}
+
+	if len(s.Args) > core.NumKernelArgs {
+		return fmt.Errorf("%d args, at most %d are supported",
+			len(s.Args), core.NumKernelArgs)
+	}
+
+	for i, v := range s.Args {
+		driver.SetKernelArg(i, v)
+	}
+
+	driver.SetRandomSeed(s.RandomSeed)
+
+	return nil
+}
+
+// rerun runs the scenario again without tracing and checks that the final
+// state matches the hash of the first run.
+func rerun(s scenario, o runOptions, hash uint64) error {
+	driver, _, err := simulate(s, trace.Discard,
+		api.DriverBuilder{}.WithSeed(o.seed), o.strict, nil)
+	if err != nil {
+		return err
+	}
+
+	if driver.StateHash() != hash {
+		return fmt.Errorf("nondeterministic run: the state hash is %016x "+
+			"in the first run and %016x in the second run",
+			hash, driver.StateHash())
+	}
+
+	fmt.Printf("state hash: %016x, deterministic\n", hash)
+
+	return nil
+}
+
+func printResults(driver api.Driver, outputs [][]uint32, o runOptions) {
+	for i, out := range outputs {
+		fmt.Printf("collect[%d]: %v\n", i, out)
+	}
+
+	values := driver.GetReturnValues()
+	coords := make([][2]int, 0, len(values))
+	for coord := range values {
+		coords = append(coords, coord)
+	}
+
+	for _, coord := range sortCoords(coords) {
+		fmt.Printf("return value of PE(%d, %d): %d\n",
+			coord[0], coord[1], values[coord])
+	}
+
+	for _, e := range driver.GetErrors() {
+		fmt.Printf("error: %v\n", e)
+	}
+
+	if o.linkStats {
+		printLinkStats(driver.GetLinkStats())
+	}
+
+	if o.stallStats {
+		printStallStats(driver.GetActivityStats())
+	}
+
+	if o.roofline {
+		printRoofline(driver.GetRoofline())
+	}
+
+	if stats := driver.GetDRAMStats(); stats != (api.DRAMStats{}) {
+		printDRAMStats(stats)
+	}
+}
+
+// printDRAMStats prints the traffic over each channel of the DRAM
+// interface.
+func printDRAMStats(s api.DRAMStats) {
+	fmt.Printf("dram read:  %d words in %d bursts, %d busy cycles, "+
+		"%d stall cycles\n", s.ReadWords, s.ReadBursts, s.ReadBusyCycles,
+		s.ReadStallCycles)
+	fmt.Printf("dram write: %d words in %d bursts, %d busy cycles, "+
+		"%d stall cycles\n", s.WriteWords, s.WriteBursts, s.WriteBusyCycles,
+		s.WriteStallCycles)
+}
+
+// printRoofline prints the achieved rates against the peaks and the limit
+// at the arithmetic intensity of the run.
+func printRoofline(r api.Roofline) {
+	fmt.Printf("cycles:     %d\n", r.Cycles)
+	fmt.Printf("compute:    %.3f of %.0f ops/cycle (%d ops)\n",
+		r.OpsPerCycle, r.PeakOpsPerCycle, r.ComputeOps)
+	fmt.Printf("bandwidth:  %.3f of %.0f words/cycle (%d words)\n",
+		r.WordsPerCycle, r.PeakWordsPerCycle, r.BoundaryWords)
+	fmt.Printf("intensity:  %.3f ops/word\n", r.Intensity)
+	fmt.Printf("bound:      %s, %.1f%% of the roof\n",
+		r.Bound, r.Efficiency*100)
+}
+
+// printStallStats prints the cycles of each PE that did not execute an
+// instruction, by the reason.
+func printStallStats(stats map[[2]int]cgra.ActivityStats) {
+	coords := make([][2]int, 0, len(stats))
+	for coord := range stats {
+		coords = append(coords, coord)
+	}
+
+	fmt.Printf("%-10s %8s %8s %8s %8s %8s %8s %8s\n", "PE",
+		"Cycles", "Inst", "Input", "Output", "Barrier", "Schedule", "NoProg")
+
+	for _, coord := range sortCoords(coords) {
+		s := stats[coord]
+		name := fmt.Sprintf("PE(%d, %d)", coord[0], coord[1])
+		fmt.Printf("%-10s %8d %8d %8d %8d %8d %8d %8d\n", name,
+			s.Cycles, s.InstCycles, s.Stalls.InputCycles, s.Stalls.OutputCycles,
+			s.Stalls.BarrierCycles, s.Stalls.ScheduleCycles,
+			s.Stalls.NoProgramCycles)
+	}
+}
+
+// printLinkStats prints the links in the order of the number of messages,
+// so that the hot links come first.
+func printLinkStats(stats map[cgra.Link]cgra.LinkStats) {
+	links := make([]cgra.Link, 0, len(stats))
+	for l := range stats {
+		links = append(links, l)
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		a, b := stats[links[i]], stats[links[j]]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+
+		if links[i].Y != links[j].Y {
+			return links[i].Y < links[j].Y
+		}
+
+		if links[i].X != links[j].X {
+			return links[i].X < links[j].X
+		}
+
+		return links[i].Side < links[j].Side
+	})
+
+	fmt.Printf("%-16s %8s %8s %8s\n", "Link", "Messages", "Stalls", "Peak")
+
+	for _, l := range links {
+		s := stats[l]
+		name := fmt.Sprintf("PE(%d, %d) %s", l.X, l.Y, l.Side.Name())
+		fmt.Printf("%-16s %8d %8d %8d\n",
+			name, s.Messages, s.Stalls, s.PeakOccupancy)
+	}
+}
+
+// openTracer creates the tracer that writes to path, or to the standard
+// output if path is empty. The returned function flushes and closes the
+// trace.
+func openTracer(path string, binary bool) (trace.Tracer, func() error, error) {
+	var w io.Writer = os.Stdout
+	closeFile := func() error { return nil }
+
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		w = f
+		closeFile = f.Close
+	}
+
+	if !binary {
+		return trace.NewTextWriter(w), closeFile, nil
+	}
+
+	bw := trace.NewBinaryWriter(w)
+
+	return bw, func() error {
+		err := bw.Flush()
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+
+		return err
+	}, nil
+}
+
+func setUpIO(driver api.Driver, s scenario) ([][]uint32, error) {
+	for _, in := range s.FeedIn {
+		side, err := parseSide(in.Side)
+		if err != nil {
+			return nil, err
+		}
+
+		if in.StartCycle == 0 && in.Period == 0 {
+			driver.FeedIn(in.Data, side, in.Ports, in.Stride)
+			continue
+		}
+
+		period := in.Period
+		if period == 0 {
+			period = 1
+		}
+
+		driver.FeedInAt(in.Data, side, in.Ports, in.Stride,
+			in.StartCycle, period)
+	}
+
+	outputs := [][]uint32{}
+	for _, out := range s.Collect {
+		side, err := parseSide(out.Side)
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]uint32, out.Length)
+		if out.Period > 0 {
+			driver.CollectAtRate(data, side, out.Ports, out.Stride, out.Period)
+		} else {
+			driver.Collect(data, side, out.Ports, out.Stride)
+		}
+
+		outputs = append(outputs, data)
+	}
+
+	return outputs, nil
+}
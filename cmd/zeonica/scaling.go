@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/zeonica/bench"
+)
+
+func runScaling(args []string) error {
+	flags := flag.NewFlagSet("scaling", flag.ExitOnError)
+	sizesFlag := flags.String("sizes", joinInts(bench.ScalingSizes),
+		"the comma-separated sizes to run each kernel at")
+	out := flags.String("o", "", "also write the results to a CSV file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica scaling [flags] [kernels]")
+		fmt.Fprintf(flags.Output(), "Kernels: %s\n",
+			strings.Join(bench.ScalingKernels, ", "))
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	sizes, err := parseInts(*sizesFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -sizes: %w", err)
+	}
+
+	kernels := flags.Args()
+	if len(kernels) == 0 {
+		kernels = bench.ScalingKernels
+	}
+
+	rows := [][]string{{"kernel", "n", "cycles", "iterations", "ii"}}
+
+	for _, name := range kernels {
+		for _, n := range sizes {
+			if limit := bench.ScalingLimit(name); limit > 0 && n > limit {
+				fmt.Printf("%-6s %6d skipped, the largest size is %d\n",
+					name, n, limit)
+				continue
+			}
+
+			r, err := bench.RunScaling(name, n)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(r)
+
+			rows = append(rows, []string{
+				r.Kernel, strconv.Itoa(r.N),
+				strconv.FormatUint(r.Cycles, 10),
+				strconv.FormatUint(r.Iterations, 10),
+				strconv.FormatFloat(r.II(), 'f', 4, 64),
+			})
+		}
+	}
+
+	if *out == "" {
+		return nil
+	}
+
+	return writeCSV(*out, rows)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func parseInts(s string) ([]int, error) {
+	values := []int{}
+
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
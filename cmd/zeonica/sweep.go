@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sarchlab/zeonica/experiments"
+	"gopkg.in/yaml.v3"
+)
+
+func runSweep(args []string) error {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	out := flags.String("o", "results.csv",
+		"the CSV file of the results, which a rerun resumes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica sweep [flags] <grid.yaml>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("sweep requires a grid file")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	grid := experiments.Grid{}
+
+	err = yaml.Unmarshal(data, &grid)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s: %w", flags.Arg(0), err)
+	}
+
+	store, err := experiments.OpenStore(*out)
+	if err != nil {
+		return err
+	}
+
+	return experiments.Sweep(grid, experiments.RunKernel, store,
+		func(row experiments.Row) {
+			if row.Error != "" {
+				fmt.Printf("%s %v: %s\n", row.Hash, row.Config, row.Error)
+				return
+			}
+
+			fmt.Printf("%s %v: %.0f cycles\n",
+				row.Hash, row.Config, row.Result["cycles"])
+		})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarchlab/zeonica/testbench"
+)
+
+func runTestbench(args []string) error {
+	flags := flag.NewFlagSet("testbench", flag.ExitOnError)
+	format := flags.String("format", "text", "text, junit, tap, or csv")
+	output := flags.String("o", "", "the output file, stdout if empty")
+	maxCycles := flags.Uint64("max-cycles", testbench.DefaultLimits.MaxCycles,
+		"the simulated cycles of a kernel whose manifest sets no max_cycles")
+	timeout := flags.Duration("timeout", testbench.DefaultLimits.Timeout,
+		"the wall-clock time of a kernel whose manifest sets no timeout")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica testbench [flags] <dir>")
+		fmt.Fprintln(flags.Output(),
+			"Runs each folder of dir that has a "+testbench.ManifestFile+".")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("testbench requires a directory")
+	}
+
+	report, err := testbench.RunAllWithLimits(flags.Arg(0),
+		testbench.Limits{MaxCycles: *maxCycles, Timeout: *timeout})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	err = writeTestbenchReport(w, report, *format)
+	if err != nil {
+		return err
+	}
+
+	if failed := len(report.Results) - report.Passed(); failed > 0 {
+		return fmt.Errorf("%d kernels failed", failed)
+	}
+
+	return nil
+}
+
+func writeTestbenchReport(w io.Writer, r testbench.Report, format string) error {
+	switch format {
+	case "text":
+		return r.WriteMatrix(w)
+	case "junit", "xml":
+		return r.WriteJUnit(w)
+	case "tap":
+		return r.WriteTAP(w)
+	case "csv":
+		return r.WriteCSV(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// timingRun is a run of a scenario in the elastic or the strict timing mode.
+type timingRun struct {
+	log     *trace.Log
+	outputs [][]uint32
+
+	// failure is the reason why a strict run stopped, e.g., an instruction
+	// that missed its schedule, or empty if the run finished.
+	failure string
+}
+
+// compareTiming runs a scenario in the elastic and the strict timing modes
+// and reports how the cycles differ, so that a wrong mapping, which fails in
+// both modes, can be told from an optimistic schedule, which only fails in
+// the strict mode.
+func compareTiming(args []string) error {
+	flags := flag.NewFlagSet("timing", flag.ExitOnError)
+	seed := flags.Int64("seed", 0,
+		"the seed that breaks ties in the driver, 0 for the creation order")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica timing [flags] <scenario.yaml>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("timing requires a scenario file")
+	}
+
+	s, err := loadScenario(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if len(s.Schedule) == 0 {
+		return fmt.Errorf("%s has no schedule", flags.Arg(0))
+	}
+
+	elastic, err := runTiming(s, *seed, false)
+	if err != nil {
+		return err
+	}
+
+	static, err := runTiming(s, *seed, true)
+	if err != nil {
+		return err
+	}
+
+	printTiming(elastic, static)
+
+	return nil
+}
+
+// runTiming runs the scenario and records the instructions. A strict run
+// that misses its schedule is not an error, but a result to report.
+func runTiming(s scenario, seed int64, strict bool) (r timingRun, err error) {
+	r.log = &trace.Log{}
+
+	defer func() {
+		if !strict {
+			return
+		}
+
+		if p := recover(); p != nil {
+			r.failure = fmt.Sprint(p)
+		}
+	}()
+
+	_, r.outputs, err = simulate(s, trace.Filter(r.log, trace.LevelInst),
+		api.DriverBuilder{}.WithSeed(seed), strict, nil)
+
+	return r, err
+}
+
+func printTiming(elastic, static timingRun) {
+	fmt.Printf("%-24s %8s %8s %8s\n", "PE", "Elastic", "Static", "Delta")
+
+	a, b := lastCycles(elastic.log), lastCycles(static.log)
+	for _, name := range unionKeys(a, b) {
+		ca, okA := a[name]
+		cb, okB := b[name]
+
+		delta := "-"
+		if okA && okB {
+			delta = fmt.Sprintf("%+d", int64(cb)-int64(ca))
+		}
+
+		fmt.Printf("%-24s %8s %8s %8s\n",
+			name, cycleText(ca, okA), cycleText(cb, okB), delta)
+	}
+
+	if static.failure != "" {
+		fmt.Println("static run failed:", static.failure)
+	} else if !reflect.DeepEqual(elastic.outputs, static.outputs) {
+		fmt.Println("collected data differ between the two runs")
+	}
+
+	d, ok := trace.FirstDivergence(elastic.log, static.log)
+	if !ok {
+		fmt.Println("no divergence")
+		return
+	}
+
+	fmt.Printf("first divergence: %s, instruction %d\n", d.Component, d.Index)
+	fmt.Println("  elastic:", describeInst(d.A))
+	fmt.Println("  static: ", describeInst(d.B))
+}
+
+// lastCycles returns the cycle of the last instruction of each component.
+func lastCycles(l *trace.Log) map[string]uint64 {
+	cycles := make(map[string]uint64)
+	for _, e := range l.Events {
+		cycles[e.Component] = uint64(math.Round(e.Time))
+	}
+
+	return cycles
+}
+
+func unionKeys(a, b map[string]uint64) []string {
+	names := []string{}
+	for name := range a {
+		names = append(names, name)
+	}
+
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// cycleText prints the cycle, or a dash if the component did not execute
+// any instruction.
+func cycleText(cycle uint64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+
+	return fmt.Sprint(cycle)
+}
+
+func describeInst(e trace.Event) string {
+	if e.Inst == "" {
+		return "not executed"
+	}
+
+	return fmt.Sprintf("cycle %d, %q", uint64(math.Round(e.Time)), e.Inst)
+}
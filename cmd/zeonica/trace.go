@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sarchlab/zeonica/trace"
+)
+
+type traceQuery struct {
+	pe, link, reg string
+	decode        bool
+}
+
+// summarizeTrace answers queries on a trace log, which is the output of
+// "zeonica run". Without a query, it counts the events of each component.
+func summarizeTrace(args []string) error {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	q := traceQuery{}
+	flags.StringVar(&q.pe, "pe", "", "print the timeline of a component")
+	flags.StringVar(&q.link, "link", "",
+		"print the messages on a link, in the form of src->dst")
+	flags.StringVar(&q.reg, "reg", "",
+		"print the writes to a register, in the form of component:$N")
+	flags.BoolVar(&q.decode, "decode", false,
+		"print all the events in the text format, e.g., to decode a binary log")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: zeonica trace [flags] <log>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("trace requires a log file")
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	log, err := trace.Parse(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+
+	return q.run(log)
+}
+
+func (q traceQuery) run(log *trace.Log) error {
+	switch {
+	case q.decode:
+		for _, e := range log.Events {
+			fmt.Println(e)
+		}
+	case q.pe != "":
+		printEvents(log.Timeline(q.pe))
+	case q.link != "":
+		ports := strings.SplitN(q.link, "->", 2)
+		if len(ports) != 2 {
+			return fmt.Errorf("invalid link %q", q.link)
+		}
+
+		printEvents(log.LinkMessages(ports[0], ports[1]))
+	case q.reg != "":
+		i := strings.LastIndex(q.reg, ":")
+		if i < 0 {
+			return fmt.Errorf("invalid register %q", q.reg)
+		}
+
+		printEvents(log.RegisterHistory(q.reg[:i], q.reg[i+1:]))
+	default:
+		printCounts(log)
+	}
+
+	return nil
+}
+
+func printEvents(events []trace.Event) {
+	for _, e := range events {
+		switch e.Kind {
+		case trace.KindInst:
+			fmt.Printf("%10f %s %s\n", e.Time, e.Kind, e.Inst)
+		case trace.KindWrite:
+			fmt.Printf("%10f %s %s %d\n", e.Time, e.Kind, e.Reg, e.Data)
+		default:
+			fmt.Printf("%10f %s %d %s->%s\n",
+				e.Time, e.Kind, e.Data, e.Src, e.Dst)
+		}
+	}
+}
+
+func printCounts(log *trace.Log) {
+	fmt.Printf("%-40s %8s %8s %8s %8s\n",
+		"Component", "Insts", "Sends", "Recvs", "Writes")
+
+	for _, name := range log.Components() {
+		c := log.Counts(name)
+		fmt.Printf("%-40s %8d %8d %8d %8d\n", name,
+			c[trace.KindInst], c[trace.KindSend], c[trace.KindRecv],
+			c[trace.KindWrite])
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sarchlab/zeonica/verify"
+)
+
+func verifyPrograms(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	archPath := flags.String("arch", "", "the arch spec file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(),
+			"Usage: zeonica verify [-arch arch_spec.yaml] <programs>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("verify requires a program file")
+	}
+
+	programs, err := loadPrograms(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, arch, err := loadArch(*archPath, programs)
+	if err != nil {
+		return err
+	}
+
+	issues := verify.Lint(programs, arch)
+	issues = append(issues, verify.CheckRates(programs, arch)...)
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	ii := verify.AnalyzeII(programs, arch)
+	fmt.Printf("II: %d (ResMII: %d)\n", ii.II, ii.ResMII)
+
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issues", len(issues))
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"gopkg.in/yaml.v3"
+)
+
+// MeshInterconnect is the only routing resource that devices built by the
+// DeviceBuilder support. Each PE connects to its 4 nearest neighbors.
+const MeshInterconnect = "mesh"
+
+// ArchDescription describes a CGRA architecture in a format that is similar
+// to the architecture description used by CGRA-ME. A description looks like
+//
+//	<cgra>
+//	  <architecture rows="4" cols="4" interconnect="mesh">
+//	    <pe x="0" y="0">
+//	      <op name="I_ADD"/>
+//	    </pe>
+//	  </architecture>
+//	</cgra>
+//
+// in XML, or like
+//
+//	architecture:
+//	  rows: 4
+//	  cols: 4
+//	  interconnect: mesh
+//	  pes:
+//	    - x: 0
+//	      "y": 0
+//	      ops: [I_ADD]
+//
+// in YAML, where the y key is quoted so that YAML 1.1 parsers do not read
+// it as a boolean. PEs without a pe element support all the operations.
+type ArchDescription struct {
+	XMLName      xml.Name            `xml:"cgra" yaml:"-"`
+	Architecture ArchitectureElement `xml:"architecture" yaml:"architecture"`
+}
+
+// ArchitectureElement is the architecture element of an ArchDescription.
+type ArchitectureElement struct {
+	Rows         int         `xml:"rows,attr" yaml:"rows"`
+	Cols         int         `xml:"cols,attr" yaml:"cols"`
+	Interconnect string      `xml:"interconnect,attr" yaml:"interconnect"`
+	PEs          []PEElement `xml:"pe" yaml:"pes,omitempty"`
+}
+
+// PEElement describes the operations that a PE supports.
+type PEElement struct {
+	X   int         `xml:"x,attr" yaml:"x"`
+	Y   int         `xml:"y,attr" yaml:"y"`
+	Ops []OpElement `xml:"op" yaml:"ops,flow"`
+}
+
+// OpElement names an operation that a PE supports. In YAML, an operation is
+// only its name.
+type OpElement struct {
+	Name string `xml:"name,attr"`
+}
+
+// MarshalYAML writes the name of the operation.
+func (o OpElement) MarshalYAML() (interface{}, error) {
+	return o.Name, nil
+}
+
+// UnmarshalYAML reads the name of the operation.
+func (o *OpElement) UnmarshalYAML(value *yaml.Node) error {
+	return value.Decode(&o.Name)
+}
+
+// ReadArchDescription parses an architecture description in the XML format.
+func ReadArchDescription(r io.Reader) (ArchDescription, error) {
+	desc := ArchDescription{}
+
+	err := xml.NewDecoder(r).Decode(&desc)
+	if err != nil {
+		return desc, fmt.Errorf("cannot parse architecture description: %w",
+			err)
+	}
+
+	err = desc.validate()
+	if err != nil {
+		return desc, err
+	}
+
+	return desc, nil
+}
+
+// ReadArchDescriptionYAML parses an architecture description in the YAML
+// format.
+func ReadArchDescriptionYAML(r io.Reader) (ArchDescription, error) {
+	desc := ArchDescription{}
+
+	err := yaml.NewDecoder(r).Decode(&desc)
+	if err != nil {
+		return desc, fmt.Errorf("cannot parse architecture description: %w",
+			err)
+	}
+
+	err = desc.validate()
+	if err != nil {
+		return desc, err
+	}
+
+	return desc, nil
+}
+
+func (a ArchDescription) validate() error {
+	arch := a.Architecture
+
+	if arch.Rows <= 0 || arch.Cols <= 0 {
+		return fmt.Errorf("invalid array size %dx%d", arch.Cols, arch.Rows)
+	}
+
+	if arch.Interconnect != "" && arch.Interconnect != MeshInterconnect {
+		return fmt.Errorf("unsupported interconnect %q", arch.Interconnect)
+	}
+
+	for _, pe := range arch.PEs {
+		if pe.X < 0 || pe.X >= arch.Cols || pe.Y < 0 || pe.Y >= arch.Rows {
+			return fmt.Errorf("PE (%d, %d) is outside of the array",
+				pe.X, pe.Y)
+		}
+	}
+
+	return nil
+}
+
+// Write writes the architecture description in the XML format.
+func (a ArchDescription) Write(w io.Writer) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err := enc.Encode(a)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+
+	return err
+}
+
+// WriteYAML writes the architecture description in the YAML format.
+func (a ArchDescription) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	err := enc.Encode(a)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// PECapabilities returns the capability classes of the PEs that are
+// described by pe elements.
+func (a ArchDescription) PECapabilities() map[[2]int]cgra.PECaps {
+	caps := make(map[[2]int]cgra.PECaps)
+
+	for _, pe := range a.Architecture.PEs {
+		ops := make([]string, 0, len(pe.Ops))
+		for _, op := range pe.Ops {
+			ops = append(ops, op.Name)
+		}
+
+		caps[[2]int{pe.X, pe.Y}] = cgra.NewPECaps(ops...)
+	}
+
+	return caps
+}
+
+// WithArchDescription configures the device according to an architecture
+// description.
+func (d DeviceBuilder) WithArchDescription(
+	desc ArchDescription,
+) DeviceBuilder {
+	d.width = desc.Architecture.Cols
+	d.height = desc.Architecture.Rows
+	d.peCaps = desc.PECapabilities()
+
+	return d
+}
+
+// ArchDescription returns the description of the device that the builder
+// builds.
+func (d DeviceBuilder) ArchDescription() ArchDescription {
+	desc := ArchDescription{
+		Architecture: ArchitectureElement{
+			Rows:         d.height,
+			Cols:         d.width,
+			Interconnect: MeshInterconnect,
+		},
+	}
+
+	for coord, caps := range d.peCaps {
+		if caps.SupportsAll() {
+			continue
+		}
+
+		pe := PEElement{X: coord[0], Y: coord[1]}
+		for _, op := range caps.Opcodes() {
+			pe.Ops = append(pe.Ops, OpElement{Name: op})
+		}
+
+		desc.Architecture.PEs = append(desc.Architecture.PEs, pe)
+	}
+
+	sort.Slice(desc.Architecture.PEs, func(i, j int) bool {
+		a, b := desc.Architecture.PEs[i], desc.Architecture.PEs[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+
+		return a.X < b.X
+	})
+
+	return desc
+}
@@ -0,0 +1,99 @@
+package config_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+)
+
+var _ = Describe("ArchDescription", func() {
+	It("should read an architecture description", func() {
+		desc, err := config.ReadArchDescription(strings.NewReader(`
+			<cgra>
+				<architecture rows="2" cols="3" interconnect="mesh">
+					<pe x="1" y="1"><op name="I_ADD"/></pe>
+				</architecture>
+			</cgra>`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.Architecture.Rows).To(Equal(2))
+		Expect(desc.Architecture.Cols).To(Equal(3))
+		Expect(desc.Architecture.PEs).To(HaveLen(1))
+		Expect(desc.Architecture.PEs[0].Ops[0].Name).To(Equal("I_ADD"))
+	})
+
+	It("should reject unsupported interconnects", func() {
+		_, err := config.ReadArchDescription(strings.NewReader(`
+			<cgra>
+				<architecture rows="2" cols="2" interconnect="torus"/>
+			</cgra>`))
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should export the device configuration", func() {
+		builder := config.DeviceBuilder{}.WithWidth(4).WithHeight(2)
+
+		buf := &bytes.Buffer{}
+		err := builder.ArchDescription().Write(buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		desc, err := config.ReadArchDescription(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.Architecture.Cols).To(Equal(4))
+		Expect(desc.Architecture.Rows).To(Equal(2))
+		Expect(desc.Architecture.Interconnect).To(Equal("mesh"))
+	})
+
+	It("should read an architecture description in YAML", func() {
+		desc, err := config.ReadArchDescriptionYAML(strings.NewReader(
+			"architecture:\n" +
+				"  rows: 2\n" +
+				"  cols: 3\n" +
+				"  interconnect: mesh\n" +
+				"  pes:\n" +
+				"    - x: 1\n" +
+				"      y: 1\n" +
+				"      ops: [I_ADD, WAIT]\n"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc.Architecture.Rows).To(Equal(2))
+		Expect(desc.Architecture.Cols).To(Equal(3))
+		Expect(desc.Architecture.PEs).To(Equal([]config.PEElement{{
+			X: 1, Y: 1,
+			Ops: []config.OpElement{{Name: "I_ADD"}, {Name: "WAIT"}},
+		}}))
+
+		_, err = config.ReadArchDescriptionYAML(strings.NewReader(
+			"architecture: {rows: 2, cols: 2, interconnect: torus}\n"))
+		Expect(err).To(MatchError("unsupported interconnect \"torus\""))
+	})
+
+	It("should export the device configuration in YAML", func() {
+		builder := config.DeviceBuilder{}.
+			WithWidth(2).
+			WithHeight(1).
+			WithPECapabilities(map[[2]int]cgra.PECaps{
+				{1, 0}: cgra.NewPECaps("WAIT", "SEND"),
+			})
+
+		buf := &bytes.Buffer{}
+		Expect(builder.ArchDescription().WriteYAML(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("architecture:\n" +
+			"  rows: 1\n" +
+			"  cols: 2\n" +
+			"  interconnect: mesh\n" +
+			"  pes:\n" +
+			"    - x: 1\n" +
+			"      \"y\": 0\n" +
+			"      ops: [SEND, WAIT]\n"))
+
+		desc, err := config.ReadArchDescriptionYAML(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(desc).To(Equal(builder.ArchDescription()))
+	})
+})
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+	"gopkg.in/yaml.v3"
+)
+
+// ArchSpec is the content of an arch_spec.yaml file. A spec looks like
+//
+//	rows: 4
+//	columns: 4
+//	topology: mesh
+//	mem_capacity: 1024
+//	registers_per_pe: 64
+//	ctrl_mem_items: 32
+//	io_channels: 2
+//	link_latency: 2
+//	link_bandwidth: 1
+//	float_rounding: rtz
+//	float_denormals: ftz
+//	issue_width: 2
+//	port_reads: 2
+//	port_writes: 1
+//	bypass: true
+//	reg_reads: 2
+//	reg_writes: 1
+//	reg_banks: 2
+//	pe_caps:
+//	  - {x: 0, y: 0, ops: [WAIT, SEND, I_ADD]}
+//	disabled_tiles: [[3, 3]]
+//
+// PEs that are not listed in pe_caps support all opcodes. The mem_capacity
+// is the number of words in the local memory of each core,
+// core.DefaultMemorySize by default. The io_channels
+// are the channels that each tile on an edge has to the driver, 1 by
+// default. The link_latency in cycles and the link_bandwidth in words per
+// cycle set the LinkConfig of all the links between neighbor tiles. The
+// float_rounding (rne, rtz, rup, or rdn) and the float_denormals (preserve,
+// ftz, daz, or ftz_daz) set the core.FloatMode of all the cores, rne and
+// preserve by default. The issue_width is the number of instructions that
+// each PE issues per cycle, 1 by default, and the port_reads and the
+// port_writes limit the NET_RECV and NET_SEND registers that the
+// instructions of a cycle access, without limits by default. With bypass,
+// an instruction can read the result of an instruction of the same cycle.
+// The reg_reads and the reg_writes are the read and write ports of each of
+// the reg_banks banks of the register file, without limits by default.
+type ArchSpec struct {
+	Rows           int          `yaml:"rows"`
+	Columns        int          `yaml:"columns"`
+	Topology       string       `yaml:"topology"`
+	MemCapacity    int          `yaml:"mem_capacity"`
+	RegistersPerPE int          `yaml:"registers_per_pe"`
+	CtrlMemItems   int          `yaml:"ctrl_mem_items"`
+	IOChannels     int          `yaml:"io_channels"`
+	LinkLatency    int          `yaml:"link_latency"`
+	LinkBandwidth  int          `yaml:"link_bandwidth"`
+	FloatRounding  string       `yaml:"float_rounding"`
+	FloatDenormals string       `yaml:"float_denormals"`
+	IssueWidth     int          `yaml:"issue_width"`
+	PortReads      int          `yaml:"port_reads"`
+	PortWrites     int          `yaml:"port_writes"`
+	Bypass         bool         `yaml:"bypass"`
+	RegReads       int          `yaml:"reg_reads"`
+	RegWrites      int          `yaml:"reg_writes"`
+	RegBanks       int          `yaml:"reg_banks"`
+	PECaps         []PECapsSpec `yaml:"pe_caps"`
+	DisabledTiles  [][2]int     `yaml:"disabled_tiles"`
+}
+
+// PECapsSpec lists the opcodes that the PE at (X, Y) supports.
+type PECapsSpec struct {
+	X   int      `yaml:"x"`
+	Y   int      `yaml:"y"`
+	Ops []string `yaml:"ops"`
+}
+
+// LoadArchSpec reads an arch_spec.yaml file. It returns a DeviceBuilder that
+// builds a device that matches the spec and the ArchInfo that the verify
+// package checks programs against. The engine and the frequency of the
+// DeviceBuilder still need to be set.
+func LoadArchSpec(path string) (DeviceBuilder, verify.ArchInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DeviceBuilder{}, verify.ArchInfo{}, err
+	}
+
+	return parseArchSpec(path, data)
+}
+
+// LoadArchSpecFS is the same as LoadArchSpec, but reads the file from a
+// file system, e.g., the files of a page in a browser.
+func LoadArchSpecFS(
+	fsys fs.FS,
+	path string,
+) (DeviceBuilder, verify.ArchInfo, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return DeviceBuilder{}, verify.ArchInfo{}, err
+	}
+
+	return parseArchSpec(path, data)
+}
+
+func parseArchSpec(
+	path string,
+	data []byte,
+) (DeviceBuilder, verify.ArchInfo, error) {
+	spec := ArchSpec{}
+	err := yaml.Unmarshal(data, &spec)
+	if err != nil {
+		return DeviceBuilder{}, verify.ArchInfo{},
+			fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	err = spec.validate()
+	if err != nil {
+		return DeviceBuilder{}, verify.ArchInfo{},
+			fmt.Errorf("invalid arch spec %s: %w", path, err)
+	}
+
+	return spec.DeviceBuilder(), spec.ArchInfo(), nil
+}
+
+func (s ArchSpec) validate() error {
+	if s.Rows <= 0 || s.Columns <= 0 {
+		return fmt.Errorf("invalid array size %dx%d", s.Columns, s.Rows)
+	}
+
+	if s.Topology != "" && s.Topology != MeshInterconnect {
+		return fmt.Errorf("unsupported topology %q", s.Topology)
+	}
+
+	if s.IOChannels < 0 || s.IOChannels > core.MaxChannels {
+		return fmt.Errorf("invalid number of I/O channels %d, at most %d "+
+			"are supported", s.IOChannels, core.MaxChannels)
+	}
+
+	if s.LinkLatency < 0 || s.LinkBandwidth < 0 {
+		return fmt.Errorf("invalid link latency %d or bandwidth %d",
+			s.LinkLatency, s.LinkBandwidth)
+	}
+
+	if _, err := s.floatMode(); err != nil {
+		return err
+	}
+
+	if err := s.issueLimits().Validate(); err != nil {
+		return err
+	}
+
+	for _, pe := range s.PECaps {
+		if !s.contains(pe.X, pe.Y) {
+			return fmt.Errorf("PE (%d, %d) is outside of the array",
+				pe.X, pe.Y)
+		}
+	}
+
+	for _, t := range s.DisabledTiles {
+		if !s.contains(t[0], t[1]) {
+			return fmt.Errorf("disabled tile (%d, %d) is outside of the array",
+				t[0], t[1])
+		}
+	}
+
+	return nil
+}
+
+func (s ArchSpec) contains(x, y int) bool {
+	return x >= 0 && x < s.Columns && y >= 0 && y < s.Rows
+}
+
+func (s ArchSpec) peCapabilities() map[[2]int]cgra.PECaps {
+	caps := make(map[[2]int]cgra.PECaps)
+	for _, pe := range s.PECaps {
+		caps[[2]int{pe.X, pe.Y}] = cgra.NewPECaps(pe.Ops...)
+	}
+
+	return caps
+}
+
+func (s ArchSpec) issueLimits() core.IssueLimits {
+	return core.IssueLimits{
+		Width:      s.IssueWidth,
+		PortReads:  s.PortReads,
+		PortWrites: s.PortWrites,
+		RegReads:   s.RegReads,
+		RegWrites:  s.RegWrites,
+		RegBanks:   s.RegBanks,
+		Bypass:     s.Bypass,
+	}
+}
+
+func (s ArchSpec) floatMode() (core.FloatMode, error) {
+	return core.ParseFloatMode(s.FloatRounding, s.FloatDenormals)
+}
+
+// DeviceBuilder returns a DeviceBuilder that builds a device that matches
+// the spec.
+func (s ArchSpec) DeviceBuilder() DeviceBuilder {
+	builder := DeviceBuilder{}.
+		WithWidth(s.Columns).
+		WithHeight(s.Rows).
+		WithPECapabilities(s.peCapabilities()).
+		WithDisabledTiles(s.DisabledTiles).
+		WithChannels(s.IOChannels).
+		WithIssueLimits(s.issueLimits())
+
+	if s.MemCapacity > 0 {
+		builder = builder.WithMemorySize(s.MemCapacity)
+	}
+
+	if m, err := s.floatMode(); err == nil {
+		builder = builder.WithFloatMode(m)
+	}
+
+	if s.LinkLatency > 0 || s.LinkBandwidth > 0 {
+		builder = builder.WithLinks(LinkConfig{
+			Latency:   s.LinkLatency,
+			Bandwidth: s.LinkBandwidth,
+		})
+	}
+
+	return builder
+}
+
+// ArchInfo returns the architecture information that the verify package
+// uses.
+func (s ArchSpec) ArchInfo() verify.ArchInfo {
+	topology := s.Topology
+	if topology == "" {
+		topology = MeshInterconnect
+	}
+
+	return verify.ArchInfo{
+		Rows:           s.Rows,
+		Columns:        s.Columns,
+		Topology:       topology,
+		MemCapacity:    s.MemCapacity,
+		RegistersPerPE: s.RegistersPerPE,
+		CtrlMemItems:   s.CtrlMemItems,
+		IssueLimits:    s.issueLimits(),
+		PECaps:         s.peCapabilities(),
+		DisabledTiles:  s.DisabledTiles,
+	}
+}
@@ -0,0 +1,201 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("LoadArchSpec", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should load the device configuration and the arch info", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path, []byte(
+			"rows: 2\ncolumns: 3\nmem_capacity: 1024\nctrl_mem_items: 32\n"+
+				"registers_per_pe: 16\n"),
+			0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		builder, arch, err := config.LoadArchSpec(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(builder.ArchDescription().Architecture.Rows).To(Equal(2))
+		Expect(builder.ArchDescription().Architecture.Cols).To(Equal(3))
+		Expect(arch).To(Equal(verify.ArchInfo{
+			Rows:           2,
+			Columns:        3,
+			Topology:       "mesh",
+			MemCapacity:    1024,
+			RegistersPerPE: 16,
+			CtrlMemItems:   32,
+			PECaps:         map[[2]int]cgra.PECaps{},
+		}))
+	})
+
+	It("should set the size of the local memory of the cores", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nmem_capacity: 32\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		builder, _, err := config.LoadArchSpec(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		device := builder.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			Build("Device")
+		tile := device.GetTile(0, 0)
+
+		Expect(tile.ReadMemory(0, 32)).To(HaveLen(32))
+		Expect(func() { tile.ReadMemory(0, 33) }).To(PanicWith(
+			"Device.Tile[0][0].Core: words [0, 33) are out of the local " +
+				"memory of 32 words"))
+	})
+
+	It("should load PE capabilities", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path, []byte(
+			"rows: 2\ncolumns: 2\npe_caps:\n"+
+				"  - {x: 1, y: 0, ops: [WAIT, SEND]}\n"),
+			0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		builder, arch, err := config.LoadArchSpec(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(arch.PECaps[[2]int{1, 0}].Opcodes()).
+			To(Equal([]string{"SEND", "WAIT"}))
+		Expect(builder.ArchDescription().Architecture.PEs).
+			To(Equal([]config.PEElement{{
+				X: 1, Y: 0,
+				Ops: []config.OpElement{{Name: "SEND"}, {Name: "WAIT"}},
+			}}))
+	})
+
+	It("should reject an empty array", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path, []byte("rows: 0\ncolumns: 3\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = config.LoadArchSpec(path)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject too many I/O channels", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nio_channels: 5\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = config.LoadArchSpec(path)
+
+		Expect(err).To(MatchError(ContainSubstring("I/O channels")))
+	})
+
+	It("should reject negative link latencies", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 2\nlink_latency: -1\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = config.LoadArchSpec(path)
+
+		Expect(err).To(MatchError(ContainSubstring("link latency")))
+	})
+
+	It("should set the float mode of the cores", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nfloat_rounding: rtz\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		builder, _, err := config.LoadArchSpec(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		engine := sim.NewSerialEngine()
+		driver := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(builder.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Device"))
+
+		// 1 + 1.5 * 2^-24 is 1 + 2^-23 when rounded to nearest.
+		driver.MapProgram("F32_ADD, $0, 0x3f800000, 0x33c00000\n"+
+			"RETURN_VALUE, $0\nDONE", [2]int{0, 0})
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()[[2]int{0, 0}]).
+			To(Equal(uint32(0x3f800000)))
+	})
+
+	It("should reject unknown float modes", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nfloat_denormals: flush\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = config.LoadArchSpec(path)
+
+		Expect(err).To(MatchError(ContainSubstring(
+			"unknown denormal mode \"flush\"")))
+	})
+
+	It("should set the issue limits of the cores", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nissue_width: 2\nport_writes: 1\n"+
+				"bypass: true\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		builder, info, err := config.LoadArchSpec(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.IssueLimits).To(Equal(
+			core.IssueLimits{Width: 2, PortWrites: 1, Bypass: true}))
+
+		engine := sim.NewSerialEngine()
+		driver := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(builder.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Device"))
+
+		driver.MapProgram("I_ADD, $0, 0, 1\nI_ADD, $1, $0, 1\nDONE",
+			[2]int{0, 0})
+		driver.SetSchedule([2]int{0, 0}, []int{0, 0, 1}, 2)
+		driver.WaitAllDone()
+
+		Expect(driver.ReadRegister([2]int{0, 0}, 1)).To(Equal(uint32(2)))
+	})
+
+	It("should reject negative issue limits", func() {
+		path := filepath.Join(dir, "arch_spec.yaml")
+		err := os.WriteFile(path,
+			[]byte("rows: 1\ncolumns: 1\nport_reads: -1\n"), 0o644)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = config.LoadArchSpec(path)
+
+		Expect(err).To(MatchError(ContainSubstring("invalid issue limits")))
+	})
+})
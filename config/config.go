@@ -3,18 +3,66 @@ package config
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/sarchlab/akita/v3/noc/networking/mesh"
 	"github.com/sarchlab/akita/v3/sim"
 	"github.com/sarchlab/zeonica/cgra"
 	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/trace"
 )
 
+// ClockDomain is a rectangular region of tiles that runs at its own
+// frequency, e.g., to model DVFS.
+type ClockDomain struct {
+	// X and Y are the coordinate of the top-left tile of the region.
+	X, Y int
+
+	// Width and Height are the size of the region in tiles.
+	Width, Height int
+
+	Freq sim.Freq
+}
+
+func (c ClockDomain) contains(x, y int) bool {
+	return x >= c.X && x < c.X+c.Width && y >= c.Y && y < c.Y+c.Height
+}
+
+type tileRegion struct {
+	x, y, width, height int
+}
+
+func (r tileRegion) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.width && y >= r.y && y < r.y+r.height
+}
+
 // DeviceBuilder can build CGRA devices.
 type DeviceBuilder struct {
 	engine        sim.Engine
 	freq          sim.Freq
 	width, height int
+	peCaps        map[[2]int]cgra.PECaps
+	disabledTiles map[[2]int]bool
+	clockDomains  []ClockDomain
+	tracer        trace.Tracer
+	traceLevel    trace.Level
+	traceFile     string
+	tracedTiles   *tileRegion
+	memSize       int
+	channels      int
+	opcodeAliases map[string]string
+	floatMode     core.FloatMode
+	issueLimits   core.IssueLimits
+	links         *LinkConfig
+	linkOverrides map[linkKey]LinkConfig
+
+	clusterWidth, clusterHeight int
+}
+
+// linkKey is the link that the tile at (x, y) sends through the side.
+type linkKey struct {
+	x, y int
+	side cgra.Side
 }
 
 // WithEngine sets the engine that drives the device simulation.
@@ -41,47 +89,254 @@ func (d DeviceBuilder) WithHeight(height int) DeviceBuilder {
 	return d
 }
 
+// WithPECapabilities sets the capability classes of the PEs, keyed by the
+// [x, y] coordinate of the PE. PEs that are not listed support all opcodes.
+func (d DeviceBuilder) WithPECapabilities(
+	caps map[[2]int]cgra.PECaps,
+) DeviceBuilder {
+	d.peCaps = caps
+	return d
+}
+
+// WithDisabledTiles sets the [x, y] coordinates of the tiles that are absent
+// from the device, e.g., due to faults or holes in the floorplan. Disabled
+// tiles are not connected to their neighbors or to the driver.
+func (d DeviceBuilder) WithDisabledTiles(tiles [][2]int) DeviceBuilder {
+	d.disabledTiles = make(map[[2]int]bool)
+	for _, t := range tiles {
+		d.disabledTiles[t] = true
+	}
+
+	return d
+}
+
+// WithClockDomains sets the regions of tiles that run at frequencies other
+// than the frequency of the device. If regions overlap, the last one applies.
+func (d DeviceBuilder) WithClockDomains(domains []ClockDomain) DeviceBuilder {
+	d.clockDomains = domains
+	return d
+}
+
+// WithMemorySize sets the number of words in the local memory of each core.
+func (d DeviceBuilder) WithMemorySize(words int) DeviceBuilder {
+	d.memSize = words
+	return d
+}
+
+// WithChannels sets the number of channels on each side of each tile, so
+// that the tiles on the edges can receive and send more than one word per
+// cycle from and to the driver. Neighbor tiles only connect through channel
+// 0. The default is 1.
+func (d DeviceBuilder) WithChannels(n int) DeviceBuilder {
+	d.channels = n
+	return d
+}
+
+// WithFloatMode sets the rounding and the denormal modes of the float32
+// operations of all the cores. The default is the IEEE 754 default.
+func (d DeviceBuilder) WithFloatMode(m core.FloatMode) DeviceBuilder {
+	d.floatMode = m
+	return d
+}
+
+// WithIssueLimits sets the number of instructions that each core issues per
+// cycle and the ports that they can access. The default issues one
+// instruction per cycle.
+func (d DeviceBuilder) WithIssueLimits(l core.IssueLimits) DeviceBuilder {
+	d.issueLimits = l
+	return d
+}
+
+// WithOpcodeAliases sets the aliases of the opcodes that the programs can
+// use. The default is core.DefaultOpcodeAliases.
+func (d DeviceBuilder) WithOpcodeAliases(
+	aliases map[string]string,
+) DeviceBuilder {
+	d.opcodeAliases = aliases
+	return d
+}
+
+// WithLinks sets the latency and the bandwidth of all the links between
+// neighbor tiles. Without WithLinks or WithLink, the tiles connect through a
+// mesh network with a latency of 1 cycle and a bandwidth of 1 word per cycle.
+func (d DeviceBuilder) WithLinks(link LinkConfig) DeviceBuilder {
+	d.links = &link
+	return d
+}
+
+// WithLink sets the latency and the bandwidth of the link that the tile at
+// (x, y) sends to its neighbor through the side. The link in the other
+// direction keeps its own settings.
+func (d DeviceBuilder) WithLink(
+	x, y int,
+	side cgra.Side,
+	link LinkConfig,
+) DeviceBuilder {
+	overrides := make(map[linkKey]LinkConfig)
+	for k, v := range d.linkOverrides {
+		overrides[k] = v
+	}
+
+	overrides[linkKey{x: x, y: y, side: side}] = link
+	d.linkOverrides = overrides
+
+	return d
+}
+
+// WithClustering groups the tiles into clusters of width x height tiles,
+// starting from the top-left tile, and connects the PEs of each cluster with
+// a crossbar in addition to the links between neighbors. Through the
+// crossbar, a PE sends to any PE of its cluster in one hop. With n I/O
+// channels, NET_SEND_(4n+k) and NET_RECV_(4n+k) connect to the k-th PE of
+// the cluster in the row-major order.
+func (d DeviceBuilder) WithClustering(width, height int) DeviceBuilder {
+	d.clusterWidth = width
+	d.clusterHeight = height
+
+	return d
+}
+
+// WithTracer sets the tracer that receives the events of all the cores.
+func (d DeviceBuilder) WithTracer(tracer trace.Tracer) DeviceBuilder {
+	d.tracer = tracer
+	return d
+}
+
+// WithTraceLevel sets the kinds of events to trace. By default, all the
+// events are traced.
+func (d DeviceBuilder) WithTraceLevel(level trace.Level) DeviceBuilder {
+	d.traceLevel = level
+	return d
+}
+
+// WithTraceFile sets the file that the events are written to in the text
+// format. The file is created when the device is built and overrides the
+// tracer set by WithTracer.
+func (d DeviceBuilder) WithTraceFile(path string) DeviceBuilder {
+	d.traceFile = path
+	return d
+}
+
+// WithTracedTiles limits tracing to a rectangular region of tiles, where
+// [x, y] is the coordinate of the top-left tile of the region.
+func (d DeviceBuilder) WithTracedTiles(x, y, width, height int) DeviceBuilder {
+	d.tracedTiles = &tileRegion{x: x, y: y, width: width, height: height}
+	return d
+}
+
 // Build creates a CGRA device.
 func (d DeviceBuilder) Build(name string) cgra.Device {
 	dev := &device{
-		Name:   name,
-		Width:  d.width,
-		Height: d.height,
-		Tiles:  make([][]*tile, d.height),
+		Name:        name,
+		Width:       d.width,
+		Height:      d.height,
+		Tiles:       make([][]*tile, d.height),
+		Channels:    d.channels,
+		linkedSides: make(map[cgra.Side]bool),
+	}
+
+	if dev.Channels == 0 {
+		dev.Channels = 1
 	}
 
-	nocConnector := mesh.NewConnector().
-		WithEngine(d.engine).
-		WithFreq(d.freq).
-		WithSwitchLatency(1).
-		WithBandwidth(1)
-	nocConnector.CreateNetwork(name + ".Mesh")
+	var nocConnector *mesh.Connector
+	if !d.usesWires() {
+		nocConnector = mesh.NewConnector().
+			WithEngine(d.engine).
+			WithFreq(d.freq).
+			WithSwitchLatency(1).
+			WithBandwidth(1).
+			WithFlitSize(cgra.WordBytes)
+		nocConnector.CreateNetwork(name + ".Mesh")
+	}
 
-	d.createTiles(dev, name, nocConnector)
+	d.tracer = d.buildTracer()
+	d.createTiles(dev, name, nocConnector, core.NewBarrier())
 	d.setRemovePorts(dev)
 
-	nocConnector.EstablishNetwork()
+	if d.clusterWidth > 0 && d.clusterHeight > 0 {
+		d.connectClusters(dev, name)
+	}
+
+	if nocConnector != nil {
+		nocConnector.EstablishNetwork()
+	}
 
 	return dev
 }
 
+// coreChannels returns the number of channels that each core needs for the
+// I/O channels and the ports of its cluster.
+func (d DeviceBuilder) coreChannels(ioChannels int) int {
+	if d.clusterWidth <= 0 || d.clusterHeight <= 0 {
+		return ioChannels
+	}
+
+	channels := ioChannels + clusterChannels(d.clusterWidth, d.clusterHeight)
+	if channels > core.MaxChannels {
+		panic(fmt.Sprintf("clusters of %dx%d tiles with %d I/O channels "+
+			"need %d channels, but a core has at most %d",
+			d.clusterWidth, d.clusterHeight, ioChannels, channels,
+			core.MaxChannels))
+	}
+
+	return channels
+}
+
+// usesWires returns true if the neighbor tiles connect through wires with
+// the LinkConfigs rather than through the mesh network.
+func (d DeviceBuilder) usesWires() bool {
+	return d.links != nil || len(d.linkOverrides) > 0
+}
+
+// link returns the LinkConfig of the link that the tile at (x, y) sends
+// through the side.
+func (d DeviceBuilder) link(x, y int, side cgra.Side) LinkConfig {
+	if link, ok := d.linkOverrides[linkKey{x: x, y: y, side: side}]; ok {
+		return link
+	}
+
+	if d.links != nil {
+		return *d.links
+	}
+
+	return LinkConfig{}
+}
+
 func (d DeviceBuilder) createTiles(
 	dev *device,
 	name string,
 	nocConnector *mesh.Connector,
+	barrier *core.Barrier,
 ) {
 	for y := 0; y < d.height; y++ {
 		dev.Tiles[y] = make([]*tile, d.width)
 		for x := 0; x < d.width; x++ {
+			if d.disabledTiles[[2]int{x, y}] {
+				continue
+			}
+
 			tile := &tile{}
 			coreName := fmt.Sprintf("%s.Tile[%d][%d].Core", name, x, y)
 			tile.Core = core.Builder{}.
 				WithEngine(d.engine).
-				WithFreq(d.freq).
+				WithFreq(d.tileFreq(x, y)).
+				WithCapabilities(d.peCaps[[2]int{x, y}]).
+				WithBarrier(barrier).
+				WithMemorySize(d.memSize).
+				WithChannels(d.coreChannels(dev.Channels)).
+				WithOpcodeAliases(d.opcodeAliases).
+				WithFloatMode(d.floatMode).
+				WithIssueLimits(d.issueLimits).
+				WithTracer(d.tileTracer(x, y)).
 				Build(coreName)
 
 			dev.Tiles[y][x] = tile
 
+			if nocConnector == nil {
+				continue
+			}
+
 			nocConnector.AddTile(
 				[3]int{x, y, 0},
 				[]sim.Port{
@@ -94,34 +349,106 @@ func (d DeviceBuilder) createTiles(
 	}
 }
 
+func (d DeviceBuilder) buildTracer() trace.Tracer {
+	tracer := d.tracer
+
+	if d.traceFile != "" {
+		f, err := os.Create(d.traceFile)
+		if err != nil {
+			panic(err)
+		}
+
+		tracer = trace.NewTextWriter(f)
+	}
+
+	if tracer == nil && d.traceLevel == trace.LevelAll {
+		return nil
+	}
+
+	if tracer == nil {
+		tracer = trace.NewTextWriter(os.Stdout)
+	}
+
+	return trace.Filter(tracer, d.traceLevel)
+}
+
+func (d DeviceBuilder) tileTracer(x, y int) trace.Tracer {
+	if d.tracedTiles != nil && !d.tracedTiles.contains(x, y) {
+		return trace.Discard
+	}
+
+	return d.tracer
+}
+
+func (d DeviceBuilder) tileFreq(x, y int) sim.Freq {
+	freq := d.freq
+
+	for _, domain := range d.clockDomains {
+		if domain.contains(x, y) {
+			freq = domain.Freq
+		}
+	}
+
+	return freq
+}
+
 func (d DeviceBuilder) setRemovePorts(dev *device) {
 	for y := 0; y < d.height; y++ {
 		for x := 0; x < d.width; x++ {
 			tile := dev.Tiles[y][x]
+			if tile == nil {
+				continue
+			}
 
 			if x > 0 {
-				westTile := dev.Tiles[y][x-1]
-				tile.SetRemotePort(cgra.West,
-					westTile.Core.GetPortByName(cgra.East.Name()))
+				d.connectNeighbor(tile, dev.Tiles[y][x-1], cgra.West)
 			}
 
 			if y > 0 {
-				northTile := dev.Tiles[y-1][x]
-				tile.SetRemotePort(cgra.North,
-					northTile.Core.GetPortByName(cgra.South.Name()))
+				d.connectNeighbor(tile, dev.Tiles[y-1][x], cgra.North)
 			}
 
 			if x < d.width-1 {
-				eastTile := dev.Tiles[y][x+1]
-				tile.SetRemotePort(cgra.East,
-					eastTile.Core.GetPortByName(cgra.West.Name()))
+				d.connectNeighbor(tile, dev.Tiles[y][x+1], cgra.East)
+				d.connectWire(dev, x, y, cgra.East)
 			}
 
 			if y < d.height-1 {
-				southTile := dev.Tiles[y+1][x]
-				tile.SetRemotePort(cgra.South,
-					southTile.Core.GetPortByName(cgra.North.Name()))
+				d.connectNeighbor(tile, dev.Tiles[y+1][x], cgra.South)
+				d.connectWire(dev, x, y, cgra.South)
 			}
 		}
 	}
 }
+
+func (d DeviceBuilder) connectNeighbor(t, neighbor *tile, side cgra.Side) {
+	if neighbor == nil {
+		return
+	}
+
+	t.SetRemotePort(side,
+		neighbor.Core.GetPortByName(side.Opposite().Name()))
+}
+
+// connectWire connects the tile at (x, y) to its neighbor on the East or the
+// South side with a wire, if the device uses wires.
+func (d DeviceBuilder) connectWire(dev *device, x, y int, side cgra.Side) {
+	nx, ny := x+1, y
+	if side == cgra.South {
+		nx, ny = x, y+1
+	}
+
+	t, neighbor := dev.Tiles[y][x], dev.Tiles[ny][nx]
+	if !d.usesWires() || neighbor == nil {
+		return
+	}
+
+	port := t.Core.GetPortByName(side.Name())
+	remote := neighbor.Core.GetPortByName(side.Opposite().Name())
+
+	w := cgra.NewTimedConnection(port.Name()+"."+remote.Name(),
+		d.engine, d.freq)
+	w.PlugInWithTiming(port, 1, d.link(x, y, side).timing(d.tileFreq(x, y)))
+	w.PlugInWithTiming(remote, 1,
+		d.link(nx, ny, side.Opposite()).timing(d.tileFreq(nx, ny)))
+}
@@ -0,0 +1,217 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+var _ = Describe("DeviceBuilder", func() {
+	It("should leave disabled tiles out of the device", func() {
+		device := config.DeviceBuilder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			WithWidth(2).
+			WithHeight(2).
+			WithDisabledTiles([][2]int{{1, 0}}).
+			Build("Device")
+
+		Expect(device.GetTile(1, 0)).To(BeNil())
+		Expect(device.GetTile(0, 0)).NotTo(BeNil())
+
+		ports := device.GetSidePorts(cgra.North, [2]int{0, 2})
+		Expect(ports[0]).NotTo(BeNil())
+		Expect(ports[1]).To(BeNil())
+	})
+
+	It("should describe the tiles for external tools", func() {
+		device := config.DeviceBuilder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			WithWidth(2).
+			WithHeight(2).
+			WithDisabledTiles([][2]int{{1, 0}}).
+			WithTracer(trace.Discard).
+			Build("Device")
+		device.GetTile(0, 0).MapProgram([]string{
+			"START:", "WAIT, $0, NET_RECV_2", "SEND, NET_SEND_2, $0", "JMP, START",
+		})
+
+		Expect(device.GetTileCoords()).
+			To(Equal([][2]int{{0, 0}, {0, 1}, {1, 1}}))
+
+		info := device.DescribeTile(0, 0)
+		Expect(info.Name).To(Equal("Device.Tile[0][0].Core"))
+		Expect(info.Ports).To(Equal([]string{
+			"Device.Tile[0][0].Core.North", "Device.Tile[0][0].Core.East",
+			"Device.Tile[0][0].Core.South", "Device.Tile[0][0].Core.West",
+		}))
+		Expect(info.Neighbors).To(Equal(
+			map[cgra.Side][2]int{cgra.South: {0, 1}}))
+		Expect(info.Program).To(Equal(cgra.ProgramSummary{
+			Instructions: 3,
+			Opcodes:      map[string]int{"WAIT": 1, "SEND": 1, "JMP": 1},
+			Current:      "WAIT, $0, NET_RECV_2",
+		}))
+		Expect(func() { device.DescribeTile(1, 0) }).To(Panic())
+	})
+
+	It("should stream through more than one channel of a tile", func() {
+		tb := newTestbed(1, 2)
+		tb.device = tb.device.WithChannels(2)
+		driver, _ := tb.build()
+
+		Expect(driver.CheckProgram("WAIT, $0, NET_RECV_11", [2]int{0, 0})).
+			To(MatchError(ContainSubstring(
+				"the PE has no channel 2 on the West side")))
+
+		src := []uint32{1, 10, 2, 20, 3, 30}
+		dst := make([]uint32, 3)
+		driver.FeedIn(src, cgra.West, [2]int{
+			api.ChannelPort(1, 0, 2), api.ChannelPort(1, 2, 2)}, 2)
+		driver.Collect(dst, cgra.East, [2]int{
+			api.ChannelPort(1, 1, 2), api.ChannelPort(1, 2, 2)}, 1)
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\n"+
+			"WAIT, $1, NET_RECV_7\nI_ADD, $0, $0, $1\n"+
+			"SEND, NET_SEND_5, $0\nJMP, START", [2]int{0, 1})
+		driver.Run()
+
+		Expect(dst).To(Equal([]uint32{11, 22, 33}))
+		Expect(driver.GetRoofline().PeakWordsPerCycle).To(Equal(12.0))
+	})
+
+	It("should connect the PEs of a cluster through a crossbar", func() {
+		tb := newTestbed(4, 2)
+		tb.device = tb.device.WithClustering(2, 2)
+		driver, _ := tb.build()
+
+		driver.MapProgram("SEND, NET_SEND_7, 5\nDONE", [2]int{0, 0})
+		driver.MapProgram("SEND, NET_SEND_7, 6\nDONE", [2]int{2, 0})
+		driver.MapProgram("SEND, NET_SEND_7, 7\nDONE", [2]int{2, 1})
+		driver.MapProgram("WAIT, $0, NET_RECV_4\nRETURN_VALUE, $0\nDONE",
+			[2]int{1, 1})
+		driver.MapProgram("WAIT, $0, NET_RECV_4\nWAIT, $1, NET_RECV_6\n"+
+			"I_ADD, $0, $0, $1\nRETURN_VALUE, $0\nDONE", [2]int{3, 1})
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()).To(Equal(map[[2]int]uint32{
+			{1, 1}: 5,
+			{3, 1}: 13,
+		}))
+	})
+
+	It("should reject clusters that need too many channels", func() {
+		tb := newTestbed(4, 4)
+		tb.device = tb.device.WithClustering(4, 4)
+
+		Expect(func() { tb.device.Build("Device") }).To(Panic())
+	})
+
+	It("should time the links with their latencies and bandwidths", func() {
+		transfer := func(
+			link func(config.DeviceBuilder) config.DeviceBuilder,
+		) sim.VTimeInSec {
+			tb := newTestbed(2, 1)
+			tb.device = link(tb.device)
+			driver, _ := tb.build()
+
+			driver.MapProgram("I_ADD, $3, 0, 7\n"+
+				"SEND_WIDE, NET_SEND_1, $0, 4\nDONE", [2]int{0, 0})
+			driver.MapProgram("WAIT_WIDE, $0, NET_RECV_3, 4\n"+
+				"RETURN_VALUE, $3\nDONE", [2]int{1, 0})
+			driver.WaitAllDone()
+
+			Expect(driver.GetReturnValues()[[2]int{1, 0}]).To(Equal(uint32(7)))
+
+			return tb.engine.CurrentTime()
+		}
+		withLink := func(
+			x, y int, side cgra.Side, link config.LinkConfig,
+		) func(config.DeviceBuilder) config.DeviceBuilder {
+			return func(b config.DeviceBuilder) config.DeviceBuilder {
+				return b.WithLink(x, y, side, link)
+			}
+		}
+		withLinks := func(
+			link config.LinkConfig,
+		) func(config.DeviceBuilder) config.DeviceBuilder {
+			return func(b config.DeviceBuilder) config.DeviceBuilder {
+				return b.WithLinks(link)
+			}
+		}
+
+		base := transfer(withLinks(config.LinkConfig{}))
+
+		Expect(transfer(withLinks(config.LinkConfig{Latency: 5}))).
+			To(BeNumerically("~", base+4e-9, 1e-12))
+		Expect(transfer(withLinks(config.LinkConfig{Bandwidth: 4}))).
+			To(BeNumerically("~", base-3e-9, 1e-12))
+		Expect(transfer(withLink(0, 0, cgra.East,
+			config.LinkConfig{Latency: 3}))).
+			To(BeNumerically("~", base+2e-9, 1e-12))
+		Expect(transfer(withLink(1, 0, cgra.West,
+			config.LinkConfig{Latency: 3}))).
+			To(BeNumerically("~", base, 1e-12))
+	})
+
+	It("should count the latency of a link in cycles of the sender", func() {
+		transfer := func(latency int) sim.VTimeInSec {
+			tb := newTestbed(2, 1)
+			tb.device = tb.device.
+				WithClockDomains([]config.ClockDomain{
+					{X: 0, Y: 0, Width: 1, Height: 1, Freq: 500 * sim.MHz},
+				}).
+				WithLink(0, 0, cgra.East, config.LinkConfig{Latency: latency})
+			driver, _ := tb.build()
+
+			driver.MapProgram("SEND, NET_SEND_1, 7\nDONE", [2]int{0, 0})
+			driver.MapProgram("WAIT, $0, NET_RECV_3\n"+
+				"RETURN_VALUE, $0\nDONE", [2]int{1, 0})
+			driver.WaitAllDone()
+
+			Expect(driver.GetReturnValues()[[2]int{1, 0}]).To(Equal(uint32(7)))
+
+			return tb.engine.CurrentTime()
+		}
+
+		Expect(transfer(5)).To(BeNumerically("~", transfer(1)+8e-9, 1e-12))
+	})
+
+	It("should trace a region of tiles at a level to a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "trace.log")
+		tb := newTestbed(2, 1)
+		tb.device = tb.device.
+			WithTraceFile(path).
+			WithTraceLevel(trace.LevelMessage).
+			WithTracedTiles(1, 0, 1, 1)
+		driver, _ := tb.build()
+
+		src := []uint32{1, 2}
+		dst := make([]uint32, 2)
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		program := "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START"
+		driver.MapProgram(program, [2]int{0, 0})
+		driver.MapProgram(program, [2]int{1, 0})
+		driver.Run()
+
+		f, err := os.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		log, err := trace.Parse(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(dst).To(Equal(src))
+		Expect(log.Components()).To(Equal([]string{"Device.Tile[1][0].Core"}))
+		counts := log.Counts("Device.Tile[1][0].Core")
+		Expect(counts[trace.KindSend]).To(Equal(2))
+		Expect(counts[trace.KindWrite]).To(BeZero())
+	})
+})
@@ -0,0 +1,135 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("PauseAt", func() {
+	It("should pause for the host to patch the registers", func() {
+		tb := newTestbed(1, 1)
+		driver, _ := tb.build()
+
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\nI_MUL, $1, $0, $2\n"+
+			"SEND, NET_SEND_1, $1\nJMP, START", [2]int{0, 0})
+		driver.WriteRegister([2]int{0, 0}, 2, 2)
+
+		first := make([]uint32, 3)
+		driver.FeedIn([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(first, cgra.East, [2]int{0, 1}, 1)
+		driver.PauseAt(100)
+		driver.Run()
+
+		Expect(driver.IsPaused()).To(BeTrue())
+		Expect(tb.engine.CurrentTime()).To(Equal(100 * sim.GHz.Period()))
+		Expect(first).To(Equal([]uint32{2, 4, 6}))
+		Expect(driver.ReadRegister([2]int{0, 0}, 1)).To(Equal(uint32(6)))
+
+		second := make([]uint32, 3)
+		driver.WriteRegister([2]int{0, 0}, 2, 10)
+		driver.FeedIn([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(second, cgra.East, [2]int{0, 1}, 1)
+		driver.Run()
+
+		Expect(driver.IsPaused()).To(BeFalse())
+		Expect(second).To(Equal([]uint32{10, 20, 30}))
+	})
+})
+
+var _ = Describe("Watchpoints", func() {
+	It("should pause when a watched location takes the value", func() {
+		tb := newTestbed(1, 1)
+		driver, _ := tb.build()
+
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\n"+
+			"I_ADD, $1, $1, $0 @ src=sum.c:4\n"+
+			"SCATTER, $0, $1\nSEND, NET_SEND_1, $1\nJMP, START", [2]int{0, 0})
+
+		dst := make([]uint32, 4)
+		driver.FeedIn([]uint32{1, 2, 3, 4}, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.WatchRegister([2]int{0, 0}, 1, 6)
+		driver.WatchMemory([2]int{0, 0}, 4, 10)
+		driver.WatchRegister([2]int{0, 0}, 1, 100)
+		driver.Run()
+
+		Expect(driver.IsPaused()).To(BeTrue())
+		hits := driver.GetWatchHits()
+		Expect(hits).To(HaveLen(1))
+		Expect(hits[0].String()).To(MatchRegexp(
+			`^device 0 tile \(0, 0\) at \d+ ns: \$1 == 6 after line 2: ` +
+				`"I_ADD, \$1, \$1, \$0" \(sum.c:4\)$`))
+		Expect(tb.engine.CurrentTime()).To(Equal(hits[0].Time))
+		Expect(driver.ReadRegister([2]int{0, 0}, 1)).To(Equal(uint32(6)))
+
+		driver.Run()
+		Expect(driver.IsPaused()).To(BeTrue())
+		Expect(driver.GetWatchHits()[1].Watchpoint).To(Equal(cgra.Watchpoint{
+			Memory: true, Index: 4, Value: 10}))
+
+		driver.Run()
+		Expect(driver.IsPaused()).To(BeFalse())
+		Expect(dst).To(Equal([]uint32{1, 3, 6, 10}))
+		Expect(driver.GetWatchHits()).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Reconfigure", func() {
+	It("should reconfigure a region while the other tiles keep running", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		driver.MapProgram("RETURN_VALUE, 1\nDONE", [2]int{0, 0})
+		driver.MapProgram("START:\nI_ADD, $0, $0, 1\nJEQ, END, $0, 50\n"+
+			"JMP, START\nEND:\nDONE", [2]int{1, 0})
+		driver.PauseAt(20)
+		driver.Run()
+
+		Expect(driver.IsPaused()).To(BeTrue())
+		Expect(func() {
+			driver.Reconfigure(api.Region{X: 1, Y: 0, Width: 1, Height: 1},
+				map[[2]int]string{{1, 0}: "DONE"})
+		}).To(PanicWith(ContainSubstring("still running")))
+		Expect(func() {
+			driver.Reconfigure(api.Region{X: 0, Y: 0, Width: 1, Height: 1},
+				map[[2]int]string{{1, 0}: "DONE"})
+		}).To(PanicWith(ContainSubstring("outside of the region")))
+
+		driver.Reconfigure(api.Region{X: 0, Y: 0, Width: 1, Height: 1},
+			map[[2]int]string{{0, 0}: "RETURN_VALUE, 2\nDONE"})
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()).
+			To(Equal(map[[2]int]uint32{{0, 0}: 2}))
+		Expect(driver.ReadRegister([2]int{1, 0}, 0)).To(Equal(uint32(50)))
+	})
+})
+
+var _ = Describe("Preempt", func() {
+	It("should preempt a region and restore it", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		driver.MapProgram("START:\nI_ADD, $0, $0, 1\nSEND, NET_SEND_1, $0\n"+
+			"JEQ, END, $0, 30\nJMP, START\nEND:\nDONE", [2]int{0, 0})
+		driver.MapProgram("START:\nWAIT, $1, NET_RECV_3\nI_ADD, $0, $0, $1\n"+
+			"JEQ, END, $1, 30\nJMP, START\nEND:\nRETURN_VALUE, $0\nDONE",
+			[2]int{1, 0})
+		driver.PauseAt(20)
+		driver.Run()
+
+		other := make([]uint32, 1)
+		region := api.Region{X: 1, Y: 0, Width: 1, Height: 1}
+		driver.Preempt(region, map[[2]int]string{
+			{1, 0}: "I_ADD, $0, 0, 7\nSEND, NET_SEND_1, $0\nDONE",
+		})
+		driver.Collect(other, cgra.East, [2]int{0, 1}, 1)
+		driver.Restore(region)
+		driver.WaitAllDone()
+
+		Expect(other).To(Equal([]uint32{7}))
+		Expect(driver.GetReturnValues()).
+			To(Equal(map[[2]int]uint32{{1, 0}: 465}))
+	})
+})
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// clusterChannels returns the number of channels that the ports of a
+// cluster of the size need.
+func clusterChannels(width, height int) int {
+	return (width*height + 3) / 4
+}
+
+// connectClusters connects the PEs of each cluster of the device with a
+// crossbar, which connects them to each other in one hop. A message arrives
+// in the next cycle of the sending PE, and each PE receives at most one
+// message per cycle from the crossbar, the oldest first. Port
+// 4*dev.Channels+k of a PE connects to the k-th PE of its cluster in the
+// row-major order.
+func (d DeviceBuilder) connectClusters(dev *device, name string) {
+	cw, ch := d.clusterWidth, d.clusterHeight
+
+	for cy := 0; cy < d.height; cy += ch {
+		for cx := 0; cx < d.width; cx += cw {
+			xbar := cgra.NewTimedConnection(
+				fmt.Sprintf("%s.Crossbar[%d][%d]", name, cx/cw, cy/ch),
+				d.engine, d.freq)
+
+			members := d.clusterMembers(dev, cx, cy)
+			for i, t := range members {
+				for k, peer := range members {
+					if i == k || t == nil || peer == nil {
+						continue
+					}
+
+					side, channel := clusterPort(dev.Channels, k)
+					xbar.PlugInWithTiming(t.GetChannelPort(side, channel), 1,
+						cgra.Timing{Freq: t.GetFreq(), Latency: 1})
+
+					peerSide, peerChannel := clusterPort(dev.Channels, i)
+					t.SetChannelRemotePort(side, channel,
+						peer.GetChannelPort(peerSide, peerChannel))
+				}
+			}
+		}
+	}
+}
+
+// clusterPort returns the side and the channel of the port that connects a
+// PE to the k-th PE of its cluster.
+func clusterPort(ioChannels, k int) (cgra.Side, int) {
+	index := 4*ioChannels + k
+
+	return cgra.Side(index % 4), index / 4
+}
+
+// clusterMembers returns the tiles of the cluster whose top-left tile is at
+// (cx, cy), in the row-major order. Disabled tiles and tiles outside of the
+// device are nil.
+func (d DeviceBuilder) clusterMembers(dev *device, cx, cy int) []*tile {
+	members := []*tile{}
+
+	for y := cy; y < cy+d.clusterHeight; y++ {
+		for x := cx; x < cx+d.clusterWidth; x++ {
+			if x >= d.width || y >= d.height {
+				members = append(members, nil)
+				continue
+			}
+
+			members = append(members, dev.Tiles[y][x])
+		}
+	}
+
+	return members
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+var _ = Describe("DRAM interface", func() {
+	passThrough := func(b api.DriverBuilder) (api.Driver, sim.VTimeInSec) {
+		engine := sim.NewSerialEngine()
+		driver := b.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(1).
+			WithHeight(2).
+			WithTracer(trace.Discard).
+			Build("Device"))
+
+		program := "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n" +
+			"JMP, START"
+		driver.MapProgram(program, [2]int{0, 0})
+		driver.MapProgram(program, [2]int{0, 1})
+
+		src := []uint32{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		dst := make([]uint32, len(src))
+		driver.FeedIn(src, cgra.West, [2]int{0, 2}, 2)
+		driver.Collect(dst, cgra.East, [2]int{0, 2}, 2)
+		driver.Run()
+
+		Expect(dst).To(Equal(src))
+
+		return driver, engine.CurrentTime()
+	}
+
+	It("should limit the data by the bandwidth, the bursts, and the latency",
+		func() {
+			_, direct := passThrough(api.DriverBuilder{})
+			driver, limited := passThrough(api.DriverBuilder{}.
+				WithDRAMInterface(api.DRAMInterface{
+					BeatsPerCycle: 1,
+					BurstWords:    4,
+					Latency:       10,
+				}))
+
+			stats := driver.GetDRAMStats()
+			Expect(stats.ReadWords).To(Equal(uint64(12)))
+			Expect(stats.ReadBursts).To(Equal(uint64(3)))
+			Expect(stats.ReadBusyCycles).To(Equal(uint64(12)))
+			Expect(stats.WriteWords).To(Equal(uint64(12)))
+			Expect(stats.WriteBursts).To(Equal(uint64(3)))
+			Expect(stats.ReadStallCycles).To(BeNumerically(">", 10))
+
+			// The FeedIn takes a cycle per word instead of a cycle per
+			// round, after the latency of the first burst, and the Collect
+			// waits for the last write.
+			Expect(limited - direct).To(BeNumerically(">=", 16e-9))
+		})
+
+	It("should not stall with enough bandwidth and outstanding bursts",
+		func() {
+			driver, _ := passThrough(api.DriverBuilder{}.
+				WithDRAMInterface(api.DRAMInterface{
+					BeatsPerCycle: 2,
+					BurstWords:    2,
+					Outstanding:   2,
+				}))
+
+			stats := driver.GetDRAMStats()
+			Expect(stats.ReadBursts).To(Equal(uint64(6)))
+			Expect(stats.WriteStallCycles).To(BeZero())
+		})
+})
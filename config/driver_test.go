@@ -0,0 +1,208 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("Driver", func() {
+	It("should collect the return values of multiple tiles", func() {
+		driver, _ := newTestbed(2, 2).build()
+
+		driver.MapProgram("RETURN_VALUE, 3\nDONE", [2]int{0, 0})
+		driver.MapProgram("I_ADD, $0, 2, 5\nRETURN_VALUE, $0\nDONE",
+			[2]int{1, 1})
+
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()).To(Equal(map[[2]int]uint32{
+			{0, 0}: 3,
+			{1, 1}: 7,
+		}))
+	})
+
+	It("should wait until all the tiles are done", func() {
+		driver, device := newTestbed(2, 1).build()
+
+		src := []uint32{1}
+		dst := make([]uint32, 1)
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.MapProgram("WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nDONE",
+			[2]int{0, 0})
+		driver.MapProgram("WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n"+
+			"WAIT, $0, NET_RECV_3",
+			[2]int{1, 0})
+
+		Expect(driver.WaitAllDone).To(PanicWith(ContainSubstring("(1, 0)")))
+		Expect(dst).To(Equal(src))
+		Expect(device.GetTile(0, 0).IsDone()).To(BeTrue())
+	})
+
+	It("should halt only the tile that fails to run an instruction", func() {
+		tb := newTestbed(2, 1)
+		tb.device = tb.device.WithMemorySize(4)
+		driver, _ := tb.build()
+
+		driver.MapProgram("I_ADD, $1, 0, 1\nGATHER, $0, 8 @ src=k.c:3\nDONE",
+			[2]int{0, 0})
+		driver.MapProgram("I_ADD, $1, 0, 5\nRETURN_VALUE, $1\nDONE",
+			[2]int{1, 0})
+
+		Expect(driver.WaitAllDone).NotTo(Panic())
+		Expect(driver.GetReturnValues()).To(Equal(map[[2]int]uint32{{1, 0}: 5}))
+		Expect(driver.ReadRegister([2]int{0, 0}, 1)).To(Equal(uint32(1)))
+
+		errs := driver.GetErrors()
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Tile).To(Equal([2]int{0, 0}))
+		Expect(errs[0].PC).To(Equal(uint32(1)))
+		Expect(errs[0].Opcode).To(Equal("GATHER"))
+		Expect(errs[0].Operands).To(Equal([]string{"$0", "8"}))
+		Expect(errs[0].Error()).To(Equal("device 0 tile (0, 0) line 1: " +
+			"\"GATHER, $0, 8\": address 8 is out of the local memory of 4 words " +
+			"(k.c:3)"))
+	})
+
+	It("should resolve the constants set on the driver", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		program := "I_MUL, $0, N, 2\nRETURN_VALUE, $0\nDONE"
+		Expect(driver.CheckProgram(program, [2]int{0, 0})).
+			To(MatchError(ContainSubstring("invalid operand \"N\"")))
+
+		driver.SetProgramConstant("N", 20)
+		driver.SetProgramConstant("N", 30)
+		driver.MapProgram(program, [2]int{0, 0})
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()).To(Equal(map[[2]int]uint32{{0, 0}: 60}))
+	})
+
+	It("should pass kernel arguments to all the tiles", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		driver.SetKernelArg(0, 5)
+		driver.SetKernelArg(1, 7)
+		driver.MapProgram("I_ADD, $0, ARG0, ARG1\nRETURN_VALUE, $0\nDONE",
+			[2]int{0, 0})
+		driver.MapProgram("RETURN_VALUE, ARG1\nDONE", [2]int{1, 0})
+		driver.WaitAllDone()
+
+		Expect(driver.GetReturnValues()).To(Equal(map[[2]int]uint32{
+			{0, 0}: 12,
+			{1, 0}: 7,
+		}))
+		Expect(func() { driver.SetKernelArg(8, 0) }).To(Panic())
+	})
+
+	It("should give each tile its own random sequence", func() {
+		run := func(seed uint64) map[[2]int]uint32 {
+			driver, _ := newTestbed(2, 1).build()
+
+			driver.SetRandomSeed(seed)
+			driver.MapProgram("RAND, $0\nRETURN_VALUE, $0\nDONE", [2]int{0, 0})
+			driver.MapProgram("RAND, $0\nRETURN_VALUE, $0\nDONE", [2]int{1, 0})
+			driver.Run()
+
+			return driver.GetReturnValues()
+		}
+
+		values := run(42)
+
+		Expect(values).To(HaveLen(2))
+		Expect(values[[2]int{0, 0}]).NotTo(Equal(values[[2]int{1, 0}]))
+		Expect(run(42)).To(Equal(values))
+		Expect(run(43)).NotTo(Equal(values))
+	})
+
+	It("should reproduce the final state with the same seed", func() {
+		run := func(seed int64, data []uint32) ([]uint32, uint64) {
+			tb := newTestbed(1, 2)
+			tb.driver = tb.driver.WithSeed(seed)
+			driver, _ := tb.build()
+
+			dst := make([]uint32, 4)
+			driver.FeedIn(data[:2], cgra.West, [2]int{0, 1}, 1)
+			driver.FeedIn(data[2:], cgra.West, [2]int{1, 2}, 1)
+			driver.Collect(dst[:2], cgra.East, [2]int{0, 1}, 1)
+			driver.Collect(dst[2:], cgra.East, [2]int{1, 2}, 1)
+			program := "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START"
+			driver.MapProgram(program, [2]int{0, 0})
+			driver.MapProgram(program, [2]int{0, 1})
+			driver.Run()
+
+			return dst, driver.StateHash()
+		}
+
+		src := []uint32{1, 2, 3, 4}
+		dst1, hash1 := run(42, src)
+		dst2, hash2 := run(42, src)
+		dst3, _ := run(7, src)
+		_, hash4 := run(42, []uint32{1, 2, 3, 5})
+
+		Expect(dst1).To(Equal(src))
+		Expect(dst2).To(Equal(src))
+		Expect(dst3).To(Equal(src))
+		Expect(hash2).To(Equal(hash1))
+		Expect(hash4).NotTo(Equal(hash1))
+	})
+
+	It("should synchronize data crossing clock domains", func() {
+		run := func(syncStages int) ([]uint32, sim.VTimeInSec) {
+			tb := newTestbed(1, 1)
+			tb.driver = tb.driver.WithSyncStages(syncStages)
+			tb.device = tb.device.WithFreq(3 * sim.GHz)
+			driver, _ := tb.build()
+
+			src := []uint32{1, 2, 3, 4}
+			dst := make([]uint32, 4)
+			driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+			driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+			driver.MapProgram(
+				"START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+				[2]int{0, 0})
+
+			driver.Run()
+
+			return dst, tb.engine.CurrentTime()
+		}
+
+		dstDirect, timeDirect := run(0)
+		dstSynced, timeSynced := run(2)
+
+		Expect(dstDirect).To(Equal([]uint32{1, 2, 3, 4}))
+		Expect(dstSynced).To(Equal([]uint32{1, 2, 3, 4}))
+		Expect(timeSynced).To(BeNumerically(">", timeDirect))
+	})
+
+	It("should delay the programs until the configuration bus loads them", func() {
+		run := func(bandwidth int) (sim.VTimeInSec, []api.ConfigTime) {
+			tb := newTestbed(2, 1)
+			tb.driver = tb.driver.WithConfigBandwidth(bandwidth)
+			driver, _ := tb.build()
+
+			program := "START:\nI_ADD, $0, 0, 1\nRETURN_VALUE, $0\nDONE"
+			driver.MapProgram(program, [2]int{0, 0})
+			driver.MapProgram(program, [2]int{1, 0})
+			driver.WaitAllDone()
+
+			Expect(driver.GetReturnValues()).To(HaveLen(2))
+
+			return tb.engine.CurrentTime(), driver.GetConfigTime()
+		}
+
+		instant, instantConfig := run(0)
+		timed, timedConfig := run(1)
+
+		Expect(instantConfig).To(Equal([]api.ConfigTime{
+			{Start: 0, End: 0, Programs: 2, Words: 6},
+		}))
+		Expect(timedConfig).To(HaveLen(1))
+		Expect(timedConfig[0].End).To(BeNumerically("~", 6e-9, 1e-12))
+		Expect(timed).To(BeNumerically("~", instant+6e-9, 2e-9))
+	})
+})
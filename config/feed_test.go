@@ -0,0 +1,222 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("FeedIn and Collect", func() {
+	It("should carry two 16-bit values per transfer", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		src := []uint16{1, 0xfffe, 3, 0xfffc}
+		dst := make([]uint32, 2)
+		driver.FeedInPacked(src, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.MapProgram("START:\n"+
+			"WAIT, $0, NET_RECV_3\n"+
+			"UNPACK, $1, $2, $0\n"+
+			"I_ADD, $3, $1, $2\n"+
+			"SEND, NET_SEND_1, $3\n"+
+			"JMP, START", [2]int{0, 0})
+
+		driver.Run()
+
+		Expect(dst).To(Equal([]uint32{0xffffffff, 0xffffffff}))
+	})
+
+	It("should multiply a sparse matrix by a vector", func() {
+		tb := newTestbed(1, 3)
+		tb.device = tb.device.WithMemorySize(16)
+		driver, _ := tb.build()
+
+		rows := [][]api.SparsePair{
+			{{Index: 0, Value: 2}, {Index: 3, Value: 5}},
+			{{Index: 1, Value: 7}},
+			{{Index: 0, Value: 1}, {Index: 2, Value: 3}},
+		}
+
+		program := `LOOP:
+JEQ, END, $3, 0
+WAIT, $1, NET_RECV_3
+WAIT, $2, NET_RECV_3
+GATHER, $1, $1
+I_MUL, $1, $1, $2
+I_ADD, $0, $0, $1
+I_SUB, $3, $3, 1
+JMP, LOOP
+END:
+SCATTER, 8, $0
+DONE`
+
+		driver.SetKernelArg(0, 2)
+		driver.FeedInSparse(rows, cgra.West, [2]int{0, 3})
+		for y := 0; y < 3; y++ {
+			driver.WriteMemory([2]int{0, y}, 0, []uint32{1, 2, 3, 4})
+			driver.MapProgram("I_ADD, $3, ARG0, 0\n"+program, [2]int{0, y})
+		}
+
+		driver.Run()
+
+		Expect(driver.ReadMemory([2]int{0, 0}, 8, 1)).To(Equal([]uint32{22}))
+		Expect(driver.ReadMemory([2]int{0, 1}, 8, 1)).To(Equal([]uint32{14}))
+		Expect(driver.ReadMemory([2]int{0, 2}, 8, 1)).To(Equal([]uint32{10}))
+	})
+
+	It("should collect the validity of a filtered stream", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		filter := `LOOP:
+WAIT, $0, NET_RECV_3
+I_CMP_GT, $1, $0, 2
+SEND_PRED, NET_SEND_1, $0, $1
+JMP, LOOP`
+
+		ports := [2]int{0, 1}
+		data := make([]uint32, 5)
+		valid := make([]bool, 5)
+
+		driver.FeedIn([]uint32{3, 1, 4, 1, 5}, cgra.West, ports, 1)
+		driver.CollectWithValidity(data, valid, cgra.East, ports, 1)
+		driver.MapProgram(filter, [2]int{0, 0})
+		driver.MapProgram("LOOP:\nFORWARD, NET_SEND_1, NET_RECV_3\nJMP, LOOP",
+			[2]int{1, 0})
+		driver.Run()
+
+		Expect(data).To(Equal([]uint32{3, 0, 4, 0, 5}))
+		Expect(valid).To(Equal([]bool{true, false, true, false, true}))
+	})
+
+	It("should loop between the device and the host checks", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		driver.MapProgram(`LOOP:
+WAIT, $0, NET_RECV_3
+JEQ, END, $0, 0
+I_MUL, $0, $0, 2
+SEND, NET_SEND_1, $0
+JMP, LOOP
+END:
+DONE`, [2]int{0, 0})
+
+		ports := [2]int{0, 1}
+		results := []uint32{}
+		driver.FeedIn([]uint32{1}, cgra.West, ports, 1)
+		driver.Chain(api.CollectTask{
+			Data:      make([]uint32, 1),
+			Side:      cgra.East,
+			PortRange: ports,
+			Stride:    1,
+		}, func(data []uint32) (*api.FeedInTask, bool) {
+			results = append(results, data[0])
+			converged := data[0] >= 100
+			next := data[0]
+			if converged {
+				next = 0
+			}
+
+			return &api.FeedInTask{
+				Data:      []uint32{next},
+				Side:      cgra.West,
+				PortRange: ports,
+				Stride:    1,
+			}, !converged
+		})
+
+		driver.WaitAllDone()
+
+		Expect(results).To(Equal([]uint32{2, 4, 8, 16, 32, 64, 128}))
+	})
+
+	It("should feed in the rounds at their cycles", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		arrivals := make([]uint32, 3)
+		driver.FeedInAt([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1, 10, 5)
+		driver.Collect(arrivals, cgra.East, [2]int{0, 1}, 1)
+		driver.MapProgram("LOOP:\nWAIT, $0, NET_RECV_3\n"+
+			"RDCOUNTER, $1, CYCLES\nSEND, NET_SEND_1, $1\nJMP, LOOP", [2]int{0, 0})
+		driver.Run()
+
+		Expect(arrivals).To(Equal([]uint32{12, 17, 22}))
+		Expect(func() {
+			driver.FeedInAt([]uint32{1}, cgra.West, [2]int{0, 1}, 1, 0, 0)
+		}).To(Panic())
+	})
+
+	It("should back up the data that a slow sink has not taken", func() {
+		tb := newTestbed(1, 1)
+		driver, _ := tb.build()
+
+		src := []uint32{1, 2, 3, 4, 5, 6}
+		dst := make([]uint32, 6)
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.CollectAtRate(dst, cgra.East, [2]int{0, 1}, 1, 10)
+		driver.MapProgram("LOOP:\nWAIT, $0, NET_RECV_3\n"+
+			"SEND, NET_SEND_1, $0\nJMP, LOOP", [2]int{0, 0})
+		driver.Run()
+
+		Expect(dst).To(Equal(src))
+		Expect(tb.engine.CurrentTime()).To(BeNumerically(">=", 50e-9))
+		Expect(driver.GetActivityStats()[[2]int{0, 0}].Stalls.OutputCycles).
+			To(BeNumerically(">", 0))
+	})
+
+	DescribeTable("should arbitrate the FeedIn tasks that share ports",
+		func(policy api.Arbitration, want []uint32) {
+			tb := newTestbed(1, 1)
+			tb.driver = tb.driver.WithArbitration(policy)
+			driver, _ := tb.build()
+
+			dst := make([]uint32, 6)
+			driver.FeedIn([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1)
+			driver.SetTaskPriority(1)
+			driver.FeedIn([]uint32{11, 12, 13}, cgra.West, [2]int{0, 1}, 1)
+			driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+			driver.MapProgram("LOOP:\nWAIT, $0, NET_RECV_3\n"+
+				"SEND, NET_SEND_1, $0\nJMP, LOOP", [2]int{0, 0})
+			driver.Run()
+
+			Expect(dst).To(Equal(want))
+
+			stats := driver.GetTaskStats()
+			Expect(stats).To(HaveLen(3))
+			Expect(stats[1].Kind).To(Equal("FeedIn"))
+			Expect(stats[1].Priority).To(Equal(1))
+			Expect(stats[1].Rounds).To(Equal(3))
+			Expect(stats[0].StarvedCycles + stats[1].StarvedCycles).
+				To(BeNumerically(">", 0))
+		},
+		Entry("in order", api.ArbitrationInOrder,
+			[]uint32{1, 2, 3, 11, 12, 13}),
+		Entry("round robin", api.ArbitrationRoundRobin,
+			[]uint32{1, 11, 2, 12, 3, 13}),
+		Entry("oldest first", api.ArbitrationOldestFirst,
+			[]uint32{1, 11, 2, 12, 3, 13}),
+		Entry("by priority", api.ArbitrationPriority,
+			[]uint32{11, 12, 13, 1, 2, 3}),
+	)
+
+	It("should reject the ports of disabled tiles", func() {
+		tb := newTestbed(1, 2)
+		tb.device = tb.device.WithDisabledTiles([][2]int{{0, 1}})
+		driver, _ := tb.build()
+
+		dst := make([]uint32, 2)
+
+		Expect(func() {
+			driver.FeedIn([]uint32{1, 2}, cgra.West, [2]int{0, 2}, 1)
+		}).To(PanicWith("port 1 on the West side of device 0 belongs " +
+			"to the disabled tile (0, 1)"))
+		Expect(func() {
+			driver.Collect(dst, cgra.East, [2]int{0, 2}, 1)
+		}).To(PanicWith("port 1 on the East side of device 0 belongs " +
+			"to the disabled tile (0, 1)"))
+		Expect(func() {
+			driver.FeedIn([]uint32{1}, cgra.West, [2]int{0, 1}, 1)
+			driver.Collect(dst[:1], cgra.East, [2]int{0, 1}, 1)
+		}).NotTo(Panic())
+	})
+})
@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("Host", func() {
+	It("should include the host latency in the end-to-end latency", func() {
+		tb := newTestbed(1, 1)
+		driver, _ := tb.build()
+		host := api.HostBuilder{}.
+			WithEngine(tb.engine).
+			WithFreq(1 * sim.GHz).
+			WithDriver(driver).
+			WithLaunchLatency(100).
+			WithTransferLatency(10).
+			Build("Host")
+
+		src := []uint32{1, 2, 3, 4}
+		dst := make([]uint32, 4)
+		host.MapProgram(
+			"START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+			[2]int{0, 0})
+		host.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		host.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		host.Wait()
+
+		host.Run()
+
+		Expect(dst).To(Equal(src))
+		records := host.GetCommandRecords()
+		Expect(records).To(HaveLen(4))
+		Expect(records[0].Name).To(Equal("MapProgram"))
+		Expect(records[0].IssueTime - records[0].StartTime).
+			To(BeNumerically("~", 100e-9, 1e-12))
+		Expect(records[3].Name).To(Equal("Wait"))
+		Expect(records[3].CompleteTime).
+			To(BeNumerically(">", records[2].IssueTime))
+		Expect(host.GetEndToEndLatency()).
+			To(BeNumerically(">", 120e-9))
+	})
+})
@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("ReductionTree", func() {
+	It("should reduce the values of a region to the root", func() {
+		run := func(op string) map[[2]int]uint32 {
+			driver, _ := newTestbed(4, 3).build()
+
+			programs, root := api.ReductionTree(0, 0, 4, 3, op, "$0")
+			for coord, program := range programs {
+				value := coord[0] + 10*coord[1]
+				program = fmt.Sprintf("I_ADD, $0, %d, 0\n%s\n", value, program)
+				if coord == root {
+					program += "RETURN_VALUE, $0\n"
+				}
+
+				driver.MapProgram(program+"DONE", coord)
+			}
+
+			driver.Run()
+
+			return driver.GetReturnValues()
+		}
+
+		Expect(run("REDUCE_ADD")).To(Equal(map[[2]int]uint32{{1, 1}: 138}))
+		Expect(run("REDUCE_MAX")).To(Equal(map[[2]int]uint32{{1, 1}: 23}))
+		Expect(run("REDUCE_MIN")).To(Equal(map[[2]int]uint32{{1, 1}: 0}))
+	})
+})
+
+var _ = Describe("GeneratePrefixSumProgram", func() {
+	It("should compute prefix sums with the generated programs", func() {
+		run := func(n, width, height int) {
+			driver, _ := newTestbed(width, height).build()
+
+			values := make([]uint32, n)
+			expected := make([]uint32, n)
+			sum := uint32(0)
+			for i := range values {
+				values[i] = uint32(i*7%11) - 3
+				sum += values[i]
+				expected[i] = sum
+			}
+
+			p := api.GeneratePrefixSumProgram(n, width, height)
+
+			Expect(p.Run(driver, values)).To(Equal(expected),
+				"%d values on %dx%d PEs", n, width, height)
+		}
+
+		run(12, 4, 3)
+		run(10, 5, 3)
+		run(16, 8, 2)
+		run(3, 1, 3)
+	})
+})
+
+var _ = Describe("Half precision", func() {
+	It("should multiply packed half-precision values", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		dst := make([]uint32, 2)
+		driver.FeedInPacked(api.ToF16([]float32{1.5, 2, -0.5, 3}),
+			cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.MapProgram("START:\n"+
+			"WAIT, $0, NET_RECV_3\n"+
+			"UNPACK, $1, $2, $0\n"+
+			"F16_MUL, $3, $1, $2\n"+
+			"SEND, NET_SEND_1, $3\n"+
+			"JMP, START", [2]int{0, 0})
+		driver.Run()
+
+		Expect(api.FromF16(api.WordHalves(dst))).
+			To(Equal([]float32{3, -1.5}))
+	})
+})
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// LinkDevices connects the given side of device a to the opposite side of
+// device b, e.g., the East edge of a to the West edge of b, to model
+// multi-chip configurations. Both devices must be built by a DeviceBuilder
+// and the linked edges must have the same length. The linked sides are no
+// longer exposed to the driver, so devices must be linked before they are
+// registered to the driver.
+func LinkDevices(
+	engine sim.Engine,
+	freq sim.Freq,
+	a cgra.Device,
+	side cgra.Side,
+	b cgra.Device,
+) {
+	devA, okA := a.(*device)
+	devB, okB := b.(*device)
+	if !okA || !okB {
+		panic("only devices built by the DeviceBuilder can be linked")
+	}
+
+	length := devA.edgeLength(side)
+	if length != devB.edgeLength(side.Opposite()) {
+		panic(fmt.Sprintf("cannot link the %s edge of %s to %s, "+
+			"the edges have different lengths",
+			side.Name(), devA.Name, devB.Name))
+	}
+
+	if devA.linkedSides[side] || devB.linkedSides[side.Opposite()] {
+		panic(fmt.Sprintf("the %s edge of %s is already linked",
+			side.Name(), devA.Name))
+	}
+
+	portRange := [2]int{0, length}
+	portsA := devA.sideTilePorts(side, portRange)
+	portsB := devB.sideTilePorts(side.Opposite(), portRange)
+
+	for i := 0; i < length; i++ {
+		if portsA[i] == nil || portsB[i] == nil {
+			continue
+		}
+
+		conn := sim.NewDirectConnection(
+			portsA[i].Name()+"."+portsB[i].Name(), engine, freq)
+		conn.PlugIn(portsA[i], 1)
+		conn.PlugIn(portsB[i], 1)
+
+		devA.edgeTile(side, i).SetRemotePort(side, portsB[i])
+		devB.edgeTile(side.Opposite(), i).
+			SetRemotePort(side.Opposite(), portsA[i])
+	}
+
+	devA.linkedSides[side] = true
+	devB.linkedSides[side.Opposite()] = true
+}
+
+func (d *device) edgeLength(side cgra.Side) int {
+	switch side {
+	case cgra.North, cgra.South:
+		return d.Width
+	case cgra.East, cgra.West:
+		return d.Height
+	default:
+		panic("invalid side")
+	}
+}
+
+func (d *device) edgeTile(side cgra.Side, index int) *tile {
+	switch side {
+	case cgra.North:
+		return d.Tiles[0][index]
+	case cgra.South:
+		return d.Tiles[d.Height-1][index]
+	case cgra.East:
+		return d.Tiles[index][d.Width-1]
+	case cgra.West:
+		return d.Tiles[index][0]
+	default:
+		panic("invalid side")
+	}
+}
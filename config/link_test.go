@@ -0,0 +1,63 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+)
+
+var _ = Describe("LinkDevices", func() {
+	It("should pass data across linked devices", func() {
+		engine := sim.NewSerialEngine()
+		driver := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		builder := config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(1).
+			WithHeight(2)
+		deviceA := builder.Build("DeviceA")
+		deviceB := builder.Build("DeviceB")
+
+		config.LinkDevices(engine, 1*sim.GHz, deviceA, cgra.East, deviceB)
+		driver.RegisterDevice(deviceA)
+		driver.RegisterDevice(deviceB)
+
+		Expect(deviceA.GetSidePorts(cgra.East, [2]int{0, 2})).
+			To(Equal([]sim.Port{nil, nil}))
+
+		src := []uint32{1, 2, 3, 4}
+		dst := make([]uint32, 4)
+		driver.FeedInToDevice(0, src, cgra.West, [2]int{0, 2}, 2)
+		driver.CollectFromDevice(1, dst, cgra.East, [2]int{0, 2}, 2)
+
+		program := "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START"
+		for y := 0; y < 2; y++ {
+			driver.MapProgramToDevice(0, program, [2]int{0, y})
+			driver.MapProgramToDevice(1, program, [2]int{0, y})
+		}
+
+		driver.Run()
+
+		Expect(dst).To(Equal(src))
+	})
+
+	It("should reject edges of different lengths", func() {
+		engine := sim.NewSerialEngine()
+		builder := config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(1)
+		deviceA := builder.WithHeight(2).Build("DeviceA")
+		deviceB := builder.WithHeight(3).Build("DeviceB")
+
+		Expect(func() {
+			config.LinkDevices(engine, 1*sim.GHz, deviceA, cgra.East, deviceB)
+		}).To(Panic())
+	})
+})
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+var _ = Describe("Messages between tiles", func() {
+	It("should charge the links for the extra words of wide messages", func() {
+		transfer := func(words int) sim.VTimeInSec {
+			tb := newTestbed(2, 1)
+			driver, _ := tb.build()
+
+			driver.MapProgram(fmt.Sprintf("I_ADD, $%d, 0, 7\n"+
+				"SEND_WIDE, NET_SEND_1, $0, %d\nDONE", words-1, words),
+				[2]int{0, 0})
+			driver.MapProgram(fmt.Sprintf("WAIT_WIDE, $0, NET_RECV_3, %d\n"+
+				"RETURN_VALUE, $%d\nDONE", words, words-1),
+				[2]int{1, 0})
+			driver.WaitAllDone()
+
+			Expect(driver.GetReturnValues()[[2]int{1, 0}]).To(Equal(uint32(7)))
+
+			return tb.engine.CurrentTime()
+		}
+
+		Expect(transfer(4)).To(BeNumerically(">", transfer(1)))
+	})
+
+	It("should copy a block to the memory of a neighbor", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		block := make([]uint32, 20)
+		for i := range block {
+			block[i] = uint32(i * i)
+		}
+
+		driver.WriteMemory([2]int{0, 0}, 0, block)
+		driver.MapProgram("MEMCPY_TILE, NET_SEND_1, 32, 0, 20\n"+
+			"SEND, NET_SEND_1, 1\nDONE", [2]int{0, 0})
+		driver.MapProgram("WAIT, $0, NET_RECV_3\nDONE", [2]int{1, 0})
+
+		Expect(driver.WaitAllDone).NotTo(Panic())
+		Expect(driver.ReadMemory([2]int{1, 0}, 32, 20)).To(Equal(block))
+		Expect(driver.ReadRegister([2]int{1, 0}, 0)).To(Equal(uint32(1)))
+	})
+
+	It("should halt the neighbor that a copy runs past the memory of", func() {
+		tb := newTestbed(2, 1)
+		tb.device = tb.device.WithMemorySize(16)
+		driver, _ := tb.build()
+
+		driver.WriteMemory([2]int{0, 0}, 0, []uint32{1, 2, 3, 4, 5, 6, 7, 8})
+		driver.MapProgram("MEMCPY_TILE, NET_SEND_1, 12, 0, 8\n"+
+			"SEND, NET_SEND_1, 1\nDONE", [2]int{0, 0})
+		driver.MapProgram("WAIT, $0, NET_RECV_3\nDONE", [2]int{1, 0})
+
+		Expect(driver.WaitAllDone).NotTo(Panic())
+
+		errs := driver.GetErrors()
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Tile).To(Equal([2]int{1, 0}))
+		Expect(errs[0].Error()).To(Equal("device 0 tile (1, 0) line 0: " +
+			"\"MEMCPY_TILE\": addresses 12 to 19 of a copy through West are " +
+			"out of the local memory of 16 words"))
+		Expect(driver.ReadMemory([2]int{1, 0}, 12, 4)).
+			To(Equal([]uint32{0, 0, 0, 0}))
+	})
+})
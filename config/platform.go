@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/sarchlab/akita/v3/sim"
 	"github.com/sarchlab/zeonica/cgra"
 )
@@ -8,7 +11,30 @@ import (
 type tileCore interface {
 	sim.Component
 	MapProgram(program []string)
+	SetKernelArg(index int, value uint32)
+	SetRandomSeed(seed uint64)
+	SetSchedule(steps []int, ii int)
+	ReadRegister(index int) uint32
+	WriteRegister(index int, value uint32)
+	WriteMemory(addr int, data []uint32)
+	ReadMemory(addr, length int) []uint32
+	CheckProgram(program []string) error
 	SetRemotePort(side cgra.Side, port sim.Port)
+	SetChannelRemotePort(side cgra.Side, channel int, port sim.Port)
+	GetActivityStats() cgra.ActivityStats
+	GetCounters() cgra.Counters
+	GetPortStats(side cgra.Side) cgra.PortStats
+	GetFreq() sim.Freq
+	IsDone() bool
+	GetRetVal() (uint32, bool)
+	WriteState(w io.Writer)
+	GetState() cgra.TileState
+	GetProgramSummary() cgra.ProgramSummary
+	SetHalted(halted bool)
+	SaveContext() cgra.TileContext
+	RestoreContext(ctx cgra.TileContext)
+	GetError() *cgra.ErrorRecord
+	Watch(w cgra.Watchpoint, hit func(cgra.WatchHit))
 }
 
 type tile struct {
@@ -31,22 +57,140 @@ func (t tile) GetPort(side cgra.Side) sim.Port {
 	}
 }
 
+// GetChannelPort returns the port of a channel on the side of the tile.
+func (t tile) GetChannelPort(side cgra.Side, channel int) sim.Port {
+	return t.Core.GetPortByName(cgra.PortName(side, channel))
+}
+
 // SetRemotePort sets the port that the core can send data to.
 func (t tile) SetRemotePort(side cgra.Side, port sim.Port) {
 	t.Core.SetRemotePort(side, port)
 }
 
+// SetChannelRemotePort sets the port that a channel of the core sends to.
+func (t tile) SetChannelRemotePort(side cgra.Side, channel int, port sim.Port) {
+	t.Core.SetChannelRemotePort(side, channel, port)
+}
+
 // MapProgram sets the program that the tile needs to run.
 func (t tile) MapProgram(program []string) {
 	t.Core.MapProgram(program)
 }
 
+// SetKernelArg sets a kernel argument of the tile.
+func (t tile) SetKernelArg(index int, value uint32) {
+	t.Core.SetKernelArg(index, value)
+}
+
+// SetRandomSeed seeds the random number generator of the tile.
+func (t tile) SetRandomSeed(seed uint64) {
+	t.Core.SetRandomSeed(seed)
+}
+
+// SetSchedule makes the tile follow a static schedule.
+func (t tile) SetSchedule(steps []int, ii int) {
+	t.Core.SetSchedule(steps, ii)
+}
+
+// ReadRegister returns the value of a register of the tile.
+func (t tile) ReadRegister(index int) uint32 {
+	return t.Core.ReadRegister(index)
+}
+
+// WriteRegister sets the value of a register of the tile.
+func (t tile) WriteRegister(index int, value uint32) {
+	t.Core.WriteRegister(index, value)
+}
+
+// WriteMemory writes the data to the local memory of the tile.
+func (t tile) WriteMemory(addr int, data []uint32) {
+	t.Core.WriteMemory(addr, data)
+}
+
+// ReadMemory reads the local memory of the tile.
+func (t tile) ReadMemory(addr, length int) []uint32 {
+	return t.Core.ReadMemory(addr, length)
+}
+
+// CheckProgram checks if the program can run on the tile.
+func (t tile) CheckProgram(program []string) error {
+	return t.Core.CheckProgram(program)
+}
+
+// GetActivityStats returns the breakdown of the cycles of the tile.
+func (t tile) GetActivityStats() cgra.ActivityStats {
+	return t.Core.GetActivityStats()
+}
+
+// GetCounters returns the counters of the tile.
+func (t tile) GetCounters() cgra.Counters {
+	return t.Core.GetCounters()
+}
+
+// GetPortStats returns the traffic through a port of the tile.
+func (t tile) GetPortStats(side cgra.Side) cgra.PortStats {
+	return t.Core.GetPortStats(side)
+}
+
+// GetFreq returns the frequency of the clock domain of the tile.
+func (t tile) GetFreq() sim.Freq {
+	return t.Core.GetFreq()
+}
+
+// IsDone returns true if the tile has finished its program.
+func (t tile) IsDone() bool {
+	return t.Core.IsDone()
+}
+
+// GetRetVal returns the return value recorded by the tile.
+func (t tile) GetRetVal() (uint32, bool) {
+	return t.Core.GetRetVal()
+}
+
+// WriteState writes the state of the tile to w.
+func (t tile) WriteState(w io.Writer) {
+	t.Core.WriteState(w)
+}
+
+// GetState returns the architectural state of the tile.
+func (t tile) GetState() cgra.TileState {
+	return t.Core.GetState()
+}
+
+// SetHalted stops the tile from running instructions, or lets it run again.
+func (t tile) SetHalted(halted bool) {
+	t.Core.SetHalted(halted)
+}
+
+// SaveContext saves the context of the tile and clears the tile.
+func (t tile) SaveContext() cgra.TileContext {
+	return t.Core.SaveContext()
+}
+
+// RestoreContext brings back a saved context of the tile.
+func (t tile) RestoreContext(ctx cgra.TileContext) {
+	t.Core.RestoreContext(ctx)
+}
+
+// GetError returns the error that has halted the tile, if any.
+func (t tile) GetError() *cgra.ErrorRecord {
+	return t.Core.GetError()
+}
+
+// Watch watches a location of the tile for a value.
+func (t tile) Watch(w cgra.Watchpoint, hit func(cgra.WatchHit)) {
+	t.Core.Watch(w, hit)
+}
+
 // A Device is a CGRA device that includes a large number of tiles. Tiles can be
 // retrieved using d.Tiles[y][x].
 type device struct {
 	Name          string
 	Width, Height int
 	Tiles         [][]*tile
+	Channels      int
+
+	linkedSides map[cgra.Side]bool
 }
 
 // GetSize returns the width and height of the device.
@@ -54,31 +198,118 @@ func (d *device) GetSize() (int, int) {
 	return d.Width, d.Height
 }
 
-// GetTile returns the tile at the given coordinates.
+// GetTile returns the tile at the given coordinates, or nil if the tile is
+// disabled.
 func (d *device) GetTile(x, y int) cgra.Tile {
+	if d.Tiles[y][x] == nil {
+		return nil
+	}
+
 	return d.Tiles[y][x]
 }
 
-// GetSidePorts returns the ports on the given side of the device.
+// GetTileCoords returns the coordinates of the tiles that are not disabled.
+func (d *device) GetTileCoords() [][2]int {
+	coords := [][2]int{}
+
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			if d.Tiles[y][x] != nil {
+				coords = append(coords, [2]int{x, y})
+			}
+		}
+	}
+
+	return coords
+}
+
+// DescribeTile returns the information of a tile for external tools.
+func (d *device) DescribeTile(x, y int) cgra.TileInfo {
+	t := d.Tiles[y][x]
+	if t == nil {
+		panic(fmt.Sprintf("tile (%d, %d) of %s is disabled", x, y, d.Name))
+	}
+
+	info := cgra.TileInfo{
+		X:         x,
+		Y:         y,
+		Name:      t.Core.Name(),
+		Neighbors: make(map[cgra.Side][2]int),
+		Program:   t.Core.GetProgramSummary(),
+	}
+
+	for side := cgra.North; side <= cgra.West; side++ {
+		for ch := 0; ch < d.Channels; ch++ {
+			info.Ports = append(info.Ports, t.GetChannelPort(side, ch).Name())
+		}
+
+		nx, ny := neighborOf(x, y, side)
+		if d.contains(nx, ny) && d.Tiles[ny][nx] != nil {
+			info.Neighbors[side] = [2]int{nx, ny}
+		}
+	}
+
+	return info
+}
+
+func neighborOf(x, y int, side cgra.Side) (int, int) {
+	switch side {
+	case cgra.North:
+		return x, y - 1
+	case cgra.East:
+		return x + 1, y
+	case cgra.South:
+		return x, y + 1
+	default:
+		return x - 1, y
+	}
+}
+
+func (d *device) contains(x, y int) bool {
+	return x >= 0 && x < d.Width && y >= 0 && y < d.Height
+}
+
+// GetChannels returns the number of channels on each side of a tile.
+func (d *device) GetChannels() int {
+	return d.Channels
+}
+
+// GetSidePorts returns the ports on the given side of the device, with the
+// channels of each tile next to each other. The ports of disabled tiles and
+// of sides linked to other devices are nil.
 func (d *device) GetSidePorts(side cgra.Side, portRange [2]int) []sim.Port {
+	if d.linkedSides[side] {
+		return make([]sim.Port, portRange[1]-portRange[0])
+	}
+
+	ports := make([]sim.Port, 0, portRange[1]-portRange[0])
+	for i := portRange[0]; i < portRange[1]; i++ {
+		ports = append(ports, d.edgeTile(side, i/d.Channels).
+			getChannelPort(side, i%d.Channels))
+	}
+
+	return ports
+}
+
+func (d *device) sideTilePorts(side cgra.Side, portRange [2]int) []sim.Port {
 	ports := make([]sim.Port, 0)
 
 	switch side {
 	case cgra.North:
 		for x := portRange[0]; x < portRange[1]; x++ {
-			ports = append(ports, d.Tiles[0][x].GetPort(side))
+			ports = append(ports, d.Tiles[0][x].getSidePort(side))
 		}
 	case cgra.West:
 		for y := portRange[0]; y < portRange[1]; y++ {
-			ports = append(ports, d.Tiles[y][0].GetPort(side))
+			ports = append(ports, d.Tiles[y][0].getSidePort(side))
 		}
 	case cgra.South:
 		for x := portRange[0]; x < portRange[1]; x++ {
-			ports = append(ports, d.Tiles[d.Height-1][x].GetPort(side))
+			ports = append(ports, d.Tiles[d.Height-1][x].getSidePort(side))
 		}
 	case cgra.East:
 		for y := portRange[0]; y < portRange[1]; y++ {
-			ports = append(ports, d.Tiles[y][d.Width-1].GetPort(side))
+			ports = append(ports, d.Tiles[y][d.Width-1].getSidePort(side))
 		}
 	default:
 		panic("invalid side")
@@ -86,3 +317,23 @@ func (d *device) GetSidePorts(side cgra.Side, portRange [2]int) []sim.Port {
 
 	return ports
 }
+
+// getChannelPort returns the port of a channel of the tile by the side, or
+// nil if the tile is disabled.
+func (t *tile) getChannelPort(side cgra.Side, channel int) sim.Port {
+	if t == nil {
+		return nil
+	}
+
+	return t.GetChannelPort(side, channel)
+}
+
+// getSidePort returns the port of the tile by the side, or nil if the tile
+// is disabled.
+func (t *tile) getSidePort(side cgra.Side) sim.Port {
+	if t == nil {
+		return nil
+	}
+
+	return t.GetPort(side)
+}
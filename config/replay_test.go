@@ -0,0 +1,113 @@
+package config_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+)
+
+var _ = Describe("Replay", func() {
+	build := func(latency int, b api.DriverBuilder) api.Driver {
+		engine := sim.NewSerialEngine()
+		driver := b.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(2).
+			WithHeight(1).
+			WithLinks(config.LinkConfig{Latency: latency}).
+			Build("Device"))
+
+		return driver
+	}
+
+	It("should reproduce a recorded run", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "run.json")
+		driver := build(1, api.DriverBuilder{}.WithRecording())
+
+		driver.SetProgramConstant("A", 3)
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\nI_MUL, $0, $0, A\n"+
+			"SCATTER, 4, $0\nSEND, NET_SEND_1, $0\nJMP, START", [2]int{0, 0})
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\n"+
+			"SEND, NET_SEND_1, $0\nJMP, START", [2]int{1, 0})
+		driver.FeedInAt([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1, 2, 3)
+		dst := make([]uint32, 3)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.Run()
+		Expect(driver.ReadMemory([2]int{0, 0}, 4, 1)).To(Equal([]uint32{9}))
+		Expect(dst).To(Equal([]uint32{3, 6, 9}))
+		Expect(driver.SaveRecording(path)).To(Succeed())
+
+		Expect(build(1, api.DriverBuilder{}).Replay(path)).To(Succeed())
+		Expect(build(3, api.DriverBuilder{}).Replay(path)).To(MatchError(
+			MatchRegexp(`^transfer 3 differs: the recording has 3 collected ` +
+				`at Driver.DeviceEast\[0\] in cycle \d+, the replay 3 ` +
+				`collected at Driver.DeviceEast\[0\] in cycle \d+$`)))
+		Expect(build(1, api.DriverBuilder{}.WithSeed(5)).Replay(path)).
+			To(MatchError("the recording has seed 0, the driver 5"))
+		Expect(build(1, api.DriverBuilder{}).SaveRecording(path)).
+			To(MatchError("the driver does not record"))
+	})
+
+	It("should reproduce the operations of streams", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "run.json")
+		driver := build(1, api.DriverBuilder{}.WithRecording())
+
+		program := "START:\nWAIT, $0, NET_RECV_3\nI_ADD, $0, $0, 1\n" +
+			"SEND, NET_SEND_1, $0\nJMP, START"
+		first := driver.CreateStream()
+		second := driver.CreateStream()
+		first.MapProgram(program, [2]int{0, 0})
+		first.MapProgram(program, [2]int{1, 0})
+		first.FeedIn([]uint32{1, 2}, cgra.West, [2]int{0, 1}, 1)
+		dst := make([]uint32, 2)
+		first.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		event := first.RecordEvent()
+		second.WaitEvent(event)
+		second.FeedIn([]uint32{5}, cgra.West, [2]int{0, 1}, 1)
+		second.Barrier()
+		last := make([]uint32, 1)
+		second.Collect(last, cgra.East, [2]int{0, 1}, 1)
+		driver.Run()
+		Expect(dst).To(Equal([]uint32{3, 4}))
+		Expect(last).To(Equal([]uint32{7}))
+		Expect(driver.SaveRecording(path)).To(Succeed())
+
+		Expect(build(1, api.DriverBuilder{}).Replay(path)).To(Succeed())
+		Expect(build(3, api.DriverBuilder{}).Replay(path)).To(MatchError(
+			HavePrefix("transfer 2 differs")))
+	})
+
+	It("should refuse to record a chain", func() {
+		driver := build(1, api.DriverBuilder{}.WithRecording())
+		task := api.CollectTask{
+			Data:      make([]uint32, 1),
+			Side:      cgra.East,
+			PortRange: [2]int{0, 1},
+			Stride:    1,
+		}
+
+		Expect(func() {
+			driver.Chain(task, func([]uint32) (*api.FeedInTask, bool) {
+				return nil, false
+			})
+		}).To(PanicWith("cannot record Chain, whose check runs on the host"))
+	})
+
+	It("should refuse to record a wait for a foreign event", func() {
+		driver := build(1, api.DriverBuilder{}.WithRecording())
+
+		Expect(func() {
+			driver.CreateStream().WaitEvent(&api.StreamEvent{})
+		}).To(PanicWith("cannot record a wait for an event that no stream " +
+			"of the driver has recorded"))
+	})
+})
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+)
+
+var _ = Describe("Statistics", func() {
+	It("should report per-tile activity in each clock domain", func() {
+		tb := newTestbed(2, 1)
+		tb.device = tb.device.WithClockDomains([]config.ClockDomain{
+			{X: 1, Y: 0, Width: 1, Height: 1, Freq: 500 * sim.MHz},
+		})
+		driver, _ := tb.build()
+
+		src := []uint32{1, 2, 3, 4}
+		dst := make([]uint32, 4)
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		program := "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START"
+		driver.MapProgram(program, [2]int{0, 0})
+		driver.MapProgram(program, [2]int{1, 0})
+
+		driver.Run()
+
+		Expect(dst).To(Equal(src))
+		stats := driver.GetActivityStats()
+		Expect(stats).To(HaveLen(2))
+		for _, s := range stats {
+			Expect(s.InstCycles).To(BeNumerically(">", 0))
+			Expect(s.PortCycles).To(BeNumerically(">", 0))
+			Expect(s.ActiveCycles).To(BeNumerically("<=", s.Cycles))
+			Expect(s.ActiveCycles + s.IdleCycles).To(Equal(s.Cycles))
+			Expect(s.InstCycles + s.Stalls.InputCycles + s.Stalls.OutputCycles +
+				s.Stalls.NoProgramCycles).To(Equal(s.Cycles))
+		}
+		Expect(stats[[2]int{1, 0}].Cycles).
+			To(BeNumerically("<", stats[[2]int{0, 0}].Cycles))
+
+		counters := driver.GetCounters()
+		Expect(counters).To(HaveLen(2))
+		for coord, c := range counters {
+			Expect(c.Cycles).To(Equal(stats[coord].Cycles))
+			Expect(c.Ops).To(Equal(stats[coord].InstCycles))
+			Expect(c.Tokens).To(Equal(uint64(4)))
+		}
+	})
+
+	It("should classify the reasons of the stalls", func() {
+		driver, _ := newTestbed(5, 1).build()
+
+		loop := func(body ...string) string {
+			return "LOOP:\n" + strings.Join(body, "\n") + "\nI_ADD, $0, $0, 1\n" +
+				"I_CMP_LT, $1, $0, 8\nJEQ, END, $1, 0\nJMP, LOOP\nEND:\n"
+		}
+
+		sends := make([]string, 4)
+		for i := range sends {
+			sends[i] = "SEND, NET_SEND_1, $0"
+		}
+
+		waits := []string{}
+		for i := 0; i < 16; i++ {
+			if i < 4 {
+				waits = append(waits, "WAIT, $2, NET_RECV_3")
+			} else {
+				waits = append(waits, "I_MUL, $2, $2, $2")
+			}
+		}
+
+		driver.MapProgram("WAIT, $0, NET_RECV_3\nDONE", [2]int{0, 0})
+		driver.MapProgram(loop(sends...)+"DONE", [2]int{1, 0})
+		driver.MapProgram(loop(waits...)+"BARRIER, 0\nDONE", [2]int{2, 0})
+		driver.MapProgram("BARRIER, 0\nDONE", [2]int{4, 0})
+		driver.Run()
+
+		stats := driver.GetActivityStats()
+		for _, s := range stats {
+			Expect(s.InstCycles + s.Stalls.InputCycles + s.Stalls.OutputCycles +
+				s.Stalls.BarrierCycles + s.Stalls.NoProgramCycles).
+				To(Equal(s.Cycles))
+		}
+
+		Expect(stats[[2]int{0, 0}].Stalls.InputCycles).
+			To(BeNumerically(">", 0))
+		Expect(stats[[2]int{1, 0}].Stalls.OutputCycles).
+			To(BeNumerically(">", 0))
+		Expect(stats[[2]int{3, 0}].Stalls.NoProgramCycles).
+			To(Equal(stats[[2]int{3, 0}].Cycles))
+		Expect(stats[[2]int{4, 0}].Stalls.BarrierCycles).
+			To(BeNumerically(">", 0))
+	})
+
+	It("should compare the achieved rates with the peaks", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		src := []uint32{1, 2, 3, 4}
+		dst := make([]uint32, 4)
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\nI_MUL, $0, $0, 3\n"+
+			"I_ADD, $0, $0, 1\nSEND, NET_SEND_1, $0\nJMP, START", [2]int{0, 0})
+		driver.MapProgram("START:\nFORWARD, NET_SEND_1, NET_RECV_3\nJMP, START",
+			[2]int{1, 0})
+		driver.Run()
+
+		r := driver.GetRoofline()
+		Expect(dst).To(Equal([]uint32{4, 7, 10, 13}))
+		Expect(r.ComputeOps).To(Equal(uint64(8)))
+		Expect(r.BoundaryWords).To(Equal(uint64(8)))
+		Expect(r.PeakOpsPerCycle).To(Equal(2.0))
+		Expect(r.PeakWordsPerCycle).To(Equal(6.0))
+		Expect(r.Intensity).To(Equal(1.0))
+		Expect(r.Bound).To(Equal("compute"))
+		Expect(r.OpsPerCycle).To(BeNumerically("~", 8/float64(r.Cycles)))
+		Expect(r.Efficiency).To(BeNumerically("~", r.OpsPerCycle/2))
+	})
+
+	It("should count the messages and stalls on each link", func() {
+		driver, _ := newTestbed(2, 1).build()
+
+		src := []uint32{1, 2, 3, 4}
+		driver.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\n"+
+			"SEND, NET_SEND_1, $0\nJMP, START", [2]int{0, 0})
+		driver.MapProgram("START:\nWAIT, $0, NET_RECV_3\n"+
+			strings.Repeat("I_ADD, $1, $1, 1\n", 8)+
+			"SEND, NET_SEND_1, $0\nJMP, START", [2]int{1, 0})
+		driver.Run()
+
+		stats := driver.GetLinkStats()
+		Expect(stats).To(HaveLen(2))
+
+		hot := stats[cgra.Link{X: 0, Y: 0, Side: cgra.East}]
+		Expect(hot.Messages).To(Equal(uint64(4)))
+		Expect(hot.PeakOccupancy).To(Equal(2))
+
+		// Without a Collect, the driver does not take the messages, so the
+		// sends stall once the buffers are full.
+		out := stats[cgra.Link{X: 1, Y: 0, Side: cgra.East}]
+		Expect(out.Messages).To(BeNumerically("<", 4))
+		Expect(out.Stalls).To(BeNumerically(">", 0))
+		Expect(out.PeakOccupancy).To(BeZero())
+	})
+})
@@ -0,0 +1,39 @@
+package config_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+var _ = Describe("Stream", func() {
+	It("should order operations across streams with events", func() {
+		driver, _ := newTestbed(1, 1).build()
+
+		src1 := []uint32{1, 2, 3, 4}
+		src2 := []uint32{5, 6, 7, 8}
+		dst1 := make([]uint32, 4)
+		dst2 := make([]uint32, 4)
+
+		s1 := driver.CreateStream()
+		s2 := driver.CreateStream()
+
+		s1.MapProgram(
+			"START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+			[2]int{0, 0})
+		s1.FeedIn(src1, cgra.West, [2]int{0, 1}, 1)
+		s1.Collect(dst1, cgra.East, [2]int{0, 1}, 1)
+		event := s1.RecordEvent()
+
+		s2.WaitEvent(event)
+		s2.FeedIn(src2, cgra.West, [2]int{0, 1}, 1)
+		s2.Collect(dst2, cgra.East, [2]int{0, 1}, 1)
+		s2.Barrier()
+
+		driver.Run()
+
+		Expect(event.Triggered()).To(BeTrue())
+		Expect(dst1).To(Equal(src1))
+		Expect(dst2).To(Equal(src2))
+	})
+})
@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// testbed holds the builders of a driver and a device that run at 1 GHz on
+// the same serial engine. A test adjusts the builders before it builds them.
+type testbed struct {
+	engine sim.Engine
+	driver api.DriverBuilder
+	device config.DeviceBuilder
+}
+
+// newTestbed returns the builders of a driver and a device of width x height
+// tiles, which discards its trace.
+func newTestbed(width, height int) *testbed {
+	engine := sim.NewSerialEngine()
+
+	return &testbed{
+		engine: engine,
+		driver: api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz),
+		device: config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(width).
+			WithHeight(height).
+			WithTracer(trace.Discard),
+	}
+}
+
+// build builds the driver and the device, and registers the device with the
+// driver.
+func (t *testbed) build() (api.Driver, cgra.Device) {
+	driver := t.driver.Build("Driver")
+	device := t.device.Build("Device")
+	driver.RegisterDevice(device)
+
+	return driver, device
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// LinkConfig is the timing of the link that a tile sends to a neighbor
+// through. The cycles are the cycles of the clock of the sending tile, which
+// is in its clock domain, if any.
+type LinkConfig struct {
+	// Latency is the number of cycles from when the last word of a message
+	// enters the link to when the message arrives. The default is 1.
+	Latency int
+
+	// Bandwidth is the number of words that enter the link per cycle. The
+	// default is 1.
+	Bandwidth int
+}
+
+func (l LinkConfig) latency() int {
+	if l.Latency <= 0 {
+		return 1
+	}
+
+	return l.Latency
+}
+
+func (l LinkConfig) bandwidth() int {
+	if l.Bandwidth <= 0 {
+		return 1
+	}
+
+	return l.Bandwidth
+}
+
+// timing returns the timing of the link for a tile that runs at the
+// frequency.
+func (l LinkConfig) timing(freq sim.Freq) cgra.Timing {
+	return cgra.Timing{
+		Freq:      freq,
+		Latency:   l.latency(),
+		Bandwidth: l.bandwidth(),
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+// A Barrier synchronizes cores. A core that executes "BARRIER, <id>" stalls
+// until all the cores whose programs contain a BARRIER instruction with the
+// same id reach the barrier. Cores join the barrier with the id when the
+// program is mapped, so each kernel can use its own set of ids.
+type Barrier struct {
+	groups map[string]*barrierGroup
+}
+
+type barrierGroup struct {
+	members    int
+	arrived    int
+	generation uint64
+	waiters    []func()
+}
+
+// NewBarrier creates a barrier that no core participates in.
+func NewBarrier() *Barrier {
+	return &Barrier{
+		groups: make(map[string]*barrierGroup),
+	}
+}
+
+func (b *Barrier) group(id string) *barrierGroup {
+	g, ok := b.groups[id]
+	if !ok {
+		g = &barrierGroup{}
+		b.groups[id] = g
+	}
+
+	return g
+}
+
+func (b *Barrier) join(id string) {
+	b.group(id).members++
+}
+
+func (b *Barrier) leave(id string) {
+	g := b.group(id)
+	g.members--
+
+	if g.arrived > 0 && g.arrived >= g.members {
+		g.release()
+	}
+}
+
+// arrive marks that a core reaches the barrier. The wake function is called
+// when the barrier is released. It returns the generation that the core
+// waits on.
+func (b *Barrier) arrive(id string, wake func()) uint64 {
+	g := b.group(id)
+	gen := g.generation
+
+	g.arrived++
+	if wake != nil {
+		g.waiters = append(g.waiters, wake)
+	}
+
+	if g.arrived >= g.members {
+		g.release()
+	}
+
+	return gen
+}
+
+// passed returns true if the barrier generation has been released.
+func (b *Barrier) passed(id string, gen uint64) bool {
+	return b.group(id).generation != gen
+}
+
+func (g *barrierGroup) release() {
+	waiters := g.waiters
+
+	g.generation++
+	g.arrived = 0
+	g.waiters = nil
+
+	for _, wake := range waiters {
+		wake()
+	}
+}
@@ -1,14 +1,32 @@
 package core
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/sarchlab/akita/v3/sim"
 	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/trace"
 )
 
+// numRegisters is the number of registers of a core.
+const numRegisters = 64
+
+// defaultTracer prints the events of the cores that are not given a tracer.
+var defaultTracer = trace.NewTextWriter(os.Stdout)
+
 // Builder can create new cores.
 type Builder struct {
-	engine sim.Engine
-	freq   sim.Freq
+	engine   sim.Engine
+	freq     sim.Freq
+	caps     cgra.PECaps
+	barrier  *Barrier
+	tracer   trace.Tracer
+	memSize  int
+	channels int
+	aliases  map[string]string
+	float    FloatMode
+	limits   IssueLimits
 }
 
 // WithEngine sets the engine.
@@ -23,32 +41,134 @@ func (b Builder) WithFreq(freq sim.Freq) Builder {
 	return b
 }
 
+// WithCapabilities sets the opcodes that the core can execute.
+func (b Builder) WithCapabilities(caps cgra.PECaps) Builder {
+	b.caps = caps
+	return b
+}
+
+// WithBarrier sets the barrier network that the core's BARRIER instructions
+// use.
+func (b Builder) WithBarrier(barrier *Barrier) Builder {
+	b.barrier = barrier
+	return b
+}
+
+// WithTracer sets the tracer that receives the events of the core. By
+// default, the events are printed to the standard output in the text format.
+func (b Builder) WithTracer(tracer trace.Tracer) Builder {
+	b.tracer = tracer
+	return b
+}
+
+// WithMemorySize sets the number of words in the local memory of the core.
+// The default is DefaultMemorySize.
+func (b Builder) WithMemorySize(words int) Builder {
+	b.memSize = words
+	return b
+}
+
+// WithChannels sets the number of channels on each side of the core, at
+// most MaxChannels. The default is 1.
+func (b Builder) WithChannels(n int) Builder {
+	b.channels = n
+	return b
+}
+
+// WithOpcodeAliases sets the aliases of the opcodes that the programs of the
+// core can use. The default is DefaultOpcodeAliases, and an empty map
+// disables the aliases.
+func (b Builder) WithOpcodeAliases(aliases map[string]string) Builder {
+	b.aliases = aliases
+	return b
+}
+
+// WithFloatMode sets the rounding and the denormal modes of the float32
+// operations of the core. The default is the IEEE 754 default.
+func (b Builder) WithFloatMode(m FloatMode) Builder {
+	b.float = m
+	return b
+}
+
+// WithIssueLimits sets the number of instructions that the core issues per
+// cycle and the ports that they can access. The default issues one
+// instruction per cycle.
+func (b Builder) WithIssueLimits(l IssueLimits) Builder {
+	b.limits = l
+	return b
+}
+
 // Build creates a core.
 func (b Builder) Build(name string) *Core {
-	c := &Core{}
+	c := &Core{caps: b.caps, tracer: b.tracer, aliases: b.aliases}
+	c.emu.float = b.float
+	c.limits = b.limits
+	if c.tracer == nil {
+		c.tracer = defaultTracer
+	}
+
+	if c.aliases == nil {
+		c.aliases = DefaultOpcodeAliases
+	}
+
+	memSize := b.memSize
+	if memSize == 0 {
+		memSize = DefaultMemorySize
+	}
+
+	channels := b.channels
+	if channels == 0 {
+		channels = 1
+	}
+
+	if err := b.limits.Validate(); err != nil {
+		panic(err.Error())
+	}
+
+	if channels < 0 || channels > MaxChannels {
+		panic(fmt.Sprintf("invalid number of channels %d", channels))
+	}
+
+	numPorts := 4 * channels
 
 	c.TickingComponent = sim.NewTickingComponent(name, b.engine, b.freq, c)
 	c.state = coreState{
-		Registers:        make([]uint32, 64),
-		RecvBufHead:      make([]uint32, 4),
-		RecvBufHeadReady: make([]bool, 4),
-		SendBufHead:      make([]uint32, 4),
-		SendBufHeadBusy:  make([]bool, 4),
+		Registers:        make([]uint32, numRegisters),
+		RecvBufHead:      make([]uint32, numPorts),
+		RecvBufHeadReady: make([]bool, numPorts),
+		SendBufHead:      make([]uint32, numPorts),
+		SendBufHeadBusy:  make([]bool, numPorts),
+
+		RecvBufHeadInvalid:  make([]bool, numPorts),
+		SendBufHeadInvalid:  make([]bool, numPorts),
+		RecvBufHeadExtra:    make([][]uint32, numPorts),
+		SendBufHeadExtra:    make([][]uint32, numPorts),
+		SendBufHeadCopy:     make([]bool, numPorts),
+		SendBufHeadCopyAddr: make([]uint32, numPorts),
+		Inbox:               make([][]token, numPorts),
+
+		Barrier: b.barrier,
+		Args:    make([]uint32, NumKernelArgs),
+		Memory:  make([]uint32, memSize),
 	}
-	c.ports = make(map[cgra.Side]*portPair)
+	c.state.BarrierWake = func() {
+		c.TickLater(c.Engine.CurrentTime())
+	}
+	c.ports = make([]*portPair, numPorts)
+	c.portStats = make([]cgra.PortStats, numPorts)
 
-	b.makePort(c, cgra.North)
-	b.makePort(c, cgra.West)
-	b.makePort(c, cgra.South)
-	b.makePort(c, cgra.East)
+	for i := range c.ports {
+		b.makePort(c, i)
+	}
 
 	return c
 }
 
-func (b *Builder) makePort(c *Core, side cgra.Side) {
-	localPort := sim.NewLimitNumMsgPort(c, 1, c.Name()+"."+side.Name())
-	c.ports[side] = &portPair{
+func (b *Builder) makePort(c *Core, index int) {
+	name := portName(index)
+	localPort := sim.NewLimitNumMsgPort(c, 1, c.Name()+"."+name)
+	c.ports[index] = &portPair{
 		local: localPort,
 	}
-	c.AddPort(side.Name(), localPort)
+	c.AddPort(name, localPort)
 }
@@ -0,0 +1,381 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// OpcodeCase is an entry of the opcode conformance table. The registers in
+// Regs are set, Code runs until it finishes or executes DONE, and the last
+// RETURN_VALUE must record Want.
+type OpcodeCase struct {
+	Name string
+	Regs map[int]uint32
+	Code []string
+	Want uint32
+}
+
+// Float32 bit patterns.
+const (
+	f32One    = 0x3f800000
+	f32Two    = 0x40000000
+	f32NegZ   = 0x80000000
+	f32NaN    = 0x7fc00000
+	f32NegOne = 0xbf800000
+)
+
+var opcodeCases = []OpcodeCase{
+	{"I_ADD", map[int]uint32{1: 2, 2: 3},
+		[]string{"I_ADD, $0, $1, $2", "RETURN_VALUE, $0"}, 5},
+	{"I_ADD overflow", map[int]uint32{1: 0xffffffff},
+		[]string{"I_ADD, $0, $1, 1", "RETURN_VALUE, $0"}, 0},
+	{"I_ADD immediates", nil,
+		[]string{"I_ADD, $0, 0x10, 4294967295", "RETURN_VALUE, $0"}, 15},
+	{"I_SUB", map[int]uint32{1: 7, 2: 3},
+		[]string{"I_SUB, $0, $1, $2", "RETURN_VALUE, $0"}, 4},
+	{"I_SUB underflow", nil,
+		[]string{"I_SUB, $0, 0, 1", "RETURN_VALUE, $0"}, 0xffffffff},
+	{"I_MUL", map[int]uint32{1: 6},
+		[]string{"I_MUL, $0, $1, 7", "RETURN_VALUE, $0"}, 42},
+	{"I_MUL overflow", map[int]uint32{1: 0x10000},
+		[]string{"I_MUL, $0, $1, $1", "RETURN_VALUE, $0"}, 0},
+	{"I_MUL negative", map[int]uint32{1: 0xfffffffe},
+		[]string{"I_MUL, $0, $1, 3", "RETURN_VALUE, $0"}, 0xfffffffa},
+	{"I_CMP_EQ", map[int]uint32{1: 5},
+		[]string{"I_CMP_EQ, $0, $1, 5", "RETURN_VALUE, $0"}, 1},
+	{"I_CMP_EQ wrapped immediate", map[int]uint32{1: 0xffffffff},
+		[]string{"I_CMP_EQ, $0, $1, 4294967295", "RETURN_VALUE, $0"}, 1},
+	{"I_CMP_NE", map[int]uint32{1: 5},
+		[]string{"I_CMP_NE, $0, $1, 5", "RETURN_VALUE, $0"}, 0},
+	{"I_CMP_LT signed", map[int]uint32{1: 0xffffffff},
+		[]string{"I_CMP_LT, $0, $1, 0", "RETURN_VALUE, $0"}, 1},
+	{"I_CMP_LE equal", map[int]uint32{1: 3},
+		[]string{"I_CMP_LE, $0, $1, 3", "RETURN_VALUE, $0"}, 1},
+	{"I_CMP_GT signed", map[int]uint32{1: 0x80000000},
+		[]string{"I_CMP_GT, $0, $1, 0", "RETURN_VALUE, $0"}, 0},
+	{"I_CMP_GE", map[int]uint32{1: 4},
+		[]string{"I_CMP_GE, $0, $1, 3", "RETURN_VALUE, $0"}, 1},
+	{"I_CMP clears the destination", map[int]uint32{0: 9, 1: 4},
+		[]string{"I_CMP_LT, $0, $1, 3", "RETURN_VALUE, $0"}, 0},
+	{"F32_CMP_EQ negative zero", map[int]uint32{1: f32NegZ},
+		[]string{"F32_CMP_EQ, $0, $1, 0", "RETURN_VALUE, $0"}, 1},
+	{"F32_CMP_EQ NaN", map[int]uint32{1: f32NaN},
+		[]string{fmt.Sprintf("F32_CMP_EQ, $0, $1, %d", f32NaN),
+			"RETURN_VALUE, $0"}, 0},
+	{"F32_CMP_NE NaN", map[int]uint32{1: f32NaN},
+		[]string{fmt.Sprintf("F32_CMP_NE, $0, $1, %d", f32NaN),
+			"RETURN_VALUE, $0"}, 1},
+	{"F32_CMP_LT", map[int]uint32{1: f32One},
+		[]string{fmt.Sprintf("F32_CMP_LT, $0, $1, %d", f32Two),
+			"RETURN_VALUE, $0"}, 1},
+	{"F32_CMP_LE NaN", map[int]uint32{1: f32NaN},
+		[]string{fmt.Sprintf("F32_CMP_LE, $0, $1, %d", f32Two),
+			"RETURN_VALUE, $0"}, 0},
+	{"F32_CMP_GT negative", map[int]uint32{1: f32NegOne},
+		[]string{"F32_CMP_GT, $0, $1, 0", "RETURN_VALUE, $0"}, 0},
+	{"F32_CMP_GE", map[int]uint32{1: f32Two},
+		[]string{fmt.Sprintf("F32_CMP_GE, $0, $1, %d", f32One),
+			"RETURN_VALUE, $0"}, 1},
+	{"PACK", map[int]uint32{1: 0x1234, 2: 0xabcd},
+		[]string{"PACK, $0, $1, $2", "RETURN_VALUE, $0"}, 0xabcd1234},
+	{"PACK drops the upper halves", map[int]uint32{1: 0xffff0001},
+		[]string{"PACK, $0, $1, $1", "RETURN_VALUE, $0"}, 0x00010001},
+	{"UNPACK low half", map[int]uint32{1: 0x7fff8000},
+		[]string{"UNPACK, $2, $3, $1", "RETURN_VALUE, $2"}, 0xffff8000},
+	{"UNPACK high half", map[int]uint32{1: 0x7fff8000},
+		[]string{"UNPACK, $2, $3, $1", "RETURN_VALUE, $3"}, 0x7fff},
+	{"FXADD_Q15 wraps", map[int]uint32{1: 0x7fffffff},
+		[]string{"FXADD_Q15, $0, $1, 1", "RETURN_VALUE, $0"}, 0x80000000},
+	{"FXADD_Q15_SAT", map[int]uint32{1: 0x7fffffff},
+		[]string{"FXADD_Q15_SAT, $0, $1, 1", "RETURN_VALUE, $0"}, 0x7fffffff},
+	{"FXADD_Q15_SAT negative", map[int]uint32{1: 0x80000000},
+		[]string{"FXADD_Q15_SAT, $0, $1, 0xffffffff", "RETURN_VALUE, $0"},
+		0x80000000},
+	{"FXMUL_Q15", map[int]uint32{1: 0x4000},
+		[]string{"FXMUL_Q15, $0, $1, $1", "RETURN_VALUE, $0"}, 0x2000},
+	{"FXMUL_Q15 negative", map[int]uint32{1: 0xffff8000},
+		[]string{"FXMUL_Q15, $0, $1, 0x4000", "RETURN_VALUE, $0"}, 0xffffc000},
+	{"FXMUL_Q15 truncates", map[int]uint32{1: 0x4000},
+		[]string{"FXMUL_Q15, $0, $1, 3", "RETURN_VALUE, $0"}, 1},
+	{"FXMUL_Q15_RND", map[int]uint32{1: 0x4000},
+		[]string{"FXMUL_Q15_RND, $0, $1, 3", "RETURN_VALUE, $0"}, 2},
+	{"FXMUL_Q0_SAT", map[int]uint32{1: 0x10000},
+		[]string{"FXMUL_Q0_SAT, $0, $1, $1", "RETURN_VALUE, $0"}, 0x7fffffff},
+	{"FXMUL_Q16_SAT_RND", map[int]uint32{1: 0x7fff0000},
+		[]string{"FXMUL_Q16_SAT_RND, $0, $1, 0x20000", "RETURN_VALUE, $0"},
+		0x7fffffff},
+	{"RAND", nil,
+		[]string{"RAND, $0", "RETURN_VALUE, $0"}, 0xe220a839},
+	{"RAND advances", nil,
+		[]string{"RAND, $0", "RAND, $1", "I_SUB, $2, $0, $1",
+			"RETURN_VALUE, $2"}, 0xe220a839 - 0x6e789e6a},
+	{"SCATTER and GATHER", map[int]uint32{1: 3},
+		[]string{"SCATTER, $1, 42", "GATHER, $0, 3", "RETURN_VALUE, $0"}, 42},
+	{"SCATTER_ADD", map[int]uint32{1: 5},
+		[]string{"SCATTER_ADD, 2, $1", "SCATTER_ADD, 2, $1", "GATHER, $0, 2",
+			"RETURN_VALUE, $0"}, 10},
+	{"GATHER zero memory", nil,
+		[]string{"GATHER, $0, 7", "RETURN_VALUE, $0"}, 0},
+	{"JMP", nil,
+		[]string{"JMP, END", "RETURN_VALUE, 1", "END:", "RETURN_VALUE, 2"}, 2},
+	{"JEQ taken on zero", nil,
+		[]string{"JEQ, END, $1, 0", "RETURN_VALUE, 1", "END:", "RETURN_VALUE, 2"},
+		2},
+	{"JEQ not taken", map[int]uint32{1: 1},
+		[]string{"JEQ, END, $1, 0", "RETURN_VALUE, 1", "END:"}, 1},
+	{"GEP", map[int]uint32{1: 0x1000},
+		[]string{"GEP, $0, $1, 8", "RETURN_VALUE, $0"}, 0x1008},
+	{"SEL true", map[int]uint32{1: 1, 2: 5, 3: 6},
+		[]string{"SEL, $0, $1, $2, $3", "RETURN_VALUE, $0"}, 5},
+	{"SEL false", map[int]uint32{2: 5, 3: 6},
+		[]string{"SEL, $0, $1, $2, $3", "RETURN_VALUE, $0"}, 6},
+	{"F32_ADD", map[int]uint32{1: f32One},
+		[]string{"F32_ADD, $0, $1, $1", "RETURN_VALUE, $0"}, f32Two},
+	{"F32_SUB", map[int]uint32{1: f32One, 2: f32Two},
+		[]string{"F32_SUB, $0, $1, $2", "RETURN_VALUE, $0"}, f32NegOne},
+	{"F32_MUL", map[int]uint32{1: f32Two},
+		[]string{fmt.Sprintf("F32_MUL, $0, $1, %d", f32NegOne),
+			"RETURN_VALUE, $0"}, 0xc0000000},
+	{"F16_ADD", map[int]uint32{1: 0x3c00},
+		[]string{"F16_ADD, $0, $1, $1", "RETURN_VALUE, $0"}, 0x4000},
+	{"F16_SUB", map[int]uint32{1: 0x3c00},
+		[]string{"F16_SUB, $0, $1, 0x4000", "RETURN_VALUE, $0"}, 0xbc00},
+	{"F16_MUL", map[int]uint32{1: 0x4000},
+		[]string{"F16_MUL, $0, $1, 0xbc00", "RETURN_VALUE, $0"}, 0xc000},
+	{"BF16_ADD", map[int]uint32{1: 0x3f80},
+		[]string{"BF16_ADD, $0, $1, 0x4000", "RETURN_VALUE, $0"}, 0x4040},
+	{"BF16_SUB", map[int]uint32{1: 0x3f80},
+		[]string{"BF16_SUB, $0, $1, 0x4000", "RETURN_VALUE, $0"}, 0xbf80},
+	{"BF16_MUL", map[int]uint32{1: 0x4000},
+		[]string{"BF16_MUL, $0, $1, $1", "RETURN_VALUE, $0"}, 0x4080},
+	{"F32_TO_F16", map[int]uint32{1: f32One},
+		[]string{"F32_TO_F16, $0, $1", "RETURN_VALUE, $0"}, 0x3c00},
+	{"F16_TO_F32", nil,
+		[]string{"F16_TO_F32, $0, 0xc000", "RETURN_VALUE, $0"}, 0xc0000000},
+	{"F32_TO_BF16 tie", map[int]uint32{1: 0x3f808000},
+		[]string{"F32_TO_BF16, $0, $1", "RETURN_VALUE, $0"}, 0x3f80},
+	{"BF16_TO_F32 high bits", map[int]uint32{1: 0xffff3f80},
+		[]string{"BF16_TO_F32, $0, $1", "RETURN_VALUE, $0"}, f32One},
+	{"F32_CLASS", map[int]uint32{1: f32NegOne},
+		[]string{"F32_CLASS, $0, $1", "RETURN_VALUE, $0"}, 1 << 1},
+	{"F32_CLASS NaN", nil,
+		[]string{fmt.Sprintf("F32_CLASS, $0, %d", f32NaN), "RETURN_VALUE, $0"},
+		1 << 9},
+	{"DATA_MOV", map[int]uint32{1: 7},
+		[]string{"DATA_MOV, $0, $1", "RETURN_VALUE, $0"}, 7},
+	{"GRANT_ALWAYS", nil,
+		[]string{"GRANT_ALWAYS, $0, 9", "RETURN_VALUE, $0"}, 9},
+	{"GRANT_ONCE grants only once", map[int]uint32{1: 5},
+		[]string{"LOOP:", "GRANT_ONCE, $0, $1", "I_ADD, $1, $1, 1",
+			"I_CMP_EQ, $2, $1, 7", "JEQ, LOOP, $2, 0", "RETURN_VALUE, $0"}, 5},
+	{"STATE_RESET grants once again", map[int]uint32{1: 5},
+		[]string{"LOOP:", "GRANT_ONCE, $0, $1", "I_ADD, $1, $1, 1",
+			"STATE_RESET", "I_CMP_EQ, $2, $1, 7", "JEQ, LOOP, $2, 0",
+			"RETURN_VALUE, $0"}, 6},
+	{"GRANT_PREDICATE granted", map[int]uint32{1: 9, 2: 1},
+		[]string{"GRANT_PREDICATE, $0, $1, $2", "RETURN_VALUE, $0"}, 9},
+	{"GRANT_PREDICATE not granted", map[int]uint32{0: 3, 1: 9},
+		[]string{"GRANT_PREDICATE, $0, $1, $2", "RETURN_VALUE, $0"}, 3},
+	{"RETURN_VALUE immediate", nil, []string{"RETURN_VALUE, 0xff"}, 255},
+	{"ENQUEUE and DEQUEUE", nil,
+		[]string{"SCATTER, 2, 2", "ENQUEUE, $1, 0, 7", "ENQUEUE, $1, 0, 8",
+			"DEQUEUE, $0, $2, 0", "RETURN_VALUE, $0"}, 7},
+	{"ENQUEUE full", nil,
+		[]string{"SCATTER, 2, 1", "ENQUEUE, $1, 0, 7", "ENQUEUE, $1, 0, 8",
+			"RETURN_VALUE, $1"}, 0},
+	{"DEQUEUE empty", map[int]uint32{0: 5},
+		[]string{"SCATTER, 2, 1", "DEQUEUE, $0, $1, 0", "I_ADD, $0, $0, $1",
+			"RETURN_VALUE, $0"}, 5},
+	{"DEQUEUE wraps around", nil,
+		[]string{"SCATTER, 12, 2", "ENQUEUE, $1, 10, 1", "ENQUEUE, $1, 10, 2",
+			"DEQUEUE, $0, $1, 10", "ENQUEUE, $1, 10, 3", "DEQUEUE, $0, $1, 10",
+			"DEQUEUE, $0, $1, 10", "RETURN_VALUE, $0"}, 3},
+	{"DONE", nil,
+		[]string{"RETURN_VALUE, 1", "DONE", "RETURN_VALUE, 2"}, 1},
+}
+
+// opcodesTestedElsewhere need the network, a barrier, or the counters of a
+// core, so they have dedicated tests instead of table entries.
+var opcodesTestedElsewhere = map[string]bool{
+	"WAIT": true, "SEND": true, "SEND_PRED": true, "FORWARD": true,
+	"WAIT_WIDE": true, "SEND_WIDE": true, "MEMCPY_TILE": true,
+	"BARRIER":    true,
+	"REDUCE_ADD": true, "REDUCE_MIN": true, "REDUCE_MAX": true,
+	"RDCOUNTER": true,
+}
+
+// OpcodeCases returns the cases of the opcode conformance table. An opcode
+// that is added to the ISA needs a case in the table, or UntestedOpcodes
+// reports it.
+func OpcodeCases() []OpcodeCase {
+	return append([]OpcodeCase(nil), opcodeCases...)
+}
+
+// UntestedOpcodes returns the opcodes of the ISA that no case runs, in
+// alphabetical order. The opcodes that need the network, a barrier, or the
+// counters of a core are not in the table, and are not reported.
+func UntestedOpcodes(cases []OpcodeCase) []string {
+	tested := make(map[string]bool)
+	for _, c := range cases {
+		for _, line := range c.Code {
+			tested[Opcode(line)] = true
+		}
+	}
+
+	opcodes := []string{}
+	for opcode := range instOperands {
+		opcodes = append(opcodes, opcode)
+	}
+
+	for _, cond := range cmpConditions {
+		opcodes = append(opcodes, "I_CMP_"+cond, "F32_CMP_"+cond)
+	}
+
+	opcodes = append(opcodes, "FXADD_Q15", "FXADD_Q15_SAT",
+		"FXMUL_Q15", "FXMUL_Q15_RND", "FXMUL_Q0_SAT")
+
+	untested := []string{}
+	for _, opcode := range opcodes {
+		if !tested[opcode] && !opcodesTestedElsewhere[opcode] {
+			untested = append(untested, opcode)
+		}
+	}
+
+	sort.Strings(untested)
+
+	return untested
+}
+
+// RunOpcodeCase runs a case on the instruction emulator and as the program
+// of a core, and returns an error if either does not record the value that
+// the case wants.
+func RunOpcodeCase(c OpcodeCase) error {
+	v, err := runOnEmulator(c)
+	if err != nil {
+		return fmt.Errorf("%s on the emulator: %w", c.Name, err)
+	}
+
+	if v != c.Want {
+		return fmt.Errorf("%s on the emulator: got %#x, want %#x",
+			c.Name, v, c.Want)
+	}
+
+	v, err = runOnCore(c)
+	if err != nil {
+		return fmt.Errorf("%s on a core: %w", c.Name, err)
+	}
+
+	if v != c.Want {
+		return fmt.Errorf("%s on a core: got %#x, want %#x", c.Name, v, c.Want)
+	}
+
+	return nil
+}
+
+// maxCaseSteps is the number of instructions after which a case on the
+// emulator is taken not to finish.
+const maxCaseSteps = 100
+
+// runOnEmulator runs the code of a case directly on the instruction
+// emulator.
+func runOnEmulator(c OpcodeCase) (v uint32, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	s := coreState{
+		Registers:        make([]uint32, numRegisters),
+		Code:             c.Code,
+		RecvBufHead:      make([]uint32, 4),
+		RecvBufHeadReady: make([]bool, 4),
+		SendBufHead:      make([]uint32, 4),
+		SendBufHeadBusy:  make([]bool, 4),
+		Memory:           make([]uint32, DefaultMemorySize),
+
+		RecvBufHeadInvalid: make([]bool, 4),
+		SendBufHeadInvalid: make([]bool, 4),
+		RecvBufHeadExtra:   make([][]uint32, 4),
+		SendBufHeadExtra:   make([][]uint32, 4),
+	}
+
+	for r, v := range c.Regs {
+		s.Registers[r] = v
+	}
+
+	for steps := 0; int(s.PC) < len(s.Code) && !s.Done; steps++ {
+		if steps >= maxCaseSteps {
+			return 0, fmt.Errorf("the code does not finish in %d steps",
+				maxCaseSteps)
+		}
+
+		if isLabel(s.Code[s.PC]) {
+			s.PC++
+			continue
+		}
+
+		instEmulator{}.RunInst(s.Code[s.PC], &s)
+	}
+
+	if !s.HasRetVal {
+		return 0, fmt.Errorf("the code does not return a value")
+	}
+
+	return s.RetVal, nil
+}
+
+// runOnCore runs the code of a case as the program of a core, after
+// instructions that set the registers.
+func runOnCore(c OpcodeCase) (v uint32, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	engine := sim.NewSerialEngine()
+	core := Builder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithTracer(trace.Discard).
+		Build("Core")
+
+	regs := make([]int, 0, len(c.Regs))
+	for r := range c.Regs {
+		regs = append(regs, r)
+	}
+
+	sort.Ints(regs)
+
+	program := []string{}
+	for _, r := range regs {
+		program = append(program, fmt.Sprintf("I_ADD, $%d, 0, %d", r, c.Regs[r]))
+	}
+
+	program = append(program, c.Code...)
+	program = append(program, "DONE")
+
+	core.MapProgram(program)
+	if err := engine.Run(); err != nil {
+		return 0, err
+	}
+
+	if fault := core.GetError(); fault != nil {
+		return 0, fault
+	}
+
+	if !core.IsDone() {
+		return 0, fmt.Errorf("the core is not done")
+	}
+
+	v, ok := core.GetRetVal()
+	if !ok {
+		return 0, fmt.Errorf("the core does not return a value")
+	}
+
+	return v, nil
+}
@@ -0,0 +1,41 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+)
+
+var _ = Describe("Opcode conformance", func() {
+	for _, c := range core.OpcodeCases() {
+		c := c
+
+		It(c.Name, func() {
+			Expect(core.RunOpcodeCase(c)).To(Succeed())
+		})
+	}
+
+	It("should have a case for every opcode", func() {
+		Expect(core.UntestedOpcodes(core.OpcodeCases())).To(BeEmpty())
+		Expect(core.UntestedOpcodes(nil)).To(ContainElements("I_ADD", "GEP"))
+	})
+
+	It("should report a case that records another value", func() {
+		err := core.RunOpcodeCase(core.OpcodeCase{
+			Name: "I_ADD wrong",
+			Regs: map[int]uint32{1: 2},
+			Code: []string{"I_ADD, $0, $1, 3", "RETURN_VALUE, $0"},
+			Want: 6,
+		})
+		Expect(err).To(MatchError("I_ADD wrong on the emulator: " +
+			"got 0x5, want 0x6"))
+
+		err = core.RunOpcodeCase(core.OpcodeCase{
+			Name: "GATHER out of memory",
+			Code: []string{"GATHER, $0, 4096", "RETURN_VALUE, $0"},
+		})
+		Expect(err).To(MatchError(
+			"GATHER out of memory on the emulator: " +
+				"address 4096 is out of the local memory of 1024 words"))
+	})
+})
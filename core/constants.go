@@ -0,0 +1,120 @@
+package core
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var constantName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseConstant converts the value of a named constant to the 32-bit word
+// that replaces the name in the programs. Integers, e.g., 20, -1, and 0x10,
+// are used as they are. Numbers with a decimal point or an exponent, e.g.,
+// 3.0, are converted to the bits of a float32.
+func ParseConstant(value string) (uint32, error) {
+	value = strings.TrimSpace(value)
+
+	i, err := strconv.ParseInt(value, 0, 64)
+	if err == nil {
+		if i < math.MinInt32 || i > math.MaxUint32 {
+			return 0, fmt.Errorf("constant %s does not fit in 32 bits", value)
+		}
+
+		return uint32(i), nil
+	}
+
+	if strings.ContainsAny(value, ".eE") {
+		f, err := strconv.ParseFloat(value, 32)
+		if err == nil {
+			return math.Float32bits(float32(f)), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid constant value %q", value)
+}
+
+// ResolveConstants replaces the operands that are names of constants with
+// the values of the constants.
+func ResolveConstants(program string, constants map[string]uint32) string {
+	if len(constants) == 0 {
+		return program
+	}
+
+	lines := strings.Split(program, "\n")
+	for i, line := range lines {
+		if Opcode(line) == "" {
+			continue
+		}
+
+		tokens := splitInst(line)
+		resolved := false
+
+		for j, t := range tokens[1:] {
+			if v, ok := constants[t]; ok {
+				tokens[j+1] = strconv.FormatUint(uint64(v), 10)
+				resolved = true
+			}
+		}
+
+		if resolved {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			lines[i] = indent + strings.Join(tokens, ", ")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ProgramFile is a program file whose programs still refer to the named
+// constants by name.
+type ProgramFile struct {
+	// Programs is keyed by the [x, y] coordinate of the PE.
+	Programs map[[2]int]string
+
+	// Constants are the values that the file declares.
+	Constants map[string]uint32
+}
+
+// LoadProgramFile loads a program file without resolving its constants. The
+// file is in the YAML format if it has a .yaml or .yml extension, or in the
+// ASM format otherwise. A driver that maps the programs must know the
+// values of the constants, e.g., through SetProgramConstant.
+func LoadProgramFile(path string) (*ProgramFile, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		return loadProgramFile(path, parseYAML)
+	}
+
+	return loadProgramFile(path, parseASM)
+}
+
+// LoadProgramFileFS is the same as LoadProgramFile, but reads the file from
+// a file system, e.g., the programs embedded in a binary.
+func LoadProgramFileFS(fsys fs.FS, path string) (*ProgramFile, error) {
+	src, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		return parseYAML(path, string(src))
+	}
+
+	return parseASM(path, string(src))
+}
+
+// Resolve returns the programs with the constants replaced by their values.
+func (f *ProgramFile) Resolve() map[[2]int]string {
+	programs := make(map[[2]int]string)
+	for coord, p := range f.Programs {
+		programs[coord] = ResolveConstants(p, f.Constants)
+	}
+
+	return programs
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// SetHalted stops the core from running instructions, or lets it run again.
+// A halted core still sends the tokens in its NET_SEND registers and keeps
+// receiving tokens, which wait in its inbox once its NET_RECV registers are
+// full.
+func (c *Core) SetHalted(halted bool) {
+	c.halted = halted
+
+	c.TickLater(c.Engine.CurrentTime())
+}
+
+// absorb moves the token that waits in the port into the inbox if the core
+// is halted, so that the port can take more tokens.
+func (c *Core) absorb(port int) bool {
+	if !c.halted {
+		return false
+	}
+
+	item := c.ports[port].local.Retrieve(c.Engine.CurrentTime())
+	if item == nil {
+		return false
+	}
+
+	c.state.Inbox[port] = append(c.state.Inbox[port],
+		tokenOf(item.(*cgra.MoveMsg)))
+
+	return true
+}
+
+// SaveContext returns the context of the core, with the program, the PC, the
+// registers, the memory, the network buffers, and the tokens that wait in
+// the ports. It then removes the program and empties the network buffers,
+// so that the core can run another program. The schedule of the core, if
+// any, is dropped.
+func (c *Core) SaveContext() cgra.TileContext {
+	for i, p := range c.ports {
+		if item := p.local.Retrieve(c.Engine.CurrentTime()); item != nil {
+			c.state.Inbox[i] = append(c.state.Inbox[i],
+				tokenOf(item.(*cgra.MoveMsg)))
+		}
+	}
+
+	ctx := c.state.clone()
+
+	s := &c.state
+	s.Code = nil
+	s.Ops = nil
+	s.PC = 0
+	s.AtBarrier = false
+	s.Done = false
+	s.HasRetVal = false
+	s.GrantedOnce = nil
+	s.CopySent = 0
+
+	for i := range c.ports {
+		s.RecvBufHeadReady[i] = false
+		s.RecvBufHeadInvalid[i] = false
+		s.RecvBufHeadExtra[i] = nil
+		s.SendBufHeadBusy[i] = false
+		s.SendBufHeadInvalid[i] = false
+		s.SendBufHeadExtra[i] = nil
+		s.SendBufHeadCopy[i] = false
+		s.Inbox[i] = nil
+	}
+
+	c.schedule = nil
+
+	return &ctx
+}
+
+// RestoreContext brings back a context that SaveContext of the core has
+// returned, and the core continues from where it was. The tokens that have
+// arrived since the context was saved and that the core has not consumed
+// are kept after the tokens of the context.
+func (c *Core) RestoreContext(ctx cgra.TileContext) {
+	saved, ok := ctx.(*coreState)
+	if !ok {
+		panic("the context is not saved by a core")
+	}
+
+	state := saved.clone()
+
+	for i := range c.ports {
+		if c.state.RecvBufHeadReady[i] {
+			state.Inbox[i] = append(state.Inbox[i], token{
+				Data:    c.state.RecvBufHead[i],
+				Invalid: c.state.RecvBufHeadInvalid[i],
+				Extra:   c.state.RecvBufHeadExtra[i],
+			})
+		}
+
+		state.Inbox[i] = append(state.Inbox[i], c.state.Inbox[i]...)
+	}
+
+	c.state = state
+
+	c.TickLater(c.Engine.CurrentTime())
+}
+
+// clone returns a copy of the state that shares nothing that the core
+// writes.
+func (s *coreState) clone() coreState {
+	cp := *s
+	cp.Registers = append([]uint32(nil), s.Registers...)
+	cp.RecvBufHead = append([]uint32(nil), s.RecvBufHead...)
+	cp.RecvBufHeadReady = append([]bool(nil), s.RecvBufHeadReady...)
+	cp.SendBufHead = append([]uint32(nil), s.SendBufHead...)
+	cp.SendBufHeadBusy = append([]bool(nil), s.SendBufHeadBusy...)
+	cp.RecvBufHeadInvalid = append([]bool(nil), s.RecvBufHeadInvalid...)
+	cp.SendBufHeadInvalid = append([]bool(nil), s.SendBufHeadInvalid...)
+	cp.RecvBufHeadExtra = append([][]uint32(nil), s.RecvBufHeadExtra...)
+	cp.SendBufHeadExtra = append([][]uint32(nil), s.SendBufHeadExtra...)
+	cp.SendBufHeadCopy = append([]bool(nil), s.SendBufHeadCopy...)
+	cp.SendBufHeadCopyAddr = append([]uint32(nil), s.SendBufHeadCopyAddr...)
+	cp.Args = append([]uint32(nil), s.Args...)
+	cp.Memory = append([]uint32(nil), s.Memory...)
+
+	cp.Inbox = make([][]token, len(s.Inbox))
+	for i, tokens := range s.Inbox {
+		cp.Inbox[i] = append([]token(nil), tokens...)
+	}
+
+	if s.GrantedOnce != nil {
+		cp.GrantedOnce = make(map[uint32]bool, len(s.GrantedOnce))
+		for pc, granted := range s.GrantedOnce {
+			cp.GrantedOnce[pc] = granted
+		}
+	}
+
+	return cp
+}
@@ -1,10 +1,16 @@
 package core
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/sarchlab/akita/v3/sim"
 	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/trace"
 )
 
 type portPair struct {
@@ -15,57 +21,623 @@ type portPair struct {
 type Core struct {
 	*sim.TickingComponent
 
-	ports map[cgra.Side]*portPair
+	ports   []*portPair
+	caps    cgra.PECaps
+	tracer  trace.Tracer
+	aliases map[string]string
 
-	state coreState
-	emu   instEmulator
+	state      coreState
+	emu        instEmulator
+	barrierIDs []string
+
+	instCycles    uint64
+	computeCycles uint64
+	portCycles    uint64
+	activeCycles  uint64
+	portStats     []cgra.PortStats
+
+	// stalls counts the cycles up to nextCycle. The cycles from nextCycle
+	// on, in which the core does not tick, stall for idleReason.
+	stalls     cgra.StallStats
+	nextCycle  uint64
+	idleReason stallReason
+
+	// schedule, if set, is the static schedule that the core enforces.
+	schedule *schedule
+
+	// limits are the resources of each cycle, and slots counts what the
+	// instructions of the current cycle have used.
+	limits IssueLimits
+	slots  issueSlots
+
+	// halted is set while the core must not run instructions, e.g., while
+	// its context is being saved.
+	halted bool
+
+	// fault is the error of the instruction that the core has failed to
+	// run. The core runs no more instructions until a program is mapped.
+	fault *cgra.ErrorRecord
+
+	// watches are the watchpoints that have not been hit.
+	watches []*watch
 }
 
 func (c *Core) SetRemotePort(side cgra.Side, remote sim.Port) {
 	c.ports[side].remote = remote
 }
 
-// MapProgram sets the program that the core needs to run.
+// SetChannelRemotePort sets the port that a channel on the side sends to.
+func (c *Core) SetChannelRemotePort(
+	side cgra.Side,
+	channel int,
+	remote sim.Port,
+) {
+	c.ports[portIndex(side, channel)].remote = remote
+}
+
+// portIndex returns the index of the NET_RECV and NET_SEND registers of a
+// channel on the side.
+func portIndex(side cgra.Side, channel int) int {
+	return 4*channel + int(side)
+}
+
+// portName returns the name of the port with the index.
+func portName(index int) string {
+	return cgra.PortName(cgra.Side(index%4), index/4)
+}
+
+// MapProgram sets the program that the core needs to run and starts running
+// it in the next cycle. The opcode aliases are replaced first. It panics
+// with all the violations if the program does not pass CheckProgram.
 func (c *Core) MapProgram(program []string) {
+	program = CanonicalizeOpcodes(program, c.aliases)
+
+	err := c.CheckProgram(program)
+	if err != nil {
+		panic(fmt.Sprintf("invalid program for %s:\n%s", c.Name(), err))
+	}
+
+	c.joinBarriers(program)
+
 	c.state.Code = program
+	c.state.Ops = decodeProgram(program)
 	c.state.PC = 0
+	c.state.AtBarrier = false
+	c.state.CopySent = 0
+	c.state.Done = false
+	c.state.HasRetVal = false
+	c.state.GrantedOnce = nil
+	c.fault = nil
+
+	c.TickLater(c.Engine.CurrentTime())
+}
+
+// CheckProgram checks the syntax of the program, the opcodes that the core
+// supports, the register indices, and the sides that the core is connected
+// to. If the program is invalid, the error is a ProgramErrors that lists all
+// the violations, where File is the name of the core and Line is the 1-based
+// index of the line in the program. The opcode aliases are replaced before
+// the checks.
+func (c *Core) CheckProgram(program []string) error {
+	program = CanonicalizeOpcodes(program, c.aliases)
+
+	lines := make([]programLine, 0, len(program))
+	for i, text := range program {
+		lines = append(lines, programLine{text: text, line: i + 1})
+	}
+
+	errs := checkProgramLines(c.Name(), lines, nil)
+	for _, l := range lines {
+		errs = append(errs, c.checkResources(l)...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+
+	return errs
+}
+
+func (c *Core) checkResources(l programLine) ProgramErrors {
+	if Opcode(l.text) == "" {
+		return nil
+	}
+
+	errs := ProgramErrors{}
+	tokens := tokenizeInst(l.text)
+
+	if !c.caps.Supports(tokens[0].text) {
+		errs = append(errs, &ProgramError{File: c.Name(), Line: l.line,
+			Column: tokens[0].column, Token: tokens[0].text,
+			Msg: "opcode not supported by the PE"})
+	}
+
+	for _, t := range tokens[1:] {
+		msg := c.checkResource(t.text)
+		if msg != "" {
+			errs = append(errs, &ProgramError{File: c.Name(), Line: l.line,
+				Column: t.column, Token: t.text, Msg: msg})
+		}
+	}
+
+	return errs
+}
+
+func (c *Core) checkResource(operand string) string {
+	for _, prefix := range []string{"NET_SEND_", "NET_RECV_"} {
+		if !strings.HasPrefix(operand, prefix) {
+			continue
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(operand, prefix))
+		if err != nil || index < 0 {
+			return ""
+		}
+
+		if index >= len(c.ports) {
+			return fmt.Sprintf("the PE has no channel %d on the %s side",
+				index/4, cgra.Side(index%4).Name())
+		}
+
+		if c.ports[index].remote == nil {
+			return fmt.Sprintf("the %s side is not connected",
+				portName(index))
+		}
+
+		return ""
+	}
+
+	if strings.HasPrefix(operand, "$") {
+		reg, err := strconv.Atoi(strings.TrimPrefix(operand, "$"))
+		if err == nil && reg >= len(c.state.Registers) {
+			return fmt.Sprintf("register out of range, the PE has %d registers",
+				len(c.state.Registers))
+		}
+	}
+
+	return ""
+}
+
+func (c *Core) joinBarriers(program []string) {
+	if c.state.Barrier == nil {
+		return
+	}
+
+	for _, id := range c.barrierIDs {
+		c.state.Barrier.leave(id)
+	}
+
+	c.barrierIDs = nil
+	joined := make(map[string]bool)
+
+	for _, line := range program {
+		if Opcode(line) != "BARRIER" {
+			continue
+		}
+
+		id := splitInst(strings.TrimSpace(line))[1]
+		if joined[id] {
+			continue
+		}
+
+		joined[id] = true
+		c.barrierIDs = append(c.barrierIDs, id)
+		c.state.Barrier.join(id)
+	}
+}
+
+// SetKernelArg sets the value that the ARGn operands of the program read,
+// where n is the index.
+func (c *Core) SetKernelArg(index int, value uint32) {
+	if index < 0 || index >= NumKernelArgs {
+		panic(fmt.Sprintf("kernel argument index %d out of range, "+
+			"the core has %d arguments", index, NumKernelArgs))
+	}
+
+	c.state.Args[index] = value
+}
+
+// SetRandomSeed restarts the generator that RAND reads from the seed.
+func (c *Core) SetRandomSeed(seed uint64) {
+	c.state.RandState = seed
+}
+
+// IsDone returns true if the core has no program or if the program has
+// executed a DONE instruction.
+func (c *Core) IsDone() bool {
+	return len(c.state.Code) == 0 || c.state.Done
 }
 
-// Tick runs the program for one cycle.
+// GetRetVal returns the value recorded by the last RETURN_VALUE instruction.
+// The second return value is false if no value has been recorded.
+func (c *Core) GetRetVal() (uint32, bool) {
+	return c.state.RetVal, c.state.HasRetVal
+}
+
+// WriteState writes the PC, the registers, the network buffers, and the
+// progress of the core to w.
+func (c *Core) WriteState(w io.Writer) {
+	s := &c.state
+	fields := []interface{}{
+		s.PC, s.Registers,
+		s.RecvBufHead, s.RecvBufHeadReady, s.SendBufHead, s.SendBufHeadBusy,
+		s.RecvBufHeadInvalid, s.SendBufHeadInvalid,
+		s.SendBufHeadCopy, s.SendBufHeadCopyAddr, s.CopySent,
+		s.AtBarrier, s.Done, s.RetVal, s.HasRetVal, s.Args, s.RandState,
+		s.Memory,
+	}
+
+	for _, bufs := range [][][]uint32{s.RecvBufHeadExtra, s.SendBufHeadExtra} {
+		for _, extra := range bufs {
+			fields = append(fields, uint32(len(extra)), extra)
+		}
+	}
+
+	for _, tokens := range s.Inbox {
+		fields = append(fields, uint32(len(tokens)))
+		for _, t := range tokens {
+			fields = append(fields, t.Data, t.Invalid, uint32(len(t.Extra)),
+				t.Extra, t.Copy, t.CopyAddr)
+		}
+	}
+
+	for _, f := range fields {
+		err := binary.Write(w, binary.LittleEndian, f)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ReadRegister returns the value of a register.
+func (c *Core) ReadRegister(index int) uint32 {
+	c.checkRegister(index)
+
+	return c.state.Registers[index]
+}
+
+// WriteRegister sets the value of a register, e.g., while the simulation is
+// paused.
+func (c *Core) WriteRegister(index int, value uint32) {
+	c.checkRegister(index)
+	c.state.Registers[index] = value
+}
+
+func (c *Core) checkRegister(index int) {
+	if index < 0 || index >= len(c.state.Registers) {
+		panic(fmt.Sprintf("%s: register $%d is out of range, the PE has %d "+
+			"registers", c.Name(), index, len(c.state.Registers)))
+	}
+}
+
+// WriteMemory writes the data to the local memory, starting from the
+// address.
+func (c *Core) WriteMemory(addr int, data []uint32) {
+	c.checkMemoryRange(addr, len(data))
+	copy(c.state.Memory[addr:], data)
+}
+
+// ReadMemory returns a copy of length words of the local memory, starting
+// from the address.
+func (c *Core) ReadMemory(addr, length int) []uint32 {
+	c.checkMemoryRange(addr, length)
+
+	return append([]uint32(nil), c.state.Memory[addr:addr+length]...)
+}
+
+func (c *Core) checkMemoryRange(addr, length int) {
+	if addr < 0 || length < 0 || addr+length > len(c.state.Memory) {
+		panic(fmt.Sprintf("%s: words [%d, %d) are out of the local memory "+
+			"of %d words", c.Name(), addr, addr+length, len(c.state.Memory)))
+	}
+}
+
+// GetState returns a copy of the PC, the registers, the network buffers,
+// and the progress of the core.
+func (c *Core) GetState() cgra.TileState {
+	s := &c.state
+
+	return cgra.TileState{
+		PC:        s.PC,
+		Registers: append([]uint32(nil), s.Registers...),
+		RecvBuf:   append([]uint32(nil), s.RecvBufHead...),
+		RecvReady: append([]bool(nil), s.RecvBufHeadReady...),
+		SendBuf:   append([]uint32(nil), s.SendBufHead...),
+		SendBusy:  append([]bool(nil), s.SendBufHeadBusy...),
+		Done:      s.Done,
+		RetVal:    s.RetVal,
+		HasRetVal: s.HasRetVal,
+	}
+}
+
+// GetProgramSummary describes the program of the core and the progress.
+func (c *Core) GetProgramSummary() cgra.ProgramSummary {
+	s := &c.state
+	summary := cgra.ProgramSummary{
+		Opcodes: make(map[string]int),
+		PC:      s.PC,
+		Done:    s.Done,
+	}
+
+	for _, op := range s.Ops {
+		if !op.label {
+			summary.Instructions++
+			summary.Opcodes[op.opcode]++
+		}
+	}
+
+	if line := c.nextLine(); !s.Done && line >= 0 {
+		summary.Current = strings.TrimSpace(s.Code[line])
+	}
+
+	return summary
+}
+
+// GetFreq returns the frequency that the core runs at.
+func (c *Core) GetFreq() sim.Freq {
+	return c.Freq
+}
+
+// GetActivityStats returns the breakdown of the cycles that have passed.
+func (c *Core) GetActivityStats() cgra.ActivityStats {
+	stats := cgra.ActivityStats{
+		Cycles:        c.Freq.Cycle(c.Engine.CurrentTime()),
+		InstCycles:    c.instCycles,
+		ComputeCycles: c.computeCycles,
+		PortCycles:    c.portCycles,
+		ActiveCycles:  c.activeCycles,
+		Stalls:        c.stalls,
+	}
+
+	// The cycle in which the core has ticked last has passed for the core.
+	if stats.Cycles < c.nextCycle {
+		stats.Cycles = c.nextCycle
+	}
+
+	if stats.Cycles > c.nextCycle {
+		addStall(&stats.Stalls, c.idleReason, stats.Cycles-c.nextCycle)
+	}
+
+	if stats.Cycles > stats.ActiveCycles {
+		stats.IdleCycles = stats.Cycles - stats.ActiveCycles
+	}
+
+	return stats
+}
+
+// GetCounters returns the counters of the core. Ops counts the instructions
+// since the core was built, across the programs that have been mapped.
+func (c *Core) GetCounters() cgra.Counters {
+	counters := cgra.Counters{
+		Cycles: c.Freq.Cycle(c.Engine.CurrentTime()),
+		Ops:    c.instCycles,
+	}
+
+	if counters.Cycles < c.nextCycle {
+		counters.Cycles = c.nextCycle
+	}
+
+	for _, s := range c.portStats {
+		counters.Tokens += s.Received
+	}
+
+	return counters
+}
+
+// updateCounters copies the counters of the core to the state that
+// RDCOUNTER reads.
+func (c *Core) updateCounters() {
+	counters := c.GetCounters()
+	c.state.Counters = [numCounters]uint64{
+		counterCycles: counters.Cycles,
+		counterOps:    counters.Ops,
+		counterTokens: counters.Tokens,
+	}
+}
+
+// GetPortStats returns the traffic through the port of channel 0 on the
+// given side.
+func (c *Core) GetPortStats(side cgra.Side) cgra.PortStats {
+	return c.portStats[side]
+}
+
+// Tick runs the program for one cycle. The core stops ticking as soon as the
+// next cycle cannot make progress, e.g., when it waits for data or has no
+// program, and a message delivery or a barrier release wakes it up.
 func (c *Core) Tick(now sim.VTimeInSec) (madeProgress bool) {
-	madeProgress = c.doRecv() || madeProgress
-	madeProgress = c.runProgram() || madeProgress
-	madeProgress = c.doSend() || madeProgress
+	cycle := c.Freq.Cycle(now)
+	if cycle > c.nextCycle {
+		addStall(&c.stalls, c.idleReason, cycle-c.nextCycle)
+	}
 
-	return madeProgress
+	recvProgress := c.doRecv()
+	instProgress := c.issue()
+
+	if !instProgress {
+		addStall(&c.stalls, c.stallReason(), 1)
+	}
+
+	sendProgress := c.doSend()
+
+	c.countActivity(instProgress, recvProgress || sendProgress)
+	c.nextCycle = cycle + 1
+	c.idleReason = c.stallReason()
+
+	madeProgress = recvProgress || instProgress || sendProgress
+
+	// A core with a schedule ticks in every cycle until it finishes, so
+	// that each instruction is checked in its cycle.
+	if c.schedule != nil && c.nextOp() != nil && !c.state.Done {
+		return true
+	}
+
+	return madeProgress && !c.isIdle()
+}
+
+// isIdle returns true if the next tick cannot make progress unless a message
+// arrives or a barrier is released.
+func (c *Core) isIdle() bool {
+	for i, p := range c.ports {
+		if c.state.SendBufHeadBusy[i] {
+			return false
+		}
+
+		if !c.state.RecvBufHeadReady[i] &&
+			(p.local.Peek() != nil || len(c.state.Inbox[i]) > 0) {
+			return false
+		}
+	}
+
+	s := &c.state
+	if s.Done || s.AtBarrier {
+		return true
+	}
+
+	// A program that ends with labels finishes in the next tick.
+	op := c.nextOp()
+	if op == nil {
+		return int(s.PC) >= len(s.Ops)
+	}
+
+	return s.waitsForRecv(op)
+}
+
+// NotifyRecv counts the message that has arrived at the port and wakes up
+// the core.
+func (c *Core) NotifyRecv(now sim.VTimeInSec, port sim.Port) {
+	for i, p := range c.ports {
+		if p.local == port {
+			c.portStats[i].Received++
+		}
+	}
+
+	c.TickingComponent.NotifyRecv(now, port)
+}
+
+// NotifyPortFree wakes up the core and its neighbors. The neighbors send
+// through the ports of the core, so a send that stalls on a busy port can
+// only retry once the port of this core is free.
+func (c *Core) NotifyPortFree(now sim.VTimeInSec, port sim.Port) {
+	c.TickLater(now)
+
+	for _, p := range c.ports {
+		if p.remote == nil {
+			continue
+		}
+
+		if t, ok := p.remote.Component().(interface {
+			TickLater(sim.VTimeInSec)
+		}); ok {
+			t.TickLater(now)
+		}
+	}
+}
+
+// nextOp returns the instruction that the core runs next, skipping the
+// labels, or nil if the program has finished.
+func (c *Core) nextOp() *operation {
+	line := c.nextLine()
+	if line < 0 {
+		return nil
+	}
+
+	return &c.state.Ops[line]
+}
+
+// nextLine returns the line of the instruction that the core runs next, or
+// -1 if the program has finished.
+func (c *Core) nextLine() int {
+	s := &c.state
+
+	pc := int(s.PC)
+	for pc < len(s.Ops) && s.Ops[pc].label {
+		pc++
+	}
+
+	if pc >= len(s.Ops) {
+		return -1
+	}
+
+	return pc
+}
+
+// waitsForRecv returns true if the instruction reads a NET_RECV register
+// that is empty.
+func (s *coreState) waitsForRecv(op *operation) bool {
+	for i := range s.RecvBufHeadReady {
+		if !s.RecvBufHeadReady[i] && readsRecv(op, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Core) countActivity(inst, port bool) {
+	if inst {
+		c.instCycles++
+	}
+
+	if port {
+		c.portCycles++
+	}
+
+	if inst || port {
+		c.activeCycles++
+	}
 }
 
 func (c *Core) doSend() bool {
 	madeProgress := false
 
-	for i := 0; i < 4; i++ {
+	for i, p := range c.ports {
 		if !c.state.SendBufHeadBusy[i] {
 			continue
 		}
 
-		msg := cgra.MoveMsgBuilder{}.
-			WithDst(c.ports[cgra.Side(i)].remote).
-			WithSrc(c.ports[cgra.Side(i)].local).
+		if p.remote == nil {
+			panic(fmt.Sprintf("%s cannot send to the %s side, "+
+				"which is not connected", c.Name(), portName(i)))
+		}
+
+		builder := cgra.MoveMsgBuilder{}.
+			WithDst(p.remote).
+			WithSrc(p.local).
 			WithData(c.state.SendBufHead[i]).
-			WithSendTime(c.Engine.CurrentTime()).
-			Build()
+			WithExtra(c.state.SendBufHeadExtra[i]).
+			WithInvalid(c.state.SendBufHeadInvalid[i]).
+			WithSendTime(c.Engine.CurrentTime())
+		if c.state.SendBufHeadCopy[i] {
+			builder = builder.WithCopyAddr(c.state.SendBufHeadCopyAddr[i])
+		}
 
-		err := c.ports[cgra.Side(i)].remote.Send(msg)
+		msg := builder.Build()
+
+		err := p.remote.Send(msg)
 		if err != nil {
+			c.portStats[i].Stalls++
 			continue
 		}
 
-		fmt.Printf("%10f, %s, Send %d %s->%s\n",
-			c.Engine.CurrentTime()*1e9,
-			c.Name(),
-			msg.Data, msg.Src.Name(), msg.Dst.Name())
+		c.portStats[i].Sent++
+
+		c.tracer.Trace(trace.Event{
+			Time:      float64(c.Engine.CurrentTime()) * 1e9,
+			Component: c.Name(),
+			Kind:      trace.KindSend,
+			Data:      msg.Data,
+			Src:       msg.Src.Name(),
+			Dst:       msg.Dst.Name(),
+			Invalid:   msg.Invalid,
+		})
 
 		c.state.SendBufHeadBusy[i] = false
+		c.state.SendBufHeadCopy[i] = false
+		madeProgress = true
 	}
 
 	return madeProgress
@@ -74,24 +646,39 @@ func (c *Core) doSend() bool {
 func (c *Core) doRecv() bool {
 	madeProgress := false
 
-	for i := 0; i < 4; i++ {
+	for i, p := range c.ports {
+		c.sampleOccupancy(i)
+
 		if c.state.RecvBufHeadReady[i] {
+			madeProgress = c.absorb(i) || madeProgress
 			continue
 		}
 
-		item := c.ports[cgra.Side(i)].local.Retrieve(c.Engine.CurrentTime())
-		if item == nil {
+		if len(c.state.Inbox[i]) > 0 {
+			c.receive(i, c.state.Inbox[i][0])
+			c.state.Inbox[i] = c.state.Inbox[i][1:]
+			madeProgress = true
+
 			continue
 		}
 
-		msg := item.(*cgra.MoveMsg)
-		c.state.RecvBufHeadReady[i] = true
-		c.state.RecvBufHead[i] = msg.Data
+		msg := c.arrived(i)
+		if msg == nil {
+			continue
+		}
 
-		fmt.Printf("%10f, %s, Recv %d %s->%s\n",
-			c.Engine.CurrentTime()*1e9,
-			c.Name(),
-			msg.Data, msg.Src.Name(), msg.Dst.Name())
+		p.local.Retrieve(c.Engine.CurrentTime())
+		c.receive(i, tokenOf(msg))
+
+		c.tracer.Trace(trace.Event{
+			Time:      float64(c.Engine.CurrentTime()) * 1e9,
+			Component: c.Name(),
+			Kind:      trace.KindRecv,
+			Data:      msg.Data,
+			Src:       msg.Src.Name(),
+			Dst:       msg.Dst.Name(),
+			Invalid:   msg.Invalid,
+		})
 
 		madeProgress = true
 	}
@@ -99,26 +686,259 @@ func (c *Core) doRecv() bool {
 	return madeProgress
 }
 
+// arrived returns the message that waits at the port, or nil if there is none
+// or the message arrives in this cycle. A core sees a message only in the
+// cycle after its arrival, so that the order in which the engine runs the
+// delivery and the tick of the core in the same cycle does not matter.
+func (c *Core) arrived(side int) *cgra.MoveMsg {
+	item := c.ports[side].local.Peek()
+	if item == nil {
+		return nil
+	}
+
+	msg := item.(*cgra.MoveMsg)
+	now := c.Engine.CurrentTime()
+	if c.Freq.Cycle(c.Freq.NextTick(msg.RecvTime)) > c.Freq.Cycle(now) {
+		return nil
+	}
+
+	return msg
+}
+
+// receive puts the token into the NET_RECV register of the port, or writes
+// the words of a block copy to the local memory.
+func (c *Core) receive(port int, t token) {
+	if !t.Copy {
+		c.state.setRecvHead(port, t)
+		return
+	}
+
+	words := append([]uint32{t.Data}, t.Extra...)
+	end := uint64(t.CopyAddr) + uint64(len(words))
+
+	if end > uint64(len(c.state.Memory)) {
+		c.recordCopyFault(port, uint64(t.CopyAddr), end-1)
+		return
+	}
+
+	copy(c.state.Memory[t.CopyAddr:], words)
+}
+
+// recordCopyFault records that a block copy from a neighbor runs past the
+// local memory, which halts the core like the error of an instruction. The
+// words of the copy are dropped.
+func (c *Core) recordCopyFault(port int, first, last uint64) {
+	if c.fault != nil {
+		return
+	}
+
+	c.fault = &cgra.ErrorRecord{
+		Time:   c.Engine.CurrentTime(),
+		PC:     c.state.PC,
+		Opcode: "MEMCPY_TILE",
+		Msg: fmt.Sprintf("addresses %d to %d of a copy through %s are "+
+			"out of the local memory of %d words", first, last,
+			portName(port), len(c.state.Memory)),
+	}
+}
+
+// sampleOccupancy records the number of received messages that wait at a
+// port.
+func (c *Core) sampleOccupancy(side int) {
+	occupancy := 0
+	if c.state.RecvBufHeadReady[side] {
+		occupancy++
+	}
+
+	if c.arrived(side) != nil {
+		occupancy++
+	}
+
+	if occupancy > c.portStats[side].PeakOccupancy {
+		c.portStats[side].PeakOccupancy = occupancy
+	}
+}
+
+// issue runs the instructions of a cycle, as many as the issue limits allow.
+// It returns true if any instruction runs.
+func (c *Core) issue() bool {
+	if c.halted {
+		return false
+	}
+
+	c.slots = issueSlots{}
+
+	// Without a schedule, which reports the instructions that do not fit
+	// into their cycle, the issue width ends the cycle without a check of
+	// the next instruction.
+	progress := false
+	for (c.schedule != nil || c.slots.insts < c.limits.width()) &&
+		c.runProgram() {
+		progress = true
+	}
+
+	return progress
+}
+
 func (c *Core) runProgram() bool {
-	if int(c.state.PC) >= len(c.state.Code) {
+	if c.fault != nil || c.state.Done || int(c.state.PC) >= len(c.state.Ops) {
 		return false
 	}
 
-	inst := c.state.Code[c.state.PC]
-	for inst[len(inst)-1] == ':' {
-		c.state.PC++
-		inst = c.state.Code[c.state.PC]
+	// A program that ends with labels, or jumps to one of them, finishes
+	// like a program that runs past its last instruction.
+	line := c.nextLine()
+	if line < 0 {
+		c.state.PC = uint32(len(c.state.Ops))
+		return false
 	}
 
+	c.state.PC = uint32(line)
+	op := &c.state.Ops[line]
+
 	prevPC := c.state.PC
-	c.emu.RunInst(inst, &c.state)
+	if reason := c.exceedsLimits(op); reason != "" {
+		if c.schedule != nil && c.dueNow(int(prevPC)) {
+			c.missSchedule(int(prevPC), "its group does not fit, as "+reason)
+		}
+
+		return false
+	}
+
+	if c.schedule != nil && c.waitsForSlot(int(prevPC)) {
+		return false
+	}
+
+	if op.opcode == "RDCOUNTER" {
+		c.updateCounters()
+	}
+
+	if !c.runOp(op) {
+		return false
+	}
+
+	if len(c.watches) > 0 {
+		c.checkWatches(op, prevPC)
+	}
+
 	nextPC := c.state.PC
 
 	if prevPC == nextPC {
+		if c.schedule != nil && !c.state.Done {
+			c.missSchedule(int(prevPC), c.stallCause(op))
+		}
+
 		return false
 	}
 
-	fmt.Printf("%10f, %s, Inst %s\n", c.Engine.CurrentTime()*1e9, c.Name(), inst)
+	c.takeSlots(op)
+
+	if c.schedule != nil {
+		c.schedule.runs[prevPC]++
+	}
+
+	if isCompute(op) {
+		c.computeCycles++
+	}
+
+	c.tracer.Trace(trace.Event{
+		Time:      float64(c.Engine.CurrentTime()) * 1e9,
+		Component: c.Name(),
+		Kind:      trace.KindInst,
+		Inst:      op.text,
+	})
+	c.traceRegisterWrite(op)
 
 	return true
 }
+
+// isCompute returns true if the instruction computes a value, rather than
+// moving data or controlling the program.
+func isCompute(op *operation) bool {
+	_, convert := floatConversions[op.opcode]
+
+	return op.arith != nil || op.cmp != nil || convert ||
+		op.opcode == "UNPACK" || op.opcode == "SEL" || op.opcode == "F32_CLASS"
+}
+
+// runOp runs an instruction. If the instruction fails, e.g., because it
+// accesses an address out of the local memory, runOp records the error and
+// returns false, and the core halts.
+func (c *Core) runOp(op *operation) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.recordFault(op, r)
+			ok = false
+		}
+	}()
+
+	c.emu.runOp(op, &c.state)
+
+	return true
+}
+
+// recordFault records the error of an instruction that has failed.
+func (c *Core) recordFault(op *operation, reason interface{}) {
+	operands := make([]string, 0, len(op.operands))
+	for _, o := range op.operands {
+		operands = append(operands, o.text)
+	}
+
+	c.fault = &cgra.ErrorRecord{
+		Time:     c.Engine.CurrentTime(),
+		PC:       c.state.PC,
+		Opcode:   op.opcode,
+		Operands: operands,
+		Msg:      fmt.Sprint(reason),
+	}
+
+	if !op.source.IsZero() {
+		c.fault.Source = op.source.String()
+	}
+}
+
+// GetError returns the error of the instruction that has halted the core,
+// or nil if no instruction has failed since the program was mapped.
+func (c *Core) GetError() *cgra.ErrorRecord {
+	if c.fault == nil {
+		return nil
+	}
+
+	fault := *c.fault
+
+	return &fault
+}
+
+// traceRegisterWrite prints the values of the destination registers of an
+// instruction.
+func (c *Core) traceRegisterWrite(op *operation) {
+	// Formatting the register names is a large part of the cost of an
+	// instruction, so skip it if the events are dropped anyway.
+	if c.tracer == trace.Discard {
+		return
+	}
+
+	kinds, _ := operandsOf(op.opcode)
+
+	for i, kind := range kinds {
+		dst := op.operands[i]
+		if concreteKind(dst.text, kind) != operandReg {
+			continue
+		}
+
+		n := 1
+		if op.opcode == "WAIT_WIDE" {
+			n = int(op.operands[2].value)
+		}
+
+		for r := dst.index; r < dst.index+n && r < len(c.state.Registers); r++ {
+			c.tracer.Trace(trace.Event{
+				Time:      float64(c.Engine.CurrentTime()) * 1e9,
+				Component: c.Name(),
+				Kind:      trace.KindWrite,
+				Reg:       fmt.Sprintf("$%d", r),
+				Data:      c.state.Registers[r],
+			})
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// tickCounter counts the ticks of a component.
+type tickCounter struct {
+	handler sim.Handler
+	ticks   int
+}
+
+func (h *tickCounter) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosBeforeEvent {
+		return
+	}
+
+	if evt, ok := ctx.Item.(sim.TickEvent); ok && evt.Handler() == h.handler {
+		h.ticks++
+	}
+}
+
+var _ = Describe("Core", func() {
+	It("should reject programs with unsupported opcodes", func() {
+		c := core.Builder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			WithCapabilities(cgra.NewPECaps("WAIT", "SEND")).
+			Build("Core")
+		neighbor := core.Builder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			Build("Neighbor")
+		c.SetRemotePort(cgra.East, neighbor.GetPortByName("West"))
+		c.SetRemotePort(cgra.West, neighbor.GetPortByName("East"))
+
+		Expect(func() {
+			c.MapProgram([]string{"WAIT, $0, NET_RECV_3", "SEND, NET_SEND_1, $0"})
+		}).NotTo(Panic())
+		Expect(func() {
+			c.MapProgram([]string{"WAIT, $0, NET_RECV_3", "I_ADD, $1, $0, 1"})
+		}).To(Panic())
+	})
+
+	It("should list all the violations of a program", func() {
+		c := core.Builder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			Build("Core")
+
+		err := c.CheckProgram([]string{
+			"WAIT, $0, NET_RECV_3",
+			"I_ADD, $64, $0, 1",
+			"FOO, $0",
+		})
+
+		Expect(err).To(MatchError(
+			"Core:1:11: the West side is not connected \"NET_RECV_3\"\n" +
+				"Core:2:8: register out of range, the PE has 64 registers \"$64\"\n" +
+				"Core:3:1: unknown opcode \"FOO\""))
+	})
+
+	It("should replace the opcode aliases", func() {
+		engine := sim.NewSerialEngine()
+		c := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard).
+			Build("Core")
+
+		c.MapProgram([]string{
+			"STORE, 3, 5",
+			"LD, $0, 3",
+			"ICMP_LT, $1, $0, 6",
+			"MOV, $2, $1",
+			"I_ADD, $3, $0, $2",
+			"RETURN_VALUE, $3",
+		})
+		Expect(engine.Run()).To(Succeed())
+
+		v, _ := c.GetRetVal()
+		Expect(v).To(Equal(uint32(6)))
+	})
+
+	It("should use the opcode aliases that it is given", func() {
+		c := core.Builder{}.
+			WithEngine(sim.NewSerialEngine()).
+			WithFreq(1 * sim.GHz).
+			WithOpcodeAliases(map[string]string{"ADD": "I_ADD"}).
+			Build("Core")
+
+		Expect(c.CheckProgram([]string{"ADD, $0, $0, 1"})).To(Succeed())
+		Expect(c.CheckProgram([]string{"MOV, $0, $1"})).To(MatchError(
+			"Core:1:1: unknown opcode \"MOV\""))
+	})
+
+	It("should finish at a trailing label", func() {
+		engine := sim.NewSerialEngine()
+		c := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard).
+			Build("Core")
+
+		c.MapProgram([]string{
+			"I_ADD, $0, 0, 1",
+			"JMP, END",
+			"I_ADD, $0, 0, 2",
+			"END:",
+		})
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(c.GetError()).To(BeNil())
+		Expect(c.ReadRegister(0)).To(Equal(uint32(1)))
+		Expect(c.GetState().PC).To(Equal(uint32(4)))
+	})
+
+	It("should save and restore its context", func() {
+		engine := sim.NewSerialEngine()
+		c := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard).
+			Build("Core")
+
+		c.MapProgram([]string{"I_ADD, $0, 0, 3", "I_ADD, $1, $0, 1", "DONE"})
+		c.SetHalted(true)
+		Expect(engine.Run()).To(Succeed())
+		Expect(c.ReadRegister(0)).To(Equal(uint32(0)))
+
+		ctx := c.SaveContext()
+		Expect(c.IsDone()).To(BeTrue())
+
+		c.SetHalted(false)
+		c.WriteRegister(0, 9)
+		c.RestoreContext(ctx)
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(c.IsDone()).To(BeTrue())
+		Expect(c.ReadRegister(1)).To(Equal(uint32(4)))
+	})
+
+	It("should stop ticking while it waits for data", func() {
+		engine := sim.NewSerialEngine()
+		builder := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard)
+		sender := builder.Build("Sender")
+		receiver := builder.Build("Receiver")
+		sender.SetRemotePort(cgra.East, receiver.GetPortByName("West"))
+		receiver.SetRemotePort(cgra.West, sender.GetPortByName("East"))
+
+		conn := sim.NewDirectConnection("Conn", engine, 1*sim.GHz)
+		conn.PlugIn(sender.GetPortByName("East"), 1)
+		conn.PlugIn(receiver.GetPortByName("West"), 1)
+
+		counter := &tickCounter{handler: receiver.TickingComponent}
+		engine.AcceptHook(counter)
+
+		program := []string{"I_ADD, $1, 0, 5", "SEND, NET_SEND_1, $1"}
+		for i := 0; i < 20; i++ {
+			program = append(program, "I_ADD, $2, $2, 1")
+		}
+
+		sender.MapProgram(append(program, "SEND, NET_SEND_1, $1", "DONE"))
+		receiver.MapProgram([]string{
+			"START:",
+			"WAIT, $0, NET_RECV_3",
+			"I_ADD, $1, $1, $0",
+			"JMP, START",
+		})
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(receiver.GetState().Registers[1]).To(Equal(uint32(10)))
+		Expect(counter.ticks).To(Equal(7))
+	})
+
+	It("should read its counters", func() {
+		engine := sim.NewSerialEngine()
+		builder := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard)
+		sender := builder.Build("Sender")
+		receiver := builder.Build("Receiver")
+		sender.SetRemotePort(cgra.East, receiver.GetPortByName("West"))
+		receiver.SetRemotePort(cgra.West, sender.GetPortByName("East"))
+
+		conn := sim.NewDirectConnection("Conn", engine, 1*sim.GHz)
+		conn.PlugIn(sender.GetPortByName("East"), 1)
+		conn.PlugIn(receiver.GetPortByName("West"), 1)
+
+		Expect(receiver.CheckProgram([]string{"RDCOUNTER, $0, STALLS"})).
+			To(MatchError("Receiver:1:16: unknown counter \"STALLS\""))
+
+		sender.MapProgram([]string{
+			"SEND, NET_SEND_1, 5", "SEND, NET_SEND_1, 6", "DONE"})
+		receiver.MapProgram([]string{
+			"WAIT, $0, NET_RECV_3",
+			"WAIT, $0, NET_RECV_3",
+			"RDCOUNTER, $1, OPS",
+			"RDCOUNTER, $2, TOKENS",
+			"RDCOUNTER, $3, CYCLES",
+			"DONE",
+		})
+		Expect(engine.Run()).To(Succeed())
+
+		counters := receiver.GetCounters()
+		Expect(receiver.ReadRegister(1)).To(Equal(uint32(2)))
+		Expect(receiver.ReadRegister(2)).To(Equal(uint32(2)))
+		Expect(receiver.ReadRegister(3)).To(BeNumerically(">", 2))
+		Expect(counters.Ops).To(Equal(uint64(5)))
+		Expect(counters.Tokens).To(Equal(uint64(2)))
+		Expect(counters.Cycles).To(BeNumerically(">", receiver.ReadRegister(3)))
+	})
+
+	It("should record the error of an instruction and halt", func() {
+		engine := sim.NewSerialEngine()
+		c := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithMemorySize(4).
+			WithTracer(trace.Discard).
+			Build("Core")
+		c.MapProgram([]string{
+			"GATHER, $0, 8 @ src=fir.c:12 node=n7",
+			"I_ADD, $1, 0, 1",
+			"DONE",
+		})
+
+		Expect(engine.Run()).To(Succeed())
+		Expect(c.IsDone()).To(BeFalse())
+		Expect(c.ReadRegister(1)).To(Equal(uint32(0)))
+		Expect(c.GetError()).To(Equal(&cgra.ErrorRecord{
+			Time:     1e-9,
+			Opcode:   "GATHER",
+			Operands: []string{"$0", "8"},
+			Source:   "fir.c:12, node n7",
+			Msg:      "address 8 is out of the local memory of 4 words",
+		}))
+
+		c.MapProgram([]string{"DONE"})
+		Expect(c.GetError()).To(BeNil())
+	})
+
+	Describe("with a schedule", func() {
+		var (
+			engine   *sim.SerialEngine
+			sender   *core.Core
+			receiver *core.Core
+		)
+
+		BeforeEach(func() {
+			engine = sim.NewSerialEngine()
+			builder := core.Builder{}.
+				WithEngine(engine).
+				WithFreq(1 * sim.GHz).
+				WithTracer(trace.Discard)
+			sender = builder.Build("Sender")
+			receiver = builder.Build("Receiver")
+			sender.SetRemotePort(cgra.East, receiver.GetPortByName("West"))
+			receiver.SetRemotePort(cgra.West, sender.GetPortByName("East"))
+
+			conn := sim.NewDirectConnection("Conn", engine, 1*sim.GHz)
+			conn.PlugIn(sender.GetPortByName("East"), 1)
+			conn.PlugIn(receiver.GetPortByName("West"), 1)
+
+			sender.MapProgram([]string{
+				"I_ADD, $1, 0, 5",
+				"SEND, NET_SEND_1, $1",
+				"DONE",
+			})
+			receiver.MapProgram([]string{
+				"WAIT, $0, NET_RECV_3",
+				"I_ADD, $1, $0, 1",
+				"DONE",
+			})
+		})
+
+		It("should run each instruction in its time step", func() {
+			sender.SetSchedule([]int{0, 4, 5}, 8)
+			receiver.SetSchedule([]int{8, 9, 10}, 12)
+			Expect(engine.Run()).To(Succeed())
+
+			Expect(receiver.GetState().Registers[1]).To(Equal(uint32(6)))
+			Expect(receiver.GetActivityStats().Stalls.ScheduleCycles).
+				To(Equal(uint64(8)))
+		})
+
+		It("should report the data that has not arrived", func() {
+			sender.SetSchedule([]int{0, 4, 5}, 8)
+			receiver.SetSchedule([]int{2, 3, 4}, 8)
+
+			Expect(func() { _ = engine.Run() }).To(PanicWith(Equal(
+				"Receiver misses its schedule in cycle 2: " +
+					"\"WAIT, $0, NET_RECV_3\" (line 0, step 2, iteration 0) " +
+					"cannot execute, as NET_RECV_3 (West) has no data")))
+		})
+
+		It("should report a late instruction", func() {
+			sender.SetSchedule([]int{2, 1, 3}, 4)
+
+			Expect(func() { _ = engine.Run() }).To(PanicWith(
+				ContainSubstring("it is late")))
+		})
+	})
+
+	Describe("with issue limits", func() {
+		var engine *sim.SerialEngine
+
+		build := func(l core.IssueLimits) *core.Core {
+			engine = sim.NewSerialEngine()
+
+			return core.Builder{}.
+				WithEngine(engine).
+				WithFreq(1 * sim.GHz).
+				WithTracer(trace.Discard).
+				WithIssueLimits(l).
+				Build("Core")
+		}
+
+		program := []string{
+			"I_ADD, $0, 0, 1",
+			"I_ADD, $1, 0, 2",
+			"I_ADD, $2, $0, $1",
+			"DONE",
+		}
+
+		It("should issue a group of instructions in a cycle", func() {
+			c := build(core.IssueLimits{Width: 2})
+			c.MapProgram(program)
+			c.SetSchedule([]int{0, 0, 1, 2}, 4)
+			Expect(engine.Run()).To(Succeed())
+
+			Expect(c.ReadRegister(2)).To(Equal(uint32(3)))
+			Expect(c.GetActivityStats().InstCycles).To(Equal(uint64(2)))
+		})
+
+		It("should report a group that is wider than the core", func() {
+			c := build(core.IssueLimits{})
+			c.MapProgram(program)
+			c.SetSchedule([]int{0, 0, 1, 2}, 4)
+
+			Expect(func() { _ = engine.Run() }).To(PanicWith(ContainSubstring(
+				"\"I_ADD, $1, 0, 2\" (line 1, step 0, iteration 0) " +
+					"cannot execute, as its group does not fit, as the " +
+					"issue width is 1")))
+		})
+
+		It("should only forward a result in its cycle with the bypass",
+			func() {
+				c := build(core.IssueLimits{Width: 2, Bypass: true})
+				c.MapProgram(program)
+				c.SetSchedule([]int{0, 1, 1, 2}, 4)
+				Expect(engine.Run()).To(Succeed())
+				Expect(c.ReadRegister(2)).To(Equal(uint32(3)))
+
+				c = build(core.IssueLimits{Width: 2})
+				c.MapProgram(program)
+				c.SetSchedule([]int{0, 1, 1, 2}, 4)
+				Expect(func() { _ = engine.Run() }).To(PanicWith(
+					ContainSubstring("its group does not fit, as $1 is " +
+						"written in the same cycle and the PE has no bypass")))
+			})
+
+		It("should stall the instructions whose registers conflict", func() {
+			c := build(core.IssueLimits{Width: 2, RegReads: 1, RegBanks: 2,
+				Bypass: true})
+			c.MapProgram([]string{
+				"I_ADD, $1, $0, 1",
+				"I_ADD, $3, $2, 1",
+				"I_ADD, $4, $1, 1",
+				"DONE",
+			})
+			Expect(engine.Run()).To(Succeed())
+
+			// $0 and $2 are both in bank 0, so the first two instructions
+			// issue in two cycles, and the third issues with the second.
+			Expect(c.ReadRegister(4)).To(Equal(uint32(2)))
+			Expect(c.GetActivityStats().InstCycles).To(Equal(uint64(2)))
+
+			c = build(core.IssueLimits{Width: 2, RegReads: 1, RegBanks: 2})
+			c.MapProgram([]string{"I_ADD, $1, $0, 1", "I_ADD, $3, $2, 1"})
+			c.SetSchedule([]int{0, 0}, 2)
+			Expect(func() { _ = engine.Run() }).To(PanicWith(
+				ContainSubstring("its group does not fit, as 2 registers " +
+					"of bank 0 are read, but 1 can be read per cycle")))
+		})
+
+		It("should stall the instructions that exceed the port limits",
+			func() {
+				c := build(core.IssueLimits{Width: 4, PortWrites: 1})
+				c.SetRemotePort(cgra.East, c.GetPortByName("West"))
+				c.SetRemotePort(cgra.West, c.GetPortByName("East"))
+				conn := sim.NewDirectConnection("Conn", engine, 1*sim.GHz)
+				conn.PlugIn(c.GetPortByName("East"), 1)
+				conn.PlugIn(c.GetPortByName("West"), 1)
+
+				c.MapProgram([]string{
+					"SEND, NET_SEND_1, 1",
+					"SEND, NET_SEND_3, 2",
+					"DONE",
+				})
+				Expect(engine.Run()).To(Succeed())
+
+				// The second SEND waits for the next cycle.
+				Expect(c.GetActivityStats().InstCycles).To(Equal(uint64(2)))
+				Expect(c.ReadRegister(0)).To(Equal(uint32(0)))
+			})
+	})
+
+	It("should disassemble a program with the neighbors and the jumps", func() {
+		listing := core.Disassemble("START:\n"+
+			"WAIT, $0, NET_RECV_3\n"+
+			"I_ADD, $1, $0, $1\n"+
+			"SEND, NET_SEND_1, $1\n"+
+			"JMP, START", [2]int{1, 2})
+
+		Expect(listing).To(Equal("PE(1, 2):\n" +
+			"   0 START:\n" +
+			"   1     WAIT, $0, NET_RECV_3             ; West from PE(0, 2)\n" +
+			"   2     I_ADD, $1, $0, $1\n" +
+			"   3     SEND, NET_SEND_1, $1             ; East to PE(2, 2)\n" +
+			"   4     JMP, START                       ; -> 0\n" +
+			"  $0: 1 writes, 1 reads\n" +
+			"  $1: 1 writes, 2 reads\n"))
+	})
+
+	It("should dump the current state", func() {
+		engine := sim.NewSerialEngine()
+		c := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard).
+			Build("Core")
+
+		c.MapProgram([]string{"I_ADD, $2, 3, 4", "RETURN_VALUE, $2", "DONE"})
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(c.DumpCurrentState()).To(Equal("Core:\n" +
+			"  PC: 2 DONE\n" +
+			"  $2: 7\n" +
+			"  at barrier: false, done: true\n" +
+			"  return value: 7\n"))
+	})
+})
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// regRefs counts the reads and the writes of a register.
+type regRefs struct {
+	reads, writes int
+}
+
+// Disassemble returns an annotated listing of the program of the PE at the
+// coordinate. Each instruction is listed with its PC, the network operands
+// are annotated with the sides and the coordinates of the neighbors, and
+// the jumps with the PCs of the targets. The listing ends with the number
+// of reads and writes of each register.
+func Disassemble(program string, coord [2]int) string {
+	lines := strings.Split(program, "\n")
+	labels := make(map[string]int)
+
+	for pc, line := range lines {
+		if isLabel(line) {
+			labels[strings.TrimSuffix(strings.TrimSpace(line), ":")] = pc
+		}
+	}
+
+	b := &strings.Builder{}
+	refs := make(map[int]*regRefs)
+
+	fmt.Fprintf(b, "PE(%d, %d):\n", coord[0], coord[1])
+
+	for pc, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if isLabel(line) {
+			fmt.Fprintf(b, "%4d %s\n", pc, line)
+			continue
+		}
+
+		notes := annotateInst(line, coord, labels, refs)
+		if len(notes) == 0 {
+			fmt.Fprintf(b, "%4d     %s\n", pc, line)
+			continue
+		}
+
+		fmt.Fprintf(b, "%4d     %-32s ; %s\n", pc, line, strings.Join(notes, ", "))
+	}
+
+	writeRegRefs(b, refs)
+
+	return b.String()
+}
+
+func annotateInst(
+	line string,
+	coord [2]int,
+	labels map[string]int,
+	refs map[int]*regRefs,
+) []string {
+	tokens := splitInst(line)
+	kinds, _ := operandsOf(tokens[0])
+	notes := []string{}
+
+	for i, operand := range tokens[1:] {
+		if i >= len(kinds) {
+			break
+		}
+
+		switch concreteKind(operand, kinds[i]) {
+		case operandReg:
+			countRef(refs, operand, true)
+		case operandSrc, operandRegIn:
+			countRef(refs, operand, false)
+		case operandRecv:
+			notes = append(notes, netNote(operand, "NET_RECV_", "from", coord))
+		case operandSend:
+			notes = append(notes, netNote(operand, "NET_SEND_", "to", coord))
+		case operandSides:
+			notes = append(notes, sidesNote(operand, coord)...)
+		case operandLabel:
+			if pc, ok := labels[operand]; ok {
+				notes = append(notes, fmt.Sprintf("-> %d", pc))
+			}
+		}
+	}
+
+	return notes
+}
+
+func countRef(refs map[int]*regRefs, operand string, write bool) {
+	if !strings.HasPrefix(operand, "$") {
+		return
+	}
+
+	reg, err := strconv.Atoi(strings.TrimPrefix(operand, "$"))
+	if err != nil {
+		return
+	}
+
+	if refs[reg] == nil {
+		refs[reg] = &regRefs{}
+	}
+
+	if write {
+		refs[reg].writes++
+	} else {
+		refs[reg].reads++
+	}
+}
+
+// netNote names the side of a network operand and the neighbor on that side.
+// The channels other than channel 0 only connect to the driver.
+func netNote(operand, prefix, dir string, coord [2]int) string {
+	index, err := strconv.Atoi(strings.TrimPrefix(operand, prefix))
+	if err != nil || index < 0 || index >= 4*MaxChannels {
+		return "invalid side"
+	}
+
+	if index > 3 {
+		return fmt.Sprintf("%s %s the driver", portName(index), dir)
+	}
+
+	side := cgra.Side(index)
+	n := neighborCoord(coord, side)
+
+	return fmt.Sprintf("%s %s PE(%d, %d)", side.Name(), dir, n[0], n[1])
+}
+
+// sidesNote names the sides in a mask of NET_RECV registers.
+func sidesNote(operand string, coord [2]int) []string {
+	mask, err := strconv.ParseInt(operand, 0, 64)
+	if err != nil {
+		return []string{"invalid side mask"}
+	}
+
+	notes := []string{}
+	for i := 0; i < 4; i++ {
+		if mask&(1<<i) != 0 {
+			notes = append(notes,
+				netNote(fmt.Sprint(i), "", "from", coord))
+		}
+	}
+
+	return notes
+}
+
+func neighborCoord(coord [2]int, side cgra.Side) [2]int {
+	switch side {
+	case cgra.North:
+		return [2]int{coord[0], coord[1] - 1}
+	case cgra.East:
+		return [2]int{coord[0] + 1, coord[1]}
+	case cgra.South:
+		return [2]int{coord[0], coord[1] + 1}
+	default:
+		return [2]int{coord[0] - 1, coord[1]}
+	}
+}
+
+func writeRegRefs(b *strings.Builder, refs map[int]*regRefs) {
+	regs := make([]int, 0, len(refs))
+	for reg := range refs {
+		regs = append(regs, reg)
+	}
+
+	sort.Ints(regs)
+
+	for _, reg := range regs {
+		fmt.Fprintf(b, "  $%d: %d writes, %d reads\n",
+			reg, refs[reg].writes, refs[reg].reads)
+	}
+}
+
+// DumpCurrentState returns the PC, the instruction at the PC, the non-zero
+// registers, the network buffers, and the progress of the core.
+func (c *Core) DumpCurrentState() string {
+	s := &c.state
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "%s:\n", c.Name())
+
+	inst := "(end of program)"
+	if int(s.PC) < len(s.Code) {
+		inst = strings.TrimSpace(s.Code[s.PC])
+	}
+
+	fmt.Fprintf(b, "  PC: %d %s\n", s.PC, inst)
+
+	for i, v := range s.Registers {
+		if v != 0 {
+			fmt.Fprintf(b, "  $%d: %d\n", i, v)
+		}
+	}
+
+	for i := range s.RecvBufHead {
+		side := portName(i)
+		if s.RecvBufHeadReady[i] {
+			fmt.Fprintf(b, "  NET_RECV_%d (%s): %s\n", i, side,
+				formatWords(s.RecvBufHead[i], s.RecvBufHeadExtra[i]))
+		}
+
+		if s.SendBufHeadBusy[i] {
+			fmt.Fprintf(b, "  NET_SEND_%d (%s): %s\n", i, side,
+				formatWords(s.SendBufHead[i], s.SendBufHeadExtra[i]))
+		}
+	}
+
+	fmt.Fprintf(b, "  at barrier: %t, done: %t\n", s.AtBarrier, s.Done)
+
+	if s.HasRetVal {
+		fmt.Fprintf(b, "  return value: %d\n", s.RetVal)
+	}
+
+	return b.String()
+}
+
+// formatWords formats the head of a network buffer, followed by the extra
+// words of a wide message in brackets.
+func formatWords(head uint32, extra []uint32) string {
+	if len(extra) == 0 {
+		return fmt.Sprint(head)
+	}
+
+	return fmt.Sprint(head, " ", extra)
+}
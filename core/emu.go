@@ -1,9 +1,10 @@
 package core
 
 import (
-	"math"
-	"strconv"
+	"fmt"
 	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
 )
 
 type coreState struct {
@@ -11,225 +12,653 @@ type coreState struct {
 	TileX, TileY     uint32
 	Registers        []uint32
 	Code             []string
+	Ops              []operation
 	RecvBufHead      []uint32
 	RecvBufHeadReady []bool
 	SendBufHead      []uint32
 	SendBufHeadBusy  []bool
+
+	// RecvBufHeadInvalid and SendBufHeadInvalid mark the tokens in the
+	// network buffers whose predicate is false.
+	RecvBufHeadInvalid []bool
+	SendBufHeadInvalid []bool
+
+	// RecvBufHeadExtra and SendBufHeadExtra are the words that follow the
+	// heads of the network buffers in wide messages.
+	RecvBufHeadExtra [][]uint32
+	SendBufHeadExtra [][]uint32
+
+	// SendBufHeadCopy marks the NET_SEND registers that hold a part of a
+	// block copy, whose words the receiver writes to its local memory from
+	// SendBufHeadCopyAddr. CopySent is the number of words that the
+	// MEMCPY_TILE at the PC has put in its NET_SEND register.
+	SendBufHeadCopy     []bool
+	SendBufHeadCopyAddr []uint32
+	CopySent            uint32
+
+	// Barrier is the barrier network that BARRIER instructions use.
+	// BarrierWake is called when the barrier that the core waits on is
+	// released.
+	Barrier        *Barrier
+	BarrierWake    func()
+	AtBarrier      bool
+	BarrierWaitGen uint64
+
+	// Done is set when the core executes a DONE instruction.
+	Done bool
+
+	// RetVal is the value recorded by the last RETURN_VALUE instruction.
+	RetVal    uint32
+	HasRetVal bool
+
+	// Args are the kernel arguments that ARGn operands read.
+	Args []uint32
+
+	// RandState is the state of the generator that RAND reads.
+	RandState uint64
+
+	// Counters are the values that RDCOUNTER reads, by the index of the
+	// counter. The core updates them before it runs a RDCOUNTER.
+	Counters [numCounters]uint64
+
+	// Memory is the local memory of the PE in words, which GATHER and
+	// SCATTER address.
+	Memory []uint32
+
+	// GrantedOnce marks the PCs of the GRANT_ONCE instructions that have
+	// granted their token since the program was mapped or the last
+	// STATE_RESET.
+	GrantedOnce map[uint32]bool
+
+	// Inbox holds, by the port, the tokens that have arrived while the
+	// NET_RECV register was full and the core was halted, or that were
+	// left over when a context was restored. They enter the NET_RECV
+	// register before the tokens that wait in the port.
+	Inbox [][]token
 }
 
-type instEmulator struct {
+// token is a token that has arrived at a port.
+type token struct {
+	Data    uint32
+	Invalid bool
+	Extra   []uint32
+
+	// Copy marks the words of a block copy to CopyAddr.
+	Copy     bool
+	CopyAddr uint32
 }
 
-func (i instEmulator) RunInst(inst string, state *coreState) {
-	tokens := strings.Split(inst, ",")
-	for i := range tokens {
-		tokens[i] = strings.TrimSpace(tokens[i])
+func tokenOf(msg *cgra.MoveMsg) token {
+	return token{
+		Data:     msg.Data,
+		Invalid:  msg.Invalid,
+		Extra:    msg.Extra,
+		Copy:     msg.Copy,
+		CopyAddr: msg.CopyAddr,
 	}
+}
 
-	instName := tokens[0]
-	if strings.Contains(instName, "CMP") {
-		instName = "CMP"
-	}
+// setRecvHead puts the token into the NET_RECV register of the port.
+func (s *coreState) setRecvHead(port int, t token) {
+	s.RecvBufHeadReady[port] = true
+	s.RecvBufHead[port] = t.Data
+	s.RecvBufHeadInvalid[port] = t.Invalid
+	s.RecvBufHeadExtra[port] = t.Extra
+}
 
-	instFuncs := map[string]func([]string, *coreState){
-		"WAIT": i.runWait,
-		"SEND": i.runSend,
-		"JMP":  i.runJmp,
-		"CMP":  i.runCmp,
-		"JEQ":  i.runJeq,
-		"DONE": func(_ []string, _ *coreState) { i.runDone() }, // Since runDone might not have parameters
-	}
+// NumKernelArgs is the number of kernel arguments, ARG0 to ARG7, that each
+// core has.
+const NumKernelArgs = 8
 
-	if instFunc, ok := instFuncs[instName]; ok {
-		instFunc(tokens, state)
-	} else {
-		panic("unknown instruction " + inst)
-	}
+// MaxChannels is the number of channels that a core can have on each side.
+// The NET_RECV and NET_SEND registers of channel k on side s are
+// NET_RECV_{4k+s} and NET_SEND_{4k+s}.
+const MaxChannels = 4
+
+// MaxMessageWords is the number of words that a wide message can have.
+const MaxMessageWords = 8
+
+// DefaultMemorySize is the number of words in the local memory of a core
+// that is not given a memory size.
+const DefaultMemorySize = 1024
+
+type instEmulator struct {
+	// float is the rounding and the denormal modes of the float32
+	// operations.
+	float FloatMode
 }
 
-func (i instEmulator) runWait(inst []string, state *coreState) {
-	dst := inst[1]
-	src := inst[2]
+// RunInst decodes an instruction, with the labels of the code of the
+// state, and runs it.
+func (i instEmulator) RunInst(inst string, state *coreState) {
+	op := decodeInst(inst, labelPCs(state.Code))
+	i.runOp(&op, state)
+}
 
-	i.waitSrcMustBeNetRecvReg(src)
-	srcIndex, err := strconv.Atoi(strings.TrimPrefix(src, "NET_RECV_"))
-	if err != nil {
-		panic(err)
-	}
+// runOp runs an instruction that has been decoded.
+func (i instEmulator) runOp(op *operation, state *coreState) {
+	op.exec(i, op, state)
+}
+
+func (i instEmulator) runWait(op *operation, state *coreState) {
+	srcIndex := op.operands[1].index
 
 	if !state.RecvBufHeadReady[srcIndex] {
 		return
 	}
 
 	state.RecvBufHeadReady[srcIndex] = false
-	i.writeOperand(dst, state.RecvBufHead[srcIndex], state)
+	i.writeOperand(op.operands[0], state.RecvBufHead[srcIndex], state)
 	state.PC++
 }
 
-func (i instEmulator) waitSrcMustBeNetRecvReg(src string) {
-	if !strings.HasPrefix(src, "NET_RECV_") {
-		panic("the source of a WAIT instruction must be NET_RECV registers")
+// runSend sends the source. SEND_PRED marks the token as invalid if its
+// predicate, the third operand, is zero.
+func (i instEmulator) runSend(op *operation, state *coreState) {
+	dstIndex := op.operands[0].index
+
+	if state.SendBufHeadBusy[dstIndex] {
+		return
 	}
+
+	state.SendBufHeadBusy[dstIndex] = true
+	state.SendBufHead[dstIndex] = i.readOperand(op.operands[1], state)
+	state.SendBufHeadExtra[dstIndex] = nil
+	state.SendBufHeadInvalid[dstIndex] =
+		len(op.operands) > 2 && i.readOperand(op.operands[2], state) == 0
+	state.PC++
 }
 
-func (i instEmulator) runSend(inst []string, state *coreState) {
-	dst := inst[1]
-	src := inst[2]
+// runForward moves the data of a NET_RECV register to a NET_SEND register
+// without going through the registers of the PE.
+func (i instEmulator) runForward(op *operation, state *coreState) {
+	dst, src := op.operands[0].index, op.operands[1].index
 
-	i.sendDstMustBeNetSendReg(dst)
-	dstIndex, err := strconv.Atoi(strings.TrimPrefix(dst, "NET_SEND_"))
-	if err != nil {
-		panic(err)
+	if state.SendBufHeadBusy[dst] || !state.RecvBufHeadReady[src] {
+		return
 	}
 
-	if state.SendBufHeadBusy[dstIndex] {
+	state.SendBufHeadBusy[dst] = true
+	state.SendBufHead[dst] = state.RecvBufHead[src]
+	state.SendBufHeadInvalid[dst] = state.RecvBufHeadInvalid[src]
+	state.SendBufHeadExtra[dst] = state.RecvBufHeadExtra[src]
+	state.RecvBufHeadReady[src] = false
+	state.PC++
+}
+
+// runSendWide sends the registers from the source on as one message, with
+// as many words as the third operand.
+func (i instEmulator) runSendWide(op *operation, state *coreState) {
+	dst := op.operands[0].index
+	if state.SendBufHeadBusy[dst] {
 		return
 	}
 
-	state.SendBufHeadBusy[dstIndex] = true
-	val := i.readOperand(src, state)
-	state.SendBufHead[dstIndex] = val
+	words := regRange(op.operands[1].index, int(op.operands[2].value), state)
+
+	state.SendBufHeadBusy[dst] = true
+	state.SendBufHead[dst] = words[0]
+	state.SendBufHeadExtra[dst] = append([]uint32(nil), words[1:]...)
+	state.SendBufHeadInvalid[dst] = false
 	state.PC++
 }
 
-func (i instEmulator) sendDstMustBeNetSendReg(dst string) {
-	if !strings.HasPrefix(dst, "NET_SEND_") {
-		panic("the destination of a SEND instruction must be NET_SEND registers")
+// runWaitWide receives the words of a message into the registers from the
+// destination on. The message must have as many words as the third operand.
+func (i instEmulator) runWaitWide(op *operation, state *coreState) {
+	src := op.operands[1].index
+	if !state.RecvBufHeadReady[src] {
+		return
+	}
+
+	n := int(op.operands[2].value)
+	if len(state.RecvBufHeadExtra[src])+1 != n {
+		panic(fmt.Sprintf("WAIT_WIDE expects %d words from NET_RECV_%d, "+
+			"but the message has %d", n, src,
+			len(state.RecvBufHeadExtra[src])+1))
 	}
+
+	regs := regRange(op.operands[0].index, n, state)
+	regs[0] = state.RecvBufHead[src]
+	copy(regs[1:], state.RecvBufHeadExtra[src])
+
+	state.RecvBufHeadReady[src] = false
+	state.PC++
 }
 
-func (i instEmulator) runJmp(inst []string, state *coreState) {
-	dst := inst[1]
+// regRange returns the n registers from the first one on.
+func regRange(first, n int, state *coreState) []uint32 {
+	if first+n > len(state.Registers) {
+		panic(fmt.Sprintf("registers $%d to $%d are out of the %d registers",
+			first, first+n-1, len(state.Registers)))
+	}
+
+	return state.Registers[first : first+n]
+}
+
+// runMove moves a token from the source to the destination, a register or
+// a NET_SEND register, once the NET_RECV operands are ready and the
+// destination is free. The token is valid if the opcode grants it, and a
+// token that is not valid is sent as invalid, but is not written to a
+// register.
+func (i instEmulator) runMove(op *operation, state *coreState) {
+	dst := op.operands[0]
+	if dst.kind == operandSend && state.SendBufHeadBusy[dst.index] {
+		return
+	}
 
-	for i := 0; i < len(state.Code); i++ {
-		line := strings.Trim(state.Code[i], " \t\n")
-		if strings.HasPrefix(line, dst) && strings.HasSuffix(line, ":") {
-			state.PC = uint32(i)
+	for _, o := range op.operands[1:] {
+		if o.kind == operandRecv && !state.RecvBufHeadReady[o.index] {
 			return
 		}
 	}
-}
 
-func (i instEmulator) readOperand(operand string, state *coreState) (value uint32) {
-	if strings.HasPrefix(operand, "$") {
-		registerIndex, err := strconv.Atoi(strings.TrimPrefix(operand, "$"))
-		if err != nil {
-			panic("invalid register index")
+	value, valid := i.readToken(op.operands[1], state)
+	valid = i.grant(op, valid, state)
+
+	var extra []uint32
+	if src := op.operands[1]; src.kind == operandRecv {
+		extra = state.RecvBufHeadExtra[src.index]
+	}
+
+	for _, o := range op.operands[1:] {
+		if o.kind == operandRecv {
+			state.RecvBufHeadReady[o.index] = false
 		}
+	}
 
-		value = state.Registers[registerIndex]
+	switch {
+	case dst.kind == operandSend:
+		state.SendBufHeadBusy[dst.index] = true
+		state.SendBufHead[dst.index] = value
+		state.SendBufHeadExtra[dst.index] = extra
+		state.SendBufHeadInvalid[dst.index] = !valid
+	case valid:
+		i.writeOperand(dst, value, state)
 	}
 
-	return
+	state.PC++
 }
 
-func (i instEmulator) writeOperand(operand string, value uint32, state *coreState) {
-	if strings.HasPrefix(operand, "$") {
-		registerIndex, err := strconv.Atoi(strings.TrimPrefix(operand, "$"))
-		if err != nil {
-			panic("invalid register index")
+// grant returns if the token of a move is valid. DATA_MOV keeps the
+// validity of the source, GRANT_ALWAYS always grants the token, GRANT_ONCE
+// grants it only the first time that it runs, and GRANT_PREDICATE grants a
+// valid token if its predicate, the third operand, is a valid nonzero value.
+func (i instEmulator) grant(op *operation, valid bool, state *coreState) bool {
+	switch op.opcode {
+	case "GRANT_ALWAYS":
+		return true
+	case "GRANT_ONCE":
+		if state.GrantedOnce == nil {
+			state.GrantedOnce = make(map[uint32]bool)
 		}
 
-		state.Registers[registerIndex] = value
+		granted := !state.GrantedOnce[state.PC]
+		state.GrantedOnce[state.PC] = true
+
+		return granted
+	case "GRANT_PREDICATE":
+		pred, predValid := i.readToken(op.operands[2], state)
+		return valid && predValid && pred != 0
+	default:
+		return valid
 	}
 }
 
-func (i instEmulator) runCmp(inst []string, state *coreState) {
-	Itype := inst[0]
-	//Float or Integer
-	switch {
-	case strings.Contains(Itype, "I"):
-		i.parseAndCompareI(inst, state)
-	case strings.Contains(Itype, "F32"):
-		i.parseAndCompareF32(inst, state)
-	default:
-		panic("invalid cmp")
+// runStateReset clears the one-shot state of the PE, so that each
+// GRANT_ONCE grants its token again the next time that it runs, e.g., when
+// an outer loop enters an inner loop again.
+func (i instEmulator) runStateReset(_ *operation, state *coreState) {
+	state.GrantedOnce = nil
+	state.PC++
+}
+
+// readToken reads an operand and whether it is valid. Only the tokens in the
+// NET_RECV registers can be invalid.
+func (i instEmulator) readToken(o operand, state *coreState) (uint32, bool) {
+	if o.kind == operandRecv {
+		return state.RecvBufHead[o.index], !state.RecvBufHeadInvalid[o.index]
 	}
+
+	return i.readOperand(o, state), true
 }
 
-func (i instEmulator) parseAndCompareI(inst []string, state *coreState) {
-	instruction := inst[0]
-	dst := inst[1]
-	src := inst[2]
+func (i instEmulator) runGather(op *operation, state *coreState) {
+	addr := memAddr(i.readOperand(op.operands[1], state), state)
+	i.writeOperand(op.operands[0], state.Memory[addr], state)
+	state.PC++
+}
 
-	srcVal := i.readOperand(src, state)
-	dstVal := uint32(0)
-	imme, err := strconv.ParseUint(inst[3], 10, 32)
-	if err != nil {
-		panic("invalid compare number")
+// runScatter stores the value to the address, or adds the value to the word
+// at the address for SCATTER_ADD.
+func (i instEmulator) runScatter(op *operation, state *coreState) {
+	addr := memAddr(i.readOperand(op.operands[0], state), state)
+	value := i.readOperand(op.operands[1], state)
+
+	if op.arith != nil {
+		value = op.arith(state.Memory[addr], value)
+	}
+
+	state.Memory[addr] = value
+	state.PC++
+}
+
+// A queue of ENQUEUE and DEQUEUE is a ring buffer in the local memory. The
+// words at the base address are the index of the head, the number of
+// entries, and the capacity, which the entries follow.
+const (
+	queueHead = iota
+	queueCount
+	queueCapacity
+	queueHeaderWords
+)
+
+// runEnqueue appends the value, which can be the token of a NET_RECV
+// register, to the queue and writes 1 to the status register. If the queue
+// is full, it writes 0 and leaves the token in the NET_RECV register.
+func (i instEmulator) runEnqueue(op *operation, state *coreState) {
+	src := op.operands[2]
+	if src.kind == operandRecv && !state.RecvBufHeadReady[src.index] {
+		return
 	}
 
-	immeI32 := int32(uint32(imme))
-	srcValI := int32(srcVal)
+	base := i.readOperand(op.operands[1], state)
+	head, count, capacity := queueHeader(base, state)
 
-	conditionFuncs := map[string]func(int32, int32) bool{
-		"EQ": func(a, b int32) bool { return a == b },
-		"NE": func(a, b int32) bool { return a != b },
-		"LE": func(a, b int32) bool { return a <= b },
-		"LT": func(a, b int32) bool { return a < b },
-		"GT": func(a, b int32) bool { return a > b },
-		"GE": func(a, b int32) bool { return a >= b },
+	if count >= capacity {
+		i.writeOperand(op.operands[0], 0, state)
+		state.PC++
+
+		return
 	}
 
-	for key, function := range conditionFuncs {
-		if strings.Contains(instruction, key) && function(srcValI, immeI32) {
-			dstVal = 1
-		}
+	value, _ := i.readToken(src, state)
+	if src.kind == operandRecv {
+		state.RecvBufHeadReady[src.index] = false
 	}
-	i.writeOperand(dst, dstVal, state)
+
+	slot := base + queueHeaderWords + (head+count)%capacity
+	state.Memory[memAddr(slot, state)] = value
+	state.Memory[base+queueCount] = count + 1
+
+	i.writeOperand(op.operands[0], 1, state)
 	state.PC++
 }
 
-func (i instEmulator) parseAndCompareF32(inst []string, state *coreState) {
-	instruction := inst[0]
-	dst := inst[1]
-	src := inst[2]
+// runDequeue removes the head of the queue, writes it to the destination, a
+// register or a free NET_SEND register, and writes 1 to the status
+// register. If the queue is empty, it writes 0 to the status register and
+// leaves the destination unchanged.
+func (i instEmulator) runDequeue(op *operation, state *coreState) {
+	dst := op.operands[0]
+	if dst.kind == operandSend && state.SendBufHeadBusy[dst.index] {
+		return
+	}
+
+	base := i.readOperand(op.operands[2], state)
+	head, count, capacity := queueHeader(base, state)
 
-	srcVal := i.readOperand(src, state)
-	dstVal := uint32(0)
-	imme, err := strconv.ParseUint(inst[3], 10, 32)
-	if err != nil {
-		panic("invalid compare number")
+	if count == 0 {
+		i.writeOperand(op.operands[1], 0, state)
+		state.PC++
+
+		return
 	}
 
-	conditionFuncsF := map[string]func(float32, float32) bool{
-		"EQ": func(a, b float32) bool { return a == b },
-		"NE": func(a, b float32) bool { return a != b },
-		"LT": func(a, b float32) bool { return a < b },
-		"LE": func(a, b float32) bool { return a <= b },
-		"GT": func(a, b float32) bool { return a > b },
-		"GE": func(a, b float32) bool { return a >= b },
+	slot := base + queueHeaderWords + head%capacity
+	value := state.Memory[memAddr(slot, state)]
+	state.Memory[base+queueHead] = (head + 1) % capacity
+	state.Memory[base+queueCount] = count - 1
+
+	if dst.kind == operandSend {
+		state.SendBufHeadBusy[dst.index] = true
+		state.SendBufHead[dst.index] = value
+		state.SendBufHeadExtra[dst.index] = nil
+		state.SendBufHeadInvalid[dst.index] = false
+	} else {
+		i.writeOperand(dst, value, state)
 	}
 
-	immeF32 := math.Float32frombits(uint32(imme))
-	srcValF := math.Float32frombits(srcVal)
+	i.writeOperand(op.operands[1], 1, state)
+	state.PC++
+}
+
+// queueHeader reads the header of the queue at the base address.
+func queueHeader(base uint32, state *coreState) (head, count, capacity uint32) {
+	memAddr(base, state)
+	memAddr(base+queueCapacity, state)
+
+	head = state.Memory[base+queueHead]
+	count = state.Memory[base+queueCount]
+	capacity = state.Memory[base+queueCapacity]
+
+	if count > capacity || (capacity > 0 && head >= capacity) {
+		panic(fmt.Sprintf("queue at address %d has a corrupted header: "+
+			"head %d, %d entries, capacity %d", base, head, count, capacity))
+	}
+
+	return head, count, capacity
+}
+
+func memAddr(addr uint32, state *coreState) uint32 {
+	if int(addr) >= len(state.Memory) {
+		panic(fmt.Sprintf("address %d is out of the local memory of %d words",
+			addr, len(state.Memory)))
+	}
+
+	return addr
+}
 
-	for key, function := range conditionFuncsF {
-		if strings.Contains(instruction, key) && function(srcValF, immeF32) {
-			dstVal = 1
+// runMemcpyTile copies a block of the local memory to the local memory of
+// the neighbor on the side of the NET_SEND register, in messages of up to
+// MaxMessageWords words, one each time the register is free. The
+// instruction completes once it has put the last message in the register.
+func (i instEmulator) runMemcpyTile(op *operation, state *coreState) {
+	port := op.operands[0].index
+	if state.SendBufHeadBusy[port] {
+		return
+	}
+
+	dst := i.readOperand(op.operands[1], state)
+	src := i.readOperand(op.operands[2], state)
+	words := i.readOperand(op.operands[3], state)
+
+	if sent := state.CopySent; sent < words {
+		n := words - sent
+		if n > MaxMessageWords {
+			n = MaxMessageWords
 		}
+
+		first := memAddr(src+sent, state)
+		last := memAddr(src+sent+n-1, state)
+		block := state.Memory[first : last+1]
+
+		state.SendBufHeadBusy[port] = true
+		state.SendBufHead[port] = block[0]
+		state.SendBufHeadExtra[port] = append([]uint32(nil), block[1:]...)
+		state.SendBufHeadInvalid[port] = false
+		state.SendBufHeadCopy[port] = true
+		state.SendBufHeadCopyAddr[port] = dst + sent
+		state.CopySent += n
+	}
+
+	if state.CopySent >= words {
+		state.CopySent = 0
+		state.PC++
+	}
+}
+
+func (i instEmulator) runJmp(op *operation, state *coreState) {
+	state.PC = uint32(op.operands[0].index)
+}
+
+func (i instEmulator) readOperand(o operand, state *coreState) uint32 {
+	switch o.kind {
+	case operandReg:
+		return state.Registers[o.index]
+	case operandArg:
+		return state.Args[o.index]
+	default:
+		return o.value
+	}
+}
+
+func (i instEmulator) writeOperand(o operand, value uint32, state *coreState) {
+	if o.kind == operandReg {
+		state.Registers[o.index] = value
 	}
-	i.writeOperand(dst, dstVal, state)
+}
+
+// runSel writes the third operand if the condition, the second operand, is
+// nonzero, or the fourth operand otherwise.
+func (i instEmulator) runSel(op *operation, state *coreState) {
+	value := i.readOperand(op.operands[3], state)
+	if i.readOperand(op.operands[1], state) != 0 {
+		value = i.readOperand(op.operands[2], state)
+	}
+
+	i.writeOperand(op.operands[0], value, state)
+	state.PC++
+}
+
+func (i instEmulator) runIntArith(op *operation, state *coreState) {
+	src1 := i.readOperand(op.operands[1], state)
+	src2 := i.readOperand(op.operands[2], state)
+
+	i.writeOperand(op.operands[0], op.arith(src1, src2), state)
 	state.PC++
 }
 
-func (i instEmulator) runJeq(inst []string, state *coreState) {
-	src := inst[2]
-	imme, err := strconv.ParseUint(inst[3], 10, 32)
+// runUnpack splits a word into the sign-extended low and high halves.
+func (i instEmulator) runUnpack(op *operation, state *coreState) {
+	src := i.readOperand(op.operands[2], state)
+
+	i.writeOperand(op.operands[0], uint32(int32(int16(src))), state)
+	i.writeOperand(op.operands[1], uint32(int32(int16(src>>16))), state)
+	state.PC++
+}
 
-	if err != nil {
-		panic("invalid compare number")
+// runRand writes the next value of the splitmix64 generator of the core.
+func (i instEmulator) runRand(op *operation, state *coreState) {
+	state.RandState += 0x9e3779b97f4a7c15
+	z := state.RandState
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	i.writeOperand(op.operands[0], uint32(z>>32), state)
+	state.PC++
+}
+
+// runRdCounter writes the low 32 bits of a counter of the core.
+func (i instEmulator) runRdCounter(op *operation, state *coreState) {
+	i.writeOperand(op.operands[0], uint32(state.Counters[op.operands[1].index]),
+		state)
+	state.PC++
+}
+
+// runReduce waits until all the NET_RECV registers in the mask are ready,
+// and then combines the source with all of them.
+func (i instEmulator) runReduce(op *operation, state *coreState) {
+	mask := op.operands[2].value
+
+	for side := 0; side < 4; side++ {
+		if mask&(1<<side) != 0 && !state.RecvBufHeadReady[side] {
+			return
+		}
 	}
 
-	srcVal := i.readOperand(src, state)
+	acc := i.readOperand(op.operands[1], state)
+	for side := 0; side < 4; side++ {
+		if mask&(1<<side) != 0 {
+			acc = op.arith(acc, state.RecvBufHead[side])
+			state.RecvBufHeadReady[side] = false
+		}
+	}
+
+	i.writeOperand(op.operands[0], acc, state)
+	state.PC++
+}
+
+// runFloatArith runs F32_ADD, F32_SUB, and F32_MUL in the float mode of the
+// emulator.
+func (i instEmulator) runFloatArith(op *operation, state *coreState) {
+	src1 := i.readOperand(op.operands[1], state)
+	src2 := i.readOperand(op.operands[2], state)
 
-	if srcVal == uint32(imme) {
-		i.runJmp(inst, state)
+	i.writeOperand(op.operands[0],
+		floatArithFuncs[op.opcode](i.float, src1, src2), state)
+	state.PC++
+}
+
+// runConvert converts the source from one float format to another in the
+// float mode of the emulator.
+func (i instEmulator) runConvert(op *operation, state *coreState) {
+	formats := floatConversions[op.opcode]
+	src := i.readOperand(op.operands[1], state)
+
+	i.writeOperand(op.operands[0],
+		i.float.convert(formats[0], formats[1], src), state)
+	state.PC++
+}
+
+// runClass writes the class of a float32 as one bit, as FCLASS.S of RISC-V
+// does.
+func (i instEmulator) runClass(op *operation, state *coreState) {
+	i.writeOperand(op.operands[0],
+		classify(i.readOperand(op.operands[1], state)), state)
+	state.PC++
+}
+
+func (i instEmulator) runCmp(op *operation, state *coreState) {
+	a, b := i.readOperand(op.operands[1], state), op.operands[2].value
+	if strings.HasPrefix(op.opcode, "F32_") {
+		a, b = i.float.flushInput(binary32, a), i.float.flushInput(binary32, b)
+	}
+
+	dstVal := uint32(0)
+	if op.cmp(a, b) {
+		dstVal = 1
+	}
+
+	i.writeOperand(op.operands[0], dstVal, state)
+	state.PC++
+}
+
+func (i instEmulator) runJeq(op *operation, state *coreState) {
+	if i.readOperand(op.operands[1], state) == op.operands[2].value {
+		i.runJmp(op, state)
 	} else {
 		state.PC++
 	}
 }
 
-func (i instEmulator) runDone() {
-	// Do nothing.
+func (i instEmulator) runBarrier(op *operation, state *coreState) {
+	id := op.operands[0].text
+
+	if state.Barrier == nil {
+		panic("BARRIER requires a barrier network")
+	}
+
+	if !state.AtBarrier {
+		state.BarrierWaitGen = state.Barrier.arrive(id, state.BarrierWake)
+		state.AtBarrier = true
+	}
+
+	if !state.Barrier.passed(id, state.BarrierWaitGen) {
+		return
+	}
+
+	state.AtBarrier = false
+	state.PC++
+}
+
+func (i instEmulator) runReturnValue(op *operation, state *coreState) {
+	state.RetVal = i.readOperand(op.operands[0], state)
+	state.HasRetVal = true
+	state.PC++
+}
+
+func (i instEmulator) runDone(_ *operation, state *coreState) {
+	state.Done = true
 }
@@ -1,6 +1,8 @@
 package core
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -23,6 +25,11 @@ var _ = Describe("InstEmulator", func() {
 			RecvBufHeadReady: make([]bool, 4),
 			SendBufHead:      make([]uint32, 4),
 			SendBufHeadBusy:  make([]bool, 4),
+
+			RecvBufHeadInvalid: make([]bool, 4),
+			SendBufHeadInvalid: make([]bool, 4),
+			RecvBufHeadExtra:   make([][]uint32, 4),
+			SendBufHeadExtra:   make([][]uint32, 4),
 		}
 	})
 
@@ -74,6 +81,480 @@ var _ = Describe("InstEmulator", func() {
 			Expect(s.SendBufHeadBusy[0]).To(BeTrue())
 			Expect(s.SendBufHead[0]).To(Equal(uint32(4)))
 		})
+
+		It("should mark the data as invalid if the predicate is false", func() {
+			s.Registers[0] = 4
+
+			ie.RunInst("SEND_PRED, NET_SEND_0, $0, $1", &s)
+			Expect(s.SendBufHeadInvalid[0]).To(BeTrue())
+
+			s.SendBufHeadBusy[0] = false
+			s.Registers[1] = 1
+
+			ie.RunInst("SEND_PRED, NET_SEND_0, $0, $1", &s)
+			Expect(s.SendBufHeadInvalid[0]).To(BeFalse())
+			Expect(s.SendBufHead[0]).To(Equal(uint32(4)))
+		})
+	})
+
+	Context("when running FORWARD", func() {
+		It("should wait for data and a free send buffer", func() {
+			s.SendBufHeadBusy[1] = true
+			s.RecvBufHeadReady[3] = true
+
+			ie.RunInst("FORWARD, NET_SEND_1, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+		})
+
+		It("should move the received data to the send buffer", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 6
+
+			ie.RunInst("FORWARD, NET_SEND_1, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.RecvBufHeadReady[3]).To(BeFalse())
+			Expect(s.SendBufHeadBusy[1]).To(BeTrue())
+			Expect(s.SendBufHead[1]).To(Equal(uint32(6)))
+		})
+
+		It("should keep the received data invalid", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHeadInvalid[3] = true
+
+			ie.RunInst("FORWARD, NET_SEND_1, NET_RECV_3", &s)
+
+			Expect(s.SendBufHeadInvalid[1]).To(BeTrue())
+		})
+	})
+
+	Context("when running wide messages", func() {
+		It("should send the registers as one message", func() {
+			s.Registers[1] = 5
+			s.Registers[2] = 6
+			s.Registers[3] = 7
+
+			ie.RunInst("SEND_WIDE, NET_SEND_1, $1, 3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.SendBufHead[1]).To(Equal(uint32(5)))
+			Expect(s.SendBufHeadExtra[1]).To(Equal([]uint32{6, 7}))
+		})
+
+		It("should receive the words into the registers", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 5
+			s.RecvBufHeadExtra[3] = []uint32{6}
+
+			ie.RunInst("WAIT_WIDE, $2, NET_RECV_3, 2", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[2:4]).To(Equal([]uint32{5, 6}))
+			Expect(s.RecvBufHeadReady[3]).To(BeFalse())
+		})
+
+		It("should forward all the words", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 5
+			s.RecvBufHeadExtra[3] = []uint32{6}
+
+			ie.RunInst("FORWARD, NET_SEND_1, NET_RECV_3", &s)
+
+			Expect(s.SendBufHeadExtra[1]).To(Equal([]uint32{6}))
+		})
+
+		It("should panic if the message has another number of words", func() {
+			s.RecvBufHeadReady[3] = true
+
+			Expect(func() {
+				ie.RunInst("WAIT_WIDE, $2, NET_RECV_3, 2", &s)
+			}).To(PanicWith(
+				"WAIT_WIDE expects 2 words from NET_RECV_3, but the message has 1"))
+		})
+
+		It("should panic on registers out of the register file", func() {
+			Expect(func() {
+				ie.RunInst("SEND_WIDE, NET_SEND_1, $3, 2", &s)
+			}).To(PanicWith("registers $3 to $4 are out of the 4 registers"))
+		})
+	})
+
+	Context("when running DATA_MOV", func() {
+		It("should wait for data and a free send buffer", func() {
+			s.SendBufHeadBusy[1] = true
+			s.RecvBufHeadReady[3] = true
+
+			ie.RunInst("DATA_MOV, NET_SEND_1, NET_RECV_3", &s)
+			Expect(s.PC).To(Equal(uint32(0)))
+
+			s.SendBufHeadBusy[1] = false
+			s.RecvBufHeadReady[3] = false
+
+			ie.RunInst("DATA_MOV, NET_SEND_1, NET_RECV_3", &s)
+			Expect(s.PC).To(Equal(uint32(0)))
+		})
+
+		It("should keep the validity of the received data", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 6
+			s.RecvBufHeadInvalid[3] = true
+
+			ie.RunInst("DATA_MOV, NET_SEND_1, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.RecvBufHeadReady[3]).To(BeFalse())
+			Expect(s.SendBufHead[1]).To(Equal(uint32(6)))
+			Expect(s.SendBufHeadInvalid[1]).To(BeTrue())
+		})
+
+		It("should not write invalid data to a register", func() {
+			s.Registers[0] = 1
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 6
+			s.RecvBufHeadInvalid[3] = true
+
+			ie.RunInst("DATA_MOV, $0, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[0]).To(Equal(uint32(1)))
+		})
+	})
+
+	Context("when running GRANT_PREDICATE", func() {
+		It("should wait for the predicate", func() {
+			s.RecvBufHeadReady[3] = true
+
+			ie.RunInst("GRANT_PREDICATE, NET_SEND_1, NET_RECV_3, NET_RECV_0", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+			Expect(s.RecvBufHeadReady[3]).To(BeTrue())
+		})
+
+		It("should invalidate the token if the predicate is false", func() {
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 6
+			s.RecvBufHeadReady[0] = true
+
+			ie.RunInst("GRANT_PREDICATE, NET_SEND_1, NET_RECV_3, NET_RECV_0", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.RecvBufHeadReady[0]).To(BeFalse())
+			Expect(s.RecvBufHeadReady[3]).To(BeFalse())
+			Expect(s.SendBufHead[1]).To(Equal(uint32(6)))
+			Expect(s.SendBufHeadInvalid[1]).To(BeTrue())
+		})
+
+		It("should invalidate the token if the predicate is invalid", func() {
+			s.RecvBufHeadReady[0] = true
+			s.RecvBufHead[0] = 1
+			s.RecvBufHeadInvalid[0] = true
+
+			ie.RunInst("GRANT_PREDICATE, NET_SEND_1, 6, NET_RECV_0", &s)
+
+			Expect(s.SendBufHeadInvalid[1]).To(BeTrue())
+		})
+
+		It("should grant the token if the predicate is true", func() {
+			s.RecvBufHeadReady[0] = true
+			s.RecvBufHead[0] = 1
+
+			ie.RunInst("GRANT_PREDICATE, NET_SEND_1, 6, NET_RECV_0", &s)
+
+			Expect(s.SendBufHead[1]).To(Equal(uint32(6)))
+			Expect(s.SendBufHeadInvalid[1]).To(BeFalse())
+		})
+	})
+
+	Context("when running STATE_RESET", func() {
+		It("should make GRANT_ONCE grant again when the block reruns", func() {
+			for _, value := range []uint32{1, 2} {
+				s.PC = 0
+				ie.RunInst(fmt.Sprintf("GRANT_ONCE, $0, %d", value), &s)
+			}
+
+			Expect(s.Registers[0]).To(Equal(uint32(1)))
+
+			ie.RunInst("STATE_RESET", &s)
+			Expect(s.PC).To(Equal(uint32(2)))
+
+			s.PC = 0
+			ie.RunInst("GRANT_ONCE, $0, 3", &s)
+
+			Expect(s.Registers[0]).To(Equal(uint32(3)))
+		})
+	})
+
+	Context("when running integer arithmetic", func() {
+		It("should add registers", func() {
+			s.Registers[0] = 3
+			s.Registers[1] = 4
+
+			ie.RunInst("I_ADD, $2, $0, $1", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[2]).To(Equal(uint32(7)))
+		})
+
+		It("should use immediate operands", func() {
+			s.Registers[0] = 3
+
+			ie.RunInst("I_SUB, $1, $0, 5", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(int32(s.Registers[1])).To(Equal(int32(-2)))
+		})
+	})
+	Context("when running REDUCE", func() {
+		It("should wait for all the sides in the mask", func() {
+			s.RecvBufHeadReady[1] = true
+			s.RecvBufHead[1] = 4
+
+			ie.RunInst("REDUCE_ADD, $0, 1, 0b1010", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+			Expect(s.RecvBufHeadReady[1]).To(BeTrue())
+		})
+
+		It("should combine the source with the sides in the mask", func() {
+			s.Registers[0] = 5
+			s.RecvBufHeadReady[0] = true
+			s.RecvBufHead[0] = 7
+			s.RecvBufHeadReady[1] = true
+			s.RecvBufHead[1] = 0xfffffffd
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 9
+
+			ie.RunInst("REDUCE_MIN, $2, $0, 0b1010", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(int32(s.Registers[2])).To(Equal(int32(-3)))
+			Expect(s.RecvBufHeadReady).To(Equal([]bool{true, false, false, false}))
+		})
+
+		It("should reject empty side masks", func() {
+			err := checkInst("REDUCE_MAX, $0, $0, 0", nil, nil)
+
+			Expect(err.msg).To(Equal("invalid side mask"))
+		})
+	})
+	Context("when accessing the local memory", func() {
+		It("should panic on addresses out of the memory", func() {
+			s.Memory = make([]uint32, 4)
+
+			Expect(func() { ie.RunInst("GATHER, $0, 4", &s) }).
+				To(PanicWith("address 4 is out of the local memory of 4 words"))
+		})
+
+		It("should panic on queues with corrupted headers", func() {
+			s.Memory = []uint32{3, 0, 2, 0, 0}
+
+			Expect(func() { ie.RunInst("DEQUEUE, $0, $1, 0", &s) }).
+				To(PanicWith("queue at address 0 has a corrupted header: " +
+					"head 3, 0 entries, capacity 2"))
+		})
+
+		It("should panic on queues that extend out of the memory", func() {
+			s.Memory = []uint32{0, 0, 0, 0, 2}
+
+			Expect(func() { ie.RunInst("ENQUEUE, $1, 4, 7", &s) }).
+				To(PanicWith("address 6 is out of the local memory of 5 words"))
+		})
+	})
+	Context("when running ENQUEUE and DEQUEUE", func() {
+		BeforeEach(func() {
+			s.Memory = []uint32{0, 0, 1, 0}
+		})
+
+		It("should wait for the token of a NET_RECV register", func() {
+			ie.RunInst("ENQUEUE, $1, 0, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 9
+			ie.RunInst("ENQUEUE, $1, 0, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[1]).To(Equal(uint32(1)))
+			Expect(s.Memory).To(Equal([]uint32{0, 1, 1, 9}))
+			Expect(s.RecvBufHeadReady[3]).To(BeFalse())
+		})
+
+		It("should leave the token if the queue is full", func() {
+			s.Memory = []uint32{0, 1, 1, 5}
+			s.RecvBufHeadReady[3] = true
+			s.RecvBufHead[3] = 9
+
+			ie.RunInst("ENQUEUE, $1, 0, NET_RECV_3", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[1]).To(Equal(uint32(0)))
+			Expect(s.Memory).To(Equal([]uint32{0, 1, 1, 5}))
+			Expect(s.RecvBufHeadReady[3]).To(BeTrue())
+		})
+
+		It("should wait for the NET_SEND register to be free", func() {
+			s.Memory = []uint32{0, 1, 1, 5}
+			s.SendBufHeadBusy[1] = true
+
+			ie.RunInst("DEQUEUE, NET_SEND_1, $1, 0", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+
+			s.SendBufHeadBusy[1] = false
+			ie.RunInst("DEQUEUE, NET_SEND_1, $1, 0", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.Registers[1]).To(Equal(uint32(1)))
+			Expect(s.SendBufHeadBusy[1]).To(BeTrue())
+			Expect(s.SendBufHead[1]).To(Equal(uint32(5)))
+			Expect(s.Memory[:3]).To(Equal([]uint32{0, 0, 1}))
+		})
+	})
+	Context("when running MEMCPY_TILE", func() {
+		BeforeEach(func() {
+			s.Memory = make([]uint32, 16)
+			for i := range s.Memory {
+				s.Memory[i] = uint32(i)
+			}
+
+			s.SendBufHeadCopy = make([]bool, 4)
+			s.SendBufHeadCopyAddr = make([]uint32, 4)
+		})
+
+		It("should send the block in wide messages", func() {
+			ie.RunInst("MEMCPY_TILE, NET_SEND_1, 100, 2, 10", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+			Expect(s.SendBufHeadBusy[1]).To(BeTrue())
+			Expect(s.SendBufHead[1]).To(Equal(uint32(2)))
+			Expect(s.SendBufHeadExtra[1]).To(Equal([]uint32{3, 4, 5, 6, 7, 8, 9}))
+			Expect(s.SendBufHeadCopy[1]).To(BeTrue())
+			Expect(s.SendBufHeadCopyAddr[1]).To(Equal(uint32(100)))
+
+			ie.RunInst("MEMCPY_TILE, NET_SEND_1, 100, 2, 10", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+
+			s.SendBufHeadBusy[1] = false
+			ie.RunInst("MEMCPY_TILE, NET_SEND_1, 100, 2, 10", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.CopySent).To(Equal(uint32(0)))
+			Expect(s.SendBufHead[1]).To(Equal(uint32(10)))
+			Expect(s.SendBufHeadExtra[1]).To(Equal([]uint32{11}))
+			Expect(s.SendBufHeadCopyAddr[1]).To(Equal(uint32(108)))
+		})
+
+		It("should panic on blocks out of the local memory", func() {
+			Expect(func() {
+				ie.RunInst("MEMCPY_TILE, NET_SEND_1, 0, 12, 8", &s)
+			}).To(PanicWith("address 19 is out of the local memory of 16 words"))
+		})
+	})
+	Context("when running BARRIER", func() {
+		var (
+			barrier *Barrier
+			other   coreState
+		)
+
+		BeforeEach(func() {
+			barrier = NewBarrier()
+			barrier.join("0")
+			barrier.join("0")
+
+			s.Barrier = barrier
+			other = coreState{Barrier: barrier}
+		})
+
+		It("should stall until all the participants arrive", func() {
+			woken := false
+			s.BarrierWake = func() { woken = true }
+
+			ie.RunInst("BARRIER, 0", &s)
+
+			Expect(s.PC).To(Equal(uint32(0)))
+			Expect(s.AtBarrier).To(BeTrue())
+
+			ie.RunInst("BARRIER, 0", &other)
+
+			Expect(other.PC).To(Equal(uint32(1)))
+			Expect(woken).To(BeTrue())
+
+			ie.RunInst("BARRIER, 0", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.AtBarrier).To(BeFalse())
+		})
 	})
+	Context("when running RETURN_VALUE", func() {
+		It("should record the return value", func() {
+			s.Registers[1] = 42
+
+			ie.RunInst("RETURN_VALUE, $1", &s)
 
+			Expect(s.PC).To(Equal(uint32(1)))
+			Expect(s.HasRetVal).To(BeTrue())
+			Expect(s.RetVal).To(Equal(uint32(42)))
+		})
+	})
+	Context("when reading kernel arguments", func() {
+		It("should read the ARGn operands", func() {
+			s.Args = make([]uint32, NumKernelArgs)
+			s.Args[1] = 20
+
+			ie.RunInst("I_MUL, $0, ARG1, 3", &s)
+
+			Expect(s.Registers[0]).To(Equal(uint32(60)))
+		})
+
+		It("should reject out-of-range arguments", func() {
+			err := checkInst("I_ADD, $0, ARG8, 1", nil, nil)
+
+			Expect(err.msg).To(Equal("invalid kernel argument"))
+		})
+	})
+	Context("when decoding a program", func() {
+		It("should resolve the operands", func() {
+			ops := decodeProgram([]string{
+				"LOOP:",
+				"I_ADD, $3, ARG1, 0x10",
+				"I_CMP_LT, $1, $3, 4294967295",
+				"JEQ, LOOP, $1, 1",
+				"DONE,",
+			})
+
+			Expect(ops[0].label).To(BeTrue())
+			Expect(ops[1].operands).To(Equal([]operand{
+				{kind: operandReg, index: 3, text: "$3"},
+				{kind: operandArg, index: 1, text: "ARG1"},
+				{kind: operandImm, value: 16, text: "0x10"},
+			}))
+			Expect(ops[2].operands[2].value).To(Equal(uint32(0xffffffff)))
+			Expect(ops[3].operands[0].index).To(Equal(0))
+			Expect(ops[4].operands).To(BeEmpty())
+		})
+
+		It("should jump to the label with the exact name", func() {
+			s.Code = []string{"END_LOOP:", "END:", "JMP, END"}
+			s.PC = 2
+
+			ie.RunInst("JMP, END", &s)
+
+			Expect(s.PC).To(Equal(uint32(1)))
+		})
+	})
+	Context("when checking fixed-point opcodes", func() {
+		It("should reject invalid fixed-point opcodes", func() {
+			Expect(checkInst("FXMUL_Q31_SAT_RND, $0, $1, 1", nil, nil)).To(BeNil())
+
+			for _, opcode := range []string{
+				"FXADD_Q15_RND", "FXMUL_Q32", "FXMUL_Q", "FXMUL_Q15_RND_SAT",
+			} {
+				err := checkInst(opcode+", $0, $1, 1", nil, nil)
+				Expect(err.msg).To(Equal("unknown opcode"), opcode)
+			}
+		})
+	})
 })
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// fixedPointOp is a fixed-point opcode, e.g., FXMUL_Q15_SAT_RND. The values
+// are signed 32-bit numbers with frac fraction bits. Without SAT, results
+// that do not fit wrap around; with SAT, they saturate. Without RND, FXMUL
+// truncates toward negative infinity; with RND, it rounds to the nearest,
+// ties up.
+type fixedPointOp struct {
+	mul  bool
+	frac uint
+	sat  bool
+	rnd  bool
+}
+
+// parseFixedPoint parses the opcodes FXADD_Qn[_SAT] and FXMUL_Qn[_SAT][_RND],
+// where n is from 0 to 31.
+func parseFixedPoint(opcode string) (fixedPointOp, bool) {
+	op := fixedPointOp{}
+
+	switch {
+	case strings.HasPrefix(opcode, "FXADD_Q"):
+		opcode = strings.TrimPrefix(opcode, "FXADD_Q")
+	case strings.HasPrefix(opcode, "FXMUL_Q"):
+		opcode = strings.TrimPrefix(opcode, "FXMUL_Q")
+		op.mul = true
+	default:
+		return op, false
+	}
+
+	if op.mul && strings.HasSuffix(opcode, "_RND") {
+		opcode = strings.TrimSuffix(opcode, "_RND")
+		op.rnd = true
+	}
+
+	if strings.HasSuffix(opcode, "_SAT") {
+		opcode = strings.TrimSuffix(opcode, "_SAT")
+		op.sat = true
+	}
+
+	frac, err := strconv.ParseUint(opcode, 10, 8)
+	if err != nil || frac > 31 || strings.HasPrefix(opcode, "+") {
+		return op, false
+	}
+
+	op.frac = uint(frac)
+
+	return op, true
+}
+
+func (op fixedPointOp) run(a, b uint32) uint32 {
+	var r int64
+	if op.mul {
+		r = int64(int32(a)) * int64(int32(b))
+		if op.rnd && op.frac > 0 {
+			r += 1 << (op.frac - 1)
+		}
+
+		r >>= op.frac
+	} else {
+		r = int64(int32(a)) + int64(int32(b))
+	}
+
+	if op.sat {
+		if r > math.MaxInt32 {
+			r = math.MaxInt32
+		} else if r < math.MinInt32 {
+			r = math.MinInt32
+		}
+	}
+
+	return uint32(int32(r))
+}
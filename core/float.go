@@ -0,0 +1,449 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// RoundingMode is the IEEE 754 rounding direction of the float32 operations.
+type RoundingMode int
+
+// The rounding modes. RoundNearestEven is the default of IEEE 754 and of C.
+const (
+	RoundNearestEven RoundingMode = iota
+	RoundTowardZero
+	RoundUp
+	RoundDown
+)
+
+var roundingModeNames = []string{"rne", "rtz", "rup", "rdn"}
+
+// String returns the short name of the rounding mode, e.g., "rtz".
+func (m RoundingMode) String() string {
+	if m < 0 || int(m) >= len(roundingModeNames) {
+		return fmt.Sprintf("RoundingMode(%d)", int(m))
+	}
+
+	return roundingModeNames[m]
+}
+
+// DenormalMode is how the float32 operations treat denormal (subnormal)
+// numbers.
+type DenormalMode int
+
+// The denormal modes. DenormalsFlushOutputs flushes the results that are
+// denormal after rounding to zero of the same sign, as FTZ does on x86, and
+// DenormalsFlushInputs reads denormal operands as zero, as DAZ does.
+const (
+	DenormalsPreserve DenormalMode = iota
+	DenormalsFlushOutputs
+	DenormalsFlushInputs
+	DenormalsFlushAll
+)
+
+var denormalModeNames = []string{"preserve", "ftz", "daz", "ftz_daz"}
+
+// String returns the short name of the denormal mode, e.g., "ftz".
+func (m DenormalMode) String() string {
+	if m < 0 || int(m) >= len(denormalModeNames) {
+		return fmt.Sprintf("DenormalMode(%d)", int(m))
+	}
+
+	return denormalModeNames[m]
+}
+
+// CanonicalNaN is the NaN that every float32 operation returns if its
+// result is NaN, whatever the NaN operands are, as in RISC-V. The result
+// does not depend on the host, which returns different NaNs on different
+// CPUs.
+const CanonicalNaN uint32 = 0x7fc00000
+
+// FloatMode configures the float32 operations of a core. The zero value is
+// the IEEE 754 default, round to nearest, ties to even, with denormals.
+type FloatMode struct {
+	Rounding  RoundingMode
+	Denormals DenormalMode
+}
+
+// ParseFloatMode returns the float mode with the rounding and the denormal
+// modes of the given short names. An empty name selects the default.
+func ParseFloatMode(rounding, denormals string) (FloatMode, error) {
+	m := FloatMode{}
+
+	if rounding != "" {
+		i := indexOf(roundingModeNames, rounding)
+		if i < 0 {
+			return m, fmt.Errorf("unknown rounding mode %q", rounding)
+		}
+
+		m.Rounding = RoundingMode(i)
+	}
+
+	if denormals != "" {
+		i := indexOf(denormalModeNames, denormals)
+		if i < 0 {
+			return m, fmt.Errorf("unknown denormal mode %q", denormals)
+		}
+
+		m.Denormals = DenormalMode(i)
+	}
+
+	return m, nil
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// floatFormat is a binary floating-point format of IEEE 754 that fits in
+// the low bits of a word.
+type floatFormat struct {
+	expBits, fracBits uint
+}
+
+// The formats of the float operations.
+var (
+	binary32 = floatFormat{expBits: 8, fracBits: 23}
+	binary16 = floatFormat{expBits: 5, fracBits: 10}
+	bfloat16 = floatFormat{expBits: 8, fracBits: 7}
+)
+
+func (f floatFormat) signBit() uint32 {
+	return 1 << (f.expBits + f.fracBits)
+}
+
+func (f floatFormat) mask(bits uint32) uint32 {
+	return bits & (f.signBit()<<1 - 1)
+}
+
+func (f floatFormat) bias() int {
+	return 1<<(f.expBits-1) - 1
+}
+
+func (f floatFormat) infBits() uint32 {
+	return (1<<f.expBits - 1) << f.fracBits
+}
+
+// nan returns the canonical NaN of the format, a quiet NaN with only the
+// top bit of the fraction set.
+func (f floatFormat) nan() uint32 {
+	return f.infBits() | 1<<(f.fracBits-1)
+}
+
+func (f floatFormat) expField(bits uint32) uint32 {
+	return bits >> f.fracBits & (1<<f.expBits - 1)
+}
+
+func (f floatFormat) fracField(bits uint32) uint32 {
+	return bits & (1<<f.fracBits - 1)
+}
+
+func (f floatFormat) isNaN(bits uint32) bool {
+	return f.expField(bits) == 1<<f.expBits-1 && f.fracField(bits) != 0
+}
+
+func (f floatFormat) isInf(bits uint32) bool {
+	return f.expField(bits) == 1<<f.expBits-1 && f.fracField(bits) == 0
+}
+
+func (f floatFormat) isZero(bits uint32) bool {
+	return bits&^f.signBit() == 0
+}
+
+// value returns the value of finite bits, which float64 holds exactly.
+func (f floatFormat) value(bits uint32) float64 {
+	exp := int(f.expField(bits))
+	frac := float64(f.fracField(bits))
+
+	v := math.Ldexp(frac, 1-f.bias()-int(f.fracBits))
+	if exp != 0 {
+		v = math.Ldexp(frac+math.Ldexp(1, int(f.fracBits)),
+			exp-f.bias()-int(f.fracBits))
+	}
+
+	if bits&f.signBit() != 0 {
+		return -v
+	}
+
+	return v
+}
+
+// flush returns zero of the same sign if the bits are a denormal.
+func (f floatFormat) flush(bits uint32) uint32 {
+	if f.expField(bits) == 0 {
+		return bits & f.signBit()
+	}
+
+	return bits
+}
+
+var floatArithFuncs = map[string]func(m FloatMode, a, b uint32) uint32{
+	"F32_ADD": FloatMode.add,
+	"F32_SUB": FloatMode.sub,
+	"F32_MUL": FloatMode.mul,
+
+	// The half-precision opcodes work on the low 16 bits of their sources
+	// and clear the high 16 bits of their destinations. F16 is the binary16
+	// format of IEEE 754 and BF16 is bfloat16, the top half of a float32.
+	"F16_ADD": func(m FloatMode, a, b uint32) uint32 {
+		return m.addIn(binary16, a, b)
+	},
+	"F16_SUB": func(m FloatMode, a, b uint32) uint32 {
+		return m.addIn(binary16, a, b^binary16.signBit())
+	},
+	"F16_MUL": func(m FloatMode, a, b uint32) uint32 {
+		return m.mulIn(binary16, a, b)
+	},
+	"BF16_ADD": func(m FloatMode, a, b uint32) uint32 {
+		return m.addIn(bfloat16, a, b)
+	},
+	"BF16_SUB": func(m FloatMode, a, b uint32) uint32 {
+		return m.addIn(bfloat16, a, b^bfloat16.signBit())
+	},
+	"BF16_MUL": func(m FloatMode, a, b uint32) uint32 {
+		return m.mulIn(bfloat16, a, b)
+	},
+}
+
+// add returns the float32 sum of the bits of a and b. The host computes the
+// sums that are rounded to nearest, ties to even.
+func (m FloatMode) add(a, b uint32) uint32 {
+	if m.Rounding != RoundNearestEven {
+		return m.addIn(binary32, a, b)
+	}
+
+	a, b = m.flushInput(binary32, a), m.flushInput(binary32, b)
+
+	return m.result(math.Float32frombits(a) + math.Float32frombits(b))
+}
+
+// sub returns the float32 difference of the bits of a and b.
+func (m FloatMode) sub(a, b uint32) uint32 {
+	return m.add(a, b^1<<31)
+}
+
+// mul returns the float32 product of the bits of a and b.
+func (m FloatMode) mul(a, b uint32) uint32 {
+	if m.Rounding != RoundNearestEven {
+		return m.mulIn(binary32, a, b)
+	}
+
+	a, b = m.flushInput(binary32, a), m.flushInput(binary32, b)
+
+	return m.result(math.Float32frombits(a) * math.Float32frombits(b))
+}
+
+// result returns the bits of a float32 result that the host has computed.
+func (m FloatMode) result(f float32) uint32 {
+	if f != f {
+		return CanonicalNaN
+	}
+
+	return m.flushOutput(binary32, math.Float32bits(f))
+}
+
+// exactPrec is the precision in bits that holds the exact sum or product of
+// any two finite numbers of the formats.
+const exactPrec = 300
+
+// addIn returns the sum of the bits of a and b in the format.
+func (m FloatMode) addIn(f floatFormat, a, b uint32) uint32 {
+	a, b = m.flushInput(f, f.mask(a)), m.flushInput(f, f.mask(b))
+
+	switch {
+	case f.isNaN(a) || f.isNaN(b) || f.isInf(a) && f.isInf(b) && a != b:
+		return f.nan()
+	case f.isInf(a):
+		return a
+	case f.isInf(b):
+		return b
+	}
+
+	sum := new(big.Float).SetPrec(exactPrec).
+		Add(big.NewFloat(f.value(a)), big.NewFloat(f.value(b)))
+	if sum.Sign() != 0 {
+		return m.flushOutput(f, m.round(f, sum))
+	}
+
+	// An exact zero is -0 if both operands are -0, or if the signs differ
+	// and the rounding is down.
+	if a&f.signBit() == b&f.signBit() {
+		return a
+	}
+
+	if m.Rounding == RoundDown {
+		return f.signBit()
+	}
+
+	return 0
+}
+
+// mulIn returns the product of the bits of a and b in the format.
+func (m FloatMode) mulIn(f floatFormat, a, b uint32) uint32 {
+	a, b = m.flushInput(f, f.mask(a)), m.flushInput(f, f.mask(b))
+	sign := (a ^ b) & f.signBit()
+
+	switch {
+	case f.isNaN(a) || f.isNaN(b),
+		f.isInf(a) && f.isZero(b), f.isZero(a) && f.isInf(b):
+		return f.nan()
+	case f.isInf(a) || f.isInf(b):
+		return sign | f.infBits()
+	case f.isZero(a) || f.isZero(b):
+		return sign
+	}
+
+	product := new(big.Float).SetPrec(exactPrec).
+		Mul(big.NewFloat(f.value(a)), big.NewFloat(f.value(b)))
+
+	return m.flushOutput(f, m.round(f, product))
+}
+
+// round rounds an exact non-zero finite value to the format in the
+// rounding mode of m.
+func (m FloatMode) round(f floatFormat, x *big.Float) uint32 {
+	neg := x.Sign() < 0
+	abs := new(big.Float).Abs(x)
+	p := int(f.fracBits) + 1
+
+	// The value is below 2^e, and the ulp is 2^(e-p), but no less than the
+	// ulp of the denormals.
+	e := abs.MantExp(nil)
+	if minExp := 2 - f.bias(); e < minExp {
+		e = minExp
+	}
+
+	ulps := new(big.Float).SetMantExp(abs, p-e)
+	n, acc := ulps.Int(nil)
+
+	if acc != big.Exact && m.roundsUp(neg, ulps, n) {
+		n.Add(n, big.NewInt(1))
+	}
+
+	mant := n.Uint64()
+	if mant == 1<<p {
+		mant >>= 1
+		e++
+	}
+
+	exp := 0
+	if mant >= 1<<(p-1) {
+		exp = e - 1 + f.bias()
+		mant -= 1 << (p - 1)
+	}
+
+	if exp >= 1<<f.expBits-1 {
+		return m.overflow(f, neg)
+	}
+
+	bits := uint32(exp)<<f.fracBits | uint32(mant)
+	if neg {
+		bits |= f.signBit()
+	}
+
+	return bits
+}
+
+// roundsUp returns true if the magnitude of an inexact value, in ulps, is
+// rounded up from n, the integer part, rather than truncated.
+func (m FloatMode) roundsUp(neg bool, ulps *big.Float, n *big.Int) bool {
+	switch m.Rounding {
+	case RoundTowardZero:
+		return false
+	case RoundUp:
+		return !neg
+	case RoundDown:
+		return neg
+	}
+
+	frac := new(big.Float).Sub(ulps, new(big.Float).SetInt(n))
+	c := frac.Cmp(big.NewFloat(0.5))
+
+	return c > 0 || c == 0 && n.Bit(0) == 1
+}
+
+// overflow returns the result of a value that is too large for the format,
+// which is infinity or the largest finite number, by the rounding mode.
+func (m FloatMode) overflow(f floatFormat, neg bool) uint32 {
+	inf := m.Rounding == RoundNearestEven ||
+		m.Rounding == RoundUp && !neg ||
+		m.Rounding == RoundDown && neg
+
+	bits := f.infBits() - 1
+	if inf {
+		bits = f.infBits()
+	}
+
+	if neg {
+		bits |= f.signBit()
+	}
+
+	return bits
+}
+
+func (m FloatMode) flushInput(f floatFormat, bits uint32) uint32 {
+	if m.Denormals == DenormalsFlushInputs || m.Denormals == DenormalsFlushAll {
+		return f.flush(bits)
+	}
+
+	return bits
+}
+
+func (m FloatMode) flushOutput(f floatFormat, bits uint32) uint32 {
+	if m.Denormals == DenormalsFlushOutputs || m.Denormals == DenormalsFlushAll {
+		return f.flush(bits)
+	}
+
+	return bits
+}
+
+// The classes of F32_CLASS, one bit each, as in the FCLASS.S instruction of
+// RISC-V.
+const (
+	classNegInf uint32 = 1 << iota
+	classNegNormal
+	classNegDenormal
+	classNegZero
+	classPosZero
+	classPosDenormal
+	classPosNormal
+	classPosInf
+	classSignalingNaN
+	classQuietNaN
+)
+
+// classify returns the class bit of the float32 bits.
+func classify(bits uint32) uint32 {
+	exp := bits >> 23 & 0xff
+	frac := bits & 0x7fffff
+
+	var pos, neg uint32
+
+	switch {
+	case exp == 0xff && frac&(1<<22) != 0:
+		return classQuietNaN
+	case exp == 0xff && frac != 0:
+		return classSignalingNaN
+	case exp == 0xff:
+		pos, neg = classPosInf, classNegInf
+	case exp != 0:
+		pos, neg = classPosNormal, classNegNormal
+	case frac != 0:
+		pos, neg = classPosDenormal, classNegDenormal
+	default:
+		pos, neg = classPosZero, classNegZero
+	}
+
+	if bits>>31 == 1 {
+		return neg
+	}
+
+	return pos
+}
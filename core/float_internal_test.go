@@ -0,0 +1,260 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// floatVector is a reference vector of a float32 operation, with the bits
+// of the operands and of the result, as a C program with the same rounding
+// and denormal modes computes them.
+type floatVector struct {
+	opcode string
+	a, b   uint32
+	mode   FloatMode
+	want   uint32
+}
+
+var (
+	rtz = FloatMode{Rounding: RoundTowardZero}
+	rup = FloatMode{Rounding: RoundUp}
+	rdn = FloatMode{Rounding: RoundDown}
+	ftz = FloatMode{Denormals: DenormalsFlushOutputs}
+	daz = FloatMode{Denormals: DenormalsFlushInputs}
+)
+
+var floatVectors = []floatVector{
+	// 1 + 2^-24 is a tie, and 1 + 1.5 * 2^-24 is above it.
+	{"F32_ADD", 0x3f800000, 0x33800000, FloatMode{}, 0x3f800000},
+	{"F32_ADD", 0x3f800000, 0x33c00000, FloatMode{}, 0x3f800001},
+	{"F32_ADD", 0x3f800000, 0x33c00000, rtz, 0x3f800000},
+	{"F32_ADD", 0x3f800000, 0x33800000, rup, 0x3f800001},
+	{"F32_ADD", 0x3f800000, 0x33800000, rdn, 0x3f800000},
+	{"F32_ADD", 0xbf800000, 0xb3800000, rdn, 0xbf800001},
+	{"F32_ADD", 0xbf800000, 0xb3800000, rup, 0xbf800000},
+	{"F32_ADD", 0x3f800001, 0x33800000, FloatMode{}, 0x3f800002},
+	{"F32_ADD", 0x7f7fffff, 0x7f7fffff, FloatMode{}, 0x7f800000},
+	{"F32_ADD", 0x7f7fffff, 0x7f7fffff, rtz, 0x7f7fffff},
+	{"F32_ADD", 0x7f7fffff, 0x7f7fffff, rdn, 0x7f7fffff},
+	{"F32_ADD", 0xff7fffff, 0xff7fffff, rdn, 0xff800000},
+	{"F32_ADD", 0xff7fffff, 0xff7fffff, rup, 0xff7fffff},
+	{"F32_ADD", 0x00000001, 0x00000001, FloatMode{}, 0x00000002},
+	{"F32_ADD", 0x00000001, 0x00000001, ftz, 0x00000000},
+	{"F32_ADD", 0x00000001, 0x3f800000, daz, 0x3f800000},
+	{"F32_ADD", 0x00000001, 0x3f800000, rup, 0x3f800001},
+	{"F32_ADD", 0x807fffff, 0x00000001, FloatMode{}, 0x807ffffe},
+	{"F32_ADD", 0x3f800000, 0xbf800000, FloatMode{}, 0x00000000},
+	{"F32_ADD", 0x3f800000, 0xbf800000, rdn, 0x80000000},
+	{"F32_ADD", 0x80000000, 0x80000000, FloatMode{}, 0x80000000},
+	{"F32_ADD", 0x00000000, 0x00000000, rdn, 0x00000000},
+	{"F32_ADD", 0x7f800000, 0xff800000, FloatMode{}, CanonicalNaN},
+	{"F32_ADD", 0x7f800001, 0x3f800000, FloatMode{}, CanonicalNaN},
+	{"F32_ADD", 0xffc00123, 0x3f800000, rtz, CanonicalNaN},
+	{"F32_ADD", 0x7f800000, 0x3f800000, rdn, 0x7f800000},
+	{"F32_SUB", 0x3f800000, 0x3f800000, FloatMode{}, 0x00000000},
+	{"F32_SUB", 0x3f800000, 0x3f800000, rdn, 0x80000000},
+	{"F32_SUB", 0x3f800000, 0x33000000, rtz, 0x3f7fffff},
+	{"F32_SUB", 0x3f800000, 0x33000000, FloatMode{}, 0x3f800000},
+	{"F32_SUB", 0x00800000, 0x00000001, ftz, 0x00000000},
+	{"F32_SUB", 0x7f800000, 0x7f800000, FloatMode{}, CanonicalNaN},
+
+	// 0.1f * 3 and (1 + 2^-23)^2, which is 1 + 2^-22 + 2^-46.
+	{"F32_MUL", 0x3dcccccd, 0x40400000, FloatMode{}, 0x3e99999a},
+	{"F32_MUL", 0x3dcccccd, 0x40400000, rtz, 0x3e999999},
+	{"F32_MUL", 0x3f800001, 0x3f800001, FloatMode{}, 0x3f800002},
+	{"F32_MUL", 0x3f800001, 0x3f800001, rup, 0x3f800003},
+	{"F32_MUL", 0xbf800001, 0x3f800001, rdn, 0xbf800003},
+	{"F32_MUL", 0xbf800001, 0x3f800001, rup, 0xbf800002},
+	{"F32_MUL", 0x00800000, 0x3f000000, FloatMode{}, 0x00400000},
+	{"F32_MUL", 0x00800000, 0x3f000000, ftz, 0x00000000},
+	{"F32_MUL", 0x80800000, 0x3f000000, ftz, 0x80000000},
+	{"F32_MUL", 0x00000001, 0x3f000000, FloatMode{}, 0x00000000},
+	{"F32_MUL", 0x00000003, 0x3f000000, FloatMode{}, 0x00000002},
+	{"F32_MUL", 0x00000001, 0x3f000000, rup, 0x00000001},
+	{"F32_MUL", 0x00400000, 0x40000000, daz, 0x00000000},
+	{"F32_MUL", 0x00400000, 0x40000000, FloatMode{}, 0x00800000},
+	{"F32_MUL", 0x7f000000, 0x40000000, FloatMode{}, 0x7f800000},
+	{"F32_MUL", 0x7f000000, 0x40000000, rtz, 0x7f7fffff},
+	{"F32_MUL", 0x80000000, 0x3f800000, rdn, 0x80000000},
+	{"F32_MUL", 0x00000000, 0x7f800000, FloatMode{}, CanonicalNaN},
+	{"F32_MUL", 0x7fa00000, 0x00000000, FloatMode{}, CanonicalNaN},
+
+	// 1 + 2^-11 is a tie in binary16, and 1 + 2^-8 in bfloat16.
+	{"F16_ADD", 0x3c00, 0x1000, FloatMode{}, 0x3c00},
+	{"F16_ADD", 0x3c00, 0x1000, rup, 0x3c01},
+	{"F16_ADD", 0x3c01, 0x1000, FloatMode{}, 0x3c02},
+	{"F16_ADD", 0xabcd3c00, 0x3c00, FloatMode{}, 0x4000},
+	{"F16_ADD", 0x7bff, 0x7bff, FloatMode{}, 0x7c00},
+	{"F16_ADD", 0x7bff, 0x7bff, rtz, 0x7bff},
+	{"F16_ADD", 0x7c00, 0xfc00, FloatMode{}, 0x7e00},
+	{"F16_SUB", 0x3c00, 0x3c00, rdn, 0x8000},
+	{"F16_SUB", 0x3c00, 0x4000, FloatMode{}, 0xbc00},
+	{"F16_MUL", 0x0001, 0x3800, FloatMode{}, 0x0000},
+	{"F16_MUL", 0x0001, 0x3800, rup, 0x0001},
+	{"F16_MUL", 0x0400, 0x3800, FloatMode{}, 0x0200},
+	{"F16_MUL", 0x0400, 0x3800, ftz, 0x0000},
+	{"F16_MUL", 0x8000, 0x7c00, FloatMode{}, 0x7e00},
+	{"F16_MUL", 0x5bff, 0x5bff, FloatMode{}, 0x7bfe},
+	{"F16_MUL", 0x5c00, 0x5c00, FloatMode{}, 0x7c00},
+	{"BF16_ADD", 0x3f80, 0x3b80, FloatMode{}, 0x3f80},
+	{"BF16_ADD", 0x3f80, 0x3b80, rup, 0x3f81},
+	{"BF16_SUB", 0x3f80, 0x4000, FloatMode{}, 0xbf80},
+	{"BF16_MUL", 0x7f7f, 0x4000, FloatMode{}, 0x7f80},
+	{"BF16_MUL", 0x7f7f, 0x4000, rdn, 0x7f7f},
+	{"BF16_MUL", 0x0080, 0x3f00, daz, 0x0040},
+	{"BF16_MUL", 0x0040, 0x4000, daz, 0x0000},
+}
+
+// convertVectors are the reference vectors of the conversion opcodes.
+var convertVectors = []struct {
+	opcode string
+	a      uint32
+	mode   FloatMode
+	want   uint32
+}{
+	{"F32_TO_F16", 0x3f800000, FloatMode{}, 0x3c00},
+	{"F32_TO_F16", 0x477fef00, FloatMode{}, 0x7bff},
+	{"F32_TO_F16", 0x477ff000, FloatMode{}, 0x7c00},
+	{"F32_TO_F16", 0x477ff000, rtz, 0x7bff},
+	{"F32_TO_F16", 0x33000000, FloatMode{}, 0x0000},
+	{"F32_TO_F16", 0x33400000, FloatMode{}, 0x0001},
+	{"F32_TO_F16", 0x33400000, ftz, 0x0000},
+	{"F32_TO_F16", 0x80000000, FloatMode{}, 0x8000},
+	{"F32_TO_F16", 0xff800000, FloatMode{}, 0xfc00},
+	{"F32_TO_F16", 0x7f800001, FloatMode{}, 0x7e00},
+	{"F16_TO_F32", 0x0001, FloatMode{}, 0x33800000},
+	{"F16_TO_F32", 0x0001, daz, 0x00000000},
+	{"F16_TO_F32", 0xffffc000, FloatMode{}, 0xc0000000},
+	{"F16_TO_F32", 0x7c01, FloatMode{}, CanonicalNaN},
+	{"F32_TO_BF16", 0x3f808000, FloatMode{}, 0x3f80},
+	{"F32_TO_BF16", 0x3f818000, FloatMode{}, 0x3f82},
+	{"F32_TO_BF16", 0x3f808001, FloatMode{}, 0x3f81},
+	{"F32_TO_BF16", 0x3f80ffff, rtz, 0x3f80},
+	{"F32_TO_BF16", 0x7f7fffff, FloatMode{}, 0x7f80},
+	{"F32_TO_BF16", 0x00000001, FloatMode{}, 0x0000},
+	{"BF16_TO_F32", 0xff80, FloatMode{}, 0xff800000},
+	{"BF16_TO_F32", 0x0001, FloatMode{}, 0x00010000},
+}
+
+var _ = Describe("Float operations", func() {
+	It("should match the reference vectors bit by bit", func() {
+		for _, v := range floatVectors {
+			got := floatArithFuncs[v.opcode](v.mode, v.a, v.b)
+
+			Expect(got).To(Equal(v.want), "%s %08x %08x in %s/%s = %08x",
+				v.opcode, v.a, v.b, v.mode.Rounding, v.mode.Denormals, got)
+		}
+	})
+
+	It("should convert between the formats", func() {
+		for _, v := range convertVectors {
+			f := floatConversions[v.opcode]
+			got := v.mode.convert(f[0], f[1], v.a)
+
+			Expect(got).To(Equal(v.want), "%s %08x in %s/%s = %08x",
+				v.opcode, v.a, v.mode.Rounding, v.mode.Denormals, got)
+		}
+	})
+
+	It("should encode and decode half-precision values", func() {
+		Expect(EncodeF16(1.5)).To(Equal(uint16(0x3e00)))
+		Expect(DecodeF16(0x3e00)).To(Equal(float32(1.5)))
+		Expect(EncodeBF16(3.14159)).To(Equal(uint16(0x4049)))
+		Expect(DecodeBF16(0x4049)).To(Equal(float32(3.140625)))
+	})
+
+	It("should round in the directed modes around the host", func() {
+		// The host rounds to nearest, so its result is either the one that
+		// is rounded down or the one that is rounded up, and rounding
+		// toward zero picks the one that is closer to zero.
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 20000; i++ {
+			a, b := randomFloat(r), randomFloat(r)
+			for _, opcode := range []string{"F32_ADD", "F32_MUL"} {
+				want := hostResult(opcode, a, b)
+				down := floatArithFuncs[opcode](rdn, a, b)
+				up := floatArithFuncs[opcode](rup, a, b)
+
+				Expect(want == down || want == up).To(BeTrue(),
+					"%s %08x %08x", opcode, a, b)
+				Expect(floatArithFuncs[opcode](rtz, a, b)).To(Equal(
+					towardZero(down, up)), "%s %08x %08x", opcode, a, b)
+			}
+		}
+	})
+
+	It("should classify float32 values", func() {
+		classes := map[uint32]uint32{
+			0xff800000: classNegInf,
+			0xbf800000: classNegNormal,
+			0x80000001: classNegDenormal,
+			0x80000000: classNegZero,
+			0x00000000: classPosZero,
+			0x007fffff: classPosDenormal,
+			0x7f7fffff: classPosNormal,
+			0x7f800000: classPosInf,
+			0x7f800001: classSignalingNaN,
+			0xffc00000: classQuietNaN,
+		}
+
+		for bits, class := range classes {
+			Expect(classify(bits)).To(Equal(class), "%08x", bits)
+		}
+	})
+
+	It("should parse the float modes", func() {
+		m, err := ParseFloatMode("rdn", "ftz_daz")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(Equal(FloatMode{
+			Rounding:  RoundDown,
+			Denormals: DenormalsFlushAll,
+		}))
+		Expect(ParseFloatMode("", "")).To(Equal(FloatMode{}))
+
+		_, err = ParseFloatMode("nearest", "")
+		Expect(err).To(MatchError("unknown rounding mode \"nearest\""))
+	})
+})
+
+// hostResult computes an operation with the float32 arithmetic of Go.
+func hostResult(opcode string, a, b uint32) uint32 {
+	fa, fb := math.Float32frombits(a), math.Float32frombits(b)
+	if opcode == "F32_MUL" {
+		return math.Float32bits(fa * fb)
+	}
+
+	return math.Float32bits(fa + fb)
+}
+
+// randomFloat returns finite float32 bits, with many denormals and values
+// near 1.
+func randomFloat(r *rand.Rand) uint32 {
+	switch r.Intn(4) {
+	case 0:
+		return r.Uint32() & 0x807fffff
+	case 1:
+		return math.Float32bits(float32(r.NormFloat64()))
+	default:
+		bits := r.Uint32()
+		if bits&0x7f800000 == 0x7f800000 {
+			bits &^= 0x40000000
+		}
+
+		return bits
+	}
+}
+
+// towardZero returns the one of the results rounded down and up that is
+// closer to zero.
+func towardZero(down, up uint32) uint32 {
+	if down>>31 == 1 {
+		return up
+	}
+
+	return down
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FoldReport is the number of instructions of the program of a PE before
+// and after FoldConstants.
+type FoldReport struct {
+	Before, After int
+}
+
+// FoldConstants folds the instructions whose sources are all constants,
+// e.g., an I_ADD of two immediate values, and propagates their results as
+// immediate values into the instructions that read them, which can then be
+// folded as well. The instructions whose results are no longer read are
+// removed as OptimizeProgram does. The constants are only propagated within
+// a run of lines without labels, since a jump can land on a label with other
+// values in the registers. The float operations are evaluated in the float
+// mode of the device that runs the programs. The programs are keyed by the
+// [x, y] coordinate of the PE, and so is the report.
+func FoldConstants(
+	programs map[[2]int]string,
+	mode FloatMode,
+) (map[[2]int]string, map[[2]int]FoldReport) {
+	folded := make(map[[2]int]string, len(programs))
+	report := make(map[[2]int]FoldReport, len(programs))
+
+	for coord, program := range programs {
+		lines := propagateConstants(strings.Split(program, "\n"), mode)
+		folded[coord] = OptimizeProgram(strings.Join(lines, "\n")).Program
+		report[coord] = FoldReport{
+			Before: countInsts(program),
+			After:  countInsts(folded[coord]),
+		}
+	}
+
+	return folded, report
+}
+
+func countInsts(program string) int {
+	n := 0
+
+	for _, line := range strings.Split(program, "\n") {
+		if Opcode(line) != "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// propagateConstants replaces the registers that hold known constants with
+// the constants.
+func propagateConstants(lines []string, mode FloatMode) []string {
+	canonical := CanonicalizeOpcodes(lines, DefaultOpcodeAliases)
+	labels := labelsOf(canonical)
+	out := make([]string, len(lines))
+	known := make(map[int]uint32)
+
+	for i, line := range lines {
+		out[i] = line
+
+		if isLabel(line) {
+			known = make(map[int]uint32)
+			continue
+		}
+
+		if Opcode(line) == "" {
+			continue
+		}
+
+		if checkInst(canonical[i], labels, nil) != nil {
+			known = make(map[int]uint32)
+			continue
+		}
+
+		out[i] = substituteConstants(line, canonical[i], known)
+		reg, value, isConst := evalConstant(
+			substituteConstants(canonical[i], canonical[i], known), mode)
+
+		for _, w := range registerWrites(canonical[i]) {
+			delete(known, w)
+		}
+
+		if isConst {
+			known[reg] = value
+		}
+	}
+
+	return out
+}
+
+// substituteConstants replaces the source registers of the line that hold
+// known constants. The kinds of the operands come from the canonical form of
+// the line.
+func substituteConstants(
+	line, canonical string,
+	known map[int]uint32,
+) string {
+	kinds, _ := operandsOf(Opcode(canonical))
+	tokens := splitInst(line)
+	changed := false
+
+	for i, kind := range kinds {
+		if i+1 >= len(tokens) || (kind != operandSrc && kind != operandIn) {
+			continue
+		}
+
+		reg, ok := registerIndex(tokens[i+1])
+		if value, isKnown := known[reg]; ok && isKnown {
+			tokens[i+1] = fmt.Sprint(value)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return line
+	}
+
+	return strings.Join(tokens, ", ") + sourceSuffix(line)
+}
+
+// evalConstant returns the register that the line writes and the value, if
+// the line is a pure instruction whose sources are all immediate values. The
+// float operations round and flush denormals as the float mode does.
+func evalConstant(line string, mode FloatMode) (int, uint32, bool) {
+	opcode := Opcode(line)
+	if !pureOpcodes[opcode] && !isCmpOrFixed(opcode) {
+		return 0, 0, false
+	}
+
+	op := decodeInst(line, nil)
+	values := make([]uint32, len(op.operands))
+
+	for i, o := range op.operands[1:] {
+		if o.kind == operandReg || o.kind == operandArg ||
+			o.kind == operandRecv {
+			return 0, 0, false
+		}
+
+		values[i+1] = o.value
+	}
+
+	if op.operands[0].kind != operandReg {
+		return 0, 0, false
+	}
+
+	dst := op.operands[0].index
+
+	if f, ok := floatArithFuncs[opcode]; ok {
+		return dst, f(mode, values[1], values[2]), true
+	}
+
+	switch {
+	case op.arith != nil && len(values) == 3:
+		return dst, op.arith(values[1], values[2]), true
+	case op.cmp != nil:
+		a, b := values[1], values[2]
+		if strings.HasPrefix(opcode, "F32_") {
+			a, b = mode.flushInput(binary32, a), mode.flushInput(binary32, b)
+		}
+
+		return dst, boolWord(op.cmp(a, b)), true
+	case opcode == "SEL":
+		if values[1] != 0 {
+			return dst, values[2], true
+		}
+
+		return dst, values[3], true
+	case opcode == "DATA_MOV" || opcode == "GRANT_ALWAYS":
+		return dst, values[1], true
+	default:
+		return 0, 0, false
+	}
+}
+
+func boolWord(b bool) uint32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func registerIndex(operand string) (int, bool) {
+	if !strings.HasPrefix(operand, "$") {
+		return 0, false
+	}
+
+	reg, err := strconv.Atoi(operand[1:])
+
+	return reg, err == nil
+}
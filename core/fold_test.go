@@ -0,0 +1,78 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+)
+
+var _ = Describe("FoldConstants", func() {
+	It("should fold constant expressions into their readers", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "START:\n" +
+				"I_ADD, $1, 2, 3\n" +
+				"WAIT, $0, NET_RECV_3\n" +
+				"GEP, $2, $0, $1\n" +
+				"I_MUL, $3, $1, 4\n" +
+				"I_CMP_LT, $4, $3, 10\n" +
+				"SEND, NET_SEND_1, $2\n" +
+				"SEND, NET_SEND_2, $3\n" +
+				"SEND, NET_SEND_0, $4\n" +
+				"JMP, START",
+		}
+
+		folded, report := core.FoldConstants(programs, core.FloatMode{})
+
+		Expect(folded[[2]int{0, 0}]).To(Equal("START:\n" +
+			"WAIT, $0, NET_RECV_3\n" +
+			"GEP, $2, $0, 5\n" +
+			"SEND, NET_SEND_1, $2\n" +
+			"SEND, NET_SEND_2, 20\n" +
+			"SEND, NET_SEND_0, 0\n" +
+			"JMP, START"))
+		Expect(report[[2]int{0, 0}]).To(Equal(
+			core.FoldReport{Before: 9, After: 6}))
+	})
+
+	It("should not propagate constants past labels", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "I_ADD, $1, 0, 0\n" +
+				"LOOP:\n" +
+				"SEND, NET_SEND_1, $1\n" +
+				"I_ADD, $1, $1, 1\n" +
+				"JMP, LOOP",
+		}
+
+		folded, report := core.FoldConstants(programs, core.FloatMode{})
+
+		Expect(folded).To(Equal(programs))
+		Expect(report[[2]int{0, 0}]).To(Equal(
+			core.FoldReport{Before: 4, After: 4}))
+	})
+
+	It("should fold the float operations in the float mode", func() {
+		// 1 plus three quarters of its ulp rounds up to the next float in
+		// the default mode, but toward zero to 1.
+		programs := map[[2]int]string{
+			{0, 0}: "F32_ADD, $0, 1065353216, 868220928\n" +
+				"F32_CMP_EQ, $1, 1, 0\n" +
+				"SEND, NET_SEND_1, $0\n" +
+				"SEND, NET_SEND_2, $1",
+		}
+
+		folded, _ := core.FoldConstants(programs, core.FloatMode{})
+
+		Expect(folded[[2]int{0, 0}]).To(Equal(
+			"SEND, NET_SEND_1, 1065353217\n" +
+				"SEND, NET_SEND_2, 0"))
+
+		folded, _ = core.FoldConstants(programs, core.FloatMode{
+			Rounding:  core.RoundTowardZero,
+			Denormals: core.DenormalsFlushInputs,
+		})
+
+		Expect(folded[[2]int{0, 0}]).To(Equal(
+			"SEND, NET_SEND_1, 1065353216\n" +
+				"SEND, NET_SEND_2, 1"))
+	})
+})
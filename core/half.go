@@ -0,0 +1,63 @@
+package core
+
+import (
+	"math"
+	"math/big"
+)
+
+// floatConversions are the formats that the conversion opcodes convert
+// from and to.
+var floatConversions = map[string][2]floatFormat{
+	"F32_TO_F16":  {binary32, binary16},
+	"F16_TO_F32":  {binary16, binary32},
+	"F32_TO_BF16": {binary32, bfloat16},
+	"BF16_TO_F32": {bfloat16, binary32},
+}
+
+// convert converts the bits of a number from one format to another, and
+// rounds the number if the other format is narrower.
+func (m FloatMode) convert(from, to floatFormat, bits uint32) uint32 {
+	bits = m.flushInput(from, from.mask(bits))
+
+	sign := uint32(0)
+	if bits&from.signBit() != 0 {
+		sign = to.signBit()
+	}
+
+	switch {
+	case from.isNaN(bits):
+		return to.nan()
+	case from.isInf(bits):
+		return sign | to.infBits()
+	case from.isZero(bits):
+		return sign
+	}
+
+	x := new(big.Float).SetFloat64(from.value(bits))
+
+	return m.flushOutput(to, m.round(to, x))
+}
+
+// EncodeF16 returns the binary16 bits of the value, rounded to nearest,
+// ties to even, as F32_TO_F16 does in the default float mode.
+func EncodeF16(v float32) uint16 {
+	return uint16(FloatMode{}.convert(binary32, binary16, math.Float32bits(v)))
+}
+
+// DecodeF16 returns the value of binary16 bits.
+func DecodeF16(bits uint16) float32 {
+	return math.Float32frombits(
+		FloatMode{}.convert(binary16, binary32, uint32(bits)))
+}
+
+// EncodeBF16 returns the bfloat16 bits of the value, rounded to nearest,
+// ties to even, as F32_TO_BF16 does in the default float mode.
+func EncodeBF16(v float32) uint16 {
+	return uint16(FloatMode{}.convert(binary32, bfloat16, math.Float32bits(v)))
+}
+
+// DecodeBF16 returns the value of bfloat16 bits.
+func DecodeBF16(bits uint16) float32 {
+	return math.Float32frombits(
+		FloatMode{}.convert(bfloat16, binary32, uint32(bits)))
+}
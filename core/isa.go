@@ -0,0 +1,323 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type operandKind int
+
+const (
+	// operandReg is a register, e.g., $0.
+	operandReg operandKind = iota
+	// operandSrc is a register or an immediate value.
+	operandSrc
+	// operandImm is an immediate value.
+	operandImm
+	// operandRecv is a NET_RECV_N register.
+	operandRecv
+	// operandSend is a NET_SEND_N register.
+	operandSend
+	// operandLabel is the name of a label in the same program.
+	operandLabel
+	// operandID is any non-empty name.
+	operandID
+	// operandArg is a kernel argument, e.g., ARG0. Only decoded operands
+	// have this kind.
+	operandArg
+	// operandSides is a mask of the NET_RECV registers, where bit N selects
+	// NET_RECV_N, e.g., 0b1010.
+	operandSides
+	// operandDst is a register or a NET_SEND_N register. Decoded operands
+	// have the kind operandReg or operandSend instead.
+	operandDst
+	// operandIn is a register, an immediate value, or a NET_RECV_N register.
+	// Decoded operands have the kind of what they are instead.
+	operandIn
+	// operandRegIn is a register that is read, e.g., the first of the
+	// registers that SEND_WIDE sends. Decoded operands have the kind
+	// operandReg instead.
+	operandRegIn
+	// operandWords is the number of words of a wide message, from 1 to
+	// MaxMessageWords.
+	operandWords
+	// operandCounter is the name of a counter of the PE, e.g., CYCLES.
+	operandCounter
+)
+
+var instOperands = map[string][]operandKind{
+	"WAIT":         {operandReg, operandRecv},
+	"SEND":         {operandSend, operandSrc},
+	"SEND_PRED":    {operandSend, operandSrc, operandSrc},
+	"FORWARD":      {operandSend, operandRecv},
+	"JMP":          {operandLabel},
+	"JEQ":          {operandLabel, operandSrc, operandImm},
+	"I_ADD":        {operandReg, operandSrc, operandSrc},
+	"I_SUB":        {operandReg, operandSrc, operandSrc},
+	"I_MUL":        {operandReg, operandSrc, operandSrc},
+	"PACK":         {operandReg, operandSrc, operandSrc},
+	"UNPACK":       {operandReg, operandReg, operandSrc},
+	"RAND":         {operandReg},
+	"GATHER":       {operandReg, operandSrc},
+	"SCATTER":      {operandSrc, operandSrc},
+	"SCATTER_ADD":  {operandSrc, operandSrc},
+	"ENQUEUE":      {operandReg, operandSrc, operandIn},
+	"DEQUEUE":      {operandDst, operandReg, operandSrc},
+	"MEMCPY_TILE":  {operandSend, operandSrc, operandSrc, operandSrc},
+	"REDUCE_ADD":   {operandReg, operandSrc, operandSides},
+	"REDUCE_MIN":   {operandReg, operandSrc, operandSides},
+	"REDUCE_MAX":   {operandReg, operandSrc, operandSides},
+	"BARRIER":      {operandID},
+	"RETURN_VALUE": {operandSrc},
+	"SEND_WIDE":    {operandSend, operandRegIn, operandWords},
+	"WAIT_WIDE":    {operandReg, operandRecv, operandWords},
+	"DONE":         {},
+
+	// The opcodes that compilers emit for dataflow programs.
+	"DATA_MOV":        {operandDst, operandIn},
+	"GRANT_ALWAYS":    {operandDst, operandIn},
+	"GRANT_ONCE":      {operandDst, operandIn},
+	"GRANT_PREDICATE": {operandDst, operandIn, operandIn},
+	"STATE_RESET":     {},
+	"GEP":             {operandReg, operandSrc, operandSrc},
+	"SEL":             {operandReg, operandSrc, operandSrc, operandSrc},
+	"F32_ADD":         {operandReg, operandSrc, operandSrc},
+	"F32_SUB":         {operandReg, operandSrc, operandSrc},
+	"F32_MUL":         {operandReg, operandSrc, operandSrc},
+	"F32_CLASS":       {operandReg, operandSrc},
+	"F16_ADD":         {operandReg, operandSrc, operandSrc},
+	"F16_SUB":         {operandReg, operandSrc, operandSrc},
+	"F16_MUL":         {operandReg, operandSrc, operandSrc},
+	"BF16_ADD":        {operandReg, operandSrc, operandSrc},
+	"BF16_SUB":        {operandReg, operandSrc, operandSrc},
+	"BF16_MUL":        {operandReg, operandSrc, operandSrc},
+	"F32_TO_F16":      {operandReg, operandSrc},
+	"F16_TO_F32":      {operandReg, operandSrc},
+	"F32_TO_BF16":     {operandReg, operandSrc},
+	"BF16_TO_F32":     {operandReg, operandSrc},
+	"RDCOUNTER":       {operandReg, operandCounter},
+}
+
+var cmpConditions = []string{"EQ", "NE", "LT", "LE", "GT", "GE"}
+
+// The counters that RDCOUNTER reads, by the index in counterNames.
+const (
+	counterCycles = iota
+	counterOps
+	counterTokens
+	numCounters
+)
+
+var counterNames = []string{"CYCLES", "OPS", "TOKENS"}
+
+// operandsOf returns the kinds of the operands of an opcode.
+func operandsOf(opcode string) ([]operandKind, bool) {
+	for _, prefix := range []string{"I_CMP_", "F32_CMP_"} {
+		if !strings.HasPrefix(opcode, prefix) {
+			continue
+		}
+
+		cond := strings.TrimPrefix(opcode, prefix)
+		for _, c := range cmpConditions {
+			if cond == c {
+				return []operandKind{operandReg, operandSrc, operandImm}, true
+			}
+		}
+
+		return nil, false
+	}
+
+	if _, ok := parseFixedPoint(opcode); ok {
+		return []operandKind{operandReg, operandSrc, operandSrc}, true
+	}
+
+	kinds, ok := instOperands[opcode]
+
+	return kinds, ok
+}
+
+// instError reports a mistake in an instruction.
+type instError struct {
+	// column is the 1-based column of the offending token.
+	column int
+	token  string
+	msg    string
+}
+
+type instToken struct {
+	text   string
+	column int
+}
+
+// tokenizeInst splits an instruction like splitInst does, but also records
+// the column where each token starts. A trailing empty operand, as in
+// "DONE,", is dropped.
+func tokenizeInst(line string) []instToken {
+	tokens := []instToken{}
+	start := 0
+
+	for _, field := range strings.Split(StripSource(line), ",") {
+		trimmed := strings.TrimLeft(field, " \t")
+		column := start + len(field) - len(trimmed) + 1
+		tokens = append(tokens,
+			instToken{text: strings.TrimSpace(field), column: column})
+		start += len(field) + 1
+	}
+
+	if n := len(tokens); n > 1 && tokens[n-1].text == "" {
+		tokens = tokens[:n-1]
+	}
+
+	return tokens
+}
+
+// checkInst checks the syntax of a line of a program. Labels and empty lines
+// are always valid. The labels that JMP and JEQ refer to must be in labels.
+// The names of the constants can be used as immediate values.
+func checkInst(
+	line string,
+	labels map[string]bool,
+	constants map[string]uint32,
+) *instError {
+	if strings.TrimSpace(line) == "" || isLabel(line) {
+		return nil
+	}
+
+	if _, bad := parseSource(line); bad != "" {
+		return &instError{strings.Index(line, bad) + 1, bad,
+			"invalid source metadata"}
+	}
+
+	tokens := tokenizeInst(line)
+	opcode := tokens[0]
+
+	kinds, ok := operandsOf(opcode.text)
+	if !ok {
+		return &instError{opcode.column, opcode.text, "unknown opcode"}
+	}
+
+	operands := tokens[1:]
+	if len(operands) != len(kinds) {
+		return &instError{opcode.column, opcode.text, fmt.Sprintf(
+			"expects %d operands, got %d", len(kinds), len(operands))}
+	}
+
+	for i, kind := range kinds {
+		if _, ok := constants[operands[i].text]; ok &&
+			(kind == operandSrc || kind == operandImm) {
+			continue
+		}
+
+		msg := checkOperand(operands[i].text, kind, labels)
+		if msg != "" {
+			return &instError{operands[i].column, operands[i].text, msg}
+		}
+	}
+
+	return nil
+}
+
+func checkOperand(
+	operand string,
+	kind operandKind,
+	labels map[string]bool,
+) string {
+	switch kind {
+	case operandReg, operandRegIn:
+		return checkIndex(operand, "$", 1<<31, "invalid register")
+	case operandSrc:
+		if strings.HasPrefix(operand, "$") {
+			return checkIndex(operand, "$", 1<<31, "invalid register")
+		}
+
+		if strings.HasPrefix(operand, "ARG") {
+			return checkIndex(operand, "ARG", NumKernelArgs,
+				"invalid kernel argument")
+		}
+
+		return checkIndex(operand, "", 0, "invalid operand")
+	case operandImm:
+		return checkIndex(operand, "", 0, "invalid immediate")
+	case operandRecv:
+		return checkIndex(operand, "NET_RECV_", 4*MaxChannels,
+			"invalid receive register")
+	case operandSend:
+		return checkIndex(operand, "NET_SEND_", 4*MaxChannels,
+			"invalid send register")
+	case operandSides:
+		if operand == "0" || checkIndex(operand, "", 16, "") != "" {
+			return "invalid side mask"
+		}
+	case operandWords:
+		if operand == "0" ||
+			checkIndex(operand, "", MaxMessageWords+1, "") != "" {
+			return "invalid number of words"
+		}
+	case operandCounter:
+		if indexOf(counterNames, operand) < 0 {
+			return "unknown counter"
+		}
+	case operandDst, operandIn:
+		return checkOperand(operand, concreteKind(operand, kind), labels)
+	case operandLabel:
+		if !labels[operand] {
+			return "undefined label"
+		}
+	case operandID:
+		if operand == "" {
+			return "missing operand"
+		}
+	}
+
+	return ""
+}
+
+// concreteKind returns the kind that an operandDst or an operandIn operand
+// has, e.g., operandSend for NET_SEND_1. Other kinds are returned as is.
+func concreteKind(operand string, kind operandKind) operandKind {
+	switch {
+	case kind == operandDst && strings.HasPrefix(operand, "NET_SEND_"):
+		return operandSend
+	case kind == operandDst:
+		return operandReg
+	case kind == operandIn && strings.HasPrefix(operand, "NET_RECV_"):
+		return operandRecv
+	case kind == operandIn:
+		return operandSrc
+	default:
+		return kind
+	}
+}
+
+// checkIndex checks that the operand is the prefix followed by an integer.
+// If limit is positive, the integer must be in [0, limit).
+func checkIndex(operand, prefix string, limit int64, msg string) string {
+	if !strings.HasPrefix(operand, prefix) {
+		return msg
+	}
+
+	v, err := strconv.ParseInt(strings.TrimPrefix(operand, prefix), 0, 64)
+	if err != nil {
+		return msg
+	}
+
+	if limit > 0 && (v < 0 || v >= limit) {
+		return msg
+	}
+
+	return ""
+}
+
+// labelsOf returns the names of the labels in a program.
+func labelsOf(program []string) map[string]bool {
+	labels := make(map[string]bool)
+
+	for _, line := range program {
+		if isLabel(line) {
+			labels[strings.TrimSuffix(strings.TrimSpace(line), ":")] = true
+		}
+	}
+
+	return labels
+}
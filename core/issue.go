@@ -0,0 +1,248 @@
+package core
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// IssueLimits are the resources that a core has in each cycle. The
+// instructions that a static schedule puts in the same time step form a
+// group, which must fit the limits.
+type IssueLimits struct {
+	// Width is the number of instructions that the core issues per cycle.
+	// The default is 1.
+	Width int
+
+	// PortReads and PortWrites are the numbers of NET_RECV registers that
+	// the instructions of a cycle can read and NET_SEND registers that they
+	// can write. If 0, they are not limited.
+	PortReads, PortWrites int
+
+	// RegReads and RegWrites are the numbers of read and write ports of
+	// each bank of the register file, i.e., the distinct registers of the
+	// bank that the instructions of a cycle can read and write. If 0, they
+	// are not limited.
+	RegReads, RegWrites int
+
+	// RegBanks is the number of banks of the register file, among which
+	// the registers are interleaved, so that $i is in bank i % RegBanks.
+	// The default is 1.
+	RegBanks int
+
+	// Bypass forwards the result of an instruction to the instructions of
+	// the same cycle. Without it, an instruction can only read a register
+	// in the cycle after the one that writes it.
+	Bypass bool
+}
+
+func (l IssueLimits) width() int {
+	if l.Width <= 0 {
+		return 1
+	}
+
+	return l.Width
+}
+
+// Banks returns the number of banks of the register file.
+func (l IssueLimits) Banks() int {
+	if l.RegBanks <= 0 {
+		return 1
+	}
+
+	return l.RegBanks
+}
+
+// Validate returns an error if any limit is negative or the register file
+// has more banks than registers.
+func (l IssueLimits) Validate() error {
+	if l.Width < 0 || l.PortReads < 0 || l.PortWrites < 0 {
+		return fmt.Errorf("invalid issue limits: width %d, port reads %d, "+
+			"port writes %d", l.Width, l.PortReads, l.PortWrites)
+	}
+
+	if l.RegReads < 0 || l.RegWrites < 0 ||
+		l.RegBanks < 0 || l.RegBanks > numRegisters {
+		return fmt.Errorf("invalid issue limits: register reads %d, "+
+			"register writes %d, banks %d", l.RegReads, l.RegWrites,
+			l.RegBanks)
+	}
+
+	return nil
+}
+
+// BankConflicts describes the banks of the register file whose ports cannot
+// serve the registers that are read and written in a cycle.
+func (l IssueLimits) BankConflicts(reads, writes []int) []string {
+	return l.bankConflicts(regMask(reads), regMask(writes))
+}
+
+func (l IssueLimits) bankConflicts(reads, writes uint64) []string {
+	if l.RegReads == 0 && l.RegWrites == 0 {
+		return nil
+	}
+
+	conflicts := []string{}
+	banks := l.Banks()
+
+	for b := 0; b < banks; b++ {
+		mask := bankMask(b, banks)
+		r := bits.OnesCount64(reads & mask)
+		w := bits.OnesCount64(writes & mask)
+
+		if l.RegReads > 0 && r > l.RegReads {
+			conflicts = append(conflicts, fmt.Sprintf("%d registers of "+
+				"%s are read, but %d can be read per cycle", r,
+				bankName(b, banks), l.RegReads))
+		}
+
+		if l.RegWrites > 0 && w > l.RegWrites {
+			conflicts = append(conflicts, fmt.Sprintf("%d registers of "+
+				"%s are written, but %d can be written per cycle", w,
+				bankName(b, banks), l.RegWrites))
+		}
+	}
+
+	return conflicts
+}
+
+// bankMask returns the mask of the registers in the bank.
+func bankMask(bank, banks int) uint64 {
+	var mask uint64
+	for reg := bank; reg < numRegisters; reg += banks {
+		mask |= 1 << uint(reg)
+	}
+
+	return mask
+}
+
+func bankName(bank, banks int) string {
+	if banks == 1 {
+		return "the register file"
+	}
+
+	return fmt.Sprintf("bank %d", bank)
+}
+
+// issueSlots counts the resources that the instructions of the current
+// cycle have used.
+type issueSlots struct {
+	insts, reads, writes int
+
+	// read and written have a bit for each register that is read and
+	// written.
+	read, written uint64
+}
+
+// PortAccesses returns the number of NET_RECV registers that the
+// instruction on the line reads and of NET_SEND registers that it writes.
+func PortAccesses(line string) (reads, writes int) {
+	if Opcode(line) == "" {
+		return 0, 0
+	}
+
+	tokens := splitInst(strings.TrimSpace(line))
+
+	return portAccesses(tokens[0], tokens[1:])
+}
+
+func portAccesses(opcode string, operands []string) (reads, writes int) {
+	for i, o := range operands {
+		switch {
+		case strings.HasPrefix(o, "NET_RECV_"):
+			reads++
+		case strings.HasPrefix(o, "NET_SEND_"):
+			writes++
+		case i == 2 && strings.HasPrefix(opcode, "REDUCE_"):
+			mask, err := strconv.ParseUint(o, 0, 64)
+			if err == nil {
+				reads += bits.OnesCount64(mask)
+			}
+		}
+	}
+
+	return reads, writes
+}
+
+// RegisterAccesses returns the registers that the instruction on the line
+// reads and writes.
+func RegisterAccesses(line string) (reads, writes []int) {
+	forEachRegister(strings.TrimSpace(line), func(reg int, kind operandKind) {
+		if kind == operandReg {
+			writes = append(writes, reg)
+		} else {
+			reads = append(reads, reg)
+		}
+	})
+
+	return reads, writes
+}
+
+// registerMasks returns the masks of the registers that the instruction on
+// the line reads and writes.
+func registerMasks(line string) (reads, writes uint64) {
+	r, w := RegisterAccesses(line)
+
+	return regMask(r), regMask(w)
+}
+
+func regMask(regs []int) uint64 {
+	var mask uint64
+	for _, reg := range regs {
+		if reg >= 0 && reg < numRegisters {
+			mask |= 1 << uint(reg)
+		}
+	}
+
+	return mask
+}
+
+// exceedsLimits returns a description of the limit that the instruction
+// would exceed if it is issued in the current cycle, or an empty string if
+// it fits. An instruction that exceeds the ports by itself issues alone.
+func (c *Core) exceedsLimits(op *operation) string {
+	l := c.limits
+	if c.slots.insts >= l.width() {
+		return fmt.Sprintf("the issue width is %d", l.width())
+	}
+
+	if c.slots.insts == 0 {
+		return ""
+	}
+
+	reads, writes := op.reads, op.writes
+	if l.PortReads > 0 && c.slots.reads+reads > l.PortReads {
+		return fmt.Sprintf("the PE reads at most %d ports per cycle",
+			l.PortReads)
+	}
+
+	if l.PortWrites > 0 && c.slots.writes+writes > l.PortWrites {
+		return fmt.Sprintf("the PE writes at most %d ports per cycle",
+			l.PortWrites)
+	}
+
+	if !l.Bypass && op.regReads&c.slots.written != 0 {
+		reg := bits.TrailingZeros64(op.regReads & c.slots.written)
+		return fmt.Sprintf("$%d is written in the same cycle and the PE "+
+			"has no bypass", reg)
+	}
+
+	conflicts := l.bankConflicts(c.slots.read|op.regReads,
+		c.slots.written|op.regWrites)
+	if len(conflicts) > 0 {
+		return conflicts[0]
+	}
+
+	return ""
+}
+
+// takeSlots records the resources of an instruction that is issued in the
+// current cycle.
+func (c *Core) takeSlots(op *operation) {
+	c.slots.insts++
+	c.slots.reads += op.reads
+	c.slots.writes += op.writes
+	c.slots.read |= op.regReads
+	c.slots.written |= op.regWrites
+}
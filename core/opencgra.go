@@ -0,0 +1,288 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// openCGRANode is a node in the DFG exported by the OpenCGRA mapper.
+type openCGRANode struct {
+	ID    int     `json:"id"`
+	Op    string  `json:"op"`
+	Pre   []int   `json:"pre"`
+	Const *uint32 `json:"const,omitempty"`
+}
+
+type openCGRADFG struct {
+	Nodes []openCGRANode `json:"nodes"`
+}
+
+// openCGRAPlacement is the location and the time step that the OpenCGRA
+// mapper assigns to a DFG node.
+type openCGRAPlacement struct {
+	ID    int `json:"id"`
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Cycle int `json:"cycle"`
+}
+
+var openCGRAOps = map[string]string{
+	"add":    "I_ADD",
+	"sub":    "I_SUB",
+	"mul":    "I_MUL",
+	"cmp":    "I_CMP_EQ",
+	"cmp_eq": "I_CMP_EQ",
+	"cmp_ne": "I_CMP_NE",
+	"cmp_lt": "I_CMP_LT",
+	"cmp_le": "I_CMP_LE",
+	"cmp_gt": "I_CMP_GT",
+	"cmp_ge": "I_CMP_GE",
+}
+
+// LoadProgramFromOpenCGRA converts the DFG and the mapping exported by
+// OpenCGRA into Zeonica programs. The returned map is keyed by the [x, y]
+// coordinate of the tile and each value can be passed to Driver.MapProgram.
+//
+// The DFG file is in the form of
+//
+//	{"nodes": [{"id": 0, "op": "add", "pre": [1, 2], "const": 3}]}
+//
+// and the mapping file is in the form of
+//
+//	[{"id": 0, "x": 1, "y": 2, "cycle": 3}]
+//
+// A node and its predecessors must be mapped to the same tile or to
+// adjacent tiles. Each program loops forever, processing one token per
+// iteration.
+func LoadProgramFromOpenCGRA(
+	dfgJSON, mappingJSON []byte,
+) (map[[2]int]string, error) {
+	dfg := openCGRADFG{}
+	err := json.Unmarshal(dfgJSON, &dfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse OpenCGRA DFG: %w", err)
+	}
+
+	placements := []openCGRAPlacement{}
+	err = json.Unmarshal(mappingJSON, &placements)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse OpenCGRA mapping: %w", err)
+	}
+
+	c := openCGRAConverter{
+		nodes:      make(map[int]openCGRANode),
+		placements: make(map[int]openCGRAPlacement),
+		regs:       make(map[int]int),
+	}
+
+	return c.convert(dfg, placements)
+}
+
+type openCGRAConverter struct {
+	nodes      map[int]openCGRANode
+	placements map[int]openCGRAPlacement
+	succs      map[int][]int
+	regs       map[int]int
+}
+
+func (c *openCGRAConverter) convert(
+	dfg openCGRADFG,
+	placements []openCGRAPlacement,
+) (map[[2]int]string, error) {
+	for _, n := range dfg.Nodes {
+		c.nodes[n.ID] = n
+	}
+
+	tiles := make(map[[2]int][]openCGRAPlacement)
+	for _, p := range placements {
+		if _, ok := c.nodes[p.ID]; !ok {
+			return nil, fmt.Errorf("node %d is mapped but not in the DFG", p.ID)
+		}
+
+		c.placements[p.ID] = p
+		coord := [2]int{p.X, p.Y}
+		tiles[coord] = append(tiles[coord], p)
+	}
+
+	err := c.buildSuccessors()
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make(map[[2]int]string)
+	for coord, tilePlacements := range tiles {
+		program, err := c.convertTile(tilePlacements)
+		if err != nil {
+			return nil, err
+		}
+
+		programs[coord] = program
+	}
+
+	return programs, nil
+}
+
+func (c *openCGRAConverter) buildSuccessors() error {
+	c.succs = make(map[int][]int)
+
+	for _, n := range c.nodes {
+		if _, ok := c.placements[n.ID]; !ok {
+			return fmt.Errorf("node %d is not mapped", n.ID)
+		}
+
+		for _, pre := range n.Pre {
+			if _, ok := c.nodes[pre]; !ok {
+				return fmt.Errorf("node %d depends on unknown node %d",
+					n.ID, pre)
+			}
+
+			c.succs[pre] = append(c.succs[pre], n.ID)
+		}
+	}
+
+	for id := range c.succs {
+		sort.Ints(c.succs[id])
+	}
+
+	return nil
+}
+
+func (c *openCGRAConverter) convertTile(
+	placements []openCGRAPlacement,
+) (string, error) {
+	sort.Slice(placements, func(i, j int) bool {
+		if placements[i].Cycle != placements[j].Cycle {
+			return placements[i].Cycle < placements[j].Cycle
+		}
+
+		return placements[i].ID < placements[j].ID
+	})
+
+	nextReg := 0
+	lines := []string{"START:"}
+
+	for _, p := range placements {
+		node := c.nodes[p.ID]
+
+		srcs := make([]string, 0, len(node.Pre)+1)
+		for _, pre := range node.Pre {
+			if c.onSameTile(pre, node.ID) {
+				reg, ok := c.regs[pre]
+				if !ok {
+					return "", fmt.Errorf(
+						"node %d is scheduled before its input node %d",
+						node.ID, pre)
+				}
+
+				srcs = append(srcs, fmt.Sprintf("$%d", reg))
+
+				continue
+			}
+
+			side, err := c.sideOf(node.ID, pre)
+			if err != nil {
+				return "", err
+			}
+
+			lines = append(lines,
+				fmt.Sprintf("WAIT, $%d, NET_RECV_%d", nextReg, side))
+			srcs = append(srcs, fmt.Sprintf("$%d", nextReg))
+			nextReg++
+		}
+
+		inst, err := c.convertOp(node, srcs, nextReg)
+		if err != nil {
+			return "", err
+		}
+
+		c.regs[node.ID] = nextReg
+		nextReg++
+		lines = append(lines, inst)
+
+		sends, err := c.sendToSuccessors(node)
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, sends...)
+	}
+
+	lines = append(lines, "JMP, START")
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (c *openCGRAConverter) convertOp(
+	node openCGRANode,
+	srcs []string,
+	dst int,
+) (string, error) {
+	opcode, ok := openCGRAOps[strings.ToLower(node.Op)]
+	if !ok {
+		return "", fmt.Errorf("node %d: unsupported op %s", node.ID, node.Op)
+	}
+
+	if node.Const != nil {
+		srcs = append(srcs, fmt.Sprintf("%d", *node.Const))
+	}
+
+	if len(srcs) != 2 {
+		return "", fmt.Errorf("node %d: op %s requires 2 sources, got %d",
+			node.ID, node.Op, len(srcs))
+	}
+
+	return fmt.Sprintf("%s, $%d, %s", opcode, dst, strings.Join(srcs, ", ")),
+		nil
+}
+
+func (c *openCGRAConverter) sendToSuccessors(
+	node openCGRANode,
+) ([]string, error) {
+	lines := []string{}
+
+	for _, succ := range c.succs[node.ID] {
+		if c.onSameTile(succ, node.ID) {
+			continue
+		}
+
+		side, err := c.sideOf(node.ID, succ)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("SEND, NET_SEND_%d, $%d", side, c.regs[node.ID]))
+	}
+
+	return lines, nil
+}
+
+func (c *openCGRAConverter) onSameTile(a, b int) bool {
+	pa, pb := c.placements[a], c.placements[b]
+	return pa.X == pb.X && pa.Y == pb.Y
+}
+
+// sideOf returns the side of the tile that node from is mapped to, through
+// which the tile that node to is mapped to can be reached.
+func (c *openCGRAConverter) sideOf(from, to int) (cgra.Side, error) {
+	pf, pt := c.placements[from], c.placements[to]
+
+	switch {
+	case pt.X == pf.X && pt.Y == pf.Y-1:
+		return cgra.North, nil
+	case pt.X == pf.X && pt.Y == pf.Y+1:
+		return cgra.South, nil
+	case pt.X == pf.X+1 && pt.Y == pf.Y:
+		return cgra.East, nil
+	case pt.X == pf.X-1 && pt.Y == pf.Y:
+		return cgra.West, nil
+	}
+
+	return 0, fmt.Errorf("node %d at (%d, %d) and node %d at (%d, %d) "+
+		"are not mapped to adjacent tiles",
+		from, pf.X, pf.Y, to, pt.X, pt.Y)
+}
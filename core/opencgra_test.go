@@ -0,0 +1,61 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+)
+
+var _ = Describe("LoadProgramFromOpenCGRA", func() {
+	It("should convert a DFG mapped to adjacent tiles", func() {
+		dfg := `{"nodes": [
+			{"id": 0, "op": "add", "pre": [3], "const": 1},
+			{"id": 1, "op": "mul", "pre": [0], "const": 2},
+			{"id": 2, "op": "sub", "pre": [0, 1]},
+			{"id": 3, "op": "cmp_lt", "pre": [2], "const": 0}
+		]}`
+		mapping := `[
+			{"id": 3, "x": 0, "y": 0, "cycle": 0},
+			{"id": 0, "x": 0, "y": 0, "cycle": 1},
+			{"id": 1, "x": 1, "y": 0, "cycle": 2},
+			{"id": 2, "x": 1, "y": 0, "cycle": 3}
+		]`
+
+		programs, err := core.LoadProgramFromOpenCGRA(
+			[]byte(dfg), []byte(mapping))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(programs).To(HaveLen(2))
+		Expect(programs[[2]int{0, 0}]).To(Equal(
+			"START:\n" +
+				"WAIT, $0, NET_RECV_1\n" +
+				"I_CMP_LT, $1, $0, 0\n" +
+				"I_ADD, $2, $1, 1\n" +
+				"SEND, NET_SEND_1, $2\n" +
+				"SEND, NET_SEND_1, $2\n" +
+				"JMP, START"))
+		Expect(programs[[2]int{1, 0}]).To(Equal(
+			"START:\n" +
+				"WAIT, $0, NET_RECV_3\n" +
+				"I_MUL, $1, $0, 2\n" +
+				"WAIT, $2, NET_RECV_3\n" +
+				"I_SUB, $3, $2, $1\n" +
+				"SEND, NET_SEND_3, $3\n" +
+				"JMP, START"))
+	})
+
+	It("should reject nodes mapped to non-adjacent tiles", func() {
+		dfg := `{"nodes": [
+			{"id": 0, "op": "add", "pre": [1], "const": 1},
+			{"id": 1, "op": "add", "pre": [0], "const": 1}
+		]}`
+		mapping := `[
+			{"id": 0, "x": 0, "y": 0, "cycle": 0},
+			{"id": 1, "x": 2, "y": 0, "cycle": 1}
+		]`
+
+		_, err := core.LoadProgramFromOpenCGRA([]byte(dfg), []byte(mapping))
+
+		Expect(err).To(MatchError(ContainSubstring("adjacent")))
+	})
+})
@@ -0,0 +1,308 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// operand is an operand that is resolved when the program is mapped, so that
+// running an instruction does not parse any text.
+type operand struct {
+	kind operandKind
+
+	// index is the register, the kernel argument, the port, or the PC of the
+	// label.
+	index int
+
+	// value is the immediate value.
+	value uint32
+
+	text string
+}
+
+// operation is a line of a program with the opcode and the operands
+// resolved.
+type operation struct {
+	text     string
+	label    bool
+	opcode   string
+	operands []operand
+
+	// source is where the instruction comes from in the source kernel.
+	source Source
+
+	// reads and writes are the numbers of NET_RECV and NET_SEND registers
+	// that the instruction accesses.
+	reads, writes int
+
+	// regReads and regWrites have a bit for each register that the
+	// instruction reads and writes.
+	regReads, regWrites uint64
+
+	exec func(instEmulator, *operation, *coreState)
+
+	// arith computes the result of an arithmetic instruction.
+	arith func(a, b uint32) uint32
+
+	// cmp evaluates the condition of an I_CMP or F32_CMP instruction.
+	cmp func(a, b uint32) bool
+}
+
+var execFuncs = map[string]func(instEmulator, *operation, *coreState){
+	"WAIT":         instEmulator.runWait,
+	"SEND":         instEmulator.runSend,
+	"SEND_PRED":    instEmulator.runSend,
+	"FORWARD":      instEmulator.runForward,
+	"JMP":          instEmulator.runJmp,
+	"JEQ":          instEmulator.runJeq,
+	"BARRIER":      instEmulator.runBarrier,
+	"I_ADD":        instEmulator.runIntArith,
+	"I_SUB":        instEmulator.runIntArith,
+	"I_MUL":        instEmulator.runIntArith,
+	"PACK":         instEmulator.runIntArith,
+	"UNPACK":       instEmulator.runUnpack,
+	"RAND":         instEmulator.runRand,
+	"GATHER":       instEmulator.runGather,
+	"SCATTER":      instEmulator.runScatter,
+	"SCATTER_ADD":  instEmulator.runScatter,
+	"ENQUEUE":      instEmulator.runEnqueue,
+	"DEQUEUE":      instEmulator.runDequeue,
+	"MEMCPY_TILE":  instEmulator.runMemcpyTile,
+	"REDUCE_ADD":   instEmulator.runReduce,
+	"REDUCE_MIN":   instEmulator.runReduce,
+	"REDUCE_MAX":   instEmulator.runReduce,
+	"DONE":         instEmulator.runDone,
+	"RETURN_VALUE": instEmulator.runReturnValue,
+	"SEND_WIDE":    instEmulator.runSendWide,
+	"WAIT_WIDE":    instEmulator.runWaitWide,
+	"RDCOUNTER":    instEmulator.runRdCounter,
+
+	"DATA_MOV":        instEmulator.runMove,
+	"GRANT_ALWAYS":    instEmulator.runMove,
+	"GRANT_ONCE":      instEmulator.runMove,
+	"GRANT_PREDICATE": instEmulator.runMove,
+	"STATE_RESET":     instEmulator.runStateReset,
+	"GEP":             instEmulator.runIntArith,
+	"SEL":             instEmulator.runSel,
+	"F32_ADD":         instEmulator.runFloatArith,
+	"F32_SUB":         instEmulator.runFloatArith,
+	"F32_MUL":         instEmulator.runFloatArith,
+	"F32_CLASS":       instEmulator.runClass,
+	"F16_ADD":         instEmulator.runFloatArith,
+	"F16_SUB":         instEmulator.runFloatArith,
+	"F16_MUL":         instEmulator.runFloatArith,
+	"BF16_ADD":        instEmulator.runFloatArith,
+	"BF16_SUB":        instEmulator.runFloatArith,
+	"BF16_MUL":        instEmulator.runFloatArith,
+	"F32_TO_F16":      instEmulator.runConvert,
+	"F16_TO_F32":      instEmulator.runConvert,
+	"F32_TO_BF16":     instEmulator.runConvert,
+	"BF16_TO_F32":     instEmulator.runConvert,
+}
+
+var intArithFuncs = map[string]func(a, b uint32) uint32{
+	"I_ADD": func(a, b uint32) uint32 { return a + b },
+	"I_SUB": func(a, b uint32) uint32 { return a - b },
+	"I_MUL": func(a, b uint32) uint32 { return a * b },
+	"PACK":  func(a, b uint32) uint32 { return a&0xffff | b<<16 },
+	"GEP":   func(a, b uint32) uint32 { return a + b },
+
+	"SCATTER_ADD": func(a, b uint32) uint32 { return a + b },
+
+	"REDUCE_ADD": func(a, b uint32) uint32 { return a + b },
+	"REDUCE_MIN": func(a, b uint32) uint32 {
+		if int32(b) < int32(a) {
+			return b
+		}
+
+		return a
+	},
+	"REDUCE_MAX": func(a, b uint32) uint32 {
+		if int32(b) > int32(a) {
+			return b
+		}
+
+		return a
+	},
+}
+
+var intConditions = map[string]func(a, b int32) bool{
+	"EQ": func(a, b int32) bool { return a == b },
+	"NE": func(a, b int32) bool { return a != b },
+	"LT": func(a, b int32) bool { return a < b },
+	"LE": func(a, b int32) bool { return a <= b },
+	"GT": func(a, b int32) bool { return a > b },
+	"GE": func(a, b int32) bool { return a >= b },
+}
+
+var f32Conditions = map[string]func(a, b float32) bool{
+	"EQ": func(a, b float32) bool { return a == b },
+	"NE": func(a, b float32) bool { return a != b },
+	"LT": func(a, b float32) bool { return a < b },
+	"LE": func(a, b float32) bool { return a <= b },
+	"GT": func(a, b float32) bool { return a > b },
+	"GE": func(a, b float32) bool { return a >= b },
+}
+
+// decodeProgram resolves every line of a program. It panics if a line does
+// not pass checkInst.
+func decodeProgram(program []string) []operation {
+	labels := labelPCs(program)
+
+	ops := make([]operation, len(program))
+	for pc, line := range program {
+		ops[pc] = decodeInst(line, labels)
+	}
+
+	return ops
+}
+
+// labelPCs maps the names of the labels of a program to their PCs. If a
+// name is defined more than once, the first definition wins.
+func labelPCs(program []string) map[string]int {
+	labels := make(map[string]int)
+
+	for pc, line := range program {
+		if !isLabel(line) {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.TrimSpace(line), ":")
+		if _, ok := labels[name]; !ok {
+			labels[name] = pc
+		}
+	}
+
+	return labels
+}
+
+func decodeInst(line string, labels map[string]int) operation {
+	if strings.TrimSpace(line) == "" || isLabel(line) {
+		return operation{text: line, label: true}
+	}
+
+	tokens := tokenizeInst(line)
+	op := operation{text: line, opcode: tokens[0].text}
+
+	kinds, ok := operandsOf(op.opcode)
+	if !ok {
+		panic("unknown instruction " + line)
+	}
+
+	if len(tokens)-1 != len(kinds) {
+		panic("wrong number of operands in " + line)
+	}
+
+	texts := make([]string, 0, len(kinds))
+	for i, kind := range kinds {
+		op.operands = append(op.operands,
+			decodeOperand(tokens[i+1].text, kind, labels))
+		texts = append(texts, tokens[i+1].text)
+	}
+
+	op.reads, op.writes = portAccesses(op.opcode, texts)
+	op.regReads, op.regWrites = registerMasks(line)
+
+	op.source = SourceOf(line)
+	op.exec = execFuncs[op.opcode]
+	op.arith = intArithFuncs[op.opcode]
+
+	if f, ok := floatArithFuncs[op.opcode]; ok {
+		op.arith = func(a, b uint32) uint32 { return f(FloatMode{}, a, b) }
+	}
+
+	if fx, ok := parseFixedPoint(op.opcode); ok {
+		op.exec = instEmulator.runIntArith
+		op.arith = fx.run
+	} else if op.exec == nil {
+		op.exec = instEmulator.runCmp
+		op.cmp = cmpFunc(op.opcode)
+	}
+
+	return op
+}
+
+// cmpFunc returns the condition of a compare opcode that operandsOf
+// accepts.
+func cmpFunc(opcode string) func(a, b uint32) bool {
+	if strings.HasPrefix(opcode, "I_CMP_") {
+		cond := intConditions[strings.TrimPrefix(opcode, "I_CMP_")]
+
+		return func(a, b uint32) bool { return cond(int32(a), int32(b)) }
+	}
+
+	cond := f32Conditions[strings.TrimPrefix(opcode, "F32_CMP_")]
+
+	return func(a, b uint32) bool {
+		return cond(math.Float32frombits(a), math.Float32frombits(b))
+	}
+}
+
+func decodeOperand(text string, kind operandKind, labels map[string]int) operand {
+	kind = concreteKind(text, kind)
+	o := operand{kind: kind, text: text}
+
+	switch kind {
+	case operandReg, operandRegIn:
+		o.kind = operandReg
+		o.index = parseIndex(text, "$", "invalid register index")
+	case operandSrc:
+		return decodeSrc(text)
+	case operandImm, operandSides, operandWords:
+		o.value = parseImm(text)
+	case operandRecv:
+		o.index = parseIndex(text, "NET_RECV_",
+			"the source of a WAIT instruction must be NET_RECV registers")
+	case operandSend:
+		o.index = parseIndex(text, "NET_SEND_",
+			"the destination of a SEND instruction must be NET_SEND registers")
+	case operandCounter:
+		o.index = indexOf(counterNames, text)
+	case operandLabel:
+		pc, ok := labels[text]
+		if !ok {
+			panic("undefined label " + text)
+		}
+
+		o.index = pc
+	}
+
+	return o
+}
+
+// decodeSrc resolves a register, a kernel argument, or an immediate value.
+func decodeSrc(text string) operand {
+	switch {
+	case strings.HasPrefix(text, "$"):
+		return operand{kind: operandReg, text: text,
+			index: parseIndex(text, "$", "invalid register index")}
+	case strings.HasPrefix(text, "ARG"):
+		return operand{kind: operandArg, text: text,
+			index: parseIndex(text, "ARG", "invalid kernel argument index")}
+	default:
+		return operand{kind: operandImm, text: text, value: parseImm(text)}
+	}
+}
+
+func parseIndex(text, prefix, msg string) int {
+	if !strings.HasPrefix(text, prefix) {
+		panic(msg)
+	}
+
+	index, err := strconv.Atoi(strings.TrimPrefix(text, prefix))
+	if err != nil {
+		panic(msg)
+	}
+
+	return index
+}
+
+func parseImm(text string) uint32 {
+	imm, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		panic("invalid operand " + text)
+	}
+
+	return uint32(imm)
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pureOpcodes are the opcodes that only write registers, so they can be
+// removed if no instruction reads what they write. Other opcodes also
+// consume tokens, send, jump, or change state, e.g., the generator of RAND.
+var pureOpcodes = map[string]bool{
+	"I_ADD": true, "I_SUB": true, "I_MUL": true,
+	"F32_ADD": true, "F32_SUB": true, "F32_MUL": true, "F32_CLASS": true,
+	"F16_ADD": true, "F16_SUB": true, "F16_MUL": true,
+	"BF16_ADD": true, "BF16_SUB": true, "BF16_MUL": true,
+	"F32_TO_F16": true, "F16_TO_F32": true,
+	"F32_TO_BF16": true, "BF16_TO_F32": true,
+	"PACK": true, "UNPACK": true, "GATHER": true, "GEP": true, "SEL": true,
+	"DATA_MOV": true, "GRANT_ALWAYS": true, "GRANT_ONCE": true,
+	"GRANT_PREDICATE": true, "RDCOUNTER": true,
+}
+
+// Optimized is a program that OptimizeProgram has optimized.
+type Optimized struct {
+	Program string
+
+	// Removed are the 0-based numbers of the lines of the original program
+	// that are removed.
+	Removed []int
+
+	// Warnings are about the instructions that are kept but write registers
+	// that no instruction reads, e.g., a WAIT that has to consume its token.
+	Warnings []string
+}
+
+// OptimizeProgram removes the instructions whose results are never
+// consumed, i.e., that only write registers that no instruction of the
+// program reads, until no more can be removed. The instructions that send,
+// receive, jump, or change other state are kept. Registers are treated as
+// read if any instruction reads them, wherever it is in the program. The
+// labels are kept, so the jumps still land where they did, but the steps of
+// a schedule have to be updated for the removed lines.
+func OptimizeProgram(program string) Optimized {
+	lines := strings.Split(program, "\n")
+	canonical := CanonicalizeOpcodes(lines, DefaultOpcodeAliases)
+	removed := make(map[int]bool)
+
+	for {
+		reads := registerReads(canonical, removed)
+		progress := false
+
+		for i, line := range canonical {
+			if !removed[i] && isDead(line, reads) {
+				removed[i] = true
+				progress = true
+			}
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	return optimizedProgram(lines, canonical, removed)
+}
+
+func optimizedProgram(lines, canonical []string, removed map[int]bool) Optimized {
+	o := Optimized{}
+	kept := []string{}
+	reads := registerReads(canonical, removed)
+
+	for i, line := range lines {
+		if removed[i] {
+			o.Removed = append(o.Removed, i)
+			continue
+		}
+
+		kept = append(kept, line)
+
+		for _, reg := range registerWrites(canonical[i]) {
+			if !reads[reg] {
+				o.Warnings = append(o.Warnings, fmt.Sprintf("line %d: %s "+
+					"writes $%d, which is never read", i,
+					Opcode(canonical[i]), reg))
+			}
+		}
+	}
+
+	sort.Ints(o.Removed)
+	o.Program = strings.Join(kept, "\n")
+
+	return o
+}
+
+// isDead returns true if the instruction only writes registers that are not
+// read.
+func isDead(line string, reads map[int]bool) bool {
+	opcode := Opcode(line)
+	if !pureOpcodes[opcode] && !isCmpOrFixed(opcode) {
+		return false
+	}
+
+	tokens := splitInst(line)
+	for _, t := range tokens[1:] {
+		if strings.HasPrefix(t, "NET_") {
+			return false
+		}
+	}
+
+	writes := registerWrites(line)
+	for _, reg := range writes {
+		if reads[reg] {
+			return false
+		}
+	}
+
+	return len(writes) > 0
+}
+
+func isCmpOrFixed(opcode string) bool {
+	if _, ok := parseFixedPoint(opcode); ok {
+		return true
+	}
+
+	return strings.HasPrefix(opcode, "I_CMP_") ||
+		strings.HasPrefix(opcode, "F32_CMP_")
+}
+
+// registerReads returns the registers that the lines that are not removed
+// read.
+func registerReads(lines []string, removed map[int]bool) map[int]bool {
+	reads := make(map[int]bool)
+
+	for i, line := range lines {
+		if removed[i] {
+			continue
+		}
+
+		forEachRegister(line, func(reg int, kind operandKind) {
+			if kind != operandReg {
+				reads[reg] = true
+			}
+		})
+	}
+
+	return reads
+}
+
+// registerWrites returns the registers that the line writes.
+func registerWrites(line string) []int {
+	writes := []int{}
+
+	forEachRegister(line, func(reg int, kind operandKind) {
+		if kind == operandReg {
+			writes = append(writes, reg)
+		}
+	})
+
+	return writes
+}
+
+// forEachRegister calls f with each register of the line and the kind of
+// the operand, where operandReg is a register that is written. The wide
+// instructions count each register of the message.
+func forEachRegister(line string, f func(reg int, kind operandKind)) {
+	opcode := Opcode(line)
+
+	kinds, ok := operandsOf(opcode)
+	if !ok {
+		return
+	}
+
+	tokens := splitInst(line)
+	words := 1
+
+	if opcode == "SEND_WIDE" || opcode == "WAIT_WIDE" {
+		if len(tokens) > 3 {
+			words, _ = strconv.Atoi(tokens[3])
+		}
+	}
+
+	for i, kind := range kinds {
+		if i+1 >= len(tokens) || !strings.HasPrefix(tokens[i+1], "$") {
+			continue
+		}
+
+		reg, err := strconv.Atoi(tokens[i+1][1:])
+		if err != nil {
+			continue
+		}
+
+		kind = concreteKind(tokens[i+1], kind)
+
+		n := 1
+		if kind == operandReg || kind == operandRegIn {
+			n = words
+		}
+
+		for k := 0; k < n; k++ {
+			f(reg+k, kind)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+)
+
+var _ = Describe("OptimizeProgram", func() {
+	It("should remove the instructions whose results are never read", func() {
+		o := core.OptimizeProgram("START:\n" +
+			"WAIT, $0, NET_RECV_3\n" +
+			"I_ADD, $1, $0, 1\n" +
+			"I_MUL, $2, $1, 2\n" +
+			"MOV, $3, $0\n" +
+			"I_SUB, $4, $0, 1\n" +
+			"SEND, NET_SEND_1, $3\n" +
+			"JMP, START")
+
+		Expect(o.Program).To(Equal("START:\n" +
+			"WAIT, $0, NET_RECV_3\n" +
+			"MOV, $3, $0\n" +
+			"SEND, NET_SEND_1, $3\n" +
+			"JMP, START"))
+		Expect(o.Removed).To(Equal([]int{2, 3, 5}))
+		Expect(o.Warnings).To(BeEmpty())
+	})
+
+	It("should keep the instructions that consume tokens", func() {
+		o := core.OptimizeProgram("WAIT, $0, NET_RECV_3\n" +
+			"WAIT_WIDE, $1, NET_RECV_2, 2\n" +
+			"SEND_WIDE, NET_SEND_1, $1, 2")
+
+		Expect(o.Removed).To(BeEmpty())
+		Expect(o.Warnings).To(Equal([]string{
+			"line 0: WAIT writes $0, which is never read",
+		}))
+	})
+})
@@ -0,0 +1,506 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProgramError reports a mistake in a program file.
+type ProgramError struct {
+	File   string
+	Line   int
+	Column int
+	Token  string
+	Msg    string
+}
+
+func (e *ProgramError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s %q",
+		e.File, e.Line, e.Column, e.Msg, e.Token)
+}
+
+// ProgramErrors is the list of the mistakes in a program file.
+type ProgramErrors []*ProgramError
+
+func (e ProgramErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// programLine is a line of a program and where it is in the program file.
+type programLine struct {
+	text string
+
+	// line is the 1-based line number in the file and offset is the number
+	// of characters before the text on that line.
+	line, offset int
+}
+
+func checkProgramLines(
+	file string,
+	lines []programLine,
+	constants map[string]uint32,
+) ProgramErrors {
+	texts := make([]string, 0, len(lines))
+	for _, l := range lines {
+		texts = append(texts, l.text)
+	}
+
+	labels := labelsOf(texts)
+	errs := ProgramErrors{}
+
+	for _, l := range lines {
+		err := checkInst(l.text, labels, constants)
+		if err != nil {
+			errs = append(errs, &ProgramError{
+				File:   file,
+				Line:   l.line,
+				Column: l.offset + err.column,
+				Token:  err.token,
+				Msg:    err.msg,
+			})
+		}
+	}
+
+	return errs
+}
+
+func hasInst(lines []programLine) bool {
+	for _, l := range lines {
+		if Opcode(l.text) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+var asmHeader = regexp.MustCompile(`^PE\(\s*(-?\d+)\s*,\s*(-?\d+)\s*\):$`)
+
+var asmConst = regexp.MustCompile(`^\.const\s+(\S+)\s*=\s*(.*)$`)
+
+func loadProgramFile(
+	path string,
+	parse func(file, src string) (*ProgramFile, error),
+) (*ProgramFile, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(path, string(src))
+}
+
+// LoadProgramFileFromASM loads the programs of multiple PEs from an assembly
+// file. The program of each PE starts with a header in the form of
+//
+//	PE(x, y):
+//
+// Before the first header, the file can declare named constants, which
+// replace the operands with the same name, in the form of
+//
+//	.const N = 20
+//
+// The returned map is keyed by the [x, y] coordinate of the PE. If the file
+// has mistakes, the error is a ProgramErrors that lists all of them.
+func LoadProgramFileFromASM(path string) (map[[2]int]string, error) {
+	f, err := loadProgramFile(path, parseASM)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Resolve(), nil
+}
+
+type asmBlock struct {
+	coord  [2]int
+	header int
+	lines  []programLine
+}
+
+// addConstant parses the value of a constant and adds it to the constants.
+// The position of the declaration is used to report mistakes.
+func addConstant(
+	constants map[string]uint32,
+	name, value string,
+	pos ProgramError,
+) *ProgramError {
+	if !constantName.MatchString(name) {
+		pos.Token, pos.Msg = name, "invalid constant name"
+		return &pos
+	}
+
+	if _, ok := constants[name]; ok {
+		pos.Token, pos.Msg = name, "duplicated constant"
+		return &pos
+	}
+
+	v, err := ParseConstant(value)
+	if err != nil {
+		pos.Token, pos.Msg = value, "invalid constant value"
+		return &pos
+	}
+
+	constants[name] = v
+
+	return nil
+}
+
+// declareASMConst adds a constant declared by a .const line. Constants must
+// be declared before the first PE header.
+func declareASMConst(
+	constants map[string]uint32,
+	m []string,
+	pos ProgramError,
+	afterHeader bool,
+) *ProgramError {
+	if afterHeader {
+		pos.Msg = "constant after the first PE header"
+		return &pos
+	}
+
+	return addConstant(constants, m[1], m[2], pos)
+}
+
+func parseASM(file, src string) (*ProgramFile, error) {
+	blocks := []*asmBlock{}
+	seen := make(map[[2]int]bool)
+	constants := make(map[string]uint32)
+	errs := ProgramErrors{}
+
+	for i, text := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(text)
+
+		if m := asmConst.FindStringSubmatch(trimmed); m != nil {
+			pos := ProgramError{File: file, Line: i + 1,
+				Column: strings.Index(text, trimmed) + 1, Token: trimmed}
+			if err := declareASMConst(constants, m, pos, len(blocks) > 0); err != nil {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		if m := asmHeader.FindStringSubmatch(trimmed); m != nil {
+			x, _ := strconv.Atoi(m[1])
+			y, _ := strconv.Atoi(m[2])
+			coord := [2]int{x, y}
+			column := strings.Index(text, trimmed) + 1
+
+			if seen[coord] {
+				errs = append(errs, &ProgramError{File: file, Line: i + 1,
+					Column: column, Token: trimmed, Msg: "duplicated PE"})
+			}
+
+			seen[coord] = true
+			blocks = append(blocks, &asmBlock{coord: coord, header: i + 1})
+
+			continue
+		}
+
+		if trimmed == "" {
+			continue
+		}
+
+		if len(blocks) == 0 {
+			errs = append(errs, &ProgramError{File: file, Line: i + 1,
+				Column: strings.Index(text, trimmed) + 1, Token: trimmed,
+				Msg: "instruction before the first PE header"})
+
+			continue
+		}
+
+		b := blocks[len(blocks)-1]
+		b.lines = append(b.lines, programLine{text: text, line: i + 1})
+	}
+
+	return buildASMPrograms(file, blocks, constants, errs)
+}
+
+func buildASMPrograms(
+	file string,
+	blocks []*asmBlock,
+	constants map[string]uint32,
+	errs ProgramErrors,
+) (*ProgramFile, error) {
+	if len(blocks) == 0 && len(errs) == 0 {
+		errs = append(errs, &ProgramError{File: file, Line: 1, Column: 1,
+			Msg: "no PE header found"})
+	}
+
+	programs := make(map[[2]int]string)
+
+	for _, b := range blocks {
+		if !hasInst(b.lines) {
+			errs = append(errs, &ProgramError{File: file, Line: b.header,
+				Column: 1, Msg: fmt.Sprintf("PE(%d, %d) has an empty program",
+					b.coord[0], b.coord[1])})
+
+			continue
+		}
+
+		errs = append(errs, checkProgramLines(file, b.lines, constants)...)
+		programs[b.coord] = joinProgramLines(b.lines)
+	}
+
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Line < errs[j].Line
+		})
+
+		return nil, errs
+	}
+
+	return &ProgramFile{Programs: programs, Constants: constants}, nil
+}
+
+func joinProgramLines(lines []programLine) string {
+	texts := make([]string, 0, len(lines))
+	for _, l := range lines {
+		texts = append(texts, strings.TrimSpace(l.text))
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+type yamlPE struct {
+	X       int       `yaml:"x"`
+	Y       int       `yaml:"y"`
+	Program yaml.Node `yaml:"program"`
+}
+
+// yamlProgramFile is the form of a YAML program file that declares named
+// constants.
+type yamlProgramFile struct {
+	Constants yaml.Node `yaml:"constants"`
+	PEs       []yamlPE  `yaml:"pes"`
+}
+
+// LoadProgramFileFromYAML loads the programs of multiple PEs from a YAML
+// file in the form of
+//
+//   - x: 0
+//     y: 0
+//     program: |
+//     WAIT, $0, NET_RECV_3
+//     SEND, NET_SEND_1, $0
+//
+// To declare named constants, which replace the operands with the same
+// name, the file lists the PEs under pes, as in
+//
+//	constants:
+//	  N: 20
+//	pes:
+//	  - x: 0
+//	    ...
+//
+// The returned map is keyed by the [x, y] coordinate of the PE. If the
+// programs have mistakes, the error is a ProgramErrors that lists all of
+// them.
+func LoadProgramFileFromYAML(path string) (map[[2]int]string, error) {
+	f, err := loadProgramFile(path, parseYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Resolve(), nil
+}
+
+func parseYAML(file, src string) (*ProgramFile, error) {
+	root := yaml.Node{}
+
+	err := yaml.Unmarshal([]byte(src), &root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	pf := yamlProgramFile{}
+	if len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode {
+		err = root.Content[0].Decode(&pf)
+	} else {
+		err = root.Decode(&pf.PEs)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	constants, errs := yamlConstants(file, pf.Constants)
+
+	return buildYAMLPrograms(file, src, pf.PEs, constants, errs)
+}
+
+func yamlConstants(
+	file string,
+	node yaml.Node,
+) (map[string]uint32, ProgramErrors) {
+	constants := make(map[string]uint32)
+	errs := ProgramErrors{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		pos := ProgramError{File: file, Line: key.Line, Column: key.Column}
+
+		if err := addConstant(constants, key.Value, value.Value, pos); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return constants, errs
+}
+
+func buildYAMLPrograms(
+	file, src string,
+	pes []yamlPE,
+	constants map[string]uint32,
+	errs ProgramErrors,
+) (*ProgramFile, error) {
+	rawLines := strings.Split(src, "\n")
+	programs := make(map[[2]int]string)
+
+	if len(pes) == 0 {
+		errs = append(errs, &ProgramError{File: file, Line: 1, Column: 1,
+			Msg: "no PE found"})
+	}
+
+	for _, pe := range pes {
+		coord := [2]int{pe.X, pe.Y}
+		node := pe.Program
+		lines := yamlProgramLines(node, rawLines)
+
+		switch {
+		case programs[coord] != "":
+			errs = append(errs, &ProgramError{File: file, Line: node.Line,
+				Column: node.Column, Msg: fmt.Sprintf("duplicated PE(%d, %d)",
+					pe.X, pe.Y)})
+		case !hasInst(lines):
+			errs = append(errs, &ProgramError{File: file, Line: node.Line,
+				Column: node.Column, Msg: fmt.Sprintf(
+					"PE(%d, %d) has an empty program", pe.X, pe.Y)})
+		default:
+			errs = append(errs, checkProgramLines(file, lines, constants)...)
+			programs[coord] = joinProgramLines(lines)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &ProgramFile{Programs: programs, Constants: constants}, nil
+}
+
+// yamlProgramLines locates the lines of a program scalar in the YAML file.
+func yamlProgramLines(node yaml.Node, rawLines []string) []programLine {
+	first := node.Line
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		first++
+	}
+
+	lines := []programLine{}
+	for i, text := range strings.Split(node.Value, "\n") {
+		l := programLine{text: text, line: first + i}
+
+		if l.line-1 < len(rawLines) {
+			if offset := strings.Index(rawLines[l.line-1], text); offset >= 0 {
+				l.offset = offset
+			}
+		}
+
+		lines = append(lines, l)
+	}
+
+	return lines
+}
+
+func sortedProgramCoords(programs map[[2]int]string) [][2]int {
+	coords := make([][2]int, 0, len(programs))
+	for coord := range programs {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	return coords
+}
+
+// WriteProgramsToASM writes the programs in the format that
+// LoadProgramFileFromASM reads.
+func WriteProgramsToASM(w io.Writer, programs map[[2]int]string) error {
+	b := &strings.Builder{}
+
+	for i, coord := range sortedProgramCoords(programs) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(b, "PE(%d, %d):\n", coord[0], coord[1])
+
+		for _, line := range strings.Split(programs[coord], "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			if !isLabel(line) {
+				b.WriteString("\t")
+			}
+
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+type yamlProgram struct {
+	X       int    `yaml:"x"`
+	Y       int    `yaml:"y"`
+	Program string `yaml:"program"`
+}
+
+// WriteProgramsToYAML writes the programs in the format that
+// LoadProgramFileFromYAML reads.
+func WriteProgramsToYAML(w io.Writer, programs map[[2]int]string) error {
+	pes := []yamlProgram{}
+	for _, coord := range sortedProgramCoords(programs) {
+		pes = append(pes, yamlProgram{
+			X:       coord[0],
+			Y:       coord[1],
+			Program: programs[coord],
+		})
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	err := enc.Encode(pes)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
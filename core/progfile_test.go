@@ -0,0 +1,186 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+)
+
+var _ = Describe("Program files", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "zeonica")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+		return path
+	}
+
+	It("should load programs from an assembly file", func() {
+		path := writeFile("kernel.asm", "PE(0, 0):\n"+
+			"START:\n"+
+			"\tWAIT, $0, NET_RECV_3\n"+
+			"\tJMP, START\n"+
+			"\n"+
+			"PE(1, 0):\n"+
+			"\tDONE,\n")
+
+		programs, err := core.LoadProgramFileFromASM(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(programs).To(Equal(map[[2]int]string{
+			{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nJMP, START",
+			{1, 0}: "DONE,",
+		}))
+	})
+
+	It("should report the location of each mistake", func() {
+		path := writeFile("kernel.asm", "PE(0, 0):\n"+
+			"\tWAIT, $0, NET_RECV_16\n"+
+			"\tJMP, NOWHERE\n"+
+			"PE(1, 0):\n")
+
+		_, err := core.LoadProgramFileFromASM(path)
+
+		Expect(err).To(BeAssignableToTypeOf(core.ProgramErrors{}))
+		errs := err.(core.ProgramErrors)
+		Expect(errs).To(HaveLen(3))
+		Expect(*errs[0]).To(Equal(core.ProgramError{
+			File: path, Line: 2, Column: 12, Token: "NET_RECV_16",
+			Msg: "invalid receive register",
+		}))
+		Expect(*errs[1]).To(Equal(core.ProgramError{
+			File: path, Line: 3, Column: 7, Token: "NOWHERE",
+			Msg: "undefined label",
+		}))
+		Expect(errs[2].Error()).To(Equal(
+			path + `:4:1: PE(1, 0) has an empty program`))
+	})
+
+	It("should keep the source metadata of the instructions", func() {
+		path := writeFile("kernel.asm", "PE(0, 0):\n"+
+			"\tWAIT, $0, NET_RECV_3 @ src=fir.c:10 node=n1\n"+
+			"\tI_ADD, $0, $0, 1 @ src=fir.c:11 loop=2\n")
+
+		_, err := core.LoadProgramFileFromASM(path)
+
+		Expect(err).To(MatchError(path + `:3:34: invalid source metadata "loop=2"`))
+
+		path = writeFile("kernel.asm", "PE(0, 0):\n"+
+			"\tWAIT, $0, NET_RECV_3 @ src=fir.c:10 node=n1\n")
+
+		programs, err := core.LoadProgramFileFromASM(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		line := programs[[2]int{0, 0}]
+		Expect(core.SourceOf(line)).To(Equal(
+			core.Source{Loc: "fir.c:10", Node: "n1"}))
+		Expect(core.Opcode(line)).To(Equal("WAIT"))
+		Expect(core.StripSource(line)).To(Equal("WAIT, $0, NET_RECV_3"))
+	})
+
+	It("should report mistakes in YAML programs", func() {
+		path := writeFile("kernel.yaml", "- x: 0\n"+
+			"  y: 0\n"+
+			"  program: |\n"+
+			"    WAIT, $0, NET_RECV_3\n"+
+			"    I_ADD, $1, $0, oops\n")
+
+		_, err := core.LoadProgramFileFromYAML(path)
+
+		Expect(err).To(MatchError(
+			path + `:5:20: invalid operand "oops"`))
+	})
+
+	It("should load YAML programs", func() {
+		path := writeFile("kernel.yaml", "- x: 1\n"+
+			"  y: 2\n"+
+			"  program: |\n"+
+			"    WAIT, $0, NET_RECV_3\n"+
+			"    SEND, NET_SEND_1, $0\n")
+
+		programs, err := core.LoadProgramFileFromYAML(path)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(programs).To(Equal(map[[2]int]string{
+			{1, 2}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n",
+		}))
+	})
+	It("should convert between the ASM and the YAML formats", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nJMP, START",
+			{1, 0}: "DONE",
+		}
+
+		asm := &strings.Builder{}
+		Expect(core.WriteProgramsToASM(asm, programs)).To(Succeed())
+		yml := &strings.Builder{}
+		Expect(core.WriteProgramsToYAML(yml, programs)).To(Succeed())
+
+		fromASM, err := core.LoadProgramFileFromASM(
+			writeFile("kernel.asm", asm.String()))
+		Expect(err).NotTo(HaveOccurred())
+		fromYAML, err := core.LoadProgramFileFromYAML(
+			writeFile("kernel.yaml", yml.String()))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fromASM).To(Equal(programs))
+		Expect(fromYAML).To(Equal(programs))
+	})
+
+	It("should resolve named constants", func() {
+		asm := writeFile("kernel.asm", ".const N = 20\n"+
+			".const ALPHA = 3.0\n"+
+			"PE(0, 0):\n"+
+			"\tI_ADD, $0, $0, N\n"+
+			"\tF32_CMP_LT, $1, $0, ALPHA\n")
+		yml := writeFile("kernel.yaml", "constants:\n"+
+			"  N: 20\n"+
+			"pes:\n"+
+			"  - x: 0\n"+
+			"    y: 0\n"+
+			"    program: |\n"+
+			"      I_ADD, $0, $0, N\n")
+
+		programs, err := core.LoadProgramFileFromASM(asm)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(programs[[2]int{0, 0}]).To(Equal(
+			"I_ADD, $0, $0, 20\nF32_CMP_LT, $1, $0, 1077936128"))
+
+		f, err := core.LoadProgramFile(yml)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Constants).To(Equal(map[string]uint32{"N": 20}))
+		Expect(f.Programs[[2]int{0, 0}]).To(Equal("I_ADD, $0, $0, N\n"))
+		Expect(f.Resolve()[[2]int{0, 0}]).To(Equal("I_ADD, $0, $0, 20\n"))
+	})
+
+	It("should report mistakes in constants", func() {
+		path := writeFile("kernel.asm", ".const N = 20\n"+
+			".const N = 30\n"+
+			".const M = x\n"+
+			"PE(0, 0):\n"+
+			"\tI_ADD, $0, $0, K\n"+
+			".const K = 1\n")
+
+		_, err := core.LoadProgramFileFromASM(path)
+
+		Expect(err).To(MatchError(path + ":2:1: duplicated constant \"N\"\n" +
+			path + ":3:1: invalid constant value \"x\"\n" +
+			path + ":5:17: invalid operand \"K\"\n" +
+			path + ":6:1: constant after the first PE header \".const K = 1\""))
+	})
+})
@@ -0,0 +1,67 @@
+package core
+
+import "strings"
+
+// splitInst splits an instruction into the opcode and the operands. The
+// source metadata, if any, is left out.
+func splitInst(inst string) []string {
+	tokens := strings.Split(StripSource(inst), ",")
+	for i := range tokens {
+		tokens[i] = strings.TrimSpace(tokens[i])
+	}
+
+	return tokens
+}
+
+// isLabel returns true if the line of a program is a label.
+func isLabel(line string) bool {
+	return strings.HasSuffix(strings.TrimSpace(line), ":")
+}
+
+// Opcode returns the opcode of a line of a program. It returns an empty
+// string if the line is a label or is empty.
+func Opcode(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || isLabel(line) {
+		return ""
+	}
+
+	return splitInst(line)[0]
+}
+
+// DefaultOpcodeAliases maps the mnemonics that compilers emit for some
+// opcodes to the opcodes of the ISA, so that their programs load without
+// editing.
+var DefaultOpcodeAliases = map[string]string{
+	"LD":      "GATHER",
+	"LOAD":    "GATHER",
+	"ST":      "SCATTER",
+	"STD":     "SCATTER",
+	"STORE":   "SCATTER",
+	"MOV":     "DATA_MOV",
+	"ICMP_EQ": "I_CMP_EQ",
+	"ICMP_NE": "I_CMP_NE",
+	"ICMP_LT": "I_CMP_LT",
+	"ICMP_LE": "I_CMP_LE",
+	"ICMP_GT": "I_CMP_GT",
+	"ICMP_GE": "I_CMP_GE",
+	"LT_EX":   "I_CMP_LT",
+	"FCLASS":  "F32_CLASS",
+}
+
+// CanonicalizeOpcodes returns a copy of the program where the opcodes that
+// are keys of the aliases are replaced by their values. The operands are
+// kept as they are.
+func CanonicalizeOpcodes(program []string, aliases map[string]string) []string {
+	canonical := make([]string, len(program))
+	for i, line := range program {
+		canonical[i] = line
+
+		opcode := Opcode(line)
+		if to, ok := aliases[opcode]; ok {
+			canonical[i] = strings.Replace(line, opcode, to, 1)
+		}
+	}
+
+	return canonical
+}
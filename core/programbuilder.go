@@ -0,0 +1,311 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Operand is an operand of an instruction that a ProgramBuilder emits, in the
+// text form of the ASM format.
+type Operand string
+
+// Reg returns the operand of a register.
+func Reg(index int) Operand {
+	return Operand(fmt.Sprintf("$%d", index))
+}
+
+// Imm returns the operand of an immediate value.
+func Imm(value int64) Operand {
+	return Operand(fmt.Sprintf("%d", value))
+}
+
+// Arg returns the operand of a kernel argument.
+func Arg(index int) Operand {
+	return Operand(fmt.Sprintf("ARG%d", index))
+}
+
+// Const returns the operand of a named constant.
+func Const(name string) Operand {
+	return Operand(name)
+}
+
+// Target returns the operand of a label that a jump targets.
+func Target(label string) Operand {
+	return Operand(label)
+}
+
+// PortDir is the direction of a network port operand.
+type PortDir int
+
+// The directions of the port operands.
+const (
+	// R receives from the port.
+	R PortDir = iota
+
+	// S sends to the port.
+	S
+)
+
+// Port returns the operand that receives from or sends to the side. The
+// optional channel selects a channel other than channel 0.
+func Port(side cgra.Side, dir PortDir, channel ...int) Operand {
+	index := int(side)
+	if len(channel) > 0 {
+		if channel[0] < 0 || channel[0] >= MaxChannels {
+			panic(fmt.Sprintf("invalid channel %d", channel[0]))
+		}
+
+		index += 4 * channel[0]
+	}
+
+	if dir == S {
+		return Operand(fmt.Sprintf("NET_SEND_%d", index))
+	}
+
+	return Operand(fmt.Sprintf("NET_RECV_%d", index))
+}
+
+// ProgramBuilder builds the programs of a kernel in Go, e.g.,
+//
+//	b := core.NewProgramBuilder()
+//	b.At(0, 0).
+//		T(0).Op("WAIT").Dst(core.Reg(0)).Src(core.Port(cgra.West, core.R)).
+//		T(1).Op("SEND").Dst(core.Port(cgra.East, core.S)).Src(core.Reg(0))
+//	file := b.Build()
+type ProgramBuilder struct {
+	pes       map[[2]int]*PEBuilder
+	constants map[string]uint32
+}
+
+// NewProgramBuilder creates a builder without any programs.
+func NewProgramBuilder() *ProgramBuilder {
+	return &ProgramBuilder{
+		pes:       make(map[[2]int]*PEBuilder),
+		constants: make(map[string]uint32),
+	}
+}
+
+// Const declares a named constant of the kernel.
+func (b *ProgramBuilder) Const(name string, value uint32) *ProgramBuilder {
+	if !constantName.MatchString(name) {
+		panic(fmt.Sprintf("invalid constant name %q", name))
+	}
+
+	b.constants[name] = value
+
+	return b
+}
+
+// At returns the builder of the program of the PE at the coordinate.
+func (b *ProgramBuilder) At(x, y int) *PEBuilder {
+	coord := [2]int{x, y}
+
+	pe, ok := b.pes[coord]
+	if !ok {
+		pe = &PEBuilder{parent: b, coord: coord, open: -1}
+		b.pes[coord] = pe
+	}
+
+	return pe
+}
+
+// Build returns the programs as a program file whose constants are not
+// resolved yet.
+func (b *ProgramBuilder) Build() *ProgramFile {
+	file := &ProgramFile{
+		Programs:  make(map[[2]int]string),
+		Constants: make(map[string]uint32),
+	}
+
+	for coord, pe := range b.pes {
+		file.Programs[coord] = strings.Join(pe.Program(), "\n")
+	}
+
+	for name, value := range b.constants {
+		file.Constants[name] = value
+	}
+
+	return file
+}
+
+// Schedules returns the time steps of the programs of the PEs that set the
+// time step of any instruction, to be passed to SetSchedule.
+func (b *ProgramBuilder) Schedules() map[[2]int][]int {
+	schedules := make(map[[2]int][]int)
+
+	for coord, pe := range b.pes {
+		if pe.timed {
+			schedules[coord] = pe.Steps()
+		}
+	}
+
+	return schedules
+}
+
+// Coords returns the coordinates of the PEs that have programs, sorted by y
+// and then by x.
+func (b *ProgramBuilder) Coords() [][2]int {
+	coords := make([][2]int, 0, len(b.pes))
+	for coord := range b.pes {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	return coords
+}
+
+// PEBuilder builds the program of a PE. Each Op starts an instruction, and
+// the Dst and Src calls that follow add its operands. The line of an
+// instruction lists the opcode, the destinations, and then the sources.
+type PEBuilder struct {
+	parent *ProgramBuilder
+	coord  [2]int
+
+	lines [][]string
+	steps []int
+	timed bool
+
+	// step is the time step of the next instruction if stepped is set.
+	step    int
+	stepped bool
+
+	// open is the index of the instruction that takes operands, or -1.
+	open int
+	srcs []string
+}
+
+// At returns the builder of the program of another PE.
+func (p *PEBuilder) At(x, y int) *PEBuilder {
+	p.close()
+	return p.parent.At(x, y)
+}
+
+// T sets the time step of the next instruction. An instruction without a
+// time step executes one step after the previous one.
+func (p *PEBuilder) T(step int) *PEBuilder {
+	if step < 0 {
+		panic(fmt.Sprintf("invalid time step %d", step))
+	}
+
+	p.close()
+	p.step = step
+	p.stepped = true
+	p.timed = true
+
+	return p
+}
+
+// Op starts an instruction with the opcode.
+func (p *PEBuilder) Op(opcode string) *PEBuilder {
+	p.close()
+
+	step := 0
+	if p.stepped {
+		step = p.step
+	} else if len(p.steps) > 0 {
+		step = p.lastStep() + 1
+	}
+
+	p.lines = append(p.lines, []string{opcode})
+	p.steps = append(p.steps, step)
+	p.open = len(p.lines) - 1
+	p.stepped = false
+
+	return p
+}
+
+// Dst adds destination operands to the current instruction.
+func (p *PEBuilder) Dst(operands ...Operand) *PEBuilder {
+	line := p.current("Dst")
+	for _, o := range operands {
+		p.lines[line] = append(p.lines[line], string(o))
+	}
+
+	return p
+}
+
+// Src adds source operands to the current instruction. The sources follow
+// all the destinations, whichever order Dst and Src are called in.
+func (p *PEBuilder) Src(operands ...Operand) *PEBuilder {
+	p.current("Src")
+	for _, o := range operands {
+		p.srcs = append(p.srcs, string(o))
+	}
+
+	return p
+}
+
+// Label adds a label that jumps can target.
+func (p *PEBuilder) Label(name string) *PEBuilder {
+	p.close()
+
+	step := 0
+	if len(p.steps) > 0 {
+		step = p.lastStep()
+	}
+
+	p.lines = append(p.lines, []string{name + ":"})
+	p.steps = append(p.steps, step)
+
+	return p
+}
+
+// Program returns the lines of the program.
+func (p *PEBuilder) Program() []string {
+	p.close()
+
+	program := make([]string, len(p.lines))
+	for i, tokens := range p.lines {
+		program[i] = strings.Join(tokens, ", ")
+	}
+
+	return program
+}
+
+// Steps returns the time step of each line of the program, including the
+// labels, in the form that SetSchedule takes.
+func (p *PEBuilder) Steps() []int {
+	p.close()
+	return append([]int(nil), p.steps...)
+}
+
+// Build returns the programs of all the PEs.
+func (p *PEBuilder) Build() *ProgramFile {
+	p.close()
+	return p.parent.Build()
+}
+
+func (p *PEBuilder) current(method string) int {
+	if p.open < 0 {
+		panic(fmt.Sprintf("%s of PE(%d, %d) is called before Op",
+			method, p.coord[0], p.coord[1]))
+	}
+
+	return p.open
+}
+
+// close appends the sources of the current instruction, after which the
+// instruction takes no more operands.
+func (p *PEBuilder) close() {
+	if p.open < 0 {
+		return
+	}
+
+	p.lines[p.open] = append(p.lines[p.open], p.srcs...)
+	p.srcs = nil
+	p.open = -1
+}
+
+func (p *PEBuilder) lastStep() int {
+	return p.steps[len(p.steps)-1]
+}
@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+var _ = Describe("ProgramBuilder", func() {
+	It("should emit the opcode, the destinations, and the sources", func() {
+		b := core.NewProgramBuilder()
+		b.At(1, 2).
+			Label("START").
+			Op("WAIT").Dst(core.Reg(0)).Src(core.Port(cgra.West, core.R)).
+			Op("I_ADD").Src(core.Reg(0), core.Imm(-1)).Dst(core.Reg(1)).
+			Op("SEND").Dst(core.Port(cgra.East, core.S, 1)).Src(core.Reg(1)).
+			Op("JMP").Src(core.Target("START"))
+
+		Expect(b.At(1, 2).Program()).To(Equal([]string{
+			"START:",
+			"WAIT, $0, NET_RECV_3",
+			"I_ADD, $1, $0, -1",
+			"SEND, NET_SEND_5, $1",
+			"JMP, START",
+		}))
+		Expect(b.Schedules()).To(BeEmpty())
+	})
+
+	It("should keep the constants unresolved", func() {
+		b := core.NewProgramBuilder().Const("A", 3)
+		b.At(0, 0).Op("I_MUL").Dst(core.Reg(0)).Src(core.Arg(0), core.Const("A"))
+
+		file := b.Build()
+		Expect(file.Programs).To(Equal(map[[2]int]string{
+			{0, 0}: "I_MUL, $0, ARG0, A",
+		}))
+		Expect(file.Resolve()).To(Equal(map[[2]int]string{
+			{0, 0}: "I_MUL, $0, ARG0, 3",
+		}))
+	})
+
+	It("should give each instruction the step after the previous one", func() {
+		b := core.NewProgramBuilder()
+		b.At(0, 0).
+			T(2).Op("I_ADD").Dst(core.Reg(0)).Src(core.Imm(0), core.Imm(1)).
+			Op("I_ADD").Dst(core.Reg(1)).Src(core.Reg(0), core.Imm(1)).
+			Label("END").
+			T(7).Op("DONE")
+		b.At(1, 0).Op("DONE")
+
+		Expect(b.Schedules()).To(Equal(map[[2]int][]int{
+			{0, 0}: {2, 3, 3, 7},
+		}))
+		Expect(b.Coords()).To(Equal([][2]int{{0, 0}, {1, 0}}))
+	})
+
+	It("should panic if an operand comes before the opcode", func() {
+		b := core.NewProgramBuilder()
+
+		Expect(func() { b.At(0, 1).Src(core.Reg(0)) }).To(PanicWith(
+			"Src of PE(0, 1) is called before Op"))
+		Expect(func() { core.Port(cgra.North, core.R, core.MaxChannels) }).
+			To(Panic())
+	})
+
+	It("should build programs that run on their schedules", func() {
+		engine := sim.NewSerialEngine()
+		builder := core.Builder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithTracer(trace.Discard)
+		sender := builder.Build("Sender")
+		receiver := builder.Build("Receiver")
+		sender.SetRemotePort(cgra.East, receiver.GetPortByName("West"))
+		receiver.SetRemotePort(cgra.West, sender.GetPortByName("East"))
+
+		conn := sim.NewDirectConnection("Conn", engine, 1*sim.GHz)
+		conn.PlugIn(sender.GetPortByName("East"), 1)
+		conn.PlugIn(receiver.GetPortByName("West"), 1)
+
+		b := core.NewProgramBuilder()
+		b.At(0, 0).
+			T(0).Op("I_ADD").Dst(core.Reg(1)).Src(core.Imm(0), core.Imm(5)).
+			T(4).Op("SEND").Dst(core.Port(cgra.East, core.S)).Src(core.Reg(1)).
+			Op("DONE").
+			At(1, 0).
+			T(8).Op("WAIT").Dst(core.Reg(0)).Src(core.Port(cgra.West, core.R)).
+			Op("I_ADD").Dst(core.Reg(1)).Src(core.Reg(0), core.Imm(1)).
+			Op("DONE")
+
+		sender.MapProgram(b.At(0, 0).Program())
+		receiver.MapProgram(b.At(1, 0).Program())
+		schedules := b.Schedules()
+		sender.SetSchedule(schedules[[2]int{0, 0}], 8)
+		receiver.SetSchedule(schedules[[2]int{1, 0}], 12)
+		Expect(engine.Run()).To(Succeed())
+
+		Expect(receiver.GetState().Registers[1]).To(Equal(uint32(6)))
+	})
+})
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// schedule is the static schedule that a core enforces in the strict timing
+// mode. The instruction on line i of the program executes for the k-th time,
+// counting from 0, at cycle steps[i] + k * ii after the start.
+type schedule struct {
+	steps []int
+	ii    int
+	start uint64
+	runs  []int
+}
+
+// SetSchedule makes the core follow a static schedule instead of executing
+// each instruction as soon as it can. Steps has the time step of each line
+// of the program, including the labels, whose steps are not used, counted
+// from the first tick after the schedule is set. An instruction that the core
+// reaches before its cycle waits, and an
+// instruction that cannot execute in its cycle, e.g., because its data has
+// not arrived, panics with the reason.
+func (c *Core) SetSchedule(steps []int, ii int) {
+	if ii <= 0 {
+		panic(fmt.Sprintf("invalid II %d", ii))
+	}
+
+	c.schedule = &schedule{
+		steps: steps,
+		ii:    ii,
+		start: c.Freq.Cycle(c.Freq.NextTick(c.Engine.CurrentTime())),
+		runs:  make([]int, len(steps)),
+	}
+
+	c.TickLater(c.Engine.CurrentTime())
+}
+
+// dueCycle returns the cycle in which the instruction on the line is due.
+func (s *schedule) dueCycle(line int) uint64 {
+	return s.start + uint64(s.steps[line]+s.runs[line]*s.ii)
+}
+
+// waitsForSlot returns true if the instruction on the line is not due yet.
+// It panics if the instruction is late.
+func (c *Core) waitsForSlot(line int) bool {
+	s := c.schedule
+	cycle := c.Freq.Cycle(c.Engine.CurrentTime())
+
+	if line >= len(s.steps) {
+		panic(fmt.Sprintf("%s has no time step for line %d of its program",
+			c.Name(), line))
+	}
+
+	due := s.dueCycle(line)
+	if cycle > due {
+		c.missSchedule(line, fmt.Sprintf("it is late, as the core only "+
+			"reached it in cycle %d", cycle-s.start))
+	}
+
+	return cycle < due
+}
+
+// dueNow returns true if the instruction on the line is due in the current
+// cycle.
+func (c *Core) dueNow(line int) bool {
+	s := c.schedule
+	if line >= len(s.steps) {
+		return false
+	}
+
+	return c.Freq.Cycle(c.Engine.CurrentTime()) == s.dueCycle(line)
+}
+
+// notDue returns true if the core has a schedule, in which the instruction
+// on the line is not due in the current cycle.
+func (c *Core) notDue(line int) bool {
+	s := c.schedule
+	if s == nil || line >= len(s.steps) {
+		return false
+	}
+
+	return c.Freq.Cycle(c.Engine.CurrentTime()) < s.dueCycle(line)
+}
+
+// missSchedule panics with the instruction on the line and the reason why
+// it cannot execute in its cycle.
+func (c *Core) missSchedule(line int, reason string) {
+	s := c.schedule
+	panic(fmt.Sprintf("%s misses its schedule in cycle %d: %q "+
+		"(line %d, step %d, iteration %d) cannot execute, as %s",
+		c.Name(), s.dueCycle(line)-s.start, c.state.Code[line],
+		line, s.steps[line], s.runs[line], reason))
+}
+
+// stallCause describes why the next instruction cannot execute.
+func (c *Core) stallCause(op *operation) string {
+	st := &c.state
+
+	switch c.stallReason() {
+	case stallInput:
+		empty := []string{}
+		for i := range st.RecvBufHead {
+			if !st.RecvBufHeadReady[i] && readsRecv(op, i) {
+				empty = append(empty, fmt.Sprintf("NET_RECV_%d (%s)",
+					i, portName(i)))
+			}
+		}
+
+		return strings.Join(empty, ", ") + " has no data"
+	case stallOutput:
+		i := op.operands[0].index
+		return fmt.Sprintf("NET_SEND_%d (%s) is still busy", i, portName(i))
+	case stallBarrier:
+		return "it waits at the barrier"
+	default:
+		return "it has not finished"
+	}
+}
+
+// readsRecv returns true if the instruction reads the NET_RECV register with
+// the index.
+func readsRecv(op *operation, index int) bool {
+	if strings.HasPrefix(op.opcode, "REDUCE_") {
+		return op.operands[2].value&(1<<index) != 0
+	}
+
+	for _, o := range op.operands {
+		if o.kind == operandRecv && o.index == index {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package core
+
+import "strings"
+
+// sourceMarker separates an instruction from its source metadata, as in
+//
+//	I_ADD, $0, $0, $1 @ src=fir.c:12 node=add3
+const sourceMarker = " @ "
+
+// Source is where an instruction comes from in the source kernel. Compilers
+// can attach it to the instructions that they emit, so that lint issues,
+// runtime panics, and traces point back to the kernel.
+type Source struct {
+	// Loc is the location in the source code, e.g., fir.c:12.
+	Loc string
+
+	// Node is the ID of the node of the dataflow graph.
+	Node string
+}
+
+// IsZero returns true if the source is unknown.
+func (s Source) IsZero() bool {
+	return s == Source{}
+}
+
+func (s Source) String() string {
+	switch {
+	case s.Loc != "" && s.Node != "":
+		return s.Loc + ", node " + s.Node
+	case s.Node != "":
+		return "node " + s.Node
+	default:
+		return s.Loc
+	}
+}
+
+// SourceOf returns the source metadata of a line of a program. It returns a
+// zero Source if the line has none or if the metadata is invalid.
+func SourceOf(line string) Source {
+	s, _ := parseSource(line)
+	return s
+}
+
+// StripSource returns a line of a program without its source metadata.
+func StripSource(line string) string {
+	if i := strings.Index(line, sourceMarker); i >= 0 {
+		return line[:i]
+	}
+
+	return line
+}
+
+// sourceSuffix returns the source metadata of a line with its marker, so
+// that a rewritten instruction can keep it.
+func sourceSuffix(line string) string {
+	return line[len(StripSource(line)):]
+}
+
+// parseSource parses the source metadata of a line, which is a list of
+// key=value pairs, where the keys are src and node. It also returns the
+// first pair that is invalid, if any.
+func parseSource(line string) (Source, string) {
+	s := Source{}
+
+	i := strings.Index(line, sourceMarker)
+	if i < 0 {
+		return s, ""
+	}
+
+	for _, field := range strings.Fields(line[i+len(sourceMarker):]) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return Source{}, field
+		}
+
+		switch kv[0] {
+		case "src":
+			s.Loc = kv[1]
+		case "node":
+			s.Node = kv[1]
+		default:
+			return Source{}, field
+		}
+	}
+
+	return s, ""
+}
@@ -0,0 +1,60 @@
+package core
+
+import "github.com/sarchlab/zeonica/cgra"
+
+// stallReason is the reason why a core cannot execute an instruction.
+type stallReason int
+
+const (
+	stallNoProgram stallReason = iota
+	stallNone
+	stallInput
+	stallOutput
+	stallBarrier
+	stallSchedule
+)
+
+// stallReason classifies why the next instruction of the core cannot run
+// now. It returns stallNone if the instruction can run.
+func (c *Core) stallReason() stallReason {
+	s := &c.state
+	if s.Done {
+		return stallNoProgram
+	}
+
+	if s.AtBarrier {
+		return stallBarrier
+	}
+
+	op := c.nextOp()
+
+	switch {
+	case op == nil:
+		return stallNoProgram
+	case c.notDue(c.nextLine()):
+		return stallSchedule
+	case s.waitsForRecv(op):
+		return stallInput
+	case len(op.operands) > 0 && op.operands[0].kind == operandSend &&
+		s.SendBufHeadBusy[op.operands[0].index]:
+		return stallOutput
+	}
+
+	return stallNone
+}
+
+// addStall adds the cycles to the counter of the reason.
+func addStall(stats *cgra.StallStats, reason stallReason, cycles uint64) {
+	switch reason {
+	case stallNoProgram:
+		stats.NoProgramCycles += cycles
+	case stallInput:
+		stats.InputCycles += cycles
+	case stallOutput:
+		stats.OutputCycles += cycles
+	case stallBarrier:
+		stats.BarrierCycles += cycles
+	case stallSchedule:
+		stats.ScheduleCycles += cycles
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// watch is a watchpoint of the core and the value that its location had
+// after the last instruction.
+type watch struct {
+	cgra.Watchpoint
+	last uint32
+	hit  func(cgra.WatchHit)
+}
+
+// Watch calls hit in the cycle in which an instruction first sets the
+// location of the watchpoint to its value, from a different value. The
+// watchpoint is removed after the hit.
+func (c *Core) Watch(w cgra.Watchpoint, hit func(cgra.WatchHit)) {
+	if w.Memory {
+		c.checkMemoryRange(w.Index, 1)
+	} else {
+		c.checkRegister(w.Index)
+	}
+
+	c.watches = append(c.watches, &watch{
+		Watchpoint: w,
+		last:       c.watchedValue(w),
+		hit:        hit,
+	})
+}
+
+func (c *Core) watchedValue(w cgra.Watchpoint) uint32 {
+	if w.Memory {
+		return c.state.Memory[w.Index]
+	}
+
+	return c.state.Registers[w.Index]
+}
+
+// checkWatches reports the watchpoints that the instruction at the PC has
+// hit.
+func (c *Core) checkWatches(op *operation, pc uint32) {
+	kept := c.watches[:0]
+
+	for _, w := range c.watches {
+		v := c.watchedValue(w.Watchpoint)
+		if v != w.Value || w.last == w.Value {
+			w.last = v
+			kept = append(kept, w)
+
+			continue
+		}
+
+		hit := cgra.WatchHit{
+			Watchpoint: w.Watchpoint,
+			Time:       c.Engine.CurrentTime(),
+			PC:         pc,
+			Inst:       strings.TrimSpace(StripSource(op.text)),
+		}
+		if !op.source.IsZero() {
+			hit.Source = op.source.String()
+		}
+
+		w.hit(hit)
+	}
+
+	c.watches = kept
+}
@@ -0,0 +1,149 @@
+// Package experiments sweeps grids of parameters, runs a simulation for each
+// configuration, and stores the results in a CSV file.
+//
+// Each configuration is identified by the hash of its parameters, so a
+// sweep that is interrupted skips the configurations whose results are
+// already in the file when it runs again.
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Grid maps the name of each parameter to the values to sweep.
+type Grid map[string][]string
+
+// Names returns the names of the parameters in sorted order.
+func (g Grid) Names() []string {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Configs returns every combination of the values of the parameters. The
+// last parameter in sorted order changes the fastest.
+func (g Grid) Configs() []Config {
+	configs := []Config{{}}
+
+	for _, name := range g.Names() {
+		next := make([]Config, 0, len(configs)*len(g[name]))
+
+		for _, c := range configs {
+			for _, v := range g[name] {
+				nc := Config{name: v}
+				for k, old := range c {
+					nc[k] = old
+				}
+
+				next = append(next, nc)
+			}
+		}
+
+		configs = next
+	}
+
+	return configs
+}
+
+// Config is a configuration of a sweep, which maps the name of each
+// parameter to its value.
+type Config map[string]string
+
+// Hash returns a short hash of the parameters and their values, which does
+// not depend on the order of the parameters.
+func (c Config) Hash() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, c[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// String lists the parameters, e.g., "kernel=fir link_latency=2".
+func (c Config) String() string {
+	parts := make([]string, 0, len(c))
+	for name, v := range c {
+		parts = append(parts, name+"="+v)
+	}
+
+	sort.Strings(parts)
+
+	return strings.Join(parts, " ")
+}
+
+// Int returns the value of an integer parameter, or def if the
+// configuration does not have the parameter.
+func (c Config) Int(name string, def int) (int, error) {
+	v, ok := c[name]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s: invalid integer %q", name, v)
+	}
+
+	return i, nil
+}
+
+func (c Config) has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
+
+// Result maps the name of each metric of a run to its value.
+type Result map[string]float64
+
+// RunFunc runs the simulation of a configuration.
+type RunFunc func(c Config) (Result, error)
+
+// Sweep runs each configuration of the grid whose result is not in the
+// store yet, and adds the result to the store right away. A run that fails
+// is stored with its error, so that it is not repeated either. progress, if
+// not nil, is called after each run.
+func Sweep(grid Grid, run RunFunc, store *Store, progress func(Row)) error {
+	for _, c := range grid.Configs() {
+		if store.Has(c.Hash()) {
+			continue
+		}
+
+		row := Row{Hash: c.Hash(), Config: c}
+
+		result, err := run(c)
+		if err != nil {
+			row.Error = err.Error()
+		} else {
+			row.Result = result
+		}
+
+		err = store.Add(row)
+		if err != nil {
+			return err
+		}
+
+		if progress != nil {
+			progress(row)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package experiments_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/experiments"
+)
+
+var _ = Describe("Sweep", func() {
+	grid := experiments.Grid{
+		"size":    {"2", "4"},
+		"latency": {"1", "2", "3"},
+	}
+
+	It("should list every combination of the parameters", func() {
+		configs := grid.Configs()
+
+		Expect(configs).To(HaveLen(6))
+		Expect(configs[0].String()).To(Equal("latency=1 size=2"))
+		Expect(configs[1].String()).To(Equal("latency=1 size=4"))
+		Expect(configs[5].String()).To(Equal("latency=3 size=4"))
+		Expect(experiments.Config{"a": "1", "b": "2"}.Hash()).
+			To(Equal(experiments.Config{"b": "2", "a": "1"}.Hash()))
+		Expect(experiments.Config{"a": "1"}.Hash()).
+			NotTo(Equal(experiments.Config{"a": "2"}.Hash()))
+	})
+
+	It("should store the results and resume", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "results.csv")
+		runs := 0
+		run := func(c experiments.Config) (experiments.Result, error) {
+			runs++
+			size, _ := c.Int("size", 0)
+			latency, _ := c.Int("latency", 0)
+			if latency == 3 {
+				return nil, errors.New("too slow")
+			}
+
+			return experiments.Result{"cycles": float64(size * latency)}, nil
+		}
+
+		store, err := experiments.OpenStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(experiments.Sweep(experiments.Grid{"size": {"2"},
+			"latency": {"1", "3"}}, run, store, nil)).To(Succeed())
+		Expect(runs).To(Equal(2))
+
+		store, err = experiments.OpenStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(experiments.Sweep(grid, run, store, nil)).To(Succeed())
+		Expect(runs).To(Equal(6))
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("hash,latency,size,metric:cycles,error\n"))
+
+		store, err = experiments.OpenStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		rows := store.Rows()
+		Expect(rows).To(HaveLen(6))
+		Expect(rows[1].Config).To(Equal(experiments.Config{
+			"latency": "3", "size": "2"}))
+		Expect(rows[1].Error).To(Equal("too slow"))
+		Expect(rows[2].Result).To(Equal(experiments.Result{"cycles": 4}))
+	})
+
+	It("should run the bench kernels", func() {
+		slow, err := experiments.RunKernel(experiments.Config{
+			"kernel": "relu", "width": "4", "height": "2", "link_latency": "4"})
+		Expect(err).NotTo(HaveOccurred())
+
+		fast, err := experiments.RunKernel(experiments.Config{
+			"kernel": "relu", "width": "4", "height": "2", "link_latency": "1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slow["cycles"]).To(BeNumerically(">", fast["cycles"]))
+
+		_, err = experiments.RunKernel(experiments.Config{
+			"kernel": "relu", "width": "four"})
+		Expect(err).To(MatchError("parameter width: invalid integer \"four\""))
+	})
+})
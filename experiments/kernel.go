@@ -0,0 +1,53 @@
+package experiments
+
+import (
+	"github.com/sarchlab/zeonica/bench"
+	"github.com/sarchlab/zeonica/config"
+)
+
+// RunKernel runs a kernel of the bench package. It reads the parameters
+// kernel, the name of the kernel; width and height, the size of the array,
+// which default to the size of the kernel; and link_latency and
+// link_bandwidth, the LinkConfig of the links between the tiles. The
+// metrics are the cycles of the run and the simulated cycles per second.
+func RunKernel(c Config) (Result, error) {
+	k, err := bench.FindKernel(c["kernel"])
+	if err != nil {
+		return nil, err
+	}
+
+	link := config.LinkConfig{}
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"width", &k.Width},
+		{"height", &k.Height},
+		{"link_latency", &link.Latency},
+		{"link_bandwidth", &link.Bandwidth},
+	}
+
+	for _, p := range ints {
+		*p.dst, err = c.Int(p.name, *p.dst)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Without the link parameters, the tiles connect through the mesh
+	// network, as in the baseline of the bench package.
+	device := config.DeviceBuilder{}
+	if c.has("link_latency") || c.has("link_bandwidth") {
+		device = device.WithLinks(link)
+	}
+
+	r, err := bench.RunOn(k, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return Result{
+		"cycles":         float64(r.Cycles),
+		"cycles_per_sec": r.CyclesPerSec,
+	}, nil
+}
@@ -0,0 +1,190 @@
+package experiments
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Row is the record of a run in a store.
+type Row struct {
+	Hash   string
+	Config Config
+	Result Result
+
+	// Error is the error of a run that has failed, or empty.
+	Error string
+}
+
+// Store is a CSV file of the rows of a sweep. The header has the hash, the
+// parameters, the metrics with a "metric:" prefix, and the error, e.g.,
+//
+//	hash,kernel,link_latency,metric:cycles,error
+type Store struct {
+	path string
+	rows []Row
+	seen map[string]bool
+}
+
+// OpenStore reads the rows of a CSV file, or starts an empty store if the
+// file does not exist. The file is written on each Add.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, seen: make(map[string]bool)}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(records) == 0 {
+		return s, nil
+	}
+
+	for i, record := range records[1:] {
+		row, err := parseRow(records[0], record)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+2, err)
+		}
+
+		s.rows = append(s.rows, row)
+		s.seen[row.Hash] = true
+	}
+
+	return s, nil
+}
+
+func parseRow(header, record []string) (Row, error) {
+	row := Row{Config: Config{}, Result: Result{}}
+
+	for i, name := range header {
+		v := record[i]
+
+		switch {
+		case name == "hash":
+			row.Hash = v
+		case name == "error":
+			row.Error = v
+		case strings.HasPrefix(name, "metric:"):
+			if v == "" {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return row, fmt.Errorf("invalid value %q of %s", v, name)
+			}
+
+			row.Result[strings.TrimPrefix(name, "metric:")] = f
+		case v != "":
+			row.Config[name] = v
+		}
+	}
+
+	return row, nil
+}
+
+// Has returns true if the store has the row of the configuration with the
+// hash.
+func (s *Store) Has(hash string) bool {
+	return s.seen[hash]
+}
+
+// Rows returns the rows in the order that they were added.
+func (s *Store) Rows() []Row {
+	return append([]Row(nil), s.rows...)
+}
+
+// Add adds a row and writes the file. The file is replaced atomically, so
+// an interrupted sweep never leaves a partial file.
+func (s *Store) Add(row Row) error {
+	s.rows = append(s.rows, row)
+	s.seen[row.Hash] = true
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sweep-*.csv")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := csv.NewWriter(tmp)
+	err = w.WriteAll(s.records())
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// records returns the header and the rows of the file.
+func (s *Store) records() [][]string {
+	params, metrics := map[string]bool{}, map[string]bool{}
+	for _, row := range s.rows {
+		for name := range row.Config {
+			params[name] = true
+		}
+
+		for name := range row.Result {
+			metrics[name] = true
+		}
+	}
+
+	paramNames, metricNames := sortedKeys(params), sortedKeys(metrics)
+
+	header := append([]string{"hash"}, paramNames...)
+	for _, name := range metricNames {
+		header = append(header, "metric:"+name)
+	}
+
+	records := [][]string{append(header, "error")}
+
+	for _, row := range s.rows {
+		record := []string{row.Hash}
+		for _, name := range paramNames {
+			record = append(record, row.Config[name])
+		}
+
+		for _, name := range metricNames {
+			v, ok := row.Result[name]
+			if ok {
+				record = append(record, strconv.FormatFloat(v, 'g', -1, 64))
+			} else {
+				record = append(record, "")
+			}
+		}
+
+		records = append(records, append(record, row.Error))
+	}
+
+	return records
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
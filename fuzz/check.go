@@ -0,0 +1,56 @@
+package fuzz
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// Run simulates the kernel and returns the outputs of the last stage.
+func Run(k Kernel) []uint32 {
+	engine := sim.NewSerialEngine()
+	driver := api.DriverBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Driver")
+	device := config.DeviceBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWidth(len(k.Stages)).
+		WithHeight(1).
+		WithTracer(trace.Discard).
+		Build("Device")
+	driver.RegisterDevice(device)
+
+	outputs := make([]uint32, len(k.Inputs))
+	driver.FeedIn(k.Inputs, cgra.West, [2]int{0, 1}, 1)
+	driver.Collect(outputs, cgra.East, [2]int{0, 1}, 1)
+
+	for coord, program := range k.Programs() {
+		driver.MapProgram(program, coord)
+	}
+
+	driver.Run()
+
+	return outputs
+}
+
+// Check simulates the kernel and returns an error if the outputs differ
+// from the outputs of the reference model.
+func Check(k Kernel) error {
+	expected := k.Expected()
+	outputs := Run(k)
+
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			return fmt.Errorf("output %d is %d, expected %d\n%s",
+				i, outputs[i], expected[i], k)
+		}
+	}
+
+	return nil
+}
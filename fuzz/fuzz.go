@@ -0,0 +1,408 @@
+// Package fuzz generates random but well-formed kernels and cross-checks the
+// results of the simulator against a reference model of the kernels.
+//
+// A kernel is a pipeline of stages that runs on a row of PEs. Each stage
+// waits for a value from the west, runs a sequence of integer, float32,
+// predicated, and local memory instructions, and sends a register to the
+// east, so every SEND has a matching WAIT.
+package fuzz
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// MemoryWords is the number of words, from address 0, of the local memory
+// that the memory instructions access.
+const MemoryWords = 16
+
+// Operand is a register or an immediate value.
+type Operand struct {
+	Reg   int
+	Imm   uint32
+	IsImm bool
+}
+
+func (o Operand) String() string {
+	if o.IsImm {
+		return fmt.Sprint(o.Imm)
+	}
+
+	return fmt.Sprintf("$%d", o.Reg)
+}
+
+func (o Operand) value(regs []uint32) uint32 {
+	if o.IsImm {
+		return o.Imm
+	}
+
+	return regs[o.Reg]
+}
+
+// Op is an instruction. The second source of a compare instruction and the
+// address of a memory instruction are always immediate
+// values, and SCATTER and SCATTER_ADD have no destination.
+type Op struct {
+	Opcode string
+	Dst    int
+	Src    []Operand
+}
+
+func (o Op) String() string {
+	operands := []string{}
+	if !isStore(o.Opcode) {
+		operands = append(operands, fmt.Sprintf("$%d", o.Dst))
+	}
+
+	for _, src := range o.Src {
+		operands = append(operands, src.String())
+	}
+
+	return o.Opcode + ", " + strings.Join(operands, ", ")
+}
+
+// canonicalNaN is the NaN that the float32 instructions write.
+const canonicalNaN = 0x7fc00000
+
+func f32Arith(f func(a, b float32) float32) func(a, b uint32) uint32 {
+	return func(a, b uint32) uint32 {
+		v := f(math.Float32frombits(a), math.Float32frombits(b))
+		if v != v {
+			return canonicalNaN
+		}
+
+		return math.Float32bits(v)
+	}
+}
+
+func f32Cmp(f func(a, b float32) bool) func(a, b uint32) uint32 {
+	return func(a, b uint32) uint32 {
+		return boolValue(f(math.Float32frombits(a), math.Float32frombits(b)))
+	}
+}
+
+func intCmp(f func(a, b int32) bool) func(a, b uint32) uint32 {
+	return func(a, b uint32) uint32 {
+		return boolValue(f(int32(a), int32(b)))
+	}
+}
+
+func boolValue(b bool) uint32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// binaryOps are the reference model of the instructions that write a
+// function of two sources.
+var binaryOps = map[string]func(a, b uint32) uint32{
+	"I_ADD": func(a, b uint32) uint32 { return a + b },
+	"I_SUB": func(a, b uint32) uint32 { return a - b },
+	"I_MUL": func(a, b uint32) uint32 { return a * b },
+
+	"I_CMP_EQ": intCmp(func(a, b int32) bool { return a == b }),
+	"I_CMP_NE": intCmp(func(a, b int32) bool { return a != b }),
+	"I_CMP_LT": intCmp(func(a, b int32) bool { return a < b }),
+	"I_CMP_LE": intCmp(func(a, b int32) bool { return a <= b }),
+	"I_CMP_GT": intCmp(func(a, b int32) bool { return a > b }),
+	"I_CMP_GE": intCmp(func(a, b int32) bool { return a >= b }),
+
+	"F32_ADD": f32Arith(func(a, b float32) float32 { return a + b }),
+	"F32_SUB": f32Arith(func(a, b float32) float32 { return a - b }),
+	"F32_MUL": f32Arith(func(a, b float32) float32 { return a * b }),
+
+	"F32_CMP_EQ": f32Cmp(func(a, b float32) bool { return a == b }),
+	"F32_CMP_NE": f32Cmp(func(a, b float32) bool { return a != b }),
+	"F32_CMP_LT": f32Cmp(func(a, b float32) bool { return a < b }),
+	"F32_CMP_LE": f32Cmp(func(a, b float32) bool { return a <= b }),
+	"F32_CMP_GT": f32Cmp(func(a, b float32) bool { return a > b }),
+	"F32_CMP_GE": f32Cmp(func(a, b float32) bool { return a >= b }),
+}
+
+var opcodes = []string{
+	"I_ADD", "I_SUB", "I_MUL",
+	"I_CMP_EQ", "I_CMP_NE", "I_CMP_LT", "I_CMP_LE", "I_CMP_GT", "I_CMP_GE",
+	"F32_ADD", "F32_SUB", "F32_MUL",
+	"F32_CMP_EQ", "F32_CMP_NE", "F32_CMP_LT", "F32_CMP_LE", "F32_CMP_GT",
+	"F32_CMP_GE",
+	"SEL", "GRANT_PREDICATE",
+	"GATHER", "SCATTER", "SCATTER_ADD",
+}
+
+// numSources returns the number of sources of an opcode.
+func numSources(opcode string) int {
+	switch opcode {
+	case "SEL":
+		return 3
+	case "GATHER":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// isStore returns true for the instructions that write the local memory
+// instead of a register.
+func isStore(opcode string) bool {
+	return opcode == "SCATTER" || opcode == "SCATTER_ADD"
+}
+
+// isMemory returns true for the instructions whose first source is an
+// address of the local memory.
+func isMemory(opcode string) bool {
+	return opcode == "GATHER" || isStore(opcode)
+}
+
+func (o Op) run(regs, mem []uint32) {
+	src := make([]uint32, len(o.Src))
+	for i, s := range o.Src {
+		src[i] = s.value(regs)
+	}
+
+	switch o.Opcode {
+	case "SEL":
+		regs[o.Dst] = src[2]
+		if src[0] != 0 {
+			regs[o.Dst] = src[1]
+		}
+	case "GRANT_PREDICATE":
+		if src[1] != 0 {
+			regs[o.Dst] = src[0]
+		}
+	case "GATHER":
+		regs[o.Dst] = mem[src[0]]
+	case "SCATTER":
+		mem[src[0]] = src[1]
+	case "SCATTER_ADD":
+		mem[src[0]] += src[1]
+	default:
+		regs[o.Dst] = binaryOps[o.Opcode](src[0], src[1])
+	}
+}
+
+// Stage is the program of a PE. Out is the register that the stage sends.
+type Stage struct {
+	Ops []Op
+	Out int
+}
+
+// Program returns the program of the stage.
+func (s Stage) Program() string {
+	lines := []string{"START:", "WAIT, $0, NET_RECV_3"}
+	for _, op := range s.Ops {
+		lines = append(lines, op.String())
+	}
+
+	lines = append(lines, fmt.Sprintf("SEND, NET_SEND_1, $%d", s.Out), "JMP, START")
+
+	return strings.Join(lines, "\n")
+}
+
+// Kernel is a pipeline of stages that run on the PEs from [0, 0] to the
+// east. The inputs are fed into the west side of the first PE.
+type Kernel struct {
+	Stages    []Stage
+	Inputs    []uint32
+	Registers int
+}
+
+// Programs returns the programs of the PEs, keyed by the [x, y] coordinate.
+func (k Kernel) Programs() map[[2]int]string {
+	programs := make(map[[2]int]string)
+	for x, s := range k.Stages {
+		programs[[2]int{x, 0}] = s.Program()
+	}
+
+	return programs
+}
+
+// Expected returns the outputs of the last stage according to the
+// reference model. Registers and the local memories keep their values
+// across iterations.
+func (k Kernel) Expected() []uint32 {
+	regs := make([][]uint32, len(k.Stages))
+	mems := make([][]uint32, len(k.Stages))
+	for i := range regs {
+		regs[i] = make([]uint32, k.Registers)
+		mems[i] = make([]uint32, MemoryWords)
+	}
+
+	outputs := make([]uint32, 0, len(k.Inputs))
+	for _, v := range k.Inputs {
+		for i, s := range k.Stages {
+			regs[i][0] = v
+			for _, op := range s.Ops {
+				op.run(regs[i], mems[i])
+			}
+
+			v = regs[i][s.Out]
+		}
+
+		outputs = append(outputs, v)
+	}
+
+	return outputs
+}
+
+func (k Kernel) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "inputs: %v\n", k.Inputs)
+
+	for x, s := range k.Stages {
+		fmt.Fprintf(b, "PE(%d, 0):\n%s\n", x, s.Program())
+	}
+
+	return b.String()
+}
+
+// Config bounds the size of the generated kernels.
+type Config struct {
+	MaxStages int
+	MaxOps    int
+	MaxInputs int
+
+	// Registers is the number of registers, from $0, that the programs use.
+	Registers int
+}
+
+// DefaultConfig generates small kernels that are quick to simulate.
+var DefaultConfig = Config{MaxStages: 4, MaxOps: 6, MaxInputs: 8, Registers: 4}
+
+// Generate creates a random kernel.
+func Generate(r *rand.Rand, cfg Config) Kernel {
+	k := Kernel{
+		Stages:    make([]Stage, 1+r.Intn(cfg.MaxStages)),
+		Inputs:    make([]uint32, 1+r.Intn(cfg.MaxInputs)),
+		Registers: cfg.Registers,
+	}
+
+	for i := range k.Inputs {
+		k.Inputs[i] = randomValue(r)
+	}
+
+	for i := range k.Stages {
+		ops := make([]Op, r.Intn(cfg.MaxOps+1))
+		for j := range ops {
+			ops[j] = randomOp(r, cfg.Registers)
+		}
+
+		k.Stages[i] = Stage{Ops: ops, Out: r.Intn(cfg.Registers)}
+	}
+
+	return k
+}
+
+// specialFloats are the float32 bit patterns where rounding, signed zeros,
+// infinities, denormals, and NaNs show up.
+var specialFloats = []uint32{
+	0x00000000, 0x80000000, 0x3f800000, 0xbf800000, 0x7f800000, 0xff800000,
+	0x7fc00000, 0x7f800001, 0x00000001, 0x007fffff, 0x00800000, 0x7f7fffff,
+}
+
+// randomValue favors the values around 0 and the limits of int32, where
+// overflows and sign errors show up, and the special float32 values.
+func randomValue(r *rand.Rand) uint32 {
+	switch r.Intn(4) {
+	case 0:
+		return uint32(r.Intn(16))
+	case 1:
+		return uint32(0x7ffffff0 + r.Intn(32))
+	case 2:
+		return specialFloats[r.Intn(len(specialFloats))]
+	default:
+		return r.Uint32()
+	}
+}
+
+func randomOp(r *rand.Rand, registers int) Op {
+	op := Op{
+		Opcode: opcodes[r.Intn(len(opcodes))],
+		Dst:    r.Intn(registers),
+	}
+
+	op.Src = make([]Operand, numSources(op.Opcode))
+	for i := range op.Src {
+		switch {
+		case r.Intn(2) == 0:
+			op.Src[i] = Operand{Reg: r.Intn(registers)}
+		case strings.HasPrefix(op.Opcode, "F32_"):
+			op.Src[i] = Operand{Imm: randomValue(r), IsImm: true}
+		default:
+			op.Src[i] = Operand{Imm: uint32(r.Intn(1000)), IsImm: true}
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(op.Opcode, "F32_CMP_"):
+		op.Src[1] = Operand{Imm: randomValue(r), IsImm: true}
+	case strings.HasPrefix(op.Opcode, "I_CMP_"):
+		op.Src[1] = Operand{Imm: uint32(r.Intn(1000)), IsImm: true}
+	case isMemory(op.Opcode):
+		op.Src[0] = Operand{Imm: uint32(r.Intn(MemoryWords)), IsImm: true}
+	}
+
+	return op
+}
+
+// Shrink returns a smaller kernel that still fails, by repeatedly removing
+// inputs, stages, and instructions as long as fails returns true.
+func Shrink(k Kernel, fails func(Kernel) bool) Kernel {
+	for {
+		smaller, ok := shrinkOnce(k, fails)
+		if !ok {
+			return k
+		}
+
+		k = smaller
+	}
+}
+
+func shrinkOnce(k Kernel, fails func(Kernel) bool) (Kernel, bool) {
+	for _, c := range candidates(k) {
+		if fails(c) {
+			return c, true
+		}
+	}
+
+	return k, false
+}
+
+// candidates lists the kernels that are one step smaller than k.
+func candidates(k Kernel) []Kernel {
+	list := []Kernel{}
+
+	for i := range k.Inputs {
+		if len(k.Inputs) > 1 {
+			c := k
+			c.Inputs = remove(k.Inputs, i)
+			list = append(list, c)
+		}
+	}
+
+	for i := range k.Stages {
+		if len(k.Stages) > 1 {
+			c := k
+			c.Stages = append(append([]Stage{}, k.Stages[:i]...),
+				k.Stages[i+1:]...)
+			list = append(list, c)
+		}
+
+		for j := range k.Stages[i].Ops {
+			c := k
+			c.Stages = append([]Stage{}, k.Stages...)
+			ops := k.Stages[i].Ops
+			c.Stages[i].Ops = append(append([]Op{}, ops[:j]...), ops[j+1:]...)
+			list = append(list, c)
+		}
+	}
+
+	return list
+}
+
+func remove(values []uint32, i int) []uint32 {
+	return append(append([]uint32{}, values[:i]...), values[i+1:]...)
+}
@@ -0,0 +1,110 @@
+package fuzz_test
+
+import (
+	"math/rand"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/fuzz"
+)
+
+var _ = Describe("Fuzz", func() {
+	It("should match the reference model on random kernels", func() {
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 50; i++ {
+			k := fuzz.Generate(r, fuzz.DefaultConfig)
+
+			err := fuzz.Check(k)
+			if err != nil {
+				k = fuzz.Shrink(k, func(k fuzz.Kernel) bool {
+					return fuzz.Check(k) != nil
+				})
+				Fail("mismatch, shrunk to:\n" + fuzz.Check(k).Error())
+			}
+		}
+	})
+
+	It("should generate float, predicate, and memory instructions", func() {
+		r := rand.New(rand.NewSource(1))
+		generated := map[string]bool{}
+
+		for i := 0; i < 50; i++ {
+			for _, s := range fuzz.Generate(r, fuzz.DefaultConfig).Stages {
+				for _, op := range s.Ops {
+					generated[op.Opcode] = true
+				}
+			}
+		}
+
+		Expect(generated).To(HaveKey("F32_MUL"))
+		Expect(generated).To(HaveKey("F32_CMP_LT"))
+		Expect(generated).To(HaveKey("SEL"))
+		Expect(generated).To(HaveKey("GRANT_PREDICATE"))
+		Expect(generated).To(HaveKey("GATHER"))
+		Expect(generated).To(HaveKey("SCATTER_ADD"))
+	})
+
+	It("should model the float, predicate, and memory instructions", func() {
+		imm := func(v uint32) fuzz.Operand {
+			return fuzz.Operand{Imm: v, IsImm: true}
+		}
+		reg := func(r int) fuzz.Operand {
+			return fuzz.Operand{Reg: r}
+		}
+
+		k := fuzz.Kernel{
+			Stages: []fuzz.Stage{{
+				Ops: []fuzz.Op{
+					{Opcode: "F32_MUL", Dst: 1, Src: []fuzz.Operand{
+						reg(0), imm(0x7f800000)}},
+					{Opcode: "F32_CMP_NE", Dst: 2, Src: []fuzz.Operand{
+						reg(1), imm(0x7f800000)}},
+					{Opcode: "SCATTER_ADD", Src: []fuzz.Operand{
+						imm(3), reg(2)}},
+					{Opcode: "GATHER", Dst: 3, Src: []fuzz.Operand{imm(3)}},
+					{Opcode: "GRANT_PREDICATE", Dst: 1, Src: []fuzz.Operand{
+						reg(3), reg(2)}},
+				},
+				Out: 1,
+			}},
+			Inputs:    []uint32{0x3f800000, 0, 0xbf800000},
+			Registers: 4,
+		}
+
+		Expect(k.Stages[0].Program()).To(ContainSubstring(
+			"SCATTER_ADD, 3, $2\nGATHER, $3, 3\n"))
+		Expect(k.Expected()).
+			To(Equal([]uint32{0x7f800000, 1, 2}))
+		Expect(fuzz.Check(k)).To(Succeed())
+	})
+
+	It("should shrink a failing kernel to the failing instruction", func() {
+		r := rand.New(rand.NewSource(2))
+		k := fuzz.Generate(r, fuzz.Config{
+			MaxStages: 4, MaxOps: 8, MaxInputs: 8, Registers: 4})
+		k.Stages[len(k.Stages)-1].Ops = append(k.Stages[len(k.Stages)-1].Ops,
+			fuzz.Op{Opcode: "I_MUL", Dst: 1, Src: []fuzz.Operand{{Reg: 0}, {Reg: 0}}})
+
+		hasMul := func(k fuzz.Kernel) bool {
+			for _, s := range k.Stages {
+				for _, op := range s.Ops {
+					if op.Opcode == "I_MUL" {
+						return true
+					}
+				}
+			}
+
+			return false
+		}
+
+		k = fuzz.Shrink(k, hasMul)
+
+		Expect(k.Inputs).To(HaveLen(1))
+		Expect(k.Stages).To(HaveLen(1))
+		Expect(k.Stages[0].Ops).To(HaveLen(1))
+		Expect(strings.Split(k.Stages[0].Program(), "\n")[2]).
+			To(HavePrefix("I_MUL"))
+	})
+})
@@ -8,6 +8,8 @@ require (
 	github.com/onsi/gomega v1.27.10
 	github.com/sarchlab/akita/v3 v3.0.0-alpha.29
 	github.com/tebeka/atexit v0.3.0
+	golang.org/x/net v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 //replace gitlab.com/akita/akita/v2 => ../akita
@@ -0,0 +1,159 @@
+// Package kernels provides small kernels whose programs are verified
+// against reference implementations, so that new users can start from
+// programs that are known to work. The programs are embedded YAML program
+// files, and each kernel can be mapped at any offset of a device:
+//
+//   - passthrough (1x1): sends the values from the west to the east.
+//   - relu (1x1): sends max(v, 0) to the east for each value from the west.
+//   - axpy (1x1): receives x and y from the west, in turns, and sends A*x+y
+//     to the east.
+//   - fir (4x1): receives a sample and a zero from the west, in turns, and
+//     sends the previous sample and the filtered sum to the east, with the
+//     taps TAP0 to TAP3.
+//   - mac (1x1): receives N pairs from the west and returns the sum of their
+//     products.
+//   - gemm-tile (2x2): receives the rows of A from the west and the columns
+//     of B from the north, and each PE returns its element of C = A*B, where
+//     A has N columns.
+//   - histogram (4x1): counts N values from the west, one bin per PE, and
+//     passes them to the east.
+package kernels
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/core"
+)
+
+//go:embed programs/*.yaml
+var programs embed.FS
+
+// Kernel is a kernel with the programs of a Width x Height block of PEs.
+type Kernel struct {
+	Name          string
+	Width, Height int
+
+	// Programs is keyed by the [x, y] coordinate of the PE in the block.
+	Programs map[[2]int]string
+
+	// Constants are the values of the named constants of the programs.
+	Constants map[string]uint32
+}
+
+// Names returns the names of the kernels in alphabetical order.
+func Names() []string {
+	files, err := fs.Glob(programs, "programs/*.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(
+			strings.TrimPrefix(f, "programs/"), ".yaml"))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Load returns the kernel with the name.
+func Load(name string) (Kernel, error) {
+	f, err := core.LoadProgramFileFS(programs, "programs/"+name+".yaml")
+	if err != nil {
+		return Kernel{}, fmt.Errorf("unknown kernel %q: %w", name, err)
+	}
+
+	k := Kernel{
+		Name:      name,
+		Programs:  f.Programs,
+		Constants: f.Constants,
+	}
+
+	for coord := range k.Programs {
+		if coord[0] >= k.Width {
+			k.Width = coord[0] + 1
+		}
+
+		if coord[1] >= k.Height {
+			k.Height = coord[1] + 1
+		}
+	}
+
+	return k, nil
+}
+
+// MustLoad is the same as Load, but panics if the kernel does not exist.
+func MustLoad(name string) Kernel {
+	k, err := Load(name)
+	if err != nil {
+		panic(err)
+	}
+
+	return k
+}
+
+// WithConstant returns a copy of the kernel with the constant set to the
+// value.
+func (k Kernel) WithConstant(name string, value uint32) Kernel {
+	if _, ok := k.Constants[name]; !ok {
+		panic(fmt.Sprintf("kernel %s has no constant %s", k.Name, name))
+	}
+
+	constants := make(map[string]uint32, len(k.Constants))
+	for n, v := range k.Constants {
+		constants[n] = v
+	}
+
+	constants[name] = value
+	k.Constants = constants
+
+	return k
+}
+
+// ProgramsAt returns the programs with the constants resolved, keyed by the
+// coordinate of the PE on a device where the block starts at the offset.
+func (k Kernel) ProgramsAt(offset [2]int) map[[2]int]string {
+	placed := make(map[[2]int]string, len(k.Programs))
+	for coord, p := range k.Programs {
+		at := [2]int{coord[0] + offset[0], coord[1] + offset[1]}
+		placed[at] = core.ResolveConstants(p, k.Constants)
+	}
+
+	return placed
+}
+
+// MapAt maps the programs to the PEs of the first device of the driver,
+// with the block starting at the offset.
+func (k Kernel) MapAt(d api.Driver, offset [2]int) {
+	k.MapToDeviceAt(d, 0, offset)
+}
+
+// MapToDeviceAt is the same as MapAt, but maps the programs to a device of
+// the driver.
+func (k Kernel) MapToDeviceAt(d api.Driver, device int, offset [2]int) {
+	placed := k.ProgramsAt(offset)
+
+	coords := make([][2]int, 0, len(placed))
+	for coord := range placed {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	for _, coord := range coords {
+		d.MapProgramToDevice(device, placed[coord], coord)
+	}
+}
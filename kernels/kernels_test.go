@@ -0,0 +1,159 @@
+package kernels_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/kernels"
+)
+
+// rowDriver returns a driver of a device that has a passthrough in front of
+// the kernel, so that the kernel runs at the offset [1, 0].
+func rowDriver(k kernels.Kernel) api.Driver {
+	engine := sim.NewSerialEngine()
+	driver := api.DriverBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Driver")
+	driver.RegisterDevice(config.DeviceBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWidth(k.Width + 1).
+		WithHeight(k.Height).
+		Build("Device"))
+
+	kernels.MustLoad("passthrough").MapAt(driver, [2]int{0, 0})
+	k.MapAt(driver, [2]int{1, 0})
+
+	return driver
+}
+
+var _ = Describe("Kernels", func() {
+	It("should list the kernels", func() {
+		Expect(kernels.Names()).To(Equal([]string{
+			"axpy", "fir", "gemm-tile", "histogram", "mac", "passthrough",
+			"relu",
+		}))
+
+		for _, name := range kernels.Names() {
+			k := kernels.MustLoad(name)
+			Expect(k.Programs).To(HaveLen(k.Width * k.Height))
+		}
+	})
+
+	It("should reject unknown kernels and constants", func() {
+		_, err := kernels.Load("conv")
+		Expect(err).To(MatchError(ContainSubstring(`unknown kernel "conv"`)))
+
+		Expect(func() { kernels.MustLoad("relu").WithConstant("N", 1) }).
+			To(PanicWith("kernel relu has no constant N"))
+	})
+
+	It("should place the programs at the offset", func() {
+		k := kernels.MustLoad("gemm-tile").WithConstant("N", 5)
+		programs := k.ProgramsAt([2]int{3, 2})
+
+		Expect(programs).To(HaveLen(4))
+		Expect(programs).To(HaveKey([2]int{4, 3}))
+		Expect(programs[[2]int{4, 3}]).To(ContainSubstring("I_CMP_LT, $5, $4, 5"))
+		Expect(kernels.MustLoad("gemm-tile").Constants["N"]).To(Equal(uint32(2)))
+	})
+
+	It("should run relu", func() {
+		d := rowDriver(kernels.MustLoad("relu"))
+		src := []uint32{5, 0xfffffffd, 0, 7}
+		dst := make([]uint32, 4)
+		d.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		d.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		d.Run()
+
+		Expect(dst).To(Equal([]uint32{5, 0, 0, 7}))
+	})
+
+	It("should run axpy", func() {
+		d := rowDriver(kernels.MustLoad("axpy").WithConstant("A", 3))
+		dst := make([]uint32, 2)
+		d.FeedIn([]uint32{1, 10, 2, 20}, cgra.West, [2]int{0, 1}, 1)
+		d.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		d.Run()
+
+		Expect(dst).To(Equal([]uint32{13, 26}))
+	})
+
+	It("should run fir", func() {
+		d := rowDriver(kernels.MustLoad("fir"))
+		samples := []uint32{1, 2, 3, 4, 5}
+		src := make([]uint32, 0, 2*len(samples))
+		for _, s := range samples {
+			src = append(src, s, 0)
+		}
+
+		dst := make([]uint32, len(src))
+		d.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		d.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+		d.Run()
+
+		for n := range samples {
+			want := uint32(0)
+			for tap := 0; tap < 4 && tap <= n; tap++ {
+				want += uint32(tap+1) * samples[n-tap]
+			}
+
+			Expect(dst[2*n+1]).To(Equal(want))
+		}
+	})
+
+	It("should run mac", func() {
+		d := rowDriver(kernels.MustLoad("mac").WithConstant("N", 3))
+		d.FeedIn([]uint32{1, 2, 3, 4, 5, 6}, cgra.West, [2]int{0, 1}, 1)
+		d.Run()
+
+		Expect(d.GetReturnValues()).To(HaveKeyWithValue([2]int{1, 0}, uint32(44)))
+	})
+
+	It("should run histogram", func() {
+		d := rowDriver(kernels.MustLoad("histogram").WithConstant("N", 6))
+		src := []uint32{0, 3, 3, 1, 3, 0}
+		d.FeedIn(src, cgra.West, [2]int{0, 1}, 1)
+		d.Collect(make([]uint32, len(src)), cgra.East, [2]int{0, 1}, 1)
+		d.Run()
+
+		got := d.GetReturnValues()
+		Expect(got).To(HaveKeyWithValue([2]int{1, 0}, uint32(2)))
+		Expect(got).To(HaveKeyWithValue([2]int{2, 0}, uint32(1)))
+		Expect(got).To(HaveKeyWithValue([2]int{3, 0}, uint32(0)))
+		Expect(got).To(HaveKeyWithValue([2]int{4, 0}, uint32(3)))
+	})
+
+	It("should run gemm-tile", func() {
+		engine := sim.NewSerialEngine()
+		d := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		d.RegisterDevice(config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(2).
+			WithHeight(2).
+			Build("Device"))
+
+		kernels.MustLoad("gemm-tile").WithConstant("N", 3).MapAt(d, [2]int{0, 0})
+
+		// a[k*2+i] is A[i][k] and b[k*2+j] is B[k][j].
+		a := []uint32{1, 4, 2, 5, 3, 6}
+		b := []uint32{1, 2, 3, 4, 5, 6}
+		d.FeedIn(a, cgra.West, [2]int{0, 2}, 2)
+		d.FeedIn(b, cgra.North, [2]int{0, 2}, 2)
+		d.Collect(make([]uint32, 6), cgra.East, [2]int{0, 2}, 2)
+		d.Collect(make([]uint32, 6), cgra.South, [2]int{0, 2}, 2)
+		d.Run()
+
+		Expect(d.GetReturnValues()).To(Equal(map[[2]int]uint32{
+			{0, 0}: 22, {1, 0}: 28, {0, 1}: 49, {1, 1}: 64,
+		}))
+	})
+})
@@ -0,0 +1,119 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// inst is an instruction of a PE, with the cycle of the iteration that it
+// is scheduled at. Sorting the instructions of each PE by their cycles,
+// and then by the order they are created in, keeps every instruction after the
+// instructions that it depends on, so the PEs cannot wait for each other in
+// a cycle.
+type inst struct {
+	cycle int
+	text  string
+}
+
+type emitter struct {
+	*mapper
+	cycles map[string]int
+	insts  map[[2]int][]inst
+}
+
+// emit produces the program of each PE that a node or a route uses.
+func (m *mapper) emit(order []Node) {
+	e := &emitter{
+		mapper: m,
+		cycles: make(map[string]int),
+		insts:  make(map[[2]int][]inst),
+	}
+
+	for _, n := range order {
+		e.emitNode(n)
+		e.emitSends(n)
+	}
+
+	for pe, insts := range e.insts {
+		sort.SliceStable(insts, func(i, j int) bool {
+			return insts[i].cycle < insts[j].cycle
+		})
+
+		lines := []string{"START:"}
+		for _, in := range insts {
+			lines = append(lines, in.text)
+		}
+
+		lines = append(lines, "JMP, START")
+		m.mapping.Programs[pe] = strings.Join(lines, "\n")
+	}
+}
+
+func (e *emitter) add(pe [2]int, cycle int, format string, a ...interface{}) {
+	e.insts[pe] = append(e.insts[pe], inst{
+		cycle: cycle,
+		text:  fmt.Sprintf(format, a...),
+	})
+}
+
+// emitNode receives the operands of the node and computes its result into
+// $0.
+func (e *emitter) emitNode(n Node) {
+	pe := e.mapping.Placement[n.Name]
+	operands := make([]string, numOperands(n))
+
+	for i := range operands {
+		operands[i] = fmt.Sprint(n.Imms[i])
+	}
+
+	for _, edge := range e.graph.Edges {
+		if edge.To != n.Name {
+			continue
+		}
+
+		path := e.mapping.Routes[edge]
+		arrival := e.cycles[edge.From] + len(path) - 1
+		if arrival > e.cycles[n.Name] {
+			e.cycles[n.Name] = arrival
+		}
+
+		reg := fmt.Sprintf("$%d", edge.Operand+1)
+		operands[edge.Operand] = reg
+		e.add(pe, arrival, "WAIT, %s, NET_RECV_%d",
+			reg, sideTo(pe, path[len(path)-2]))
+	}
+
+	cycle := e.cycles[n.Name]
+
+	switch n.Opcode {
+	case Input:
+		e.add(pe, cycle, "WAIT, $0, NET_RECV_%d", cgra.West)
+	case Output:
+		e.add(pe, cycle, "SEND, NET_SEND_%d, %s", cgra.East, operands[0])
+	default:
+		e.add(pe, cycle, "%s, $0, %s", n.Opcode, strings.Join(operands, ", "))
+	}
+}
+
+// emitSends sends the result of the node along the routes of the edges that
+// leave it, with the PEs in the middle of each route forwarding it.
+func (e *emitter) emitSends(n Node) {
+	cycle := e.cycles[n.Name]
+
+	for _, edge := range e.graph.Edges {
+		if edge.From != n.Name {
+			continue
+		}
+
+		path := e.mapping.Routes[edge]
+		e.add(path[0], cycle, "SEND, NET_SEND_%d, $0", sideTo(path[0], path[1]))
+
+		for i := 1; i+1 < len(path); i++ {
+			e.add(path[i], cycle+i, "FORWARD, NET_SEND_%d, NET_RECV_%d",
+				sideTo(path[i], path[i+1]), sideTo(path[i], path[i-1]))
+		}
+	}
+}
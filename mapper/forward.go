@@ -0,0 +1,364 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+// remotePrefix is the prefix of the operands that name a PE that is not a
+// neighbor, e.g., PE_2_0 names the PE at [2, 0].
+const remotePrefix = "PE_"
+
+// remoteEnd is a SEND or a WAIT that names a PE instead of a port.
+type remoteEnd struct {
+	pe      [2]int
+	line    int
+	operand int
+}
+
+// remotePair is a SEND and the WAIT that receives what it sends.
+type remotePair struct {
+	send, wait remoteEnd
+}
+
+// forwarder inserts the FORWARD instructions of InsertForwards.
+type forwarder struct {
+	*mapper
+	lines   map[[2]int][]string
+	steps   map[[2]int][]int
+	inserts map[[2]int][]inst
+}
+
+// InsertForwards routes the tokens that the programs send to PEs that are
+// not neighbors. Such a SEND names the PE that it sends to, as in
+//
+//	SEND, PE_2_0, $0
+//
+// and the WAIT on that PE names the PE that it receives from, as in
+//
+//	WAIT, $1, PE_0_0
+//
+// The n-th SEND from one PE to another pairs with the n-th WAIT of the other
+// PE from the first. Each pair is routed over the shortest path of the links
+// that no program uses, the operands are replaced with the ports of the
+// path, and each PE in the middle of the path gets a FORWARD instruction.
+//
+// Steps, if not nil, are the time steps of the lines of the programs, as in
+// Driver.SetSchedule. The FORWARD at the i-th hop is scheduled i cycles
+// after the SEND, and it is inserted before the first instruction that is
+// scheduled after it, or before the JMP that ends the program. The returned
+// steps include the inserted lines. Without steps, the FORWARDs are inserted
+// before the JMP that ends the program. PEs without programs get programs
+// that only forward.
+func InsertForwards(
+	programs map[[2]int]string,
+	steps map[[2]int][]int,
+	arch verify.ArchInfo,
+) (map[[2]int]string, map[[2]int][]int, error) {
+	f := &forwarder{
+		mapper: &mapper{
+			arch:  arch,
+			links: usedLinks(programs, arch),
+		},
+		lines:   make(map[[2]int][]string),
+		inserts: make(map[[2]int][]inst),
+	}
+
+	for coord, program := range programs {
+		f.lines[coord] = strings.Split(program, "\n")
+	}
+
+	if steps != nil {
+		f.steps = make(map[[2]int][]int)
+		for coord, s := range steps {
+			f.steps[coord] = append([]int(nil), s...)
+		}
+	}
+
+	pairs, err := f.pairRemoteEnds()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range pairs {
+		if err := f.routePair(p); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	f.applyInserts()
+
+	out := make(map[[2]int]string)
+	for coord, lines := range f.lines {
+		out[coord] = strings.Join(lines, "\n")
+	}
+
+	return out, f.steps, nil
+}
+
+// usedLinks returns the links that the programs send or receive through.
+func usedLinks(programs map[[2]int]string, arch verify.ArchInfo) map[link]bool {
+	links := make(map[link]bool)
+	g := verify.BuildDepGraph(programs, arch)
+
+	for _, counts := range []map[verify.Channel]int{g.Produced, g.Consumed} {
+		for ch, n := range counts {
+			if n > 0 {
+				links[link{ch.From, ch.Side}] = true
+			}
+		}
+	}
+
+	return links
+}
+
+// pairRemoteEnds pairs the SENDs that name a PE with the WAITs that name the
+// PE of the SEND, in the order of the coordinates of the PEs.
+func (f *forwarder) pairRemoteEnds() ([]remotePair, error) {
+	sends := make(map[[2][2]int][]remoteEnd)
+	waits := make(map[[2][2]int][]remoteEnd)
+
+	coords := make([][2]int, 0, len(f.lines))
+	for coord := range f.lines {
+		coords = append(coords, coord)
+	}
+
+	sortCoords(coords)
+
+	keys, waitKeys := [][2][2]int{}, [][2][2]int{}
+
+	for _, coord := range coords {
+		for i, line := range f.lines[coord] {
+			peer, operand, isSend, err := remoteOperand(line)
+			if err != nil {
+				return nil, fmt.Errorf("PE(%d, %d) line %d: %w",
+					coord[0], coord[1], i, err)
+			}
+
+			end := remoteEnd{pe: coord, line: i, operand: operand}
+
+			switch {
+			case operand < 0:
+			case isSend:
+				key := [2][2]int{coord, peer}
+				if len(sends[key]) == 0 {
+					keys = append(keys, key)
+				}
+
+				sends[key] = append(sends[key], end)
+			default:
+				key := [2][2]int{peer, coord}
+				if len(waits[key]) == 0 {
+					waitKeys = append(waitKeys, key)
+				}
+
+				waits[key] = append(waits[key], end)
+			}
+		}
+	}
+
+	return matchEnds(keys, waitKeys, sends, waits)
+}
+
+func matchEnds(
+	keys, waitKeys [][2][2]int,
+	sends, waits map[[2][2]int][]remoteEnd,
+) ([]remotePair, error) {
+	pairs := []remotePair{}
+
+	for _, key := range keys {
+		if len(sends[key]) != len(waits[key]) {
+			return nil, fmt.Errorf("PE(%d, %d) sends %d tokens to "+
+				"PE(%d, %d), which waits for %d", key[0][0], key[0][1],
+				len(sends[key]), key[1][0], key[1][1], len(waits[key]))
+		}
+
+		for i := range sends[key] {
+			pairs = append(pairs, remotePair{sends[key][i], waits[key][i]})
+		}
+
+	}
+
+	for _, key := range waitKeys {
+		if len(sends[key]) == 0 {
+			return nil, fmt.Errorf("PE(%d, %d) waits for PE(%d, %d), "+
+				"which sends nothing to it", key[1][0], key[1][1],
+				key[0][0], key[0][1])
+		}
+	}
+
+	return pairs, nil
+}
+
+// remoteOperand returns the PE that the line names, the index of the
+// operand that names it, and whether the line sends to it. The index is -1
+// if the line names no PE.
+func remoteOperand(line string) ([2]int, int, bool, error) {
+	op := core.Opcode(line)
+	operands := strings.Split(core.StripSource(line), ",")
+
+	for i := 1; i < len(operands); i++ {
+		name := strings.TrimSpace(operands[i])
+		if !strings.HasPrefix(name, remotePrefix) {
+			continue
+		}
+
+		var pe [2]int
+
+		coords := strings.Split(strings.TrimPrefix(name, remotePrefix), "_")
+		if len(coords) != 2 {
+			return pe, 0, false, fmt.Errorf("invalid PE %q", name)
+		}
+
+		for k, c := range coords {
+			v, err := strconv.Atoi(c)
+			if err != nil {
+				return pe, 0, false, fmt.Errorf("invalid PE %q", name)
+			}
+
+			pe[k] = v
+		}
+
+		switch {
+		case (op == "SEND" || op == "SEND_PRED") && i == 1:
+			return pe, i, true, nil
+		case op == "WAIT" && i == 2:
+			return pe, i, false, nil
+		default:
+			return pe, 0, false, fmt.Errorf(
+				"only SEND and WAIT can name PE %q", name)
+		}
+	}
+
+	return [2]int{}, -1, false, nil
+}
+
+// routePair replaces the operands of the pair with the ports of a path and
+// schedules the FORWARDs in the middle of the path.
+func (f *forwarder) routePair(p remotePair) error {
+	from, to := p.send.pe, p.wait.pe
+
+	path := f.route(from, to)
+	if path == nil {
+		return fmt.Errorf("no free links can route PE(%d, %d) to PE(%d, %d)",
+			from[0], from[1], to[0], to[1])
+	}
+
+	f.setLinks(path, true)
+
+	n := len(path)
+	f.setOperand(p.send, fmt.Sprintf("NET_SEND_%d", sideTo(path[0], path[1])))
+	f.setOperand(p.wait, fmt.Sprintf("NET_RECV_%d", sideTo(to, path[n-2])))
+
+	step := 0
+	if f.steps != nil {
+		s, ok := f.steps[from]
+		if !ok || p.send.line >= len(s) {
+			return fmt.Errorf("PE(%d, %d) has no time step for line %d",
+				from[0], from[1], p.send.line)
+		}
+
+		step = s[p.send.line]
+	}
+
+	for i := 1; i+1 < n; i++ {
+		f.inserts[path[i]] = append(f.inserts[path[i]], inst{
+			cycle: step + i,
+			text: fmt.Sprintf("FORWARD, NET_SEND_%d, NET_RECV_%d",
+				sideTo(path[i], path[i+1]), sideTo(path[i], path[i-1])),
+		})
+	}
+
+	return nil
+}
+
+func (f *forwarder) setOperand(end remoteEnd, port string) {
+	line := f.lines[end.pe][end.line]
+	source := line[len(core.StripSource(line)):]
+	operands := strings.Split(core.StripSource(line), ",")
+	operands[end.operand] = " " + port
+	f.lines[end.pe][end.line] = strings.Join(operands, ",") + source
+}
+
+// applyInserts inserts the FORWARDs into the programs.
+func (f *forwarder) applyInserts() {
+	for pe, insts := range f.inserts {
+		sort.SliceStable(insts, func(i, j int) bool {
+			return insts[i].cycle < insts[j].cycle
+		})
+
+		if _, ok := f.lines[pe]; !ok {
+			f.newForwardingProgram(pe, insts)
+			continue
+		}
+
+		for _, in := range insts {
+			f.insert(pe, in)
+		}
+	}
+}
+
+// newForwardingProgram creates the program of a PE that only forwards.
+func (f *forwarder) newForwardingProgram(pe [2]int, insts []inst) {
+	lines := []string{"START:"}
+	steps := []int{0}
+
+	for _, in := range insts {
+		lines = append(lines, in.text)
+		steps = append(steps, in.cycle)
+	}
+
+	f.lines[pe] = append(lines, "JMP, START")
+
+	if f.steps != nil {
+		f.steps[pe] = append(steps, insts[len(insts)-1].cycle+1)
+	}
+}
+
+// insert inserts a FORWARD into the existing program of a PE.
+func (f *forwarder) insert(pe [2]int, in inst) {
+	lines := f.lines[pe]
+	steps := f.steps[pe]
+	at := len(lines)
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		if core.Opcode(lines[i]) != "" {
+			if core.Opcode(lines[i]) == "JMP" {
+				at = i
+			}
+
+			break
+		}
+	}
+
+	for i, line := range lines[:at] {
+		if core.Opcode(line) != "" && i < len(steps) && steps[i] > in.cycle {
+			at = i
+			break
+		}
+	}
+
+	f.lines[pe] = append(lines[:at], append([]string{in.text},
+		lines[at:]...)...)
+
+	if f.steps != nil && at <= len(steps) {
+		f.steps[pe] = append(steps[:at], append([]int{in.cycle},
+			steps[at:]...)...)
+	}
+}
+
+// sortCoords sorts [x, y] coordinates in row-major order.
+func sortCoords(coords [][2]int) {
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+}
@@ -0,0 +1,102 @@
+package mapper_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/mapper"
+	"github.com/sarchlab/zeonica/trace"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("InsertForwards", func() {
+	arch := verify.ArchInfo{Rows: 2, Columns: 3}
+	programs := map[[2]int]string{
+		{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, PE_2_0, $0\nJMP, START",
+		{2, 0}: "START:\nWAIT, $0, PE_0_0\nI_ADD, $0, $0, 1\n" +
+			"SEND, NET_SEND_1, $0\nJMP, START",
+	}
+
+	It("should forward the tokens through the PEs in between", func() {
+		out, steps, err := mapper.InsertForwards(programs, nil, arch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps).To(BeNil())
+		Expect(out[[2]int{0, 0}]).To(Equal(
+			"START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START"))
+		Expect(out[[2]int{1, 0}]).To(Equal(
+			"START:\nFORWARD, NET_SEND_1, NET_RECV_3\nJMP, START"))
+		Expect(out[[2]int{2, 0}]).To(HavePrefix(
+			"START:\nWAIT, $0, NET_RECV_3\n"))
+
+		engine := sim.NewSerialEngine()
+		driver := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(3).
+			WithHeight(2).
+			WithTracer(trace.Discard).
+			Build("Device"))
+
+		dst := make([]uint32, 3)
+		driver.FeedIn([]uint32{1, 2, 3}, cgra.West, [2]int{0, 1}, 1)
+		driver.Collect(dst, cgra.East, [2]int{0, 1}, 1)
+
+		for coord, program := range out {
+			driver.MapProgram(program, coord)
+		}
+
+		driver.Run()
+
+		Expect(dst).To(Equal([]uint32{2, 3, 4}))
+	})
+
+	It("should schedule the forwards after the sends", func() {
+		with := map[[2]int]string{
+			{0, 0}: programs[[2]int{0, 0}],
+			{2, 0}: programs[[2]int{2, 0}],
+			{1, 0}: "START:\nWAIT, $0, NET_RECV_2\nJMP, START",
+		}
+		steps := map[[2]int][]int{
+			{0, 0}: {0, 0, 1, 2},
+			{1, 0}: {0, 0, 5},
+			{2, 0}: {0, 3, 4, 5, 6},
+		}
+
+		out, outSteps, err := mapper.InsertForwards(with, steps, arch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out[[2]int{1, 0}]).To(Equal("START:\nWAIT, $0, NET_RECV_2\n" +
+			"FORWARD, NET_SEND_1, NET_RECV_3\nJMP, START"))
+		Expect(outSteps[[2]int{1, 0}]).To(Equal([]int{0, 0, 2, 5}))
+		Expect(outSteps[[2]int{2, 0}]).To(Equal(steps[[2]int{2, 0}]))
+		Expect(steps[[2]int{1, 0}]).To(Equal([]int{0, 0, 5}))
+	})
+
+	It("should route around the links that the programs use", func() {
+		with := map[[2]int]string{
+			{0, 0}: programs[[2]int{0, 0}],
+			{2, 0}: programs[[2]int{2, 0}],
+			{1, 0}: "START:\nWAIT, $1, NET_RECV_3\nJMP, START",
+		}
+
+		out, _, err := mapper.InsertForwards(with, nil, arch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out[[2]int{0, 0}]).To(ContainSubstring("SEND, NET_SEND_2, $0"))
+		Expect(out[[2]int{0, 1}]).To(ContainSubstring("FORWARD"))
+		Expect(out[[2]int{1, 1}]).To(ContainSubstring("FORWARD"))
+	})
+
+	It("should reject sends that no wait pairs with", func() {
+		_, _, err := mapper.InsertForwards(map[[2]int]string{
+			{0, 0}: "SEND, PE_2_0, 1",
+		}, nil, arch)
+		Expect(err).To(MatchError(
+			"PE(0, 0) sends 1 tokens to PE(2, 0), which waits for 0"))
+	})
+})
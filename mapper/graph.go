@@ -0,0 +1,143 @@
+// Package mapper maps a dataflow graph onto the PEs of a device. It places
+// each node on a PE, routes each edge over the links between the PEs, and
+// produces the program of each PE, so that kernels can run without
+// hand-written programs.
+package mapper
+
+import "fmt"
+
+const (
+	// Input is the opcode of the nodes that receive a value from the driver
+	// through the West side of the device in each iteration.
+	Input = "INPUT"
+
+	// Output is the opcode of the nodes that send the value of their only
+	// operand to the driver through the East side of the device.
+	Output = "OUTPUT"
+)
+
+// Node is an operation of a dataflow graph.
+type Node struct {
+	Name string
+
+	// Opcode is Input, Output, or an opcode with a destination register and
+	// two source operands, e.g., I_ADD or F32_MUL.
+	Opcode string
+
+	// Imms are the immediate values of the operands that no edge feeds,
+	// keyed by the index of the operand.
+	Imms map[int]uint32
+}
+
+// Edge carries the result of the node From to an operand of the node To.
+type Edge struct {
+	From, To string
+	Operand  int
+}
+
+// Graph is a dataflow graph. The mapped programs run the graph once per
+// iteration, over and over.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// numOperands returns the number of operands of a node.
+func numOperands(n Node) int {
+	switch n.Opcode {
+	case Input:
+		return 0
+	case Output:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// validate checks that the edges connect the nodes of the graph and that
+// each operand of each node is fed by exactly one edge or immediate value.
+func (g Graph) validate() error {
+	nodes := make(map[string]Node)
+	for _, n := range g.Nodes {
+		if _, ok := nodes[n.Name]; ok {
+			return fmt.Errorf("node %q is defined more than once", n.Name)
+		}
+
+		nodes[n.Name] = n
+	}
+
+	fed := make(map[Edge]bool)
+
+	for _, e := range g.Edges {
+		from, okFrom := nodes[e.From]
+		to, okTo := nodes[e.To]
+
+		switch {
+		case !okFrom || !okTo:
+			return fmt.Errorf("edge %s -> %s connects unknown nodes",
+				e.From, e.To)
+		case from.Opcode == Output:
+			return fmt.Errorf("output %q cannot feed other nodes", e.From)
+		case e.Operand < 0 || e.Operand >= numOperands(to):
+			return fmt.Errorf("node %q has no operand %d", e.To, e.Operand)
+		}
+
+		operand := Edge{To: e.To, Operand: e.Operand}
+		if fed[operand] {
+			return fmt.Errorf("operand %d of node %q is fed more than once",
+				e.Operand, e.To)
+		}
+
+		fed[operand] = true
+	}
+
+	for _, n := range g.Nodes {
+		for i := 0; i < numOperands(n); i++ {
+			_, imm := n.Imms[i]
+			if fed[Edge{To: n.Name, Operand: i}] == imm {
+				return fmt.Errorf("operand %d of node %q needs either "+
+					"an edge or an immediate value", i, n.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// topoOrder returns the nodes in an order where every node comes after the
+// nodes that feed it.
+func (g Graph) topoOrder() ([]Node, error) {
+	inDegree := make(map[string]int)
+	for _, e := range g.Edges {
+		inDegree[e.To]++
+	}
+
+	order := []Node{}
+	done := make(map[string]bool)
+
+	for len(order) < len(g.Nodes) {
+		progress := false
+
+		for _, n := range g.Nodes {
+			if done[n.Name] || inDegree[n.Name] > 0 {
+				continue
+			}
+
+			done[n.Name] = true
+			order = append(order, n)
+			progress = true
+
+			for _, e := range g.Edges {
+				if e.From == n.Name {
+					inDegree[e.To]--
+				}
+			}
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("the graph has a cycle")
+		}
+	}
+
+	return order, nil
+}
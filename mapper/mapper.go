@@ -0,0 +1,119 @@
+package mapper
+
+import (
+	"fmt"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+// Mapping is the result of mapping a dataflow graph onto a device.
+type Mapping struct {
+	// Placement is the [x, y] coordinate of the PE of each node.
+	Placement map[string][2]int
+
+	// Routes are the PEs that each edge goes through, from the PE of the
+	// producer to the PE of the consumer.
+	Routes map[Edge][][2]int
+
+	// Inputs and Outputs are the rows of the West and East ports that the
+	// driver feeds and collects the Input and Output nodes through.
+	Inputs, Outputs map[string]int
+
+	// Programs are the programs of the PEs, keyed by the [x, y] coordinate
+	// of the PE.
+	Programs map[[2]int]string
+}
+
+// Map places the nodes of the graph on the PEs of the architecture and
+// routes the edges between them. Input nodes are placed in the West column
+// and Output nodes in the East column. Each of the other nodes is placed on
+// the free PE that supports its opcode and is the closest to the nodes that
+// feed it, among the PEs that its edges can be routed to. Each edge is
+// routed over the shortest path of free links, and each link carries at
+// most one edge.
+func Map(g Graph, arch verify.ArchInfo) (*Mapping, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+
+	order, err := g.topoOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mapper{
+		graph:   g,
+		arch:    arch,
+		used:    make(map[[2]int]bool),
+		links:   make(map[link]bool),
+		mapping: newMapping(),
+	}
+
+	for _, n := range order {
+		if err := m.place(n); err != nil {
+			return nil, err
+		}
+	}
+
+	m.emit(order)
+
+	if issues := verify.Lint(m.mapping.Programs, arch); len(issues) > 0 {
+		return nil, fmt.Errorf("the mapped programs do not fit the "+
+			"architecture:\n%s", issueText(issues))
+	}
+
+	return m.mapping, nil
+}
+
+func newMapping() *Mapping {
+	return &Mapping{
+		Placement: make(map[string][2]int),
+		Routes:    make(map[Edge][][2]int),
+		Inputs:    make(map[string]int),
+		Outputs:   make(map[string]int),
+		Programs:  make(map[[2]int]string),
+	}
+}
+
+// link is the link that leaves a PE through a side.
+type link struct {
+	pe   [2]int
+	side cgra.Side
+}
+
+type mapper struct {
+	graph   Graph
+	arch    verify.ArchInfo
+	used    map[[2]int]bool
+	links   map[link]bool
+	mapping *Mapping
+}
+
+// enabled returns true if the coordinate is a tile of the device.
+func (m *mapper) enabled(pe [2]int) bool {
+	if pe[0] < 0 || pe[0] >= m.arch.Columns ||
+		pe[1] < 0 || pe[1] >= m.arch.Rows {
+		return false
+	}
+
+	for _, t := range m.arch.DisabledTiles {
+		if t == pe {
+			return false
+		}
+	}
+
+	return true
+}
+
+// supports returns true if the PE can execute all the opcodes.
+func (m *mapper) supports(pe [2]int, opcodes ...string) bool {
+	caps := m.arch.PECaps[pe]
+	for _, op := range opcodes {
+		if !caps.Supports(op) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,117 @@
+package mapper_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/mapper"
+	"github.com/sarchlab/zeonica/trace"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("Map", func() {
+	// out = a * b + a
+	graph := mapper.Graph{
+		Nodes: []mapper.Node{
+			{Name: "a", Opcode: mapper.Input},
+			{Name: "b", Opcode: mapper.Input},
+			{Name: "mul", Opcode: "I_MUL"},
+			{Name: "add", Opcode: "I_ADD"},
+			{Name: "out", Opcode: mapper.Output},
+		},
+		Edges: []mapper.Edge{
+			{From: "a", To: "mul", Operand: 0},
+			{From: "b", To: "mul", Operand: 1},
+			{From: "mul", To: "add", Operand: 0},
+			{From: "a", To: "add", Operand: 1},
+			{From: "add", To: "out", Operand: 0},
+		},
+	}
+	arch := verify.ArchInfo{Rows: 3, Columns: 4}
+
+	It("should produce programs that compute the graph", func() {
+		m, err := mapper.Map(graph, arch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Inputs).To(Equal(map[string]int{"a": 0, "b": 1}))
+		Expect(m.Placement["mul"][0]).To(BeNumerically(">", 0))
+
+		engine := sim.NewSerialEngine()
+		driver := api.DriverBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			Build("Driver")
+		driver.RegisterDevice(config.DeviceBuilder{}.
+			WithEngine(engine).
+			WithFreq(1 * sim.GHz).
+			WithWidth(arch.Columns).
+			WithHeight(arch.Rows).
+			WithTracer(trace.Discard).
+			Build("Device"))
+
+		row := m.Outputs["out"]
+		dst := make([]uint32, 3)
+		driver.FeedIn([]uint32{1, 2, 3, 4, 5, 6}, cgra.West, [2]int{0, 2}, 2)
+		driver.Collect(dst, cgra.East, [2]int{row, row + 1}, 1)
+
+		for coord, program := range m.Programs {
+			driver.MapProgram(program, coord)
+		}
+
+		driver.Run()
+
+		Expect(dst).To(Equal([]uint32{3, 15, 35}))
+	})
+
+	It("should route around the tiles that are absent", func() {
+		disabled := arch
+		disabled.DisabledTiles = [][2]int{{2, 1}}
+
+		m, err := mapper.Map(graph, disabled)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, path := range m.Routes {
+			for _, pe := range path {
+				Expect(disabled.DisabledTiles).NotTo(ContainElement(pe))
+			}
+		}
+	})
+
+	It("should reject graphs with a cycle", func() {
+		_, err := mapper.Map(mapper.Graph{
+			Nodes: []mapper.Node{
+				{Name: "x", Opcode: "I_ADD", Imms: map[int]uint32{1: 1}},
+				{Name: "y", Opcode: "I_ADD", Imms: map[int]uint32{1: 1}},
+			},
+			Edges: []mapper.Edge{
+				{From: "x", To: "y", Operand: 0},
+				{From: "y", To: "x", Operand: 0},
+			},
+		}, arch)
+		Expect(err).To(MatchError("the graph has a cycle"))
+	})
+
+	It("should reject operands without a value", func() {
+		_, err := mapper.Map(mapper.Graph{
+			Nodes: []mapper.Node{{Name: "x", Opcode: "I_ADD"}},
+		}, arch)
+		Expect(err).To(MatchError(ContainSubstring(
+			`operand 0 of node "x" needs either an edge or an immediate value`)))
+	})
+
+	It("should only place nodes on PEs that support their opcodes", func() {
+		caps := verify.ArchInfo{Rows: 3, Columns: 4,
+			PECaps: map[[2]int]cgra.PECaps{}}
+		for y := 0; y < 3; y++ {
+			for x := 1; x < 3; x++ {
+				caps.PECaps[[2]int{x, y}] = cgra.NewPECaps(
+					"WAIT", "SEND", "JMP", "FORWARD", "I_ADD")
+			}
+		}
+
+		_, err := mapper.Map(graph, caps)
+		Expect(err).To(MatchError(`no free PE can run node "mul"`))
+	})
+})
@@ -0,0 +1,113 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+)
+
+// place puts the node on the free PE that is the closest to the nodes that
+// feed it and that the edges into the node can be routed to.
+func (m *mapper) place(n Node) error {
+	pes := [][2]int{}
+	for _, pe := range m.candidates(n) {
+		if !m.used[pe] && m.supports(pe, opcodesOf(n)...) {
+			pes = append(pes, pe)
+		}
+	}
+
+	if len(pes) == 0 {
+		return fmt.Errorf("no free PE can run node %q", n.Name)
+	}
+
+	sort.SliceStable(pes, func(i, j int) bool {
+		return m.distanceToProducers(n, pes[i]) <
+			m.distanceToProducers(n, pes[j])
+	})
+
+	for _, pe := range pes {
+		if m.routeInto(n, pe) {
+			m.used[pe] = true
+			m.mapping.Placement[n.Name] = pe
+
+			switch n.Opcode {
+			case Input:
+				m.mapping.Inputs[n.Name] = pe[1]
+			case Output:
+				m.mapping.Outputs[n.Name] = pe[1]
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no free links can route the edges into node %q",
+		n.Name)
+}
+
+// candidates returns the PEs that the node can be placed on, row by row.
+// Input and Output nodes take the West and East columns. The other nodes
+// keep away from these columns if the device is wide enough.
+func (m *mapper) candidates(n Node) [][2]int {
+	pes := [][2]int{}
+
+	for y := 0; y < m.arch.Rows; y++ {
+		for x := 0; x < m.arch.Columns; x++ {
+			pe := [2]int{x, y}
+			if m.enabled(pe) && m.inColumns(n, x) {
+				pes = append(pes, pe)
+			}
+		}
+	}
+
+	return pes
+}
+
+func (m *mapper) inColumns(n Node, x int) bool {
+	east := m.arch.Columns - 1
+
+	switch {
+	case n.Opcode == Input:
+		return x == 0
+	case n.Opcode == Output:
+		return x == east
+	case m.arch.Columns > 2:
+		return x != 0 && x != east
+	default:
+		return true
+	}
+}
+
+// distanceToProducers returns the sum of the Manhattan distances from the PE
+// to the PEs of the nodes that feed the node.
+func (m *mapper) distanceToProducers(n Node, pe [2]int) int {
+	dist := 0
+
+	for _, e := range m.graph.Edges {
+		if e.To != n.Name {
+			continue
+		}
+
+		from := m.mapping.Placement[e.From]
+		dist += abs(from[0]-pe[0]) + abs(from[1]-pe[1])
+	}
+
+	return dist
+}
+
+// opcodesOf returns the opcodes that the PE of the node runs.
+func opcodesOf(n Node) []string {
+	switch n.Opcode {
+	case Input, Output:
+		return []string{"WAIT", "SEND", "JMP"}
+	default:
+		return []string{n.Opcode, "WAIT", "SEND", "JMP"}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
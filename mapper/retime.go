@@ -0,0 +1,134 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/verify"
+)
+
+// Delay is the number of cycles that Retime delays an instruction by.
+type Delay struct {
+	Inst   verify.InstRef
+	Cycles int
+}
+
+// Retiming is the result of Retime.
+type Retiming struct {
+	// Schedules are the corrected schedules.
+	Schedules map[[2]int]verify.Schedule
+
+	// Delays are the instructions that are delayed, sorted by PE and by
+	// line.
+	Delays []Delay
+
+	// AddedLatency is the number of cycles that the corrected schedules add
+	// to the last step of an iteration.
+	AddedLatency int
+}
+
+// Retime fixes the timing issues that verify.CheckTiming reports. It pads
+// the schedules by delaying each late instruction until its data is ready,
+// and the instructions that follow it as far as needed, which keeps the
+// programs and the IIs as they are. It returns an error if the IIs are too
+// small for any schedule to meet the latencies.
+func Retime(
+	programs map[[2]int]string,
+	schedules map[[2]int]verify.Schedule,
+	arch verify.ArchInfo,
+) (*Retiming, error) {
+	r := &Retiming{Schedules: make(map[[2]int]verify.Schedule)}
+	for pe, s := range schedules {
+		r.Schedules[pe] = verify.Schedule{
+			Steps: append([]int(nil), s.Steps...),
+			II:    s.II,
+		}
+	}
+
+	g := verify.BuildDepGraph(programs, arch)
+
+	for round := 0; r.relax(g); round++ {
+		if round > len(g.Insts) {
+			return nil, fmt.Errorf("the IIs are too small to meet the "+
+				"timing:\n%s", issueText(verify.CheckTiming(
+				programs, schedules, arch)))
+		}
+	}
+
+	r.report(schedules)
+
+	return r, nil
+}
+
+// relax delays the instructions that run before their data is ready, and
+// returns true if any instruction is delayed.
+func (r *Retiming) relax(g *verify.DepGraph) bool {
+	changed := false
+
+	for _, d := range g.Deps {
+		ready, ok := verify.ReadyStep(d, r.Schedules)
+		if !ok {
+			continue
+		}
+
+		steps := r.Schedules[d.To.PE].Steps
+		if steps[d.To.Line] < ready {
+			steps[d.To.Line] = ready
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+func (r *Retiming) report(original map[[2]int]verify.Schedule) {
+	lastBefore, lastAfter := 0, 0
+
+	for pe, s := range r.Schedules {
+		for line, step := range s.Steps {
+			before := original[pe].Steps[line]
+			if step > before {
+				r.Delays = append(r.Delays, Delay{
+					Inst:   verify.InstRef{PE: pe, Line: line},
+					Cycles: step - before,
+				})
+			}
+
+			lastBefore = maxInt(lastBefore, before)
+			lastAfter = maxInt(lastAfter, step)
+		}
+	}
+
+	r.AddedLatency = lastAfter - lastBefore
+
+	sort.Slice(r.Delays, func(i, j int) bool {
+		a, b := r.Delays[i].Inst, r.Delays[j].Inst
+		if a.PE[1] != b.PE[1] {
+			return a.PE[1] < b.PE[1]
+		}
+
+		if a.PE[0] != b.PE[0] {
+			return a.PE[0] < b.PE[0]
+		}
+
+		return a.Line < b.Line
+	})
+}
+
+func issueText(issues []verify.Issue) string {
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = issue.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
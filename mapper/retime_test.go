@@ -0,0 +1,49 @@
+package mapper_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/mapper"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("Retime", func() {
+	arch := verify.ArchInfo{Rows: 1, Columns: 2}
+	programs := map[[2]int]string{
+		{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+		{1, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+	}
+
+	It("should delay the instructions that run before their data", func() {
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1, 2}, II: 3},
+			{1, 0}: {Steps: []int{0, 1, 2, 3}, II: 3},
+		}
+		Expect(verify.CheckTiming(programs, schedules, arch)).To(HaveLen(1))
+
+		r, err := mapper.Retime(programs, schedules, arch)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Schedules[[2]int{1, 0}].Steps).To(Equal([]int{0, 2, 3, 4}))
+		Expect(r.Schedules[[2]int{0, 0}]).To(Equal(schedules[[2]int{0, 0}]))
+		Expect(schedules[[2]int{1, 0}].Steps).To(Equal([]int{0, 1, 2, 3}))
+		Expect(r.Delays).To(Equal([]mapper.Delay{
+			{Inst: verify.InstRef{PE: [2]int{1, 0}, Line: 1}, Cycles: 1},
+			{Inst: verify.InstRef{PE: [2]int{1, 0}, Line: 2}, Cycles: 1},
+			{Inst: verify.InstRef{PE: [2]int{1, 0}, Line: 3}, Cycles: 1},
+		}))
+		Expect(r.AddedLatency).To(Equal(1))
+		Expect(verify.CheckTiming(programs, r.Schedules, arch)).To(BeEmpty())
+	})
+
+	It("should reject IIs that are too small", func() {
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1, 2}, II: 2},
+		}
+
+		_, err := mapper.Retime(programs, schedules, arch)
+
+		Expect(err).To(MatchError(HavePrefix(
+			"the IIs are too small to meet the timing:\n[TIMING] PE(0, 0)")))
+	})
+})
@@ -0,0 +1,112 @@
+package mapper
+
+import "github.com/sarchlab/zeonica/cgra"
+
+// routeInto routes the edges that feed the node to a PE. If any edge cannot
+// be routed, it returns false and leaves the links as they were.
+func (m *mapper) routeInto(n Node, pe [2]int) bool {
+	routed := []Edge{}
+
+	for _, e := range m.graph.Edges {
+		if e.To != n.Name {
+			continue
+		}
+
+		path := m.route(m.mapping.Placement[e.From], pe)
+		if path == nil {
+			for _, r := range routed {
+				m.setLinks(m.mapping.Routes[r], false)
+				delete(m.mapping.Routes, r)
+			}
+
+			return false
+		}
+
+		m.setLinks(path, true)
+		m.mapping.Routes[e] = path
+		routed = append(routed, e)
+	}
+
+	return true
+}
+
+func (m *mapper) setLinks(path [][2]int, used bool) {
+	for i := 0; i+1 < len(path); i++ {
+		l := link{path[i], sideTo(path[i], path[i+1])}
+		if used {
+			m.links[l] = true
+		} else {
+			delete(m.links, l)
+		}
+	}
+}
+
+// route returns the shortest path of free links from one PE to another, or
+// nil if there is none. The PEs in the middle of the path forward the
+// tokens, so they must support FORWARD.
+func (m *mapper) route(from, to [2]int) [][2]int {
+	prev := map[[2]int][2]int{from: from}
+	queue := [][2]int{from}
+
+	for len(queue) > 0 {
+		pe := queue[0]
+		queue = queue[1:]
+
+		if pe == to {
+			return backtrack(prev, from, to)
+		}
+
+		if pe != from && !m.supports(pe, "FORWARD", "JMP") {
+			continue
+		}
+
+		for side := cgra.North; side <= cgra.West; side++ {
+			next := neighbor(pe, side)
+			if _, seen := prev[next]; seen || !m.enabled(next) ||
+				m.links[link{pe, side}] {
+				continue
+			}
+
+			prev[next] = pe
+			queue = append(queue, next)
+		}
+	}
+
+	return nil
+}
+
+func backtrack(prev map[[2]int][2]int, from, to [2]int) [][2]int {
+	path := [][2]int{to}
+	for pe := to; pe != from; {
+		pe = prev[pe]
+		path = append([][2]int{pe}, path...)
+	}
+
+	return path
+}
+
+func neighbor(pe [2]int, side cgra.Side) [2]int {
+	switch side {
+	case cgra.North:
+		pe[1]--
+	case cgra.East:
+		pe[0]++
+	case cgra.South:
+		pe[1]++
+	case cgra.West:
+		pe[0]--
+	}
+
+	return pe
+}
+
+// sideTo returns the side of a PE that faces an adjacent PE.
+func sideTo(from, to [2]int) cgra.Side {
+	for side := cgra.North; side <= cgra.West; side++ {
+		if neighbor(from, side) == to {
+			return side
+		}
+	}
+
+	panic("the PEs are not adjacent")
+}
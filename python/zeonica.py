@@ -0,0 +1,166 @@
+"""A client of zeonica-server that mirrors the driver API.
+
+A Simulation records the calls to the driver, like MapProgram and FeedIn,
+and run() submits them as a job to the server, waits for the job, and
+returns its results:
+
+    import zeonica
+
+    sim = zeonica.Simulation("http://localhost:8080", width=1, height=1)
+    sim.map_program("WAIT, $0, NET_RECV_3\\nGATHER, $1, $0\\n"
+                    "SCATTER, 8, $1\\nDONE", (0, 0))
+    sim.preload_memory((0, 0), 0, [5, 6, 7])
+    sim.feed_in([2], "west", 0)
+    mem = sim.read_memory((0, 0), 8, 1)
+    results = sim.run()
+    print(results.memory[mem], results.cycles)
+
+The module only uses the standard library.
+"""
+
+import json
+import time
+import urllib.error
+import urllib.request
+
+
+class Error(Exception):
+    """A job that the server rejects, or that fails."""
+
+
+class Results:
+    """The results of a job.
+
+    outputs has the data of each collect() and memory the data of each
+    read_memory(), indexed by the return values of the calls.
+    return_values maps the (x, y) of each PE to its RETURN_VALUE, and ops
+    to the number of instructions that it has executed.
+    """
+
+    def __init__(self, data):
+        self.cycles = data.get("cycles", 0)
+        self.outputs = data.get("outputs") or []
+        self.memory = data.get("memory") or []
+        self.errors = data.get("errors") or []
+        self.return_values = _by_pe(data.get("return_values"))
+        self.ops = _by_pe(data.get("ops"))
+
+
+def _by_pe(values):
+    return {
+        tuple(int(v) for v in pe.split(",")): value
+        for pe, value in (values or {}).items()
+    }
+
+
+class Simulation:
+    """A run of one kernel on a mesh of width x height PEs."""
+
+    def __init__(self, url, width, height, seed=0, trace=False):
+        self.url = url.rstrip("/")
+        self.job = {
+            "width": width,
+            "height": height,
+            "seed": seed,
+            "trace": trace,
+            "programs": [],
+            "preload": [],
+            "feed_in": [],
+            "collect": [],
+            "read_memory": [],
+        }
+        self.id = None
+        self.status = None
+
+    def load_kernel(self, path, constants=None):
+        """Loads a program file, in the ASM or the YAML format."""
+        with open(path) as f:
+            self.job["kernel"] = f.read()
+
+        yaml = path.endswith(".yaml") or path.endswith(".yml")
+        self.job["format"] = "yaml" if yaml else "asm"
+        self.job["constants"] = constants or {}
+
+    def map_program(self, program, core):
+        """Maps a program to the PE at core, an (x, y) pair."""
+        self.job["programs"].append(
+            {"x": core[0], "y": core[1], "program": program})
+
+    def preload_memory(self, core, addr, data):
+        """Writes data to the local memory of a PE before the run."""
+        self.job["preload"].append(
+            {"x": core[0], "y": core[1], "addr": addr, "data": list(data)})
+
+    def feed_in(self, data, side, port):
+        """Sends data into a port of a side: north, east, south, or west."""
+        self.job["feed_in"].append(
+            {"side": side, "port": port, "data": list(data)})
+
+    def collect(self, side, port, length):
+        """Receives length values from a port of a side.
+
+        Returns the index of the data in the outputs of the results.
+        """
+        self.job["collect"].append(
+            {"side": side, "port": port, "length": length})
+
+        return len(self.job["collect"]) - 1
+
+    def read_memory(self, core, addr, length):
+        """Reads length words of the local memory of a PE after the run.
+
+        Returns the index of the data in the memory of the results.
+        """
+        self.job["read_memory"].append(
+            {"x": core[0], "y": core[1], "addr": addr, "length": length})
+
+        return len(self.job["read_memory"]) - 1
+
+    def run(self, poll=0.1):
+        """Submits the job, waits for it, and returns its Results.
+
+        Raises Error if the server rejects the job or if the job fails
+        before it has results, e.g., for a program that does not load. A
+        job that deadlocks has results, and the error is in the status
+        attribute.
+        """
+        status = self._request("POST", "/jobs", self.job)
+        self.id = status["id"]
+        if status["state"] == "rejected":
+            raise Error(status["error"])
+
+        while status["state"] in ("queued", "running"):
+            time.sleep(poll)
+            status = self._request("GET", "/jobs/" + self.id)
+
+        self.status = status
+        data = self._request("GET", "/jobs/%s/results" % self.id)
+        if "cycles" not in data:
+            raise Error(status.get("error", "the job has no results"))
+
+        return Results(data)
+
+    def artifact(self, name):
+        """Returns a file of the job, e.g., trace.log, as bytes."""
+        url = "%s/jobs/%s/artifacts/%s" % (self.url, self.id, name)
+        with urllib.request.urlopen(url) as resp:
+            return resp.read()
+
+    def _request(self, method, path, body=None):
+        data = None if body is None else json.dumps(body).encode()
+        req = urllib.request.Request(
+            self.url + path, data=data, method=method,
+            headers={"Content-Type": "application/json"})
+
+        try:
+            with urllib.request.urlopen(req) as resp:
+                return json.load(resp)
+        except urllib.error.HTTPError as e:
+            if e.code not in (400, 409):
+                raise
+
+            text = e.read().decode()
+            try:
+                return json.loads(text)
+            except ValueError:
+                raise Error(text.strip()) from None
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/tebeka/atexit"
+)
+
+var width = 8
+var height = 4
+
+// writeScenario writes the programs and a scenario that feeds the values in,
+// so that zeonica run and zeonica verify can check the kernel.
+func writeScenario(dir string, p api.PrefixSum, values []uint32) error {
+	f, err := os.Create(filepath.Join(dir, "prefixsum.asm"))
+	if err != nil {
+		return err
+	}
+
+	err = core.WriteProgramsToASM(f, p.Programs)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	data := strings.Trim(fmt.Sprint(p.Input(values)), "[]")
+	scenario := fmt.Sprintf("programs: prefixsum.asm\n"+
+		"feed_in:\n"+
+		"  - {side: west, ports: [0, %d], stride: %d, data: [%s]}\n"+
+		"collect:\n"+
+		"  - {side: east, ports: [0, %d], stride: %d, length: %d}\n",
+		p.Rows, p.Rows, strings.ReplaceAll(data, " ", ", "),
+		p.Rows, p.Rows, len(values))
+
+	return os.WriteFile(filepath.Join(dir, "scenario.yaml"),
+		[]byte(scenario), 0o644)
+}
+
+func prefixSum(driver api.Driver, scenarioDir string) int {
+	n := width * height
+	p := api.GeneratePrefixSumProgram(n, width, height)
+
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = uint32(rand.Intn(100))
+	}
+
+	if scenarioDir != "" {
+		if err := writeScenario(scenarioDir, p, values); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+
+	sums := p.Run(driver, values)
+
+	fmt.Println(values)
+	fmt.Println(sums)
+
+	sum := uint32(0)
+	for i, v := range values {
+		sum += v
+		if sums[i] != sum {
+			fmt.Printf("mismatch at %d: expected %d, got %d\n", i, sum, sums[i])
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func main() {
+	scenarioDir := flag.String("scenario", "",
+		"write the programs and a scenario for zeonica run to a directory")
+	flag.Parse()
+
+	engine := sim.NewSerialEngine()
+
+	driver := api.DriverBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Driver")
+
+	device := config.DeviceBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWidth(width).
+		WithHeight(height).
+		Build("Device")
+
+	driver.RegisterDevice(device)
+	atexit.Exit(prefixSum(driver, *scenarioDir))
+}
@@ -0,0 +1,663 @@
+PE(0, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	DONE
+
+PE(1, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(2, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(3, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(4, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(5, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(6, 0):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(7, 0):
+	WAIT, $0, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_2, $0
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(0, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	WAIT, $1, NET_RECV_1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	DONE
+
+PE(1, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(2, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(3, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(4, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(5, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(6, 1):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(7, 1):
+	WAIT, $0, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_0
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_2, $0
+	SEND, NET_SEND_3, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(0, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	WAIT, $1, NET_RECV_1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	DONE
+
+PE(1, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(2, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(3, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(4, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(5, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(6, 2):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(7, 2):
+	WAIT, $0, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_0
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_2, $0
+	SEND, NET_SEND_3, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(0, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	SEND, NET_SEND_1, $0
+	WAIT, $1, NET_RECV_1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	DONE
+
+PE(1, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(2, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(3, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(4, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(5, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(6, 3):
+	WAIT, $0, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	SEND, NET_SEND_1, $0
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	FORWARD, NET_SEND_1, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_1
+	SEND, NET_SEND_3, $1
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
+
+PE(7, 3):
+	WAIT, $0, NET_RECV_3
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	REDUCE_ADD, $0, $0, 8
+	WAIT, $1, NET_RECV_0
+	I_ADD, $0, $0, $1
+	SEND, NET_SEND_3, $1
+	SEND, NET_SEND_1, $0
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	FORWARD, NET_SEND_1, NET_RECV_3
+	DONE
@@ -0,0 +1,538 @@
+// Package server runs simulations for remote clients over HTTP. A client
+// submits a job with a kernel and the data to feed in and collect, the
+// server queues the job and runs it with the zeonica package, and the
+// client polls the job and fetches its results and artifacts:
+//
+//	POST /jobs                       submit a job, returns its status
+//	GET  /jobs                       list the status of every job
+//	GET  /jobs/{id}                  the status of a job
+//	GET  /jobs/{id}/results          the results of a finished job
+//	GET  /jobs/{id}/artifacts/{name} a file of a job, e.g., trace.log
+//
+// The requests and the responses are JSON, except for the artifacts.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/sarchlab/zeonica"
+)
+
+// The states of a job.
+const (
+	Queued   = "queued"
+	Running  = "running"
+	Done     = "done"
+	Failed   = "failed"
+	Rejected = "rejected"
+)
+
+// FeedIn is the data that a job feeds into a port of a side of the device.
+type FeedIn struct {
+	Side string   `json:"side"`
+	Port int      `json:"port"`
+	Data []uint32 `json:"data"`
+}
+
+// Collect is the number of values that a job collects from a port of a
+// side of the device.
+type Collect struct {
+	Side   string `json:"side"`
+	Port   int    `json:"port"`
+	Length int    `json:"length"`
+}
+
+// Program is the program of the PE at (x, y).
+type Program struct {
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Program string `json:"program"`
+}
+
+// Memory is the data in the local memory of the PE at (x, y), starting
+// from an address in words.
+type Memory struct {
+	X    int      `json:"x"`
+	Y    int      `json:"y"`
+	Addr int      `json:"addr"`
+	Data []uint32 `json:"data"`
+}
+
+// MemoryRange is a range of the local memory of the PE at (x, y).
+type MemoryRange struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Addr   int `json:"addr"`
+	Length int `json:"length"`
+}
+
+// Job is a kernel and the device and the data to run it with.
+type Job struct {
+	// Kernel is the program file, in the format of Format, which is yaml
+	// or asm. Programs maps programs to single PEs, after the kernel, if
+	// any.
+	Kernel   string    `json:"kernel,omitempty"`
+	Format   string    `json:"format,omitempty"`
+	Programs []Program `json:"programs,omitempty"`
+
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Seed   int64 `json:"seed"`
+
+	// Constants override the named constants of the kernel.
+	Constants map[string]uint32 `json:"constants,omitempty"`
+
+	// Preload is written to the local memories before the run, and
+	// ReadMemory is read into the Memory of the results after the run.
+	Preload    []Memory      `json:"preload,omitempty"`
+	FeedIn     []FeedIn      `json:"feed_in"`
+	Collect    []Collect     `json:"collect"`
+	ReadMemory []MemoryRange `json:"read_memory,omitempty"`
+
+	// Trace keeps the text trace of the run as the trace.log artifact.
+	Trace bool `json:"trace"`
+}
+
+// Status is the state of a job, and the error of a job that has failed or
+// has been rejected.
+type Status struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// Results are the results of a job.
+type Results struct {
+	Cycles       uint64            `json:"cycles"`
+	Outputs      [][]uint32        `json:"outputs"`
+	ReturnValues map[string]uint32 `json:"return_values"`
+	Errors       []string          `json:"errors"`
+
+	// Ops is the number of instructions that each PE has executed, keyed
+	// by "x,y", like the ReturnValues.
+	Ops map[string]uint64 `json:"ops"`
+
+	// Memory has the data of each range of the ReadMemory of the job.
+	Memory [][]uint32 `json:"memory,omitempty"`
+}
+
+type entry struct {
+	job     Job
+	status  Status
+	results *Results
+}
+
+// Server queues the jobs and runs them on a fixed number of workers. The
+// files of each job go into a folder of the work directory, named after
+// the ID of the job.
+type Server struct {
+	dir   string
+	queue chan string
+
+	lock   sync.Mutex
+	jobs   map[string]*entry
+	nextID int
+}
+
+// New creates a server that keeps the files of the jobs in dir, and starts
+// its workers. At most queueSize jobs wait in the queue; the server
+// rejects the jobs that are submitted when the queue is full.
+func New(dir string, workers, queueSize int) (*Server, error) {
+	if workers <= 0 {
+		return nil, fmt.Errorf("invalid number of workers %d", workers)
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		dir:   dir,
+		queue: make(chan string, queueSize),
+		jobs:  make(map[string]*entry),
+	}
+
+	for i := 0; i < workers; i++ {
+		go s.work()
+	}
+
+	return s, nil
+}
+
+// Submit queues a job and returns its status.
+func (s *Server) Submit(job Job) Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	e := &entry{job: job, status: Status{ID: id, State: Queued}}
+	s.jobs[id] = e
+
+	err := checkJob(job)
+	if err == nil {
+		err = os.MkdirAll(s.jobDir(id), 0o755)
+	}
+
+	if err == nil {
+		select {
+		case s.queue <- id:
+		default:
+			err = errors.New("the queue is full")
+		}
+	}
+
+	if err != nil {
+		e.status.State = Rejected
+		e.status.Error = err.Error()
+	}
+
+	return e.status
+}
+
+// Status returns the status of a job.
+func (s *Server) Status(id string) (Status, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.jobs[id]
+	if !ok {
+		return Status{}, false
+	}
+
+	return e.status, true
+}
+
+// Results returns the results of a job that has run, even if it has
+// failed, or nil if the job has not run.
+func (s *Server) Results(id string) *Results {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	return e.results
+}
+
+func checkJob(job Job) error {
+	if job.Kernel == "" && len(job.Programs) == 0 {
+		return errors.New("the job has no kernel and no programs")
+	}
+
+	if job.Kernel != "" && job.Format != "yaml" && job.Format != "asm" {
+		return fmt.Errorf("invalid format %q", job.Format)
+	}
+
+	if len(job.Collect) == 0 && len(job.ReadMemory) == 0 {
+		return errors.New("the job collects no data")
+	}
+
+	return nil
+}
+
+func (s *Server) jobDir(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *Server) work() {
+	for id := range s.queue {
+		s.lock.Lock()
+		e := s.jobs[id]
+		e.status.State = Running
+		s.lock.Unlock()
+
+		results, err := s.run(id, e.job)
+
+		s.lock.Lock()
+		e.results = results
+		e.status.State = Done
+
+		if err != nil {
+			e.status.State = Failed
+			e.status.Error = err.Error()
+		}
+		s.lock.Unlock()
+	}
+}
+
+func (s *Server) run(id string, job Job) (*Results, error) {
+	dir := s.jobDir(id)
+
+	if job.Kernel != "" {
+		err := os.WriteFile(filepath.Join(dir, "kernel."+job.Format),
+			[]byte(job.Kernel), 0o644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var w io.Writer
+
+	if job.Trace {
+		f, err := os.Create(filepath.Join(dir, "trace.log"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	results, err := Run(job, w)
+	if results == nil {
+		return nil, err
+	}
+
+	writeErr := writeJSON(filepath.Join(dir, "results.json"), results)
+	if err == nil {
+		err = writeErr
+	}
+
+	return results, err
+}
+
+// Run runs a job in the calling goroutine, without a server, e.g., in a
+// browser, and writes the trace of the run to w if w is not nil. The
+// results are nil if the job fails before the run.
+func Run(job Job, w io.Writer) (*Results, error) {
+	err := checkJob(job)
+	if err != nil {
+		return nil, err
+	}
+
+	c := zeonica.Config{
+		Width:  job.Width,
+		Height: job.Height,
+		Seed:   job.Seed,
+		Trace:  w,
+		FS: fstest.MapFS{
+			"kernel." + job.Format: &fstest.MapFile{Data: []byte(job.Kernel)},
+		},
+	}
+
+	sim, err := zeonica.NewSimulator(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = load(sim, job)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, feed := range job.FeedIn {
+		err = sim.FeedIn(feed.Side, feed.Port, feed.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, collect := range job.Collect {
+		err = sim.Collect(collect.Side, collect.Port, collect.Length)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	runErr := sim.Run()
+	results := convertResults(sim.Results())
+
+	for _, m := range job.ReadMemory {
+		data, err := sim.ReadMemory(m.X, m.Y, m.Addr, m.Length)
+		if err != nil {
+			return results, err
+		}
+
+		results.Memory = append(results.Memory, data)
+	}
+
+	return results, runErr
+}
+
+// load loads the kernel and the programs of a job, and preloads the
+// memories.
+func load(sim *zeonica.Simulator, job Job) error {
+	if job.Kernel != "" {
+		err := sim.LoadKernel("kernel."+job.Format, job.Constants)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range job.Programs {
+		err := sim.MapProgram(p.X, p.Y, p.Program)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range job.Preload {
+		err := sim.PreloadMemory(m.X, m.Y, m.Addr, m.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func convertResults(r zeonica.Results) *Results {
+	results := &Results{
+		Cycles:       r.Cycles,
+		Outputs:      r.Outputs,
+		ReturnValues: make(map[string]uint32),
+		Ops:          make(map[string]uint64),
+		Errors:       r.Errors,
+	}
+
+	for c, v := range r.ReturnValues {
+		results.ReturnValues[fmt.Sprintf("%d,%d", c[0], c[1])] = v
+	}
+
+	for c, n := range r.Ops {
+		results.Ops[fmt.Sprintf("%d,%d", c[0], c[1])] = n
+	}
+
+	return results
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ServeHTTP serves the endpoints of the package documentation.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "jobs" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.serveSubmit(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.serveList(w)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.serveStatus(w, r, parts[1])
+	case len(parts) == 3 && parts[2] == "results" &&
+		r.Method == http.MethodGet:
+		s.serveResults(w, r, parts[1])
+	case len(parts) == 4 && parts[2] == "artifacts" &&
+		r.Method == http.MethodGet:
+		s.serveArtifact(w, r, parts[1], parts[3])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveSubmit(w http.ResponseWriter, r *http.Request) {
+	job := Job{}
+
+	err := json.NewDecoder(r.Body).Decode(&job)
+	if err != nil {
+		http.Error(w, "cannot parse the job: "+err.Error(),
+			http.StatusBadRequest)
+		return
+	}
+
+	status := s.Submit(job)
+	if status.State == Rejected {
+		respond(w, http.StatusBadRequest, status)
+		return
+	}
+
+	respond(w, http.StatusAccepted, status)
+}
+
+func (s *Server) serveList(w http.ResponseWriter) {
+	s.lock.Lock()
+	list := make([]Status, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		list = append(list, e.status)
+	}
+	s.lock.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, _ := strconv.Atoi(list[i].ID)
+		b, _ := strconv.Atoi(list[j].ID)
+
+		return a < b
+	})
+
+	respond(w, http.StatusOK, list)
+}
+
+func (s *Server) serveStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	id string,
+) {
+	status, ok := s.Status(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	respond(w, http.StatusOK, status)
+}
+
+func (s *Server) serveResults(
+	w http.ResponseWriter,
+	r *http.Request,
+	id string,
+) {
+	status, ok := s.Status(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	results := s.Results(id)
+	if results == nil {
+		respond(w, http.StatusConflict, status)
+		return
+	}
+
+	respond(w, http.StatusOK, results)
+}
+
+func (s *Server) serveArtifact(
+	w http.ResponseWriter,
+	r *http.Request,
+	id, name string,
+) {
+	_, ok := s.Status(id)
+	if !ok || name == "" || name != filepath.Base(name) || name[0] == '.' {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeFile(w, r, filepath.Join(s.jobDir(id), name))
+}
+
+func respond(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,235 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/server"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		ts     *httptest.Server
+		kernel string
+	)
+
+	BeforeEach(func() {
+		s, err := server.New(GinkgoT().TempDir(), 1, 4)
+		Expect(err).NotTo(HaveOccurred())
+
+		ts = httptest.NewServer(s)
+		DeferCleanup(ts.Close)
+
+		data, err := os.ReadFile("../kernels/programs/axpy.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		kernel = string(data)
+	})
+
+	submit := func(job server.Job) (int, server.Status) {
+		body, err := json.Marshal(job)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := http.Post(ts.URL+"/jobs", "application/json",
+			bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		status := server.Status{}
+		Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+
+		return resp.StatusCode, status
+	}
+
+	get := func(path string, v interface{}) int {
+		resp, err := http.Get(ts.URL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		if v != nil {
+			Expect(json.NewDecoder(resp.Body).Decode(v)).To(Succeed())
+		}
+
+		return resp.StatusCode
+	}
+
+	wait := func(id string) server.Status {
+		status := server.Status{}
+		Eventually(func() string {
+			get("/jobs/"+id, &status)
+			return status.State
+		}).ShouldNot(BeElementOf(server.Queued, server.Running))
+
+		return status
+	}
+
+	It("should run a job and serve its results and artifacts", func() {
+		code, status := submit(server.Job{
+			Kernel:    kernel,
+			Format:    "yaml",
+			Width:     1,
+			Height:    1,
+			Constants: map[string]uint32{"A": 3},
+			FeedIn:    []server.FeedIn{{Side: "west", Data: []uint32{1, 10, 2, 20}}},
+			Collect:   []server.Collect{{Side: "east", Length: 2}},
+			Trace:     true,
+		})
+		Expect(code).To(Equal(http.StatusAccepted))
+		Expect(status.ID).To(Equal("1"))
+
+		Expect(wait("1")).To(Equal(server.Status{ID: "1", State: server.Done}))
+
+		results := server.Results{}
+		Expect(get("/jobs/1/results", &results)).To(Equal(http.StatusOK))
+		Expect(results.Outputs).To(Equal([][]uint32{{13, 26}}))
+		Expect(results.Cycles).To(BeNumerically(">", 0))
+
+		resp, err := http.Get(ts.URL + "/jobs/1/artifacts/trace.log")
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		log, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(log)).To(ContainSubstring("Device"))
+
+		Expect(get("/jobs/1/artifacts/..", nil)).To(Equal(http.StatusNotFound))
+		Expect(get("/jobs/2", nil)).To(Equal(http.StatusNotFound))
+	})
+
+	It("should map programs and read the local memories", func() {
+		_, status := submit(server.Job{
+			Programs: []server.Program{{Program: "WAIT, $0, NET_RECV_3\n" +
+				"GATHER, $1, $0\nSCATTER, 8, $1\nDONE"}},
+			Width:      1,
+			Height:     1,
+			Preload:    []server.Memory{{Data: []uint32{5, 6, 7}}},
+			FeedIn:     []server.FeedIn{{Side: "west", Data: []uint32{2}}},
+			ReadMemory: []server.MemoryRange{{Addr: 8, Length: 1}},
+		})
+		Expect(wait(status.ID).State).To(Equal(server.Done))
+
+		results := server.Results{}
+		Expect(get("/jobs/1/results", &results)).To(Equal(http.StatusOK))
+		Expect(results.Memory).To(Equal([][]uint32{{7}}))
+	})
+
+	It("should report the jobs that fail or are rejected", func() {
+		code, status := submit(server.Job{Kernel: kernel, Format: "c"})
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(status).To(Equal(server.Status{
+			ID: "1", State: server.Rejected, Error: "invalid format \"c\"",
+		}))
+
+		_, status = submit(server.Job{
+			Kernel:  kernel,
+			Format:  "yaml",
+			Width:   1,
+			Height:  1,
+			FeedIn:  []server.FeedIn{{Side: "west", Data: []uint32{1, 2}}},
+			Collect: []server.Collect{{Side: "east", Length: 3}},
+		})
+		Expect(wait(status.ID)).To(Equal(server.Status{
+			ID:    "2",
+			State: server.Failed,
+			Error: "the simulation stopped before output 0 was collected",
+		}))
+
+		results := server.Results{}
+		Expect(get("/jobs/2/results", &results)).To(Equal(http.StatusOK))
+		Expect(results.Outputs).To(Equal([][]uint32{{3, 0, 0}}))
+
+		list := []server.Status{}
+		Expect(get("/jobs", &list)).To(Equal(http.StatusOK))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].State).To(Equal(server.Rejected))
+	})
+
+	It("should run a job without a server", func() {
+		w := &strings.Builder{}
+		results, err := server.Run(server.Job{
+			Kernel:  kernel,
+			Format:  "yaml",
+			Width:   1,
+			Height:  1,
+			FeedIn:  []server.FeedIn{{Side: "west", Data: []uint32{1, 10}}},
+			Collect: []server.Collect{{Side: "east", Length: 1}},
+		}, w)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(results.Outputs).To(Equal([][]uint32{{11}}))
+		Expect(results.Ops).To(HaveKeyWithValue("0,0", BeNumerically(">", 0)))
+		Expect(w.String()).To(ContainSubstring("Device"))
+
+		_, err = server.Run(server.Job{}, nil)
+		Expect(err).To(MatchError("the job has no kernel and no programs"))
+	})
+
+	It("should run the jobs that the Python client submits", func() {
+		python, err := exec.LookPath("python3")
+		if err != nil {
+			Skip("python3 is not installed")
+		}
+
+		script := `
+import json, sys
+import zeonica
+
+sim = zeonica.Simulation(sys.argv[1], width=1, height=1)
+sim.map_program("WAIT, $0, NET_RECV_3\nGATHER, $1, $0\n"
+                "SCATTER, 8, $1\nRETURN_VALUE, $1\nDONE", (0, 0))
+sim.preload_memory((0, 0), 0, [5, 6, 7])
+sim.feed_in([2], "west", 0)
+mem = sim.read_memory((0, 0), 8, 1)
+results = sim.run(poll=0.01)
+
+kernel = zeonica.Simulation(sys.argv[1], width=1, height=1)
+kernel.load_kernel("../kernels/programs/axpy.yaml", {"A": 3})
+kernel.feed_in([1, 10], "west", 0)
+out = kernel.collect("east", 0, 1)
+outputs = kernel.run(poll=0.01).outputs[out]
+
+try:
+    zeonica.Simulation(sys.argv[1], width=1, height=1).run(poll=0.01)
+    failed = ""
+except zeonica.Error as e:
+    failed = str(e)
+
+json.dump({
+    "memory": results.memory[mem],
+    "cycles": results.cycles,
+    "return_values": {
+        "%d,%d" % pe: v for pe, v in results.return_values.items()},
+    "outputs": outputs,
+    "failed": failed,
+}, sys.stdout)
+`
+		cmd := exec.Command(python, "-c", script, ts.URL)
+		cmd.Env = append(os.Environ(), "PYTHONPATH=../python")
+		cmd.Stderr = GinkgoWriter
+		out, err := cmd.Output()
+		Expect(err).NotTo(HaveOccurred())
+
+		var results struct {
+			Memory       []uint32          `json:"memory"`
+			Cycles       uint64            `json:"cycles"`
+			ReturnValues map[string]uint32 `json:"return_values"`
+			Outputs      []uint32          `json:"outputs"`
+			Failed       string            `json:"failed"`
+		}
+		Expect(json.Unmarshal(out, &results)).To(Succeed())
+
+		Expect(results.Memory).To(Equal([]uint32{7}))
+		Expect(results.Cycles).To(BeNumerically(">", 0))
+		Expect(results.ReturnValues).To(Equal(map[string]uint32{"0,0": 7}))
+		Expect(results.Outputs).To(Equal([]uint32{13}))
+		Expect(results.Failed).
+			To(Equal("the job has no kernel and no programs"))
+	})
+})
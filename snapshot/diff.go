@@ -0,0 +1,162 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// Difference is a part of the state of a PE that differs between two
+// snapshots. Field names the part, e.g., "PC", "$3", or "NET_RECV_1".
+type Difference struct {
+	X, Y  int
+	Field string
+	A, B  string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("PE(%d, %d) %s: %s -> %s", d.X, d.Y, d.Field, d.A, d.B)
+}
+
+// Diff lists the differences between the snapshots a and b, ordered by the
+// PE. A PE that is in only one of the snapshots is a single difference
+// in the "PE" field.
+func Diff(a, b *Snapshot) []Difference {
+	as, bs := byCoord(a), byCoord(b)
+
+	coords := make([][2]int, 0, len(as)+len(bs))
+	for coord := range as {
+		coords = append(coords, coord)
+	}
+
+	for coord := range bs {
+		if _, ok := as[coord]; !ok {
+			coords = append(coords, coord)
+		}
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	diffs := []Difference{}
+	for _, coord := range coords {
+		sa, inA := as[coord]
+		sb, inB := bs[coord]
+
+		switch {
+		case !inA:
+			diffs = append(diffs, Difference{coord[0], coord[1], "PE", "missing", "present"})
+		case !inB:
+			diffs = append(diffs, Difference{coord[0], coord[1], "PE", "present", "missing"})
+		default:
+			for _, d := range diffState(sa, sb) {
+				d.X, d.Y = coord[0], coord[1]
+				diffs = append(diffs, d)
+			}
+		}
+	}
+
+	return diffs
+}
+
+func byCoord(s *Snapshot) map[[2]int]cgra.TileState {
+	m := make(map[[2]int]cgra.TileState)
+	for _, pe := range s.PEs {
+		m[[2]int{pe.X, pe.Y}] = pe.State
+	}
+
+	return m
+}
+
+// diffState compares two states field by field. Missing registers and
+// buffer entries compare as 0 or false.
+func diffState(a, b cgra.TileState) []Difference {
+	diffs := []Difference{}
+	add := func(field string, va, vb interface{}) {
+		if va != vb {
+			diffs = append(diffs, Difference{
+				Field: field, A: fmt.Sprint(va), B: fmt.Sprint(vb)})
+		}
+	}
+
+	add("PC", a.PC, b.PC)
+
+	for i := 0; i < maxLen(len(a.Registers), len(b.Registers)); i++ {
+		add(fmt.Sprintf("$%d", i), word(a.Registers, i), word(b.Registers, i))
+	}
+
+	for i := 0; i < maxLen(len(a.RecvBuf), len(b.RecvBuf)); i++ {
+		name := fmt.Sprintf("NET_RECV_%d", i)
+		add(name, word(a.RecvBuf, i), word(b.RecvBuf, i))
+		add(name+" ready", flag(a.RecvReady, i), flag(b.RecvReady, i))
+	}
+
+	for i := 0; i < maxLen(len(a.SendBuf), len(b.SendBuf)); i++ {
+		name := fmt.Sprintf("NET_SEND_%d", i)
+		add(name, word(a.SendBuf, i), word(b.SendBuf, i))
+		add(name+" busy", flag(a.SendBusy, i), flag(b.SendBusy, i))
+	}
+
+	add("done", a.Done, b.Done)
+	add("return value", retVal(a), retVal(b))
+
+	return diffs
+}
+
+func maxLen(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func word(values []uint32, i int) uint32 {
+	if i < len(values) {
+		return values[i]
+	}
+
+	return 0
+}
+
+func flag(values []bool, i int) bool {
+	if i < len(values) {
+		return values[i]
+	}
+
+	return false
+}
+
+func retVal(s cgra.TileState) string {
+	if !s.HasRetVal {
+		return "none"
+	}
+
+	return fmt.Sprint(s.RetVal)
+}
+
+// WriteDiff writes the differences to w, grouped by the PE.
+func WriteDiff(w io.Writer, diffs []Difference) error {
+	for i, d := range diffs {
+		if i == 0 || d.X != diffs[i-1].X || d.Y != diffs[i-1].Y {
+			_, err := fmt.Fprintf(w, "PE(%d, %d):\n", d.X, d.Y)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "  %s: %s -> %s\n", d.Field, d.A, d.B)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
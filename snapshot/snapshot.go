@@ -0,0 +1,96 @@
+// Package snapshot saves the state of the tiles at the end of a run and
+// compares the states of two runs, e.g., before and after a change to the
+// emulator.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/sarchlab/zeonica/cgra"
+)
+
+// PE is the state of the PE at [X, Y].
+type PE struct {
+	X     int            `json:"x"`
+	Y     int            `json:"y"`
+	State cgra.TileState `json:"state"`
+}
+
+// Snapshot is the state of all the PEs of a device, in the row-major order.
+type Snapshot struct {
+	PEs []PE `json:"pes"`
+}
+
+// New creates a snapshot from the states that are keyed by the [x, y]
+// coordinate of the PE, e.g., the states that a driver returns.
+func New(states map[[2]int]cgra.TileState) *Snapshot {
+	s := &Snapshot{PEs: make([]PE, 0, len(states))}
+	for coord, state := range states {
+		s.PEs = append(s.PEs, PE{X: coord[0], Y: coord[1], State: state})
+	}
+
+	sort.Slice(s.PEs, func(i, j int) bool {
+		if s.PEs[i].Y != s.PEs[j].Y {
+			return s.PEs[i].Y < s.PEs[j].Y
+		}
+
+		return s.PEs[i].X < s.PEs[j].X
+	})
+
+	return s
+}
+
+// Write writes the snapshot to w in JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}
+
+// Read reads a snapshot in JSON from r.
+func Read(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+
+	err := json.NewDecoder(r).Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	return s, nil
+}
+
+// Save writes the snapshot to a file.
+func (s *Snapshot) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = s.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Load reads a snapshot from a file.
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,90 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/snapshot"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// run runs one program on each of two PEs and takes a snapshot.
+func run(program string) *snapshot.Snapshot {
+	engine := sim.NewSerialEngine()
+	driver := api.DriverBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		Build("Driver")
+	driver.RegisterDevice(config.DeviceBuilder{}.
+		WithEngine(engine).
+		WithFreq(1 * sim.GHz).
+		WithWidth(2).
+		WithHeight(1).
+		WithTracer(trace.Discard).
+		Build("Device"))
+
+	driver.MapProgram("I_ADD, $1, 0, 3\nRETURN_VALUE, $1\nDONE", [2]int{0, 0})
+	driver.MapProgram(program, [2]int{1, 0})
+	driver.Run()
+
+	return snapshot.New(driver.GetTileStates())
+}
+
+var _ = Describe("Snapshot", func() {
+	It("should record the state of every PE", func() {
+		s := run("I_ADD, $2, 0, 5\nDONE")
+
+		Expect(s.PEs).To(HaveLen(2))
+		Expect(s.PEs[0].X).To(Equal(0))
+		Expect(s.PEs[0].State.Registers[1]).To(Equal(uint32(3)))
+		Expect(s.PEs[0].State.HasRetVal).To(BeTrue())
+		Expect(s.PEs[1].State.Registers[2]).To(Equal(uint32(5)))
+		Expect(s.PEs[1].State.Done).To(BeTrue())
+	})
+
+	It("should save and load a snapshot", func() {
+		s := run("I_ADD, $2, 0, 5\nDONE")
+		path := filepath.Join(GinkgoT().TempDir(), "run.json")
+
+		Expect(s.Save(path)).To(Succeed())
+
+		loaded, err := snapshot.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(s))
+		Expect(snapshot.Diff(s, loaded)).To(BeEmpty())
+	})
+
+	It("should report the differences of each PE", func() {
+		a := run("I_ADD, $2, 0, 5\nDONE")
+		b := run("I_ADD, $2, 0, 6\nRETURN_VALUE, $2\nDONE")
+
+		diffs := snapshot.Diff(a, b)
+		Expect(diffs).To(Equal([]snapshot.Difference{
+			{X: 1, Y: 0, Field: "PC", A: "1", B: "2"},
+			{X: 1, Y: 0, Field: "$2", A: "5", B: "6"},
+			{X: 1, Y: 0, Field: "return value", A: "none", B: "6"},
+		}))
+
+		buf := &bytes.Buffer{}
+		Expect(snapshot.WriteDiff(buf, diffs)).To(Succeed())
+		Expect(buf.String()).To(Equal("PE(1, 0):\n" +
+			"  PC: 1 -> 2\n" +
+			"  $2: 5 -> 6\n" +
+			"  return value: none -> 6\n"))
+	})
+
+	It("should report the PEs that are in one snapshot only", func() {
+		a := snapshot.New(map[[2]int]cgra.TileState{{0, 0}: {}})
+		b := snapshot.New(map[[2]int]cgra.TileState{{0, 0}: {}, {0, 1}: {}})
+
+		Expect(snapshot.Diff(a, b)).To(Equal([]snapshot.Difference{
+			{X: 0, Y: 1, Field: "PE", A: "missing", B: "present"},
+		}))
+	})
+})
@@ -0,0 +1,236 @@
+package testbench
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Time       float64         `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Failure    *junitFailure   `xml:"failure"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report in the JUnit XML format, with a test case
+// for each kernel. The reason of a failure is the type of the failure, and
+// each mismatch is a line in its body. The cycles are a property of the
+// test case.
+func (r Report) WriteJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:     "testbench",
+		Tests:    len(r.Results),
+		Failures: len(r.Results) - r.Passed(),
+	}
+
+	for _, res := range r.Results {
+		c := junitCase{
+			Name:      res.Kernel,
+			ClassName: "testbench",
+			Time:      res.Elapsed.Seconds(),
+			Properties: []junitProperty{
+				{Name: "cycles", Value: fmt.Sprint(res.Cycles)},
+			},
+		}
+
+		if !res.Passed() {
+			c.Failure = &junitFailure{
+				Type:    string(res.Reason),
+				Message: res.Detail,
+				Body:    mismatchLines(res.Mismatches),
+			}
+		}
+
+		suite.Time += c.Time
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(junitSuites{Suites: []junitSuite{suite}})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+
+	return err
+}
+
+func mismatchLines(mismatches []Mismatch) string {
+	lines := make([]string, 0, len(mismatches))
+	for _, m := range mismatches {
+		lines = append(lines, m.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// tapDiagnostic is the YAML block that follows a test point in TAP.
+type tapDiagnostic struct {
+	Reason     string        `yaml:"reason,omitempty"`
+	Message    string        `yaml:"message,omitempty"`
+	Cycles     uint64        `yaml:"cycles"`
+	Mismatches []tapMismatch `yaml:"mismatches,omitempty"`
+}
+
+type tapMismatch struct {
+	Output string `yaml:"output"`
+	Index  int    `yaml:"index"`
+	Got    uint32 `yaml:"got"`
+	Want   uint32 `yaml:"want"`
+}
+
+// WriteTAP writes the report in the TAP version 13 format, with a test point
+// for each kernel. A YAML block after each test point has the cycles and,
+// if the kernel fails, the reason, the detail, and the mismatches.
+func (r Report) WriteTAP(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(r.Results))
+	if err != nil {
+		return err
+	}
+
+	for i, res := range r.Results {
+		status := "ok"
+		if !res.Passed() {
+			status = "not ok"
+		}
+
+		_, err = fmt.Fprintf(w, "%s %d - %s\n", status, i+1, res.Kernel)
+		if err != nil {
+			return err
+		}
+
+		err = writeTAPDiagnostic(w, res)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTAPDiagnostic(w io.Writer, res Result) error {
+	d := tapDiagnostic{
+		Reason:  string(res.Reason),
+		Message: res.Detail,
+		Cycles:  res.Cycles,
+	}
+
+	for _, m := range res.Mismatches {
+		d.Mismatches = append(d.Mismatches, tapMismatch(m))
+	}
+
+	buf := strings.Builder{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	err := enc.Encode(d)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = "  " + lines[i]
+	}
+
+	_, err = fmt.Fprintf(w, "  ---\n%s\n  ...\n", strings.Join(lines, "\n"))
+
+	return err
+}
+
+// CSVColumns are the columns of WriteCSV, in order. New columns are only
+// added at the end, so that scripts can rely on the names and the order:
+//
+//	kernel       the name of the kernel folder
+//	status       PASS or FAIL
+//	reason       the reason code of a failure, or empty
+//	width        the number of columns of the device
+//	height       the number of rows of the device
+//	pes          the number of PEs that run a program
+//	ii           the initiation interval that verify.AnalyzeII estimates
+//	cycles       the simulated cycles
+//	inst_cycles  the cycles in which the PEs executed instructions, summed
+//	idle_cycles  the cycles in which the PEs did nothing, summed
+//	utilization  inst_cycles over the cycles of all the PEs, from 0 to 1
+//	mismatches   the number of outputs that differ from the expected ones
+//	elapsed_sec  the wall-clock time of the run in seconds
+var CSVColumns = []string{
+	"kernel", "status", "reason", "width", "height", "pes", "ii", "cycles",
+	"inst_cycles", "idle_cycles", "utilization", "mismatches", "elapsed_sec",
+}
+
+// WriteCSV writes the report as a CSV file with a header and one row per
+// kernel, with the columns of CSVColumns, e.g., for pandas.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(CSVColumns)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range r.Results {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+		}
+
+		err = cw.Write([]string{
+			res.Kernel, status, string(res.Reason),
+			strconv.Itoa(res.Width), strconv.Itoa(res.Height),
+			strconv.Itoa(res.PEs), strconv.Itoa(res.II),
+			strconv.FormatUint(res.Cycles, 10),
+			strconv.FormatUint(res.InstCycles, 10),
+			strconv.FormatUint(res.IdleCycles, 10),
+			strconv.FormatFloat(res.Utilization(), 'f', 4, 64),
+			strconv.Itoa(len(res.Mismatches)),
+			strconv.FormatFloat(res.Elapsed.Seconds(), 'f', 6, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
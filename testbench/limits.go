@@ -0,0 +1,105 @@
+package testbench
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sarchlab/akita/v3/sim"
+)
+
+// Limits bound how long a kernel can run before it times out.
+type Limits struct {
+	// MaxCycles is the number of simulated cycles.
+	MaxCycles uint64
+
+	// Timeout is the wall-clock time of the simulation.
+	Timeout time.Duration
+}
+
+// DefaultLimits are the limits of Run and RunAll.
+var DefaultLimits = Limits{
+	MaxCycles: 1000000,
+	Timeout:   time.Minute,
+}
+
+// of returns the limits with the ones that the manifest sets.
+func (l Limits) of(m Manifest) Limits {
+	if m.MaxCycles > 0 {
+		l.MaxCycles = m.MaxCycles
+	}
+
+	if m.Timeout > 0 {
+		l.Timeout = m.Timeout
+	}
+
+	return l
+}
+
+// clockCheckInterval is the number of events between two reads of the
+// wall clock.
+const clockCheckInterval = 1024
+
+// limitHook stops the engine before it handles an event after the max
+// cycles or after the timeout.
+type limitHook struct {
+	freq   sim.Freq
+	limits Limits
+	start  time.Time
+	events int
+}
+
+var (
+	errCycleLimit = errors.New("cycle limit")
+	errTimeout    = errors.New("timeout")
+)
+
+func (h *limitHook) Func(ctx sim.HookCtx) {
+	if ctx.Pos != sim.HookPosBeforeEvent {
+		return
+	}
+
+	if h.freq.Cycle(ctx.Item.(sim.Event).Time()) > h.limits.MaxCycles {
+		panic(errCycleLimit)
+	}
+
+	h.events++
+	if h.events%clockCheckInterval == 0 &&
+		time.Since(h.start) > h.limits.Timeout {
+		panic(errTimeout)
+	}
+}
+
+// run runs the simulation until it ends or reaches a limit.
+func (b *bench) run(l Limits) (err error) {
+	b.engine.AcceptHook(&limitHook{freq: b.freq, limits: l, start: time.Now()})
+
+	defer func() {
+		p := recover()
+		switch {
+		case p == nil:
+		case p == errCycleLimit:
+			err = fail(ReasonTimeout, "the kernel did not finish in %d cycles",
+				l.MaxCycles)
+		case p == errTimeout:
+			err = fail(ReasonTimeout,
+				"the kernel did not finish in %v, after %d cycles",
+				l.Timeout, b.cycles())
+		default:
+			err = fail(ReasonRuntimeError, "%v", p)
+		}
+	}()
+
+	b.driver.Run()
+
+	if errs := b.driver.GetErrors(); len(errs) > 0 {
+		details := make([]string, 0, len(errs))
+		for _, e := range errs {
+			details = append(details, e.Error())
+		}
+
+		return fail(ReasonRuntimeError, "%s", strings.Join(details, "\n"))
+	}
+
+	return nil
+}
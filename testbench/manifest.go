@@ -0,0 +1,96 @@
+package testbench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the manifest in each kernel folder.
+const ManifestFile = "manifest.yaml"
+
+// Manifest describes how to run a kernel and what it must produce. The paths
+// are relative to the kernel folder. A manifest looks like
+//
+//	programs: kernel.yaml
+//	arch: arch_spec.yaml
+//	constants: {N: 4}
+//	args: [3]
+//	max_cycles: 10000
+//	timeout: 30s
+//	feed_in:
+//	  - {side: west, ports: [0, 1], stride: 1, data: [1, 2, 3, 4]}
+//	expect:
+//	  - {side: east, ports: [0, 1], stride: 1, data: [2, 4, 6, 8]}
+//	return_values:
+//	  - {pe: [1, 0], value: 10}
+//
+// Without an arch spec, the device is a mesh that is just large enough for
+// the programs. The constants override the named constants of the program
+// file, and the args are the kernel arguments that ARG0, ARG1, ... read.
+// The max cycles and the timeout, in wall-clock time, override the limits
+// of the runner for the kernel.
+type Manifest struct {
+	Programs     string            `yaml:"programs"`
+	Arch         string            `yaml:"arch"`
+	Constants    map[string]string `yaml:"constants"`
+	Args         []uint32          `yaml:"args"`
+	MaxCycles    uint64            `yaml:"max_cycles"`
+	Timeout      time.Duration     `yaml:"timeout"`
+	FeedIn       []Stream          `yaml:"feed_in"`
+	Expect       []Stream          `yaml:"expect"`
+	ReturnValues []ReturnValue     `yaml:"return_values"`
+}
+
+// Stream is the data that the driver feeds to or expects from the ports of a
+// side of the device.
+type Stream struct {
+	Side   string   `yaml:"side"`
+	Ports  [2]int   `yaml:"ports"`
+	Stride int      `yaml:"stride"`
+	Data   []uint32 `yaml:"data"`
+}
+
+// ReturnValue is the value that a PE must record with RETURN_VALUE.
+type ReturnValue struct {
+	PE    [2]int `yaml:"pe"`
+	Value uint32 `yaml:"value"`
+}
+
+// LoadManifest loads the manifest of a kernel folder and makes its paths
+// relative to the working directory.
+func LoadManifest(dir string) (Manifest, error) {
+	m := Manifest{}
+	path := filepath.Join(dir, ManifestFile)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+
+	err = yaml.Unmarshal(data, &m)
+	if err != nil {
+		return m, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	if m.Programs == "" {
+		return m, fmt.Errorf("%s: programs is not set", path)
+	}
+
+	m.Programs = filepath.Join(dir, m.Programs)
+	if m.Arch != "" {
+		m.Arch = filepath.Join(dir, m.Arch)
+	}
+
+	for _, s := range append(m.FeedIn, m.Expect...) {
+		if s.Stride <= 0 {
+			return m, fmt.Errorf("%s: the stride of the %s side must be positive",
+				path, s.Side)
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,99 @@
+package testbench
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Report is the results of a run of the testbench, in the order of the
+// kernel names.
+type Report struct {
+	Results []Result
+}
+
+// Passed returns the number of kernels that pass.
+func (r Report) Passed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Passed() {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Reasons returns the number of kernels that fail for each reason.
+func (r Report) Reasons() map[Reason]int {
+	counts := make(map[Reason]int)
+	for _, res := range r.Results {
+		if !res.Passed() {
+			counts[res.Reason]++
+		}
+	}
+
+	return counts
+}
+
+// WriteMatrix writes a table with a row for each kernel, followed by the
+// number of kernels that pass and the number that fail for each reason. The
+// details are written on one line.
+func (r Report) WriteMatrix(w io.Writer) error {
+	width := len("Kernel")
+	for _, res := range r.Results {
+		if len(res.Kernel) > width {
+			width = len(res.Kernel)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%-*s %-6s %-17s %10s  %s\n",
+		width, "Kernel", "Result", "Reason", "Cycles", "Detail")
+	if err != nil {
+		return err
+	}
+
+	for _, res := range r.Results {
+		status, reason := "PASS", "-"
+		if !res.Passed() {
+			status, reason = "FAIL", string(res.Reason)
+		}
+
+		line := fmt.Sprintf("%-*s %-6s %-17s %10d  %s",
+			width, res.Kernel, status, reason, res.Cycles,
+			strings.Join(strings.Fields(res.Detail), " "))
+
+		_, err = fmt.Fprintln(w, strings.TrimRight(line, " "))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "\n%d/%d passed\n", r.Passed(), len(r.Results))
+	if err != nil {
+		return err
+	}
+
+	return r.writeReasons(w)
+}
+
+func (r Report) writeReasons(w io.Writer) error {
+	counts := r.Reasons()
+
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, string(reason))
+	}
+
+	sort.Strings(reasons)
+
+	for _, reason := range reasons {
+		_, err := fmt.Fprintf(w, "%-17s %d\n", reason, counts[Reason(reason)])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
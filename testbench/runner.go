@@ -0,0 +1,585 @@
+// Package testbench runs a directory of kernels and reports which of them
+// pass. Each kernel is a folder with a manifest that names the program file
+// and lists the inputs and the expected outputs, so that a set of kernels
+// from a compiler can be triaged in one run.
+package testbench
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/trace"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+// Reason is the code of the reason why a kernel fails.
+type Reason string
+
+const (
+	// ReasonCompileLoadFail means that the manifest, the program file, or
+	// the arch spec cannot be loaded, e.g., because the compiler emitted a
+	// program with a syntax error.
+	ReasonCompileLoadFail Reason = "COMPILE_LOAD_FAIL"
+
+	// ReasonLintFail means that the linter finds the programs do not fit the
+	// architecture, e.g., a program that exceeds the control memory.
+	ReasonLintFail Reason = "LINT_FAIL"
+
+	// ReasonMissingOp means that a program uses an opcode that the simulator
+	// or the PE does not support.
+	ReasonMissingOp Reason = "MISSING_OP"
+
+	// ReasonRoutingFail means that a program is placed outside the device or
+	// on a disabled tile, or uses a port that is not connected.
+	ReasonRoutingFail Reason = "ROUTING_FAIL"
+
+	// ReasonDeadlock means that the simulation stopped before the kernel
+	// produced all its outputs, as all the PEs wait for data that never
+	// arrives.
+	ReasonDeadlock Reason = "DEADLOCK"
+
+	// ReasonTimeout means that the kernel was still running when it reached
+	// its limit of simulated cycles or of wall-clock time.
+	ReasonTimeout Reason = "TIMEOUT"
+
+	// ReasonMismatch means that the outputs differ from the expected ones.
+	ReasonMismatch Reason = "MISMATCH"
+
+	// ReasonRuntimeError means that a PE failed to run an instruction, e.g.,
+	// a memory access out of range, or that the simulation panicked.
+	ReasonRuntimeError Reason = "RUNTIME_ERROR"
+)
+
+// Result is the outcome of running a kernel.
+type Result struct {
+	// Kernel is the name of the kernel folder.
+	Kernel string
+
+	// Reason is empty if the kernel passes.
+	Reason Reason
+
+	// Detail explains the failure.
+	Detail string
+
+	// Cycles is the number of simulated cycles, or 0 if the simulation did
+	// not start.
+	Cycles uint64
+
+	// Elapsed is the wall-clock time of the run.
+	Elapsed time.Duration
+
+	// Mismatches are the outputs that differ from the expected values.
+	Mismatches []Mismatch
+
+	// Width and Height are the size of the device, and PEs is the number
+	// of PEs that run a program. They are 0 if the kernel cannot be set up.
+	Width, Height int
+	PEs           int
+
+	// II is the initiation interval that verify.AnalyzeII estimates from
+	// the programs, or 0 if the programs are not analyzed.
+	II int
+
+	// InstCycles and IdleCycles are the sums over the PEs of the device of
+	// the cycles in which a PE executed an instruction or did nothing, and
+	// PECycles is the sum of the cycles that have passed for each PE.
+	InstCycles, IdleCycles, PECycles uint64
+}
+
+// Utilization is the fraction of the cycles of the PEs of the device in
+// which a PE executed an instruction, or 0 if no cycle has passed.
+func (r Result) Utilization() float64 {
+	if r.PECycles == 0 {
+		return 0
+	}
+
+	return float64(r.InstCycles) / float64(r.PECycles)
+}
+
+// Passed returns true if the kernel produced the expected outputs.
+func (r Result) Passed() bool {
+	return r.Reason == ""
+}
+
+// failure is an error that carries the reason code.
+type failure struct {
+	reason Reason
+	detail string
+}
+
+func (f *failure) Error() string {
+	return fmt.Sprintf("%s: %s", f.reason, f.detail)
+}
+
+func fail(reason Reason, format string, args ...interface{}) *failure {
+	return &failure{reason: reason, detail: fmt.Sprintf(format, args...)}
+}
+
+// Discover returns the folders directly under dir that have a manifest,
+// sorted by name.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		_, err := os.Stat(filepath.Join(path, ManifestFile))
+		if err == nil {
+			dirs = append(dirs, path)
+		}
+	}
+
+	return dirs, nil
+}
+
+// RunAll runs all the kernels that Discover finds under dir with the
+// default limits.
+func RunAll(dir string) (Report, error) {
+	return RunAllWithLimits(dir, DefaultLimits)
+}
+
+// RunAllWithLimits is the same as RunAll, but with the limits of the
+// kernels whose manifests do not set them.
+func RunAllWithLimits(dir string, l Limits) (Report, error) {
+	dirs, err := Discover(dir)
+	if err != nil {
+		return Report{}, err
+	}
+
+	r := Report{}
+	for _, d := range dirs {
+		r.Results = append(r.Results, RunWithLimits(d, l))
+	}
+
+	return r, nil
+}
+
+// Run runs the kernel in a folder with the default limits and checks its
+// outputs.
+func Run(dir string) Result {
+	return RunWithLimits(dir, DefaultLimits)
+}
+
+// RunWithLimits is the same as Run, but with the limits that apply if the
+// manifest does not set them.
+func RunWithLimits(dir string, l Limits) Result {
+	r := Result{Kernel: filepath.Base(dir)}
+	start := time.Now()
+
+	b, err := setUp(dir)
+	if b != nil {
+		r.Width, r.Height = b.device.GetSize()
+		r.PEs, r.II = b.pes, b.ii
+	}
+
+	if err == nil {
+		err = b.run(l.of(b.manifest))
+		r.Cycles = b.cycles()
+		b.addActivity(&r)
+	}
+
+	if err == nil {
+		err = b.check()
+		r.Mismatches = b.mismatches
+	}
+
+	r.Elapsed = time.Since(start)
+
+	var f *failure
+	if errors.As(err, &f) {
+		r.Reason = f.reason
+		r.Detail = f.detail
+	}
+
+	return r
+}
+
+// bench is a kernel that is set up to run.
+type bench struct {
+	manifest Manifest
+	engine   sim.Engine
+	freq     sim.Freq
+	driver   api.Driver
+	device   cgra.Device
+
+	constants map[string]uint32
+
+	outputs    [][]uint32
+	finished   []bool
+	mismatches []Mismatch
+
+	// pes is the number of programs and ii is their initiation interval.
+	pes int
+	ii  int
+}
+
+func setUp(dir string) (*bench, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, fail(ReasonCompileLoadFail, "%v", err)
+	}
+
+	file, err := core.LoadProgramFile(m.Programs)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	builder, arch, err := deviceBuilder(m.Arch, file.Programs)
+	if err != nil {
+		return nil, fail(ReasonCompileLoadFail, "%v", err)
+	}
+
+	b := &bench{
+		manifest:  m,
+		engine:    sim.NewSerialEngine(),
+		freq:      1 * sim.GHz,
+		constants: make(map[string]uint32),
+	}
+	b.driver = api.DriverBuilder{}.
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		Build("Driver")
+	b.device = builder.
+		WithEngine(b.engine).
+		WithFreq(b.freq).
+		WithTracer(trace.Discard).
+		Build("Device")
+	b.driver.RegisterDevice(b.device)
+
+	err = b.setKernelParams(file.Constants)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.mapPrograms(file.Programs, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, b.setUpIO()
+}
+
+// deviceBuilder loads the arch spec. If the path is empty, the device is a
+// mesh that is just large enough for the programs.
+func deviceBuilder(
+	path string,
+	programs map[[2]int]string,
+) (config.DeviceBuilder, verify.ArchInfo, error) {
+	if path != "" {
+		return config.LoadArchSpec(path)
+	}
+
+	spec := config.ArchSpec{Topology: config.MeshInterconnect}
+	for coord := range programs {
+		if coord[0]+1 > spec.Columns {
+			spec.Columns = coord[0] + 1
+		}
+
+		if coord[1]+1 > spec.Rows {
+			spec.Rows = coord[1] + 1
+		}
+	}
+
+	return spec.DeviceBuilder(), spec.ArchInfo(), nil
+}
+
+func (b *bench) setKernelParams(constants map[string]uint32) error {
+	for name, v := range constants {
+		b.constants[name] = v
+	}
+
+	for name, value := range b.manifest.Constants {
+		v, err := core.ParseConstant(value)
+		if err != nil {
+			return fail(ReasonCompileLoadFail, "constant %s: %v", name, err)
+		}
+
+		b.constants[name] = v
+	}
+
+	for name, v := range b.constants {
+		b.driver.SetProgramConstant(name, v)
+	}
+
+	if len(b.manifest.Args) > core.NumKernelArgs {
+		return fail(ReasonCompileLoadFail, "%d args, at most %d are supported",
+			len(b.manifest.Args), core.NumKernelArgs)
+	}
+
+	for i, v := range b.manifest.Args {
+		b.driver.SetKernelArg(i, v)
+	}
+
+	return nil
+}
+
+// mapPrograms checks and lints all the programs before it maps any of them,
+// so that a kernel that cannot run fails with the reason rather than a
+// panic.
+func (b *bench) mapPrograms(
+	programs map[[2]int]string,
+	arch verify.ArchInfo,
+) error {
+	coords := make([][2]int, 0, len(programs))
+	for coord := range programs {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	width, height := b.device.GetSize()
+	for _, c := range coords {
+		if c[0] >= width || c[1] >= height {
+			return fail(ReasonRoutingFail, "PE(%d, %d) is outside the %dx%d device",
+				c[0], c[1], width, height)
+		}
+
+		err := b.driver.CheckProgram(programs[c], c)
+		if err != nil {
+			return classify(err)
+		}
+	}
+
+	err := b.lint(programs, arch)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range coords {
+		b.driver.MapProgram(programs[c], c)
+	}
+
+	return nil
+}
+
+// classify finds the reason of an error in loading or checking the
+// programs. Opcodes take precedence over ports, which take precedence over
+// the other mistakes.
+func classify(err error) *failure {
+	var errs core.ProgramErrors
+	if !errors.As(err, &errs) {
+		if strings.Contains(err.Error(), "disabled") {
+			return fail(ReasonRoutingFail, "%v", err)
+		}
+
+		return fail(ReasonCompileLoadFail, "%v", err)
+	}
+
+	reason := ReasonCompileLoadFail
+	for _, e := range errs {
+		switch {
+		case e.Msg == "unknown opcode" || e.Msg == "opcode not supported by the PE":
+			return fail(ReasonMissingOp, "%v", e)
+		case strings.HasPrefix(e.Token, "NET_"):
+			reason = ReasonRoutingFail
+		}
+	}
+
+	return fail(reason, "%v", err)
+}
+
+// lint fails with the first issue that the linter finds in the programs
+// with the constants resolved.
+func (b *bench) lint(programs map[[2]int]string, arch verify.ArchInfo) error {
+	resolved := make(map[[2]int]string, len(programs))
+	for c, p := range programs {
+		resolved[c] = core.ResolveConstants(p, b.constants)
+	}
+
+	b.pes = len(resolved)
+	b.ii = verify.AnalyzeII(resolved, arch).II
+
+	issues := verify.Lint(resolved, arch)
+	switch n := len(issues); {
+	case n == 1:
+		return fail(ReasonLintFail, "%v", issues[0])
+	case n > 1:
+		return fail(ReasonLintFail, "%v, and %d more issues", issues[0], n-1)
+	}
+
+	return nil
+}
+
+func (b *bench) setUpIO() error {
+	for _, in := range b.manifest.FeedIn {
+		side, err := parseSide(in.Side)
+		if err != nil {
+			return err
+		}
+
+		b.driver.FeedIn(in.Data, side, in.Ports, in.Stride)
+	}
+
+	b.finished = make([]bool, len(b.manifest.Expect))
+	for i, out := range b.manifest.Expect {
+		side, err := parseSide(out.Side)
+		if err != nil {
+			return err
+		}
+
+		i := i
+		data := make([]uint32, len(out.Data))
+		b.outputs = append(b.outputs, data)
+		b.driver.Chain(api.CollectTask{
+			Data:      data,
+			Side:      side,
+			PortRange: out.Ports,
+			Stride:    out.Stride,
+		}, func([]uint32) (*api.FeedInTask, bool) {
+			b.finished[i] = true
+			return nil, false
+		})
+	}
+
+	return nil
+}
+
+func parseSide(name string) (cgra.Side, error) {
+	for side := cgra.North; side <= cgra.West; side++ {
+		if strings.EqualFold(name, side.Name()) {
+			return side, nil
+		}
+	}
+
+	return 0, fail(ReasonCompileLoadFail, "invalid side %q", name)
+}
+
+func (b *bench) cycles() uint64 {
+	return b.freq.Cycle(b.engine.CurrentTime())
+}
+
+// addActivity sums the activity of the PEs into the result.
+func (b *bench) addActivity(r *Result) {
+	for _, s := range b.driver.GetActivityStats() {
+		r.InstCycles += s.InstCycles
+		r.IdleCycles += s.IdleCycles
+		r.PECycles += s.Cycles
+	}
+}
+
+// Mismatch is an output that differs from the expected value.
+type Mismatch struct {
+	// Output is the side of an expected stream, e.g., east, or the PE of an
+	// expected return value, e.g., PE(1, 0).
+	Output string
+
+	// Index is the index of the value in the stream, or -1 for a return
+	// value.
+	Index int
+
+	Got, Want uint32
+}
+
+func (m Mismatch) String() string {
+	if m.Index < 0 {
+		return fmt.Sprintf("%s returns %d, want %d", m.Output, m.Got, m.Want)
+	}
+
+	return fmt.Sprintf("%s side, value %d is %d, want %d",
+		m.Output, m.Index, m.Got, m.Want)
+}
+
+// check compares the outputs and the return values with the manifest, and
+// records all the values that differ.
+func (b *bench) check() error {
+	err := b.checkFinished()
+	if err != nil {
+		return err
+	}
+
+	for i, out := range b.manifest.Expect {
+		for j, want := range out.Data {
+			if b.outputs[i][j] != want {
+				b.mismatches = append(b.mismatches,
+					Mismatch{Output: out.Side, Index: j, Got: b.outputs[i][j], Want: want})
+			}
+		}
+	}
+
+	got := b.driver.GetReturnValues()
+	for _, rv := range b.manifest.ReturnValues {
+		if got[rv.PE] != rv.Value {
+			b.mismatches = append(b.mismatches, Mismatch{
+				Output: fmt.Sprintf("PE(%d, %d)", rv.PE[0], rv.PE[1]),
+				Index:  -1,
+				Got:    got[rv.PE],
+				Want:   rv.Value,
+			})
+		}
+	}
+
+	switch n := len(b.mismatches); {
+	case n == 1:
+		return fail(ReasonMismatch, "%v", b.mismatches[0])
+	case n > 1:
+		return fail(ReasonMismatch, "%v, and %d more values differ",
+			b.mismatches[0], n-1)
+	}
+
+	return nil
+}
+
+// checkFinished checks that the kernel produced all the outputs before the
+// simulation stopped.
+func (b *bench) checkFinished() error {
+	for i, out := range b.manifest.Expect {
+		if !b.finished[i] {
+			return fail(ReasonDeadlock,
+				"the simulation stopped before the %s side produced all the data%s",
+				out.Side, b.waitingPEs())
+		}
+	}
+
+	got := b.driver.GetReturnValues()
+	for _, rv := range b.manifest.ReturnValues {
+		if _, ok := got[rv.PE]; !ok {
+			return fail(ReasonDeadlock,
+				"the simulation stopped before PE(%d, %d) returned a value%s",
+				rv.PE[0], rv.PE[1], b.waitingPEs())
+		}
+	}
+
+	return nil
+}
+
+// waitingPEs lists the PEs that have not finished their programs, with the
+// instructions that they wait at.
+func (b *bench) waitingPEs() string {
+	waiting := []string{}
+	for _, c := range b.device.GetTileCoords() {
+		p := b.device.DescribeTile(c[0], c[1]).Program
+		if p.Instructions > 0 && !p.Done {
+			waiting = append(waiting,
+				fmt.Sprintf("PE(%d, %d) at %q", c[0], c[1], p.Current))
+		}
+	}
+
+	if len(waiting) == 0 {
+		return ""
+	}
+
+	return ", waiting: " + strings.Join(waiting, ", ")
+}
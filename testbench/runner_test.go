@@ -0,0 +1,324 @@
+package testbench_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/testbench"
+)
+
+const passthrough = `
+- x: 0
+  y: 0
+  program: |
+    START:
+    WAIT, $0, NET_RECV_3
+    SEND, NET_SEND_1, $0
+    JMP, START
+`
+
+const passthroughManifest = `
+programs: kernel.yaml
+feed_in:
+  - {side: west, ports: [0, 1], stride: 1, data: [1, 2, 3]}
+expect:
+  - {side: east, ports: [0, 1], stride: 1, data: [%s]}
+`
+
+// writeKernel writes the files of a kernel folder under root.
+func writeKernel(root, name string, files map[string]string) {
+	dir := filepath.Join(root, name)
+	Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+
+	for file, content := range files {
+		Expect(os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644)).
+			To(Succeed())
+	}
+}
+
+func manifest(expect string) string {
+	return fmt.Sprintf(passthroughManifest, expect)
+}
+
+var _ = Describe("Runner", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("should discover the kernel folders", func() {
+		writeKernel(root, "b", map[string]string{"manifest.yaml": ""})
+		writeKernel(root, "a", map[string]string{"manifest.yaml": ""})
+		writeKernel(root, "notes", map[string]string{"README": ""})
+
+		dirs, err := testbench.Discover(root)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dirs).To(Equal([]string{
+			filepath.Join(root, "a"), filepath.Join(root, "b"),
+		}))
+	})
+
+	It("should pass a kernel with the expected outputs", func() {
+		writeKernel(root, "pass", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3"),
+			"kernel.yaml":   passthrough,
+		})
+
+		r := testbench.Run(filepath.Join(root, "pass"))
+
+		Expect(r.Passed()).To(BeTrue(), r.Detail)
+		Expect(r.Kernel).To(Equal("pass"))
+		Expect(r.Cycles).To(BeNumerically(">", 0))
+	})
+
+	It("should check the return values", func() {
+		writeKernel(root, "ret", map[string]string{
+			"manifest.yaml": "programs: kernel.yaml\n" +
+				"return_values: [{pe: [0, 0], value: 7}]\n",
+			"kernel.yaml": "- x: 0\n  y: 0\n  program: |\n" +
+				"    RETURN_VALUE, 6\n    DONE\n",
+		})
+
+		r := testbench.Run(filepath.Join(root, "ret"))
+
+		Expect(r.Reason).To(Equal(testbench.ReasonMismatch))
+		Expect(r.Detail).To(Equal("PE(0, 0) returns 6, want 7"))
+	})
+
+	It("should classify the failures", func() {
+		writeKernel(root, "mismatch", map[string]string{
+			"manifest.yaml": manifest("1, 2, 4"),
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "missing-op", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3"),
+			"kernel.yaml":   "- x: 0\n  y: 0\n  program: |\n    F32_DIV, $0, $1, $2\n",
+		})
+		writeKernel(root, "routing", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3") + "arch: arch.yaml\n",
+			"arch.yaml":     "rows: 1\ncolumns: 2\ndisabled_tiles: [[0, 0]]\n",
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "stall", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3, 4"),
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "spin", map[string]string{
+			"manifest.yaml": "programs: kernel.yaml\nmax_cycles: 50\n",
+			"kernel.yaml": "- x: 0\n  y: 0\n  program: |\n" +
+				"    START:\n    JMP, START\n",
+		})
+		writeKernel(root, "too-long", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3") + "arch: arch.yaml\n",
+			"arch.yaml":     "rows: 1\ncolumns: 1\nctrl_mem_items: 2\n",
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "fault", map[string]string{
+			"manifest.yaml": "programs: kernel.yaml\n",
+			"kernel.yaml": "- x: 0\n  y: 0\n  program: |\n" +
+				"    GATHER, $0, 1000000\n    DONE\n",
+		})
+		writeKernel(root, "no-programs", map[string]string{
+			"manifest.yaml": "feed_in: []\n",
+		})
+
+		report, err := testbench.RunAll(root)
+		Expect(err).NotTo(HaveOccurred())
+
+		reasons := map[string]testbench.Reason{}
+		for _, r := range report.Results {
+			reasons[r.Kernel] = r.Reason
+		}
+
+		Expect(reasons).To(Equal(map[string]testbench.Reason{
+			"fault":       testbench.ReasonRuntimeError,
+			"mismatch":    testbench.ReasonMismatch,
+			"missing-op":  testbench.ReasonMissingOp,
+			"no-programs": testbench.ReasonCompileLoadFail,
+			"routing":     testbench.ReasonRoutingFail,
+			"spin":        testbench.ReasonTimeout,
+			"stall":       testbench.ReasonDeadlock,
+			"too-long":    testbench.ReasonLintFail,
+		}))
+		Expect(report.Passed()).To(Equal(0))
+		Expect(report.Reasons()[testbench.ReasonTimeout]).To(Equal(1))
+		Expect(report.Results[0].Detail).To(ContainSubstring(
+			`tile (0, 0) line 0: "GATHER, $0, 1000000": address 1000000`))
+		Expect(report.Results[6].Detail).To(Equal(
+			"the simulation stopped before the east side produced all the " +
+				`data, waiting: PE(0, 0) at "WAIT, $0, NET_RECV_3"`))
+	})
+
+	It("should write the pass/fail matrix", func() {
+		writeKernel(root, "pass", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3"),
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "mismatch", map[string]string{
+			"manifest.yaml": manifest("1, 2, 4"),
+			"kernel.yaml":   passthrough,
+		})
+
+		report, err := testbench.RunAll(root)
+		Expect(err).NotTo(HaveOccurred())
+
+		buf := bytes.Buffer{}
+		Expect(report.WriteMatrix(&buf)).To(Succeed())
+
+		Expect(buf.String()).To(ContainSubstring(
+			"mismatch FAIL   MISMATCH"))
+		Expect(buf.String()).To(ContainSubstring(
+			"east side, value 2 is 3, want 4"))
+		Expect(buf.String()).To(ContainSubstring("pass     PASS   -"))
+		Expect(buf.String()).To(ContainSubstring("1/2 passed\nMISMATCH          1\n"))
+	})
+})
+
+var _ = Describe("Limits", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		writeKernel(root, "spin", map[string]string{
+			"manifest.yaml": "programs: kernel.yaml\n",
+			"kernel.yaml": "- x: 0\n  y: 0\n  program: |\n" +
+				"    START:\n    JMP, START\n",
+		})
+	})
+
+	It("should stop a kernel at the max cycles of the runner", func() {
+		r := testbench.RunWithLimits(filepath.Join(root, "spin"),
+			testbench.Limits{MaxCycles: 100, Timeout: time.Minute})
+
+		Expect(r.Reason).To(Equal(testbench.ReasonTimeout))
+		Expect(r.Detail).To(Equal("the kernel did not finish in 100 cycles"))
+		Expect(r.Cycles).To(BeNumerically("~", 100, 2))
+	})
+
+	It("should stop a kernel at the timeout of its manifest", func() {
+		writeKernel(root, "spin", map[string]string{
+			"manifest.yaml": "programs: kernel.yaml\ntimeout: 1ns\n",
+		})
+
+		r := testbench.RunWithLimits(filepath.Join(root, "spin"),
+			testbench.Limits{MaxCycles: 1 << 40, Timeout: time.Minute})
+
+		Expect(r.Reason).To(Equal(testbench.ReasonTimeout))
+		Expect(r.Detail).To(HavePrefix("the kernel did not finish in 1ns, after"))
+	})
+})
+
+var _ = Describe("Formats", func() {
+	var report testbench.Report
+
+	BeforeEach(func() {
+		root := GinkgoT().TempDir()
+		writeKernel(root, "pass", map[string]string{
+			"manifest.yaml": manifest("1, 2, 3"),
+			"kernel.yaml":   passthrough,
+		})
+		writeKernel(root, "mismatch", map[string]string{
+			"manifest.yaml": manifest("1, 5, 4"),
+			"kernel.yaml":   passthrough,
+		})
+
+		var err error
+		report, err = testbench.RunAll(root)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should write JUnit XML", func() {
+		buf := bytes.Buffer{}
+		Expect(report.WriteJUnit(&buf)).To(Succeed())
+
+		suites := struct {
+			Suites []struct {
+				Tests    int `xml:"tests,attr"`
+				Failures int `xml:"failures,attr"`
+				Cases    []struct {
+					Name       string `xml:"name,attr"`
+					Properties []struct {
+						Name  string `xml:"name,attr"`
+						Value string `xml:"value,attr"`
+					} `xml:"properties>property"`
+					Failure *struct {
+						Type string `xml:"type,attr"`
+						Body string `xml:",chardata"`
+					} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}{}
+		Expect(xml.Unmarshal(buf.Bytes(), &suites)).To(Succeed())
+
+		suite := suites.Suites[0]
+		Expect(suite.Tests).To(Equal(2))
+		Expect(suite.Failures).To(Equal(1))
+		Expect(suite.Cases[0].Name).To(Equal("mismatch"))
+		Expect(suite.Cases[0].Failure.Type).To(Equal("MISMATCH"))
+		Expect(suite.Cases[0].Failure.Body).To(Equal(
+			"east side, value 1 is 2, want 5\neast side, value 2 is 3, want 4"))
+		Expect(suite.Cases[1].Failure).To(BeNil())
+		Expect(suite.Cases[1].Properties[0].Name).To(Equal("cycles"))
+		Expect(suite.Cases[1].Properties[0].Value).
+			To(Equal(fmt.Sprint(report.Results[1].Cycles)))
+	})
+
+	It("should write TAP", func() {
+		buf := bytes.Buffer{}
+		Expect(report.WriteTAP(&buf)).To(Succeed())
+
+		Expect(buf.String()).To(Equal(fmt.Sprintf(`TAP version 13
+1..2
+not ok 1 - mismatch
+  ---
+  reason: MISMATCH
+  message: east side, value 1 is 2, want 5, and 1 more values differ
+  cycles: %d
+  mismatches:
+    - output: east
+      index: 1
+      got: 2
+      want: 5
+    - output: east
+      index: 2
+      got: 3
+      want: 4
+  ...
+ok 2 - pass
+  ---
+  cycles: %d
+  ...
+`, report.Results[0].Cycles, report.Results[1].Cycles)))
+	})
+
+	It("should write a CSV row per kernel", func() {
+		buf := bytes.Buffer{}
+		Expect(report.WriteCSV(&buf)).To(Succeed())
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rows).To(HaveLen(3))
+		Expect(rows[0]).To(Equal(testbench.CSVColumns))
+
+		pass := report.Results[1]
+		Expect(pass.PEs).To(BeNumerically(">", 0))
+		Expect(pass.InstCycles).To(BeNumerically(">", 0))
+		Expect(rows[1][:3]).To(Equal([]string{"mismatch", "FAIL", "MISMATCH"}))
+		Expect(rows[1][11]).To(Equal("2"))
+		Expect(rows[2][:8]).To(Equal([]string{
+			"pass", "PASS", "",
+			fmt.Sprint(pass.Width), fmt.Sprint(pass.Height),
+			fmt.Sprint(pass.PEs), fmt.Sprint(pass.II), fmt.Sprint(pass.Cycles),
+		}))
+		Expect(rows[2][10]).To(Equal(fmt.Sprintf("%.4f", pass.Utilization())))
+	})
+})
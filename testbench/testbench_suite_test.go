@@ -0,0 +1,13 @@
+package testbench_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTestbench(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Testbench Suite")
+}
@@ -0,0 +1,83 @@
+package trace
+
+// Divergence is the first instruction that two runs of a kernel execute
+// differently, either in a different cycle or not at all.
+type Divergence struct {
+	Component string
+
+	// Index is the position of the instruction among the instructions that
+	// the component executes.
+	Index int
+
+	// A and B are the instruction in the two runs. The Inst of an event is
+	// empty if the run did not execute the instruction.
+	A, B Event
+}
+
+// Time returns the earliest time of the instruction in the two runs.
+func (d Divergence) Time() float64 {
+	switch {
+	case d.A.Inst == "":
+		return d.B.Time
+	case d.B.Inst == "" || d.A.Time < d.B.Time:
+		return d.A.Time
+	default:
+		return d.B.Time
+	}
+}
+
+// Trace appends an event to the log, so that a Log records a simulation in
+// memory.
+func (l *Log) Trace(e Event) {
+	l.Events = append(l.Events, e)
+}
+
+// FirstDivergence compares the instructions that each component executes in
+// two runs, one by one, and returns the earliest one that differs in the
+// text or in the time. The second return value is false if the runs execute
+// the same instructions at the same times.
+func FirstDivergence(a, b *Log) (Divergence, bool) {
+	first := Divergence{}
+	found := false
+
+	seen := make(map[string]bool)
+	for _, c := range append(a.Components(), b.Components()...) {
+		if seen[c] {
+			continue
+		}
+
+		seen[c] = true
+
+		d, ok := divergenceOf(c, a.instructions(c), b.instructions(c))
+		if ok && (!found || d.Time() < first.Time()) {
+			first, found = d, true
+		}
+	}
+
+	return first, found
+}
+
+func divergenceOf(component string, a, b []Event) (Divergence, bool) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		d := Divergence{Component: component, Index: i}
+		if i < len(a) {
+			d.A = a[i]
+		}
+
+		if i < len(b) {
+			d.B = b[i]
+		}
+
+		if d.A.Inst != d.B.Inst || d.A.Time != d.B.Time {
+			return d, true
+		}
+	}
+
+	return Divergence{}, false
+}
+
+func (l *Log) instructions(component string) []Event {
+	return l.filter(func(e Event) bool {
+		return e.Kind == KindInst && e.Component == component
+	})
+}
@@ -0,0 +1,95 @@
+package trace
+
+import "fmt"
+
+// Level selects the kinds of events to trace.
+type Level int
+
+const (
+	// LevelAll traces all the events. It is the default.
+	LevelAll Level = iota
+	// LevelMessage traces the instructions and the messages, but not the
+	// register writes.
+	LevelMessage
+	// LevelInst traces the instructions only.
+	LevelInst
+	// LevelOff traces nothing.
+	LevelOff
+)
+
+var levelNames = map[string]Level{
+	"all":     LevelAll,
+	"message": LevelMessage,
+	"inst":    LevelInst,
+	"off":     LevelOff,
+}
+
+// ParseLevel converts the name of a level, i.e., all, message, inst, or off,
+// to the level.
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown trace level %q", name)
+	}
+
+	return level, nil
+}
+
+// Includes returns true if events of the kind are traced at the level.
+func (l Level) Includes(kind Kind) bool {
+	switch l {
+	case LevelAll:
+		return true
+	case LevelMessage:
+		return kind != KindWrite
+	case LevelInst:
+		return kind == KindInst
+	default:
+		return false
+	}
+}
+
+type discard struct{}
+
+func (discard) Trace(Event) {}
+
+// Discard is a Tracer that drops all the events.
+var Discard Tracer = discard{}
+
+type levelFilter struct {
+	tracer Tracer
+	level  Level
+}
+
+func (f levelFilter) Trace(e Event) {
+	if f.level.Includes(e.Kind) {
+		f.tracer.Trace(e)
+	}
+}
+
+// Filter returns a Tracer that passes the events that the level includes to
+// the tracer.
+func Filter(tracer Tracer, level Level) Tracer {
+	switch level {
+	case LevelAll:
+		return tracer
+	case LevelOff:
+		return Discard
+	default:
+		return levelFilter{tracer: tracer, level: level}
+	}
+}
+
+type tee []Tracer
+
+func (t tee) Trace(e Event) {
+	for _, tracer := range t {
+		tracer.Trace(e)
+	}
+}
+
+// Tee returns a tracer that passes each event to all the tracers, e.g., to
+// a file and to a Stream.
+func Tee(tracers ...Tracer) Tracer {
+	return tee(tracers)
+}
@@ -0,0 +1,146 @@
+package trace
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// streamBuffer is the number of events that a subscriber can fall behind
+// before it loses events.
+const streamBuffer = 4096
+
+// Stream is a tracer that sends the events to the websocket clients that
+// subscribe to it, e.g., a web dashboard, while the simulation runs. Each
+// event is a JSON message. A client that cannot keep up loses events
+// instead of slowing down the simulation.
+type Stream struct {
+	lock    sync.Mutex
+	subs    map[*subscriber]bool
+	closed  bool
+	dropped uint64
+	active  sync.WaitGroup
+}
+
+type subscriber struct {
+	events chan Event
+	kinds  map[Kind]bool
+}
+
+type streamEvent struct {
+	Time      float64 `json:"time"`
+	Component string  `json:"component"`
+	Kind      Kind    `json:"kind"`
+	Inst      string  `json:"inst,omitempty"`
+	Data      uint32  `json:"data"`
+	Src       string  `json:"src,omitempty"`
+	Dst       string  `json:"dst,omitempty"`
+	Reg       string  `json:"reg,omitempty"`
+	Invalid   bool    `json:"invalid,omitempty"`
+}
+
+// NewStream creates a stream without subscribers.
+func NewStream() *Stream {
+	return &Stream{subs: make(map[*subscriber]bool)}
+}
+
+// Trace sends the event to each subscriber that wants its kind.
+func (s *Stream) Trace(e Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for sub := range s.subs {
+		if sub.kinds != nil && !sub.kinds[e.Kind] {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// Subscribers returns the number of clients that are connected, e.g., to
+// wait for a dashboard before the simulation starts.
+func (s *Stream) Subscribers() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.subs)
+}
+
+// Dropped returns the number of events that the subscribers have lost.
+func (s *Stream) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.dropped
+}
+
+// Close ends the connections of the subscribers after they have received
+// the events that they have not lost, and rejects new subscribers. It
+// waits for the connections to end, so that a command does not exit before
+// the events are sent.
+func (s *Stream) Close() {
+	s.lock.Lock()
+	s.closed = true
+
+	for sub := range s.subs {
+		close(sub.events)
+		delete(s.subs, sub)
+	}
+	s.lock.Unlock()
+
+	s.active.Wait()
+}
+
+// Handler returns the websocket endpoint of the stream. A client can only
+// subscribe to some kinds of events, e.g., with ?kinds=Inst,Send.
+func (s *Stream) Handler() http.Handler {
+	return websocket.Handler(s.serve)
+}
+
+func (s *Stream) serve(ws *websocket.Conn) {
+	sub := &subscriber{events: make(chan Event, streamBuffer)}
+
+	if kinds := ws.Request().URL.Query().Get("kinds"); kinds != "" {
+		sub.kinds = make(map[Kind]bool)
+		for _, k := range strings.Split(kinds, ",") {
+			sub.kinds[Kind(strings.TrimSpace(k))] = true
+		}
+	}
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+
+	s.subs[sub] = true
+	s.active.Add(1)
+	s.lock.Unlock()
+
+	defer s.active.Done()
+	defer s.unsubscribe(sub)
+
+	for e := range sub.events {
+		err := websocket.JSON.Send(ws, streamEvent(e))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Stream) unsubscribe(sub *subscriber) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.subs[sub] {
+		close(sub.events)
+		delete(s.subs, sub)
+	}
+}
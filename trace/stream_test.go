@@ -0,0 +1,53 @@
+package trace_test
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/trace"
+	"golang.org/x/net/websocket"
+)
+
+var _ = Describe("Stream", func() {
+	It("should send the events to the subscribers", func() {
+		s := trace.NewStream()
+		ts := httptest.NewServer(s.Handler())
+		defer ts.Close()
+
+		url := "ws" + strings.TrimPrefix(ts.URL, "http")
+		all, err := websocket.Dial(url, "", ts.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer all.Close()
+
+		sends, err := websocket.Dial(url+"?kinds=Send", "", ts.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer sends.Close()
+
+		Eventually(s.Subscribers).Should(Equal(2))
+
+		s.Trace(trace.Event{Time: 1, Component: "Core", Kind: trace.KindInst,
+			Inst: "WAIT, $0, NET_RECV_3"})
+		s.Trace(trace.Event{Time: 2, Component: "Core", Kind: trace.KindSend,
+			Data: 7, Src: "Core.East", Dst: "Driver.East[0]"})
+		s.Close()
+
+		msg := ""
+		Expect(websocket.Message.Receive(all, &msg)).To(Succeed())
+		Expect(msg).To(MatchJSON(`{"time": 1, "component": "Core",
+			"kind": "Inst", "inst": "WAIT, $0, NET_RECV_3", "data": 0}`))
+		Expect(websocket.Message.Receive(all, &msg)).To(Succeed())
+		Expect(msg).To(ContainSubstring(`"kind":"Send"`))
+		Expect(websocket.Message.Receive(all, &msg)).NotTo(Succeed())
+
+		Expect(websocket.Message.Receive(sends, &msg)).To(Succeed())
+		Expect(msg).To(MatchJSON(`{"time": 2, "component": "Core",
+			"kind": "Send", "data": 7, "src": "Core.East",
+			"dst": "Driver.East[0]"}`))
+		Expect(websocket.Message.Receive(sends, &msg)).NotTo(Succeed())
+
+		Expect(s.Dropped()).To(BeZero())
+		Eventually(s.Subscribers).Should(BeZero())
+	})
+})
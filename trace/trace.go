@@ -0,0 +1,230 @@
+// Package trace parses and queries the trace logs that the cores print
+// during simulation.
+package trace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a trace event.
+type Kind string
+
+const (
+	// KindInst is the execution of an instruction.
+	KindInst Kind = "Inst"
+	// KindSend is a message sent to another port.
+	KindSend Kind = "Send"
+	// KindRecv is a message received from another port.
+	KindRecv Kind = "Recv"
+	// KindWrite is a write to a register.
+	KindWrite Kind = "Write"
+)
+
+// Event is a line of a trace log.
+type Event struct {
+	// Time is the simulated time in nanoseconds.
+	Time float64
+
+	Component string
+	Kind      Kind
+
+	// Inst is the instruction of an Inst event.
+	Inst string
+
+	// Data is the data of a Send, Recv, or Write event.
+	Data uint32
+
+	// Src and Dst are the names of the ports of a Send or Recv event.
+	Src, Dst string
+
+	// Reg is the register of a Write event, e.g., $0.
+	Reg string
+
+	// Invalid marks the Send and Recv events of a token whose predicate is
+	// false. The tracers in the simulation see it, but the trace formats
+	// do not record it.
+	Invalid bool
+}
+
+// Log is a parsed trace log.
+type Log struct {
+	Events []Event
+}
+
+// Parse reads a trace log in either the text format or the binary format
+// that BinaryWriter writes. In the text format, lines that are not trace
+// events, e.g., the output of the program, are skipped.
+func Parse(r io.Reader) (*Log, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(binaryMagic))
+	if err == nil && string(magic) == binaryMagic {
+		return parseBinary(br)
+	}
+
+	log := &Log{}
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		e, ok, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if ok {
+			log.Events = append(log.Events, e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return log, nil
+}
+
+func parseLine(line string) (Event, bool, error) {
+	fields := strings.SplitN(line, ", ", 3)
+	if len(fields) != 3 {
+		return Event{}, false, nil
+	}
+
+	e := Event{Component: fields[1]}
+	kind := strings.SplitN(fields[2], " ", 2)
+	if len(kind) != 2 {
+		return Event{}, false, nil
+	}
+
+	e.Kind = Kind(kind[0])
+	switch e.Kind {
+	case KindInst, KindSend, KindRecv, KindWrite:
+	default:
+		return Event{}, false, nil
+	}
+
+	t, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return Event{}, false, fmt.Errorf("invalid time %q", fields[0])
+	}
+
+	e.Time = t
+
+	err = e.parseArgs(kind[1])
+
+	return e, err == nil, err
+}
+
+func (e *Event) parseArgs(args string) error {
+	if e.Kind == KindInst {
+		e.Inst = args
+		return nil
+	}
+
+	parts := strings.Fields(args)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid %s event %q", e.Kind, args)
+	}
+
+	dataStr := parts[0]
+	if e.Kind == KindWrite {
+		e.Reg, dataStr = parts[0], parts[1]
+	} else {
+		ports := strings.SplitN(parts[1], "->", 2)
+		if len(ports) != 2 {
+			return fmt.Errorf("invalid ports %q", parts[1])
+		}
+
+		e.Src, e.Dst = ports[0], ports[1]
+	}
+
+	data, err := strconv.ParseUint(dataStr, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid data %q", dataStr)
+	}
+
+	e.Data = uint32(data)
+
+	return nil
+}
+
+// Components returns the sorted names of the components in the log.
+func (l *Log) Components() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+
+	for _, e := range l.Events {
+		if !seen[e.Component] {
+			seen[e.Component] = true
+			names = append(names, e.Component)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (l *Log) filter(keep func(e Event) bool) []Event {
+	events := []Event{}
+	for _, e := range l.Events {
+		if keep(e) {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// Timeline returns the events of a component, such as a PE, in time order.
+func (l *Log) Timeline(component string) []Event {
+	return l.filter(func(e Event) bool {
+		return e.Component == component
+	})
+}
+
+// LinkMessages returns the messages sent from the src port to the dst port.
+// The ports are matched by the suffix of their names, so
+// "Tile[0][0].Core.East" matches "Device.Tile[0][0].Core.East".
+func (l *Log) LinkMessages(src, dst string) []Event {
+	return l.filter(func(e Event) bool {
+		return e.Kind == KindSend &&
+			strings.HasSuffix(e.Src, src) && strings.HasSuffix(e.Dst, dst)
+	})
+}
+
+// RegisterHistory returns the writes to a register of a component.
+func (l *Log) RegisterHistory(component, reg string) []Event {
+	return l.filter(func(e Event) bool {
+		return e.Kind == KindWrite && e.Component == component && e.Reg == reg
+	})
+}
+
+// FirstWrite returns the first write to a register of a component. The
+// second return value is false if the register is never written.
+func (l *Log) FirstWrite(component, reg string) (Event, bool) {
+	writes := l.RegisterHistory(component, reg)
+	if len(writes) == 0 {
+		return Event{}, false
+	}
+
+	return writes[0], true
+}
+
+// Counts returns the number of events of each kind of a component.
+func (l *Log) Counts(component string) map[Kind]int {
+	counts := make(map[Kind]int)
+	for _, e := range l.Timeline(component) {
+		counts[e.Kind]++
+	}
+
+	return counts
+}
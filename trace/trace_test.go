@@ -0,0 +1,93 @@
+package trace_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+var _ = Describe("Log", func() {
+	const log = `  1.000000, Dev.Tile[0][0].Core, Recv 7 Driver.DeviceWest[0]->Dev.Tile[0][0].Core.West
+  1.000000, Dev.Tile[0][0].Core, Inst WAIT, $0, NET_RECV_3
+  1.000000, Dev.Tile[0][0].Core, Write $0 7
+  2.000000, Dev.Tile[0][0].Core, Inst SEND, NET_SEND_1, $0
+  2.000000, Dev.Tile[0][0].Core, Send 7 Dev.Tile[0][0].Core.East->Dev.Tile[1][0].Core.West
+  3.000000, Dev.Tile[1][0].Core, Write $0 9
+collect[0]: [7]
+`
+
+	var l *trace.Log
+
+	BeforeEach(func() {
+		var err error
+		l, err = trace.Parse(strings.NewReader(log))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should parse the events", func() {
+		Expect(l.Events).To(HaveLen(6))
+		Expect(l.Events[0]).To(Equal(trace.Event{
+			Time:      1,
+			Component: "Dev.Tile[0][0].Core",
+			Kind:      trace.KindRecv,
+			Data:      7,
+			Src:       "Driver.DeviceWest[0]",
+			Dst:       "Dev.Tile[0][0].Core.West",
+		}))
+		Expect(l.Events[1].Inst).To(Equal("WAIT, $0, NET_RECV_3"))
+		Expect(l.Components()).To(Equal(
+			[]string{"Dev.Tile[0][0].Core", "Dev.Tile[1][0].Core"}))
+	})
+
+	It("should answer queries", func() {
+		Expect(l.Timeline("Dev.Tile[0][0].Core")).To(HaveLen(5))
+		Expect(l.LinkMessages("Tile[0][0].Core.East", "Tile[1][0].Core.West")).
+			To(HaveLen(1))
+
+		w, ok := l.FirstWrite("Dev.Tile[1][0].Core", "$0")
+		Expect(ok).To(BeTrue())
+		Expect(w.Data).To(Equal(uint32(9)))
+		Expect(w.Time).To(Equal(3.0))
+
+		Expect(l.Counts("Dev.Tile[0][0].Core")).To(Equal(map[trace.Kind]int{
+			trace.KindInst: 2, trace.KindSend: 1, trace.KindRecv: 1,
+			trace.KindWrite: 1,
+		}))
+	})
+
+	It("should report malformed events", func() {
+		_, err := trace.Parse(strings.NewReader(
+			"  1.000000, Core, Send x A->B\n"))
+
+		Expect(err).To(MatchError(ContainSubstring("line 1")))
+	})
+
+	It("should find the first instruction that two runs execute differently", func() {
+		other := &trace.Log{}
+		for _, e := range l.Events {
+			if e.Kind == trace.KindInst && e.Inst != "WAIT, $0, NET_RECV_3" {
+				e.Time += 2
+			}
+
+			other.Trace(e)
+		}
+
+		_, ok := trace.FirstDivergence(l, l)
+		Expect(ok).To(BeFalse())
+
+		d, ok := trace.FirstDivergence(l, other)
+		Expect(ok).To(BeTrue())
+		Expect(d.Component).To(Equal("Dev.Tile[0][0].Core"))
+		Expect(d.Index).To(Equal(1))
+		Expect(d.A.Time).To(Equal(2.0))
+		Expect(d.B.Time).To(Equal(4.0))
+
+		other.Events = other.Events[:2]
+		d, ok = trace.FirstDivergence(l, other)
+		Expect(ok).To(BeTrue())
+		Expect(d.B.Inst).To(BeEmpty())
+		Expect(d.Time()).To(Equal(2.0))
+	})
+})
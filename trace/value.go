@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValuePredicate selects the events by the data that they carry.
+type ValuePredicate func(e Event) bool
+
+// ParseValuePredicate parses a comma-separated list of conditions, any of
+// which selects an event: eq=N for the data N, nan for data that is a
+// float32 NaN, and invalid for a token whose predicate is false, e.g.,
+// "eq=0xdeadbeef,nan".
+func ParseValuePredicate(expr string) (ValuePredicate, error) {
+	conds := []ValuePredicate{}
+
+	for _, cond := range strings.Split(expr, ",") {
+		p, err := parseValueCond(strings.TrimSpace(cond))
+		if err != nil {
+			return nil, err
+		}
+
+		conds = append(conds, p)
+	}
+
+	return func(e Event) bool {
+		for _, p := range conds {
+			if p(e) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+func parseValueCond(cond string) (ValuePredicate, error) {
+	switch {
+	case cond == "nan":
+		return func(e Event) bool {
+			return e.Data&0x7f800000 == 0x7f800000 && e.Data&0x7fffff != 0
+		}, nil
+	case cond == "invalid":
+		return func(e Event) bool { return e.Invalid }, nil
+	case strings.HasPrefix(cond, "eq="):
+		v, err := strconv.ParseUint(strings.TrimPrefix(cond, "eq="), 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %q", cond)
+		}
+
+		return func(e Event) bool { return e.Data == uint32(v) }, nil
+	default:
+		return nil, fmt.Errorf("unknown value condition %q", cond)
+	}
+}
+
+type valueFilter struct {
+	tracer Tracer
+	match  ValuePredicate
+}
+
+func (f valueFilter) Trace(e Event) {
+	if e.Kind != KindInst && f.match(e) {
+		f.tracer.Trace(e)
+	}
+}
+
+// FilterValues returns a Tracer that only passes the Send, Recv, and Write
+// events whose data matches to the tracer, e.g., to find where a corrupted
+// token comes from without tracing everything.
+func FilterValues(tracer Tracer, match ValuePredicate) Tracer {
+	return valueFilter{tracer: tracer, match: match}
+}
@@ -0,0 +1,283 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// A Tracer receives the events that the cores generate.
+type Tracer interface {
+	Trace(e Event)
+}
+
+// TextWriter writes events in the text format that Parse reads.
+type TextWriter struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewTextWriter creates a TextWriter.
+func NewTextWriter(w io.Writer) *TextWriter {
+	return &TextWriter{w: w}
+}
+
+// Trace writes an event as a line.
+func (t *TextWriter) Trace(e Event) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	fmt.Fprintln(t.w, e.String())
+}
+
+// String formats the event as a line of the text format.
+func (e Event) String() string {
+	prefix := fmt.Sprintf("%10f, %s, %s", e.Time, e.Component, e.Kind)
+
+	switch e.Kind {
+	case KindInst:
+		return prefix + " " + e.Inst
+	case KindWrite:
+		return fmt.Sprintf("%s %s %d", prefix, e.Reg, e.Data)
+	default:
+		return fmt.Sprintf("%s %d %s->%s", prefix, e.Data, e.Src, e.Dst)
+	}
+}
+
+// binaryMagic starts a binary trace.
+const binaryMagic = "ZTR\x01"
+
+var binaryKinds = []Kind{KindInst, KindSend, KindRecv, KindWrite}
+
+// BinaryWriter writes events in a compact binary format. The times are
+// delta-encoded varints in femtoseconds and each string is written once and
+// then referred to by index. Call Flush after the last event.
+type BinaryWriter struct {
+	lock     sync.Mutex
+	w        *bufio.Writer
+	err      error
+	started  bool
+	lastTime uint64
+	strings  map[string]uint64
+}
+
+// NewBinaryWriter creates a BinaryWriter.
+func NewBinaryWriter(w io.Writer) *BinaryWriter {
+	return &BinaryWriter{
+		w:       bufio.NewWriter(w),
+		strings: make(map[string]uint64),
+	}
+}
+
+// Trace writes an event.
+func (t *BinaryWriter) Trace(e Event) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.started {
+		t.started = true
+		_, t.err = t.w.WriteString(binaryMagic)
+	}
+
+	kind := -1
+	for i, k := range binaryKinds {
+		if k == e.Kind {
+			kind = i
+		}
+	}
+
+	if kind < 0 {
+		t.setErr(fmt.Errorf("unknown event kind %q", e.Kind))
+		return
+	}
+
+	t.setErr(t.w.WriteByte(byte(kind)))
+
+	time := uint64(math.Round(e.Time * 1e6))
+	t.writeUvarint(time - t.lastTime)
+	t.lastTime = time
+
+	t.writeString(e.Component)
+
+	switch e.Kind {
+	case KindInst:
+		t.writeString(e.Inst)
+	case KindWrite:
+		t.writeString(e.Reg)
+		t.writeUvarint(uint64(e.Data))
+	default:
+		t.writeUvarint(uint64(e.Data))
+		t.writeString(e.Src)
+		t.writeString(e.Dst)
+	}
+}
+
+func (t *BinaryWriter) setErr(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *BinaryWriter) writeUvarint(v uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+	_, err := t.w.Write(buf[:n])
+	t.setErr(err)
+}
+
+// writeString writes the index of the string plus 1, or 0 followed by the
+// string if the string has not been written before.
+func (t *BinaryWriter) writeString(s string) {
+	if index, ok := t.strings[s]; ok {
+		t.writeUvarint(index + 1)
+		return
+	}
+
+	t.strings[s] = uint64(len(t.strings))
+	t.writeUvarint(0)
+	t.writeUvarint(uint64(len(s)))
+	_, err := t.w.WriteString(s)
+	t.setErr(err)
+}
+
+// Flush writes the buffered events and returns the first error that
+// happened.
+func (t *BinaryWriter) Flush() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.setErr(t.w.Flush())
+
+	return t.err
+}
+
+type binaryReader struct {
+	r        *bufio.Reader
+	lastTime uint64
+	strings  []string
+}
+
+func parseBinary(r *bufio.Reader) (*Log, error) {
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+
+	br := &binaryReader{r: r}
+	log := &Log{}
+
+	for {
+		e, err := br.readEvent()
+		if errors.Is(err, io.EOF) {
+			return log, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %w", len(log.Events), err)
+		}
+
+		log.Events = append(log.Events, e)
+	}
+}
+
+func (br *binaryReader) readEvent() (Event, error) {
+	kind, err := br.r.ReadByte()
+	if err != nil {
+		return Event{}, err
+	}
+
+	if int(kind) >= len(binaryKinds) {
+		return Event{}, fmt.Errorf("unknown event kind %d", kind)
+	}
+
+	e := Event{Kind: binaryKinds[kind]}
+	fields := []func() error{br.readTime(&e), br.readString(&e.Component)}
+
+	switch e.Kind {
+	case KindInst:
+		fields = append(fields, br.readString(&e.Inst))
+	case KindWrite:
+		fields = append(fields, br.readString(&e.Reg), br.readData(&e.Data))
+	default:
+		fields = append(fields, br.readData(&e.Data),
+			br.readString(&e.Src), br.readString(&e.Dst))
+	}
+
+	for _, read := range fields {
+		if err := read(); err != nil {
+			return Event{}, noEOF(err)
+		}
+	}
+
+	return e, nil
+}
+
+// noEOF turns an EOF in the middle of an event into an unexpected EOF.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+func (br *binaryReader) readTime(e *Event) func() error {
+	return func() error {
+		delta, err := binary.ReadUvarint(br.r)
+		if err != nil {
+			return err
+		}
+
+		br.lastTime += delta
+		e.Time = float64(br.lastTime) / 1e6
+
+		return nil
+	}
+}
+
+func (br *binaryReader) readData(data *uint32) func() error {
+	return func() error {
+		v, err := binary.ReadUvarint(br.r)
+		*data = uint32(v)
+
+		return err
+	}
+}
+
+func (br *binaryReader) readString(s *string) func() error {
+	return func() error {
+		index, err := binary.ReadUvarint(br.r)
+		if err != nil {
+			return err
+		}
+
+		if index > 0 {
+			if index > uint64(len(br.strings)) {
+				return fmt.Errorf("invalid string index %d", index-1)
+			}
+
+			*s = br.strings[index-1]
+
+			return nil
+		}
+
+		length, err := binary.ReadUvarint(br.r)
+		if err != nil {
+			return err
+		}
+
+		buf := make([]byte, length)
+		if _, err := io.ReadFull(br.r, buf); err != nil {
+			return err
+		}
+
+		*s = string(buf)
+		br.strings = append(br.strings, *s)
+
+		return nil
+	}
+}
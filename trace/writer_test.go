@@ -0,0 +1,108 @@
+package trace_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+var _ = Describe("Writers", func() {
+	events := []trace.Event{
+		{Time: 1, Component: "Core", Kind: trace.KindRecv, Data: 7,
+			Src: "Driver.West[0]", Dst: "Core.West"},
+		{Time: 1, Component: "Core", Kind: trace.KindInst,
+			Inst: "WAIT, $0, NET_RECV_3"},
+		{Time: 1.333333, Component: "Core", Kind: trace.KindWrite,
+			Reg: "$0", Data: 7},
+		{Time: 2, Component: "Core", Kind: trace.KindSend, Data: 7,
+			Src: "Core.East", Dst: "Driver.East[0]"},
+	}
+
+	It("should write the text format", func() {
+		b := &strings.Builder{}
+		w := trace.NewTextWriter(b)
+		for _, e := range events {
+			w.Trace(e)
+		}
+
+		Expect(b.String()).To(HavePrefix(
+			"  1.000000, Core, Recv 7 Driver.West[0]->Core.West\n" +
+				"  1.000000, Core, Inst WAIT, $0, NET_RECV_3\n"))
+
+		log, err := trace.Parse(strings.NewReader(b.String()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log.Events).To(Equal(events))
+	})
+
+	It("should round-trip the binary format", func() {
+		b := &bytes.Buffer{}
+		w := trace.NewBinaryWriter(b)
+		for _, e := range events {
+			w.Trace(e)
+		}
+		Expect(w.Flush()).To(Succeed())
+
+		log, err := trace.Parse(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log.Events).To(Equal(events))
+	})
+
+	It("should be smaller than the text format", func() {
+		b := &bytes.Buffer{}
+		w := trace.NewBinaryWriter(b)
+		text := &strings.Builder{}
+		tw := trace.NewTextWriter(text)
+
+		for i := 0; i < 100; i++ {
+			for _, e := range events {
+				e.Time += float64(i)
+				w.Trace(e)
+				tw.Trace(e)
+			}
+		}
+		Expect(w.Flush()).To(Succeed())
+
+		Expect(b.Len()).To(BeNumerically("<", text.Len()/4))
+	})
+
+	It("should report truncated binary traces", func() {
+		b := &bytes.Buffer{}
+		w := trace.NewBinaryWriter(b)
+		w.Trace(events[0])
+		Expect(w.Flush()).To(Succeed())
+
+		_, err := trace.Parse(bytes.NewReader(b.Bytes()[:b.Len()-2]))
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should only pass the events whose values match", func() {
+		match, err := trace.ParseValuePredicate("eq=7, nan,invalid")
+		Expect(err).NotTo(HaveOccurred())
+
+		b := &strings.Builder{}
+		w := trace.FilterValues(trace.NewTextWriter(b), match)
+		for _, e := range events {
+			w.Trace(e)
+		}
+		w.Trace(trace.Event{Time: 3, Component: "Core", Kind: trace.KindWrite,
+			Reg: "$1", Data: 0x7fc00000})
+		w.Trace(trace.Event{Time: 3, Component: "Core", Kind: trace.KindSend,
+			Data: 1, Src: "Core.East", Dst: "Driver.East[0]", Invalid: true})
+		w.Trace(trace.Event{Time: 3, Component: "Core", Kind: trace.KindWrite,
+			Reg: "$2", Data: 0x7f800000})
+
+		log, err := trace.Parse(strings.NewReader(b.String()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log.Events).To(HaveLen(5))
+		Expect(log.Events[3].Data).To(Equal(uint32(0x7fc00000)))
+
+		_, err = trace.ParseValuePredicate("eq=x")
+		Expect(err).To(MatchError("invalid value in \"eq=x\""))
+		_, err = trace.ParseValuePredicate("odd")
+		Expect(err).To(MatchError("unknown value condition \"odd\""))
+	})
+})
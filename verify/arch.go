@@ -0,0 +1,54 @@
+// Package verify provides static checks of CGRA programs against the
+// architecture that they are mapped to. The programs can use the aliases of
+// core.DefaultOpcodeAliases, which all the checks replace with the opcodes
+// that they stand for.
+package verify
+
+import (
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+)
+
+// ArchInfo describes the architecture parameters that the checks rely on.
+type ArchInfo struct {
+	// Rows and Columns are the size of the PE array.
+	Rows, Columns int
+
+	// Topology is the interconnect among the PEs, e.g., "mesh".
+	Topology string
+
+	// MemCapacity is the number of words in the local memory of each PE. If
+	// it is 0, the memory usage is not checked.
+	MemCapacity int
+
+	// RegistersPerPE is the number of registers of each PE. If it is 0, the
+	// register usage is not checked.
+	RegistersPerPE int
+
+	// CtrlMemItems is the number of instructions that the control memory of
+	// each PE can hold. If it is 0, the program size is not checked.
+	CtrlMemItems int
+
+	// IssueLimits are the instructions that each PE issues per cycle and
+	// the ports that they can access, which the groups of the instructions
+	// that a schedule puts in the same step must fit.
+	IssueLimits core.IssueLimits
+
+	// PECaps are the capability classes of the PEs, keyed by the [x, y]
+	// coordinate of the PE. PEs that are not listed support all opcodes.
+	PECaps map[[2]int]cgra.PECaps
+
+	// DisabledTiles are the [x, y] coordinates of the tiles that are absent
+	// from the device.
+	DisabledTiles [][2]int
+}
+
+// programLines splits a program into its lines and replaces the opcodes of
+// core.DefaultOpcodeAliases, so that all the checks see the opcodes that
+// the cores run.
+func programLines(program string) []string {
+	return core.CanonicalizeOpcodes(strings.Split(program, "\n"),
+		core.DefaultOpcodeAliases)
+}
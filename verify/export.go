@@ -0,0 +1,187 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Schedule returns the earliest cycle that each instruction of the first
+// iteration can start at, considering the dependencies within the
+// iteration. It returns an error if the dependencies within an iteration
+// form a cycle, which means that the programs deadlock.
+func (g *DepGraph) Schedule() (map[InstRef]int, error) {
+	start := make(map[InstRef]int, len(g.Insts))
+
+	for round := 0; round <= len(g.Insts); round++ {
+		changed := false
+
+		for _, d := range g.Deps {
+			if d.Distance > 0 {
+				continue
+			}
+
+			if t := start[d.From] + d.Latency; t > start[d.To] {
+				start[d.To] = t
+				changed = true
+			}
+		}
+
+		if !changed {
+			return start, nil
+		}
+	}
+
+	return nil, errors.New("dependencies within an iteration form a cycle")
+}
+
+// WriteDOT writes the dependency graph in the Graphviz DOT format. The
+// instructions are grouped by PE and labeled with the cycles they are
+// scheduled at. The edges are labeled with the latencies and, for the
+// loop-carried dependencies, which are dashed, the iteration distances.
+func (g *DepGraph) WriteDOT(w io.Writer) error {
+	schedule, schedErr := g.Schedule()
+
+	b := &strings.Builder{}
+	b.WriteString("digraph deps {\n\tnode [shape=box];\n")
+
+	pes, insts := g.instsByPE()
+	for _, pe := range pes {
+		fmt.Fprintf(b, "\tsubgraph cluster_%d_%d {\n\t\tlabel=\"PE(%d, %d)\";\n",
+			pe[0], pe[1], pe[0], pe[1])
+
+		for _, inst := range insts[pe] {
+			label := g.Code[inst]
+			if schedErr == nil {
+				label = fmt.Sprintf("t=%d: %s", schedule[inst], label)
+			}
+
+			fmt.Fprintf(b, "\t\t%s [label=%q];\n", dotID(inst), label)
+		}
+
+		b.WriteString("\t}\n")
+	}
+
+	for _, d := range g.Deps {
+		attrs := fmt.Sprintf("label=\"%d\"", d.Latency)
+		if d.Distance > 0 {
+			attrs = fmt.Sprintf("label=\"%d (+%d)\", style=dashed",
+				d.Latency, d.Distance)
+		}
+
+		if d.Channel != nil {
+			attrs += ", color=blue"
+		}
+
+		fmt.Fprintf(b, "\t%s -> %s [%s];\n", dotID(d.From), dotID(d.To), attrs)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func dotID(inst InstRef) string {
+	return fmt.Sprintf("pe_%d_%d_%d", inst.PE[0], inst.PE[1], inst.Line)
+}
+
+func (g *DepGraph) instsByPE() ([][2]int, map[[2]int][]InstRef) {
+	pes := [][2]int{}
+	insts := make(map[[2]int][]InstRef)
+
+	for _, inst := range g.Insts {
+		if _, ok := insts[inst.PE]; !ok {
+			pes = append(pes, inst.PE)
+		}
+
+		insts[inst.PE] = append(insts[inst.PE], inst)
+	}
+
+	return pes, insts
+}
+
+type timelineRow struct {
+	PE    [2]int
+	Cells []string
+}
+
+const timelineStyle = `<style>
+table { border-collapse: collapse; font-family: monospace; }
+td, th { border: 1px solid #ccc; padding: 2px 6px; white-space: nowrap; }
+td.busy { background: #cde; }
+</style>`
+
+const timelineTable = `{{define "timeline"}}<table>
+<tr><th>PE</th>{{range .Cycles}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th>({{index .PE 0}}, {{index .PE 1}})</th>` +
+	`{{range .Cells}}{{if .}}<td class="busy">{{.}}</td>{{else}}<td></td>` +
+	`{{end}}{{end}}</tr>
+{{end}}</table>{{end}}`
+
+var timelineTemplate = template.Must(template.Must(
+	template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Schedule</title>
+` + timelineStyle + `
+</head>
+<body>
+{{template "timeline" .}}
+</body>
+</html>
+`)).Parse(timelineTable))
+
+type timeline struct {
+	Cycles []int
+	Rows   []timelineRow
+}
+
+// WriteTimeline writes an HTML page with a Gantt-style timeline of the first
+// iteration, with a row for each PE and a column for each cycle.
+func (g *DepGraph) WriteTimeline(w io.Writer) error {
+	t, err := g.timeline()
+	if err != nil {
+		return err
+	}
+
+	return timelineTemplate.Execute(w, t)
+}
+
+func (g *DepGraph) timeline() (timeline, error) {
+	schedule, err := g.Schedule()
+	if err != nil {
+		return timeline{}, err
+	}
+
+	length := 0
+	for _, t := range schedule {
+		if t+1 > length {
+			length = t + 1
+		}
+	}
+
+	pes, insts := g.instsByPE()
+	t := timeline{
+		Cycles: make([]int, length),
+		Rows:   make([]timelineRow, 0, len(pes)),
+	}
+
+	for i := range t.Cycles {
+		t.Cycles[i] = i
+	}
+
+	for _, pe := range pes {
+		row := timelineRow{PE: pe, Cells: make([]string, length)}
+		for _, inst := range insts[pe] {
+			row.Cells[schedule[inst]] = g.Code[inst]
+		}
+
+		t.Rows = append(t.Rows, row)
+	}
+
+	return t, nil
+}
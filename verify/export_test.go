@@ -0,0 +1,65 @@
+package verify_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("DepGraph", func() {
+	arch := verify.ArchInfo{Rows: 1, Columns: 2, Topology: "mesh"}
+	programs := map[[2]int]string{
+		{0, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0",
+		{1, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0",
+	}
+
+	It("should schedule the instructions", func() {
+		g := verify.BuildDepGraph(programs, arch)
+
+		schedule, err := g.Schedule()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedule[verify.InstRef{PE: [2]int{1, 0}, Line: 0}]).
+			To(Equal(2))
+		Expect(schedule[verify.InstRef{PE: [2]int{1, 0}, Line: 1}]).
+			To(Equal(3))
+	})
+
+	It("should write the graph in DOT", func() {
+		g := verify.BuildDepGraph(programs, arch)
+		b := &strings.Builder{}
+
+		Expect(g.WriteDOT(b)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring(
+			`pe_1_0_0 [label="t=2: WAIT, $0, NET_RECV_3"];`))
+		Expect(b.String()).To(ContainSubstring(
+			`pe_0_0_1 -> pe_1_0_0 [label="1", color=blue];`))
+		Expect(b.String()).To(ContainSubstring(
+			`pe_1_0_1 -> pe_1_0_0 [label="1 (+1)", style=dashed];`))
+	})
+
+	It("should write the timeline in HTML", func() {
+		g := verify.BuildDepGraph(programs, arch)
+		b := &strings.Builder{}
+
+		Expect(g.WriteTimeline(b)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring(
+			`<tr><th>(1, 0)</th><td></td><td></td>` +
+				`<td class="busy">WAIT, $0, NET_RECV_3</td>`))
+	})
+
+	It("should not schedule programs that deadlock", func() {
+		g := verify.BuildDepGraph(map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_1\nSEND, NET_SEND_1, $0",
+			{1, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_3, $0",
+		}, arch)
+
+		_, err := g.Schedule()
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,341 @@
+package verify
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+)
+
+const (
+	// instLatency is the number of cycles between two instructions that run
+	// back to back on a PE.
+	instLatency = 1
+
+	// hopLatency is the number of cycles between a SEND and the WAIT on the
+	// neighbor that receives the data.
+	hopLatency = 1
+)
+
+// InstRef identifies an instruction by the [x, y] coordinate of the PE and
+// the 0-based line number in the program.
+type InstRef struct {
+	PE   [2]int
+	Line int
+}
+
+// Channel is the link from a PE to the neighbor on one of its sides.
+type Channel struct {
+	From, To [2]int
+	Side     cgra.Side
+}
+
+// Dependency is an edge in a DepGraph. The To instruction of iteration i+
+// Distance cannot start until Latency cycles after the From instruction of
+// iteration i starts.
+type Dependency struct {
+	From, To InstRef
+	Latency  int
+	Distance int
+
+	// Channel is the channel that carries the data, or nil if both
+	// instructions are on the same PE.
+	Channel *Channel
+}
+
+// DepGraph is the producer-consumer graph of the instructions of all the
+// PEs. Each program is treated as the body of a loop, so the last
+// instruction of a PE feeds the first instruction of the next iteration.
+type DepGraph struct {
+	Insts []InstRef
+	Deps  []Dependency
+
+	// Code holds the text of each instruction.
+	Code map[InstRef]string
+
+	// Produced and Consumed are the number of SENDs and WAITs on each
+	// channel per iteration. Channels to and from the driver are not
+	// included.
+	Produced map[Channel]int
+	Consumed map[Channel]int
+}
+
+type peProgram struct {
+	coord [2]int
+	insts []int
+	sends map[cgra.Side][]int
+	waits map[cgra.Side][]int
+}
+
+// BuildDepGraph builds the dependency graph of the programs. The programs on
+// disabled tiles are left out.
+func BuildDepGraph(programs map[[2]int]string, arch ArchInfo) *DepGraph {
+	g := &DepGraph{
+		Code:     make(map[InstRef]string),
+		Produced: make(map[Channel]int),
+		Consumed: make(map[Channel]int),
+	}
+
+	pes := make(map[[2]int]*peProgram)
+	for _, coord := range sortedCoords(programs) {
+		if isDisabled(coord, arch) {
+			continue
+		}
+
+		pe := g.addProgram(coord, programLines(programs[coord]),
+			arch.IssueLimits)
+		if len(pe.insts) > 0 {
+			pes[coord] = pe
+		}
+	}
+
+	for _, coord := range sortedCoords(programs) {
+		if pe, ok := pes[coord]; ok {
+			g.addChannels(pe, pes)
+			g.addIdleNeighbors(pe, pes, arch)
+		}
+	}
+
+	return g
+}
+
+func sortedCoords(programs map[[2]int]string) [][2]int {
+	coords := make([][2]int, 0, len(programs))
+	for coord := range programs {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	return coords
+}
+
+// addProgram adds the instructions of a PE, each of which depends on the
+// previous one. A PE that issues one instruction per cycle runs them in
+// consecutive cycles. A wider PE can run them in the same cycle, unless an
+// instruction reads a register that an instruction of the same cycle writes
+// and the PE has no bypass, which adds a dependency of 1 cycle between the
+// two.
+func (g *DepGraph) addProgram(
+	coord [2]int,
+	lines []string,
+	limits core.IssueLimits,
+) *peProgram {
+	pe := &peProgram{
+		coord: coord,
+		sends: make(map[cgra.Side][]int),
+		waits: make(map[cgra.Side][]int),
+	}
+
+	for i, line := range lines {
+		op := core.Opcode(line)
+		if op == "" {
+			continue
+		}
+
+		ref := InstRef{PE: coord, Line: i}
+		g.Insts = append(g.Insts, ref)
+		g.Code[ref] = strings.TrimSpace(line)
+		pe.insts = append(pe.insts, i)
+
+		pe.addPorts(op, operandsOf(line), i)
+	}
+
+	latency := instLatency
+	if limits.Width > 1 {
+		latency = 0
+	}
+
+	for i := range pe.insts {
+		next, distance := i+1, 0
+		if next == len(pe.insts) {
+			next, distance = 0, 1
+		}
+
+		g.Deps = append(g.Deps, Dependency{
+			From:     InstRef{PE: coord, Line: pe.insts[i]},
+			To:       InstRef{PE: coord, Line: pe.insts[next]},
+			Latency:  latency,
+			Distance: distance,
+		})
+	}
+
+	if limits.Width > 1 && !limits.Bypass {
+		g.addRegisterDeps(coord, lines, pe.insts)
+	}
+
+	return pe
+}
+
+// addRegisterDeps adds a dependency of 1 cycle from each instruction that
+// writes a register to the instructions of the same iteration that read it
+// before it is written again.
+func (g *DepGraph) addRegisterDeps(coord [2]int, lines []string, insts []int) {
+	writer := make(map[int]int)
+
+	for _, line := range insts {
+		reads, writes := core.RegisterAccesses(lines[line])
+
+		from := make(map[int]bool)
+		for _, reg := range reads {
+			w, ok := writer[reg]
+			if !ok || from[w] {
+				continue
+			}
+
+			from[w] = true
+			g.Deps = append(g.Deps, Dependency{
+				From:    InstRef{PE: coord, Line: w},
+				To:      InstRef{PE: coord, Line: line},
+				Latency: instLatency,
+			})
+		}
+
+		for _, reg := range writes {
+			writer[reg] = line
+		}
+	}
+}
+
+// addPorts records the sides that the instruction at the line sends to or
+// receives from. A FORWARD instruction does both, and a REDUCE instruction
+// receives from all the sides in its mask. Other instructions, such as
+// DATA_MOV, send to their NET_SEND operands and receive from their NET_RECV
+// operands.
+func (pe *peProgram) addPorts(op string, operands []string, line int) {
+	switch {
+	case (op == "SEND" || op == "SEND_PRED") && len(operands) > 0:
+		if side, ok := portSide(operands[0], "NET_SEND_"); ok {
+			pe.sends[side] = append(pe.sends[side], line)
+		}
+	case op == "WAIT" && len(operands) > 1:
+		if side, ok := portSide(operands[1], "NET_RECV_"); ok {
+			pe.waits[side] = append(pe.waits[side], line)
+		}
+	case op == "FORWARD" && len(operands) > 1:
+		if side, ok := portSide(operands[0], "NET_SEND_"); ok {
+			pe.sends[side] = append(pe.sends[side], line)
+		}
+
+		if side, ok := portSide(operands[1], "NET_RECV_"); ok {
+			pe.waits[side] = append(pe.waits[side], line)
+		}
+	case strings.HasPrefix(op, "REDUCE_") && len(operands) > 2:
+		mask, err := strconv.ParseInt(operands[2], 0, 64)
+		if err != nil {
+			return
+		}
+
+		for side := cgra.North; side <= cgra.West; side++ {
+			if mask&(1<<side) != 0 {
+				pe.waits[side] = append(pe.waits[side], line)
+			}
+		}
+	default:
+		pe.addNetOperands(operands, line)
+	}
+}
+
+func (pe *peProgram) addNetOperands(operands []string, line int) {
+	for _, o := range operands {
+		if side, ok := portSide(o, "NET_SEND_"); ok {
+			pe.sends[side] = append(pe.sends[side], line)
+		}
+
+		if side, ok := portSide(o, "NET_RECV_"); ok {
+			pe.waits[side] = append(pe.waits[side], line)
+		}
+	}
+}
+
+func portSide(operand, prefix string) (cgra.Side, bool) {
+	if !strings.HasPrefix(operand, prefix) {
+		return 0, false
+	}
+
+	index, err := strconv.Atoi(strings.TrimPrefix(operand, prefix))
+	if err != nil || index < 0 || index > 3 {
+		return 0, false
+	}
+
+	return cgra.Side(index), true
+}
+
+func neighbor(coord [2]int, side cgra.Side) [2]int {
+	switch side {
+	case cgra.North:
+		return [2]int{coord[0], coord[1] - 1}
+	case cgra.East:
+		return [2]int{coord[0] + 1, coord[1]}
+	case cgra.South:
+		return [2]int{coord[0], coord[1] + 1}
+	default:
+		return [2]int{coord[0] - 1, coord[1]}
+	}
+}
+
+// addChannels connects the k-th SEND on a channel to the k-th WAIT on the
+// other end of the channel.
+func (g *DepGraph) addChannels(pe *peProgram, pes map[[2]int]*peProgram) {
+	for side := cgra.North; side <= cgra.West; side++ {
+		dst, ok := pes[neighbor(pe.coord, side)]
+		if !ok {
+			continue
+		}
+
+		ch := &Channel{From: pe.coord, To: dst.coord, Side: side}
+		sends := pe.sends[side]
+		waits := dst.waits[side.Opposite()]
+
+		if len(sends) > 0 || len(waits) > 0 {
+			g.Produced[*ch] = len(sends)
+			g.Consumed[*ch] = len(waits)
+		}
+
+		for k := 0; k < len(sends) && k < len(waits); k++ {
+			g.Deps = append(g.Deps, Dependency{
+				From:    InstRef{PE: pe.coord, Line: sends[k]},
+				To:      InstRef{PE: dst.coord, Line: waits[k]},
+				Latency: hopLatency,
+				Channel: ch,
+			})
+		}
+	}
+}
+
+// addIdleNeighbors records the channels between the PE and the neighbors in
+// the array that have no program, which neither consume nor produce data.
+func (g *DepGraph) addIdleNeighbors(
+	pe *peProgram,
+	pes map[[2]int]*peProgram,
+	arch ArchInfo,
+) {
+	for side := cgra.North; side <= cgra.West; side++ {
+		nb := neighbor(pe.coord, side)
+		if _, ok := pes[nb]; ok || !inArray(nb, arch) {
+			continue
+		}
+
+		if n := len(pe.sends[side]); n > 0 {
+			g.Produced[Channel{From: pe.coord, To: nb, Side: side}] = n
+		}
+
+		if n := len(pe.waits[side]); n > 0 {
+			ch := Channel{From: nb, To: pe.coord, Side: side.Opposite()}
+			g.Consumed[ch] = n
+		}
+	}
+}
+
+func inArray(coord [2]int, arch ArchInfo) bool {
+	return coord[0] >= 0 && coord[0] < arch.Columns &&
+		coord[1] >= 0 && coord[1] < arch.Rows
+}
@@ -0,0 +1,160 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sarchlab/zeonica/core"
+)
+
+// CheckGroups checks that the instructions that each schedule puts in the
+// same time step fit the issue limits of the architecture, i.e., that no
+// group has more instructions than the issue width or accesses more ports
+// than a PE can in a cycle. An issue is reported at the first line of each
+// group that exceeds a limit.
+func CheckGroups(
+	programs map[[2]int]string,
+	schedules map[[2]int]Schedule,
+	arch ArchInfo,
+) []Issue {
+	issues := []Issue{}
+	limits := arch.IssueLimits
+
+	width := limits.Width
+	if width <= 0 {
+		width = 1
+	}
+
+	for coord, program := range programs {
+		s, ok := schedules[coord]
+		if !ok {
+			continue
+		}
+
+		for _, g := range groupLines(programLines(program), s.Steps) {
+			issue := func(format string, args ...interface{}) {
+				issues = append(issues, Issue{
+					Type: IssueStruct,
+					PE:   coord,
+					Line: g.lines[0],
+					Message: fmt.Sprintf("group of step %d ", g.step) +
+						fmt.Sprintf(format, args...),
+				})
+			}
+
+			if len(g.lines) > width {
+				issue("has %d instructions, but the issue width is %d",
+					len(g.lines), width)
+			}
+
+			if limits.PortReads > 0 && g.reads > limits.PortReads {
+				issue("reads %d ports, but the PE reads at most %d",
+					g.reads, limits.PortReads)
+			}
+
+			if limits.PortWrites > 0 && g.writes > limits.PortWrites {
+				issue("writes %d ports, but the PE writes at most %d",
+					g.writes, limits.PortWrites)
+			}
+
+			for _, c := range limits.BankConflicts(g.regReads, g.regWrites) {
+				issue("conflicts, as %s", c)
+			}
+		}
+	}
+
+	addSources(issues, programs)
+	sortIssues(issues)
+
+	return issues
+}
+
+// instGroup is the instructions of a program in a time step.
+type instGroup struct {
+	step          int
+	lines         []int
+	reads, writes int
+
+	regReads, regWrites []int
+}
+
+// groupLines groups the instructions of a program by their time steps, in
+// the order of the steps. The labels and the lines without steps are not in
+// any group.
+func groupLines(lines []string, steps []int) []instGroup {
+	byStep := make(map[int]*instGroup)
+
+	for i, line := range lines {
+		if i >= len(steps) || core.Opcode(line) == "" {
+			continue
+		}
+
+		g, ok := byStep[steps[i]]
+		if !ok {
+			g = &instGroup{step: steps[i]}
+			byStep[steps[i]] = g
+		}
+
+		reads, writes := core.PortAccesses(line)
+		g.lines = append(g.lines, i)
+		g.reads += reads
+		g.writes += writes
+
+		regReads, regWrites := core.RegisterAccesses(line)
+		g.regReads = append(g.regReads, regReads...)
+		g.regWrites = append(g.regWrites, regWrites...)
+	}
+
+	groups := make([]instGroup, 0, len(byStep))
+	for _, g := range byStep {
+		groups = append(groups, *g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].step < groups[j].step
+	})
+
+	return groups
+}
+
+// checkIssueLimits reports the instructions that exceed the ports of the PE
+// by themselves, which issue alone and still cannot access all their
+// operands in a cycle.
+func checkIssueLimits(coord [2]int, lines []string, arch ArchInfo) []Issue {
+	issues := []Issue{}
+	limits := arch.IssueLimits
+
+	for i, line := range lines {
+		if core.Opcode(line) == "" {
+			continue
+		}
+
+		msgs := []string{}
+
+		reads, writes := core.PortAccesses(line)
+		if limits.PortReads > 0 && reads > limits.PortReads {
+			msgs = append(msgs, fmt.Sprintf("reads %d ports, but the PE "+
+				"reads at most %d", reads, limits.PortReads))
+		}
+
+		if limits.PortWrites > 0 && writes > limits.PortWrites {
+			msgs = append(msgs, fmt.Sprintf("writes %d ports, but the PE "+
+				"writes at most %d", writes, limits.PortWrites))
+		}
+
+		for _, c := range limits.BankConflicts(core.RegisterAccesses(line)) {
+			msgs = append(msgs, "conflicts, as "+c)
+		}
+
+		for _, msg := range msgs {
+			issues = append(issues, Issue{
+				Type:    IssueStruct,
+				PE:      coord,
+				Line:    i,
+				Message: "instruction " + msg,
+			})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,108 @@
+package verify_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("CheckGroups", func() {
+	programs := map[[2]int]string{
+		{0, 0}: "START:\n" +
+			"WAIT, $0, NET_RECV_3\n" +
+			"WAIT, $1, NET_RECV_0\n" +
+			"I_ADD, $2, $0, $1\n" +
+			"SEND, NET_SEND_1, $2\n" +
+			"JMP, START",
+	}
+	schedules := map[[2]int]verify.Schedule{
+		{0, 0}: {Steps: []int{0, 0, 0, 1, 2, 2}, II: 3},
+	}
+
+	It("should accept groups that fit the issue limits", func() {
+		arch := verify.ArchInfo{
+			Rows: 1, Columns: 1, Topology: "mesh",
+			IssueLimits: core.IssueLimits{Width: 2, PortReads: 2},
+		}
+
+		Expect(verify.CheckGroups(programs, schedules, arch)).To(BeEmpty())
+	})
+
+	It("should report groups that exceed the issue limits", func() {
+		arch := verify.ArchInfo{
+			Rows: 1, Columns: 1, Topology: "mesh",
+			IssueLimits: core.IssueLimits{PortReads: 1},
+		}
+
+		issues := verify.CheckGroups(programs, schedules, arch)
+
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[0].String()).To(Equal("[STRUCT] PE(0, 0) line 1: " +
+			"group of step 0 has 2 instructions, but the issue width is 1"))
+		Expect(issues[1].Message).To(Equal("group of step 0 reads 2 " +
+			"ports, but the PE reads at most 1"))
+		Expect(issues[2].String()).To(Equal("[STRUCT] PE(0, 0) line 4: " +
+			"group of step 2 has 2 instructions, but the issue width is 1"))
+	})
+
+	It("should report the register bank conflicts", func() {
+		arch := verify.ArchInfo{
+			Rows: 1, Columns: 1, Topology: "mesh",
+			IssueLimits: core.IssueLimits{
+				Width: 4, RegReads: 2, RegWrites: 1, RegBanks: 2},
+		}
+		programs := map[[2]int]string{
+			{0, 0}: "I_ADD, $0, $2, $4\nI_ADD, $6, $8, 1\nI_ADD, $1, $3, $5",
+		}
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1}, II: 2},
+		}
+
+		issues := verify.CheckGroups(programs, schedules, arch)
+
+		Expect(issues).To(HaveLen(2))
+		Expect(issues[0].Message).To(Equal("group of step 0 conflicts, as " +
+			"3 registers of bank 0 are read, but 2 can be read per cycle"))
+		Expect(issues[1].Message).To(Equal("group of step 0 conflicts, as " +
+			"2 registers of bank 0 are written, but 1 can be written " +
+			"per cycle"))
+		Expect(verify.Lint(programs, arch)).To(BeEmpty())
+	})
+
+	It("should count the registers of the instructions with aliases", func() {
+		arch := verify.ArchInfo{
+			Rows: 1, Columns: 1, Topology: "mesh",
+			IssueLimits: core.IssueLimits{Width: 2, RegReads: 1, RegBanks: 2},
+		}
+		programs := map[[2]int]string{
+			{0, 0}: "LD, $1, $2\nMOV, $3, $4",
+		}
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0}, II: 1},
+		}
+
+		issues := verify.CheckGroups(programs, schedules, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Message).To(Equal("group of step 0 conflicts, as " +
+			"2 registers of bank 0 are read, but 1 can be read per cycle"))
+	})
+
+	It("should lint the instructions that exceed the ports alone", func() {
+		arch := verify.ArchInfo{
+			Rows: 1, Columns: 1, Topology: "mesh",
+			IssueLimits: core.IssueLimits{RegReads: 1, PortWrites: 1},
+		}
+		programs := map[[2]int]string{
+			{0, 0}: "I_ADD, $0, $1, $2\nFORWARD, NET_SEND_1, NET_RECV_3",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].String()).To(Equal("[STRUCT] PE(0, 0) line 0: " +
+			"instruction conflicts, as 2 registers of the register file " +
+			"are read, but 1 can be read per cycle"))
+	})
+})
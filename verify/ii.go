@@ -0,0 +1,121 @@
+package verify
+
+// IIAnalysis is the throughput estimate of a set of programs.
+type IIAnalysis struct {
+	// II is the minimum initiation interval in cycles, i.e., the number of
+	// cycles between the starts of two consecutive iterations.
+	II int
+
+	// ResMII is the bound on the II from the number of instructions of the
+	// busiest PE and the number of instructions that it issues per cycle.
+	ResMII int
+
+	// CriticalCycle is a cycle of dependencies that binds the II, which can
+	// span multiple PEs. It is empty if the programs have no instructions.
+	CriticalCycle []InstRef
+}
+
+// AnalyzeII computes the initiation interval that the programs can achieve
+// from the recurrence cycles in the dependency graph, including the
+// loop-carried dependencies across PEs, and from the number of instructions
+// of each PE.
+func AnalyzeII(programs map[[2]int]string, arch ArchInfo) IIAnalysis {
+	g := BuildDepGraph(programs, arch)
+
+	result := IIAnalysis{ResMII: resMII(g, arch.IssueLimits.Width)}
+	if len(g.Insts) == 0 {
+		return result
+	}
+
+	lo, hi := result.ResMII, maxII(g)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if g.positiveCycle(mid) == nil {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	result.II = lo
+	result.CriticalCycle = g.positiveCycle(lo - 1)
+
+	return result
+}
+
+func resMII(g *DepGraph, width int) int {
+	counts := make(map[[2]int]int)
+	busiest := 0
+
+	for _, inst := range g.Insts {
+		counts[inst.PE]++
+		if counts[inst.PE] > busiest {
+			busiest = counts[inst.PE]
+		}
+	}
+
+	if width > 1 {
+		return (busiest + width - 1) / width
+	}
+
+	return busiest
+}
+
+// maxII is an II that is always feasible, since any cycle has a distance of
+// at least 1 and a latency of at most the sum of all the latencies.
+func maxII(g *DepGraph) int {
+	sum := 0
+	for _, d := range g.Deps {
+		sum += d.Latency
+	}
+
+	return sum
+}
+
+// positiveCycle returns a cycle whose latency is more than ii times its
+// distance, or nil if there is no such cycle. It uses the Bellman-Ford
+// algorithm to find the longest paths with the weight latency - ii*distance.
+func (g *DepGraph) positiveCycle(ii int) []InstRef {
+	dist := make(map[InstRef]int, len(g.Insts))
+	pred := make(map[InstRef]InstRef, len(g.Insts))
+
+	var last *InstRef
+
+	for round := 0; round < len(g.Insts); round++ {
+		last = nil
+
+		for _, d := range g.Deps {
+			w := dist[d.From] + d.Latency - ii*d.Distance
+			if w > dist[d.To] {
+				dist[d.To] = w
+				pred[d.To] = d.From
+				to := d.To
+				last = &to
+			}
+		}
+
+		if last == nil {
+			return nil
+		}
+	}
+
+	return extractCycle(*last, pred, len(g.Insts))
+}
+
+func extractCycle(start InstRef, pred map[InstRef]InstRef, n int) []InstRef {
+	inst := start
+	for i := 0; i < n; i++ {
+		inst = pred[inst]
+	}
+
+	cycle := []InstRef{inst}
+	for p := pred[inst]; p != inst; p = pred[p] {
+		cycle = append(cycle, p)
+	}
+
+	for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+		cycle[i], cycle[j] = cycle[j], cycle[i]
+	}
+
+	return cycle
+}
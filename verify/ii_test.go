@@ -0,0 +1,62 @@
+package verify_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("AnalyzeII", func() {
+	arch := verify.ArchInfo{Rows: 1, Columns: 2, Topology: "mesh"}
+
+	It("should be bound by the busiest PE without recurrences", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n" +
+				"JMP, START",
+		}
+
+		result := verify.AnalyzeII(programs, arch)
+
+		Expect(result.II).To(Equal(3))
+		Expect(result.ResMII).To(Equal(3))
+		Expect(result.CriticalCycle).To(HaveLen(3))
+	})
+
+	It("should find recurrences across PEs", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "SEND, NET_SEND_1, $0\nWAIT, $0, NET_RECV_1\n" +
+				"I_ADD, $0, $0, 1",
+			{1, 0}: "WAIT, $0, NET_RECV_3\nI_ADD, $0, $0, 1\n" +
+				"I_ADD, $0, $0, 1\nSEND, NET_SEND_3, $0",
+		}
+
+		result := verify.AnalyzeII(programs, arch)
+
+		Expect(result.ResMII).To(Equal(4))
+		Expect(result.II).To(Equal(7))
+		Expect(result.CriticalCycle).To(ConsistOf(
+			verify.InstRef{PE: [2]int{0, 0}, Line: 0},
+			verify.InstRef{PE: [2]int{0, 0}, Line: 1},
+			verify.InstRef{PE: [2]int{0, 0}, Line: 2},
+			verify.InstRef{PE: [2]int{1, 0}, Line: 0},
+			verify.InstRef{PE: [2]int{1, 0}, Line: 1},
+			verify.InstRef{PE: [2]int{1, 0}, Line: 2},
+			verify.InstRef{PE: [2]int{1, 0}, Line: 3},
+		))
+	})
+
+	It("should connect the sends to the sides of a REDUCE", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "SEND, NET_SEND_1, $0\nWAIT, $0, NET_RECV_1\n" +
+				"I_ADD, $0, $0, 1",
+			{1, 0}: "REDUCE_ADD, $0, 0, 0b1000\nI_ADD, $0, $0, 1\n" +
+				"I_ADD, $0, $0, 1\nSEND, NET_SEND_3, $0",
+		}
+
+		result := verify.AnalyzeII(programs, arch)
+
+		Expect(result.II).To(Equal(7))
+		Expect(result.CriticalCycle).To(ContainElement(
+			verify.InstRef{PE: [2]int{1, 0}, Line: 0}))
+	})
+})
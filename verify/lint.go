@@ -0,0 +1,156 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/zeonica/core"
+)
+
+// IssueType is the category of an issue found by the linter.
+type IssueType string
+
+const (
+	// IssueStruct is an issue about how a program fits the structure of the
+	// architecture.
+	IssueStruct IssueType = "STRUCT"
+
+	// IssueRate is an issue about a channel whose producer and consumer do
+	// not transfer the same number of tokens per iteration.
+	IssueRate IssueType = "RATE"
+
+	// IssueTiming is an issue about an instruction that a static schedule
+	// runs before its data can be ready.
+	IssueTiming IssueType = "TIMING"
+)
+
+// Issue is a problem found in a program.
+type Issue struct {
+	Type IssueType
+
+	// PE is the [x, y] coordinate of the PE that the program is mapped to.
+	PE [2]int
+
+	// Line is the 0-based line number in the program, or -1 if the issue is
+	// not about a specific line.
+	Line int
+
+	Message string
+
+	// Source is where the instruction on the line comes from in the source
+	// kernel, if the program has source metadata.
+	Source core.Source
+}
+
+func (i Issue) String() string {
+	if i.Line < 0 {
+		return fmt.Sprintf("[%s] PE(%d, %d): %s",
+			i.Type, i.PE[0], i.PE[1], i.Message)
+	}
+
+	if !i.Source.IsZero() {
+		return fmt.Sprintf("[%s] PE(%d, %d) line %d (%s): %s",
+			i.Type, i.PE[0], i.PE[1], i.Line, i.Source, i.Message)
+	}
+
+	return fmt.Sprintf("[%s] PE(%d, %d) line %d: %s",
+		i.Type, i.PE[0], i.PE[1], i.Line, i.Message)
+}
+
+// Lint checks the programs against the architecture. The programs are keyed
+// by the [x, y] coordinate of the PE that they are mapped to, and can use the
+// core.DefaultOpcodeAliases. The issues are sorted by PE and by line.
+func Lint(programs map[[2]int]string, arch ArchInfo) []Issue {
+	issues := []Issue{}
+
+	for coord, program := range programs {
+		if isDisabled(coord, arch) {
+			issues = append(issues, Issue{
+				Type:    IssueStruct,
+				PE:      coord,
+				Line:    -1,
+				Message: "program is mapped to a disabled tile",
+			})
+
+			continue
+		}
+
+		lines := programLines(program)
+		issues = append(issues, checkCapabilities(coord, lines, arch)...)
+		issues = append(issues, checkRegisters(coord, lines, arch)...)
+		issues = append(issues, checkMemory(coord, lines, arch)...)
+		issues = append(issues, checkCtrlMem(coord, lines, arch)...)
+		issues = append(issues, checkIssueLimits(coord, lines, arch)...)
+	}
+
+	addSources(issues, programs)
+	sortIssues(issues)
+
+	return issues
+}
+
+// addSources sets the sources of the issues about lines with source
+// metadata.
+func addSources(issues []Issue, programs map[[2]int]string) {
+	for i := range issues {
+		if issues[i].Line < 0 {
+			continue
+		}
+
+		lines := strings.Split(programs[issues[i].PE], "\n")
+		if issues[i].Line < len(lines) {
+			issues[i].Source = core.SourceOf(lines[issues[i].Line])
+		}
+	}
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.PE[1] != b.PE[1] {
+			return a.PE[1] < b.PE[1]
+		}
+
+		if a.PE[0] != b.PE[0] {
+			return a.PE[0] < b.PE[0]
+		}
+
+		return a.Line < b.Line
+	})
+}
+
+func isDisabled(coord [2]int, arch ArchInfo) bool {
+	for _, t := range arch.DisabledTiles {
+		if t == coord {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkCapabilities(
+	coord [2]int,
+	lines []string,
+	arch ArchInfo,
+) []Issue {
+	issues := []Issue{}
+	caps := arch.PECaps[coord]
+
+	for i, line := range lines {
+		op := core.Opcode(line)
+		if op == "" || caps.Supports(op) {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Type:    IssueStruct,
+			PE:      coord,
+			Line:    i,
+			Message: fmt.Sprintf("opcode %s is not supported by the PE", op),
+		})
+	}
+
+	return issues
+}
@@ -0,0 +1,127 @@
+package verify_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("Lint", func() {
+	var arch verify.ArchInfo
+
+	BeforeEach(func() {
+		arch = verify.ArchInfo{
+			Rows:     2,
+			Columns:  2,
+			Topology: "mesh",
+			PECaps: map[[2]int]cgra.PECaps{
+				{1, 0}: cgra.NewPECaps("WAIT", "SEND", "JMP"),
+			},
+		}
+	})
+
+	It("should accept programs that the PEs support", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_3\nI_MUL, $1, $0, 2",
+			{1, 0}: "START:\nWAIT, $0, NET_RECV_3\nJMP, START",
+		}
+
+		Expect(verify.Lint(programs, arch)).To(BeEmpty())
+	})
+
+	It("should report opcodes that the PE does not support", func() {
+		programs := map[[2]int]string{
+			{1, 0}: "WAIT, $0, NET_RECV_3\nI_MUL, $1, $0, 2",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Type).To(Equal(verify.IssueStruct))
+		Expect(issues[0].PE).To(Equal([2]int{1, 0}))
+		Expect(issues[0].Line).To(Equal(1))
+	})
+
+	It("should report where the instructions come from", func() {
+		programs := map[[2]int]string{
+			{1, 0}: "WAIT, $0, NET_RECV_3\nI_MUL, $1, $0, 2 @ src=fir.c:7 node=n3",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Source).To(Equal(core.Source{Loc: "fir.c:7", Node: "n3"}))
+		Expect(issues[0].String()).To(HavePrefix(
+			"[STRUCT] PE(1, 0) line 1 (fir.c:7, node n3): "))
+	})
+
+	It("should check the opcodes that aliases stand for", func() {
+		arch.PECaps[[2]int{1, 0}] = cgra.NewPECaps("GATHER")
+		programs := map[[2]int]string{
+			{1, 0}: "LD, $0, 3\nST, 3, $0",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Line).To(Equal(1))
+		Expect(issues[0].Message).To(ContainSubstring("SCATTER"))
+	})
+
+	It("should report programs mapped to disabled tiles", func() {
+		arch.DisabledTiles = [][2]int{{1, 1}}
+		programs := map[[2]int]string{
+			{1, 1}: "WAIT, $0, NET_RECV_3",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].PE).To(Equal([2]int{1, 1}))
+		Expect(issues[0].Line).To(Equal(-1))
+	})
+	It("should report registers beyond the register file", func() {
+		arch.RegistersPerPE = 4
+		programs := map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_3\nI_ADD, $4, $0, 1\nI_ADD, $7, $4, 1",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Line).To(Equal(1))
+		Expect(issues[0].Message).To(ContainSubstring("$7"))
+	})
+
+	It("should report immediate addresses beyond the local memory", func() {
+		arch.MemCapacity = 16
+		programs := map[[2]int]string{
+			{0, 0}: "LD, $0, 15\nLD, $1, 16\nST, 0x20, $1\nST, $0, $1\n" +
+				"ENQUEUE, $2, 16, $1\nDEQUEUE, $0, $2, 8",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[0].Line).To(Equal(1))
+		Expect(issues[1].Line).To(Equal(2))
+		Expect(issues[2].Line).To(Equal(4))
+	})
+	It("should report programs that overflow the control memory", func() {
+		arch.CtrlMemItems = 2
+		programs := map[[2]int]string{
+			{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nJMP, START",
+			{0, 1}: "WAIT, $0, NET_RECV_3\nI_ADD, $1, $0, 1\n" +
+				"SEND, NET_SEND_1, $1",
+		}
+
+		issues := verify.Lint(programs, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].PE).To(Equal([2]int{0, 1}))
+		Expect(issues[0].Line).To(Equal(-1))
+		Expect(issues[0].Message).To(ContainSubstring("3 instructions"))
+	})
+})
@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CheckRates checks that each channel between two PEs carries the same
+// number of tokens from the producer as the consumer takes in an iteration.
+// As all the PEs start an iteration every II cycles, an unbalanced channel
+// accumulates tokens until the producer stalls, or starves the consumer.
+// Both end up as a hang at run time.
+func CheckRates(programs map[[2]int]string, arch ArchInfo) []Issue {
+	g := BuildDepGraph(programs, arch)
+
+	channels := make(map[Channel]bool)
+	for ch := range g.Produced {
+		channels[ch] = true
+	}
+
+	for ch := range g.Consumed {
+		channels[ch] = true
+	}
+
+	issues := []Issue{}
+
+	for ch := range channels {
+		produced, consumed := g.Produced[ch], g.Consumed[ch]
+
+		switch {
+		case produced > consumed:
+			issues = append(issues, Issue{
+				Type: IssueRate,
+				PE:   ch.From,
+				Line: -1,
+				Message: fmt.Sprintf("channel to PE(%d, %d) produces %d "+
+					"tokens per iteration, but %d are consumed; tokens "+
+					"accumulate", ch.To[0], ch.To[1], produced, consumed),
+			})
+		case produced < consumed:
+			issues = append(issues, Issue{
+				Type: IssueRate,
+				PE:   ch.To,
+				Line: -1,
+				Message: fmt.Sprintf("channel from PE(%d, %d) produces %d "+
+					"tokens per iteration, but %d are consumed; the "+
+					"consumer starves", ch.From[0], ch.From[1],
+					produced, consumed),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Message < issues[j].Message
+	})
+	sortIssues(issues)
+
+	return issues
+}
@@ -0,0 +1,49 @@
+package verify_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("CheckRates", func() {
+	arch := verify.ArchInfo{Rows: 2, Columns: 2, Topology: "mesh"}
+
+	It("should accept balanced channels", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0",
+			{1, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0",
+		}
+
+		Expect(verify.CheckRates(programs, arch)).To(BeEmpty())
+	})
+
+	It("should count the network operands of DATA_MOV", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "DATA_MOV, NET_SEND_1, 5\nWAIT, $0, NET_RECV_1",
+			{1, 0}: "DATA_MOV, NET_SEND_3, NET_RECV_3",
+		}
+
+		Expect(verify.CheckRates(programs, arch)).To(BeEmpty())
+	})
+
+	It("should report unbalanced channels", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n" +
+				"SEND, NET_SEND_1, $0\nSEND, NET_SEND_2, $0",
+			{1, 0}: "WAIT, $0, NET_RECV_3\nWAIT, $1, NET_RECV_2\n" +
+				"SEND, NET_SEND_1, $0",
+		}
+
+		issues := verify.CheckRates(programs, arch)
+
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[0].Type).To(Equal(verify.IssueRate))
+		Expect(issues[0].PE).To(Equal([2]int{0, 0}))
+		Expect(issues[0].Message).To(ContainSubstring("PE(0, 1)"))
+		Expect(issues[1].PE).To(Equal([2]int{0, 0}))
+		Expect(issues[1].Message).To(ContainSubstring("accumulate"))
+		Expect(issues[2].PE).To(Equal([2]int{1, 0}))
+		Expect(issues[2].Message).To(ContainSubstring("starves"))
+	})
+})
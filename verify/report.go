@@ -0,0 +1,192 @@
+package verify
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// PESummary summarizes the program of a PE.
+type PESummary struct {
+	PE           [2]int
+	Instructions int
+	Sends, Waits int
+	Issues       int
+}
+
+// Report collects the results of all the checks of a set of programs.
+type Report struct {
+	Issues []Issue
+	II     IIAnalysis
+	PEs    []PESummary
+
+	graph *DepGraph
+}
+
+// GenerateReport runs Lint, CheckRates, and AnalyzeII on the programs.
+func GenerateReport(programs map[[2]int]string, arch ArchInfo) Report {
+	r := Report{
+		Issues: Lint(programs, arch),
+		II:     AnalyzeII(programs, arch),
+		graph:  BuildDepGraph(programs, arch),
+	}
+
+	r.Issues = append(r.Issues, CheckRates(programs, arch)...)
+	sortIssues(r.Issues)
+
+	issueCounts := make(map[[2]int]int)
+	for _, issue := range r.Issues {
+		issueCounts[issue.PE]++
+	}
+
+	for _, coord := range sortedCoords(programs) {
+		s := PESummary{PE: coord, Issues: issueCounts[coord]}
+		for _, inst := range r.graph.Insts {
+			if inst.PE != coord {
+				continue
+			}
+
+			s.Instructions++
+			switch {
+			case strings.HasPrefix(r.graph.Code[inst], "SEND"):
+				s.Sends++
+			case strings.HasPrefix(r.graph.Code[inst], "WAIT"):
+				s.Waits++
+			}
+		}
+
+		r.PEs = append(r.PEs, s)
+	}
+
+	return r
+}
+
+func (r Report) criticalCycle() []string {
+	lines := make([]string, 0, len(r.II.CriticalCycle))
+	for _, inst := range r.II.CriticalCycle {
+		lines = append(lines, fmt.Sprintf("PE(%d, %d) line %d: %s",
+			inst.PE[0], inst.PE[1], inst.Line, r.graph.Code[inst]))
+	}
+
+	return lines
+}
+
+var textReportTemplate = template.Must(template.New("text").Parse(
+	`Verification report
+II: {{.II.II}} (ResMII: {{.II.ResMII}})
+Critical cycle:
+{{range .CriticalCycle}}  {{.}}
+{{end}}Issues: {{len .Issues}}
+{{range .Issues}}  {{.}}
+{{end}}`))
+
+// WriteText writes the report in plain text.
+func (r Report) WriteText(w io.Writer) error {
+	return textReportTemplate.Execute(w, r.templateData())
+}
+
+var markdownReportTemplate = template.Must(template.New("markdown").Parse(
+	`# Verification Report
+
+## Throughput
+
+| II | ResMII |
+|----|--------|
+| {{.II.II}} | {{.II.ResMII}} |
+
+Critical cycle:
+{{range .CriticalCycle}}
+1. {{.}}{{end}}
+
+## PEs
+
+| PE | Instructions | Sends | Waits | Issues |
+|----|--------------|-------|-------|--------|
+{{range .PEs}}| ({{index .PE 0}}, {{index .PE 1}}) | {{.Instructions}} | ` +
+		`{{.Sends}} | {{.Waits}} | {{.Issues}} |
+{{end}}
+## Issues
+{{if .Issues}}
+| Type | PE | Line | Message |
+|------|----|------|---------|
+{{range .Issues}}| {{.Type}} | ({{index .PE 0}}, {{index .PE 1}}) | ` +
+		`{{if lt .Line 0}}-{{else}}{{.Line}}{{end}} | {{.Message}} |
+{{end}}{{else}}
+No issues found.
+{{end}}`))
+
+// WriteMarkdown writes the report in Markdown, with tables of the PEs and
+// the issues.
+func (r Report) WriteMarkdown(w io.Writer) error {
+	return markdownReportTemplate.Execute(w, r.templateData())
+}
+
+var htmlReportTemplate = htmltemplate.Must(htmltemplate.Must(
+	htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Verification Report</title>
+` + timelineStyle + `
+</head>
+<body>
+<h1>Verification Report</h1>
+<h2>Throughput</h2>
+<p>II: {{.II.II}} (ResMII: {{.II.ResMII}})</p>
+<ol>{{range .CriticalCycle}}<li>{{.}}</li>{{end}}</ol>
+<h2>PEs</h2>
+<table>
+<tr><th>PE</th><th>Instructions</th><th>Sends</th><th>Waits</th><th>Issues</th></tr>
+{{range .PEs}}<tr><td>({{index .PE 0}}, {{index .PE 1}})</td>` +
+		`<td>{{.Instructions}}</td><td>{{.Sends}}</td><td>{{.Waits}}</td>` +
+		`<td>{{.Issues}}</td></tr>
+{{end}}</table>
+<h2>Issues</h2>
+{{if .Issues}}<table>
+<tr><th>Type</th><th>PE</th><th>Line</th><th>Message</th></tr>
+{{range .Issues}}<tr><td>{{.Type}}</td>` +
+		`<td>({{index .PE 0}}, {{index .PE 1}})</td>` +
+		`<td>{{if lt .Line 0}}-{{else}}{{.Line}}{{end}}</td>` +
+		`<td>{{.Message}}</td></tr>
+{{end}}</table>{{else}}<p>No issues found.</p>{{end}}
+<h2>Schedule</h2>
+{{if .Timeline}}{{template "timeline" .Timeline}}` +
+		`{{else}}<p>{{.ScheduleError}}</p>{{end}}
+<h2>Dependency Graph</h2>
+<pre>{{.DOT}}</pre>
+</body>
+</html>
+`)).Parse(timelineTable))
+
+// WriteHTML writes the report as an HTML page. Besides the tables in the
+// Markdown report, the page includes the timeline of the schedule and the
+// dependency graph in the DOT format.
+func (r Report) WriteHTML(w io.Writer) error {
+	return htmlReportTemplate.Execute(w, r.templateData())
+}
+
+type reportData struct {
+	Report
+	CriticalCycle []string
+	Timeline      *timeline
+	ScheduleError string
+	DOT           string
+}
+
+func (r Report) templateData() reportData {
+	d := reportData{Report: r, CriticalCycle: r.criticalCycle()}
+
+	t, err := r.graph.timeline()
+	if err != nil {
+		d.ScheduleError = err.Error()
+	} else {
+		d.Timeline = &t
+	}
+
+	b := &strings.Builder{}
+	_ = r.graph.WriteDOT(b)
+	d.DOT = b.String()
+
+	return d
+}
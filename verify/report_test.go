@@ -0,0 +1,58 @@
+package verify_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("Report", func() {
+	var report verify.Report
+
+	BeforeEach(func() {
+		arch := verify.ArchInfo{Rows: 1, Columns: 2, Topology: "mesh"}
+		report = verify.GenerateReport(map[[2]int]string{
+			{0, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0",
+			{1, 0}: "WAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\n" +
+				"SEND, NET_SEND_3, $0",
+		}, arch)
+	})
+
+	It("should summarize the PEs", func() {
+		Expect(report.II.II).To(Equal(3))
+		Expect(report.PEs).To(Equal([]verify.PESummary{
+			{PE: [2]int{0, 0}, Instructions: 2, Sends: 1, Waits: 1},
+			{PE: [2]int{1, 0}, Instructions: 3, Sends: 2, Waits: 1, Issues: 1},
+		}))
+	})
+
+	It("should write Markdown", func() {
+		b := &strings.Builder{}
+
+		Expect(report.WriteMarkdown(b)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring("| (1, 0) | 3 | 2 | 1 | 1 |"))
+		Expect(b.String()).To(ContainSubstring("| RATE | (1, 0) | - |"))
+	})
+
+	It("should write HTML", func() {
+		b := &strings.Builder{}
+
+		Expect(report.WriteHTML(b)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring("<td>RATE</td>"))
+		Expect(b.String()).To(ContainSubstring(`<td class="busy">`))
+		Expect(b.String()).To(ContainSubstring("digraph deps"))
+	})
+
+	It("should write plain text", func() {
+		b := &strings.Builder{}
+
+		Expect(report.WriteText(b)).To(Succeed())
+
+		Expect(b.String()).To(ContainSubstring("II: 3 (ResMII: 3)"))
+		Expect(b.String()).To(ContainSubstring("[RATE] PE(1, 0)"))
+	})
+})
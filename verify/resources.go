@@ -0,0 +1,144 @@
+package verify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sarchlab/zeonica/core"
+)
+
+// checkRegisters reports the first line that uses a register beyond the
+// register file of the PE, together with the highest register index that
+// the program uses.
+func checkRegisters(coord [2]int, lines []string, arch ArchInfo) []Issue {
+	if arch.RegistersPerPE <= 0 {
+		return nil
+	}
+
+	firstLine, maxReg := -1, -1
+
+	for i, line := range lines {
+		for _, operand := range operandsOf(line) {
+			if !strings.HasPrefix(operand, "$") {
+				continue
+			}
+
+			reg, err := strconv.Atoi(strings.TrimPrefix(operand, "$"))
+			if err != nil || reg < arch.RegistersPerPE {
+				continue
+			}
+
+			if firstLine < 0 {
+				firstLine = i
+			}
+
+			if reg > maxReg {
+				maxReg = reg
+			}
+		}
+	}
+
+	if firstLine < 0 {
+		return nil
+	}
+
+	return []Issue{{
+		Type: IssueStruct,
+		PE:   coord,
+		Line: firstLine,
+		Message: fmt.Sprintf("program uses up to register $%d, "+
+			"but the PE has %d registers", maxReg, arch.RegistersPerPE),
+	}}
+}
+
+// memAddrOperand is the index of the address operand of the memory
+// instructions, counting the opcode as 0.
+var memAddrOperand = map[string]int{
+	"GATHER":      2,
+	"SCATTER":     1,
+	"SCATTER_ADD": 1,
+	"ENQUEUE":     2,
+	"DEQUEUE":     3,
+	"MEMCPY_TILE": 3,
+}
+
+// checkMemory reports the memory instructions whose immediate addresses are
+// beyond the local memory of the PE. Addresses in registers are only known
+// at run time and are not checked.
+func checkMemory(coord [2]int, lines []string, arch ArchInfo) []Issue {
+	if arch.MemCapacity <= 0 {
+		return nil
+	}
+
+	issues := []Issue{}
+
+	for i, line := range lines {
+		index, ok := memAddrOperand[core.Opcode(line)]
+		if !ok {
+			continue
+		}
+
+		operands := operandsOf(line)
+		if index-1 >= len(operands) {
+			continue
+		}
+
+		addr, err := strconv.ParseInt(operands[index-1], 0, 64)
+		if err != nil || (addr >= 0 && addr < int64(arch.MemCapacity)) {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Type: IssueStruct,
+			PE:   coord,
+			Line: i,
+			Message: fmt.Sprintf("address %d is outside of the %d-word "+
+				"local memory", addr, arch.MemCapacity),
+		})
+	}
+
+	return issues
+}
+
+// operandsOf returns the operands of an instruction, or nil if the line is
+// not an instruction.
+func operandsOf(line string) []string {
+	if core.Opcode(line) == "" {
+		return nil
+	}
+
+	tokens := strings.Split(core.StripSource(line), ",")[1:]
+	for i := range tokens {
+		tokens[i] = strings.TrimSpace(tokens[i])
+	}
+
+	return tokens
+}
+
+// checkCtrlMem reports programs that have more instructions than the control
+// memory of the PE can hold. Labels do not take control memory entries.
+func checkCtrlMem(coord [2]int, lines []string, arch ArchInfo) []Issue {
+	if arch.CtrlMemItems <= 0 {
+		return nil
+	}
+
+	count := 0
+	for _, line := range lines {
+		if core.Opcode(line) != "" {
+			count++
+		}
+	}
+
+	if count <= arch.CtrlMemItems {
+		return nil
+	}
+
+	return []Issue{{
+		Type: IssueStruct,
+		PE:   coord,
+		Line: -1,
+		Message: fmt.Sprintf("program has %d instructions, but the control "+
+			"memory holds %d", count, arch.CtrlMemItems),
+	}}
+}
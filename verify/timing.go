@@ -0,0 +1,78 @@
+package verify
+
+import "fmt"
+
+// Schedule is the static schedule of a PE, as in Driver.SetSchedule. Steps
+// has the time step of each line of the program, including the labels, and
+// II is the number of cycles between two iterations.
+type Schedule struct {
+	Steps []int
+	II    int
+}
+
+// CheckTiming checks that the schedules run each instruction after the
+// instructions that it depends on, including the hop latency of the
+// channels between the PEs. The PEs that communicate are expected to share
+// the same II. The dependencies of the PEs without schedules are not
+// checked.
+func CheckTiming(
+	programs map[[2]int]string,
+	schedules map[[2]int]Schedule,
+	arch ArchInfo,
+) []Issue {
+	issues := []Issue{}
+	g := BuildDepGraph(programs, arch)
+
+	for _, d := range g.Deps {
+		ready, ok := ReadyStep(d, schedules)
+		if !ok {
+			continue
+		}
+
+		step := schedules[d.To.PE].Steps[d.To.Line]
+		if step >= ready {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Type: IssueTiming,
+			PE:   d.To.PE,
+			Line: d.To.Line,
+			Message: fmt.Sprintf("scheduled at step %d, but its input "+
+				"from PE(%d, %d) line %d%s is ready at step %d", step,
+				d.From.PE[0], d.From.PE[1], d.From.Line,
+				iterationNote(d.Distance), ready),
+		})
+	}
+
+	addSources(issues, programs)
+	sortIssues(issues)
+
+	return issues
+}
+
+// ReadyStep returns the earliest step of the To instruction of the
+// dependency under the schedules, counted in the iteration of the To
+// instruction. It returns false if either instruction has no step.
+func ReadyStep(d Dependency, schedules map[[2]int]Schedule) (int, bool) {
+	from, okFrom := schedules[d.From.PE]
+	to, okTo := schedules[d.To.PE]
+
+	if !okFrom || !okTo ||
+		d.From.Line >= len(from.Steps) || d.To.Line >= len(to.Steps) {
+		return 0, false
+	}
+
+	return from.Steps[d.From.Line] + d.Latency - d.Distance*to.II, true
+}
+
+func iterationNote(distance int) string {
+	switch distance {
+	case 0:
+		return ""
+	case 1:
+		return " of the previous iteration"
+	default:
+		return fmt.Sprintf(" of %d iterations before", distance)
+	}
+}
@@ -0,0 +1,85 @@
+package verify_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/verify"
+)
+
+var _ = Describe("CheckTiming", func() {
+	arch := verify.ArchInfo{Rows: 1, Columns: 2, Topology: "mesh"}
+	programs := map[[2]int]string{
+		{0, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+		{1, 0}: "START:\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0\nJMP, START",
+	}
+
+	It("should accept schedules that meet the hop latency", func() {
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1, 2}, II: 3},
+			{1, 0}: {Steps: []int{0, 2, 3, 4}, II: 3},
+		}
+
+		Expect(verify.CheckTiming(programs, schedules, arch)).To(BeEmpty())
+	})
+
+	It("should report instructions that run before their data", func() {
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1, 2}, II: 3},
+			{1, 0}: {Steps: []int{0, 1, 2, 3}, II: 3},
+		}
+
+		issues := verify.CheckTiming(programs, schedules, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].String()).To(Equal("[TIMING] PE(1, 0) line 1: " +
+			"scheduled at step 1, but its input from PE(0, 0) line 2 " +
+			"is ready at step 2"))
+	})
+
+	It("should report iterations that start too early", func() {
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 1, 2}, II: 2},
+		}
+
+		issues := verify.CheckTiming(programs, schedules, arch)
+
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Message).To(Equal("scheduled at step 0, but its " +
+			"input from PE(0, 0) line 3 of the previous iteration is ready " +
+			"at step 1"))
+	})
+
+	Describe("with a wide PE", func() {
+		programs := map[[2]int]string{
+			{0, 0}: "I_ADD, $0, 0, 1\nI_ADD, $1, 0, 2\nI_ADD, $2, $0, $1",
+		}
+		schedules := map[[2]int]verify.Schedule{
+			{0, 0}: {Steps: []int{0, 0, 0}, II: 1},
+		}
+
+		It("should let an instruction read a result through the bypass",
+			func() {
+				arch := verify.ArchInfo{Rows: 1, Columns: 1, Topology: "mesh",
+					IssueLimits: core.IssueLimits{Width: 3, Bypass: true}}
+
+				Expect(verify.CheckTiming(programs, schedules, arch)).
+					To(BeEmpty())
+				Expect(verify.AnalyzeII(programs, arch).II).To(Equal(1))
+			})
+
+		It("should report a result that is read in the same step without "+
+			"the bypass", func() {
+			arch := verify.ArchInfo{Rows: 1, Columns: 1, Topology: "mesh",
+				IssueLimits: core.IssueLimits{Width: 3}}
+
+			issues := verify.CheckTiming(programs, schedules, arch)
+
+			Expect(issues).To(HaveLen(2))
+			Expect(issues[0].String()).To(Equal("[TIMING] PE(0, 0) line 2: " +
+				"scheduled at step 0, but its input from PE(0, 0) line 0 " +
+				"is ready at step 1"))
+			Expect(issues[1].Message).To(ContainSubstring("line 1"))
+		})
+	})
+})
@@ -0,0 +1,377 @@
+// Package zeonica is the stable API for embedding the simulator in other
+// tools, e.g., the CI of a compiler or a design space exploration. It only
+// exposes plain Go types, so that the tools do not depend on the api,
+// config, and core packages, which change with the simulator.
+//
+// A simulation loads a kernel, feeds data in, and collects data out:
+//
+//	sim, err := zeonica.NewSimulator(zeonica.Config{Width: 4, Height: 4})
+//	err = sim.LoadKernel("kernel.yaml", map[string]uint32{"N": 8})
+//	sim.PreloadMemory(0, 0, 0, []uint32{5, 6})
+//	sim.FeedIn("west", 0, []uint32{1, 2, 3})
+//	sim.Collect("east", 0, 3)
+//	err = sim.Run()
+//	fmt.Println(sim.Results().Outputs[0], sim.Results().Cycles)
+package zeonica
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/sarchlab/akita/v3/sim"
+	"github.com/sarchlab/zeonica/api"
+	"github.com/sarchlab/zeonica/cgra"
+	"github.com/sarchlab/zeonica/config"
+	"github.com/sarchlab/zeonica/core"
+	"github.com/sarchlab/zeonica/trace"
+)
+
+// Config is the device that a Simulator simulates.
+type Config struct {
+	// Arch is the path of an arch_spec.yaml file. If it is empty, the device
+	// is a mesh of Width x Height PEs.
+	Arch          string
+	Width, Height int
+
+	// Seed seeds the arbitration of the driver, 0 by default.
+	Seed int64
+
+	// Trace, if not nil, receives the trace of the run in the text format.
+	Trace io.Writer
+
+	// FS, if not nil, is the file system that the Arch and the kernels are
+	// read from, e.g., in a browser, which has no files.
+	FS fs.FS
+}
+
+// Results are the results of a run.
+type Results struct {
+	// Cycles is the number of simulated cycles.
+	Cycles uint64
+
+	// Outputs has the data of each Collect, in the order of the calls.
+	Outputs [][]uint32
+
+	// ReturnValues are the values of the RETURN_VALUE instructions, keyed
+	// by the [x, y] coordinate of the PE.
+	ReturnValues map[[2]int]uint32
+
+	// Ops is the number of instructions that each PE has executed, keyed
+	// by the [x, y] coordinate of the PE.
+	Ops map[[2]int]uint64
+
+	// Errors describe the instructions that have halted PEs, e.g., an
+	// address out of the local memory.
+	Errors []string
+}
+
+// A Simulator runs one kernel on one device. It is not safe for concurrent
+// use.
+type Simulator struct {
+	engine sim.Engine
+	freq   sim.Freq
+	driver api.Driver
+	device cgra.Device
+
+	fsys     fs.FS
+	loaded   bool
+	ran      bool
+	outputs  [][]uint32
+	finished []bool
+	results  Results
+}
+
+// NewSimulator builds a simulator of the device of the config.
+func NewSimulator(c Config) (*Simulator, error) {
+	builder := config.DeviceBuilder{}.WithWidth(c.Width).WithHeight(c.Height)
+	if c.Arch != "" {
+		var err error
+
+		if c.FS != nil {
+			builder, _, err = config.LoadArchSpecFS(c.FS, c.Arch)
+		} else {
+			builder, _, err = config.LoadArchSpec(c.Arch)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	} else if c.Width <= 0 || c.Height <= 0 {
+		return nil, fmt.Errorf("invalid device size %dx%d", c.Width, c.Height)
+	}
+
+	var tracer trace.Tracer = trace.Discard
+	if c.Trace != nil {
+		tracer = trace.NewTextWriter(c.Trace)
+	}
+
+	s := &Simulator{
+		engine: sim.NewSerialEngine(),
+		freq:   1 * sim.GHz,
+		fsys:   c.FS,
+	}
+	s.driver = api.DriverBuilder{}.
+		WithEngine(s.engine).
+		WithFreq(s.freq).
+		WithSeed(c.Seed).
+		Build("Driver")
+	s.device = builder.
+		WithEngine(s.engine).
+		WithFreq(s.freq).
+		WithTracer(tracer).
+		Build("Device")
+	s.driver.RegisterDevice(s.device)
+
+	return s, nil
+}
+
+// LoadKernel loads a program file, in the YAML format if it has a .yaml or
+// .yml extension, or in the ASM format otherwise, and maps its programs.
+// The constants override the named constants of the file. It checks all
+// the programs before it maps any of them.
+func (s *Simulator) LoadKernel(path string, constants map[string]uint32) error {
+	if s.loaded {
+		return errors.New("a kernel is already loaded")
+	}
+
+	var (
+		file *core.ProgramFile
+		err  error
+	)
+
+	if s.fsys != nil {
+		file, err = core.LoadProgramFileFS(s.fsys, path)
+	} else {
+		file, err = core.LoadProgramFile(path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for name, v := range file.Constants {
+		s.driver.SetProgramConstant(name, v)
+	}
+
+	for name, v := range constants {
+		s.driver.SetProgramConstant(name, v)
+	}
+
+	coords := make([][2]int, 0, len(file.Programs))
+	for coord := range file.Programs {
+		coords = append(coords, coord)
+	}
+
+	sort.Slice(coords, func(i, j int) bool {
+		if coords[i][1] != coords[j][1] {
+			return coords[i][1] < coords[j][1]
+		}
+
+		return coords[i][0] < coords[j][0]
+	})
+
+	width, height := s.device.GetSize()
+	for _, c := range coords {
+		if c[0] >= width || c[1] >= height {
+			return fmt.Errorf("PE(%d, %d) is outside the %dx%d device",
+				c[0], c[1], width, height)
+		}
+
+		err = s.driver.CheckProgram(file.Programs[c], c)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range coords {
+		s.driver.MapProgram(file.Programs[c], c)
+	}
+
+	s.loaded = true
+
+	return nil
+}
+
+// MapProgram checks a program and maps it to the PE at (x, y), like a PE of
+// a kernel file. It is an alternative to LoadKernel, for the tools that
+// generate the programs.
+func (s *Simulator) MapProgram(x, y int, program string) error {
+	if s.ran {
+		return errors.New("the simulator has already run")
+	}
+
+	err := s.checkPE(x, y)
+	if err != nil {
+		return err
+	}
+
+	err = s.driver.CheckProgram(program, [2]int{x, y})
+	if err != nil {
+		return err
+	}
+
+	s.driver.MapProgram(program, [2]int{x, y})
+	s.loaded = true
+
+	return nil
+}
+
+// PreloadMemory writes the data to the local memory of the PE at (x, y),
+// starting from the address in words, before the run.
+func (s *Simulator) PreloadMemory(x, y, addr int, data []uint32) error {
+	if s.ran {
+		return errors.New("the simulator has already run")
+	}
+
+	err := s.checkPE(x, y)
+	if err != nil {
+		return err
+	}
+
+	return catch(func() {
+		s.driver.WriteMemory([2]int{x, y}, addr, data)
+	})
+}
+
+// ReadMemory returns length words of the local memory of the PE at (x, y),
+// starting from the address, e.g., after the run.
+func (s *Simulator) ReadMemory(x, y, addr, length int) ([]uint32, error) {
+	err := s.checkPE(x, y)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []uint32
+
+	err = catch(func() {
+		data = s.driver.ReadMemory([2]int{x, y}, addr, length)
+	})
+
+	return data, err
+}
+
+func (s *Simulator) checkPE(x, y int) error {
+	width, height := s.device.GetSize()
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return fmt.Errorf("PE(%d, %d) is outside the %dx%d device",
+			x, y, width, height)
+	}
+
+	if s.device.GetTile(x, y) == nil {
+		return fmt.Errorf("PE(%d, %d) is disabled", x, y)
+	}
+
+	return nil
+}
+
+// catch turns the panic of a call into an error, e.g., for an address out
+// of the local memory.
+func catch(f func()) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+
+	f()
+
+	return nil
+}
+
+// FeedIn sends the data into a port of a side of the device, one value per
+// cycle. The side is north, east, south, or west.
+func (s *Simulator) FeedIn(side string, port int, data []uint32) error {
+	sd, err := parseSide(side)
+	if err != nil {
+		return err
+	}
+
+	s.driver.FeedIn(data, sd, [2]int{port, port + 1}, 1)
+
+	return nil
+}
+
+// Collect receives n values from a port of a side of the device into the
+// next element of the Outputs of the results.
+func (s *Simulator) Collect(side string, port int, n int) error {
+	sd, err := parseSide(side)
+	if err != nil {
+		return err
+	}
+
+	i := len(s.outputs)
+	data := make([]uint32, n)
+	s.outputs = append(s.outputs, data)
+	s.finished = append(s.finished, false)
+	s.driver.Chain(api.CollectTask{
+		Data:      data,
+		Side:      sd,
+		PortRange: [2]int{port, port + 1},
+		Stride:    1,
+	}, func([]uint32) (*api.FeedInTask, bool) {
+		s.finished[i] = true
+		return nil, false
+	})
+
+	return nil
+}
+
+// Run simulates until the device has nothing left to do. It returns an
+// error if the simulation stops before all the data is collected, which
+// means that the kernel deadlocks. The results are available in either
+// case.
+func (s *Simulator) Run() error {
+	if !s.loaded {
+		return errors.New("no kernel is loaded")
+	}
+
+	if s.ran {
+		return errors.New("the simulator has already run")
+	}
+
+	s.ran = true
+	s.driver.Run()
+
+	s.results = Results{
+		Cycles:       s.freq.Cycle(s.engine.CurrentTime()),
+		Outputs:      s.outputs,
+		ReturnValues: s.driver.GetReturnValues(),
+		Ops:          make(map[[2]int]uint64),
+	}
+
+	for c, counters := range s.driver.GetCounters() {
+		s.results.Ops[c] = counters.Ops
+	}
+
+	for _, e := range s.driver.GetErrors() {
+		s.results.Errors = append(s.results.Errors, e.Error())
+	}
+
+	for i, done := range s.finished {
+		if !done {
+			return fmt.Errorf(
+				"the simulation stopped before output %d was collected", i)
+		}
+	}
+
+	return nil
+}
+
+// Results returns the results of the run, or the zero Results before Run.
+func (s *Simulator) Results() Results {
+	return s.results
+}
+
+func parseSide(name string) (cgra.Side, error) {
+	for side := cgra.North; side <= cgra.West; side++ {
+		if strings.EqualFold(name, side.Name()) {
+			return side, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid side %q", name)
+}
@@ -0,0 +1,13 @@
+package zeonica_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestZeonica(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zeonica Suite")
+}
@@ -0,0 +1,93 @@
+package zeonica_test
+
+import (
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sarchlab/zeonica"
+)
+
+var _ = Describe("Simulator", func() {
+	It("should run a kernel", func() {
+		s, err := zeonica.NewSimulator(zeonica.Config{Width: 1, Height: 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.LoadKernel("kernels/programs/axpy.yaml",
+			map[string]uint32{"A": 3})).To(Succeed())
+		Expect(s.FeedIn("west", 0, []uint32{1, 10, 2, 20})).To(Succeed())
+		Expect(s.Collect("east", 0, 2)).To(Succeed())
+		Expect(s.Run()).To(Succeed())
+
+		r := s.Results()
+		Expect(r.Outputs).To(Equal([][]uint32{{13, 26}}))
+		Expect(r.Cycles).To(BeNumerically(">", 0))
+		Expect(r.Errors).To(BeEmpty())
+		Expect(s.Run()).To(MatchError("the simulator has already run"))
+	})
+
+	It("should report the data that is not collected", func() {
+		s, err := zeonica.NewSimulator(zeonica.Config{Width: 1, Height: 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.LoadKernel("kernels/programs/passthrough.yaml", nil)).
+			To(Succeed())
+		Expect(s.FeedIn("west", 0, []uint32{1, 2})).To(Succeed())
+		Expect(s.Collect("east", 0, 3)).To(Succeed())
+
+		Expect(s.Run()).To(MatchError(
+			"the simulation stopped before output 0 was collected"))
+		Expect(s.Results().Outputs).To(Equal([][]uint32{{1, 2, 0}}))
+	})
+
+	It("should map programs and access the local memories", func() {
+		s, err := zeonica.NewSimulator(zeonica.Config{Width: 1, Height: 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.MapProgram(0, 0,
+			"WAIT, $0, NET_RECV_3\nGATHER, $1, $0\nSCATTER, 8, $1\nDONE")).
+			To(Succeed())
+		Expect(s.PreloadMemory(0, 0, 0, []uint32{5, 6, 7})).To(Succeed())
+		Expect(s.FeedIn("west", 0, []uint32{2})).To(Succeed())
+		Expect(s.Run()).To(Succeed())
+
+		Expect(s.ReadMemory(0, 0, 8, 1)).To(Equal([]uint32{7}))
+		_, err = s.ReadMemory(1, 0, 0, 1)
+		Expect(err).To(MatchError("PE(1, 0) is outside the 1x1 device"))
+		_, err = s.ReadMemory(0, 0, -1, 1)
+		Expect(err).To(MatchError(ContainSubstring(
+			"words [-1, 0) are out of the local memory")))
+		Expect(s.MapProgram(0, 0, "DONE")).To(MatchError(
+			"the simulator has already run"))
+	})
+
+	It("should read the kernel from a file system", func() {
+		fsys := fstest.MapFS{"k.asm": &fstest.MapFile{
+			Data: []byte("PE(0, 0):\nWAIT, $0, NET_RECV_3\nSEND, NET_SEND_1, $0"),
+		}}
+		s, err := zeonica.NewSimulator(
+			zeonica.Config{Width: 1, Height: 1, FS: fsys})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.LoadKernel("k.asm", nil)).To(Succeed())
+		Expect(s.FeedIn("west", 0, []uint32{4})).To(Succeed())
+		Expect(s.Collect("east", 0, 1)).To(Succeed())
+		Expect(s.Run()).To(Succeed())
+
+		Expect(s.Results().Outputs).To(Equal([][]uint32{{4}}))
+		Expect(s.Results().Ops).To(Equal(map[[2]int]uint64{{0, 0}: 2}))
+	})
+
+	It("should reject a kernel that does not fit", func() {
+		s, err := zeonica.NewSimulator(zeonica.Config{Width: 1, Height: 1})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.LoadKernel("kernels/programs/fir.yaml", nil)).To(MatchError(
+			"PE(1, 0) is outside the 1x1 device"))
+		Expect(s.Collect("up", 0, 1)).To(MatchError("invalid side \"up\""))
+		Expect(s.Run()).To(MatchError("no kernel is loaded"))
+
+		_, err = zeonica.NewSimulator(zeonica.Config{})
+		Expect(err).To(MatchError("invalid device size 0x0"))
+	})
+})